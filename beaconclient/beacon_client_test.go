@@ -1,16 +1,23 @@
 package beaconclient
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	eth2Api "github.com/attestantio/go-eth2-client/api"
+	eth2ApiV1Deneb "github.com/attestantio/go-eth2-client/api/v1/deneb"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	uberatomic "go.uber.org/atomic"
 )
 
 const testPubKey = "0x93247f2209abcacf57b75a51dafae777f9dd38bc7053d1af526f220a7489a6d3a2753e5f3e8b1cfe39b56f43611df74a"
@@ -204,6 +211,137 @@ func TestFetchValidators(t *testing.T) {
 	})
 }
 
+func TestStreamStateValidators(t *testing.T) {
+	t.Run("decodes all entries and skips other fields", func(t *testing.T) {
+		resp := `{"execution_optimistic":false,"data":[{"index":"1","validator":{"pubkey":"0x01"}},{"index":"2","validator":{"pubkey":"0x02"}}],"finalized":true}`
+		pubkeys := make(map[uint64]string)
+		numValidators, err := decodeStateValidators(strings.NewReader(resp), func(entry ValidatorResponseEntry) {
+			pubkeys[entry.Index] = entry.Validator.Pubkey
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), numValidators)
+		require.Equal(t, map[uint64]string{1: "0x01", 2: "0x02"}, pubkeys)
+	})
+
+	t.Run("returns err on truncated response", func(t *testing.T) {
+		resp := `{"data":[{"index":"1","validator":{"pubkey":"0x01"}},{"index":"2","valid`
+		numValidators, err := decodeStateValidators(strings.NewReader(resp), func(entry ValidatorResponseEntry) {})
+		require.Error(t, err)
+		require.Equal(t, uint64(1), numValidators)
+	})
+
+	t.Run("streams from beacon node that did not err", func(t *testing.T) {
+		backend := newTestBackend(t, 2)
+		backend.beaconInstances[0].MockFetchValidatorsErr = errTest
+		backend.beaconInstances[1].AddValidator(ValidatorResponseEntry{Validator: ValidatorResponseValidatorData{Pubkey: testPubKey}})
+
+		pubkeys := []string{}
+		numValidators, err := backend.beaconClient.StreamStateValidators("1", nil, func(entry ValidatorResponseEntry) {
+			pubkeys = append(pubkeys, entry.Validator.Pubkey)
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), numValidators)
+		require.Equal(t, []string{testPubKey}, pubkeys)
+	})
+}
+
+func TestPublishBlock(t *testing.T) {
+	block := &common.VersionedSignedProposal{
+		VersionedSignedProposal: eth2Api.VersionedSignedProposal{
+			Version: spec.DataVersionDeneb,
+			Deneb: &eth2ApiV1Deneb.SignedBlockContents{
+				SignedBlock: &deneb.SignedBeaconBlock{
+					Message: &deneb.BeaconBlock{
+						Slot: 1,
+						Body: &deneb.BeaconBlockBody{
+							ExecutionPayload: &deneb.ExecutionPayload{},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("returns after the first beacon node accepted the block", func(t *testing.T) {
+		backend := newTestBackend(t, 3)
+		backend.beaconInstances[0].ResponseDelay = 5 * time.Second
+		backend.beaconInstances[1].MockPublishBlockErr = errTest
+
+		timeStart := time.Now()
+		code, err := backend.beaconClient.PublishBlock(block)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		require.Less(t, time.Since(timeStart), time.Second)
+	})
+
+	t.Run("reports blocks which were broadcast but failed validation", func(t *testing.T) {
+		backend := newTestBackend(t, 2)
+		backend.beaconInstances[0].MockPublishBlockCode = http.StatusAccepted
+		backend.beaconInstances[1].MockPublishBlockErr = errTest
+		backend.beaconInstances[1].MockPublishBlockCode = http.StatusBadRequest
+
+		code, err := backend.beaconClient.PublishBlock(block)
+		require.ErrorIs(t, err, ErrBeaconBlock202)
+		require.Equal(t, http.StatusAccepted, code)
+	})
+
+	t.Run("fails if no beacon node accepted the block", func(t *testing.T) {
+		backend := newTestBackend(t, 2)
+		backend.beaconInstances[0].MockPublishBlockErr = errTest
+		backend.beaconInstances[0].MockPublishBlockCode = http.StatusBadRequest
+		backend.beaconInstances[1].MockPublishBlockErr = errTest
+		backend.beaconInstances[1].MockPublishBlockCode = http.StatusBadRequest
+
+		code, err := backend.beaconClient.PublishBlock(block)
+		require.ErrorIs(t, err, errTest)
+		require.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("falls back to JSON if the beacon node does not support SSZ", func(t *testing.T) {
+		contentTypes := []string{}
+		r := mux.NewRouter()
+		r.HandleFunc("/eth/v2/beacon/blocks", func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, string(Consensus), r.URL.Query().Get("broadcast_validation"))
+			contentType := r.Header.Get("Content-Type")
+			contentTypes = append(contentTypes, contentType)
+			if contentType == "application/octet-stream" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				_, err := w.Write([]byte(`{"code":415,"message":"unsupported content type"}`))
+				assert.NoError(t, err)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		srv := httptest.NewServer(r)
+		defer srv.Close()
+
+		block := new(common.VersionedSignedProposal)
+		err := json.Unmarshal(common.LoadGzippedBytes(t, "../testdata/signedBeaconBlockContentsDeneb_Goerli.json.gz"), block)
+		require.NoError(t, err)
+
+		bc := NewProdBeaconInstance(common.TestLog, srv.URL, srv.URL)
+		bc.ffUseSSZEncodingPublishBlock = true
+
+		code, err := bc.PublishBlock(block, Consensus)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, []string{"application/octet-stream", "application/json"}, contentTypes)
+
+		// subsequent blocks are published as JSON right away
+		code, err = bc.PublishBlock(block, Consensus)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, []string{"application/octet-stream", "application/json", "application/json"}, contentTypes)
+	})
+
+	t.Run("publishes SSZ encoded blocks to the v2 endpoint with broadcast validation", func(t *testing.T) {
+		bc := NewProdBeaconInstance(common.TestLog, "http://localhost:5052", "http://localhost:5052")
+		bc.ffUseV1PublishBlockEndpoint = true
+		require.Equal(t, "http://localhost:5052/eth/v1/beacon/blocks", bc.publishBlockURI(ConsensusAndEquivocation, false))
+		require.Equal(t, "http://localhost:5052/eth/v2/beacon/blocks?broadcast_validation=consensus_and_equivocation", bc.publishBlockURI(ConsensusAndEquivocation, true))
+	})
+}
+
 func TestGetForkSchedule(t *testing.T) {
 	r := mux.NewRouter()
 	srv := httptest.NewServer(r)
@@ -242,3 +380,144 @@ func TestGetForkSchedule(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, forkSchedule.Data, 4)
 }
+
+func TestNewEthNetworkDetailsFromBeacon(t *testing.T) {
+	r := mux.NewRouter()
+	srv := httptest.NewServer(r)
+	bc := NewMultiBeaconClient(common.TestLog, []IBeaconInstance{NewProdBeaconInstance(common.TestLog, srv.URL, srv.URL)})
+
+	r.HandleFunc("/eth/v1/beacon/genesis", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`{"data": {"genesis_time": "1742213400", "genesis_validators_root": "` + common.GenesisValidatorsRootHoodi + `", "genesis_fork_version": "` + common.GenesisForkVersionHoodi + `"}}`))
+		assert.NoError(t, err)
+	})
+	r.HandleFunc("/eth/v1/config/spec", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`{"data": {
+			"CONFIG_NAME": "hoodi",
+			"SECONDS_PER_SLOT": "12",
+			"GENESIS_FORK_VERSION": "` + common.GenesisForkVersionHoodi + `",
+			"BELLATRIX_FORK_VERSION": "` + common.BellatrixForkVersionHoodi + `",
+			"CAPELLA_FORK_VERSION": "` + common.CapellaForkVersionHoodi + `",
+			"DENEB_FORK_VERSION": "` + common.DenebForkVersionHoodi + `",
+			"ELECTRA_FORK_VERSION": "` + common.ElectraForkVersionHoodi + `"
+		}}`))
+		assert.NoError(t, err)
+	})
+
+	networkInfo, err := NewEthNetworkDetails(common.EthNetworkBeacon, bc)
+	require.NoError(t, err)
+	hoodi, err := common.NewEthNetworkDetails(common.EthNetworkHoodi)
+	require.NoError(t, err)
+	require.Equal(t, hoodi, networkInfo)
+
+	// named networks don't query the beacon node
+	sepolia, err := NewEthNetworkDetails(common.EthNetworkSepolia, nil)
+	require.NoError(t, err)
+	require.Equal(t, common.EthNetworkSepolia, sepolia.Name)
+}
+
+func TestGetBlock(t *testing.T) {
+	blockRoot := "0x56b683afa68170c775f3c9debc18a6a72caea9055584d037333a6fe43c8ceb83"
+	blockHash := "0x9a2fefd2fdb57f74993c7780ea5b9030d2897b615b89f808011ca5aebed54eaf"
+
+	r := mux.NewRouter()
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+	bc := NewProdBeaconInstance(common.TestLog, srv.URL, srv.URL)
+
+	r.HandleFunc("/eth/v1/beacon/blinded_blocks/{block_id}", func(w http.ResponseWriter, r *http.Request) {
+		blockID := mux.Vars(r)["block_id"]
+		if blockID != "10" && blockID != blockRoot {
+			w.WriteHeader(http.StatusNotFound)
+			_, err := w.Write([]byte(`{"code":404,"message":"block not found"}`))
+			assert.NoError(t, err)
+			return
+		}
+		_, err := w.Write([]byte(`{"data":{"message":{"slot":"10","proposer_index":"5","body":{"execution_payload_header":{"block_hash":"` + blockHash + `"}}}}}`))
+		assert.NoError(t, err)
+	})
+
+	block, err := bc.GetBlockBySlot(10)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), block.Data.Message.ProposerIndex)
+	require.Equal(t, blockHash, block.Data.Message.Body.ExecutionPayloadHeader.BlockHash)
+
+	block, err = bc.GetBlockByRoot(blockRoot)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), block.Data.Message.Slot)
+
+	_, err = bc.GetBlockBySlot(11)
+	require.ErrorIs(t, err, ErrBlockNotFound)
+
+	multiClient := NewMultiBeaconClient(common.TestLog, []IBeaconInstance{bc})
+	_, err = multiClient.GetBlockByRoot("0x01")
+	require.ErrorIs(t, err, ErrBlockNotFound)
+}
+
+func TestBeaconInstancesByHealth(t *testing.T) {
+	backend := newTestBackend(t, 4)
+	client, ok := backend.beaconClient.(*MultiBeaconClient)
+	require.True(t, ok)
+	instances := backend.beaconInstances
+
+	instances[0].MockSyncStatusErr = errTest
+	instances[1].MockSyncStatus = &SyncStatusPayloadData{HeadSlot: 10}
+	instances[1].ResponseDelay = 20 * time.Millisecond
+	instances[2].MockSyncStatus = &SyncStatusPayloadData{HeadSlot: 10}
+	instances[3].MockSyncStatus = &SyncStatusPayloadData{HeadSlot: 5}
+
+	_, err := client.BestSyncStatus()
+	require.NoError(t, err)
+
+	// fastest synced node first, then the slower one, then the lagging one, and the failing node last
+	expected := []IBeaconInstance{instances[2], instances[1], instances[3], instances[0]}
+	require.Equal(t, expected, client.beaconInstancesByHealth())
+	require.Equal(t, []IBeaconInstance{instances[0], instances[3], instances[1], instances[2]}, client.beaconInstancesByLeastUsed())
+
+	// a failed request demotes the node
+	_, err = client.GetProposerDuties(1)
+	require.NoError(t, err)
+	instances[2].MockProposerDutiesErr = errTest
+	_, err = client.GetProposerDuties(1)
+	require.NoError(t, err)
+	require.Equal(t, instances[1], client.beaconInstancesByHealth()[0])
+
+	// a node without recent head events is considered stalled
+	client.recordResult(instances[2], nil)
+	client.recordHeadEvent(instances[1], 10)
+	client.health[instances[1]].lastHeadEventAt = time.Now().Add(-2 * eventsStallTimeout)
+	require.Equal(t, instances[2], client.beaconInstancesByHealth()[0])
+}
+
+func TestSubscribeToEventsReconnectsStalledNode(t *testing.T) {
+	var numConnections uberatomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		numConnections.Inc()
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-req.Context().Done() // stalled, never sends an event
+	}))
+	defer srv.Close()
+
+	bc := NewProdBeaconInstance(common.TestLog, srv.URL, srv.URL)
+	bc.eventsStallTimeout = 100 * time.Millisecond
+	go bc.SubscribeToHeadEvents(make(chan HeadEventData))
+	require.Eventually(t, func() bool { return numConnections.Load() >= 2 }, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestSubscribeToEventsBacksOffReconnects(t *testing.T) {
+	var numConnections uberatomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		numConnections.Inc()
+		w.WriteHeader(http.StatusServiceUnavailable) // fails right away
+	}))
+	defer srv.Close()
+
+	bc := NewProdBeaconInstance(common.TestLog, srv.URL, srv.URL)
+	go bc.SubscribeToHeadEvents(make(chan HeadEventData))
+	require.Eventually(t, func() bool { return numConnections.Load() >= 3 }, 5*time.Second, 50*time.Millisecond)
+
+	// reconnects are delayed instead of spinning
+	time.Sleep(time.Second)
+	require.Less(t, numConnections.Load(), int64(10))
+}
@@ -1,6 +1,8 @@
 package beaconclient
 
 import (
+	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -16,6 +18,8 @@ type MockBeaconInstance struct {
 	MockProposerDuties     *ProposerDutiesResponse
 	MockProposerDutiesErr  error
 	MockFetchValidatorsErr error
+	MockPublishBlockCode   int
+	MockPublishBlockErr    error
 
 	ResponseDelay time.Duration
 }
@@ -34,6 +38,8 @@ func NewMockBeaconInstance() *MockBeaconInstance {
 		MockSyncStatusErr:      nil,
 		MockProposerDutiesErr:  nil,
 		MockFetchValidatorsErr: nil,
+		MockPublishBlockCode:   http.StatusOK,
+		MockPublishBlockErr:    nil,
 
 		ResponseDelay: 0,
 
@@ -77,6 +83,23 @@ func (c *MockBeaconInstance) GetStateValidators(stateID string) (*GetStateValida
 	return validatorResp, c.MockFetchValidatorsErr
 }
 
+func (c *MockBeaconInstance) StreamStateValidators(stateID string, indices []uint64, fn func(entry ValidatorResponseEntry)) (numValidators uint64, err error) {
+	c.addDelay()
+	if c.MockFetchValidatorsErr != nil {
+		return 0, c.MockFetchValidatorsErr
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, entry := range c.validatorSet {
+		if len(indices) > 0 && !slices.Contains(indices, entry.Index) {
+			continue
+		}
+		fn(entry)
+		numValidators++
+	}
+	return numValidators, nil
+}
+
 func (c *MockBeaconInstance) SyncStatus() (*SyncStatusPayloadData, error) {
 	c.addDelay()
 	return c.MockSyncStatus, c.MockSyncStatusErr
@@ -111,7 +134,8 @@ func (c *MockBeaconInstance) addDelay() {
 }
 
 func (c *MockBeaconInstance) PublishBlock(block *common.VersionedSignedProposal, broadcaseMode BroadcastMode) (code int, err error) {
-	return 0, nil
+	c.addDelay()
+	return c.MockPublishBlockCode, c.MockPublishBlockErr
 }
 
 func (c *MockBeaconInstance) GetGenesis() (*GetGenesisResponse, error) {
@@ -133,3 +157,11 @@ func (c *MockBeaconInstance) GetRandao(slot uint64) (spec *GetRandaoResponse, er
 func (c *MockBeaconInstance) GetWithdrawals(slot uint64) (spec *GetWithdrawalsResponse, err error) {
 	return nil, nil
 }
+
+func (c *MockBeaconInstance) GetBlockBySlot(slot uint64) (blockResp *GetBlindedBlockResponse, err error) {
+	return nil, nil
+}
+
+func (c *MockBeaconInstance) GetBlockByRoot(root string) (blockResp *GetBlindedBlockResponse, err error) {
+	return nil, nil
+}
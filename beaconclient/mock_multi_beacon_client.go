@@ -15,6 +15,8 @@ func (*MockMultiBeaconClient) BestSyncStatus() (*SyncStatusPayloadData, error) {
 	return &SyncStatusPayloadData{HeadSlot: 1}, nil //nolint:exhaustruct
 }
 
+func (*MockMultiBeaconClient) StartHealthChecks() {}
+
 func (*MockMultiBeaconClient) SubscribeToHeadEvents(slotC chan HeadEventData) {}
 
 func (*MockMultiBeaconClient) SubscribeToPayloadAttributesEvents(payloadAttrC chan PayloadAttributesEvent) {
@@ -24,6 +26,10 @@ func (*MockMultiBeaconClient) GetStateValidators(stateID string) (*GetStateValid
 	return nil, nil
 }
 
+func (*MockMultiBeaconClient) StreamStateValidators(stateID string, indices []uint64, fn func(entry ValidatorResponseEntry)) (uint64, error) {
+	return 0, nil
+}
+
 func (*MockMultiBeaconClient) GetProposerDuties(epoch uint64) (*ProposerDutiesResponse, error) {
 	return nil, nil
 }
@@ -39,7 +45,9 @@ func (*MockMultiBeaconClient) GetGenesis() (*GetGenesisResponse, error) {
 }
 
 func (*MockMultiBeaconClient) GetSpec() (spec *GetSpecResponse, err error) {
-	return nil, nil
+	resp := &GetSpecResponse{} //nolint:exhaustruct
+	resp.Data.SecondsPerSlot = common.SecondsPerSlot
+	return resp, nil
 }
 
 func (*MockMultiBeaconClient) GetForkSchedule() (spec *GetForkScheduleResponse, err error) {
@@ -67,3 +75,11 @@ func (*MockMultiBeaconClient) GetWithdrawals(slot uint64) (spec *GetWithdrawalsR
 	resp.Data.Withdrawals = append(resp.Data.Withdrawals, &capella.Withdrawal{}) //nolint:exhaustruct
 	return resp, nil
 }
+
+func (*MockMultiBeaconClient) GetBlockBySlot(slot uint64) (blockResp *GetBlindedBlockResponse, err error) {
+	return nil, nil
+}
+
+func (*MockMultiBeaconClient) GetBlockByRoot(root string) (blockResp *GetBlindedBlockResponse, err error) {
+	return nil, nil
+}
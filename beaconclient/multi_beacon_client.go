@@ -4,13 +4,15 @@ package beaconclient
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/sirupsen/logrus"
-	uberatomic "go.uber.org/atomic"
 )
 
 var (
@@ -18,6 +20,7 @@ var (
 	ErrBeaconNodesUnavailable   = errors.New("all beacon nodes responded with error")
 	ErrWithdrawalsBeforeCapella = errors.New("withdrawals are not supported before capella")
 	ErrBeaconBlock202           = errors.New("beacon block failed validation but was still broadcast (202)")
+	ErrBlockNotFound            = errors.New("no block found for slot")
 )
 
 type BroadcastMode string
@@ -31,12 +34,18 @@ const (
 // IMultiBeaconClient is the interface for the MultiBeaconClient, which can manage several beacon client instances under the hood
 type IMultiBeaconClient interface {
 	BestSyncStatus() (*SyncStatusPayloadData, error)
+	// StartHealthChecks regularly polls the sync status of all beacon nodes to rank them, blocking
+	StartHealthChecks()
 	SubscribeToHeadEvents(slotC chan HeadEventData)
 	// SubscribeToPayloadAttributesEvents subscribes to payload attributes events to validate fields such as prevrandao and withdrawals
 	SubscribeToPayloadAttributesEvents(payloadAttrC chan PayloadAttributesEvent)
 
 	// GetStateValidators returns all active and pending validators from the beacon node
 	GetStateValidators(stateID string) (*GetStateValidatorsResponse, error)
+	// StreamStateValidators calls fn for all active and pending validators (or only those with the given indices) while they
+	// are received from the beacon node. If a beacon node fails mid-way, the next one is queried and fn may be called again
+	// for the same validators.
+	StreamStateValidators(stateID string, indices []uint64, fn func(entry ValidatorResponseEntry)) (numValidators uint64, err error)
 	GetProposerDuties(epoch uint64) (*ProposerDutiesResponse, error)
 	PublishBlock(block *common.VersionedSignedProposal) (code int, err error)
 	GetGenesis() (*GetGenesisResponse, error)
@@ -44,6 +53,10 @@ type IMultiBeaconClient interface {
 	GetForkSchedule() (spec *GetForkScheduleResponse, err error)
 	GetRandao(slot uint64) (spec *GetRandaoResponse, err error)
 	GetWithdrawals(slot uint64) (spec *GetWithdrawalsResponse, err error)
+	// GetBlockBySlot returns the canonical block of a slot, or ErrBlockNotFound if there is none
+	GetBlockBySlot(slot uint64) (blockResp *GetBlindedBlockResponse, err error)
+	// GetBlockByRoot returns the block with the given root, or ErrBlockNotFound if no beacon node knows it
+	GetBlockByRoot(root string) (blockResp *GetBlindedBlockResponse, err error)
 }
 
 // IBeaconInstance is the interface for a single beacon client instance
@@ -53,6 +66,7 @@ type IBeaconInstance interface {
 	SubscribeToHeadEvents(slotC chan HeadEventData)
 	SubscribeToPayloadAttributesEvents(slotC chan PayloadAttributesEvent)
 	GetStateValidators(stateID string) (*GetStateValidatorsResponse, error)
+	StreamStateValidators(stateID string, indices []uint64, fn func(entry ValidatorResponseEntry)) (numValidators uint64, err error)
 	GetProposerDuties(epoch uint64) (*ProposerDutiesResponse, error)
 	GetURI() string
 	GetPublishURI() string
@@ -62,13 +76,17 @@ type IBeaconInstance interface {
 	GetForkSchedule() (spec *GetForkScheduleResponse, err error)
 	GetRandao(slot uint64) (spec *GetRandaoResponse, err error)
 	GetWithdrawals(slot uint64) (spec *GetWithdrawalsResponse, err error)
+	GetBlockBySlot(slot uint64) (blockResp *GetBlindedBlockResponse, err error)
+	GetBlockByRoot(root string) (blockResp *GetBlindedBlockResponse, err error)
 }
 
 type MultiBeaconClient struct {
 	log             *logrus.Entry
-	bestBeaconIndex uberatomic.Int64
 	beaconInstances []IBeaconInstance
 
+	healthLock sync.RWMutex
+	health     map[IBeaconInstance]*beaconNodeHealth
+
 	// feature flags
 	ffAllowSyncingBeaconNode bool
 
@@ -79,11 +97,15 @@ func NewMultiBeaconClient(log *logrus.Entry, beaconInstances []IBeaconInstance)
 	client := &MultiBeaconClient{
 		log:                      log.WithField("component", "beaconClient"),
 		beaconInstances:          beaconInstances,
-		bestBeaconIndex:          *uberatomic.NewInt64(0),
+		health:                   make(map[IBeaconInstance]*beaconNodeHealth),
 		ffAllowSyncingBeaconNode: false,
 		broadcastMode:            ConsensusAndEquivocation,
 	}
 
+	for _, instance := range beaconInstances {
+		client.health[instance] = &beaconNodeHealth{}
+	}
+
 	// feature flags
 	if os.Getenv("ALLOW_SYNCING_BEACON_NODE") != "" {
 		client.log.Warn("env: ALLOW_SYNCING_BEACON_NODE: allow syncing beacon node")
@@ -119,7 +141,9 @@ func (c *MultiBeaconClient) BestSyncStatus() (*SyncStatusPayloadData, error) {
 			log := c.log.WithField("uri", instance.GetURI())
 			log.Debug("getting sync status")
 
+			start := time.Now()
 			syncStatus, err := instance.SyncStatus()
+			c.recordSyncStatus(instance, syncStatus, time.Since(start), err)
 			if err != nil {
 				log.WithError(err).Error("failed to get sync status")
 				return
@@ -157,11 +181,57 @@ func (c *MultiBeaconClient) BestSyncStatus() (*SyncStatusPayloadData, error) {
 	return bestSyncStatus, nil
 }
 
+// StartHealthChecks regularly polls the sync status of all beacon nodes, to rank them by head slot and latency
+func (c *MultiBeaconClient) StartHealthChecks() {
+	bestURI := ""
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		_, err := c.BestSyncStatus()
+		if err != nil {
+			c.log.WithError(err).Warn("health check: no synced beacon node")
+		}
+
+		best := c.beaconInstancesByHealth()[0]
+		if best.GetURI() != bestURI {
+			c.log.WithFields(logrus.Fields{
+				"uri":         best.GetURI(),
+				"previousURI": bestURI,
+			}).Info("best beacon node changed")
+			bestURI = best.GetURI()
+		}
+
+		// the head slot of the services freezes if none of the nodes delivers head events
+		if lastHeadEventAt := c.lastHeadEventAt(); eventsStallTimeout > 0 && !lastHeadEventAt.IsZero() && time.Since(lastHeadEventAt) > eventsStallTimeout {
+			c.log.WithField("lastHeadEventAt", lastHeadEventAt).Error("health check: no head events received from any beacon node")
+		}
+	}
+}
+
+// lastHeadEventAt returns when the latest head event was received from any of the beacon nodes
+func (c *MultiBeaconClient) lastHeadEventAt() (lastHeadEventAt time.Time) {
+	c.healthLock.RLock()
+	defer c.healthLock.RUnlock()
+	for _, health := range c.health {
+		if health.lastHeadEventAt.After(lastHeadEventAt) {
+			lastHeadEventAt = health.lastHeadEventAt
+		}
+	}
+	return lastHeadEventAt
+}
+
 // SubscribeToHeadEvents subscribes to head events from all beacon nodes. A single head event will be received multiple times,
-// likely once for every beacon nodes.
+// likely once for every beacon nodes. The events of each node are also used to rank the nodes, and to detect stalled ones.
 func (c *MultiBeaconClient) SubscribeToHeadEvents(slotC chan HeadEventData) {
 	for _, instance := range c.beaconInstances {
-		go instance.SubscribeToHeadEvents(slotC)
+		instanceC := make(chan HeadEventData)
+		go instance.SubscribeToHeadEvents(instanceC)
+		go func(instance IBeaconInstance) {
+			for headEvent := range instanceC {
+				c.recordHeadEvent(instance, headEvent.Slot)
+				slotC <- headEvent
+			}
+		}(instance)
 	}
 }
 
@@ -173,69 +243,94 @@ func (c *MultiBeaconClient) SubscribeToPayloadAttributesEvents(slotC chan Payloa
 
 // GetStateValidators returns all known validators, and queries the beacon nodes in reverse order (because it is a heavy request for the CL client)
 func (c *MultiBeaconClient) GetStateValidators(stateID string) (*GetStateValidatorsResponse, error) {
-	for i, client := range c.beaconInstancesByLeastUsed() {
+	for _, client := range c.beaconInstancesByLeastUsed() {
 		log := c.log.WithField("uri", client.GetURI())
 		log.Debug("fetching validators")
 
 		validators, err := client.GetStateValidators(stateID)
 		if err != nil {
+			c.recordResult(client, err)
 			log.WithError(err).Error("failed to fetch validators")
 			continue
 		}
 
-		c.bestBeaconIndex.Store(int64(i))
-
-		// Received successful response. Set this index as last successful beacon node
+		// Received successful response
+		c.recordResult(client, nil)
 		return validators, nil
 	}
 
 	return nil, ErrBeaconNodesUnavailable
 }
 
+// StreamStateValidators streams all known validators, and queries the beacon nodes in reverse order (because it is a heavy request for the CL client)
+func (c *MultiBeaconClient) StreamStateValidators(stateID string, indices []uint64, fn func(entry ValidatorResponseEntry)) (numValidators uint64, err error) {
+	for _, client := range c.beaconInstancesByLeastUsed() {
+		log := c.log.WithField("uri", client.GetURI())
+		log.Debug("streaming validators")
+
+		numValidators, err = client.StreamStateValidators(stateID, indices, fn)
+		if err != nil {
+			c.recordResult(client, err)
+			log.WithError(err).WithField("numValidators", numValidators).Error("failed to stream validators")
+			continue
+		}
+
+		// Received successful response
+		c.recordResult(client, nil)
+		return numValidators, nil
+	}
+
+	return 0, ErrBeaconNodesUnavailable
+}
+
 func (c *MultiBeaconClient) GetProposerDuties(epoch uint64) (*ProposerDutiesResponse, error) {
 	// return the first successful beacon node response
-	clients := c.beaconInstancesByLastResponse()
+	clients := c.beaconInstancesByHealth()
 	log := c.log.WithField("epoch", epoch)
 
-	for i, client := range clients {
+	for _, client := range clients {
 		log := log.WithField("uri", client.GetURI())
 		log.Debug("fetching proposer duties")
 
 		duties, err := client.GetProposerDuties(epoch)
 		if err != nil {
+			c.recordResult(client, err)
 			log.WithError(err).Error("failed to get proposer duties")
 			continue
 		}
 
-		c.bestBeaconIndex.Store(int64(i))
-
-		// Received successful response. Set this index as last successful beacon node
+		// Received successful response
+		c.recordResult(client, nil)
 		return duties, nil
 	}
 
 	return nil, ErrBeaconNodesUnavailable
 }
 
-// beaconInstancesByLastResponse returns a list of beacon clients that has the client
-// with the last successful response as the first element of the slice
-func (c *MultiBeaconClient) beaconInstancesByLastResponse() []IBeaconInstance {
-	index := c.bestBeaconIndex.Load()
-	if index == 0 {
-		return c.beaconInstances
-	}
-
+// beaconInstancesByHealth returns the beacon clients ordered from the best to the worst node (see beaconNodeHealth)
+func (c *MultiBeaconClient) beaconInstancesByHealth() []IBeaconInstance {
 	instances := make([]IBeaconInstance, len(c.beaconInstances))
 	copy(instances, c.beaconInstances)
-	instances[0], instances[index] = instances[index], instances[0]
 
+	c.healthLock.RLock()
+	defer c.healthLock.RUnlock()
+
+	now := time.Now()
+	bestHeadSlot := uint64(0)
+	for _, h := range c.health {
+		bestHeadSlot = max(bestHeadSlot, h.headSlot)
+	}
+	sort.SliceStable(instances, func(i, j int) bool {
+		return c.health[instances[i]].isBetterThan(c.health[instances[j]], bestHeadSlot, now)
+	})
 	return instances
 }
 
-// beaconInstancesByLeastUsed returns a list of beacon clients that has the client
-// with the last successful response as the last element of the slice (used only by
-// GetStateValidators, because it's a heavy call on the CL)
+// beaconInstancesByLeastUsed returns a list of beacon clients that has the best
+// node as the last element of the slice (used only by GetStateValidators, because
+// it's a heavy call on the CL)
 func (c *MultiBeaconClient) beaconInstancesByLeastUsed() []IBeaconInstance {
-	beaconInstances := c.beaconInstancesByLastResponse()
+	beaconInstances := c.beaconInstancesByHealth()
 	instances := make([]IBeaconInstance, len(c.beaconInstances))
 	for i := range beaconInstances {
 		instances[i] = beaconInstances[len(beaconInstances)-i-1]
@@ -266,7 +361,7 @@ func (c *MultiBeaconClient) PublishBlock(block *common.VersionedSignedProposal)
 		"blockHash": blockHash.String(),
 	})
 
-	clients := c.beaconInstancesByLastResponse()
+	clients := c.beaconInstancesByHealth()
 
 	// The chan will be cleaner up automatically once the function exists even if it was still being written to
 	resChans := make(chan publishResp, len(clients))
@@ -285,29 +380,29 @@ func (c *MultiBeaconClient) PublishBlock(block *common.VersionedSignedProposal)
 	}
 
 	var lastErrPublishResp publishResp
+	wasBroadcast := false
 	for range clients {
 		res := <-resChans
 		log = log.WithField("beacon", clients[res.index].GetPublishURI())
+		c.recordResult(clients[res.index], res.err)
 		if res.err != nil {
 			log.WithField("statusCode", res.code).WithError(res.err).Warn("failed to publish block")
 			lastErrPublishResp = res
 			continue
-		} else if res.code == 202 {
+		} else if res.code == http.StatusAccepted {
 			// Should the block fail full validation, a separate success response code (202) is used to indicate that the block was successfully broadcast but failed integration.
 			// https://ethereum.github.io/beacon-APIs/?urls.primaryName=dev#/Beacon/publishBlock
 			log.WithField("statusCode", res.code).WithError(res.err).Warn("CL client failed block integration, but block was successfully broadcast")
-			lastErrPublishResp = res
+			wasBroadcast = true
 			continue
 		}
 
-		c.bestBeaconIndex.Store(int64(res.index))
-
 		log.WithField("statusCode", res.code).Info("published block")
 		return res.code, nil
 	}
 
-	if lastErrPublishResp.err == nil {
-		return lastErrPublishResp.code, nil
+	if wasBroadcast {
+		return http.StatusAccepted, ErrBeaconBlock202
 	}
 	log.Error("failed to publish block on any CL node")
 	return lastErrPublishResp.code, fmt.Errorf("last error: %w", lastErrPublishResp.err)
@@ -315,15 +410,16 @@ func (c *MultiBeaconClient) PublishBlock(block *common.VersionedSignedProposal)
 
 // GetGenesis returns the genesis info - https://ethereum.github.io/beacon-APIs/#/Beacon/getGenesis
 func (c *MultiBeaconClient) GetGenesis() (genesisInfo *GetGenesisResponse, err error) {
-	clients := c.beaconInstancesByLastResponse()
-	for i, client := range clients {
+	clients := c.beaconInstancesByHealth()
+	for _, client := range clients {
 		log := c.log.WithField("uri", client.GetURI())
 		if genesisInfo, err = client.GetGenesis(); err != nil {
+			c.recordResult(client, err)
 			log.WithError(err).Warn("failed to get genesis info")
 			continue
 		}
 
-		c.bestBeaconIndex.Store(int64(i))
+		c.recordResult(client, nil)
 
 		return genesisInfo, nil
 	}
@@ -334,14 +430,17 @@ func (c *MultiBeaconClient) GetGenesis() (genesisInfo *GetGenesisResponse, err e
 
 // GetSpec - https://ethereum.github.io/beacon-APIs/#/Config/getSpec
 func (c *MultiBeaconClient) GetSpec() (spec *GetSpecResponse, err error) {
-	clients := c.beaconInstancesByLastResponse()
+	clients := c.beaconInstancesByHealth()
 	for _, client := range clients {
 		log := c.log.WithField("uri", client.GetURI())
 		if spec, err = client.GetSpec(); err != nil {
+			c.recordResult(client, err)
 			log.WithError(err).Warn("failed to get spec")
 			continue
 		}
 
+		c.recordResult(client, nil)
+
 		return spec, nil
 	}
 
@@ -351,15 +450,16 @@ func (c *MultiBeaconClient) GetSpec() (spec *GetSpecResponse, err error) {
 
 // GetForkSchedule - https://ethereum.github.io/beacon-APIs/#/Config/getForkSchedule
 func (c *MultiBeaconClient) GetForkSchedule() (spec *GetForkScheduleResponse, err error) {
-	clients := c.beaconInstancesByLastResponse()
-	for i, client := range clients {
+	clients := c.beaconInstancesByHealth()
+	for _, client := range clients {
 		log := c.log.WithField("uri", client.GetURI())
 		if spec, err = client.GetForkSchedule(); err != nil {
+			c.recordResult(client, err)
 			log.WithError(err).Warn("failed to get fork schedule")
 			continue
 		}
 
-		c.bestBeaconIndex.Store(int64(i))
+		c.recordResult(client, nil)
 
 		return spec, nil
 	}
@@ -370,15 +470,16 @@ func (c *MultiBeaconClient) GetForkSchedule() (spec *GetForkScheduleResponse, er
 
 // GetRandao - 3500/eth/v1/beacon/states/<slot>/randao
 func (c *MultiBeaconClient) GetRandao(slot uint64) (randaoResp *GetRandaoResponse, err error) {
-	clients := c.beaconInstancesByLastResponse()
-	for i, client := range clients {
+	clients := c.beaconInstancesByHealth()
+	for _, client := range clients {
 		log := c.log.WithField("uri", client.GetURI())
 		if randaoResp, err = client.GetRandao(slot); err != nil {
+			c.recordResult(client, err)
 			log.WithField("slot", slot).WithError(err).Warn("failed to get randao")
 			continue
 		}
 
-		c.bestBeaconIndex.Store(int64(i))
+		c.recordResult(client, nil)
 
 		return randaoResp, nil
 	}
@@ -389,18 +490,19 @@ func (c *MultiBeaconClient) GetRandao(slot uint64) (randaoResp *GetRandaoRespons
 
 // GetWithdrawals - 3500/eth/v1/beacon/states/<slot>/withdrawals
 func (c *MultiBeaconClient) GetWithdrawals(slot uint64) (withdrawalsResp *GetWithdrawalsResponse, err error) {
-	clients := c.beaconInstancesByLastResponse()
-	for i, client := range clients {
+	clients := c.beaconInstancesByHealth()
+	for _, client := range clients {
 		log := c.log.WithField("uri", client.GetURI())
 		if withdrawalsResp, err = client.GetWithdrawals(slot); err != nil {
 			if strings.Contains(err.Error(), "Withdrawals not enabled before capella") {
 				break
 			}
+			c.recordResult(client, err)
 			log.WithField("slot", slot).WithError(err).Warn("failed to get withdrawals")
 			continue
 		}
 
-		c.bestBeaconIndex.Store(int64(i))
+		c.recordResult(client, nil)
 
 		return withdrawalsResp, nil
 	}
@@ -413,3 +515,97 @@ func (c *MultiBeaconClient) GetWithdrawals(slot uint64) (withdrawalsResp *GetWit
 	c.log.WithField("slot", slot).WithError(err).Warn("failed to get withdrawals from any CL node")
 	return nil, err
 }
+
+// GetBlockBySlot - /eth/v1/beacon/blinded_blocks/<slot>, returns ErrBlockNotFound if no CL node knows a block for the slot
+func (c *MultiBeaconClient) GetBlockBySlot(slot uint64) (blockResp *GetBlindedBlockResponse, err error) {
+	return c.getBlindedBlock(c.log.WithField("slot", slot), func(client IBeaconInstance) (*GetBlindedBlockResponse, error) {
+		return client.GetBlockBySlot(slot)
+	})
+}
+
+// GetBlockByRoot - /eth/v1/beacon/blinded_blocks/<root>, returns ErrBlockNotFound if no CL node knows the block
+func (c *MultiBeaconClient) GetBlockByRoot(root string) (blockResp *GetBlindedBlockResponse, err error) {
+	return c.getBlindedBlock(c.log.WithField("blockRoot", root), func(client IBeaconInstance) (*GetBlindedBlockResponse, error) {
+		return client.GetBlockByRoot(root)
+	})
+}
+
+func (c *MultiBeaconClient) getBlindedBlock(log *logrus.Entry, get func(client IBeaconInstance) (*GetBlindedBlockResponse, error)) (blockResp *GetBlindedBlockResponse, err error) {
+	clients := c.beaconInstancesByHealth()
+	isNotFound := false
+	for _, client := range clients {
+		if blockResp, err = get(client); err != nil {
+			if errors.Is(err, ErrBlockNotFound) {
+				isNotFound = true
+			} else {
+				c.recordResult(client, err)
+			}
+			log.WithField("uri", client.GetURI()).WithError(err).Warn("failed to get blinded block")
+			continue
+		}
+
+		c.recordResult(client, nil)
+
+		return blockResp, nil
+	}
+
+	if isNotFound {
+		return nil, ErrBlockNotFound
+	}
+	log.WithError(err).Warn("failed to get blinded block from any CL node")
+	return nil, err
+}
+
+func (c *MultiBeaconClient) recordSyncStatus(instance IBeaconInstance, syncStatus *SyncStatusPayloadData, latency time.Duration, err error) {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+	c.health[instance].recordSyncStatus(syncStatus, latency, err)
+}
+
+func (c *MultiBeaconClient) recordHeadEvent(instance IBeaconInstance, slot uint64) {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+	c.health[instance].recordHeadEvent(slot, time.Now())
+}
+
+// recordResult keeps track of consecutive failed requests to a node
+func (c *MultiBeaconClient) recordResult(instance IBeaconInstance, err error) {
+	c.healthLock.Lock()
+	defer c.healthLock.Unlock()
+	if err != nil {
+		c.health[instance].numFailures++
+	} else {
+		c.health[instance].numFailures = 0
+	}
+}
+
+// NewEthNetworkDetails returns the details of the given network. For common.EthNetworkBeacon, the genesis validators
+// root and the fork versions are loaded from the beacon node (/eth/v1/beacon/genesis and /eth/v1/config/spec), and the
+// network is named after the CONFIG_NAME of its spec.
+func NewEthNetworkDetails(networkName string, client IMultiBeaconClient) (*common.EthNetworkDetails, error) {
+	if networkName != common.EthNetworkBeacon {
+		return common.NewEthNetworkDetails(networkName)
+	}
+
+	genesis, err := client.GetGenesis()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get genesis info: %w", err)
+	}
+	spec, err := client.GetSpec()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spec: %w", err)
+	}
+
+	name := spec.Data.ConfigName
+	if name == "" {
+		name = common.EthNetworkCustom
+	}
+	return common.NewEthNetworkDetailsFromForkVersions(name, common.EthNetworkForkVersions{
+		GenesisForkVersion:    spec.Data.GenesisForkVersion,
+		GenesisValidatorsRoot: genesis.Data.GenesisValidatorsRoot,
+		BellatrixForkVersion:  spec.Data.BellatrixForkVersion,
+		CapellaForkVersion:    spec.Data.CapellaForkVersion,
+		DenebForkVersion:      spec.Data.DenebForkVersion,
+		ElectraForkVersion:    spec.Data.ElectraForkVersion,
+	})
+}
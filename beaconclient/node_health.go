@@ -0,0 +1,81 @@
+package beaconclient
+
+import (
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+	"github.com/flashbots/mev-boost-relay/common"
+)
+
+var (
+	healthCheckInterval = common.GetEnvDurationSec("BEACON_HEALTH_CHECK_INTERVAL_SEC", 6)
+
+	// nodes lagging more slots behind the best head slot are only used if no other node is available
+	maxHeadSlotLag = uint64(cli.GetEnvInt("BEACON_MAX_HEAD_SLOT_LAG", 2)) //nolint:gosec
+
+	// nodes without events for this long are considered stalled, and their event subscriptions are reconnected.
+	// events are expected every slot, the default tolerates a single missed slot.
+	eventsStallTimeout = common.GetEnvDurationSec("BEACON_EVENTS_STALL_TIMEOUT_SEC", 25)
+
+	// maximum delay between reconnection attempts of event subscriptions
+	eventsMaxReconnectInterval = 10 * time.Second
+)
+
+// beaconNodeHealth is used to rank the beacon nodes, reads go to the best node first
+type beaconNodeHealth struct {
+	headSlot        uint64
+	isSyncing       bool
+	latency         time.Duration // moving average of the sync status response time
+	numFailures     uint64        // consecutive failed requests
+	lastHeadEventAt time.Time
+}
+
+func (h *beaconNodeHealth) recordSyncStatus(syncStatus *SyncStatusPayloadData, latency time.Duration, err error) {
+	if err != nil {
+		h.numFailures++
+		return
+	}
+	h.numFailures = 0
+	h.isSyncing = syncStatus.IsSyncing
+	if syncStatus.HeadSlot > h.headSlot {
+		h.headSlot = syncStatus.HeadSlot
+	}
+	if h.latency == 0 {
+		h.latency = latency
+	} else {
+		h.latency = (3*h.latency + latency) / 4
+	}
+}
+
+func (h *beaconNodeHealth) recordHeadEvent(slot uint64, now time.Time) {
+	h.lastHeadEventAt = now
+	if slot > h.headSlot {
+		h.headSlot = slot
+	}
+}
+
+// isStalled returns true if the node delivered head events before, but not recently
+func (h *beaconNodeHealth) isStalled(now time.Time) bool {
+	return eventsStallTimeout > 0 && !h.lastHeadEventAt.IsZero() && now.Sub(h.lastHeadEventAt) > eventsStallTimeout
+}
+
+func (h *beaconNodeHealth) isHealthy(bestHeadSlot uint64, now time.Time) bool {
+	return h.numFailures == 0 && !h.isSyncing && !h.isStalled(now) && h.headSlot+maxHeadSlotLag >= bestHeadSlot
+}
+
+// isBetterThan ranks healthy nodes before unhealthy ones, then nodes with a higher head slot (if unhealthy), then by latency
+func (h *beaconNodeHealth) isBetterThan(other *beaconNodeHealth, bestHeadSlot uint64, now time.Time) bool {
+	isHealthy, otherIsHealthy := h.isHealthy(bestHeadSlot, now), other.isHealthy(bestHeadSlot, now)
+	if isHealthy != otherIsHealthy {
+		return isHealthy
+	}
+	if !isHealthy {
+		if h.numFailures != other.numFailures {
+			return h.numFailures < other.numFailures
+		}
+		if h.headSlot != other.headSlot {
+			return h.headSlot > other.headSlot
+		}
+	}
+	return h.latency < other.latency
+}
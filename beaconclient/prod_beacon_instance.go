@@ -1,17 +1,23 @@
 package beaconclient
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/r3labs/sse/v2"
 	"github.com/sirupsen/logrus"
+	uberatomic "go.uber.org/atomic"
+	"gopkg.in/cenkalti/backoff.v1"
 )
 
 type ProdBeaconInstance struct {
@@ -25,6 +31,11 @@ type ProdBeaconInstance struct {
 
 	// http clients
 	publishingClient *http.Client
+
+	eventsStallTimeout time.Duration
+
+	// set once the node rejected an SSZ encoded block, after which blocks are published as JSON
+	sszPublishUnsupported uberatomic.Bool
 }
 
 func NewProdBeaconInstance(log *logrus.Entry, beaconURI, beaconPublishURI string) *ProdBeaconInstance {
@@ -34,7 +45,7 @@ func NewProdBeaconInstance(log *logrus.Entry, beaconURI, beaconPublishURI string
 		"beaconPublishURI": beaconPublishURI,
 	})
 
-	client := &ProdBeaconInstance{_log, beaconURI, beaconPublishURI, false, false, &http.Client{}}
+	client := &ProdBeaconInstance{_log, beaconURI, beaconPublishURI, false, false, &http.Client{}, eventsStallTimeout, uberatomic.Bool{}}
 
 	// feature flags
 	if os.Getenv("USE_V1_PUBLISH_BLOCK_ENDPOINT") != "" {
@@ -45,6 +56,9 @@ func NewProdBeaconInstance(log *logrus.Entry, beaconURI, beaconPublishURI string
 	if os.Getenv("USE_SSZ_ENCODING_PUBLISH_BLOCK") != "" {
 		_log.Warn("env: USE_SSZ_ENCODING_PUBLISH_BLOCK: using SSZ encoding to publish blocks")
 		client.ffUseSSZEncodingPublishBlock = true
+		if client.ffUseV1PublishBlockEndpoint {
+			_log.Warn("env: USE_SSZ_ENCODING_PUBLISH_BLOCK: SSZ encoded blocks are published to the v2 endpoint to apply the broadcast validation")
+		}
 	}
 
 	return client
@@ -83,57 +97,85 @@ type PayloadAttributes struct {
 }
 
 func (c *ProdBeaconInstance) SubscribeToHeadEvents(slotC chan HeadEventData) {
-	eventsURL := c.beaconURI + "/eth/v1/events?topics=head"
-	log := c.log.WithField("url", eventsURL)
-	log.Info("subscribing to head events")
-
-	client := sse.NewClient(eventsURL)
-
-	for {
-		err := client.SubscribeRaw(func(msg *sse.Event) {
-			var data HeadEventData
-			err := json.Unmarshal(msg.Data, &data)
-			if err != nil {
-				log.WithError(err).Error("could not unmarshal head event")
-			} else {
-				slotC <- data
-			}
-		})
+	c.subscribeToEvents("head", func(msg *sse.Event) error {
+		var data HeadEventData
+		err := json.Unmarshal(msg.Data, &data)
 		if err != nil {
-			log.WithError(err).Error("failed to subscribe to head events")
-			time.Sleep(1 * time.Second)
+			return err
 		}
-		c.log.Warn("beaconclient SubscribeRaw/SubscribeToHeadEvents ended, reconnecting")
-		time.Sleep(500 * time.Millisecond)
-	}
+		slotC <- data
+		return nil
+	})
 }
 
 func (c *ProdBeaconInstance) SubscribeToPayloadAttributesEvents(payloadAttributesC chan PayloadAttributesEvent) {
-	eventsURL := c.beaconURI + "/eth/v1/events?topics=payload_attributes"
-	log := c.log.WithField("url", eventsURL)
-	log.Info("subscribing to payload_attributes events")
+	c.subscribeToEvents("payload_attributes", func(msg *sse.Event) error {
+		var data PayloadAttributesEvent
+		err := json.Unmarshal(msg.Data, &data)
+		if err != nil {
+			return err
+		}
+		payloadAttributesC <- data
+		return nil
+	})
+}
 
-	client := sse.NewClient(eventsURL)
+// subscribeToEvents subscribes to the events of a topic, and reconnects when the subscription ends or no event was
+// received for c.eventsStallTimeout. Reconnects are delayed by an exponential backoff with jitter, which is reset
+// whenever an event is received.
+func (c *ProdBeaconInstance) subscribeToEvents(topic string, handler func(msg *sse.Event) error) {
+	eventsURL := c.beaconURI + "/eth/v1/events?topics=" + topic
+	log := c.log.WithField("url", eventsURL)
+	log.Infof("subscribing to %s events", topic)
 
+	reconnectBackoff := newEventsReconnectBackoff()
 	for {
-		err := client.SubscribeRaw(func(msg *sse.Event) {
-			var data PayloadAttributesEvent
-			err := json.Unmarshal(msg.Data, &data)
-			if err != nil {
-				log.WithError(err).Error("could not unmarshal payload_attributes event")
-			} else {
-				payloadAttributesC <- data
+		ctx, cancel := context.WithCancel(context.Background())
+		client := sse.NewClient(eventsURL)
+		client.ReconnectStrategy = backoff.WithContext(reconnectBackoff, ctx)
+		client.ReconnectNotify = func(err error, next time.Duration) {
+			log.WithError(err).Warnf("%s events subscription failed, reconnecting in %s", topic, next)
+		}
+
+		// a stalled node keeps the connection open without sending events, reconnect in that case
+		var stallTimer *time.Timer
+		if c.eventsStallTimeout > 0 {
+			stallTimer = time.AfterFunc(c.eventsStallTimeout, func() {
+				log.Warnf("no %s events received for %s, reconnecting", topic, c.eventsStallTimeout)
+				cancel()
+			})
+		}
+
+		err := client.SubscribeRawWithContext(ctx, func(msg *sse.Event) {
+			if stallTimer != nil {
+				stallTimer.Reset(c.eventsStallTimeout)
+			}
+			reconnectBackoff.Reset()
+			if err := handler(msg); err != nil {
+				log.WithError(err).Errorf("could not unmarshal %s event", topic)
 			}
 		})
-		if err != nil {
-			log.WithError(err).Error("failed to subscribe to payload_attributes events")
-			time.Sleep(1 * time.Second)
+		if stallTimer != nil {
+			stallTimer.Stop()
 		}
-		c.log.Warn("beaconclient SubscribeRaw/SubscribeToPayloadAttributesEvents ended, reconnecting")
-		time.Sleep(500 * time.Millisecond)
+		cancel()
+
+		delay := reconnectBackoff.NextBackOff()
+		log.WithError(err).Warnf("beaconclient subscription to %s events ended, reconnecting in %s", topic, delay)
+		time.Sleep(delay)
 	}
 }
 
+// newEventsReconnectBackoff returns a backoff which retries forever, with randomized intervals of at most eventsMaxReconnectInterval
+func newEventsReconnectBackoff() *backoff.ExponentialBackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = 250 * time.Millisecond
+	b.MaxInterval = eventsMaxReconnectInterval
+	b.MaxElapsedTime = 0
+	b.Reset()
+	return b
+}
+
 type GetStateValidatorsResponse struct {
 	ExecutionOptimistic bool `json:"execution_optimistic"`
 	Finalized           bool `json:"finalized"`
@@ -161,12 +203,95 @@ type ValidatorResponseValidatorData struct {
 // GetStateValidators loads all active and pending validators
 // https://ethereum.github.io/beacon-APIs/#/Beacon/getStateValidators
 func (c *ProdBeaconInstance) GetStateValidators(stateID string) (*GetStateValidatorsResponse, error) {
-	uri := fmt.Sprintf("%s/eth/v1/beacon/states/%s/validators?status=active,pending", c.beaconURI, stateID)
 	vd := new(GetStateValidatorsResponse)
-	_, err := fetchBeacon(http.MethodGet, uri, nil, vd, nil, http.Header{}, false)
+	_, err := c.StreamStateValidators(stateID, nil, func(entry ValidatorResponseEntry) {
+		vd.Data = append(vd.Data, entry)
+	})
 	return vd, err
 }
 
+// StreamStateValidators decodes the active and pending validators one by one while the response
+// is received, without holding the whole validator set in memory. If indices are given, only
+// these validators are queried (https://ethereum.github.io/beacon-APIs/#/Beacon/postStateValidators).
+// The beacon API defines no SSZ encoding for validators, so the response is always JSON.
+func (c *ProdBeaconInstance) StreamStateValidators(stateID string, indices []uint64, fn func(entry ValidatorResponseEntry)) (numValidators uint64, err error) {
+	decode := func(body io.Reader) error {
+		numValidators, err = decodeStateValidators(body, fn)
+		return err
+	}
+
+	if len(indices) == 0 {
+		uri := fmt.Sprintf("%s/eth/v1/beacon/states/%s/validators?status=active,pending", c.beaconURI, stateID)
+		_, err = streamBeacon(http.MethodGet, uri, nil, nil, decode)
+		return numValidators, err
+	}
+
+	ids := make([]string, len(indices))
+	for i, index := range indices {
+		ids[i] = strconv.FormatUint(index, 10)
+	}
+	payload, err := json.Marshal(postStateValidatorsRequest{IDs: ids, Statuses: []string{"active", "pending"}})
+	if err != nil {
+		return 0, err
+	}
+	uri := fmt.Sprintf("%s/eth/v1/beacon/states/%s/validators", c.beaconURI, stateID)
+	_, err = streamBeacon(http.MethodPost, uri, payload, nil, decode)
+	return numValidators, err
+}
+
+type postStateValidatorsRequest struct {
+	IDs      []string `json:"ids"`
+	Statuses []string `json:"statuses"`
+}
+
+// decodeStateValidators calls fn for every entry of the "data" list of a getStateValidators response
+func decodeStateValidators(r io.Reader, fn func(entry ValidatorResponseEntry)) (numValidators uint64, err error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return 0, err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return numValidators, err
+		}
+		if key != "data" {
+			// skip execution_optimistic, finalized, etc.
+			if err := dec.Decode(new(json.RawMessage)); err != nil {
+				return numValidators, err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '['); err != nil {
+			return numValidators, err
+		}
+		for dec.More() {
+			var entry ValidatorResponseEntry
+			if err := dec.Decode(&entry); err != nil {
+				return numValidators, err
+			}
+			fn(entry)
+			numValidators++
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return numValidators, err
+		}
+	}
+	return numValidators, expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("%w: expected %s, got %v", ErrInvalidResponse, delim, token)
+	}
+	return nil
+}
+
 // SyncStatusPayload is the response payload for /eth/v1/node/syncing
 // {"data":{"head_slot":"251114","sync_distance":"0","is_syncing":false,"is_optimistic":false}}
 type SyncStatusPayload struct {
@@ -257,13 +382,10 @@ func (c *ProdBeaconInstance) GetPublishURI() string {
 	return c.beaconPublishURI
 }
 
+// PublishBlock publishes the block to the beacon node, SSZ encoded if enabled. SSZ encoded blocks are
+// always published to the v2 endpoint, as only that one applies the given broadcast validation.
+// Validators and proposer duties have no SSZ encoding in the beacon API and are requested as JSON.
 func (c *ProdBeaconInstance) PublishBlock(block *common.VersionedSignedProposal, broadcastMode BroadcastMode) (code int, err error) {
-	var uri string
-	if c.ffUseV1PublishBlockEndpoint {
-		uri = c.beaconPublishURI + "/eth/v1/beacon/blocks"
-	} else {
-		uri = fmt.Sprintf("%s/eth/v2/beacon/blocks?broadcast_validation=%s", c.beaconPublishURI, broadcastMode)
-	}
 	headers := http.Header{}
 	headers.Add("Eth-Consensus-Version", strings.ToLower(block.Version.String())) // optional in v1, required in v2
 
@@ -272,8 +394,25 @@ func (c *ProdBeaconInstance) PublishBlock(block *common.VersionedSignedProposal,
 		slot = 0
 	}
 
+	useSSZ := c.ffUseSSZEncodingPublishBlock && !c.sszPublishUnsupported.Load()
+	code, err = c.publishBlock(c.publishBlockURI(broadcastMode, useSSZ), headers, block, slot, useSSZ)
+	if useSSZ && code == http.StatusUnsupportedMediaType {
+		c.log.WithError(err).Warn("beacon node does not support SSZ encoded blocks, publishing as JSON")
+		c.sszPublishUnsupported.Store(true)
+		code, err = c.publishBlock(c.publishBlockURI(broadcastMode, false), headers, block, slot, false)
+	}
+	return code, err
+}
+
+func (c *ProdBeaconInstance) publishBlockURI(broadcastMode BroadcastMode, useSSZ bool) string {
+	if c.ffUseV1PublishBlockEndpoint && !useSSZ {
+		return c.beaconPublishURI + "/eth/v1/beacon/blocks"
+	}
+	return fmt.Sprintf("%s/eth/v2/beacon/blocks?broadcast_validation=%s", c.beaconPublishURI, broadcastMode)
+}
+
+func (c *ProdBeaconInstance) publishBlock(uri string, headers http.Header, block *common.VersionedSignedProposal, slot phase0.Slot, useSSZ bool) (code int, err error) {
 	var payloadBytes []byte
-	useSSZ := c.ffUseSSZEncodingPublishBlock
 	log := c.log
 	encodeStartTime := time.Now().UTC()
 	if useSSZ {
@@ -318,7 +457,17 @@ func (c *ProdBeaconInstance) GetGenesis() (*GetGenesisResponse, error) {
 }
 
 type GetSpecResponse struct {
+	Data GetSpecResponseData `json:"data"`
+}
+
+type GetSpecResponseData struct {
+	ConfigName                      string `json:"CONFIG_NAME"`                        //nolint:tagliatelle
 	SecondsPerSlot                  uint64 `json:"SECONDS_PER_SLOT,string"`            //nolint:tagliatelle
+	GenesisForkVersion              string `json:"GENESIS_FORK_VERSION"`               //nolint:tagliatelle
+	BellatrixForkVersion            string `json:"BELLATRIX_FORK_VERSION"`             //nolint:tagliatelle
+	CapellaForkVersion              string `json:"CAPELLA_FORK_VERSION"`               //nolint:tagliatelle
+	DenebForkVersion                string `json:"DENEB_FORK_VERSION"`                 //nolint:tagliatelle
+	ElectraForkVersion              string `json:"ELECTRA_FORK_VERSION"`               //nolint:tagliatelle
 	DepositContractAddress          string `json:"DEPOSIT_CONTRACT_ADDRESS"`           //nolint:tagliatelle
 	DepositNetworkID                string `json:"DEPOSIT_NETWORK_ID"`                 //nolint:tagliatelle
 	DomainAggregateAndProof         string `json:"DOMAIN_AGGREGATE_AND_PROOF"`         //nolint:tagliatelle
@@ -364,6 +513,41 @@ func (c *ProdBeaconInstance) GetRandao(slot uint64) (randaoResp *GetRandaoRespon
 	return resp, err
 }
 
+type GetBlindedBlockResponse struct {
+	Data struct {
+		Message struct {
+			Slot          uint64 `json:"slot,string"`
+			ProposerIndex uint64 `json:"proposer_index,string"`
+			ParentRoot    string `json:"parent_root"`
+			Body          struct {
+				ExecutionPayloadHeader struct {
+					BlockHash string `json:"block_hash"`
+				} `json:"execution_payload_header"`
+			} `json:"body"`
+		} `json:"message"`
+	}
+}
+
+// GetBlockBySlot returns the canonical block of a slot, or ErrBlockNotFound if there is none - https://ethereum.github.io/beacon-APIs/#/Beacon/getBlindedBlock
+func (c *ProdBeaconInstance) GetBlockBySlot(slot uint64) (blockResp *GetBlindedBlockResponse, err error) {
+	return c.getBlindedBlock(strconv.FormatUint(slot, 10))
+}
+
+// GetBlockByRoot returns the block with the given root, or ErrBlockNotFound if the node doesn't know it
+func (c *ProdBeaconInstance) GetBlockByRoot(root string) (blockResp *GetBlindedBlockResponse, err error) {
+	return c.getBlindedBlock(root)
+}
+
+func (c *ProdBeaconInstance) getBlindedBlock(blockID string) (blockResp *GetBlindedBlockResponse, err error) {
+	uri := fmt.Sprintf("%s/eth/v1/beacon/blinded_blocks/%s", c.beaconURI, blockID)
+	resp := new(GetBlindedBlockResponse)
+	code, err := fetchBeacon(http.MethodGet, uri, nil, resp, nil, http.Header{}, false)
+	if code == http.StatusNotFound {
+		return nil, ErrBlockNotFound
+	}
+	return resp, err
+}
+
 type GetWithdrawalsResponse struct {
 	Data struct {
 		Withdrawals []*capella.Withdrawal `json:"withdrawals"`
@@ -13,6 +13,7 @@ import (
 var (
 	ErrHTTPErrorResponse     = errors.New("got an HTTP error response")
 	ErrInvalidRequestPayload = errors.New("invalid request payload")
+	ErrInvalidResponse       = errors.New("invalid response")
 
 	StateIDHead      = "head"
 	StateIDGenesis   = "genesis"
@@ -71,14 +72,7 @@ func fetchBeacon(method, url string, payload []byte, dst any, httpClient *http.C
 	}
 
 	if resp.StatusCode >= http.StatusMultipleChoices {
-		ec := &struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		}{}
-		if err = json.Unmarshal(bodyBytes, ec); err != nil {
-			return resp.StatusCode, fmt.Errorf("could not unmarshal error response from beacon node for %s from %s: %w", url, string(bodyBytes), err)
-		}
-		return resp.StatusCode, fmt.Errorf("%w: %s", ErrHTTPErrorResponse, ec.Message)
+		return resp.StatusCode, parseErrorResponse(url, bodyBytes)
 	}
 
 	if dst != nil {
@@ -90,3 +84,54 @@ func fetchBeacon(method, url string, payload []byte, dst any, httpClient *http.C
 
 	return resp.StatusCode, nil
 }
+
+// streamBeacon sends a request and passes the response body to decode as it is received,
+// instead of reading it into memory first
+func streamBeacon(method, url string, payload []byte, httpClient *http.Client, decode func(body io.Reader) error) (code int, err error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return 0, fmt.Errorf("invalid request for %s: %w", url, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	client := http.DefaultClient
+	if httpClient != nil {
+		client = httpClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("client refused for %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return resp.StatusCode, fmt.Errorf("could not read response body for %s: %w", url, err)
+		}
+		return resp.StatusCode, parseErrorResponse(url, bodyBytes)
+	}
+
+	err = decode(resp.Body)
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("could not decode response for %s: %w", url, err)
+	}
+	return resp.StatusCode, nil
+}
+
+func parseErrorResponse(url string, bodyBytes []byte) error {
+	ec := &struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}{}
+	if err := json.Unmarshal(bodyBytes, ec); err != nil {
+		return fmt.Errorf("could not unmarshal error response from beacon node for %s from %s: %w", url, string(bodyBytes), err)
+	}
+	return fmt.Errorf("%w: %s", ErrHTTPErrorResponse, ec.Message)
+}
@@ -6,9 +6,11 @@ import (
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-utils/cli"
 	"github.com/flashbots/mev-boost-relay/beaconclient"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/flashbots/mev-boost-relay/database"
@@ -23,6 +25,7 @@ var (
 	apiDefaultBlockSim   = common.GetEnv("BLOCKSIM_URI", "http://localhost:8545")
 	apiDefaultSecretKey  = common.GetEnv("SECRET_KEY", "")
 	apiDefaultLogTag     = os.Getenv("LOG_TAG")
+	apiDefaultMinBid     = common.GetEnv("MIN_BID_ETH", "0")
 
 	apiDefaultPprofEnabled       = os.Getenv("PPROF") == "1"
 	apiDefaultInternalAPIEnabled = os.Getenv("ENABLE_INTERNAL_API") == "1"
@@ -32,6 +35,23 @@ var (
 	apiDefaultDataAPIEnabled     = os.Getenv("DISABLE_DATA_API") != "1"
 	apiDefaultProposerAPIEnabled = os.Getenv("DISABLE_PROPOSER_API") != "1"
 
+	// HTTP server settings
+	apiDefaultReadTimeout       = time.Duration(cli.GetEnvInt("API_TIMEOUT_READ_MS", int(api.DefaultReadTimeout.Milliseconds()))) * time.Millisecond
+	apiDefaultReadHeaderTimeout = time.Duration(cli.GetEnvInt("API_TIMEOUT_READHEADER_MS", int(api.DefaultReadHeaderTimeout.Milliseconds()))) * time.Millisecond
+	apiDefaultWriteTimeout      = time.Duration(cli.GetEnvInt("API_TIMEOUT_WRITE_MS", int(api.DefaultWriteTimeout.Milliseconds()))) * time.Millisecond
+	apiDefaultIdleTimeout       = time.Duration(cli.GetEnvInt("API_TIMEOUT_IDLE_MS", int(api.DefaultIdleTimeout.Milliseconds()))) * time.Millisecond
+	apiDefaultMaxPayloadBytes   = cli.GetEnvInt("API_MAX_PAYLOAD_BYTES", api.DefaultMaxPayloadBytes)
+
+	// Remote signer for the relay key, instead of the secret key
+	apiDefaultRemoteSignerURL     = os.Getenv("REMOTE_SIGNER_URL")
+	apiDefaultRemoteSignerPubkey  = os.Getenv("REMOTE_SIGNER_PUBKEY")
+	apiDefaultRemoteSignerTimeout = time.Duration(cli.GetEnvInt("REMOTE_SIGNER_TIMEOUT_MS", int(common.DefaultRemoteSignerTimeout.Milliseconds()))) * time.Millisecond
+	apiDefaultRemoteSignerMaxConc = cli.GetEnvInt("REMOTE_SIGNER_MAX_CONCURRENT", common.DefaultRemoteSignerMaxConcurrent)
+
+	// EIP-2335 keystore with the relay key, instead of the plaintext secret key
+	apiDefaultSecretKeyKeystore     = os.Getenv("SECRET_KEY_KEYSTORE")
+	apiDefaultSecretKeyPasswordFile = os.Getenv("SECRET_KEY_PASSWORD_FILE")
+
 	apiListenAddr   string
 	apiPprofEnabled bool
 	apiSecretKey    string
@@ -42,32 +62,142 @@ var (
 	apiInternalAPI  bool
 	apiProposerAPI  bool
 	apiLogTag       string
+	apiMinBid       string
+
+	apiReadTimeout       time.Duration
+	apiReadHeaderTimeout time.Duration
+	apiWriteTimeout      time.Duration
+	apiIdleTimeout       time.Duration
+
+	// Separate listeners of the API groups, served by the main listener without a listen address
+	apiProposerListener api.ListenerOpts
+	apiBuilderListener  api.ListenerOpts
+	apiDataListener     api.ListenerOpts
+
+	apiRemoteSignerURL     string
+	apiRemoteSignerPubkey  string
+	apiRemoteSignerTimeout time.Duration
+	apiRemoteSignerMaxConc int
+	apiMaxPayloadBytes     int
+
+	apiSecretKeyKeystore     string
+	apiSecretKeyPasswordFile string
+
+	apiRedisConnOpts datastore.RedisConnectionOpts
 )
 
 func init() {
 	rootCmd.AddCommand(apiCmd)
 	apiCmd.Flags().BoolVar(&logJSON, "json", defaultLogJSON, "log in JSON format instead of text")
+	flagEnv(apiCmd, "json", "LOG_JSON")
 	apiCmd.Flags().StringVar(&logLevel, "loglevel", defaultLogLevel, "log-level: trace, debug, info, warn/warning, error, fatal, panic")
+	flagEnv(apiCmd, "loglevel", "LOG_LEVEL")
 	apiCmd.Flags().StringVar(&apiLogTag, "log-tag", apiDefaultLogTag, "if set, a 'tag' field will be added to all log entries")
+	flagEnv(apiCmd, "log-tag", "LOG_TAG")
 	apiCmd.Flags().BoolVar(&apiDebug, "debug", false, "debug logging")
 
 	apiCmd.Flags().StringVar(&apiListenAddr, "listen-addr", apiDefaultListenAddr, "listen address for webserver")
+	flagEnv(apiCmd, "listen-addr", "LISTEN_ADDR")
 	apiCmd.Flags().StringSliceVar(&beaconNodeURIs, "beacon-uris", defaultBeaconURIs, "beacon endpoints")
+	flagEnv(apiCmd, "beacon-uris", "BEACON_URIS")
 	apiCmd.Flags().StringSliceVar(&beaconNodePublishURIs, "beacon-publish-uris", defaultBeaconPublishURIs, "beacon publish endpoints")
+	flagEnv(apiCmd, "beacon-publish-uris", "BEACON_PUBLISH_URIS")
 	apiCmd.Flags().StringVar(&redisURI, "redis-uri", defaultRedisURI, "redis uri")
+	flagEnv(apiCmd, "redis-uri", "REDIS_URI")
 	apiCmd.Flags().StringVar(&redisReadonlyURI, "redis-readonly-uri", defaultRedisReadonlyURI, "redis readonly uri")
+	flagEnv(apiCmd, "redis-readonly-uri", "REDIS_READONLY_URI")
+	apiCmd.Flags().IntVar(&apiRedisConnOpts.PoolSize, "redis-pool-size", datastore.DefaultRedisConnectionOpts.PoolSize, "redis connection pool size (0 for the go-redis default of 10 per CPU)")
+	flagEnv(apiCmd, "redis-pool-size", "REDIS_CONNECTION_POOL_SIZE")
+	apiCmd.Flags().IntVar(&apiRedisConnOpts.MinIdleConns, "redis-min-idle-conns", datastore.DefaultRedisConnectionOpts.MinIdleConns, "minimum number of idle redis connections")
+	flagEnv(apiCmd, "redis-min-idle-conns", "REDIS_MIN_IDLE_CONNECTIONS")
+	apiCmd.Flags().DurationVar(&apiRedisConnOpts.DialTimeout, "redis-dial-timeout", datastore.DefaultRedisConnectionOpts.DialTimeout, "redis dial timeout (0 for the go-redis default of 5s)")
+	flagEnv(apiCmd, "redis-dial-timeout", "REDIS_DIAL_TIMEOUT_SEC")
+	apiCmd.Flags().DurationVar(&apiRedisConnOpts.ReadTimeout, "redis-read-timeout", datastore.DefaultRedisConnectionOpts.ReadTimeout, "redis read timeout (0 for the go-redis default of 3s)")
+	flagEnv(apiCmd, "redis-read-timeout", "REDIS_READ_TIMEOUT_SEC")
+	apiCmd.Flags().DurationVar(&apiRedisConnOpts.WriteTimeout, "redis-write-timeout", datastore.DefaultRedisConnectionOpts.WriteTimeout, "redis write timeout (0 for the go-redis default of 3s)")
+	flagEnv(apiCmd, "redis-write-timeout", "REDIS_WRITE_TIMEOUT_SEC")
+	apiCmd.Flags().DurationVar(&apiRedisConnOpts.PoolTimeout, "redis-pool-timeout", datastore.DefaultRedisConnectionOpts.PoolTimeout, "how long to wait for a free redis connection (0 for the go-redis default of read timeout + 1s)")
+	flagEnv(apiCmd, "redis-pool-timeout", "REDIS_POOL_TIMEOUT_SEC")
 	apiCmd.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	flagEnv(apiCmd, "db", "POSTGRES_DSN")
+	apiCmd.Flags().StringVar(&postgresReadDSN, "db-readonly", defaultPostgresReadDSN, "PostgreSQL DSN of a read replica for the data API")
+	flagEnv(apiCmd, "db-readonly", "POSTGRES_READONLY_DSN")
 	apiCmd.Flags().StringSliceVar(&memcachedURIs, "memcached-uris", defaultMemcachedURIs,
 		"Enable memcached, typically used as secondary backup to Redis for redundancy")
+	flagEnv(apiCmd, "memcached-uris", "MEMCACHED_URIS")
 	apiCmd.Flags().StringVar(&apiSecretKey, "secret-key", apiDefaultSecretKey, "secret key for signing bids")
+	flagEnv(apiCmd, "secret-key", "SECRET_KEY")
+	apiCmd.Flags().StringVar(&apiSecretKeyKeystore, "secret-key-keystore", apiDefaultSecretKeyKeystore, "EIP-2335 keystore file with the secret key for signing bids, instead of the plaintext secret key")
+	flagEnv(apiCmd, "secret-key-keystore", "SECRET_KEY_KEYSTORE")
+	apiCmd.Flags().StringVar(&apiSecretKeyPasswordFile, "secret-key-password-file", apiDefaultSecretKeyPasswordFile, "file with the password of the keystore (default: SECRET_KEY_PASSWORD env var)")
+	flagEnv(apiCmd, "secret-key-password-file", "SECRET_KEY_PASSWORD_FILE")
+	apiCmd.Flags().StringVar(&apiRemoteSignerURL, "remote-signer-url", apiDefaultRemoteSignerURL, "URL of a Web3Signer compatible remote signer for signing bids, instead of the secret key")
+	flagEnv(apiCmd, "remote-signer-url", "REMOTE_SIGNER_URL")
+	apiCmd.Flags().StringVar(&apiRemoteSignerPubkey, "remote-signer-pubkey", apiDefaultRemoteSignerPubkey, "public key of the relay key in the remote signer")
+	flagEnv(apiCmd, "remote-signer-pubkey", "REMOTE_SIGNER_PUBKEY")
+	apiCmd.Flags().DurationVar(&apiRemoteSignerTimeout, "remote-signer-timeout", apiDefaultRemoteSignerTimeout, "timeout of signing requests to the remote signer")
+	flagEnv(apiCmd, "remote-signer-timeout", "REMOTE_SIGNER_TIMEOUT_MS")
+	apiCmd.Flags().IntVar(&apiRemoteSignerMaxConc, "remote-signer-max-concurrent", apiDefaultRemoteSignerMaxConc, "maximum number of concurrent signing requests to the remote signer")
+	flagEnv(apiCmd, "remote-signer-max-concurrent", "REMOTE_SIGNER_MAX_CONCURRENT")
 	apiCmd.Flags().StringVar(&apiBlockSimURL, "blocksim", apiDefaultBlockSim, "URL for block simulator")
+	flagEnv(apiCmd, "blocksim", "BLOCKSIM_URI")
 	apiCmd.Flags().StringVar(&network, "network", defaultNetwork, "Which network to use")
+	flagEnv(apiCmd, "network", "NETWORK")
+	apiCmd.Flags().StringVar(&apiMinBid, "min-bid", apiDefaultMinBid, "minimum bid value in ETH, getHeader responds with 204 for lower bids")
+	flagEnv(apiCmd, "min-bid", "MIN_BID_ETH")
 
 	apiCmd.Flags().BoolVar(&apiPprofEnabled, "pprof", apiDefaultPprofEnabled, "enable pprof API")
+	flagEnv(apiCmd, "pprof", "PPROF")
 	apiCmd.Flags().BoolVar(&apiBuilderAPI, "builder-api", apiDefaultBuilderAPIEnabled, "enable builder API (/builder/...)")
+	flagEnv(apiCmd, "builder-api", "DISABLE_BUILDER_API")
 	apiCmd.Flags().BoolVar(&apiDataAPI, "data-api", apiDefaultDataAPIEnabled, "enable data API (/data/...)")
+	flagEnv(apiCmd, "data-api", "DISABLE_DATA_API")
 	apiCmd.Flags().BoolVar(&apiInternalAPI, "internal-api", apiDefaultInternalAPIEnabled, "enable internal API (/internal/...)")
+	flagEnv(apiCmd, "internal-api", "ENABLE_INTERNAL_API")
 	apiCmd.Flags().BoolVar(&apiProposerAPI, "proposer-api", apiDefaultProposerAPIEnabled, "enable proposer API (/proposer/...)")
+	flagEnv(apiCmd, "proposer-api", "DISABLE_PROPOSER_API")
+
+	apiCmd.Flags().DurationVar(&apiReadTimeout, "http-read-timeout", apiDefaultReadTimeout, "http server read timeout")
+	flagEnv(apiCmd, "http-read-timeout", "API_TIMEOUT_READ_MS")
+	apiCmd.Flags().DurationVar(&apiReadHeaderTimeout, "http-read-header-timeout", apiDefaultReadHeaderTimeout, "http server read header timeout")
+	flagEnv(apiCmd, "http-read-header-timeout", "API_TIMEOUT_READHEADER_MS")
+	apiCmd.Flags().DurationVar(&apiWriteTimeout, "http-write-timeout", apiDefaultWriteTimeout, "http server write timeout")
+	flagEnv(apiCmd, "http-write-timeout", "API_TIMEOUT_WRITE_MS")
+	apiCmd.Flags().DurationVar(&apiIdleTimeout, "http-idle-timeout", apiDefaultIdleTimeout, "http server idle timeout")
+	flagEnv(apiCmd, "http-idle-timeout", "API_TIMEOUT_IDLE_MS")
+	apiCmd.Flags().IntVar(&apiMaxPayloadBytes, "http-max-payload-bytes", apiDefaultMaxPayloadBytes, "maximum size of request bodies in bytes")
+	flagEnv(apiCmd, "http-max-payload-bytes", "API_MAX_PAYLOAD_BYTES")
+
+	addAPIListenerFlags("proposer", "PROPOSER_API", &apiProposerListener)
+	addAPIListenerFlags("builder", "BUILDER_API", &apiBuilderListener)
+	addAPIListenerFlags("data", "DATA_API", &apiDataListener)
+}
+
+// addAPIListenerFlags adds the flags of the separate listener of an API group (i.e. --proposer-listen-addr and
+// --proposer-http-read-timeout), which default to the environment variables with the given prefix (i.e.
+// PROPOSER_API_LISTEN_ADDR and PROPOSER_API_TIMEOUT_READ_MS). Timeouts of 0 fall back to the main listener's.
+func addAPIListenerFlags(group, envPrefix string, opts *api.ListenerOpts) {
+	envTimeout := func(name string) time.Duration {
+		return time.Duration(cli.GetEnvInt(envPrefix+name, 0)) * time.Millisecond
+	}
+	apiCmd.Flags().StringVar(&opts.ListenAddr, group+"-listen-addr", os.Getenv(envPrefix+"_LISTEN_ADDR"), "separate listen address for the "+group+" API (default: the main listen address)")
+	apiCmd.Flags().DurationVar(&opts.ReadTimeout, group+"-http-read-timeout", envTimeout("_TIMEOUT_READ_MS"), "http server read timeout of the separate "+group+" API listener (default: --http-read-timeout)")
+	apiCmd.Flags().DurationVar(&opts.ReadHeaderTimeout, group+"-http-read-header-timeout", envTimeout("_TIMEOUT_READHEADER_MS"), "http server read header timeout of the separate "+group+" API listener (default: --http-read-header-timeout)")
+	apiCmd.Flags().DurationVar(&opts.WriteTimeout, group+"-http-write-timeout", envTimeout("_TIMEOUT_WRITE_MS"), "http server write timeout of the separate "+group+" API listener (default: --http-write-timeout)")
+	apiCmd.Flags().DurationVar(&opts.IdleTimeout, group+"-http-idle-timeout", envTimeout("_TIMEOUT_IDLE_MS"), "http server idle timeout of the separate "+group+" API listener (default: --http-idle-timeout)")
+	flagEnv(apiCmd, group+"-listen-addr", envPrefix+"_LISTEN_ADDR")
+	flagEnv(apiCmd, group+"-http-read-timeout", envPrefix+"_TIMEOUT_READ_MS")
+	flagEnv(apiCmd, group+"-http-read-header-timeout", envPrefix+"_TIMEOUT_READHEADER_MS")
+	flagEnv(apiCmd, group+"-http-write-timeout", envPrefix+"_TIMEOUT_WRITE_MS")
+	flagEnv(apiCmd, group+"-http-idle-timeout", envPrefix+"_TIMEOUT_IDLE_MS")
+}
+
+// apiListenerOpts returns the options of a separate listener for an API group, or nil without a listen address
+func apiListenerOpts(opts api.ListenerOpts) *api.ListenerOpts {
+	if opts.ListenAddr == "" {
+		return nil
+	}
+	return &opts
 }
 
 var apiCmd = &cobra.Command{
@@ -89,13 +219,6 @@ var apiCmd = &cobra.Command{
 		}
 		log.Infof("boost-relay %s", Version)
 
-		networkInfo, err := common.NewEthNetworkDetails(network)
-		if err != nil {
-			log.WithError(err).Fatalf("error getting network details")
-		}
-		log.Infof("Using network: %s", networkInfo.Name)
-		log.Debug(networkInfo.String())
-
 		// Connect to beacon clients and ensure it's synced
 		if len(beaconNodeURIs) == 0 {
 			log.Fatalf("no beacon endpoints specified")
@@ -116,16 +239,24 @@ var apiCmd = &cobra.Command{
 		}
 		beaconClient := beaconclient.NewMultiBeaconClient(log, beaconInstances)
 
+		networkInfo, err := beaconclient.NewEthNetworkDetails(network, beaconClient)
+		if err != nil {
+			log.WithError(err).Fatalf("error getting network details")
+		}
+		log.Infof("Using network: %s", networkInfo.Name)
+		log.Debug(networkInfo.String())
+
 		// Connect to Redis
 		if redisReadonlyURI == "" {
 			log.Infof("Connecting to Redis at %s ...", redisURI)
 		} else {
 			log.Infof("Connecting to Redis at %s / readonly: %s ...", redisURI, redisReadonlyURI)
 		}
-		redis, err := datastore.NewRedisCache(networkInfo.Name, redisURI, redisReadonlyURI)
+		redis, err := datastore.NewRedisCacheWithOpts(networkInfo.Name, redisURI, redisReadonlyURI, apiRedisConnOpts)
 		if err != nil {
 			log.WithError(err).Fatalf("Failed to connect to Redis at %s", redisURI)
 		}
+		redis.SetLog(log)
 
 		// Connect to Memcached if it exists
 		var mem *datastore.Memcached
@@ -147,6 +278,17 @@ var apiCmd = &cobra.Command{
 		if err != nil {
 			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
 		}
+		if postgresReadDSN != "" {
+			readDBURL, err := url.Parse(postgresReadDSN)
+			if err != nil {
+				log.WithError(err).Fatalf("couldn't read readonly db URL")
+			}
+			log.Infof("Connecting to Postgres read replica at %s%s ...", readDBURL.Host, readDBURL.Path)
+			err = db.ConnectReadReplica(postgresReadDSN)
+			if err != nil {
+				log.WithError(err).Fatalf("Failed to connect to Postgres read replica at %s%s", readDBURL.Host, readDBURL.Path)
+			}
+		}
 
 		log.Info("Setting up datastore...")
 		ds, err := datastore.NewDatastore(redis, mem, db)
@@ -170,21 +312,80 @@ var apiCmd = &cobra.Command{
 			InternalAPI:     apiInternalAPI,
 			ProposerAPI:     apiProposerAPI,
 			PprofAPI:        apiPprofEnabled,
+
+			ReadTimeout:       apiReadTimeout,
+			ReadHeaderTimeout: apiReadHeaderTimeout,
+			WriteTimeout:      apiWriteTimeout,
+			IdleTimeout:       apiIdleTimeout,
+			MaxPayloadBytes:   apiMaxPayloadBytes,
+
+			ProposerListener: apiListenerOpts(apiProposerListener),
+			BuilderListener:  apiListenerOpts(apiBuilderListener),
+			DataListener:     apiListenerOpts(apiDataListener),
 		}
 
-		// Decode the private key
-		if apiSecretKey == "" {
-			log.Warn("No secret key specified, block builder API is disabled")
-			opts.BlockBuilderAPI = false
-		} else {
+		// Parse the minimum bid value
+		minBid, err := common.EthStrToWei(apiMinBid)
+		if err != nil {
+			log.WithError(err).Fatal("invalid min-bid")
+		}
+		if !minBid.IsZero() {
+			log.Infof("Using minimum bid of %s ETH", apiMinBid)
+			opts.MinBid = minBid
+		}
+
+		// Set up the signer, with the remote signer, the keystore or the private key
+		numKeySources := 0
+		for _, source := range []string{apiRemoteSignerURL, apiSecretKeyKeystore, apiSecretKey} {
+			if source != "" {
+				numKeySources++
+			}
+		}
+		if numKeySources > 1 {
+			log.Fatal("only one of secret key, keystore and remote signer can be used")
+		}
+
+		switch {
+		case apiRemoteSignerURL != "":
+			remoteSignerPubkey, err := common.StrToPhase0Pubkey(apiRemoteSignerPubkey)
+			if err != nil {
+				log.WithError(err).Fatal("incorrect remote signer pubkey provided")
+			}
+			log.Infof("Using remote signer at %s", apiRemoteSignerURL)
+			opts.Signer = common.NewRemoteSigner(apiRemoteSignerURL, remoteSignerPubkey, apiRemoteSignerTimeout, apiRemoteSignerMaxConc)
+		case apiSecretKeyKeystore != "":
+			password := os.Getenv("SECRET_KEY_PASSWORD")
+			if apiSecretKeyPasswordFile != "" {
+				password, err = common.ReadPasswordFile(apiSecretKeyPasswordFile)
+				if err != nil {
+					log.WithError(err).Fatal("failed to read keystore password file")
+				}
+			}
+			sk, err := common.LoadKeystoreSecretKey(apiSecretKeyKeystore, password)
+			if err != nil {
+				log.WithError(err).Fatal("failed to load secret key from keystore")
+			}
+			log.Infof("Using secret key from keystore %s", apiSecretKeyKeystore)
+			opts.Signer, err = common.NewLocalSigner(sk)
+			if err != nil {
+				log.WithError(err).Fatal("incorrect builder API secret key provided")
+			}
+		case apiSecretKey != "":
 			envSkBytes, err := hexutil.Decode(apiSecretKey)
 			if err != nil {
 				log.WithError(err).Fatal("incorrect secret key provided")
 			}
-			opts.SecretKey, err = bls.SecretKeyFromBytes(envSkBytes[:])
+			sk, err := bls.SecretKeyFromBytes(envSkBytes[:])
+			if err != nil {
+				log.WithError(err).Fatal("incorrect builder API secret key provided")
+			}
+			opts.Signer, err = common.NewLocalSigner(sk)
 			if err != nil {
 				log.WithError(err).Fatal("incorrect builder API secret key provided")
 			}
+		default:
+			log.Warn("No secret key, keystore or remote signer specified, block builder API is disabled")
+			opts.BlockBuilderAPI = false
 		}
 
 		// Create the relay service
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	defaultConfigFile = os.Getenv("CONFIG_FILE")
+
+	configFile string
+)
+
+// flagEnvAnnotation is the annotation of a flag with the env var which sets the default of the flag
+const flagEnvAnnotation = "env"
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", defaultConfigFile, "YAML or TOML file with flag values, which env vars and flags take precedence over")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if configFile == "" {
+			return nil
+		}
+		return applyConfigFile(cmd, configFile)
+	}
+}
+
+// applyConfigFile sets the flags of the command to the values of the config file, unless they are set by a flag or
+// env var. Top-level keys apply to all commands which have the flag, and keys in a table named like the command (i.e.
+// api) only to the command, overriding top-level keys.
+func applyConfigFile(cmd *cobra.Command, path string) error {
+	config, err := common.ReadConfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	for key, value := range config {
+		if _, isTable := value.(map[string]any); isTable || cmd.Flags().Lookup(key) == nil {
+			continue
+		}
+		if err := setFlagFromConfig(cmd, key, value); err != nil {
+			return err
+		}
+	}
+
+	commandConfig, _ := config[cmd.Name()].(map[string]any)
+	for key, value := range commandConfig {
+		if cmd.Flags().Lookup(key) == nil {
+			return fmt.Errorf("%w: unknown flag %s of command %s", common.ErrInvalidConfigFile, key, cmd.Name())
+		}
+		if err := setFlagFromConfig(cmd, key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flagEnv annotates a flag with the env var which sets its default. Flags with their env var set are not changed by
+// the config file.
+func flagEnv(cmd *cobra.Command, name, envVar string) {
+	if err := cmd.Flags().SetAnnotation(name, flagEnvAnnotation, []string{envVar}); err != nil {
+		panic(err)
+	}
+}
+
+func setFlagFromConfig(cmd *cobra.Command, name string, value any) error {
+	flag := cmd.Flags().Lookup(name)
+	if flag.Changed {
+		return nil
+	}
+	for _, envVar := range flag.Annotations[flagEnvAnnotation] {
+		if os.Getenv(envVar) != "" {
+			return nil
+		}
+	}
+
+	if sliceValue, ok := flag.Value.(interface{ Replace(values []string) error }); ok {
+		values, isList := value.([]any)
+		if !isList {
+			values = []any{}
+			for _, v := range strings.Split(fmt.Sprint(value), ",") {
+				values = append(values, v)
+			}
+		}
+		strValues := make([]string, len(values))
+		for i, v := range values {
+			strValues[i] = strings.TrimSpace(fmt.Sprint(v))
+		}
+		return sliceValue.Replace(strValues)
+	} else if _, isList := value.([]any); isList {
+		return fmt.Errorf("%w: %s is not a list", common.ErrInvalidConfigFile, name)
+	}
+
+	if err := cmd.Flags().Set(name, fmt.Sprint(value)); err != nil {
+		return fmt.Errorf("%w: invalid value of %s: %w", common.ErrInvalidConfigFile, name, err)
+	}
+	// the value is from the config file, so the table of the command can still override a top-level value
+	flag.Changed = false
+	return nil
+}
@@ -25,16 +25,24 @@ var (
 func init() {
 	rootCmd.AddCommand(housekeeperCmd)
 	housekeeperCmd.Flags().BoolVar(&logJSON, "json", defaultLogJSON, "log in JSON format instead of text")
+	flagEnv(housekeeperCmd, "json", "LOG_JSON")
 	housekeeperCmd.Flags().StringVar(&logLevel, "loglevel", defaultLogLevel, "log-level: trace, debug, info, warn/warning, error, fatal, panic")
+	flagEnv(housekeeperCmd, "loglevel", "LOG_LEVEL")
 
 	housekeeperCmd.Flags().StringSliceVar(&beaconNodeURIs, "beacon-uris", defaultBeaconURIs, "beacon endpoints")
+	flagEnv(housekeeperCmd, "beacon-uris", "BEACON_URIS")
 	housekeeperCmd.Flags().StringVar(&redisURI, "redis-uri", defaultRedisURI, "redis uri")
+	flagEnv(housekeeperCmd, "redis-uri", "REDIS_URI")
 	housekeeperCmd.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	flagEnv(housekeeperCmd, "db", "POSTGRES_DSN")
 
 	housekeeperCmd.Flags().StringVar(&network, "network", defaultNetwork, "Which network to use")
+	flagEnv(housekeeperCmd, "network", "NETWORK")
 
 	housekeeperCmd.Flags().BoolVar(&hkPprofEnabled, "pprof", hkDefaultPprofEnabled, "enable pprof API")
+	flagEnv(housekeeperCmd, "pprof", "PPROF")
 	housekeeperCmd.Flags().StringVar(&hkPprofListenAddr, "pprof-listen-addr", hkDefaultPprofListenAddr, "listen address for pprof server")
+	flagEnv(housekeeperCmd, "pprof-listen-addr", "PPROF_LISTEN_ADDR")
 }
 
 var housekeeperCmd = &cobra.Command{
@@ -49,13 +57,6 @@ var housekeeperCmd = &cobra.Command{
 		})
 		log.Infof("boost-relay %s", Version)
 
-		networkInfo, err := common.NewEthNetworkDetails(network)
-		if err != nil {
-			log.WithError(err).Fatalf("error getting network details")
-		}
-		log.Infof("Using network: %s", networkInfo.Name)
-		log.Debug(networkInfo.String())
-
 		// Connect to beacon clients and ensure it's synced
 		if len(beaconNodeURIs) == 0 {
 			log.Fatalf("no beacon endpoints specified")
@@ -67,11 +68,19 @@ var housekeeperCmd = &cobra.Command{
 		}
 		beaconClient := beaconclient.NewMultiBeaconClient(log, beaconInstances)
 
+		networkInfo, err := beaconclient.NewEthNetworkDetails(network, beaconClient)
+		if err != nil {
+			log.WithError(err).Fatalf("error getting network details")
+		}
+		log.Infof("Using network: %s", networkInfo.Name)
+		log.Debug(networkInfo.String())
+
 		// Connect to Redis and setup the datastore
 		redis, err := datastore.NewRedisCache(networkInfo.Name, redisURI, "")
 		if err != nil {
 			log.WithError(err).Fatalf("Failed to connect to Redis at %s", redisURI)
 		}
+		redis.SetLog(log)
 
 		// Connect to Postgres
 		dbURL, err := url.Parse(postgresDSN)
@@ -84,9 +93,15 @@ var housekeeperCmd = &cobra.Command{
 			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
 		}
 
+		ds, err := datastore.NewDatastore(redis, nil, db)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed setting up prod datastore")
+		}
+
 		opts := &housekeeper.HousekeeperOpts{
 			Log:          log,
 			Redis:        redis,
+			Datastore:    ds,
 			DB:           db,
 			BeaconClient: beaconClient,
 
@@ -10,6 +10,11 @@ import (
 func init() {
 	toolCmd.AddCommand(tool.DataAPIExportPayloads)
 	toolCmd.AddCommand(tool.DataAPIExportBids)
+	toolCmd.AddCommand(tool.DataExport)
+	toolCmd.AddCommand(tool.DataArchive)
+	toolCmd.AddCommand(tool.Backcheck)
+	toolCmd.AddCommand(tool.Resimulate)
+	toolCmd.AddCommand(tool.CheckConsistency)
 	toolCmd.AddCommand(tool.ArchiveExecutionPayloads)
 	toolCmd.AddCommand(tool.Migrate)
 	rootCmd.AddCommand(toolCmd)
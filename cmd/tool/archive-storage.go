@@ -0,0 +1,164 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var ErrInvalidArchiveStorage = errors.New("invalid archive storage")
+
+// archiveStoragePartSize is the size of the parts of multipart uploads, which are buffered in memory one at a time
+const archiveStoragePartSize = 64 * 1024 * 1024
+
+// archiveStorage stores the archived files
+type archiveStorage interface {
+	// Create returns a writer for the named file
+	Create(ctx context.Context, name string) (archiveFile, error)
+
+	// Location returns where the named file is stored, as recorded in the archive manifest
+	Location(name string) string
+}
+
+// newArchiveStorage returns the storage of an s3://bucket/prefix URI, in the S3-compatible storage at the endpoint
+// (with the credentials of the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY env vars), or else of a local directory
+func newArchiveStorage(uri, endpoint string) (archiveStorage, error) {
+	bucketAndPrefix, isS3 := strings.CutPrefix(uri, "s3://")
+	if !isS3 {
+		return &localArchiveStorage{dir: uri}, os.MkdirAll(uri, 0o755)
+	}
+	bucket, prefix, _ := strings.Cut(bucketAndPrefix, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("%w: missing bucket in %s", ErrInvalidArchiveStorage, uri)
+	}
+
+	// the endpoint is a host, or a URL to choose between http and https
+	secure := true
+	if endpointURL, err := url.Parse(endpoint); err == nil && endpointURL.Host != "" {
+		secure = endpointURL.Scheme != "http"
+		endpoint = endpointURL.Host
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewEnvAWS(),
+		Secure: secure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchiveStorage, err)
+	}
+	return &s3ArchiveStorage{client: client, bucket: bucket, prefix: strings.Trim(prefix, "/")}, nil
+}
+
+// archiveFile is a file which is being written to the archive storage. It is only stored once it is closed, and is
+// discarded if it is closed with an error.
+type archiveFile interface {
+	io.Writer
+	Close() error
+	CloseWithError(err error) error
+}
+
+// localArchiveStorage stores the archived files in a local directory
+type localArchiveStorage struct {
+	dir string
+}
+
+func (s *localArchiveStorage) Create(ctx context.Context, name string) (archiveFile, error) {
+	filePath := filepath.Join(s.dir, name)
+	f, err := os.Create(filePath + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &localArchiveFile{f: f, path: filePath}, nil
+}
+
+func (s *localArchiveStorage) Location(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+// localArchiveFile is written to a temporary file, which is renamed to the file once it is complete
+type localArchiveFile struct {
+	f    *os.File
+	path string
+}
+
+func (f *localArchiveFile) Write(p []byte) (int, error) {
+	return f.f.Write(p)
+}
+
+func (f *localArchiveFile) Close() error {
+	if err := f.f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.f.Name(), f.path)
+}
+
+func (f *localArchiveFile) CloseWithError(err error) error {
+	_ = f.f.Close()
+	return os.Remove(f.f.Name())
+}
+
+// s3ArchiveStorage uploads the archived files to a bucket of S3-compatible object storage, like AWS S3, GCS (with HMAC
+// keys) or MinIO
+type s3ArchiveStorage struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func (s *s3ArchiveStorage) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+func (s *s3ArchiveStorage) Create(ctx context.Context, name string) (archiveFile, error) {
+	pr, pw := io.Pipe()
+	w := &s3ArchiveWriter{pw: pw, done: make(chan error, 1)}
+	go func() {
+		_, err := s.client.PutObject(ctx, s.bucket, s.key(name), pr, -1, minio.PutObjectOptions{
+			ContentType: "application/vnd.apache.parquet",
+			PartSize:    archiveStoragePartSize,
+		})
+		// unblock the writer if the upload failed before reading everything
+		pr.CloseWithError(err)
+		w.done <- err
+	}()
+	return w, nil
+}
+
+func (s *s3ArchiveStorage) Location(name string) string {
+	return "s3://" + s.bucket + "/" + s.key(name)
+}
+
+// s3ArchiveWriter streams a file to its upload, which completes when the writer is closed, and is aborted when it is
+// closed with an error
+type s3ArchiveWriter struct {
+	pw   *io.PipeWriter
+	done chan error
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func (w *s3ArchiveWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+func (w *s3ArchiveWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+func (w *s3ArchiveWriter) CloseWithError(err error) error {
+	w.closeOnce.Do(func() {
+		_ = w.pw.CloseWithError(err)
+		w.closeErr = <-w.done
+	})
+	return w.closeErr
+}
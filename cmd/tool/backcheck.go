@@ -0,0 +1,186 @@
+package tool
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/flashbots/mev-boost-relay/beaconclient"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/flashbots/mev-boost-relay/services/housekeeper"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// backcheckDefaultDelaySlots is how far behind the head slot the backcheck stops by default, to not record blocks
+// which could still be reorged
+const backcheckDefaultDelaySlots = 32
+
+var (
+	backcheckBeaconURIs []string
+	backcheckSlotFrom   uint64
+	backcheckSlotTo     uint64
+	backcheckRecheck    bool
+	backcheckBatchSize  uint64
+)
+
+func init() {
+	Backcheck.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	Backcheck.Flags().StringSliceVar(&backcheckBeaconURIs, "beacon-uris", defaultBeaconURIs, "beacon endpoints")
+	Backcheck.Flags().Uint64Var(&backcheckSlotFrom, "slot-from", 0, "start slot (inclusive)")
+	Backcheck.Flags().Uint64Var(&backcheckSlotTo, "slot-to", 0, "end slot (inclusive), defaults to 32 slots before the head slot")
+	Backcheck.Flags().BoolVar(&backcheckRecheck, "recheck", false, "also check the payloads which already have a landed status")
+	Backcheck.Flags().Uint64Var(&backcheckBatchSize, "batch-size", 1000, "number of payloads read from the DB at a time")
+}
+
+var Backcheck = &cobra.Command{
+	Use:   "backcheck",
+	Short: "check for the delivered payloads in the DB whether they landed on chain, and record their landed status",
+	Run: func(cmd *cobra.Command, args []string) {
+		if backcheckBatchSize == 0 {
+			log.Fatal("--batch-size must be greater than 0")
+		}
+
+		// Connect to the beacon nodes
+		if len(backcheckBeaconURIs) == 0 {
+			log.Fatal("no beacon endpoints specified")
+		}
+		log.Infof("Using beacon endpoints: %s", strings.Join(backcheckBeaconURIs, ", "))
+		var beaconInstances []beaconclient.IBeaconInstance
+		for _, uri := range backcheckBeaconURIs {
+			beaconInstances = append(beaconInstances, beaconclient.NewProdBeaconInstance(log, uri, uri))
+		}
+		beaconClient := beaconclient.NewMultiBeaconClient(log, beaconInstances)
+
+		if backcheckSlotTo == 0 {
+			syncStatus, err := beaconClient.BestSyncStatus()
+			if err != nil {
+				log.WithError(err).Fatal("couldn't get the head slot from the beacon nodes")
+			}
+			if syncStatus.HeadSlot <= backcheckDefaultDelaySlots {
+				log.Fatalf("head slot %d is too low to backcheck", syncStatus.HeadSlot)
+			}
+			backcheckSlotTo = syncStatus.HeadSlot - backcheckDefaultDelaySlots
+		}
+
+		// Connect to Postgres
+		dbURL, err := url.Parse(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("couldn't read db URL")
+		}
+		log.Infof("Connecting to Postgres database at %s%s ...", dbURL.Host, dbURL.Path)
+		db, err := database.NewDatabaseService(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
+		}
+
+		log.Infof("checking the delivered payloads of slots %d to %d", backcheckSlotFrom, backcheckSlotTo)
+		numByStatus := make(map[string]int)
+		numUnknown := 0
+		var earliestAvailableSlot uint64 // determined with the first slot without a block
+		isEarliestAvailableSlotKnown := false
+		afterID := int64(0)
+		for {
+			entries, err := db.GetDeliveredPayloadsForBackcheck(backcheckSlotFrom, backcheckSlotTo, afterID, !backcheckRecheck, backcheckBatchSize)
+			if err != nil {
+				log.WithError(err).Fatal("failed to get delivered payloads")
+			}
+			if len(entries) == 0 {
+				break
+			}
+
+			for _, entry := range entries {
+				log := log.WithFields(logrus.Fields{
+					"slot":      entry.Slot,
+					"blockHash": entry.BlockHash,
+				})
+
+				landedStatus, landedBlockHash, err := housekeeper.DeliveredPayloadLandedStatus(beaconClient, entry.Slot, entry.BlockHash)
+				if err != nil {
+					log.WithError(err).Fatal("failed to get block of delivered payload")
+				}
+
+				// pruned or non-archive beacon nodes don't have the blocks before their earliest available slot, so a
+				// slot without a block before it can't be told apart from a missed one, and is left unchecked
+				if landedStatus == database.PayloadLandedStatusMissed {
+					if !isEarliestAvailableSlotKnown {
+						earliestAvailableSlot, err = backcheckEarliestAvailableSlot(beaconClient, backcheckSlotTo)
+						if err != nil {
+							log.WithError(err).Fatal("failed to determine the earliest available slot of the beacon nodes")
+						}
+						log.Infof("earliest available slot of the beacon nodes: %d", earliestAvailableSlot)
+						isEarliestAvailableSlotKnown = true
+					}
+					if entry.Slot < earliestAvailableSlot {
+						log.Warn("slot is before the earliest available slot of the beacon nodes, landed status unknown")
+						numUnknown++
+						afterID = entry.ID
+						continue
+					}
+				}
+
+				err = db.SetDeliveredPayloadLandedStatus(entry.ID, landedStatus, landedBlockHash)
+				if err != nil {
+					log.WithError(err).Fatal("failed to save landed status of delivered payload")
+				}
+
+				if landedStatus != database.PayloadLandedStatusLanded {
+					log.WithFields(logrus.Fields{
+						"landedStatus":    landedStatus,
+						"landedBlockHash": landedBlockHash,
+					}).Info("delivered payload did not land on chain")
+				}
+				numByStatus[landedStatus]++
+				afterID = entry.ID
+			}
+			log.Infof("checked %d payloads, up to slot %d", len(entries), entries[len(entries)-1].Slot)
+		}
+
+		log.WithFields(logrus.Fields{
+			"landed":   numByStatus[database.PayloadLandedStatusLanded],
+			"missed":   numByStatus[database.PayloadLandedStatusMissed],
+			"replaced": numByStatus[database.PayloadLandedStatusReplaced],
+			"unknown":  numUnknown,
+		}).Info("backcheck done")
+	},
+}
+
+// backcheckEarliestAvailableSlot returns the first slot up to slotTo for which the beacon nodes have a block. It is
+// found with a binary search for the first epoch-long window with a block, as single slots may have been missed.
+func backcheckEarliestAvailableSlot(beaconClient beaconclient.IMultiBeaconClient, slotTo uint64) (uint64, error) {
+	// firstBlockInWindow returns the slot of the first block in the window starting at slot, if any
+	firstBlockInWindow := func(slot uint64) (uint64, bool, error) {
+		for s := slot; s < slot+common.SlotsPerEpoch && s <= slotTo; s++ {
+			_, err := beaconClient.GetBlockBySlot(s)
+			if errors.Is(err, beaconclient.ErrBlockNotFound) {
+				continue
+			} else if err != nil {
+				return 0, false, err
+			}
+			return s, true, nil
+		}
+		return 0, false, nil
+	}
+
+	lo, hi := uint64(0), slotTo
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		_, found, err := firstBlockInWindow(mid)
+		if err != nil {
+			return 0, err
+		}
+		if found {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	slot, found, err := firstBlockInWindow(lo)
+	if err != nil {
+		return 0, err
+	} else if !found {
+		return slotTo + 1, nil
+	}
+	return slot, nil
+}
@@ -0,0 +1,231 @@
+package tool
+
+import (
+	"context"
+	"net/url"
+	"os"
+
+	"github.com/flashbots/mev-boost-relay/beaconclient"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// checkConsistencyRepairBatchSize is the number of registration timestamps written to Redis at once when repairing
+const checkConsistencyRepairBatchSize = 10000
+
+var (
+	checkConsistencyRedisURI string
+	checkConsistencyNetwork  string
+	checkConsistencyBeacons  []string
+	checkConsistencyRepair   bool
+)
+
+func init() {
+	CheckConsistency.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	CheckConsistency.Flags().StringVar(&checkConsistencyRedisURI, "redis-uri", common.GetEnv("REDIS_URI", "localhost:6379"), "redis uri")
+	CheckConsistency.Flags().StringVar(&checkConsistencyNetwork, "network", common.GetEnv("NETWORK", ""), "Which network to use")
+	CheckConsistency.Flags().StringSliceVar(&checkConsistencyBeacons, "beacon-uris", defaultBeaconURIs, "beacon endpoints, only used to load the network details with --network beacon")
+	CheckConsistency.Flags().BoolVar(&checkConsistencyRepair, "repair", false, "repair the drift which can be repaired from the DB")
+}
+
+var CheckConsistency = &cobra.Command{
+	Use:   "check-consistency",
+	Short: "compare the validator registrations, known validators and builder statuses in Redis with the DB, and report (or repair) drift",
+	Run: func(cmd *cobra.Command, args []string) {
+		var beaconClient beaconclient.IMultiBeaconClient
+		if checkConsistencyNetwork == common.EthNetworkBeacon {
+			var beaconInstances []beaconclient.IBeaconInstance
+			for _, uri := range checkConsistencyBeacons {
+				beaconInstances = append(beaconInstances, beaconclient.NewProdBeaconInstance(log, uri, uri))
+			}
+			beaconClient = beaconclient.NewMultiBeaconClient(log, beaconInstances)
+		}
+		networkInfo, err := beaconclient.NewEthNetworkDetails(checkConsistencyNetwork, beaconClient)
+		if err != nil {
+			log.WithError(err).Fatalf("error getting network details")
+		}
+
+		redis, err := datastore.NewRedisCache(networkInfo.Name, checkConsistencyRedisURI, "")
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to connect to Redis at %s", checkConsistencyRedisURI)
+		}
+
+		// Connect to Postgres
+		dbURL, err := url.Parse(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("couldn't read db URL")
+		}
+		log.Infof("Connecting to Postgres database at %s%s ...", dbURL.Host, dbURL.Path)
+		db, err := database.NewDatabaseService(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
+		}
+
+		registrations, err := db.GetLatestValidatorRegistrations(true)
+		if err != nil {
+			log.WithError(err).Fatal("failed to get latest validator registrations")
+		}
+		dbTimestamps := make(map[common.PubkeyHex]uint64, len(registrations))
+		for _, reg := range registrations {
+			dbTimestamps[common.NewPubkeyHex(reg.Pubkey)] = reg.Timestamp
+		}
+
+		numUnrepaired := checkRegistrationTimestamps(redis, dbTimestamps)
+		numUnrepaired += checkKnownValidators(redis, dbTimestamps)
+		numUnrepaired += checkBuilderStatuses(redis, db)
+		if numUnrepaired > 0 {
+			log.Warnf("found %d inconsistencies which were not repaired", numUnrepaired)
+			os.Exit(1)
+		}
+		log.Info("Redis is consistent with the DB")
+	},
+}
+
+// checkRegistrationTimestamps compares the registration timestamps in Redis with the latest registrations in the DB.
+// Timestamps missing in Redis, or older than in the DB, are restored with --repair. Newer timestamps in Redis are
+// registrations which weren't saved to the DB, and are only reported. It returns the number of unrepaired inconsistencies.
+func checkRegistrationTimestamps(redis *datastore.RedisCache, dbTimestamps map[common.PubkeyHex]uint64) (numUnrepaired int) {
+	redisTimestamps := make(map[common.PubkeyHex]uint64, len(dbTimestamps))
+	_, err := redis.StreamValidatorRegistrationTimestamps(context.Background(), func(pubkey common.PubkeyHex, timestamp uint64) {
+		redisTimestamps[pubkey] = timestamp
+	})
+	if err != nil {
+		log.WithError(err).Fatal("failed to get validator registration timestamps from Redis")
+	}
+
+	var numMissing, numOlder, numNewer int
+	outdated := make(map[common.PubkeyHex]uint64)
+	for pubkey, timestamp := range dbTimestamps {
+		redisTimestamp, ok := redisTimestamps[pubkey]
+		switch {
+		case !ok:
+			numMissing++
+		case redisTimestamp < timestamp:
+			numOlder++
+		default:
+			continue
+		}
+		outdated[pubkey] = timestamp
+	}
+	for pubkey, timestamp := range redisTimestamps {
+		if dbTimestamp, ok := dbTimestamps[pubkey]; !ok || dbTimestamp < timestamp {
+			numNewer++
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"numDB":                 len(dbTimestamps),
+		"numRedis":              len(redisTimestamps),
+		"numMissingInRedis":     numMissing,
+		"numOlderInRedis":       numOlder,
+		"numNewerOrMissingInDB": numNewer,
+	}).Info("checked validator registrations")
+
+	if !checkConsistencyRepair {
+		return len(outdated) + numNewer
+	}
+	batch := make(map[common.PubkeyHex]uint64, checkConsistencyRepairBatchSize)
+	for pubkey, timestamp := range outdated {
+		batch[pubkey] = timestamp
+		if len(batch) < checkConsistencyRepairBatchSize {
+			continue
+		}
+		if err := redis.SetValidatorRegistrationTimestampsIfNewer(batch); err != nil {
+			log.WithError(err).Fatal("failed to repair validator registration timestamps")
+		}
+		clear(batch)
+	}
+	if err := redis.SetValidatorRegistrationTimestampsIfNewer(batch); err != nil {
+		log.WithError(err).Fatal("failed to repair validator registration timestamps")
+	}
+	return numNewer
+}
+
+// checkKnownValidators reports the registered validators which are not among the known validators in Redis. The known
+// validators are loaded from the beacon node (and published to Redis by the API or the housekeeper), so they can't be
+// repaired from the DB. Validators which exited after registering are expected here. It returns the number of
+// unrepaired inconsistencies, which is only non-zero if there are no known validators at all.
+func checkKnownValidators(redis *datastore.RedisCache, dbTimestamps map[common.PubkeyHex]uint64) (numUnrepaired int) {
+	knownPubkeys := make(map[common.PubkeyHex]struct{})
+	_, err := redis.StreamKnownValidators(context.Background(), nil, func(index uint64, pubkey common.PubkeyHex) {
+		knownPubkeys[pubkey] = struct{}{}
+	})
+	if err != nil {
+		log.WithError(err).Fatal("failed to get known validators from Redis")
+	}
+
+	numUnknown := 0
+	for pubkey := range dbTimestamps {
+		if _, ok := knownPubkeys[pubkey]; !ok {
+			numUnknown++
+		}
+	}
+	log.WithFields(logrus.Fields{
+		"numKnown":              len(knownPubkeys),
+		"numRegisteredNotKnown": numUnknown,
+	}).Info("checked known validators")
+
+	if len(knownPubkeys) == 0 && len(dbTimestamps) > 0 {
+		log.Warn("there are no known validators in Redis, they are published again on the next refresh by the API or the housekeeper")
+		return 1
+	}
+	return 0
+}
+
+// checkBuilderStatuses compares the builder statuses published in Redis with the DB. Both are written together, so a
+// differing status in Redis is stale and is deleted with --repair (the API then uses the status from the DB). It returns
+// the number of unrepaired inconsistencies.
+func checkBuilderStatuses(redis *datastore.RedisCache, db database.IDatabaseService) (numUnrepaired int) {
+	builders, err := db.GetBlockBuilders()
+	if err != nil {
+		log.WithError(err).Fatal("failed to get block builders")
+	}
+	dbStatuses := make(map[string]common.BuilderStatus, len(builders))
+	for _, builder := range builders {
+		dbStatuses[builder.BuilderPubkey] = common.BuilderStatus{
+			IsHighPrio:    builder.IsHighPrio,
+			IsBlacklisted: builder.IsBlacklisted,
+			IsOptimistic:  builder.IsOptimistic,
+		}
+	}
+
+	redisStatuses, err := redis.GetBlockBuilderStatuses()
+	if err != nil {
+		log.WithError(err).Fatal("failed to get builder statuses from Redis")
+	}
+
+	numStale := 0
+	for builderPubkey, status := range redisStatuses {
+		dbStatus, ok := dbStatuses[builderPubkey]
+		if ok && dbStatus == status {
+			continue
+		}
+		numStale++
+		log := log.WithFields(logrus.Fields{
+			"builderPubkey": builderPubkey,
+			"redisStatus":   status,
+			"dbStatus":      dbStatus,
+			"inDB":          ok,
+		})
+		if !checkConsistencyRepair {
+			log.Warn("builder status in Redis differs from the DB")
+			continue
+		}
+		if err := redis.DelBlockBuilderStatus(builderPubkey); err != nil {
+			log.WithError(err).Fatal("failed to delete stale builder status")
+		}
+		log.Info("deleted stale builder status in Redis")
+	}
+	log.WithFields(logrus.Fields{
+		"numRedis": len(redisStatuses),
+		"numStale": numStale,
+	}).Info("checked builder statuses")
+
+	if checkConsistencyRepair {
+		return 0
+	}
+	return numStale
+}
@@ -6,6 +6,7 @@ import "github.com/flashbots/mev-boost-relay/common"
 var (
 	log                = common.LogSetup(false, "info")
 	defaultPostgresDSN = common.GetEnv("POSTGRES_DSN", "")
+	defaultBeaconURIs  = common.GetSliceEnv("BEACON_URIS", []string{"http://localhost:3500"})
 
 	postgresDSN string
 	outFiles    []string
@@ -0,0 +1,172 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/flashbots/mev-boost-relay/cmd/tool/parquet"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	"github.com/spf13/cobra"
+)
+
+var ErrArchiveNoIDColumn = errors.New("archived table has no id column")
+
+var (
+	archiveTableName  string
+	archiveTo         string
+	archiveS3Endpoint string
+	archiveBatchSize  uint64
+
+	// archiveTables are the tables which can be archived, by the name of the --table flag
+	archiveTables = map[string]string{
+		"payloads": vars.TableDeliveredPayload,
+		"bids":     vars.TableBuilderBlockSubmission,
+	}
+)
+
+func init() {
+	DataArchive.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	DataArchive.Flags().StringVar(&archiveTableName, "table", "bids", "table to archive: payloads (delivered payloads) or bids (block submissions)")
+	DataArchive.Flags().Uint64Var(&slotFrom, "slot-from", 0, "start slot (inclusive)")
+	DataArchive.Flags().Uint64Var(&slotTo, "slot-to", 0, "end slot (inclusive)")
+	DataArchive.Flags().StringVar(&archiveTo, "to", "", "s3://bucket/prefix to upload the archive to S3-compatible storage, or else a local directory")
+	DataArchive.Flags().StringVar(&archiveS3Endpoint, "s3-endpoint", common.GetEnv("ARCHIVE_S3_ENDPOINT", "s3.amazonaws.com"), "endpoint of the S3-compatible storage, e.g. storage.googleapis.com for GCS, or http://localhost:9000 for a local MinIO")
+	DataArchive.Flags().Uint64Var(&archiveBatchSize, "batch-size", 10_000, "number of rows read from the DB at a time, and written as one Parquet row group")
+	_ = DataArchive.MarkFlagRequired("to")
+}
+
+var DataArchive = &cobra.Command{
+	Use:   "data-archive",
+	Short: "archive all columns of the delivered payloads or block submissions of a slot range as Parquet, and record it in the archive manifest",
+	Run: func(cmd *cobra.Command, args []string) {
+		tableName, ok := archiveTables[archiveTableName]
+		if !ok {
+			log.Fatalf("unknown table %s, must be payloads or bids", archiveTableName)
+		}
+		if slotFrom == 0 || slotTo < slotFrom {
+			log.Fatal("must specify --slot-from and --slot-to")
+		}
+		if archiveBatchSize == 0 {
+			log.Fatal("--batch-size must be greater than 0")
+		}
+
+		storage, err := newArchiveStorage(archiveTo, archiveS3Endpoint)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to open the archive storage %s", archiveTo)
+		}
+
+		// Connect to Postgres
+		dbURL, err := url.Parse(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("couldn't read db URL")
+		}
+		log.Infof("Connecting to Postgres database at %s%s ...", dbURL.Host, dbURL.Path)
+		db, err := database.NewDatabaseService(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
+		}
+
+		name := fmt.Sprintf("%s_slot-%d-to-%d.parquet", tableName, slotFrom, slotTo)
+		log.Infof("archiving slots %d to %d of %s to %s", slotFrom, slotTo, tableName, storage.Location(name))
+		numRows, err := archiveTable(cmd.Context(), db, storage, tableName, name)
+		if err != nil {
+			log.WithError(err).Fatalf("failed to archive to %s", storage.Location(name))
+		}
+		log.Infof("Wrote %d rows to %s", numRows, storage.Location(name))
+
+		// the slots can only be pruned once the file was stored completely
+		err = db.InsertArchiveManifestEntry(database.ArchiveManifestEntry{
+			TableName: tableName,
+			SlotFrom:  slotFrom,
+			SlotTo:    slotTo,
+			NumRows:   numRows,
+			Location:  storage.Location(name),
+		})
+		if err != nil {
+			log.WithError(err).Fatal("failed to record the archived slots")
+		}
+		log.Infof("recorded slots %d to %d of %s as archived", slotFrom, slotTo, tableName)
+	},
+}
+
+// archiveTable writes all columns of all rows of the slot range to the named Parquet file, in batches by id. The file is
+// discarded if it can't be written completely.
+func archiveTable(ctx context.Context, db database.IDatabaseService, storage archiveStorage, tableName, name string) (numRows uint64, err error) {
+	f, err := storage.Create(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.CloseWithError(err)
+		}
+	}()
+
+	var pw *parquet.Writer
+	idColumn := -1
+	lastID, ok := int64(0), true
+	for {
+		dbColumns, rows, err := db.GetArchiveRows(tableName, slotFrom, slotTo, lastID, archiveBatchSize)
+		if err != nil {
+			return numRows, err
+		}
+
+		// the columns are only known with the first batch
+		if pw == nil {
+			columns := make([]parquet.Column, len(dbColumns))
+			for i, dbColumn := range dbColumns {
+				columns[i] = archiveColumn(dbColumn)
+				if columns[i].Name == "id" {
+					idColumn = i
+				}
+			}
+			if idColumn < 0 {
+				return 0, fmt.Errorf("%w: %s", ErrArchiveNoIDColumn, tableName)
+			}
+			pw, err = parquet.NewWriter(f, columns, int(archiveBatchSize)) //nolint:gosec
+			if err != nil {
+				return 0, err
+			}
+		}
+		if len(rows) == 0 {
+			break
+		}
+
+		for _, row := range rows {
+			if err := pw.Write(row); err != nil {
+				return numRows, err
+			}
+		}
+		numRows += uint64(len(rows))
+		lastID, ok = rows[len(rows)-1][idColumn].(int64)
+		if !ok {
+			return numRows, fmt.Errorf("%w: %s", ErrArchiveNoIDColumn, tableName)
+		}
+		log.Infof("archived %d rows, up to id %d", numRows, lastID)
+	}
+
+	if err = pw.Close(); err != nil {
+		return numRows, err
+	}
+	return numRows, f.Close()
+}
+
+// archiveColumn returns the Parquet column for a database column. All columns are optional, as the nullability of the
+// database columns isn't known, and the types without a Parquet equivalent (like numeric) are archived as strings.
+func archiveColumn(dbColumn database.ArchiveColumn) parquet.Column {
+	column := parquet.Column{Name: dbColumn.Name, Type: parquet.String, Optional: true}
+	switch strings.ToUpper(dbColumn.DBType) {
+	case "INT2", "INT4", "INT8":
+		column.Type = parquet.Int64
+	case "BOOL":
+		column.Type = parquet.Bool
+	case "TIMESTAMP", "TIMESTAMPTZ":
+		column.Type = parquet.TimestampMicros
+	}
+	return column
+}
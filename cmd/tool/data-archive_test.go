@@ -0,0 +1,133 @@
+package tool
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/stretchr/testify/require"
+)
+
+var errArchiveTestDB = errors.New("archive test db error")
+
+// archiveTestDB returns the rows by id, and fails after the first batch if failAfterFirstBatch is set
+type archiveTestDB struct {
+	database.MockDB
+	rows                [][]any
+	failAfterFirstBatch bool
+}
+
+func (db archiveTestDB) GetArchiveRows(tableName string, slotFrom, slotTo uint64, afterID int64, limit uint64) (columns []database.ArchiveColumn, rows [][]any, err error) {
+	if db.failAfterFirstBatch && afterID > 0 {
+		return nil, nil, errArchiveTestDB
+	}
+	columns = []database.ArchiveColumn{
+		{Name: "id", DBType: "INT8"},
+		{Name: "inserted_at", DBType: "TIMESTAMP"},
+		{Name: "sim_success", DBType: "BOOL"},
+		{Name: "value", DBType: "NUMERIC"},
+	}
+	for _, row := range db.rows {
+		if row[0].(int64) > afterID && uint64(len(rows)) < limit {
+			rows = append(rows, row)
+		}
+	}
+	return columns, rows, nil
+}
+
+func TestArchiveTable(t *testing.T) {
+	prevSlotFrom, prevSlotTo, prevBatchSize := slotFrom, slotTo, archiveBatchSize
+	t.Cleanup(func() { slotFrom, slotTo, archiveBatchSize = prevSlotFrom, prevSlotTo, prevBatchSize })
+	slotFrom, slotTo, archiveBatchSize = 1, 10, 2
+	insertedAt := time.Now()
+	db := archiveTestDB{rows: [][]any{
+		{int64(1), insertedAt, true, "100"},
+		{int64(2), insertedAt, nil, "200"},
+		{int64(3), insertedAt, false, nil},
+	}}
+
+	t.Run("local directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "archive")
+		storage, err := newArchiveStorage(dir, "")
+		require.NoError(t, err)
+
+		numRows, err := archiveTable(t.Context(), db, storage, "bids", "bids.parquet")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), numRows)
+		require.Equal(t, filepath.Join(dir, "bids.parquet"), storage.Location("bids.parquet"))
+
+		file, err := os.ReadFile(filepath.Join(dir, "bids.parquet"))
+		require.NoError(t, err)
+		require.Equal(t, []byte("PAR1"), file[:4])
+		require.Equal(t, []byte("PAR1"), file[len(file)-4:])
+		_, err = os.Stat(filepath.Join(dir, "bids.parquet.tmp"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+
+		// an incomplete archive is discarded
+		db := db
+		db.failAfterFirstBatch = true
+		_, err = archiveTable(t.Context(), db, storage, "bids", "failed.parquet")
+		require.ErrorIs(t, err, errArchiveTestDB)
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("s3", func(t *testing.T) {
+		// a minimal S3 API for the multipart uploads of files of unknown size
+		var lock sync.Mutex
+		parts := make(map[string][]byte)
+		objects := make(map[string][]byte)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			lock.Lock()
+			defer lock.Unlock()
+			query := req.URL.Query()
+			switch {
+			case req.Method == http.MethodGet && query.Has("location"):
+				_, _ = w.Write([]byte(`<LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`))
+			case req.Method == http.MethodPost && query.Has("uploads"):
+				_, _ = w.Write([]byte(`<InitiateMultipartUploadResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><UploadId>upload</UploadId></InitiateMultipartUploadResult>`))
+			case req.Method == http.MethodPut && query.Get("uploadId") == "upload":
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				parts[query.Get("partNumber")] = body
+				w.Header().Set("ETag", `"part"`)
+			case req.Method == http.MethodPost && query.Get("uploadId") == "upload":
+				require.Len(t, parts, 1)
+				objects[req.URL.Path] = parts["1"]
+				_, _ = w.Write([]byte(`<CompleteMultipartUploadResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Bucket>archive</Bucket><Key>relay/bids.parquet</Key><ETag>"object"</ETag></CompleteMultipartUploadResult>`))
+			default:
+				w.WriteHeader(http.StatusNotImplemented)
+			}
+		}))
+		defer server.Close()
+
+		storage, err := newArchiveStorage("s3://archive/relay/", server.URL)
+		require.NoError(t, err)
+		require.Equal(t, "s3://archive/relay/bids.parquet", storage.Location("bids.parquet"))
+
+		numRows, err := archiveTable(t.Context(), db, storage, "bids", "bids.parquet")
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), numRows)
+
+		lock.Lock()
+		defer lock.Unlock()
+		require.Contains(t, objects, "/archive/relay/bids.parquet")
+		file := objects["/archive/relay/bids.parquet"]
+		require.True(t, bytes.HasPrefix(file, []byte("PAR1")))
+		require.True(t, bytes.HasSuffix(file, []byte("PAR1")))
+	})
+
+	t.Run("invalid storage", func(t *testing.T) {
+		_, err := newArchiveStorage("s3://", "")
+		require.ErrorIs(t, err, ErrInvalidArchiveStorage)
+	})
+}
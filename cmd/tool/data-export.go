@@ -0,0 +1,271 @@
+package tool
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/cmd/tool/parquet"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportTableName string
+	exportBatchSize uint64
+)
+
+func init() {
+	DataExport.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	DataExport.Flags().StringVar(&exportTableName, "table", "payloads", "table to export: payloads (delivered payloads) or bids (block submissions)")
+	DataExport.Flags().Uint64Var(&idFirst, "id-from", 0, "start id (inclusive)")
+	DataExport.Flags().Uint64Var(&idLast, "id-to", 0, "end id (inclusive)")
+	DataExport.Flags().StringVar(&dateStart, "date-start", "", "start date in UTC, as YYYY-MM-DD (inclusive)")
+	DataExport.Flags().StringVar(&dateEnd, "date-end", "", "end date in UTC, as YYYY-MM-DD (exclusive)")
+	DataExport.Flags().StringSliceVar(&outFiles, "out", []string{}, "output filename, ending in .csv or .parquet")
+	DataExport.Flags().Uint64Var(&exportBatchSize, "batch-size", 10_000, "number of rows read from the DB at a time")
+	_ = DataExport.MarkFlagRequired("out")
+}
+
+// exportTable describes the columns of an exported table, and how to read the next batch of its rows
+type exportTable struct {
+	columns []parquet.Column
+	fetch   func(db database.IDatabaseService, filters database.ExportFilters) (rows [][]any, lastID int64, err error)
+}
+
+var exportTables = map[string]exportTable{
+	"payloads": {
+		columns: []parquet.Column{
+			{Name: "id", Type: parquet.Int64},
+			{Name: "inserted_at", Type: parquet.TimestampMillis},
+			{Name: "signed_at", Type: parquet.TimestampMillis, Optional: true},
+			{Name: "slot", Type: parquet.Int64},
+			{Name: "epoch", Type: parquet.Int64},
+			{Name: "builder_pubkey", Type: parquet.String},
+			{Name: "proposer_pubkey", Type: parquet.String},
+			{Name: "proposer_fee_recipient", Type: parquet.String},
+			{Name: "parent_hash", Type: parquet.String},
+			{Name: "block_hash", Type: parquet.String},
+			{Name: "block_number", Type: parquet.Int64},
+			{Name: "num_tx", Type: parquet.Int64},
+			{Name: "value", Type: parquet.String},
+			{Name: "num_blobs", Type: parquet.Int64},
+			{Name: "blob_gas_used", Type: parquet.Int64},
+			{Name: "excess_blob_gas", Type: parquet.Int64},
+			{Name: "gas_used", Type: parquet.Int64},
+			{Name: "gas_limit", Type: parquet.Int64},
+			{Name: "publish_ms", Type: parquet.Int64},
+			{Name: "landed_status", Type: parquet.String},
+			{Name: "landed_block_hash", Type: parquet.String},
+			{Name: "adjusted_value", Type: parquet.String, Optional: true},
+		},
+		fetch: func(db database.IDatabaseService, filters database.ExportFilters) (rows [][]any, lastID int64, err error) {
+			entries, err := db.GetDeliveredPayloadsForExport(filters)
+			for _, e := range entries {
+				rows = append(rows, []any{
+					e.ID, e.InsertedAt, nullTime(e.SignedAt), e.Slot, e.Epoch,
+					e.BuilderPubkey, e.ProposerPubkey, e.ProposerFeeRecipient, e.ParentHash, e.BlockHash,
+					e.BlockNumber, e.NumTx, string(e.Value), e.NumBlobs, e.BlobGasUsed, e.ExcessBlobGas,
+					e.GasUsed, e.GasLimit, e.PublishMs, e.LandedStatus, e.LandedBlockHash, nullString(e.AdjustedValue),
+				})
+				lastID = e.ID
+			}
+			return rows, lastID, err
+		},
+	},
+	"bids": {
+		columns: []parquet.Column{
+			{Name: "id", Type: parquet.Int64},
+			{Name: "inserted_at", Type: parquet.TimestampMillis},
+			{Name: "received_at", Type: parquet.TimestampMillis, Optional: true},
+			{Name: "eligible_at", Type: parquet.TimestampMillis, Optional: true},
+			{Name: "slot", Type: parquet.Int64},
+			{Name: "epoch", Type: parquet.Int64},
+			{Name: "builder_pubkey", Type: parquet.String},
+			{Name: "proposer_pubkey", Type: parquet.String},
+			{Name: "proposer_fee_recipient", Type: parquet.String},
+			{Name: "parent_hash", Type: parquet.String},
+			{Name: "block_hash", Type: parquet.String},
+			{Name: "block_number", Type: parquet.Int64},
+			{Name: "num_tx", Type: parquet.Int64},
+			{Name: "value", Type: parquet.String},
+			{Name: "block_value", Type: parquet.String, Optional: true},
+			{Name: "gas_used", Type: parquet.Int64},
+			{Name: "gas_limit", Type: parquet.Int64},
+			{Name: "was_simulated", Type: parquet.Bool},
+			{Name: "sim_success", Type: parquet.Bool},
+			{Name: "sim_error", Type: parquet.String},
+			{Name: "optimistic_submission", Type: parquet.Bool},
+			{Name: "adjusted_value", Type: parquet.String, Optional: true},
+		},
+		fetch: func(db database.IDatabaseService, filters database.ExportFilters) (rows [][]any, lastID int64, err error) {
+			entries, err := db.GetBuilderSubmissionsForExport(filters)
+			for _, e := range entries {
+				rows = append(rows, []any{
+					e.ID, e.InsertedAt, nullTime(e.ReceivedAt), nullTime(e.EligibleAt), e.Slot, e.Epoch,
+					e.BuilderPubkey, e.ProposerPubkey, e.ProposerFeeRecipient, e.ParentHash, e.BlockHash,
+					e.BlockNumber, e.NumTx, string(e.Value), nullString(e.BlockValue), e.GasUsed, e.GasLimit,
+					e.WasSimulated, e.SimSuccess, e.SimError, e.OptimisticSubmission, nullString(e.AdjustedValue),
+				})
+				lastID = e.ID
+			}
+			return rows, lastID, err
+		},
+	},
+}
+
+func nullTime(t sql.NullTime) any {
+	if !t.Valid {
+		return nil
+	}
+	return t.Time
+}
+
+func nullString(s sql.NullString) any {
+	if !s.Valid {
+		return nil
+	}
+	return s.String
+}
+
+// exportWriter writes rows to an output file
+type exportWriter interface {
+	Write(row []any) error
+	Close() error
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer, columns []parquet.Column) (*csvExportWriter, error) {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	cw := &csvExportWriter{w: csv.NewWriter(w)}
+	return cw, cw.w.Write(header)
+}
+
+func (cw *csvExportWriter) Write(row []any) error {
+	record := make([]string, len(row))
+	for i, value := range row {
+		switch v := value.(type) {
+		case nil:
+			record[i] = ""
+		case time.Time:
+			record[i] = v.UTC().Format(time.RFC3339Nano)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvExportWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+var DataExport = &cobra.Command{
+	Use:   "data-export",
+	Short: "export delivered payloads or block submissions from the DB to CSV or Parquet files, streaming in batches",
+	Run: func(cmd *cobra.Command, args []string) {
+		table, ok := exportTables[exportTableName]
+		if !ok {
+			log.Fatalf("unknown table %s, must be payloads or bids", exportTableName)
+		}
+		if exportBatchSize == 0 {
+			log.Fatal("--batch-size must be greater than 0")
+		}
+
+		filters := database.ExportFilters{
+			IDLast: idLast,
+			Limit:  exportBatchSize,
+		}
+		if idFirst > 0 {
+			filters.Cursor = int64(idFirst) - 1 //nolint:gosec
+		}
+		if dateStart != "" {
+			t, err := time.Parse(time.DateOnly, dateStart)
+			if err != nil {
+				log.WithError(err).Fatalf("invalid --date-start %s", dateStart)
+			}
+			filters.InsertedFrom = t
+		}
+		if dateEnd != "" {
+			t, err := time.Parse(time.DateOnly, dateEnd)
+			if err != nil {
+				log.WithError(err).Fatalf("invalid --date-end %s", dateEnd)
+			}
+			filters.InsertedTo = t
+		}
+
+		// Open the output files
+		writers := make([]exportWriter, len(outFiles))
+		for i, outFile := range outFiles {
+			f, err := os.Create(outFile)
+			if err != nil {
+				log.WithError(err).Fatalf("failed to open file %s", outFile)
+			}
+			defer f.Close()
+
+			switch {
+			case strings.HasSuffix(outFile, ".csv"):
+				writers[i], err = newCSVExportWriter(f, table.columns)
+			case strings.HasSuffix(outFile, ".parquet"):
+				writers[i], err = parquet.NewWriter(f, table.columns, int(exportBatchSize)) //nolint:gosec
+			default:
+				log.Fatalf("unsupported output file %s, must end in .csv or .parquet", outFile)
+			}
+			if err != nil {
+				log.WithError(err).Fatalf("failed to write to file %s", outFile)
+			}
+		}
+
+		// Connect to Postgres
+		dbURL, err := url.Parse(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("couldn't read db URL")
+		}
+		log.Infof("Connecting to Postgres database at %s%s ...", dbURL.Host, dbURL.Path)
+		db, err := database.NewDatabaseService(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
+		}
+
+		log.Infof("exporting %s to %s", exportTableName, strings.Join(outFiles, ", "))
+		numRows := 0
+		for {
+			rows, lastID, err := table.fetch(db, filters)
+			if err != nil {
+				log.WithError(err).Fatal("failed to read rows")
+			}
+			if len(rows) == 0 {
+				break
+			}
+			for _, row := range rows {
+				for i, w := range writers {
+					if err := w.Write(row); err != nil {
+						log.WithError(err).Fatalf("failed to write row to file %s", outFiles[i])
+					}
+				}
+			}
+			numRows += len(rows)
+			filters.Cursor = lastID
+			log.Infof("exported %d rows, up to id %d", numRows, lastID)
+		}
+
+		for i, w := range writers {
+			if err := w.Close(); err != nil {
+				log.WithError(err).Fatalf("failed to write file %s", outFiles[i])
+			}
+		}
+		log.Infof("Wrote %d rows to %s", numRows, strings.Join(outFiles, ", "))
+	},
+}
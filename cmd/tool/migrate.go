@@ -3,17 +3,26 @@ package tool
 import (
 	"net/url"
 
+	"github.com/flashbots/mev-boost-relay/database"
 	"github.com/flashbots/mev-boost-relay/database/migrations"
 	"github.com/flashbots/mev-boost-relay/database/vars"
 	"github.com/jmoiron/sqlx"
 	migrate "github.com/rubenv/sql-migrate"
+	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	Migrate.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	Migrate.Flags().BoolVar(&migrateStatus, "status", false, "only list the applied and pending migrations")
+	Migrate.Flags().BoolVar(&migratePartitionBySlot, "partition-by-slot", false, "after migrating, partition the block submissions and delivered payloads by slot (locks the tables, stop the relay services first)")
 }
 
+var (
+	migrateStatus          bool
+	migratePartitionBySlot bool
+)
+
 var Migrate = &cobra.Command{
 	Use:   "migrate",
 	Short: "migrate the database to the latest schema",
@@ -29,12 +38,50 @@ var Migrate = &cobra.Command{
 			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
 		}
 
-		log.Infof("Migrating database ...")
 		migrate.SetTable(vars.TableMigrations)
+		if migrateStatus {
+			records, err := migrate.GetMigrationRecords(db.DB, "postgres")
+			if err != nil {
+				log.WithError(err).Fatalf("Failed to get applied migrations")
+			}
+			for _, record := range records {
+				log.WithField("appliedAt", record.AppliedAt).Infof("applied: %s", record.Id)
+			}
+			planned, _, err := migrate.PlanMigration(db.DB, "postgres", migrations.Migrations, migrate.Up, 0)
+			if err != nil {
+				log.WithError(err).Fatalf("Failed to plan migrations")
+			}
+			for _, migration := range planned {
+				log.Infof("pending: %s", migration.Id)
+			}
+			log.WithFields(logrus.Fields{
+				"numAppliedMigrations": len(records),
+				"numPendingMigrations": len(planned),
+			}).Info("Migration status")
+			return
+		}
+
+		log.Infof("Migrating database ...")
 		numAppliedMigrations, err := migrate.Exec(db.DB, "postgres", migrations.Migrations, migrate.Up)
 		if err != nil {
 			log.WithError(err).Fatalf("Failed to migrate database")
 		}
 		log.WithField("num_applied_migrations", numAppliedMigrations).Info("Migrations applied successfully")
+
+		if migratePartitionBySlot {
+			log.Info("Partitioning tables by slot ...")
+			dbService, err := database.NewDatabaseService(postgresDSN)
+			if err != nil {
+				log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
+			}
+			partitioned, err := dbService.PartitionTablesBySlot()
+			for _, table := range partitioned {
+				log.WithField("table", table).Info("Partitioned table by slot")
+			}
+			if err != nil {
+				log.WithError(err).Fatalf("Failed to partition tables by slot")
+			}
+			log.WithField("numPartitionedTables", len(partitioned)).Info("Tables partitioned successfully")
+		}
 	},
 }
@@ -0,0 +1,425 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+var ErrInvalidValue = errors.New("invalid parquet value")
+
+// Type is the type of the values of a parquet column
+type Type int
+
+const (
+	Int64 Type = iota
+	String
+	Bool
+	TimestampMillis
+	TimestampMicros
+)
+
+// Column is a column of a parquet file. Optional columns accept nil values.
+type Column struct {
+	Name     string
+	Type     Type
+	Optional bool
+}
+
+var parquetMagic = []byte("PAR1")
+
+// parquet thrift enum values
+const (
+	parquetPhysicalBoolean   = 0
+	parquetPhysicalInt64     = 2
+	parquetPhysicalByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+	parquetConvertedTimestampMicros = 10
+
+	parquetRepetitionRequired = 0
+	parquetRepetitionOptional = 1
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetPageTypeData = 0
+
+	parquetCodecGzip = 2
+)
+
+// Writer writes rows to a parquet file, in row groups of a fixed number of rows with one PLAIN encoded and GZIP
+// compressed data page per column. Only the current row group is kept in memory.
+type Writer struct {
+	w            io.Writer
+	offset       int64
+	columns      []Column
+	rowGroupSize int
+
+	values    []bytes.Buffer // PLAIN encoded values of the current row group, by column
+	bools     [][]bool       // values of the bool columns, which are bit-packed when the row group is written
+	defLevels [][]bool       // whether the values of optional columns are set
+	numRows   int
+
+	totalRows int64
+	rowGroups []rowGroup
+}
+
+type rowGroup struct {
+	numRows        int64
+	totalByteSize  int64
+	dataPageOffset []int64
+	chunkSize      []int64
+	rawChunkSize   []int64 // size of the chunks with uncompressed pages
+}
+
+// NewWriter writes the header of a parquet file with the columns, and returns the writer for its rows
+func NewWriter(w io.Writer, columns []Column, rowGroupSize int) (*Writer, error) {
+	pw := &Writer{
+		w:            w,
+		columns:      columns,
+		rowGroupSize: max(rowGroupSize, 1),
+		values:       make([]bytes.Buffer, len(columns)),
+		bools:        make([][]bool, len(columns)),
+		defLevels:    make([][]bool, len(columns)),
+	}
+	return pw, pw.write(parquetMagic)
+}
+
+func (pw *Writer) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+// Write adds a row, with a value for each column: int, int64 or uint64 for Int64, string for String,
+// bool for Bool, time.Time for TimestampMillis and TimestampMicros, and nil for unset values of optional columns.
+func (pw *Writer) Write(row []any) error {
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("%w: got %d values for %d columns", ErrInvalidValue, len(row), len(pw.columns))
+	}
+
+	// validate the whole row first, so an invalid value doesn't leave a partial row behind
+	values := make([]any, len(row))
+	for i, column := range pw.columns {
+		value, err := column.value(row[i])
+		if err != nil {
+			return fmt.Errorf("%w: column %s: %v", err, column.Name, row[i])
+		}
+		values[i] = value
+	}
+
+	for i, column := range pw.columns {
+		if column.Optional {
+			pw.defLevels[i] = append(pw.defLevels[i], values[i] != nil)
+		}
+		switch value := values[i].(type) {
+		case int64:
+			pw.values[i].Write(binary.LittleEndian.AppendUint64(nil, uint64(value))) //nolint:gosec
+		case string:
+			pw.values[i].Write(binary.LittleEndian.AppendUint32(nil, uint32(len(value)))) //nolint:gosec
+			pw.values[i].WriteString(value)
+		case bool:
+			pw.bools[i] = append(pw.bools[i], value)
+		}
+	}
+
+	pw.numRows++
+	if pw.numRows >= pw.rowGroupSize {
+		return pw.writeRowGroup()
+	}
+	return nil
+}
+
+// value returns the value to encode for the column: int64 for Int64 and the timestamps, string for String, bool for
+// Bool, and nil for unset values of optional columns
+func (c Column) value(value any) (any, error) {
+	if value == nil {
+		if c.Optional {
+			return nil, nil
+		}
+		return nil, ErrInvalidValue
+	}
+
+	switch v := value.(type) {
+	case int:
+		if c.Type == Int64 {
+			return int64(v), nil
+		}
+	case int64:
+		if c.Type == Int64 {
+			return v, nil
+		}
+	case uint64:
+		if c.Type == Int64 && v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case time.Time:
+		if c.Type == TimestampMillis {
+			return v.UnixMilli(), nil
+		} else if c.Type == TimestampMicros {
+			return v.UnixMicro(), nil
+		}
+	case string:
+		if c.Type == String {
+			return v, nil
+		}
+	case bool:
+		if c.Type == Bool {
+			return v, nil
+		}
+	}
+	return nil, ErrInvalidValue
+}
+
+// packBits packs bools into bytes, least significant bit first, as used by PLAIN booleans and bit-packed runs
+func packBits(values []bool) []byte {
+	packed := make([]byte, (len(values)+7)/8)
+	for i, value := range values {
+		if value {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+// writeRowGroup writes a data page with the values of the current row group for each column
+func (pw *Writer) writeRowGroup() error {
+	if pw.numRows == 0 {
+		return nil
+	}
+
+	group := rowGroup{numRows: int64(pw.numRows)}
+	for i, column := range pw.columns {
+		var page bytes.Buffer
+		if column.Optional {
+			// definition levels with bit width 1, as a single bit-packed run, prefixed by their length
+			levels := binary.AppendUvarint(nil, uint64((len(pw.defLevels[i])+7)/8)<<1|1)
+			levels = append(levels, packBits(pw.defLevels[i])...)
+			page.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))) //nolint:gosec
+			page.Write(levels)
+		}
+		if column.Type == Bool {
+			page.Write(packBits(pw.bools[i]))
+		} else {
+			page.Write(pw.values[i].Bytes())
+		}
+
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		header := newThriftWriter()
+		header.i32Field(1, parquetPageTypeData)
+		header.i32Field(2, int32(page.Len()))       //nolint:gosec
+		header.i32Field(3, int32(compressed.Len())) //nolint:gosec
+		header.beginStruct(5)
+		header.i32Field(1, int32(pw.numRows)) //nolint:gosec
+		header.i32Field(2, parquetEncodingPlain)
+		header.i32Field(3, parquetEncodingRLE)
+		header.i32Field(4, parquetEncodingRLE)
+		header.endStruct()
+		header.endStruct()
+
+		group.dataPageOffset = append(group.dataPageOffset, pw.offset)
+		if err := pw.write(header.bytes()); err != nil {
+			return err
+		}
+		if err := pw.write(compressed.Bytes()); err != nil {
+			return err
+		}
+		rawChunkSize := int64(len(header.bytes()) + page.Len())
+		group.chunkSize = append(group.chunkSize, int64(len(header.bytes())+compressed.Len()))
+		group.rawChunkSize = append(group.rawChunkSize, rawChunkSize)
+		group.totalByteSize += rawChunkSize
+
+		pw.values[i].Reset()
+		pw.bools[i] = pw.bools[i][:0]
+		pw.defLevels[i] = pw.defLevels[i][:0]
+	}
+
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.totalRows += int64(pw.numRows)
+	pw.numRows = 0
+	return nil
+}
+
+// Close writes the last row group and the footer with the file metadata. It doesn't close the underlying writer.
+func (pw *Writer) Close() error {
+	if err := pw.writeRowGroup(); err != nil {
+		return err
+	}
+
+	meta := newThriftWriter()
+	meta.i32Field(1, 1)
+	meta.listHeader(2, thriftTypeStruct, len(pw.columns)+1)
+	meta.beginListStruct()
+	meta.stringField(4, "schema")
+	meta.i32Field(5, int32(len(pw.columns))) //nolint:gosec
+	meta.endStruct()
+	for _, column := range pw.columns {
+		physicalType, convertedType := column.physicalType()
+		repetition := int32(parquetRepetitionRequired)
+		if column.Optional {
+			repetition = parquetRepetitionOptional
+		}
+		meta.beginListStruct()
+		meta.i32Field(1, physicalType)
+		meta.i32Field(3, repetition)
+		meta.stringField(4, column.Name)
+		if convertedType >= 0 {
+			meta.i32Field(6, convertedType)
+		}
+		meta.endStruct()
+	}
+	meta.i64Field(3, pw.totalRows)
+
+	meta.listHeader(4, thriftTypeStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		meta.beginListStruct()
+		meta.listHeader(1, thriftTypeStruct, len(pw.columns))
+		for i, column := range pw.columns {
+			physicalType, _ := column.physicalType()
+			meta.beginListStruct()
+			meta.i64Field(2, group.dataPageOffset[i])
+			meta.beginStruct(3)
+			meta.i32Field(1, physicalType)
+			meta.listHeader(2, thriftTypeI32, 2)
+			meta.listI32(parquetEncodingPlain)
+			meta.listI32(parquetEncodingRLE)
+			meta.listHeader(3, thriftTypeBinary, 1)
+			meta.listString(column.Name)
+			meta.i32Field(4, parquetCodecGzip)
+			meta.i64Field(5, group.numRows)
+			meta.i64Field(6, group.rawChunkSize[i])
+			meta.i64Field(7, group.chunkSize[i])
+			meta.i64Field(9, group.dataPageOffset[i])
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64Field(2, group.totalByteSize)
+		meta.i64Field(3, group.numRows)
+		meta.endStruct()
+	}
+	meta.stringField(6, "mev-boost-relay")
+	meta.endStruct()
+
+	footer := meta.bytes()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer))) //nolint:gosec
+	footer = append(footer, parquetMagic...)
+	return pw.write(footer)
+}
+
+// physicalType returns the parquet type of the column, and its converted type (-1 if none)
+func (c Column) physicalType() (physicalType, convertedType int32) {
+	switch c.Type {
+	case String:
+		return parquetPhysicalByteArray, parquetConvertedUTF8
+	case Bool:
+		return parquetPhysicalBoolean, -1
+	case TimestampMillis:
+		return parquetPhysicalInt64, parquetConvertedTimestampMillis
+	case TimestampMicros:
+		return parquetPhysicalInt64, parquetConvertedTimestampMicros
+	default:
+		return parquetPhysicalInt64, -1
+	}
+}
+
+// thrift compact protocol types
+const (
+	thriftTypeI32    = 5
+	thriftTypeI64    = 6
+	thriftTypeBinary = 8
+	thriftTypeList   = 9
+	thriftTypeStruct = 12
+)
+
+// thriftWriter encodes structs with the thrift compact protocol, which parquet uses for its metadata
+type thriftWriter struct {
+	buf          bytes.Buffer
+	lastFieldIDs []int16 // of the structs being written, for the field id deltas
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{lastFieldIDs: []int16{0}}
+}
+
+func (t *thriftWriter) bytes() []byte {
+	return t.buf.Bytes()
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.buf.Write(binary.AppendVarint(nil, v)) // zigzag encoded
+}
+
+func (t *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	last := &t.lastFieldIDs[len(t.lastFieldIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		t.buf.WriteByte(fieldType)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) i32Field(id int16, v int32) {
+	t.fieldHeader(id, thriftTypeI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64Field(id int16, v int64) {
+	t.fieldHeader(id, thriftTypeI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) stringField(id int16, s string) {
+	t.fieldHeader(id, thriftTypeBinary)
+	t.listString(s)
+}
+
+func (t *thriftWriter) beginStruct(id int16) {
+	t.fieldHeader(id, thriftTypeStruct)
+	t.lastFieldIDs = append(t.lastFieldIDs, 0)
+}
+
+// beginListStruct begins a struct which is an element of a list
+func (t *thriftWriter) beginListStruct() {
+	t.lastFieldIDs = append(t.lastFieldIDs, 0)
+}
+
+func (t *thriftWriter) endStruct() {
+	t.buf.WriteByte(0)
+	t.lastFieldIDs = t.lastFieldIDs[:len(t.lastFieldIDs)-1]
+}
+
+func (t *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftTypeList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType) //nolint:gosec
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.buf.Write(binary.AppendUvarint(nil, uint64(size))) //nolint:gosec
+	}
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) listString(s string) {
+	t.buf.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	t.buf.WriteString(s)
+}
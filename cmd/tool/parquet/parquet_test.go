@@ -0,0 +1,238 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// thriftReader decodes thrift compact protocol structs into maps of field ids to values, to check the written metadata
+type thriftReader struct {
+	t   *testing.T
+	buf *bytes.Reader
+}
+
+func (r *thriftReader) varint() int64 {
+	v, err := binary.ReadVarint(r.buf)
+	require.NoError(r.t, err)
+	return v
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, err := binary.ReadUvarint(r.buf)
+	require.NoError(r.t, err)
+	return v
+}
+
+func (r *thriftReader) readByte() byte {
+	b, err := r.buf.ReadByte()
+	require.NoError(r.t, err)
+	return b
+}
+
+func (r *thriftReader) value(fieldType byte) any {
+	switch fieldType {
+	case thriftTypeI32, thriftTypeI64:
+		return r.varint()
+	case thriftTypeBinary:
+		s := make([]byte, r.uvarint())
+		_, err := r.buf.Read(s)
+		require.NoError(r.t, err)
+		return string(s)
+	case thriftTypeList:
+		header := r.readByte()
+		size := uint64(header >> 4)
+		if size == 15 {
+			size = r.uvarint()
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftTypeStruct:
+		return r.structValue()
+	}
+	r.t.Fatalf("unexpected thrift type %d", fieldType)
+	return nil
+}
+
+func (r *thriftReader) structValue() map[int16]any {
+	fields := make(map[int16]any)
+	lastID := int16(0)
+	for {
+		header := r.readByte()
+		if header == 0 {
+			return fields
+		}
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint())
+		}
+		fields[id] = r.value(header & 0x0f)
+		lastID = id
+	}
+}
+
+func TestWriter(t *testing.T) {
+	columns := []Column{
+		{Name: "slot", Type: Int64},
+		{Name: "block_hash", Type: String},
+		{Name: "optimistic", Type: Bool},
+		{Name: "received_at", Type: TimestampMillis, Optional: true},
+	}
+	receivedAt := time.UnixMilli(1_700_000_000_123)
+	rows := [][]any{
+		{uint64(1), "0x01", true, receivedAt},
+		{uint64(2), "0x02", false, nil},
+		{uint64(3), "0x03", true, receivedAt},
+	}
+
+	var buf bytes.Buffer
+	pw, err := NewWriter(&buf, columns, 2)
+	require.NoError(t, err)
+	for _, row := range rows {
+		require.NoError(t, pw.Write(row))
+	}
+	require.ErrorIs(t, pw.Write([]any{uint64(4), "0x04", "true", nil}), ErrInvalidValue)
+	require.ErrorIs(t, pw.Write([]any{uint64(4), nil, true, nil}), ErrInvalidValue)
+	require.NoError(t, pw.Close())
+
+	file := buf.Bytes()
+	require.Equal(t, parquetMagic, file[:4])
+	require.Equal(t, parquetMagic, file[len(file)-4:])
+	footerLen := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := &thriftReader{t: t, buf: bytes.NewReader(file[len(file)-8-footerLen : len(file)-8])}
+	meta := footer.structValue()
+	require.Zero(t, footer.buf.Len())
+
+	require.Equal(t, int64(3), meta[3])
+	schema := meta[2].([]any)
+	require.Len(t, schema, 5)
+	require.Equal(t, int64(4), schema[0].(map[int16]any)[5])
+	require.Equal(t, "received_at", schema[4].(map[int16]any)[4])
+	require.Equal(t, int64(parquetRepetitionOptional), schema[4].(map[int16]any)[3])
+
+	// two row groups, of two rows and one row
+	rowGroups := meta[4].([]any)
+	require.Len(t, rowGroups, 2)
+	readColumn := func(rowGroup, column int) (pageHeader map[int16]any, data []byte) {
+		chunks := rowGroups[rowGroup].(map[int16]any)[1].([]any)
+		chunkMeta := chunks[column].(map[int16]any)[3].(map[int16]any)
+		offset := chunkMeta[9].(int64)
+		reader := &thriftReader{t: t, buf: bytes.NewReader(file[offset:])}
+		pageHeader = reader.structValue()
+		start := len(file[offset:]) - reader.buf.Len()
+		pageSize := int(pageHeader[3].(int64))
+		require.Equal(t, int64(parquetCodecGzip), chunkMeta[4])
+		require.Equal(t, chunkMeta[7], int64(start+pageSize))
+
+		// the pages are gzip compressed
+		zr, err := gzip.NewReader(bytes.NewReader(file[int(offset)+start : int(offset)+start+pageSize]))
+		require.NoError(t, err)
+		data, err = io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Equal(t, pageHeader[2], int64(len(data)))
+		require.Equal(t, chunkMeta[6], int64(start+len(data)))
+		return pageHeader, data
+	}
+
+	pageHeader, data := readColumn(0, 0)
+	require.Equal(t, int64(2), pageHeader[5].(map[int16]any)[1])
+	require.Equal(t, uint64(1), binary.LittleEndian.Uint64(data[0:8]))
+	require.Equal(t, uint64(2), binary.LittleEndian.Uint64(data[8:16]))
+
+	_, data = readColumn(0, 1)
+	require.Equal(t, []byte{4, 0, 0, 0, '0', 'x', '0', '1', 4, 0, 0, 0, '0', 'x', '0', '2'}, data)
+
+	_, data = readColumn(0, 2)
+	require.Equal(t, []byte{0b01}, data)
+
+	// optional values are prefixed by the definition levels, and unset values are skipped
+	_, data = readColumn(0, 3)
+	require.Equal(t, []byte{2, 0, 0, 0, 0b11, 0b01}, data[:6])
+	require.Equal(t, uint64(receivedAt.UnixMilli()), binary.LittleEndian.Uint64(data[6:])) //nolint:gosec
+
+	pageHeader, data = readColumn(1, 0)
+	require.Equal(t, int64(1), pageHeader[5].(map[int16]any)[1])
+	require.Len(t, data, 8)
+	require.Equal(t, uint64(3), binary.LittleEndian.Uint64(data))
+}
+
+// readParquetWithPyarrow reads a parquet file with pyarrow, and returns the values by column name, with timestamps as
+// integers in their unit, and the number of row groups
+const readParquetWithPyarrow = `
+import json, sys
+import pyarrow as pa, pyarrow.parquet as pq
+table = pq.read_table(sys.argv[1])
+columns = {}
+for name in table.column_names:
+    column = table[name]
+    if pa.types.is_timestamp(column.type):
+        column = column.cast(pa.int64())
+    columns[name] = column.to_pylist()
+print(json.dumps({"columns": columns, "num_row_groups": pq.ParquetFile(sys.argv[1]).num_row_groups}))
+`
+
+// TestWriterWithPyarrow checks that the files are read by pyarrow, which needs python3 with pyarrow installed
+func TestWriterWithPyarrow(t *testing.T) {
+	if os.Getenv("RUN_INTEGRATION_TESTS") != "1" {
+		t.Skip("Skipping integration tests for parquet")
+	}
+
+	columns := []Column{
+		{Name: "slot", Type: Int64},
+		{Name: "block_hash", Type: String},
+		{Name: "optimistic", Type: Bool},
+		{Name: "received_at", Type: TimestampMillis, Optional: true},
+		{Name: "adjusted_value", Type: String, Optional: true},
+		{Name: "inserted_at", Type: TimestampMicros},
+	}
+	receivedAt := time.UnixMilli(1_700_000_000_123)
+	path := filepath.Join(t.TempDir(), "export.parquet")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	pw, err := NewWriter(file, columns, 2)
+	require.NoError(t, err)
+	for i := range 5 {
+		row := []any{uint64(i), "0x0" + string(rune('0'+i)), i%2 == 0, nil, nil, receivedAt.Add(time.Duration(i) * time.Microsecond)}
+		if i != 1 {
+			row[3] = receivedAt.Add(time.Duration(i) * time.Millisecond)
+		}
+		if i == 3 {
+			row[4] = "123"
+		}
+		require.NoError(t, pw.Write(row))
+	}
+	require.NoError(t, pw.Close())
+	require.NoError(t, file.Close())
+
+	out, err := exec.Command("python3", "-c", readParquetWithPyarrow, path).Output()
+	require.NoError(t, err)
+	result := struct {
+		Columns      map[string][]any `json:"columns"`
+		NumRowGroups int              `json:"num_row_groups"`
+	}{}
+	require.NoError(t, json.Unmarshal(out, &result))
+
+	ms := float64(receivedAt.UnixMilli())
+	us := float64(receivedAt.UnixMicro())
+	require.Equal(t, 3, result.NumRowGroups)
+	require.Equal(t, map[string][]any{
+		"slot":           {0.0, 1.0, 2.0, 3.0, 4.0},
+		"block_hash":     {"0x00", "0x01", "0x02", "0x03", "0x04"},
+		"optimistic":     {true, false, true, false, true},
+		"received_at":    {ms, nil, ms + 2, ms + 3, ms + 4},
+		"adjusted_value": {nil, nil, nil, "123", nil},
+		"inserted_at":    {us, us + 1, us + 2, us + 3, us + 4},
+	}, result.Columns)
+}
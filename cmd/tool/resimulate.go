@@ -0,0 +1,272 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/flashbots/go-boost-utils/utils"
+	"github.com/flashbots/mev-boost-relay/beaconclient"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/flashbots/mev-boost-relay/services/api"
+	"github.com/holiman/uint256"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// resimulateMaxMissedSlots is how many slots after a submission are searched for a block, to find the parent beacon
+// block root the submission was built on
+const resimulateMaxMissedSlots = 32
+
+var (
+	resimulateBlockSimURL string
+	resimulateBeaconURIs  []string
+	resimulateSlotFrom    uint64
+	resimulateSlotTo      uint64
+	resimulateBatchSize   uint64
+	resimulateOutFile     string
+)
+
+func init() {
+	Resimulate.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	Resimulate.Flags().StringVar(&resimulateBlockSimURL, "blocksim", common.GetEnv("BLOCKSIM_URI", "http://localhost:8545"), "URL for block simulator")
+	Resimulate.Flags().StringSliceVar(&resimulateBeaconURIs, "beacon-uris", defaultBeaconURIs, "beacon endpoints, to look up the parent beacon block roots")
+	Resimulate.Flags().Uint64Var(&resimulateSlotFrom, "slot-from", 0, "start slot (inclusive)")
+	Resimulate.Flags().Uint64Var(&resimulateSlotTo, "slot-to", 0, "end slot (inclusive)")
+	Resimulate.Flags().Uint64Var(&resimulateBatchSize, "batch-size", 100, "number of submissions read from the DB at a time")
+	Resimulate.Flags().StringVar(&resimulateOutFile, "out", "", "CSV file to write the diverging submissions to")
+	_ = Resimulate.MarkFlagRequired("slot-from")
+	_ = Resimulate.MarkFlagRequired("slot-to")
+}
+
+var resimulateCSVHeader = []string{"id", "slot", "block_hash", "builder_pubkey", "original_sim_success", "original_sim_error", "original_block_value", "sim_success", "sim_error", "block_value"}
+
+var Resimulate = &cobra.Command{
+	Use:   "resimulate",
+	Short: "send the stored block submissions of a slot range to a block simulator again, and report where the result differs from the original simulation",
+	Run: func(cmd *cobra.Command, args []string) {
+		if resimulateSlotTo < resimulateSlotFrom {
+			log.Fatal("--slot-to must not be lower than --slot-from")
+		}
+		if resimulateBatchSize == 0 {
+			log.Fatal("--batch-size must be greater than 0")
+		}
+
+		// Connect to the beacon nodes
+		if len(resimulateBeaconURIs) == 0 {
+			log.Fatal("no beacon endpoints specified")
+		}
+		log.Infof("Using beacon endpoints: %s", strings.Join(resimulateBeaconURIs, ", "))
+		var beaconInstances []beaconclient.IBeaconInstance
+		for _, uri := range resimulateBeaconURIs {
+			beaconInstances = append(beaconInstances, beaconclient.NewProdBeaconInstance(log, uri, uri))
+		}
+		beaconClient := beaconclient.NewMultiBeaconClient(log, beaconInstances)
+
+		// Connect to Postgres
+		dbURL, err := url.Parse(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("couldn't read db URL")
+		}
+		log.Infof("Connecting to Postgres database at %s%s ...", dbURL.Host, dbURL.Path)
+		db, err := database.NewDatabaseService(postgresDSN)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to connect to Postgres database at %s%s", dbURL.Host, dbURL.Path)
+		}
+
+		var csvWriter *csv.Writer
+		if resimulateOutFile != "" {
+			f, err := os.Create(resimulateOutFile)
+			if err != nil {
+				log.WithError(err).Fatal("failed to open file")
+			}
+			defer f.Close()
+			csvWriter = csv.NewWriter(f)
+			defer csvWriter.Flush()
+			if err := csvWriter.Write(resimulateCSVHeader); err != nil {
+				log.WithError(err).Fatal("error writing record to file")
+			}
+		}
+
+		blockSim := api.NewBlockSimulationRateLimiter(resimulateBlockSimURL)
+		parentBeaconRoots := make(map[uint64]*phase0.Root)
+		registeredGasLimits := make(map[string]uint64)
+
+		log.Infof("resimulating the block submissions of slots %d to %d with %s", resimulateSlotFrom, resimulateSlotTo, resimulateBlockSimURL)
+		var numSimulated, numDiverging, numFailed int
+		afterID := int64(0)
+		for {
+			entries, err := db.GetBuilderSubmissionsForResimulation(resimulateSlotFrom, resimulateSlotTo, afterID, resimulateBatchSize)
+			if err != nil {
+				log.WithError(err).Fatal("failed to get block submissions")
+			}
+			if len(entries) == 0 {
+				break
+			}
+
+			for _, entry := range entries {
+				afterID = entry.ID
+				log := log.WithFields(logrus.Fields{
+					"id":            entry.ID,
+					"slot":          entry.Slot,
+					"blockHash":     entry.BlockHash,
+					"builderPubkey": entry.BuilderPubkey,
+				})
+
+				req, err := resimulationRequest(db, beaconClient, entry, parentBeaconRoots, registeredGasLimits)
+				if err != nil {
+					log.WithError(err).Warn("failed to rebuild the block submission")
+					numFailed++
+					continue
+				}
+
+				response, _, requestErr, validationErr := blockSim.Send(context.Background(), req, false, false)
+				if requestErr != nil {
+					log.WithError(requestErr).Warn("failed to simulate the block submission")
+					numFailed++
+					continue
+				}
+				numSimulated++
+
+				simError := ""
+				if validationErr != nil {
+					simError = validationErr.Error()
+				}
+				blockValue := ""
+				if response != nil && response.BlockValue != nil {
+					blockValue = response.BlockValue.Dec()
+				}
+				originalBlockValue := ""
+				if entry.BlockValue.Valid {
+					originalBlockValue = entry.BlockValue.String
+				}
+
+				// The block values are only compared if both were recorded, as older simulators didn't return them
+				simSuccess := validationErr == nil
+				valueDiverges := simSuccess && entry.SimSuccess && blockValue != "" && originalBlockValue != "" && blockValue != originalBlockValue
+				if simSuccess == entry.SimSuccess && !valueDiverges {
+					continue
+				}
+
+				numDiverging++
+				log.WithFields(logrus.Fields{
+					"originalSimSuccess": entry.SimSuccess,
+					"originalSimError":   entry.SimError,
+					"originalBlockValue": originalBlockValue,
+					"simSuccess":         simSuccess,
+					"simError":           simError,
+					"blockValue":         blockValue,
+				}).Info("simulation result differs from the original")
+				if csvWriter != nil {
+					record := []string{
+						strconv.FormatInt(entry.ID, 10),
+						strconv.FormatUint(entry.Slot, 10),
+						entry.BlockHash,
+						entry.BuilderPubkey,
+						strconv.FormatBool(entry.SimSuccess),
+						entry.SimError,
+						originalBlockValue,
+						strconv.FormatBool(simSuccess),
+						simError,
+						blockValue,
+					}
+					if err := csvWriter.Write(record); err != nil {
+						log.WithError(err).Fatal("error writing record to file")
+					}
+				}
+			}
+			log.Infof("resimulated %d submissions, up to slot %d", numSimulated, entries[len(entries)-1].Slot)
+		}
+
+		log.WithFields(logrus.Fields{
+			"simulated": numSimulated,
+			"diverging": numDiverging,
+			"failed":    numFailed,
+		}).Info("resimulation done")
+	},
+}
+
+// resimulationRequest rebuilds the simulation request of a stored block submission. The registered gas limit is taken
+// from the registration of the proposer which was valid when the submission was received (or the gas limit of the
+// block if there was none).
+func resimulationRequest(db database.IDatabaseService, beaconClient beaconclient.IMultiBeaconClient, entry *database.BuilderBlockSubmissionEntry, parentBeaconRoots map[uint64]*phase0.Root, registeredGasLimits map[string]uint64) (*common.BuilderBlockValidationRequest, error) {
+	payloadEntry, err := db.GetExecutionPayloadEntryByID(entry.ExecutionPayloadID.Int64)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := database.BuilderSubmissionEntryToSubmitBlockRequest(entry, payloadEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	// the registration can change between slots, so the gas limits are cached per proposer and slot
+	receivedAt := entry.InsertedAt
+	if entry.ReceivedAt.Valid {
+		receivedAt = entry.ReceivedAt.Time
+	}
+	gasLimitKey := fmt.Sprintf("%s_%d", entry.ProposerPubkey, entry.Slot)
+	gasLimit, ok := registeredGasLimits[gasLimitKey]
+	if !ok {
+		gasLimit = entry.GasLimit
+		registration, err := db.GetValidatorRegistrationAt(entry.ProposerPubkey, uint64(receivedAt.Unix())) //nolint:gosec
+		if err == nil {
+			gasLimit = registration.GasLimit
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		registeredGasLimits[gasLimitKey] = gasLimit
+	}
+
+	parentBeaconRoot, ok := parentBeaconRoots[entry.Slot]
+	if !ok {
+		parentBeaconRoot, err = lookupParentBeaconRoot(beaconClient, entry.Slot)
+		if err != nil {
+			return nil, err
+		}
+		parentBeaconRoots[entry.Slot] = parentBeaconRoot
+	}
+
+	// an adjusted bid had to pay the proposer the adjusted value
+	var proposerPayment *uint256.Int
+	if entry.AdjustedValue.Valid {
+		proposerPayment, err = uint256.FromDecimal(entry.AdjustedValue.String)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &common.BuilderBlockValidationRequest{
+		VersionedSubmitBlockRequest: payload,
+		RegisteredGasLimit:          gasLimit,
+		ParentBeaconBlockRoot:       parentBeaconRoot,
+		ProposerPayment:             proposerPayment,
+	}, nil
+}
+
+// lookupParentBeaconRoot returns the root of the last block before the slot, which is the parent root of the first
+// block at or after the slot
+func lookupParentBeaconRoot(beaconClient beaconclient.IMultiBeaconClient, slot uint64) (*phase0.Root, error) {
+	for blockSlot := slot; blockSlot < slot+resimulateMaxMissedSlots; blockSlot++ {
+		blockResp, err := beaconClient.GetBlockBySlot(blockSlot)
+		if errors.Is(err, beaconclient.ErrBlockNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		hash, err := utils.HexToHash(blockResp.Data.Message.ParentRoot)
+		if err != nil {
+			return nil, err
+		}
+		root := phase0.Root(hash)
+		return &root, nil
+	}
+	return nil, beaconclient.ErrBlockNotFound
+}
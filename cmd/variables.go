@@ -13,6 +13,7 @@ var (
 	defaultRedisURI          = common.GetEnv("REDIS_URI", "localhost:6379")
 	defaultRedisReadonlyURI  = common.GetEnv("REDIS_READONLY_URI", "")
 	defaultPostgresDSN       = common.GetEnv("POSTGRES_DSN", "")
+	defaultPostgresReadDSN   = common.GetEnv("POSTGRES_READONLY_DSN", "")
 	defaultMemcachedURIs     = common.GetSliceEnv("MEMCACHED_URIS", nil)
 	defaultLogJSON           = os.Getenv("LOG_JSON") != ""
 	defaultLogLevel          = common.GetEnv("LOG_LEVEL", "info")
@@ -22,6 +23,7 @@ var (
 	redisURI              string
 	redisReadonlyURI      string
 	postgresDSN           string
+	postgresReadDSN       string
 	memcachedURIs         []string
 
 	logJSON  bool
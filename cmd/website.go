@@ -3,7 +3,10 @@ package cmd
 import (
 	"net/url"
 	"os"
+	"time"
 
+	"github.com/flashbots/go-utils/cli"
+	"github.com/flashbots/mev-boost-relay/beaconclient"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/flashbots/mev-boost-relay/database"
 	"github.com/flashbots/mev-boost-relay/datastore"
@@ -19,6 +22,7 @@ var (
 	websiteDefaultLinkEtherscan     = common.GetEnv("LINK_ETHERSCAN", "https://etherscan.io")
 	websiteDefaultLinkDataAPI       = common.GetEnv("LINK_DATA_API", "")
 	websiteDefaultRelayURL          = common.GetEnv("RELAY_URL", "")
+	websiteDefaultRefreshInterval   = time.Duration(cli.GetEnvInt("WEBSITE_REFRESH_INTERVAL_SEC", int(website.DefaultRefreshInterval.Seconds()))) * time.Second
 
 	websiteListenAddr        string
 	websitePubkeyOverride    string
@@ -28,25 +32,44 @@ var (
 	websiteLinkEtherscan   string
 	websiteLinkDataAPI     string
 	websiteRelayURL        string
+
+	websiteRefreshInterval time.Duration
 )
 
 func init() {
 	rootCmd.AddCommand(websiteCmd)
 	websiteCmd.Flags().BoolVar(&logJSON, "json", defaultLogJSON, "log in JSON format instead of text")
+	flagEnv(websiteCmd, "json", "LOG_JSON")
 	websiteCmd.Flags().StringVar(&logLevel, "loglevel", defaultLogLevel, "log-level: trace, debug, info, warn/warning, error, fatal, panic")
+	flagEnv(websiteCmd, "loglevel", "LOG_LEVEL")
 
 	websiteCmd.Flags().StringVar(&websiteListenAddr, "listen-addr", websiteDefaultListenAddr, "listen address for webserver")
+	flagEnv(websiteCmd, "listen-addr", "LISTEN_ADDR")
 	websiteCmd.Flags().StringVar(&redisURI, "redis-uri", defaultRedisURI, "redis uri")
+	flagEnv(websiteCmd, "redis-uri", "REDIS_URI")
 	websiteCmd.Flags().StringVar(&redisReadonlyURI, "redis-readonly-uri", defaultRedisReadonlyURI, "redis readonly uri")
+	flagEnv(websiteCmd, "redis-readonly-uri", "REDIS_READONLY_URI")
 	websiteCmd.Flags().StringVar(&postgresDSN, "db", defaultPostgresDSN, "PostgreSQL DSN")
+	flagEnv(websiteCmd, "db", "POSTGRES_DSN")
 	websiteCmd.Flags().StringVar(&websitePubkeyOverride, "pubkey-override", os.Getenv("PUBKEY_OVERRIDE"), "override for public key")
+	flagEnv(websiteCmd, "pubkey-override", "PUBKEY_OVERRIDE")
 
 	websiteCmd.Flags().StringVar(&network, "network", defaultNetwork, "Which network to use")
+	flagEnv(websiteCmd, "network", "NETWORK")
+	websiteCmd.Flags().StringSliceVar(&beaconNodeURIs, "beacon-uris", defaultBeaconURIs, "beacon endpoints, only used to load the network details with --network beacon")
+	flagEnv(websiteCmd, "beacon-uris", "BEACON_URIS")
 	websiteCmd.Flags().BoolVar(&websiteShowConfigDetails, "show-config-details", websiteDefaultShowConfigDetails, "show config details")
+	flagEnv(websiteCmd, "show-config-details", "SHOW_CONFIG_DETAILS")
 	websiteCmd.Flags().StringVar(&websiteLinkBeaconchain, "link-beaconchain", websiteDefaultLinkBeaconchain, "url for beaconcha.in")
+	flagEnv(websiteCmd, "link-beaconchain", "LINK_BEACONCHAIN")
 	websiteCmd.Flags().StringVar(&websiteLinkEtherscan, "link-etherscan", websiteDefaultLinkEtherscan, "url for etherscan")
+	flagEnv(websiteCmd, "link-etherscan", "LINK_ETHERSCAN")
 	websiteCmd.Flags().StringVar(&websiteLinkDataAPI, "link-data-api", websiteDefaultLinkDataAPI, "origin url for data api (https://domain:port)")
+	flagEnv(websiteCmd, "link-data-api", "LINK_DATA_API")
 	websiteCmd.Flags().StringVar(&websiteRelayURL, "relay-url", websiteDefaultRelayURL, "full url for the relay (https://pubkey@host)")
+	flagEnv(websiteCmd, "relay-url", "RELAY_URL")
+	websiteCmd.Flags().DurationVar(&websiteRefreshInterval, "refresh-interval", websiteDefaultRefreshInterval, "how often the pages are rendered, and how long search results are cached")
+	flagEnv(websiteCmd, "refresh-interval", "WEBSITE_REFRESH_INTERVAL_SEC")
 }
 
 var websiteCmd = &cobra.Command{
@@ -61,7 +84,15 @@ var websiteCmd = &cobra.Command{
 		})
 		log.Infof("boost-relay %s", Version)
 
-		networkInfo, err := common.NewEthNetworkDetails(network)
+		var beaconClient beaconclient.IMultiBeaconClient
+		if network == common.EthNetworkBeacon {
+			var beaconInstances []beaconclient.IBeaconInstance
+			for _, uri := range beaconNodeURIs {
+				beaconInstances = append(beaconInstances, beaconclient.NewProdBeaconInstance(log, uri, uri))
+			}
+			beaconClient = beaconclient.NewMultiBeaconClient(log, beaconInstances)
+		}
+		networkInfo, err := beaconclient.NewEthNetworkDetails(network, beaconClient)
 		if err != nil {
 			log.WithError(err).Fatalf("error getting network details")
 		}
@@ -79,6 +110,7 @@ var websiteCmd = &cobra.Command{
 		if err != nil {
 			log.WithError(err).Fatalf("Failed to connect to Redis at %s", redisURI)
 		}
+		redis.SetLog(log)
 
 		relayPubkey := ""
 		if websitePubkeyOverride != "" {
@@ -115,6 +147,7 @@ var websiteCmd = &cobra.Command{
 			LinkEtherscan:     websiteLinkEtherscan,
 			LinkDataAPI:       websiteLinkDataAPI,
 			RelayURL:          websiteRelayURL,
+			RefreshInterval:   websiteRefreshInterval,
 		}
 
 		srv, err := website.NewWebserver(opts)
@@ -23,6 +23,34 @@ func SlotToEpoch(slot uint64) uint64 {
 	return slot / SlotsPerEpoch
 }
 
+// SlotClock converts between slots and wallclock time, based on the genesis time reported by the beacon node
+type SlotClock struct {
+	GenesisTime uint64 // unix timestamp in seconds
+}
+
+// SlotStartTimestamp returns the unix timestamp (in seconds) of the start of the slot, which is also the timestamp of its execution payload
+func (c SlotClock) SlotStartTimestamp(slot uint64) uint64 {
+	return c.GenesisTime + slot*SecondsPerSlot
+}
+
+func (c SlotClock) SlotStartTime(slot uint64) time.Time {
+	return time.Unix(int64(c.SlotStartTimestamp(slot)), 0).UTC() //nolint:gosec
+}
+
+// MsIntoSlot returns how many milliseconds t is after the start of the slot, negative if t is before the slot started
+func (c SlotClock) MsIntoSlot(slot uint64, t time.Time) int64 {
+	return t.UnixMilli() - c.SlotStartTime(slot).UnixMilli()
+}
+
+// SlotAt returns the slot at time t, or 0 if t is before genesis
+func (c SlotClock) SlotAt(t time.Time) uint64 {
+	sinceGenesis := t.Unix() - int64(c.GenesisTime) //nolint:gosec
+	if sinceGenesis < 0 {
+		return 0
+	}
+	return uint64(sinceGenesis) / SecondsPerSlot
+}
+
 // HTTPServerTimeouts are various timeouts for requests to the mev-boost HTTP server
 type HTTPServerTimeouts struct {
 	Read       time.Duration // Timeout for body reads. None if 0.
@@ -38,21 +66,82 @@ type BuilderStatus struct {
 	IsOptimistic  bool
 }
 
+// Penalties which are subtracted from the score of a builder for every demotion (i.e. an invalid block which was
+// accepted optimistically), every getPayload request for one of its bids which was part of a proposer equivocation,
+// and every other failed getPayload request for one of its bids (invalidated bid, unknown payload or failed publishing)
+const (
+	BuilderScoreDemotionPenalty          = 10
+	BuilderScoreEquivocationPenalty      = 5
+	BuilderScoreGetPayloadFailurePenalty = 2
+)
+
+// BuilderScoreCounts are the inputs of a builder score, counted over a recent window of epochs
+type BuilderScoreCounts struct {
+	NumSubmissions        uint64 `db:"num_submissions"`
+	NumSimErrors          uint64 `db:"num_sim_errors"`
+	NumDemotions          uint64 `db:"num_demotions"`
+	NumEquivocations      uint64 `db:"num_equivocations"`
+	NumGetPayloadFailures uint64 `db:"num_get_payload_failures"`
+}
+
+// BuilderScore is the reputation of a builder, computed regularly from its recent submissions (see ComputeBuilderScore)
+type BuilderScore struct {
+	BuilderPubkey         string  `json:"builder_pubkey"`
+	NumSubmissions        uint64  `json:"num_submissions,string"`
+	SuccessRate           float64 `json:"success_rate"`
+	SimFailureRate        float64 `json:"sim_failure_rate"`
+	NumDemotions          uint64  `json:"num_demotions,string"`
+	NumEquivocations      uint64  `json:"num_equivocations,string"`
+	NumGetPayloadFailures uint64  `json:"num_get_payload_failures,string"`
+	Score                 float64 `json:"score"`
+}
+
+// ComputeBuilderScore calculates a score between 0 and 100 from the rate of successfully simulated submissions,
+// with a penalty for every demotion, equivocation and failed getPayload request
+func ComputeBuilderScore(builderPubkey string, counts BuilderScoreCounts) *BuilderScore {
+	score := &BuilderScore{ //nolint:exhaustruct
+		BuilderPubkey:         builderPubkey,
+		NumSubmissions:        counts.NumSubmissions,
+		NumDemotions:          counts.NumDemotions,
+		NumEquivocations:      counts.NumEquivocations,
+		NumGetPayloadFailures: counts.NumGetPayloadFailures,
+	}
+	if counts.NumSubmissions > 0 {
+		score.SimFailureRate = float64(counts.NumSimErrors) / float64(counts.NumSubmissions)
+		score.SuccessRate = 1 - score.SimFailureRate
+	}
+	penalty := counts.NumDemotions*BuilderScoreDemotionPenalty +
+		counts.NumEquivocations*BuilderScoreEquivocationPenalty +
+		counts.NumGetPayloadFailures*BuilderScoreGetPayloadFailurePenalty
+	score.Score = max(100*score.SuccessRate-float64(penalty), 0)
+	return score
+}
+
+// CircuitBreakerState tracks whether the payloads delivered by the relay landed on chain. Once too many
+// delivered payloads in a row were missed, the circuit breaker trips and bids are withheld until an operator resets it.
+type CircuitBreakerState struct {
+	LastCheckedSlot uint64 `json:"last_checked_slot,string"`
+	NumMissedSlots  uint64 `json:"num_missed_slots,string"`
+	IsTripped       bool   `json:"is_tripped"`
+}
+
 // Profile captures performance metrics for the block submission handler. Each
 // field corresponds to the number of microseconds in each stage. The `Total`
 // field is the number of microseconds taken for entire flow.
 type Profile struct {
-	PayloadLoad uint64
-	Decode      uint64
-	Prechecks   uint64
-	Simulation  uint64
-	RedisUpdate uint64
-	Total       uint64
+	PayloadLoad    uint64
+	Decode         uint64
+	Prechecks      uint64
+	SignatureCheck uint64
+	SimQueueWait   uint64
+	Simulation     uint64
+	RedisUpdate    uint64
+	Total          uint64
 
 	// Redis profiling
 	RedisSavePayload  uint64
 	RedisUpdateTopBid uint64
-	RedisUpdateFloor  uint64
+	RedisExec         uint64
 
 	// Attributes
 	IsGzip            bool
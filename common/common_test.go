@@ -0,0 +1,48 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeBuilderScore(t *testing.T) {
+	cases := []struct {
+		description   string
+		counts        BuilderScoreCounts
+		expectedScore float64
+	}{
+		{"no submissions", BuilderScoreCounts{}, 0},
+		{"no sim errors", BuilderScoreCounts{NumSubmissions: 100}, 100},
+		{"some sim errors", BuilderScoreCounts{NumSubmissions: 100, NumSimErrors: 5}, 95},
+		{"sim errors and demotions", BuilderScoreCounts{NumSubmissions: 100, NumSimErrors: 5, NumDemotions: 2}, 75},
+		{"equivocations", BuilderScoreCounts{NumSubmissions: 100, NumEquivocations: 1}, 95},
+		{"getPayload failures", BuilderScoreCounts{NumSubmissions: 100, NumSimErrors: 5, NumGetPayloadFailures: 3}, 89},
+		{"score is never negative", BuilderScoreCounts{NumSubmissions: 100, NumSimErrors: 50, NumDemotions: 10}, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			score := ComputeBuilderScore("0xabc", c.counts)
+			require.InDelta(t, c.expectedScore, score.Score, 0.0001)
+			if c.counts.NumSubmissions > 0 {
+				require.InDelta(t, 1, score.SuccessRate+score.SimFailureRate, 0.0001)
+			}
+		})
+	}
+}
+
+func TestSlotClock(t *testing.T) {
+	clock := SlotClock{GenesisTime: 1606824023}
+	slotStart := time.Unix(1606824023+int64(100*SecondsPerSlot), 0) //nolint:gosec
+
+	require.Equal(t, uint64(slotStart.Unix()), clock.SlotStartTimestamp(100)) //nolint:gosec
+	require.True(t, slotStart.Equal(clock.SlotStartTime(100)))
+	require.Equal(t, int64(1500), clock.MsIntoSlot(100, slotStart.Add(1500*time.Millisecond)))
+	require.Equal(t, int64(-200), clock.MsIntoSlot(100, slotStart.Add(-200*time.Millisecond)))
+
+	require.Equal(t, uint64(100), clock.SlotAt(slotStart))
+	require.Equal(t, uint64(99), clock.SlotAt(slotStart.Add(-time.Second)))
+	require.Equal(t, uint64(0), clock.SlotAt(time.Unix(0, 0)))
+}
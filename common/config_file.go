@@ -0,0 +1,40 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrInvalidConfigFile = errors.New("invalid config file")
+
+// ReadConfigFile reads a YAML (.yaml, .yml) or TOML (.toml) config file into a map of keys to values, which are
+// strings, numbers, booleans, lists or nested maps
+func ReadConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		config := make(map[string]any)
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+		}
+		return config, nil
+	case ".toml":
+		config := make(map[string]any)
+		if err := toml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidConfigFile, err)
+		}
+		return config, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format of %s, expected .yaml, .yml or .toml", ErrInvalidConfigFile, path)
+	}
+}
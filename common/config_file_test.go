@@ -0,0 +1,90 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestReadConfigFile(t *testing.T) {
+	expected := map[string]any{
+		"network":     "mainnet",
+		"beacon-uris": []any{"http://a:3500", "http://b:3500"},
+		"api": map[string]any{
+			"min-bid":           0.01,
+			"pprof":             true,
+			"http-read-timeout": "1500ms",
+		},
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		path := writeTestConfigFile(t, "relay.yaml", `
+network: mainnet
+beacon-uris:
+  - http://a:3500
+  - http://b:3500
+api:
+  min-bid: 0.01
+  pprof: true
+  http-read-timeout: 1500ms
+`)
+		config, err := ReadConfigFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, config)
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		path := writeTestConfigFile(t, "relay.toml", `
+# shared by all commands
+network = "mainnet"
+beacon-uris = [ "http://a:3500", 'http://b:3500' ] # two nodes
+
+[api]
+min-bid = 0.01
+pprof = true
+"http-read-timeout" = "1500ms"
+`)
+		config, err := ReadConfigFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expected, config)
+	})
+
+	t.Run("toml values", func(t *testing.T) {
+		path := writeTestConfigFile(t, "relay.toml", `
+int = 1_000
+empty = []
+escaped = "a\"b # c"
+multiline = [
+  "a",
+  "b", # trailing comma
+]
+`)
+		config, err := ReadConfigFile(path)
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{"int": int64(1000), "empty": []any{}, "escaped": `a"b # c`, "multiline": []any{"a", "b"}}, config)
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		for name, content := range map[string]string{
+			"unknown.json":   `{}`,
+			"invalid.yaml":   "network: [",
+			"value.toml":     "network = mainnet",
+			"array.toml":     `uris = ["a" "b"]`,
+			"duplicate.toml": "a = 1\na = 2",
+			"header.toml":    "[api",
+			"trailing.toml":  `a = "b" c`,
+		} {
+			_, err := ReadConfigFile(writeTestConfigFile(t, name, content))
+			require.ErrorIs(t, err, ErrInvalidConfigFile, name)
+		}
+	})
+}
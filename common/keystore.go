@@ -0,0 +1,287 @@
+package common
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrKeystoreInvalidPassword  = errors.New("invalid keystore password")
+	ErrKeystoreUnsupported      = errors.New("unsupported keystore")
+	ErrKeystorePubkeyMismatch   = errors.New("keystore pubkey doesn't match the decrypted key")
+	ErrKeystoreParamOutOfBounds = errors.New("keystore parameter out of bounds")
+)
+
+// scrypt parameters of new keystores, as recommended by EIP-2335
+const (
+	keystoreScryptN = 262144
+	keystoreScryptR = 8
+	keystoreScryptP = 1
+)
+
+// bounds of the KDF parameters of keystores, so that a crafted keystore can't exhaust the memory or CPU
+const (
+	keystoreMaxDKLen        = 64
+	keystoreMaxScryptN      = 1 << 20
+	keystoreMaxScryptR      = 16
+	keystoreMaxScryptP      = 16
+	keystoreMaxScryptMemory = 1 << 30 // 128 * n * r bytes
+	keystoreMaxPBKDF2C      = 10_000_000
+)
+
+// Keystore is an EIP-2335 keystore, with a BLS secret key encrypted by a password
+type Keystore struct {
+	Crypto      KeystoreCrypto `json:"crypto"`
+	Description string         `json:"description"`
+	Pubkey      string         `json:"pubkey"`
+	Path        string         `json:"path"`
+	UUID        string         `json:"uuid"`
+	Version     uint64         `json:"version"`
+}
+
+type KeystoreCrypto struct {
+	KDF      KeystoreModule `json:"kdf"`
+	Checksum KeystoreModule `json:"checksum"`
+	Cipher   KeystoreModule `json:"cipher"`
+}
+
+type KeystoreModule struct {
+	Function string         `json:"function"`
+	Params   map[string]any `json:"params"`
+	Message  string         `json:"message"`
+}
+
+// keystorePassword processes a password as specified by EIP-2335: NFKD normalized, without control codes
+func keystorePassword(password string) []byte {
+	return []byte(strings.Map(func(r rune) rune {
+		if r <= 0x1f || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, norm.NFKD.String(password)))
+}
+
+func (m *KeystoreModule) intParam(name string) (int, error) {
+	value, ok := m.Params[name].(float64)
+	if !ok || value < 0 || value != float64(int(value)) {
+		return 0, fmt.Errorf("%w: invalid %s %s parameter", ErrKeystoreUnsupported, m.Function, name)
+	}
+	return int(value), nil
+}
+
+// boundedIntParam returns an int parameter, failing if it is greater than maxValue
+func (m *KeystoreModule) boundedIntParam(name string, maxValue int) (int, error) {
+	value, err := m.intParam(name)
+	if err != nil {
+		return 0, err
+	} else if value > maxValue {
+		return 0, fmt.Errorf("%w: %s %s %d is greater than %d", ErrKeystoreParamOutOfBounds, m.Function, name, value, maxValue)
+	}
+	return value, nil
+}
+
+func (m *KeystoreModule) hexParam(name string) ([]byte, error) {
+	value, ok := m.Params[name].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing %s %s parameter", ErrKeystoreUnsupported, m.Function, name)
+	}
+	return hex.DecodeString(value)
+}
+
+// decryptionKey derives the decryption key from the password with the KDF of the keystore
+func (k *Keystore) decryptionKey(password string) ([]byte, error) {
+	kdf := k.Crypto.KDF
+	salt, err := kdf.hexParam("salt")
+	if err != nil {
+		return nil, err
+	}
+	dklen, err := kdf.boundedIntParam("dklen", keystoreMaxDKLen)
+	if err != nil {
+		return nil, err
+	} else if dklen < 32 {
+		return nil, fmt.Errorf("%w: dklen %d is too short", ErrKeystoreUnsupported, dklen)
+	}
+
+	switch kdf.Function {
+	case "scrypt":
+		n, err := kdf.boundedIntParam("n", keystoreMaxScryptN)
+		if err != nil {
+			return nil, err
+		}
+		r, err := kdf.boundedIntParam("r", keystoreMaxScryptR)
+		if err != nil {
+			return nil, err
+		}
+		p, err := kdf.boundedIntParam("p", keystoreMaxScryptP)
+		if err != nil {
+			return nil, err
+		}
+		if 128*n*r > keystoreMaxScryptMemory {
+			return nil, fmt.Errorf("%w: scrypt memory of n %d and r %d is greater than %d bytes", ErrKeystoreParamOutOfBounds, n, r, keystoreMaxScryptMemory)
+		}
+		return scrypt.Key(keystorePassword(password), salt, n, r, p, dklen)
+	case "pbkdf2":
+		if prf, _ := kdf.Params["prf"].(string); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("%w: pbkdf2 prf %s", ErrKeystoreUnsupported, prf)
+		}
+		c, err := kdf.boundedIntParam("c", keystoreMaxPBKDF2C)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(keystorePassword(password), salt, c, dklen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("%w: kdf %s", ErrKeystoreUnsupported, kdf.Function)
+	}
+}
+
+// Decrypt returns the secret key of the keystore, failing if the password is wrong or if the key doesn't belong to the
+// pubkey of the keystore
+func (k *Keystore) Decrypt(password string) (*bls.SecretKey, error) {
+	if k.Version != 4 {
+		return nil, fmt.Errorf("%w: version %d", ErrKeystoreUnsupported, k.Version)
+	}
+	if k.Crypto.Checksum.Function != "sha256" {
+		return nil, fmt.Errorf("%w: checksum %s", ErrKeystoreUnsupported, k.Crypto.Checksum.Function)
+	}
+	if k.Crypto.Cipher.Function != "aes-128-ctr" {
+		return nil, fmt.Errorf("%w: cipher %s", ErrKeystoreUnsupported, k.Crypto.Cipher.Function)
+	}
+
+	key, err := k.decryptionKey(password)
+	if err != nil {
+		return nil, err
+	}
+	cipherMessage, err := hex.DecodeString(k.Crypto.Cipher.Message)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := hex.DecodeString(k.Crypto.Checksum.Message)
+	if err != nil {
+		return nil, err
+	}
+	expectedChecksum := sha256.Sum256(append(append([]byte{}, key[16:32]...), cipherMessage...))
+	if !bytes.Equal(checksum, expectedChecksum[:]) {
+		return nil, ErrKeystoreInvalidPassword
+	}
+
+	iv, err := k.Crypto.Cipher.hexParam("iv")
+	if err != nil {
+		return nil, err
+	}
+	skBytes, err := aes128CTR(key[:16], iv, cipherMessage)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := bls.SecretKeyFromBytes(skBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// the pubkey is optional in some keystores, but has to match if given
+	if k.Pubkey != "" {
+		blsPubkey, err := bls.PublicKeyFromSecretKey(sk)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(strings.TrimPrefix(k.Pubkey, "0x"), hex.EncodeToString(bls.PublicKeyToBytes(blsPubkey))) {
+			return nil, fmt.Errorf("%w: %s", ErrKeystorePubkeyMismatch, k.Pubkey)
+		}
+	}
+	return sk, nil
+}
+
+func aes128CTR(key, iv, message []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() {
+		return nil, fmt.Errorf("%w: iv length %d", ErrKeystoreUnsupported, len(iv))
+	}
+	out := make([]byte, len(message))
+	cipher.NewCTR(block, iv).XORKeyStream(out, message)
+	return out, nil
+}
+
+// EncryptKeystore returns a keystore of the secret key, encrypted by the password with scrypt and aes-128-ctr
+func EncryptKeystore(sk *bls.SecretKey, password string) (*Keystore, error) {
+	blsPubkey, err := bls.PublicKeyFromSecretKey(sk)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key(keystorePassword(password), salt, keystoreScryptN, keystoreScryptR, keystoreScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	cipherMessage, err := aes128CTR(key[:16], iv, bls.SecretKeyToBytes(sk))
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(append(append([]byte{}, key[16:32]...), cipherMessage...))
+
+	return &Keystore{
+		Crypto: KeystoreCrypto{
+			KDF: KeystoreModule{
+				Function: "scrypt",
+				Params:   map[string]any{"dklen": 32, "n": keystoreScryptN, "r": keystoreScryptR, "p": keystoreScryptP, "salt": hex.EncodeToString(salt)},
+			},
+			Checksum: KeystoreModule{Function: "sha256", Params: map[string]any{}, Message: hex.EncodeToString(checksum[:])},
+			Cipher: KeystoreModule{
+				Function: "aes-128-ctr",
+				Params:   map[string]any{"iv": hex.EncodeToString(iv)},
+				Message:  hex.EncodeToString(cipherMessage),
+			},
+		},
+		Description: "mev-boost-relay key",
+		Pubkey:      hex.EncodeToString(bls.PublicKeyToBytes(blsPubkey)),
+		UUID:        uuid.NewString(),
+		Version:     4,
+	}, nil
+}
+
+// LoadKeystoreSecretKey decrypts the secret key of the keystore file with the password
+func LoadKeystoreSecretKey(keystoreFile, password string) (*bls.SecretKey, error) {
+	keystoreJSON, err := os.ReadFile(keystoreFile)
+	if err != nil {
+		return nil, err
+	}
+	keystore := new(Keystore)
+	if err := json.Unmarshal(keystoreJSON, keystore); err != nil {
+		return nil, err
+	}
+	return keystore.Decrypt(password)
+}
+
+// ReadPasswordFile returns the password of the password file, without the trailing newline
+func ReadPasswordFile(passwordFile string) (string, error) {
+	password, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(password), "\r\n"), nil
+}
@@ -0,0 +1,159 @@
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of EIP-2335
+const (
+	testKeystorePassword = "𝔱𝔢𝔰𝔱𝔭𝔞𝔰𝔰𝔴𝔬𝔯𝔡🔑"
+	testKeystoreSecret   = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+
+	testKeystoreScrypt = `{
+    "crypto": {
+        "kdf": {
+            "function": "scrypt",
+            "params": {
+                "dklen": 32,
+                "n": 262144,
+                "p": 1,
+                "r": 8,
+                "salt": "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"
+            },
+            "message": ""
+        },
+        "checksum": {
+            "function": "sha256",
+            "params": {},
+            "message": "d2217fe5f3e9a1e34581ef8a78f7c9928e436d36dacc5e846690a5581e8ea484"
+        },
+        "cipher": {
+            "function": "aes-128-ctr",
+            "params": {
+                "iv": "264daa3f303d7259501c93d997d84fe6"
+            },
+            "message": "06ae90d55fe0a6e9c5c3bc5b170827b2e5cce3929ed3f116c2811e6366dfe20f"
+        }
+    },
+    "description": "This is a test keystore that uses scrypt to secure the secret.",
+    "pubkey": "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07",
+    "path": "m/12381/60/3141592653/589793238",
+    "uuid": "1d85ae20-35c5-4611-98e8-aa14a633906f",
+    "version": 4
+}`
+
+	testKeystorePBKDF2 = `{
+    "crypto": {
+        "kdf": {
+            "function": "pbkdf2",
+            "params": {
+                "dklen": 32,
+                "c": 262144,
+                "prf": "hmac-sha256",
+                "salt": "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"
+            },
+            "message": ""
+        },
+        "checksum": {
+            "function": "sha256",
+            "params": {},
+            "message": "8a9f5d9912ed7e75ea794bc5a89bca5f193721d30868ade6f73043c6ea6febf1"
+        },
+        "cipher": {
+            "function": "aes-128-ctr",
+            "params": {
+                "iv": "264daa3f303d7259501c93d997d84fe6"
+            },
+            "message": "cee03fde2af33149775b7223e7845e4fb2c8ae1792e5f99fe9ecf474cc8c16ad"
+        }
+    },
+    "description": "This is a test keystore that uses PBKDF2 to secure the secret.",
+    "pubkey": "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07",
+    "path": "m/12381/60/0/0",
+    "uuid": "64625def-3331-4eea-ab6f-782f3ed16a83",
+    "version": 4
+}`
+)
+
+func TestKeystoreDecrypt(t *testing.T) {
+	for name, keystoreJSON := range map[string]string{"scrypt": testKeystoreScrypt, "pbkdf2": testKeystorePBKDF2} {
+		t.Run(name, func(t *testing.T) {
+			keystore := new(Keystore)
+			require.NoError(t, json.Unmarshal([]byte(keystoreJSON), keystore))
+
+			sk, err := keystore.Decrypt(testKeystorePassword)
+			require.NoError(t, err)
+			require.Equal(t, testKeystoreSecret, hex.EncodeToString(bls.SecretKeyToBytes(sk)))
+
+			_, err = keystore.Decrypt("wrong password")
+			require.ErrorIs(t, err, ErrKeystoreInvalidPassword)
+		})
+	}
+}
+
+func TestKeystoreDecryptChecks(t *testing.T) {
+	t.Run("pubkey mismatch", func(t *testing.T) {
+		keystore := new(Keystore)
+		require.NoError(t, json.Unmarshal([]byte(testKeystoreScrypt), keystore))
+		keystore.Pubkey = "0x" + strings.Repeat("ab", 48)
+		_, err := keystore.Decrypt(testKeystorePassword)
+		require.ErrorIs(t, err, ErrKeystorePubkeyMismatch)
+	})
+
+	for name, params := range map[string]map[string]any{
+		"scrypt n":      {"n": float64(1 << 21)},
+		"scrypt memory": {"n": float64(1 << 20), "r": float64(16)},
+		"dklen":         {"dklen": float64(1 << 20)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			keystore := new(Keystore)
+			require.NoError(t, json.Unmarshal([]byte(testKeystoreScrypt), keystore))
+			maps.Copy(keystore.Crypto.KDF.Params, params)
+			_, err := keystore.Decrypt(testKeystorePassword)
+			require.ErrorIs(t, err, ErrKeystoreParamOutOfBounds)
+		})
+	}
+
+	t.Run("pbkdf2 c", func(t *testing.T) {
+		keystore := new(Keystore)
+		require.NoError(t, json.Unmarshal([]byte(testKeystorePBKDF2), keystore))
+		keystore.Crypto.KDF.Params["c"] = float64(1 << 30)
+		_, err := keystore.Decrypt(testKeystorePassword)
+		require.ErrorIs(t, err, ErrKeystoreParamOutOfBounds)
+	})
+}
+
+func TestEncryptKeystore(t *testing.T) {
+	sk, pubkey, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+
+	keystore, err := EncryptKeystore(sk, testKeystorePassword)
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(bls.PublicKeyToBytes(pubkey)), keystore.Pubkey)
+
+	keystoreJSON, err := json.Marshal(keystore)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	keystoreFile := filepath.Join(dir, "keystore.json")
+	passwordFile := filepath.Join(dir, "password.txt")
+	require.NoError(t, os.WriteFile(keystoreFile, keystoreJSON, 0o600))
+	require.NoError(t, os.WriteFile(passwordFile, []byte(testKeystorePassword+"\n"), 0o600))
+
+	password, err := ReadPasswordFile(passwordFile)
+	require.NoError(t, err)
+	require.Equal(t, testKeystorePassword, password)
+
+	decryptedSk, err := LoadKeystoreSecretKey(keystoreFile, password)
+	require.NoError(t, err)
+	require.Equal(t, bls.SecretKeyToBytes(sk), bls.SecretKeyToBytes(decryptedSk))
+}
@@ -0,0 +1,207 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-boost-utils/utils"
+)
+
+var (
+	ErrInvalidSignerSignature = errors.New("signer returned an invalid signature")
+
+	// DefaultRemoteSignerTimeout is the timeout of a signing request to the remote signer
+	DefaultRemoteSignerTimeout = 500 * time.Millisecond
+
+	// DefaultRemoteSignerMaxConcurrent is the maximum number of concurrent signing requests to the remote signer
+	DefaultRemoteSignerMaxConcurrent = 16
+
+	// remoteSignerCacheSize is the number of recent signatures of the remote signer which are cached
+	remoteSignerCacheSize = 1024
+)
+
+// RemoteSignerTypeBuilderBid is the type of the signing requests of builder bids to the remote signer
+const RemoteSignerTypeBuilderBid = "BUILDER_BID"
+
+// Signer signs with the relay BLS key, which is either held in memory or by a remote signer
+type Signer interface {
+	PublicKey() phase0.BLSPubKey
+
+	// Sign returns the signature of a signing root (the hash tree root of the message and the signing domain)
+	Sign(ctx context.Context, root phase0.Root) (phase0.BLSSignature, error)
+}
+
+// LocalSigner signs with a secret key in memory
+type LocalSigner struct {
+	sk     *bls.SecretKey
+	pubkey phase0.BLSPubKey
+}
+
+func NewLocalSigner(sk *bls.SecretKey) (*LocalSigner, error) {
+	if sk == nil {
+		return nil, ErrMissingSecretKey
+	}
+	blsPubkey, err := bls.PublicKeyFromSecretKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := utils.BlsPublicKeyToPublicKey(blsPubkey)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSigner{sk: sk, pubkey: pubkey}, nil
+}
+
+func (s *LocalSigner) PublicKey() phase0.BLSPubKey {
+	return s.pubkey
+}
+
+func (s *LocalSigner) Sign(_ context.Context, root phase0.Root) (sig phase0.BLSSignature, err error) {
+	copy(sig[:], bls.SignatureToBytes(bls.Sign(s.sk, root[:])))
+	return sig, nil
+}
+
+// RemoteSigner signs with a key held by a Web3Signer compatible remote signer, using the eth2 sign endpoint
+// (POST /api/v1/eth2/sign/{pubkey}) with a request of type BUILDER_BID. At most maxConcurrent requests are sent at the
+// same time, and recent signatures are cached, since the same bid can be signed more than once.
+type RemoteSigner struct {
+	url     string
+	pubkey  phase0.BLSPubKey
+	client  http.Client
+	timeout time.Duration
+
+	sem chan struct{}
+
+	cacheLock sync.Mutex
+	cache     map[phase0.Root]phase0.BLSSignature
+	cacheKeys []phase0.Root // in the order of insertion, to evict the oldest signature
+}
+
+func NewRemoteSigner(url string, pubkey phase0.BLSPubKey, timeout time.Duration, maxConcurrent int) *RemoteSigner {
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultRemoteSignerMaxConcurrent
+	}
+	return &RemoteSigner{
+		url:     strings.TrimRight(url, "/"),
+		pubkey:  pubkey,
+		client:  http.Client{Timeout: timeout},
+		timeout: timeout,
+		sem:     make(chan struct{}, maxConcurrent),
+		cache:   make(map[phase0.Root]phase0.BLSSignature),
+	}
+}
+
+func (s *RemoteSigner) PublicKey() phase0.BLSPubKey {
+	return s.pubkey
+}
+
+type remoteSignerRequest struct {
+	Type        string `json:"type"`
+	SigningRoot string `json:"signingRoot"`
+}
+
+type remoteSignerResponse struct {
+	Signature string `json:"signature"`
+}
+
+func (s *RemoteSigner) Sign(ctx context.Context, root phase0.Root) (sig phase0.BLSSignature, err error) {
+	if sig, ok := s.cachedSignature(root); ok {
+		return sig, nil
+	}
+
+	// the deadline applies to waiting for a free request slot as well as to the request
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	case <-ctx.Done():
+		return sig, ctx.Err()
+	}
+
+	url := fmt.Sprintf("%s/api/v1/eth2/sign/%s", s.url, s.pubkey.String())
+	resp, err := makeRequest(ctx, s.client, http.MethodPost, url, remoteSignerRequest{Type: RemoteSignerTypeBuilderBid, SigningRoot: hexutil.Encode(root[:])})
+	if err != nil {
+		return sig, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return sig, err
+	}
+
+	// the signature is returned as JSON or as plain text, depending on the Accept header
+	sigHex := strings.TrimSpace(string(body))
+	if strings.HasPrefix(sigHex, "{") {
+		sigResp := new(remoteSignerResponse)
+		if err := json.Unmarshal(body, sigResp); err != nil {
+			return sig, err
+		}
+		sigHex = sigResp.Signature
+	}
+	sigBytes, err := hexutil.Decode(sigHex)
+	if err != nil || len(sigBytes) != len(sig) {
+		return sig, fmt.Errorf("%w: %s", ErrInvalidSignerSignature, sigHex)
+	}
+	copy(sig[:], sigBytes)
+	s.cacheSignature(root, sig)
+	return sig, nil
+}
+
+func (s *RemoteSigner) cachedSignature(root phase0.Root) (phase0.BLSSignature, bool) {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+	sig, ok := s.cache[root]
+	return sig, ok
+}
+
+func (s *RemoteSigner) cacheSignature(root phase0.Root, sig phase0.BLSSignature) {
+	s.cacheLock.Lock()
+	defer s.cacheLock.Unlock()
+	if _, ok := s.cache[root]; ok {
+		return
+	}
+	if len(s.cacheKeys) >= remoteSignerCacheSize {
+		delete(s.cache, s.cacheKeys[0])
+		s.cacheKeys = s.cacheKeys[1:]
+	}
+	s.cache[root] = sig
+	s.cacheKeys = append(s.cacheKeys, root)
+}
+
+// CheckSigner signs a test message, and verifies the signature with the public key of the signer
+func CheckSigner(ctx context.Context, signer Signer) error {
+	var root phase0.Root
+	copy(root[:], "mev-boost-relay signer check")
+	sig, err := signer.Sign(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	pubkey := signer.PublicKey()
+	blsPubkey, err := bls.PublicKeyFromBytes(pubkey[:])
+	if err != nil {
+		return err
+	}
+	blsSig, err := bls.SignatureFromBytes(sig[:])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSignerSignature, err)
+	}
+	ok, err := bls.VerifySignature(blsSig, blsPubkey, root[:])
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("%w: signature does not match the public key %s", ErrInvalidSignerSignature, pubkey.String())
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalSigner(t *testing.T) {
+	signer := NewTestSigner(t)
+	require.NoError(t, CheckSigner(context.Background(), signer))
+
+	_, err := NewLocalSigner(nil)
+	require.ErrorIs(t, err, ErrMissingSecretKey)
+}
+
+func TestRemoteSigner(t *testing.T) {
+	localSigner := NewTestSigner(t)
+	pubkey := localSigner.PublicKey()
+
+	respondJSON := false
+	var numRequests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		numRequests.Add(1)
+		require.Equal(t, "/api/v1/eth2/sign/"+pubkey.String(), r.URL.Path)
+		req := new(remoteSignerRequest)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(req))
+		require.Equal(t, RemoteSignerTypeBuilderBid, req.Type)
+
+		var root phase0.Root
+		copy(root[:], hexutil.MustDecode(req.SigningRoot))
+		sig, err := localSigner.Sign(r.Context(), root)
+		require.NoError(t, err)
+		if respondJSON {
+			require.NoError(t, json.NewEncoder(w).Encode(remoteSignerResponse{Signature: sig.String()}))
+		} else {
+			_, err = w.Write([]byte(sig.String()))
+			require.NoError(t, err)
+		}
+	}))
+	defer srv.Close()
+
+	// signatures as plain text and as JSON, and the signature of the same root is cached
+	signer := NewRemoteSigner(srv.URL+"/", pubkey, DefaultRemoteSignerTimeout, 1)
+	require.NoError(t, CheckSigner(context.Background(), signer))
+	require.NoError(t, CheckSigner(context.Background(), signer))
+	require.Equal(t, int64(1), numRequests.Load())
+	respondJSON = true
+	_, err := signer.Sign(context.Background(), phase0.Root{1})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), numRequests.Load())
+
+	// the signature of another key is detected
+	otherSigner := NewRemoteSigner(srv.URL, NewTestSigner(t).PublicKey(), DefaultRemoteSignerTimeout, 1)
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sig, err := localSigner.Sign(r.Context(), phase0.Root{})
+		require.NoError(t, err)
+		_, err = w.Write([]byte(sig.String()))
+		require.NoError(t, err)
+	})
+	require.ErrorIs(t, CheckSigner(context.Background(), otherSigner), ErrInvalidSignerSignature)
+
+	// errors of the remote signer are returned
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	_, err = signer.Sign(context.Background(), phase0.Root{2})
+	require.ErrorIs(t, err, ErrHTTPErrorResponse)
+
+	// a request which waits for a free request slot longer than the timeout fails
+	blockingSigner := NewRemoteSigner(srv.URL, pubkey, 50*time.Millisecond, 1)
+	blockingSigner.sem <- struct{}{}
+	_, err = blockingSigner.Sign(context.Background(), phase0.Root{3})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"io"
@@ -123,6 +124,15 @@ func TestBuilderSubmitBlockRequest(sk *bls.SecretKey, bid *BidTraceV2WithBlobFie
 	}
 }
 
+// NewTestSigner returns a local signer with a random secret key
+func NewTestSigner(t require.TestingT) *LocalSigner {
+	sk, _, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+	signer, err := NewLocalSigner(sk)
+	require.NoError(t, err)
+	return signer
+}
+
 type CreateTestBlockSubmissionOpts struct {
 	relaySk bls.SecretKey
 	relayPk phase0.BLSPubKey
@@ -207,7 +217,7 @@ func CreateTestBlockSubmission(t *testing.T, builderPubkey string, value *uint25
 		}
 	}
 
-	getHeaderResponse, err = BuildGetHeaderResponse(payload, &relaySk, &relayPk, domain)
+	getHeaderResponse, err = BuildGetHeaderResponse(context.Background(), payload, &LocalSigner{sk: &relaySk, pubkey: relayPk}, domain)
 	require.NoError(t, err)
 
 	getPayloadResponse, err = BuildGetPayloadResponse(payload)
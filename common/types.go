@@ -1,10 +1,12 @@
 package common
 
 import (
+	"cmp"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -14,46 +16,57 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/electra"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	ssz "github.com/ferranbt/fastssz"
 	boostSsz "github.com/flashbots/go-boost-utils/ssz"
+	"github.com/holiman/uint256"
 )
 
 var (
-	ErrUnknownNetwork = errors.New("unknown network")
-	ErrEmptyPayload   = errors.New("empty payload")
+	ErrUnknownNetwork     = errors.New("unknown network")
+	ErrEmptyPayload       = errors.New("empty payload")
+	ErrUnknownForkVersion = errors.New("unknown fork version")
 
 	EthNetworkHolesky = "holesky"
+	EthNetworkHoodi   = "hoodi"
 	EthNetworkSepolia = "sepolia"
 	EthNetworkGoerli  = "goerli"
 	EthNetworkMainnet = "mainnet"
 	EthNetworkCustom  = "custom"
+	EthNetworkBeacon  = "beacon" // network details are loaded from the beacon node (see beaconclient.NewEthNetworkDetails)
 
 	GenesisForkVersionHolesky = "0x01017000"
+	GenesisForkVersionHoodi   = "0x10000910"
 	GenesisForkVersionSepolia = "0x90000069"
 	GenesisForkVersionGoerli  = "0x00001020"
 	GenesisForkVersionMainnet = "0x00000000"
 
 	GenesisValidatorsRootHolesky = "0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"
+	GenesisValidatorsRootHoodi   = "0x212f13fc4df078b6cb7db228f1c8307566dcecf900867401a92023d7ba99cb5f"
 	GenesisValidatorsRootSepolia = "0xd8ea171f3c94aea21ebc42a1ed61052acf3f9209c00e4efbaaddac09ed9b8078"
 	GenesisValidatorsRootGoerli  = "0x043db0d9a83813551ee2f33450d23797757d430911a9320530ad8a0eabc43efb"
 	GenesisValidatorsRootMainnet = "0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"
 
 	BellatrixForkVersionHolesky = "0x03017000"
+	BellatrixForkVersionHoodi   = "0x30000910"
 	BellatrixForkVersionSepolia = "0x90000071"
 	BellatrixForkVersionGoerli  = "0x02001020"
 	BellatrixForkVersionMainnet = "0x02000000"
 
 	CapellaForkVersionHolesky = "0x04017000"
+	CapellaForkVersionHoodi   = "0x40000910"
 	CapellaForkVersionSepolia = "0x90000072"
 	CapellaForkVersionGoerli  = "0x03001020"
 	CapellaForkVersionMainnet = "0x03000000"
 
 	DenebForkVersionHolesky = "0x05017000"
+	DenebForkVersionHoodi   = "0x50000910"
 	DenebForkVersionSepolia = "0x90000073"
 	DenebForkVersionGoerli  = "0x04001020"
 	DenebForkVersionMainnet = "0x04000000"
 
 	ElectraForkVersionHolesky = "0x06017000"
+	ElectraForkVersionHoodi   = "0x60000910"
 	ElectraForkVersionSepolia = "0x90000074"
 	ElectraForkVersionGoerli  = "0x05001020"
 	ElectraForkVersionMainnet = "0x05000000"
@@ -74,99 +87,149 @@ type EthNetworkDetails struct {
 	ElectraForkVersionHex    string
 
 	DomainBuilder                 phase0.Domain
+	DomainProposerPreferences     phase0.Domain
 	DomainBeaconProposerBellatrix phase0.Domain
 	DomainBeaconProposerCapella   phase0.Domain
 	DomainBeaconProposerDeneb     phase0.Domain
 	DomainBeaconProposerElectra   phase0.Domain
+
+	// ForkSchedule is sorted by epoch, and set from the fork schedule of the beacon node (see SetForkSchedule)
+	ForkSchedule []ForkScheduleEntry
+}
+
+// ForkScheduleEntry is a fork version which activates at the given epoch
+type ForkScheduleEntry struct {
+	Epoch          uint64
+	CurrentVersion string
+}
+
+// EthNetworkForkVersions are the genesis validators root and fork versions which identify a network
+type EthNetworkForkVersions struct {
+	GenesisForkVersion    string
+	GenesisValidatorsRoot string
+	BellatrixForkVersion  string
+	CapellaForkVersion    string
+	DenebForkVersion      string
+	ElectraForkVersion    string
+}
+
+var ethNetworkPresets = map[string]EthNetworkForkVersions{
+	EthNetworkHolesky: {
+		GenesisForkVersion:    GenesisForkVersionHolesky,
+		GenesisValidatorsRoot: GenesisValidatorsRootHolesky,
+		BellatrixForkVersion:  BellatrixForkVersionHolesky,
+		CapellaForkVersion:    CapellaForkVersionHolesky,
+		DenebForkVersion:      DenebForkVersionHolesky,
+		ElectraForkVersion:    ElectraForkVersionHolesky,
+	},
+	EthNetworkHoodi: {
+		GenesisForkVersion:    GenesisForkVersionHoodi,
+		GenesisValidatorsRoot: GenesisValidatorsRootHoodi,
+		BellatrixForkVersion:  BellatrixForkVersionHoodi,
+		CapellaForkVersion:    CapellaForkVersionHoodi,
+		DenebForkVersion:      DenebForkVersionHoodi,
+		ElectraForkVersion:    ElectraForkVersionHoodi,
+	},
+	EthNetworkSepolia: {
+		GenesisForkVersion:    GenesisForkVersionSepolia,
+		GenesisValidatorsRoot: GenesisValidatorsRootSepolia,
+		BellatrixForkVersion:  BellatrixForkVersionSepolia,
+		CapellaForkVersion:    CapellaForkVersionSepolia,
+		DenebForkVersion:      DenebForkVersionSepolia,
+		ElectraForkVersion:    ElectraForkVersionSepolia,
+	},
+	EthNetworkGoerli: {
+		GenesisForkVersion:    GenesisForkVersionGoerli,
+		GenesisValidatorsRoot: GenesisValidatorsRootGoerli,
+		BellatrixForkVersion:  BellatrixForkVersionGoerli,
+		CapellaForkVersion:    CapellaForkVersionGoerli,
+		DenebForkVersion:      DenebForkVersionGoerli,
+		ElectraForkVersion:    ElectraForkVersionGoerli,
+	},
+	EthNetworkMainnet: {
+		GenesisForkVersion:    GenesisForkVersionMainnet,
+		GenesisValidatorsRoot: GenesisValidatorsRootMainnet,
+		BellatrixForkVersion:  BellatrixForkVersionMainnet,
+		CapellaForkVersion:    CapellaForkVersionMainnet,
+		DenebForkVersion:      DenebForkVersionMainnet,
+		ElectraForkVersion:    ElectraForkVersionMainnet,
+	},
 }
 
+// NewEthNetworkDetails returns the details of a named network, or of a custom network defined by environment variables.
+// The details of EthNetworkBeacon can't be created here, they are loaded from the beacon node.
 func NewEthNetworkDetails(networkName string) (ret *EthNetworkDetails, err error) {
-	var genesisForkVersion string
-	var genesisValidatorsRoot string
-	var bellatrixForkVersion string
-	var capellaForkVersion string
-	var denebForkVersion string
-	var electraForkVersion string
+	switch networkName {
+	case EthNetworkCustom:
+		return NewEthNetworkDetailsFromForkVersions(networkName, EthNetworkForkVersions{
+			GenesisForkVersion:    os.Getenv("GENESIS_FORK_VERSION"),
+			GenesisValidatorsRoot: os.Getenv("GENESIS_VALIDATORS_ROOT"),
+			BellatrixForkVersion:  os.Getenv("BELLATRIX_FORK_VERSION"),
+			CapellaForkVersion:    os.Getenv("CAPELLA_FORK_VERSION"),
+			DenebForkVersion:      os.Getenv("DENEB_FORK_VERSION"),
+			ElectraForkVersion:    os.Getenv("ELECTRA_FORK_VERSION"),
+		})
+	case EthNetworkBeacon:
+		return nil, fmt.Errorf("%w: %s needs to be loaded from the beacon node", ErrUnknownNetwork, networkName)
+	}
+
+	forkVersions, ok := ethNetworkPresets[networkName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, networkName)
+	}
+	return NewEthNetworkDetailsFromForkVersions(networkName, forkVersions)
+}
+
+// NewEthNetworkDetailsFromForkVersions returns the details of a network with the given fork versions, and computes its
+// signing domains
+func NewEthNetworkDetailsFromForkVersions(networkName string, forkVersions EthNetworkForkVersions) (ret *EthNetworkDetails, err error) {
 	var domainBuilder phase0.Domain
+	var domainProposerPreferences phase0.Domain
 	var domainBeaconProposerBellatrix phase0.Domain
 	var domainBeaconProposerCapella phase0.Domain
 	var domainBeaconProposerDeneb phase0.Domain
 	var domainBeaconProposerElectra phase0.Domain
 
-	switch networkName {
-	case EthNetworkHolesky:
-		genesisForkVersion = GenesisForkVersionHolesky
-		genesisValidatorsRoot = GenesisValidatorsRootHolesky
-		bellatrixForkVersion = BellatrixForkVersionHolesky
-		capellaForkVersion = CapellaForkVersionHolesky
-		denebForkVersion = DenebForkVersionHolesky
-		electraForkVersion = ElectraForkVersionHolesky
-	case EthNetworkSepolia:
-		genesisForkVersion = GenesisForkVersionSepolia
-		genesisValidatorsRoot = GenesisValidatorsRootSepolia
-		bellatrixForkVersion = BellatrixForkVersionSepolia
-		capellaForkVersion = CapellaForkVersionSepolia
-		denebForkVersion = DenebForkVersionSepolia
-		electraForkVersion = ElectraForkVersionSepolia
-	case EthNetworkGoerli:
-		genesisForkVersion = GenesisForkVersionGoerli
-		genesisValidatorsRoot = GenesisValidatorsRootGoerli
-		bellatrixForkVersion = BellatrixForkVersionGoerli
-		capellaForkVersion = CapellaForkVersionGoerli
-		denebForkVersion = DenebForkVersionGoerli
-		electraForkVersion = ElectraForkVersionGoerli
-	case EthNetworkMainnet:
-		genesisForkVersion = GenesisForkVersionMainnet
-		genesisValidatorsRoot = GenesisValidatorsRootMainnet
-		bellatrixForkVersion = BellatrixForkVersionMainnet
-		capellaForkVersion = CapellaForkVersionMainnet
-		denebForkVersion = DenebForkVersionMainnet
-		electraForkVersion = ElectraForkVersionMainnet
-	case EthNetworkCustom:
-		genesisForkVersion = os.Getenv("GENESIS_FORK_VERSION")
-		genesisValidatorsRoot = os.Getenv("GENESIS_VALIDATORS_ROOT")
-		bellatrixForkVersion = os.Getenv("BELLATRIX_FORK_VERSION")
-		capellaForkVersion = os.Getenv("CAPELLA_FORK_VERSION")
-		denebForkVersion = os.Getenv("DENEB_FORK_VERSION")
-		electraForkVersion = os.Getenv("ELECTRA_FORK_VERSION")
-	default:
-		return nil, fmt.Errorf("%w: %s", ErrUnknownNetwork, networkName)
+	domainBuilder, err = ComputeDomain(boostSsz.DomainTypeAppBuilder, forkVersions.GenesisForkVersion, phase0.Root{}.String())
+	if err != nil {
+		return nil, err
 	}
 
-	domainBuilder, err = ComputeDomain(boostSsz.DomainTypeAppBuilder, genesisForkVersion, phase0.Root{}.String())
+	domainProposerPreferences, err = ComputeDomain(DomainTypeProposerPreferences, forkVersions.GenesisForkVersion, phase0.Root{}.String())
 	if err != nil {
 		return nil, err
 	}
 
-	domainBeaconProposerBellatrix, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, bellatrixForkVersion, genesisValidatorsRoot)
+	domainBeaconProposerBellatrix, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, forkVersions.BellatrixForkVersion, forkVersions.GenesisValidatorsRoot)
 	if err != nil {
 		return nil, err
 	}
 
-	domainBeaconProposerCapella, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, capellaForkVersion, genesisValidatorsRoot)
+	domainBeaconProposerCapella, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, forkVersions.CapellaForkVersion, forkVersions.GenesisValidatorsRoot)
 	if err != nil {
 		return nil, err
 	}
 
-	domainBeaconProposerDeneb, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, denebForkVersion, genesisValidatorsRoot)
+	domainBeaconProposerDeneb, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, forkVersions.DenebForkVersion, forkVersions.GenesisValidatorsRoot)
 	if err != nil {
 		return nil, err
 	}
 
-	domainBeaconProposerElectra, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, electraForkVersion, genesisValidatorsRoot)
+	domainBeaconProposerElectra, err = ComputeDomain(boostSsz.DomainTypeBeaconProposer, forkVersions.ElectraForkVersion, forkVersions.GenesisValidatorsRoot)
 	if err != nil {
 		return nil, err
 	}
 
 	return &EthNetworkDetails{
 		Name:                          networkName,
-		GenesisForkVersionHex:         genesisForkVersion,
-		GenesisValidatorsRootHex:      genesisValidatorsRoot,
-		BellatrixForkVersionHex:       bellatrixForkVersion,
-		CapellaForkVersionHex:         capellaForkVersion,
-		DenebForkVersionHex:           denebForkVersion,
-		ElectraForkVersionHex:         electraForkVersion,
+		GenesisForkVersionHex:         forkVersions.GenesisForkVersion,
+		GenesisValidatorsRootHex:      forkVersions.GenesisValidatorsRoot,
+		BellatrixForkVersionHex:       forkVersions.BellatrixForkVersion,
+		CapellaForkVersionHex:         forkVersions.CapellaForkVersion,
+		DenebForkVersionHex:           forkVersions.DenebForkVersion,
+		ElectraForkVersionHex:         forkVersions.ElectraForkVersion,
 		DomainBuilder:                 domainBuilder,
+		DomainProposerPreferences:     domainProposerPreferences,
 		DomainBeaconProposerBellatrix: domainBeaconProposerBellatrix,
 		DomainBeaconProposerCapella:   domainBeaconProposerCapella,
 		DomainBeaconProposerDeneb:     domainBeaconProposerDeneb,
@@ -174,6 +237,43 @@ func NewEthNetworkDetails(networkName string) (ret *EthNetworkDetails, err error
 	}, nil
 }
 
+// SetForkSchedule sets the fork schedule, i.e. as returned by the /eth/v1/config/fork_schedule endpoint of the beacon node
+func (e *EthNetworkDetails) SetForkSchedule(forks []ForkScheduleEntry) {
+	e.ForkSchedule = slices.Clone(forks)
+	slices.SortStableFunc(e.ForkSchedule, func(a, b ForkScheduleEntry) int {
+		return cmp.Compare(a.Epoch, b.Epoch)
+	})
+}
+
+// ForkVersionAtSlot returns the fork version which is active at the given slot, according to the fork schedule
+func (e *EthNetworkDetails) ForkVersionAtSlot(slot uint64) string {
+	epoch := SlotToEpoch(slot)
+	version := e.GenesisForkVersionHex
+	for _, fork := range e.ForkSchedule {
+		if fork.Epoch > epoch {
+			break
+		}
+		version = fork.CurrentVersion
+	}
+	return version
+}
+
+// DomainBeaconProposerAtSlot returns the beacon proposer domain for blocks of the given slot
+func (e *EthNetworkDetails) DomainBeaconProposerAtSlot(slot uint64) (phase0.Domain, error) {
+	switch version := e.ForkVersionAtSlot(slot); version {
+	case e.ElectraForkVersionHex:
+		return e.DomainBeaconProposerElectra, nil
+	case e.DenebForkVersionHex:
+		return e.DomainBeaconProposerDeneb, nil
+	case e.CapellaForkVersionHex:
+		return e.DomainBeaconProposerCapella, nil
+	case e.BellatrixForkVersionHex:
+		return e.DomainBeaconProposerBellatrix, nil
+	default:
+		return phase0.Domain{}, fmt.Errorf("%w: %s at slot %d", ErrUnknownForkVersion, version, slot)
+	}
+}
+
 func (e *EthNetworkDetails) String() string {
 	return fmt.Sprintf(
 		`EthNetworkDetails{
@@ -185,6 +285,7 @@ func (e *EthNetworkDetails) String() string {
 	DenebForkVersionHex: %s,
 	ElectraForkVersionHex: %s,
 	DomainBuilder: %x,
+	DomainProposerPreferences: %x,
 	DomainBeaconProposerBellatrix: %x,
 	DomainBeaconProposerCapella: %x,
 	DomainBeaconProposerDeneb: %x
@@ -198,6 +299,7 @@ func (e *EthNetworkDetails) String() string {
 		e.DenebForkVersionHex,
 		e.ElectraForkVersionHex,
 		e.DomainBuilder,
+		e.DomainProposerPreferences,
 		e.DomainBeaconProposerBellatrix,
 		e.DomainBeaconProposerCapella,
 		e.DomainBeaconProposerDeneb,
@@ -218,6 +320,169 @@ type BuilderGetValidatorsResponseEntry struct {
 	Slot           uint64                                    `json:"slot,string"`
 	ValidatorIndex uint64                                    `json:"validator_index,string"`
 	Entry          *builderApiV1.SignedValidatorRegistration `json:"entry"`
+	Preferences    *ProposerPreferences                      `json:"preferences,omitempty"`
+
+	// Set by the API, so builders can build blocks which pass the checks of the relay
+	GasLimit       uint64              `json:"gas_limit,string"` // registered gas limit, which submissions are checked against
+	HasPreferences bool                `json:"has_preferences"`  // whether the proposer set relay-specific preferences
+	Parent         *ProposerDutyParent `json:"parent,omitempty"` // only once the payload attributes of the slot are known
+}
+
+// ProposerDutyParent is the parent block a block for the slot has to build on, with the fields a submission has to
+// match, from the latest payload attributes of the slot
+type ProposerDutyParent struct {
+	ParentHash            string `json:"parent_hash"`
+	BlockNumber           uint64 `json:"block_number,string"` // number of the block to build
+	Timestamp             uint64 `json:"timestamp,string"`
+	PrevRandao            string `json:"prev_randao"`
+	WithdrawalsRoot       string `json:"withdrawals_root,omitempty"`
+	ParentBeaconBlockRoot string `json:"parent_beacon_block_root,omitempty"`
+}
+
+// MaxProposerBlockedBuilders is the maximum number of builders a proposer can block
+const MaxProposerBlockedBuilders = 128
+
+// DomainTypeProposerPreferences is the application domain type of the proposer preferences. It differs from the builder
+// domain type, so signed preferences can't be replayed as a validator registration or the other way around.
+var DomainTypeProposerPreferences = phase0.DomainType{0x00, 0x00, 0x01, 0x01}
+
+// ProposerPreferences are policies a validator sets for its own slots, signed with DomainTypeProposerPreferences
+type ProposerPreferences struct {
+	Pubkey             phase0.BLSPubKey
+	Timestamp          uint64
+	MinBidValue        *uint256.Int       // getHeader responds with 204 for lower bids
+	BlockedBuilders    []phase0.BLSPubKey // block submissions of these builders are rejected
+	MaxGasUsed         uint64             // block submissions using more gas are rejected (0 for no limit)
+	RequirePaymentLast bool               // block submissions must end with the payment to the fee recipient
+}
+
+type SignedProposerPreferences struct {
+	Message   *ProposerPreferences `json:"message"`
+	Signature phase0.BLSSignature  `json:"signature"`
+}
+
+type ProposerPreferencesJSON struct {
+	Pubkey             string   `json:"pubkey"`
+	Timestamp          uint64   `json:"timestamp,string"`
+	MinBidValue        string   `json:"min_bid_value"`
+	BlockedBuilders    []string `json:"blocked_builders"`
+	MaxGasUsed         uint64   `json:"max_gas_used,string"`
+	RequirePaymentLast bool     `json:"require_payment_last"`
+}
+
+func (p ProposerPreferences) MarshalJSON() ([]byte, error) {
+	blockedBuilders := make([]string, len(p.BlockedBuilders))
+	for i, builderPubkey := range p.BlockedBuilders {
+		blockedBuilders[i] = builderPubkey.String()
+	}
+	minBidValue := "0"
+	if p.MinBidValue != nil {
+		minBidValue = p.MinBidValue.Dec()
+	}
+	return json.Marshal(&ProposerPreferencesJSON{
+		Pubkey:             p.Pubkey.String(),
+		Timestamp:          p.Timestamp,
+		MinBidValue:        minBidValue,
+		BlockedBuilders:    blockedBuilders,
+		MaxGasUsed:         p.MaxGasUsed,
+		RequirePaymentLast: p.RequirePaymentLast,
+	})
+}
+
+func (p *ProposerPreferences) UnmarshalJSON(data []byte) error {
+	params := new(ProposerPreferencesJSON)
+	err := json.Unmarshal(data, params)
+	if err != nil {
+		return err
+	}
+
+	pubkey, err := hexToBLSPubkey(params.Pubkey)
+	if err != nil {
+		return err
+	}
+	minBidValue := new(uint256.Int)
+	if params.MinBidValue != "" {
+		if err := minBidValue.SetFromDecimal(params.MinBidValue); err != nil {
+			return fmt.Errorf("invalid min_bid_value: %w", err)
+		}
+	}
+	if len(params.BlockedBuilders) > MaxProposerBlockedBuilders {
+		return fmt.Errorf("%w: more than %d blocked builders", ssz.ErrListTooBig, MaxProposerBlockedBuilders)
+	}
+	blockedBuilders := make([]phase0.BLSPubKey, len(params.BlockedBuilders))
+	for i, builderPubkey := range params.BlockedBuilders {
+		blockedBuilders[i], err = hexToBLSPubkey(builderPubkey)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.Pubkey = pubkey
+	p.Timestamp = params.Timestamp
+	p.MinBidValue = minBidValue
+	p.BlockedBuilders = blockedBuilders
+	p.MaxGasUsed = params.MaxGasUsed
+	p.RequirePaymentLast = params.RequirePaymentLast
+	return nil
+}
+
+func hexToBLSPubkey(s string) (pubkey phase0.BLSPubKey, err error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != len(pubkey) {
+		return pubkey, fmt.Errorf("%w: %s", ErrInvalidPubkey, s)
+	}
+	copy(pubkey[:], b)
+	return pubkey, nil
+}
+
+// IsBuilderBlocked returns true if the proposer blocked the builder with the given pubkey
+func (p *ProposerPreferences) IsBuilderBlocked(builderPubkey phase0.BLSPubKey) bool {
+	return slices.Contains(p.BlockedBuilders, builderPubkey)
+}
+
+// HashTreeRoot returns the SSZ hash tree root of the preferences, as container
+// { pubkey: BLSPubkey, timestamp: uint64, min_bid_value: uint256, blocked_builders: List[BLSPubkey, 128],
+// max_gas_used: uint64, require_payment_last: boolean }
+func (p *ProposerPreferences) HashTreeRoot() ([32]byte, error) {
+	hh := ssz.DefaultHasherPool.Get()
+	defer ssz.DefaultHasherPool.Put(hh)
+	if err := p.HashTreeRootWith(hh); err != nil {
+		return [32]byte{}, err
+	}
+	return hh.HashRoot()
+}
+
+func (p *ProposerPreferences) HashTreeRootWith(hh ssz.HashWalker) (err error) {
+	indx := hh.Index()
+
+	hh.PutBytes(p.Pubkey[:])
+	hh.PutUint64(p.Timestamp)
+
+	// uint256 is hashed little-endian
+	minBidValue := new(uint256.Int)
+	if p.MinBidValue != nil {
+		minBidValue = p.MinBidValue
+	}
+	minBidValueBE := minBidValue.Bytes32()
+	hh.PutBytes(reverse(minBidValueBE[:]))
+
+	{
+		subIndx := hh.Index()
+		num := uint64(len(p.BlockedBuilders))
+		if num > MaxProposerBlockedBuilders {
+			return ssz.ErrIncorrectListSize
+		}
+		for _, builderPubkey := range p.BlockedBuilders {
+			hh.PutBytes(builderPubkey[:])
+		}
+		hh.MerkleizeWithMixin(subIndx, num, MaxProposerBlockedBuilders)
+	}
+
+	hh.PutUint64(p.MaxGasUsed)
+	hh.PutBool(p.RequirePaymentLast)
+
+	hh.Merkleize(indx)
+	return nil
 }
 
 type BidTraceV2 struct {
@@ -238,6 +503,7 @@ type BidTraceV2JSON struct {
 	Value                string `json:"value"`
 	NumTx                uint64 `json:"num_tx,string"`
 	BlockNumber          uint64 `json:"block_number,string"`
+	AdjustedValue        string `json:"adjusted_value,omitempty"` // value served to proposers, if changed by a bid adjustment
 }
 
 func (b BidTraceV2) MarshalJSON() ([]byte, error) {
@@ -311,9 +577,25 @@ func (b *BidTraceV2JSON) ToCSVRecord() []string {
 
 type BidTraceV2WithTimestampJSON struct {
 	BidTraceV2JSON
-	Timestamp            int64 `json:"timestamp,string,omitempty"`
-	TimestampMs          int64 `json:"timestamp_ms,string,omitempty"`
-	OptimisticSubmission bool  `json:"optimistic_submission"`
+	ID                   int64                  `json:"id,string,omitempty"` // to be used as cursor for the next page
+	Timestamp            int64                  `json:"timestamp,string,omitempty"`
+	TimestampMs          int64                  `json:"timestamp_ms,string,omitempty"`
+	OptimisticSubmission bool                   `json:"optimistic_submission"`
+	Latency              *SubmissionLatencyJSON `json:"latency,omitempty"`
+	SimSuccess           *bool                  `json:"sim_success,omitempty"` // only if requested, nil if the submission wasn't simulated
+	SimError             string                 `json:"sim_error,omitempty"`
+	FilterError          string                 `json:"filter_error,omitempty"`
+}
+
+// SubmissionLatencyJSON is the breakdown of the time spent processing a block submission, in microseconds
+type SubmissionLatencyJSON struct {
+	DecodeUs         uint64 `json:"decode_us,string"`
+	PrechecksUs      uint64 `json:"prechecks_us,string"`
+	SignatureCheckUs uint64 `json:"signature_check_us,string"`
+	SimQueueWaitUs   uint64 `json:"sim_queue_wait_us,string"`
+	SimulationUs     uint64 `json:"simulation_us,string"`
+	RedisUpdateUs    uint64 `json:"redis_update_us,string"`
+	TotalUs          uint64 `json:"total_us,string"`
 }
 
 func (b *BidTraceV2WithTimestampJSON) CSVHeader() []string {
@@ -332,6 +614,7 @@ func (b *BidTraceV2WithTimestampJSON) CSVHeader() []string {
 		"timestamp",
 		"timestamp_ms",
 		"optimistic_submission",
+		"id", // to be used as cursor for the next page
 	}
 }
 
@@ -351,6 +634,86 @@ func (b *BidTraceV2WithTimestampJSON) ToCSVRecord() []string {
 		strconv.FormatInt(b.Timestamp, 10),
 		strconv.FormatInt(b.TimestampMs, 10),
 		strconv.FormatBool(b.OptimisticSubmission),
+		strconv.FormatInt(b.ID, 10),
+	}
+}
+
+// GetHeaderLogJSON is a bid which was served in response to a getHeader request
+type GetHeaderLogJSON struct {
+	Slot           uint64 `json:"slot,string"`
+	ParentHash     string `json:"parent_hash"`
+	ProposerPubkey string `json:"proposer_pubkey"`
+	BlockHash      string `json:"block_hash"`
+	Value          string `json:"value"`
+	UserAgent      string `json:"user_agent"`
+	TimestampMs    int64  `json:"timestamp_ms,string"`
+	LatencyMs      uint64 `json:"latency_ms,string"`
+}
+
+func (g *GetHeaderLogJSON) CSVHeader() []string {
+	return []string{
+		"slot",
+		"parent_hash",
+		"proposer_pubkey",
+		"block_hash",
+		"value",
+		"user_agent",
+		"timestamp_ms",
+		"latency_ms",
+	}
+}
+
+func (g *GetHeaderLogJSON) ToCSVRecord() []string {
+	return []string{
+		strconv.FormatUint(g.Slot, 10),
+		g.ParentHash,
+		g.ProposerPubkey,
+		g.BlockHash,
+		g.Value,
+		g.UserAgent,
+		strconv.FormatInt(g.TimestampMs, 10),
+		strconv.FormatUint(g.LatencyMs, 10),
+	}
+}
+
+// GetPayloadFailureJSON is a getPayload request which failed, with the reason code and the returned error
+type GetPayloadFailureJSON struct {
+	Slot           uint64 `json:"slot,string"`
+	ProposerIndex  uint64 `json:"proposer_index,string"`
+	ProposerPubkey string `json:"proposer_pubkey"`
+	BlockHash      string `json:"block_hash"`
+	Reason         string `json:"reason"`
+	Error          string `json:"error"`
+	UserAgent      string `json:"user_agent"`
+	TimestampMs    int64  `json:"timestamp_ms,string"`
+	MsIntoSlot     int64  `json:"ms_into_slot,string"`
+}
+
+func (g *GetPayloadFailureJSON) CSVHeader() []string {
+	return []string{
+		"slot",
+		"proposer_index",
+		"proposer_pubkey",
+		"block_hash",
+		"reason",
+		"error",
+		"user_agent",
+		"timestamp_ms",
+		"ms_into_slot",
+	}
+}
+
+func (g *GetPayloadFailureJSON) ToCSVRecord() []string {
+	return []string{
+		strconv.FormatUint(g.Slot, 10),
+		strconv.FormatUint(g.ProposerIndex, 10),
+		g.ProposerPubkey,
+		g.BlockHash,
+		g.Reason,
+		g.Error,
+		g.UserAgent,
+		strconv.FormatInt(g.TimestampMs, 10),
+		strconv.FormatInt(g.MsIntoSlot, 10),
 	}
 }
 
@@ -361,6 +724,9 @@ type BidTraceV2WithBlobFields struct {
 	NumBlobs      uint64 `db:"num_blobs"       json:"num_blobs,string"`
 	BlobGasUsed   uint64 `db:"blob_gas_used"   json:"blob_gas_used,string"`
 	ExcessBlobGas uint64 `db:"excess_blob_gas" json:"excess_blob_gas,string"`
+
+	// AdjustedValue is the value of the bid served to proposers, if it was changed by a bid adjustment
+	AdjustedValue *uint256.Int `db:"-" json:"adjusted_value,omitempty"`
 }
 
 type BidTraceV2WithBlobFieldsJSON struct {
@@ -378,9 +744,14 @@ type BidTraceV2WithBlobFieldsJSON struct {
 	NumBlobs             uint64 `json:"num_blobs,string"`
 	BlobGasUsed          uint64 `json:"blob_gas_used,string"`
 	ExcessBlobGas        uint64 `json:"excess_blob_gas,string"`
+	AdjustedValue        string `json:"adjusted_value,omitempty"`
 }
 
 func (b BidTraceV2WithBlobFields) MarshalJSON() ([]byte, error) {
+	adjustedValue := ""
+	if b.AdjustedValue != nil {
+		adjustedValue = b.AdjustedValue.Dec()
+	}
 	return json.Marshal(&BidTraceV2WithBlobFieldsJSON{
 		Slot:                 b.Slot,
 		ParentHash:           b.ParentHash.String(),
@@ -396,6 +767,7 @@ func (b BidTraceV2WithBlobFields) MarshalJSON() ([]byte, error) {
 		NumBlobs:             b.NumBlobs,
 		BlobGasUsed:          b.BlobGasUsed,
 		ExcessBlobGas:        b.ExcessBlobGas,
+		AdjustedValue:        adjustedValue,
 	})
 }
 
@@ -406,6 +778,7 @@ func (b *BidTraceV2WithBlobFields) UnmarshalJSON(data []byte) error {
 		NumBlobs      uint64 `json:"num_blobs,string"`
 		BlobGasUsed   uint64 `json:"blob_gas_used,string"`
 		ExcessBlobGas uint64 `json:"excess_blob_gas,string"`
+		AdjustedValue string `json:"adjusted_value"`
 	}{}
 	err := json.Unmarshal(data, params)
 	if err != nil {
@@ -416,6 +789,13 @@ func (b *BidTraceV2WithBlobFields) UnmarshalJSON(data []byte) error {
 	b.NumBlobs = params.NumBlobs
 	b.BlobGasUsed = params.BlobGasUsed
 	b.ExcessBlobGas = params.ExcessBlobGas
+	b.AdjustedValue = nil
+	if params.AdjustedValue != "" {
+		b.AdjustedValue, err = uint256.FromDecimal(params.AdjustedValue)
+		if err != nil {
+			return err
+		}
+	}
 
 	bidTrace := new(builderApiV1.BidTrace)
 	err = json.Unmarshal(data, bidTrace)
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -19,7 +20,6 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/electra"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
-	"github.com/flashbots/go-boost-utils/bls"
 	"github.com/flashbots/go-boost-utils/ssz"
 	"github.com/flashbots/go-boost-utils/utils"
 	"github.com/holiman/uint256"
@@ -39,12 +39,18 @@ type HTTPErrorResp struct {
 
 var NilResponse = struct{}{}
 
-func BuildGetHeaderResponse(payload *VersionedSubmitBlockRequest, sk *bls.SecretKey, pubkey *phase0.BLSPubKey, domain phase0.Domain) (*builderSpec.VersionedSignedBuilderBid, error) {
+func BuildGetHeaderResponse(ctx context.Context, payload *VersionedSubmitBlockRequest, signer Signer, domain phase0.Domain) (*builderSpec.VersionedSignedBuilderBid, error) {
+	return BuildGetHeaderResponseWithValue(ctx, payload, nil, signer, domain)
+}
+
+// BuildGetHeaderResponseWithValue builds the signed builder bid of a block submission, with the given bid value instead
+// of the value of the submission (unless it's nil)
+func BuildGetHeaderResponseWithValue(ctx context.Context, payload *VersionedSubmitBlockRequest, value *uint256.Int, signer Signer, domain phase0.Domain) (*builderSpec.VersionedSignedBuilderBid, error) {
 	if payload == nil {
 		return nil, ErrMissingRequest
 	}
 
-	if sk == nil {
+	if signer == nil {
 		return nil, ErrMissingSecretKey
 	}
 
@@ -56,7 +62,7 @@ func BuildGetHeaderResponse(payload *VersionedSubmitBlockRequest, sk *bls.Secret
 		if err != nil {
 			return nil, err
 		}
-		signedBuilderBid, err := BuilderBlockRequestToSignedBuilderBid(payload, header, sk, pubkey, domain)
+		signedBuilderBid, err := BuilderBlockRequestToSignedBuilderBid(ctx, payload, header, value, signer, domain)
 		if err != nil {
 			return nil, err
 		}
@@ -70,7 +76,7 @@ func BuildGetHeaderResponse(payload *VersionedSubmitBlockRequest, sk *bls.Secret
 		if err != nil {
 			return nil, err
 		}
-		signedBuilderBid, err := BuilderBlockRequestToSignedBuilderBid(payload, header, sk, pubkey, domain)
+		signedBuilderBid, err := BuilderBlockRequestToSignedBuilderBid(ctx, payload, header, value, signer, domain)
 		if err != nil {
 			return nil, err
 		}
@@ -84,7 +90,7 @@ func BuildGetHeaderResponse(payload *VersionedSubmitBlockRequest, sk *bls.Secret
 		if err != nil {
 			return nil, err
 		}
-		signedBuilderBid, err := BuilderBlockRequestToSignedBuilderBid(payload, header, sk, pubkey, domain)
+		signedBuilderBid, err := BuilderBlockRequestToSignedBuilderBid(ctx, payload, header, value, signer, domain)
 		if err != nil {
 			return nil, err
 		}
@@ -128,21 +134,64 @@ func BuildGetPayloadResponse(payload *VersionedSubmitBlockRequest) (*builderApi.
 	return nil, ErrEmptyPayload
 }
 
-func BuilderBlockRequestToSignedBuilderBid(payload *VersionedSubmitBlockRequest, header *builderApi.VersionedExecutionPayloadHeader, sk *bls.SecretKey, pubkey *phase0.BLSPubKey, domain phase0.Domain) (*builderSpec.VersionedSignedBuilderBid, error) {
-	value, err := payload.Value()
+// SignedBuilderBidToSSZ encodes the signed builder bid of a getHeader response as SSZ
+func SignedBuilderBidToSSZ(bid *builderSpec.VersionedSignedBuilderBid) ([]byte, error) {
+	switch bid.Version { //nolint:exhaustive
+	case spec.DataVersionCapella:
+		return bid.Capella.MarshalSSZ()
+	case spec.DataVersionDeneb:
+		return bid.Deneb.MarshalSSZ()
+	case spec.DataVersionElectra:
+		return bid.Electra.MarshalSSZ()
+	default:
+		return nil, errors.Wrap(ErrInvalidVersion, fmt.Sprintf("%s is not supported", bid.Version))
+	}
+}
+
+// GetPayloadResponseToSSZ encodes the execution payload (and blobs bundle) of a getPayload response as SSZ
+func GetPayloadResponseToSSZ(resp *builderApi.VersionedSubmitBlindedBlockResponse) ([]byte, error) {
+	switch resp.Version { //nolint:exhaustive
+	case spec.DataVersionCapella:
+		return resp.Capella.MarshalSSZ()
+	case spec.DataVersionDeneb:
+		return resp.Deneb.MarshalSSZ()
+	case spec.DataVersionElectra:
+		return resp.Electra.MarshalSSZ()
+	default:
+		return nil, errors.Wrap(ErrInvalidVersion, fmt.Sprintf("%s is not supported", resp.Version))
+	}
+}
+
+// signBuilderBid returns the signature of the builder bid by the relay
+func signBuilderBid(ctx context.Context, bid ssz.ObjWithHashTreeRoot, domain phase0.Domain, signer Signer) (phase0.BLSSignature, error) {
+	root, err := ssz.ComputeSigningRoot(bid, domain)
 	if err != nil {
-		return nil, err
+		return phase0.BLSSignature{}, err
 	}
+	return signer.Sign(ctx, root)
+}
+
+// BuilderBlockRequestToSignedBuilderBid signs a builder bid for the payload header, with the value of the submission if
+// value is nil
+func BuilderBlockRequestToSignedBuilderBid(ctx context.Context, payload *VersionedSubmitBlockRequest, header *builderApi.VersionedExecutionPayloadHeader, value *uint256.Int, signer Signer, domain phase0.Domain) (*builderSpec.VersionedSignedBuilderBid, error) {
+	if value == nil {
+		var err error
+		value, err = payload.Value()
+		if err != nil {
+			return nil, err
+		}
+	}
+	pubkey := signer.PublicKey()
 
 	switch payload.Version { //nolint:exhaustive
 	case spec.DataVersionCapella:
 		builderBid := builderApiCapella.BuilderBid{
 			Value:  value,
 			Header: header.Capella,
-			Pubkey: *pubkey,
+			Pubkey: pubkey,
 		}
 
-		sig, err := ssz.SignMessage(&builderBid, domain, sk)
+		sig, err := signBuilderBid(ctx, &builderBid, domain, signer)
 		if err != nil {
 			return nil, err
 		}
@@ -159,10 +208,10 @@ func BuilderBlockRequestToSignedBuilderBid(payload *VersionedSubmitBlockRequest,
 			Header:             header.Deneb,
 			BlobKZGCommitments: payload.Deneb.BlobsBundle.Commitments,
 			Value:              value,
-			Pubkey:             *pubkey,
+			Pubkey:             pubkey,
 		}
 
-		sig, err := ssz.SignMessage(&builderBid, domain, sk)
+		sig, err := signBuilderBid(ctx, &builderBid, domain, signer)
 		if err != nil {
 			return nil, err
 		}
@@ -180,10 +229,10 @@ func BuilderBlockRequestToSignedBuilderBid(payload *VersionedSubmitBlockRequest,
 			BlobKZGCommitments: payload.Electra.BlobsBundle.Commitments,
 			ExecutionRequests:  payload.Electra.ExecutionRequests,
 			Value:              value,
-			Pubkey:             *pubkey,
+			Pubkey:             pubkey,
 		}
 
-		sig, err := ssz.SignMessage(&builderBid, domain, sk)
+		sig, err := signBuilderBid(ctx, &builderBid, domain, signer)
 		if err != nil {
 			return nil, err
 		}
@@ -318,6 +367,20 @@ type BuilderBlockValidationRequest struct {
 	*VersionedSubmitBlockRequest
 	RegisteredGasLimit    uint64
 	ParentBeaconBlockRoot *phase0.Root
+
+	// ProposerPayment, if set, is the value the block must pay the proposer instead of the value of the bid trace, i.e.
+	// the value of an adjusted bid which is served to proposers
+	ProposerPayment *uint256.Int
+}
+
+// message returns the bid trace sent to the validation node, with the value replaced by the proposer payment if set
+func (r *BuilderBlockValidationRequest) message(bidTrace *builderApiV1.BidTrace) *builderApiV1.BidTrace {
+	if r.ProposerPayment == nil {
+		return bidTrace
+	}
+	msg := *bidTrace
+	msg.Value = r.ProposerPayment
+	return &msg
 }
 
 type capellaBuilderBlockValidationRequestJSON struct {
@@ -350,14 +413,14 @@ func (r *BuilderBlockValidationRequest) MarshalJSON() ([]byte, error) {
 	switch r.Version { //nolint:exhaustive
 	case spec.DataVersionCapella:
 		return json.Marshal(&capellaBuilderBlockValidationRequestJSON{
-			Message:            r.Capella.Message,
+			Message:            r.message(r.Capella.Message),
 			ExecutionPayload:   r.Capella.ExecutionPayload,
 			Signature:          r.Capella.Signature.String(),
 			RegisteredGasLimit: r.RegisteredGasLimit,
 		})
 	case spec.DataVersionDeneb:
 		return json.Marshal(&denebBuilderBlockValidationRequestJSON{
-			Message:               r.Deneb.Message,
+			Message:               r.message(r.Deneb.Message),
 			ExecutionPayload:      r.Deneb.ExecutionPayload,
 			BlobsBundle:           r.Deneb.BlobsBundle,
 			Signature:             r.Deneb.Signature.String(),
@@ -366,7 +429,7 @@ func (r *BuilderBlockValidationRequest) MarshalJSON() ([]byte, error) {
 		})
 	case spec.DataVersionElectra:
 		return json.Marshal(&electraBuilderBlockValidationRequestJSON{
-			Message:               r.Electra.Message,
+			Message:               r.message(r.Electra.Message),
 			ExecutionPayload:      r.Electra.ExecutionPayload,
 			BlobsBundle:           r.Electra.BlobsBundle,
 			ExecutionRequests:     r.Electra.ExecutionRequests,
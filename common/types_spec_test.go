@@ -132,6 +132,10 @@ func TestBuildGetPayloadResponse(t *testing.T) {
 			blockHash, err := resp.BlockHash()
 			require.NoError(t, err)
 			require.Equal(t, testCase.blockHash, blockHash.String())
+
+			sszBytes, err := GetPayloadResponseToSSZ(resp)
+			require.NoError(t, err)
+			require.NotEmpty(t, sszBytes)
 		})
 	}
 }
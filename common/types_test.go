@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/json"
 	"testing"
 
 	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
@@ -84,12 +85,140 @@ func makeTestSubmitBlockRequestV2Optimistic(t *testing.T) *SubmitBlockRequestV2O
 	}
 }
 
+func TestEthNetworkDetailsForkSchedule(t *testing.T) {
+	mainnet, err := NewEthNetworkDetails(EthNetworkMainnet)
+	require.NoError(t, err)
+	mainnet.SetForkSchedule([]ForkScheduleEntry{
+		{Epoch: 269568, CurrentVersion: DenebForkVersionMainnet},
+		{Epoch: 0, CurrentVersion: GenesisForkVersionMainnet},
+		{Epoch: 194048, CurrentVersion: CapellaForkVersionMainnet},
+		{Epoch: 144896, CurrentVersion: BellatrixForkVersionMainnet},
+	})
+
+	require.Equal(t, GenesisForkVersionMainnet, mainnet.ForkVersionAtSlot(0))
+	require.Equal(t, CapellaForkVersionMainnet, mainnet.ForkVersionAtSlot(269568*SlotsPerEpoch-1))
+	require.Equal(t, DenebForkVersionMainnet, mainnet.ForkVersionAtSlot(269568*SlotsPerEpoch))
+
+	domain, err := mainnet.DomainBeaconProposerAtSlot(269568*SlotsPerEpoch - 1)
+	require.NoError(t, err)
+	require.Equal(t, mainnet.DomainBeaconProposerCapella, domain)
+	domain, err = mainnet.DomainBeaconProposerAtSlot(269568 * SlotsPerEpoch)
+	require.NoError(t, err)
+	require.Equal(t, mainnet.DomainBeaconProposerDeneb, domain)
+
+	// there is no beacon proposer domain before bellatrix
+	_, err = mainnet.DomainBeaconProposerAtSlot(0)
+	require.ErrorIs(t, err, ErrUnknownForkVersion)
+}
+
+func TestNewEthNetworkDetails(t *testing.T) {
+	hoodi, err := NewEthNetworkDetails(EthNetworkHoodi)
+	require.NoError(t, err)
+	require.Equal(t, EthNetworkHoodi, hoodi.Name)
+	require.Equal(t, GenesisValidatorsRootHoodi, hoodi.GenesisValidatorsRootHex)
+	require.Equal(t, ElectraForkVersionHoodi, hoodi.ElectraForkVersionHex)
+
+	// the custom network is the same as the preset, if configured with the same fork versions
+	t.Setenv("GENESIS_FORK_VERSION", GenesisForkVersionHoodi)
+	t.Setenv("GENESIS_VALIDATORS_ROOT", GenesisValidatorsRootHoodi)
+	t.Setenv("BELLATRIX_FORK_VERSION", BellatrixForkVersionHoodi)
+	t.Setenv("CAPELLA_FORK_VERSION", CapellaForkVersionHoodi)
+	t.Setenv("DENEB_FORK_VERSION", DenebForkVersionHoodi)
+	t.Setenv("ELECTRA_FORK_VERSION", ElectraForkVersionHoodi)
+	custom, err := NewEthNetworkDetails(EthNetworkCustom)
+	require.NoError(t, err)
+	require.Equal(t, EthNetworkCustom, custom.Name)
+	require.Equal(t, hoodi.DomainBuilder, custom.DomainBuilder)
+	require.Equal(t, hoodi.DomainBeaconProposerElectra, custom.DomainBeaconProposerElectra)
+
+	_, err = NewEthNetworkDetails(EthNetworkBeacon)
+	require.ErrorIs(t, err, ErrUnknownNetwork)
+	_, err = NewEthNetworkDetails("unknown")
+	require.ErrorIs(t, err, ErrUnknownNetwork)
+	_, err = NewEthNetworkDetailsFromForkVersions("devnet", EthNetworkForkVersions{GenesisForkVersion: "0x10000910"})
+	require.ErrorIs(t, err, ErrInvalidForkVersion)
+}
+
+func TestBidTraceV2WithBlobFieldsAdjustedValue(t *testing.T) {
+	trace := BidTraceV2WithBlobFields{
+		BidTrace:      builderApiV1.BidTrace{Value: uint256.NewInt(100)},
+		AdjustedValue: uint256.NewInt(90),
+	}
+	data, err := json.Marshal(trace)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"adjusted_value":"90"`)
+
+	decoded := new(BidTraceV2WithBlobFields)
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.Equal(t, uint256.NewInt(100), decoded.Value)
+	require.Equal(t, uint256.NewInt(90), decoded.AdjustedValue)
+
+	// the adjusted value is omitted for bids served as submitted
+	trace.AdjustedValue = nil
+	data, err = json.Marshal(trace)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "adjusted_value")
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.Nil(t, decoded.AdjustedValue)
+}
+
 func TestDataVersion(t *testing.T) {
 	require.Equal(t, ForkVersionStringBellatrix, spec.DataVersionBellatrix.String())
 	require.Equal(t, ForkVersionStringCapella, spec.DataVersionCapella.String())
 	require.Equal(t, ForkVersionStringDeneb, spec.DataVersionDeneb.String())
 }
 
+func TestProposerPreferences(t *testing.T) {
+	pubkey, err := utils.HexToPubkey("0xb872a4f5f596ea7dfd695e45afbe4551b405b10dafba98b2d897c58a5047fc288ef2c1bc4216f906ea05d7fdbed61116")
+	require.NoError(t, err)
+	builderPubkey, err := utils.HexToPubkey("0xae7bde4839fa905b7d8125fd84cfdcd0c32cd74e1be3fa24263d71b520fc78113326ce0a90b95d73f19e6d8150a2f73b")
+	require.NoError(t, err)
+	preferences := &ProposerPreferences{
+		Pubkey:             pubkey,
+		Timestamp:          1700000000,
+		MinBidValue:        uint256.NewInt(50000000000000000),
+		BlockedBuilders:    []phase0.BLSPubKey{builderPubkey},
+		MaxGasUsed:         30000000,
+		RequirePaymentLast: true,
+	}
+
+	// JSON round trip
+	data, err := json.Marshal(preferences)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"pubkey":"`+pubkey.String()+`","timestamp":"1700000000","min_bid_value":"50000000000000000","blocked_builders":["`+builderPubkey.String()+`"],"max_gas_used":"30000000","require_payment_last":true}`, string(data))
+	decoded := new(ProposerPreferences)
+	require.NoError(t, json.Unmarshal(data, decoded))
+	require.Equal(t, preferences, decoded)
+	require.True(t, decoded.IsBuilderBlocked(builderPubkey))
+	require.False(t, decoded.IsBuilderBlocked(pubkey))
+
+	// Every field is part of the hash tree root
+	root, err := preferences.HashTreeRoot()
+	require.NoError(t, err)
+	decoded.BlockedBuilders = nil
+	root2, err := decoded.HashTreeRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root, root2)
+	decoded.MinBidValue = uint256.NewInt(1)
+	root3, err := decoded.HashTreeRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root2, root3)
+	decoded.MaxGasUsed = 0
+	root4, err := decoded.HashTreeRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root3, root4)
+	decoded.RequirePaymentLast = false
+	root5, err := decoded.HashTreeRoot()
+	require.NoError(t, err)
+	require.NotEqual(t, root4, root5)
+
+	// Too many blocked builders
+	decoded.BlockedBuilders = make([]phase0.BLSPubKey, MaxProposerBlockedBuilders+1)
+	_, err = decoded.HashTreeRoot()
+	require.Error(t, err)
+	require.Error(t, json.Unmarshal([]byte(`{"pubkey":"0x01"}`), decoded))
+}
+
 func compareV2RequestEquality(t *testing.T, src, targ *SubmitBlockRequestV2Optimistic) {
 	t.Helper()
 	require.Equal(t, src.Message.String(), targ.Message.String())
@@ -3,6 +3,7 @@ package common
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -29,6 +30,7 @@ var (
 	ErrInvalidForkVersion = errors.New("invalid fork version")
 	ErrHTTPErrorResponse  = errors.New("got an HTTP error response")
 	ErrIncorrectLength    = errors.New("incorrect length")
+	ErrInvalidEthValue    = errors.New("invalid ETH value")
 )
 
 // SlotPos returns the slot's position in the epoch (1-based, i.e. 1..32)
@@ -127,6 +129,36 @@ func U256StrToUint256(s types.U256Str) *uint256.Int {
 	return i
 }
 
+// EthStrToWei parses a plain decimal ETH amount (i.e. "0.05") into wei. Signs, exponents and fractions like "1/2" are
+// not accepted.
+func EthStrToWei(s string) (*uint256.Int, error) {
+	whole, frac, hasFrac := strings.Cut(strings.TrimSpace(s), ".")
+	if !isDecimalDigits(whole) || (hasFrac && !isDecimalDigits(frac)) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEthValue, s)
+	}
+	if len(frac) > 18 {
+		return nil, fmt.Errorf("%w: %s has more than 18 decimals", ErrInvalidEthValue, s)
+	}
+	value, err := uint256.FromDecimal(whole + frac + strings.Repeat("0", 18-len(frac)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidEthValue, s)
+	}
+	return value, nil
+}
+
+// isDecimalDigits returns true if s is a non-empty string of the digits 0-9
+func isDecimalDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 func reverse(src []byte) []byte {
 	dst := make([]byte, len(src))
 	copy(dst, src)
@@ -299,3 +331,25 @@ func GetBlockSubmissionExecutionPayload(submission *VersionedSubmitBlockRequest)
 	}
 	return nil, ErrEmptyPayload
 }
+
+// WeakETag returns a weak ETag of the response body, a hash of the contents
+func WeakETag(body []byte) string {
+	hash := sha256.Sum256(body)
+	return fmt.Sprintf(`W/"%x"`, hash[:16])
+}
+
+// ETagMatches returns true if the If-None-Match header contains the ETag, using the weak comparison
+func ETagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
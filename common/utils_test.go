@@ -86,6 +86,43 @@ func TestU256StrToUint256(t *testing.T) {
 	}
 }
 
+func TestEthStrToWei(t *testing.T) {
+	tests := []struct {
+		eth  string
+		want string
+		err  bool
+	}{
+		{eth: "0", want: "0"},
+		{eth: "1", want: "1000000000000000000"},
+		{eth: "0.05", want: "50000000000000000"},
+		{eth: "0.000000000000000001", want: "1"},
+		{eth: "0.0000000000000000001", err: true},
+		{eth: "-1", err: true},
+		{eth: "abc", err: true},
+		{eth: "1/2", err: true},
+		{eth: "1e-3", err: true},
+		{eth: "1e18", err: true},
+		{eth: "+1", err: true},
+		{eth: "0x10", err: true},
+		{eth: ".5", err: true},
+		{eth: "1.", err: true},
+		{eth: "", err: true},
+		{eth: "1000000000000000000000000000000000000000000000000000000000000000000000000000000", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.eth, func(t *testing.T) {
+			got, err := EthStrToWei(test.eth)
+			if test.err {
+				require.ErrorIs(t, err, ErrInvalidEthValue)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.want, got.Dec())
+		})
+	}
+}
+
 func TestGetEnvStrSlice(t *testing.T) {
 	testEnvVar := "TESTENV_TestGetEnvStrSlice"
 	os.Unsetenv(testEnvVar)
@@ -191,3 +228,13 @@ func TestGetBlockSubmissionInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestETagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	require.True(t, ETagMatches(`W/"abc"`, etag))
+	require.True(t, ETagMatches(`"abc"`, etag))
+	require.True(t, ETagMatches(`"xyz", W/"abc"`, etag))
+	require.True(t, ETagMatches(`*`, etag))
+	require.False(t, ETagMatches(``, etag))
+	require.False(t, ETagMatches(`W/"xyz"`, etag))
+}
@@ -2,9 +2,11 @@
 package database
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -23,14 +25,21 @@ import (
 type IDatabaseService interface {
 	NumRegisteredValidators() (count uint64, err error)
 	SaveValidatorRegistration(entry ValidatorRegistrationEntry) error
+	SaveValidatorRegistrations(entries []ValidatorRegistrationEntry) error
 	GetLatestValidatorRegistrations(timestampOnly bool) ([]*ValidatorRegistrationEntry, error)
 	GetValidatorRegistration(pubkey string) (*ValidatorRegistrationEntry, error)
 	GetValidatorRegistrationsForPubkeys(pubkeys []string) ([]*ValidatorRegistrationEntry, error)
+	GetValidatorRegistrationHistory(pubkey string, limit uint64) ([]*ValidatorRegistrationEntry, error)
+	GetValidatorRegistrationAt(pubkey string, timestamp uint64) (*ValidatorRegistrationEntry, error)
 
 	SaveBuilderBlockSubmission(payload *common.VersionedSubmitBlockRequest, requestError, validationError error, receivedAt, eligibleAt time.Time, wasSimulated, saveExecPayload bool, profile common.Profile, optimisticSubmission bool, blockValue *uint256.Int) (entry *BuilderBlockSubmissionEntry, err error)
+	SaveBuilderBlockSubmissions(submissions []*BuilderBlockSubmissionEntry, execPayloads []*ExecutionPayloadEntry) error
 	GetBlockSubmissionEntry(slot uint64, proposerPubkey, blockHash string) (entry *BuilderBlockSubmissionEntry, err error)
 	GetBuilderSubmissions(filters GetBuilderSubmissionsFilters) ([]*BuilderBlockSubmissionEntry, error)
 	GetBuilderSubmissionsBySlots(slotFrom, slotTo uint64) (entries []*BuilderBlockSubmissionEntry, err error)
+	GetArchiveRows(tableName string, slotFrom, slotTo uint64, afterID int64, limit uint64) (columns []ArchiveColumn, rows [][]any, err error)
+	GetBuilderSubmissionsForExport(filters ExportFilters) (entries []*BuilderBlockSubmissionEntry, err error)
+	GetBuilderSubmissionsForResimulation(slotFrom, slotTo uint64, afterID int64, limit uint64) (entries []*BuilderBlockSubmissionEntry, err error)
 	GetExecutionPayloadEntryByID(executionPayloadID int64) (entry *ExecutionPayloadEntry, err error)
 	GetExecutionPayloadEntryBySlotPkHash(slot uint64, proposerPubkey, blockHash string) (entry *ExecutionPayloadEntry, err error)
 	GetExecutionPayloads(idFirst, idLast uint64) (entries []*ExecutionPayloadEntry, err error)
@@ -40,6 +49,17 @@ type IDatabaseService interface {
 	GetNumDeliveredPayloads() (uint64, error)
 	GetRecentDeliveredPayloads(filters GetPayloadsFilters) ([]*DeliveredPayloadEntry, error)
 	GetDeliveredPayloads(idFirst, idLast uint64) (entries []*DeliveredPayloadEntry, err error)
+	GetDeliveredPayloadsForExport(filters ExportFilters) (entries []*DeliveredPayloadEntry, err error)
+	GetUncheckedDeliveredPayloads(slotTo, limit uint64) (entries []*DeliveredPayloadEntry, err error)
+	GetDeliveredPayloadsForBackcheck(slotFrom, slotTo uint64, afterID int64, uncheckedOnly bool, limit uint64) (entries []*DeliveredPayloadEntry, err error)
+	SetDeliveredPayloadLandedStatus(id int64, status, landedBlockHash string) error
+	RefreshDeliveredPayloadDailyStats() error
+	GetDeliveredPayloadDailyStats(dayFrom, dayTo time.Time) (entries []*DeliveredPayloadDailyStatsEntry, err error)
+	RefreshBuilderEpochStats() error
+	GetBuilderEpochStats(builderPubkey string, epochFrom, epochTo uint64) (entries []*BuilderEpochStatsEntry, err error)
+	GetBuilderDailyStats(builderPubkey string, dayFrom, dayTo time.Time) (entries []*BuilderDailyStatsEntry, err error)
+	GetBuilderIdentityEpochStats(builderID string, epochFrom, epochTo uint64) (entries []*BuilderEpochStatsEntry, err error)
+	GetBuilderIdentityDailyStats(builderID string, dayFrom, dayTo time.Time) (entries []*BuilderDailyStatsEntry, err error)
 
 	GetBlockBuilders() ([]*BlockBuilderEntry, error)
 	GetBlockBuilderByPubkey(pubkey string) (*BlockBuilderEntry, error)
@@ -49,22 +69,50 @@ type IDatabaseService interface {
 	UpsertBlockBuilderEntryAfterSubmission(lastSubmission *BuilderBlockSubmissionEntry, isError bool) error
 	IncBlockBuilderStatsAfterGetPayload(builderPubkey string) error
 
+	GetBuilderIdentities() ([]*BuilderIdentityEntry, error)
+	GetBuilderIdentity(builderID string) (*BuilderIdentityEntry, error)
+	UpsertBuilderIdentity(entry BuilderIdentityEntry) error
+	SetBlockBuilderIdentity(pubkey, builderID string) error
+
 	InsertBuilderDemotion(submitBlockRequest *common.VersionedSubmitBlockRequest, simError error) error
 	UpdateBuilderDemotion(trace *common.BidTraceV2WithBlobFields, signedBlock *common.VersionedSignedProposal, signedRegistration *builderApiV1.SignedValidatorRegistration) error
 	GetBuilderDemotion(trace *common.BidTraceV2WithBlobFields) (*BuilderDemotionEntry, error)
+	GetBuilderDemotionCounts() (map[string]uint64, error)
+	GetBuilderScoreCounts(epochFrom uint64) (map[string]*common.BuilderScoreCounts, error)
+	GetBuilderRecentSimStats(builderPubkey string, numSubmissions uint64) (numSimulated, numSimErrors uint64, err error)
 
 	GetTooLateGetPayload(slot uint64) (entries []*TooLateGetPayloadEntry, err error)
 	InsertTooLateGetPayload(slot uint64, proposerPubkey, blockHash string, slotStart, requestTime, decodeTime, msIntoSlot uint64) error
+	InsertGetPayloadFailure(entry GetPayloadFailureEntry) error
+	GetGetPayloadFailures(filters GetPayloadFailureFilters) (entries []*GetPayloadFailureEntry, err error)
+
+	InsertInternalAPIAuditEntry(entry InternalAPIAuditEntry) error
+
+	InsertGetHeaderLogEntries(entries []GetHeaderLogEntry) error
+	GetGetHeaderLogEntries(filters GetHeaderLogFilters) (entries []*GetHeaderLogEntry, err error)
+
+	InsertArchiveManifestEntry(entry ArchiveManifestEntry) error
+	GetArchivedSlotTo(tableName string) (slotTo uint64, err error)
+
+	PruneExecutionPayloads(slotBefore, limit uint64) (numDeleted int64, err error)
+	PruneBuilderBlockSubmissions(slotBefore, limit uint64) (numDeleted int64, err error)
+	PruneGetHeaderLog(slotBefore, limit uint64) (numDeleted int64, err error)
+
+	CreateSlotPartitions(slot, numPartitionsAhead uint64) (created []*SlotPartition, err error)
+	DetachSlotPartitions(slotBefore uint64, drop bool) (detached []*SlotPartition, err error)
 }
 
 type DatabaseService struct {
 	DB *sqlx.DB
 
+	// readDB is used for the heavy reads of the data API, it is a read replica if configured and DB otherwise
+	readDB *sqlx.DB
+
 	nstmtInsertExecutionPayload       *sqlx.NamedStmt
 	nstmtInsertBlockBuilderSubmission *sqlx.NamedStmt
 }
 
-func NewDatabaseService(dsn string) (*DatabaseService, error) {
+func connectDatabase(dsn string) (*sqlx.DB, error) {
 	db, err := sqlx.Connect("postgres", dsn)
 	if err != nil {
 		return nil, err
@@ -73,6 +121,14 @@ func NewDatabaseService(dsn string) (*DatabaseService, error) {
 	db.DB.SetMaxOpenConns(50)
 	db.DB.SetMaxIdleConns(10)
 	db.DB.SetConnMaxIdleTime(0)
+	return db, nil
+}
+
+func NewDatabaseService(dsn string) (*DatabaseService, error) {
+	db, err := connectDatabase(dsn)
+	if err != nil {
+		return nil, err
+	}
 
 	if os.Getenv("DB_DONT_APPLY_SCHEMA") == "" {
 		migrate.SetTable(vars.TableMigrations)
@@ -82,11 +138,21 @@ func NewDatabaseService(dsn string) (*DatabaseService, error) {
 		}
 	}
 
-	dbService := &DatabaseService{DB: db} //nolint:exhaustruct
+	dbService := &DatabaseService{DB: db, readDB: db} //nolint:exhaustruct
 	err = dbService.prepareNamedQueries()
 	return dbService, err
 }
 
+// ConnectReadReplica connects to a read replica, which is then used for the data API reads instead of the primary
+func (s *DatabaseService) ConnectReadReplica(dsn string) error {
+	db, err := connectDatabase(dsn)
+	if err != nil {
+		return err
+	}
+	s.readDB = db
+	return nil
+}
+
 func (s *DatabaseService) prepareNamedQueries() (err error) {
 	// Insert execution payload
 	query := `INSERT INTO ` + vars.TableExecutionPayload + `
@@ -100,26 +166,34 @@ func (s *DatabaseService) prepareNamedQueries() (err error) {
 	}
 
 	// Insert block builder submission
-	query = `INSERT INTO ` + vars.TableBuilderBlockSubmission + `
-	(received_at, eligible_at, execution_payload_id, was_simulated, sim_success, sim_error, sim_req_error, signature, slot, parent_hash, block_hash, builder_pubkey, proposer_pubkey, proposer_fee_recipient, gas_used, gas_limit, num_tx, value, epoch, block_number, decode_duration, prechecks_duration, simulation_duration, redis_update_duration, total_duration, optimistic_submission, block_value) VALUES
-	(:received_at, :eligible_at, :execution_payload_id, :was_simulated, :sim_success, :sim_error, :sim_req_error, :signature, :slot, :parent_hash, :block_hash, :builder_pubkey, :proposer_pubkey, :proposer_fee_recipient, :gas_used, :gas_limit, :num_tx, :value, :epoch, :block_number, :decode_duration, :prechecks_duration, :simulation_duration, :redis_update_duration, :total_duration, :optimistic_submission, :block_value)
-	RETURNING id`
-	s.nstmtInsertBlockBuilderSubmission, err = s.DB.PrepareNamed(query)
+	s.nstmtInsertBlockBuilderSubmission, err = s.DB.PrepareNamed(insertBlockBuilderSubmissionQuery)
 	return err
 }
 
+// insertBlockBuilderSubmissionQuery is both prepared for single inserts, and used for batch inserts
+var insertBlockBuilderSubmissionQuery = `INSERT INTO ` + vars.TableBuilderBlockSubmission + `
+	(received_at, eligible_at, execution_payload_id, was_simulated, sim_success, sim_error, sim_req_error, filter_error, signature, slot, parent_hash, block_hash, builder_pubkey, proposer_pubkey, proposer_fee_recipient, gas_used, gas_limit, num_tx, value, epoch, block_number, decode_duration, prechecks_duration, signature_check_duration, sim_queue_wait_duration, simulation_duration, redis_update_duration, total_duration, optimistic_submission, block_value, adjusted_value) VALUES
+	(:received_at, :eligible_at, :execution_payload_id, :was_simulated, :sim_success, :sim_error, :sim_req_error, :filter_error, :signature, :slot, :parent_hash, :block_hash, :builder_pubkey, :proposer_pubkey, :proposer_fee_recipient, :gas_used, :gas_limit, :num_tx, :value, :epoch, :block_number, :decode_duration, :prechecks_duration, :signature_check_duration, :sim_queue_wait_duration, :simulation_duration, :redis_update_duration, :total_duration, :optimistic_submission, :block_value, :adjusted_value)
+	RETURNING id`
+
+// Close closes the connections to the primary database and to the read replica, if one is connected
 func (s *DatabaseService) Close() error {
-	return s.DB.Close()
+	err := s.DB.Close()
+	if s.readDB != s.DB {
+		err = errors.Join(err, s.readDB.Close())
+	}
+	return err
 }
 
 // NumRegisteredValidators returns the number of unique pubkeys that have registered
 func (s *DatabaseService) NumRegisteredValidators() (count uint64, err error) {
-	query := `SELECT COUNT(*) FROM (SELECT DISTINCT pubkey FROM ` + vars.TableValidatorRegistration + `) AS temp;`
+	query := `SELECT COUNT(*) FROM ` + vars.TableValidatorRegistrationLatest + `;`
 	row := s.DB.QueryRow(query)
 	err = row.Scan(&count)
 	return count, err
 }
 
+// NumValidatorRegistrationRows returns the number of registrations in the history
 func (s *DatabaseService) NumValidatorRegistrationRows() (count uint64, err error) {
 	query := `SELECT COUNT(*) FROM ` + vars.TableValidatorRegistration + `;`
 	row := s.DB.QueryRow(query)
@@ -127,34 +201,89 @@ func (s *DatabaseService) NumValidatorRegistrationRows() (count uint64, err erro
 	return count, err
 }
 
+// SaveValidatorRegistration replaces the latest registration of the validator if the new one has a newer timestamp,
+// and only then appends it to the registration history
 func (s *DatabaseService) SaveValidatorRegistration(entry ValidatorRegistrationEntry) error {
+	return s.SaveValidatorRegistrations([]ValidatorRegistrationEntry{entry})
+}
+
+// SaveValidatorRegistrations saves many registrations like SaveValidatorRegistration, with a single insert. Of several
+// registrations of the same validator, only the newest one is saved.
+func (s *DatabaseService) SaveValidatorRegistrations(entries []ValidatorRegistrationEntry) error {
+	// a row can't be updated twice by the same insert
+	newest := make(map[string]int, len(entries))
+	for i, entry := range entries {
+		if j, ok := newest[entry.Pubkey]; !ok || entries[j].Timestamp < entry.Timestamp {
+			newest[entry.Pubkey] = i
+		}
+	}
+	if len(newest) == 0 {
+		return nil
+	}
+	batch := make([]ValidatorRegistrationEntry, 0, len(newest))
+	for i, entry := range entries {
+		if newest[entry.Pubkey] == i {
+			batch = append(batch, entry)
+		}
+	}
+
 	query := `WITH latest_registration AS (
-		SELECT DISTINCT ON (pubkey) pubkey, fee_recipient, timestamp, gas_limit, signature FROM ` + vars.TableValidatorRegistration + ` WHERE pubkey=:pubkey ORDER BY pubkey, timestamp DESC limit 1
+		INSERT INTO ` + vars.TableValidatorRegistrationLatest + ` (pubkey, fee_recipient, timestamp, gas_limit, signature)
+		VALUES (:pubkey, :fee_recipient, :timestamp, :gas_limit, :signature)
+		ON CONFLICT (pubkey) DO UPDATE SET
+			fee_recipient = EXCLUDED.fee_recipient,
+			timestamp = EXCLUDED.timestamp,
+			gas_limit = EXCLUDED.gas_limit,
+			signature = EXCLUDED.signature,
+			updated_at = current_timestamp
+		WHERE ` + vars.TableValidatorRegistrationLatest + `.timestamp < EXCLUDED.timestamp
+		RETURNING pubkey, fee_recipient, timestamp, gas_limit, signature
 	)
 	INSERT INTO ` + vars.TableValidatorRegistration + ` (pubkey, fee_recipient, timestamp, gas_limit, signature)
-	SELECT :pubkey, :fee_recipient, :timestamp, :gas_limit, :signature
-	WHERE NOT EXISTS (
-		SELECT 1 from latest_registration WHERE pubkey=:pubkey AND :timestamp <= latest_registration.timestamp OR (:fee_recipient = latest_registration.fee_recipient AND :gas_limit = latest_registration.gas_limit)
-	);`
-	_, err := s.DB.NamedExec(query, entry)
+	SELECT pubkey, fee_recipient, timestamp, gas_limit, signature FROM latest_registration
+	ON CONFLICT DO NOTHING;`
+	_, err := s.DB.NamedExec(query, batch)
 	return err
 }
 
 func (s *DatabaseService) GetValidatorRegistration(pubkey string) (*ValidatorRegistrationEntry, error) {
-	query := `SELECT DISTINCT ON (pubkey) pubkey, fee_recipient, timestamp, gas_limit, signature
+	query := `SELECT pubkey, fee_recipient, timestamp, gas_limit, signature
+		FROM ` + vars.TableValidatorRegistrationLatest + `
+		WHERE pubkey=$1;`
+	entry := &ValidatorRegistrationEntry{}
+	err := s.DB.Get(entry, query, pubkey)
+	return entry, err
+}
+
+// GetValidatorRegistrationHistory returns the accepted registrations of a validator, newest first
+func (s *DatabaseService) GetValidatorRegistrationHistory(pubkey string, limit uint64) (entries []*ValidatorRegistrationEntry, err error) {
+	query := `SELECT id, inserted_at, pubkey, fee_recipient, timestamp, gas_limit, signature
 		FROM ` + vars.TableValidatorRegistration + `
 		WHERE pubkey=$1
-		ORDER BY pubkey, timestamp DESC;`
+		ORDER BY timestamp DESC
+		LIMIT $2;`
+	err = s.readDB.Select(&entries, query, pubkey, limit)
+	return entries, err
+}
+
+// GetValidatorRegistrationAt returns the newest accepted registration of a validator with a timestamp up to the given
+// one, which is the registration that was valid at that time
+func (s *DatabaseService) GetValidatorRegistrationAt(pubkey string, timestamp uint64) (*ValidatorRegistrationEntry, error) {
+	query := `SELECT id, inserted_at, pubkey, fee_recipient, timestamp, gas_limit, signature
+		FROM ` + vars.TableValidatorRegistration + `
+		WHERE pubkey=$1 AND timestamp <= $2
+		ORDER BY timestamp DESC
+		LIMIT 1;`
 	entry := &ValidatorRegistrationEntry{}
-	err := s.DB.Get(entry, query, pubkey)
+	err := s.readDB.Get(entry, query, pubkey, timestamp)
 	return entry, err
 }
 
 func (s *DatabaseService) GetValidatorRegistrationsForPubkeys(pubkeys []string) (entries []*ValidatorRegistrationEntry, err error) {
-	query := `SELECT DISTINCT ON (pubkey) pubkey, fee_recipient, timestamp, gas_limit, signature
-		FROM ` + vars.TableValidatorRegistration + `
+	query := `SELECT pubkey, fee_recipient, timestamp, gas_limit, signature
+		FROM ` + vars.TableValidatorRegistrationLatest + `
 		WHERE pubkey IN (?)
-		ORDER BY pubkey, timestamp DESC;`
+		ORDER BY pubkey;`
 
 	q, args, err := sqlx.In(query, pubkeys)
 	if err != nil {
@@ -165,12 +294,11 @@ func (s *DatabaseService) GetValidatorRegistrationsForPubkeys(pubkeys []string)
 }
 
 func (s *DatabaseService) GetLatestValidatorRegistrations(timestampOnly bool) ([]*ValidatorRegistrationEntry, error) {
-	// query details: https://stackoverflow.com/questions/3800551/select-first-row-in-each-group-by-group/7630564#7630564
-	query := `SELECT DISTINCT ON (pubkey) pubkey, fee_recipient, timestamp, gas_limit, signature`
+	query := `SELECT pubkey, fee_recipient, timestamp, gas_limit, signature`
 	if timestampOnly {
-		query = `SELECT DISTINCT ON (pubkey) pubkey, timestamp`
+		query = `SELECT pubkey, timestamp`
 	}
-	query += ` FROM ` + vars.TableValidatorRegistration + ` ORDER BY pubkey, timestamp DESC;`
+	query += ` FROM ` + vars.TableValidatorRegistrationLatest + ` ORDER BY pubkey;`
 
 	var registrations []*ValidatorRegistrationEntry
 	err := s.DB.Select(&registrations, query)
@@ -192,71 +320,99 @@ func (s *DatabaseService) SaveBuilderBlockSubmission(payload *common.VersionedSu
 	}
 
 	// Save block_submission
-	simErrStr := ""
-	if validationError != nil {
-		simErrStr = validationError.Error()
+	blockSubmissionEntry, err := NewBuilderBlockSubmissionEntry(payload, requestError, validationError, receivedAt, eligibleAt, wasSimulated, profile, optimisticSubmission, blockValue)
+	if err != nil {
+		return nil, err
 	}
+	blockSubmissionEntry.ExecutionPayloadID = NewNullInt64(execPayloadEntry.ID)
+	err = s.nstmtInsertBlockBuilderSubmission.QueryRow(blockSubmissionEntry).Scan(&blockSubmissionEntry.ID)
+	return blockSubmissionEntry, err
+}
 
-	requestErrStr := ""
-	if requestError != nil {
-		requestErrStr = requestError.Error()
+// blockSubmissionsBatchSize keeps the batch inserts of block submissions below the limit of 65535 bind parameters of
+// a postgres query, with one parameter per column
+var blockSubmissionsBatchSize = 65535 / strings.Count(insertBlockBuilderSubmissionQuery, ":")
+
+// SaveBuilderBlockSubmissions saves block submissions with batch inserts. Each submission may have an execution payload
+// at the same index, which is saved in the same transaction (nil to not save one). If a batch fails, i.e. because of
+// a single invalid row, its submissions are saved one by one instead. The IDs of the saved entries are set, the
+// returned error is of the first submission which couldn't be saved.
+func (s *DatabaseService) SaveBuilderBlockSubmissions(submissions []*BuilderBlockSubmissionEntry, execPayloads []*ExecutionPayloadEntry) error {
+	var firstErr error
+	for start := 0; start < len(submissions); start += blockSubmissionsBatchSize {
+		end := min(start+blockSubmissionsBatchSize, len(submissions))
+		err := s.insertBuilderBlockSubmissions(submissions[start:end], execPayloads[start:end])
+		if err == nil || end-start == 1 {
+			firstErr = cmp.Or(firstErr, err)
+			continue
+		}
+		for i := start; i < end; i++ {
+			firstErr = cmp.Or(firstErr, s.insertBuilderBlockSubmissions(submissions[i:i+1], execPayloads[i:i+1]))
+		}
 	}
+	return firstErr
+}
 
-	blockValueStr := ""
-	if blockValue != nil {
-		blockValueStr = blockValue.Dec()
-	}
-	submission, err := common.GetBlockSubmissionInfo(payload)
+// insertBuilderBlockSubmissions saves the submissions and their execution payloads in one transaction
+func (s *DatabaseService) insertBuilderBlockSubmissions(submissions []*BuilderBlockSubmissionEntry, execPayloads []*ExecutionPayloadEntry) (err error) {
+	tx, err := s.DB.Beginx()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			for _, submission := range submissions {
+				submission.ID = 0
+				submission.ExecutionPayloadID = sql.NullInt64{}
+			}
+		}
+	}()
 
-	blockSubmissionEntry := &BuilderBlockSubmissionEntry{
-		ReceivedAt:         NewNullTime(receivedAt),
-		EligibleAt:         NewNullTime(eligibleAt),
-		ExecutionPayloadID: NewNullInt64(execPayloadEntry.ID),
-
-		WasSimulated: wasSimulated,
-		SimSuccess:   wasSimulated && validationError == nil,
-		SimError:     simErrStr,
-		SimReqError:  requestErrStr,
-		BlockValue: sql.NullString{
-			String: blockValueStr,
-			Valid:  blockValue != nil,
-		},
-
-		Signature: submission.Signature.String(),
-
-		Slot:       submission.BidTrace.Slot,
-		BlockHash:  submission.BidTrace.BlockHash.String(),
-		ParentHash: submission.BidTrace.ParentHash.String(),
-
-		BuilderPubkey:        submission.BidTrace.BuilderPubkey.String(),
-		ProposerPubkey:       submission.BidTrace.ProposerPubkey.String(),
-		ProposerFeeRecipient: submission.BidTrace.ProposerFeeRecipient.String(),
-
-		GasUsed:  submission.GasUsed,
-		GasLimit: submission.GasLimit,
-
-		NumTx: uint64(len(submission.Transactions)),
-		Value: submission.BidTrace.Value.Dec(),
-
-		Epoch:       submission.BidTrace.Slot / common.SlotsPerEpoch,
-		BlockNumber: submission.BlockNumber,
+	insertExecPayload := tx.NamedStmt(s.nstmtInsertExecutionPayload)
+	for i, execPayloadEntry := range execPayloads {
+		if execPayloadEntry == nil {
+			continue
+		}
+		err = insertExecPayload.QueryRow(execPayloadEntry).Scan(&execPayloadEntry.ID)
+		if err != nil {
+			return err
+		}
+		submissions[i].ExecutionPayloadID = NewNullInt64(execPayloadEntry.ID)
+	}
 
-		DecodeDuration:       profile.Decode,
-		PrechecksDuration:    profile.Prechecks,
-		SimulationDuration:   profile.Simulation,
-		RedisUpdateDuration:  profile.RedisUpdate,
-		TotalDuration:        profile.Total,
-		OptimisticSubmission: optimisticSubmission,
+	// sqlx expands the values for each entry. Postgres doesn't guarantee the order of the returned rows, so they are
+	// matched by the builder signature, which is the same only for identical submissions.
+	rows, err := tx.NamedQuery(insertBlockBuilderSubmissionQuery+", signature", submissions)
+	if err != nil {
+		return err
 	}
-	err = s.nstmtInsertBlockBuilderSubmission.QueryRow(blockSubmissionEntry).Scan(&blockSubmissionEntry.ID)
-	return blockSubmissionEntry, err
+	idsBySignature := make(map[string][]int64, len(submissions))
+	for rows.Next() {
+		var id int64
+		var signature string
+		if err = rows.Scan(&id, &signature); err != nil {
+			rows.Close()
+			return err
+		}
+		idsBySignature[signature] = append(idsBySignature[signature], id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for _, submission := range submissions {
+		ids := idsBySignature[submission.Signature]
+		if len(ids) == 0 {
+			return fmt.Errorf("%w: %s", ErrMissingInsertedSubmission, submission.Signature)
+		}
+		submission.ID, idsBySignature[submission.Signature] = ids[0], ids[1:]
+	}
+	return tx.Commit()
 }
 
 func (s *DatabaseService) GetBlockSubmissionEntry(slot uint64, proposerPubkey, blockHash string) (entry *BuilderBlockSubmissionEntry, err error) {
-	query := `SELECT id, inserted_at, received_at, eligible_at, execution_payload_id, sim_success, sim_error, signature, slot, parent_hash, block_hash, builder_pubkey, proposer_pubkey, proposer_fee_recipient, gas_used, gas_limit, num_tx, value, epoch, block_number, decode_duration, prechecks_duration, simulation_duration, redis_update_duration, total_duration, optimistic_submission 
+	query := `SELECT id, inserted_at, received_at, eligible_at, execution_payload_id, sim_success, sim_error, signature, slot, parent_hash, block_hash, builder_pubkey, proposer_pubkey, proposer_fee_recipient, gas_used, gas_limit, num_tx, value, epoch, block_number, decode_duration, prechecks_duration, signature_check_duration, sim_queue_wait_duration, simulation_duration, redis_update_duration, total_duration, optimistic_submission 
 	FROM ` + vars.TableBuilderBlockSubmission + `
 	WHERE slot=$1 AND proposer_pubkey=$2 AND block_hash=$3
 	ORDER BY builder_pubkey ASC
@@ -307,7 +463,7 @@ func (s *DatabaseService) SaveDeliveredPayload(bidTrace *common.BidTraceV2WithBl
 		GasLimit: bidTrace.GasLimit,
 
 		NumTx: bidTrace.NumTx,
-		Value: bidTrace.Value.ToBig().String(),
+		Value: NewU256Str(bidTrace.Value),
 
 		NumBlobs:      bidTrace.NumBlobs,
 		BlobGasUsed:   bidTrace.BlobGasUsed,
@@ -315,10 +471,13 @@ func (s *DatabaseService) SaveDeliveredPayload(bidTrace *common.BidTraceV2WithBl
 
 		PublishMs: publishMs,
 	}
+	if bidTrace.AdjustedValue != nil {
+		deliveredPayloadEntry.AdjustedValue = NewNullString(bidTrace.AdjustedValue.Dec())
+	}
 
 	query := `INSERT INTO ` + vars.TableDeliveredPayload + `
-		(signed_at, signed_blinded_beacon_block, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, gas_used, gas_limit, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, publish_ms) VALUES
-		(:signed_at, :signed_blinded_beacon_block, :slot, :epoch, :builder_pubkey, :proposer_pubkey, :proposer_fee_recipient, :parent_hash, :block_hash, :block_number, :gas_used, :gas_limit, :num_tx, :value, :num_blobs, :blob_gas_used, :excess_blob_gas, :publish_ms)
+		(signed_at, signed_blinded_beacon_block, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, gas_used, gas_limit, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, publish_ms, adjusted_value) VALUES
+		(:signed_at, :signed_blinded_beacon_block, :slot, :epoch, :builder_pubkey, :proposer_pubkey, :proposer_fee_recipient, :parent_hash, :block_hash, :block_number, :gas_used, :gas_limit, :num_tx, :value, :num_blobs, :blob_gas_used, :excess_blob_gas, :publish_ms, :adjusted_value)
 		ON CONFLICT DO NOTHING`
 	_, err = s.DB.NamedExec(query, deliveredPayloadEntry)
 	return err
@@ -329,13 +488,14 @@ func (s *DatabaseService) GetRecentDeliveredPayloads(queryArgs GetPayloadsFilter
 		"limit":           queryArgs.Limit,
 		"slot":            queryArgs.Slot,
 		"cursor":          queryArgs.Cursor,
+		"from_slot":       queryArgs.FromSlot,
 		"block_hash":      queryArgs.BlockHash,
 		"block_number":    queryArgs.BlockNumber,
 		"proposer_pubkey": queryArgs.ProposerPubkey,
 		"builder_pubkey":  queryArgs.BuilderPubkey,
 	}
 
-	fields := "id, inserted_at, signed_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, gas_used, gas_limit, publish_ms"
+	fields := "id, inserted_at, signed_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, gas_used, gas_limit, publish_ms, landed_status, landed_block_hash, adjusted_value"
 
 	whereConds := []string{}
 	if queryArgs.Slot > 0 {
@@ -343,6 +503,13 @@ func (s *DatabaseService) GetRecentDeliveredPayloads(queryArgs GetPayloadsFilter
 	} else if queryArgs.Cursor > 0 {
 		whereConds = append(whereConds, "slot <= :cursor")
 	}
+	if queryArgs.FromSlot > 0 {
+		whereConds = append(whereConds, "slot >= :from_slot")
+	}
+	if queryArgs.ToSlot != nil {
+		arg["to_slot"] = *queryArgs.ToSlot
+		whereConds = append(whereConds, "slot <= :to_slot")
+	}
 	if queryArgs.BlockHash != "" {
 		whereConds = append(whereConds, "block_hash = :block_hash")
 	}
@@ -373,7 +540,7 @@ func (s *DatabaseService) GetRecentDeliveredPayloads(queryArgs GetPayloadsFilter
 	defer cancel()
 
 	entries := []*DeliveredPayloadEntry{}
-	rows, err := s.DB.NamedQueryContext(ctx, query, arg)
+	rows, err := s.readDB.NamedQueryContext(ctx, query, arg)
 	if err != nil {
 		return nil, err
 	}
@@ -389,7 +556,7 @@ func (s *DatabaseService) GetRecentDeliveredPayloads(queryArgs GetPayloadsFilter
 }
 
 func (s *DatabaseService) GetDeliveredPayloads(idFirst, idLast uint64) (entries []*DeliveredPayloadEntry, err error) {
-	query := `SELECT id, inserted_at, signed_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, gas_used, gas_limit, publish_ms
+	query := `SELECT id, inserted_at, signed_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, gas_used, gas_limit, publish_ms, landed_status, landed_block_hash
 	FROM ` + vars.TableDeliveredPayload + `
 	WHERE id >= $1 AND id <= $2
 	ORDER BY slot ASC`
@@ -398,6 +565,180 @@ func (s *DatabaseService) GetDeliveredPayloads(idFirst, idLast uint64) (entries
 	return entries, err
 }
 
+// GetDeliveredPayloadsForExport returns the next batch of delivered payloads after the cursor, by id
+func (s *DatabaseService) GetDeliveredPayloadsForExport(filters ExportFilters) (entries []*DeliveredPayloadEntry, err error) {
+	fields := "id, inserted_at, signed_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, gas_used, gas_limit, publish_ms, landed_status, landed_block_hash, adjusted_value"
+	err = s.selectForExport(&entries, fields, vars.TableDeliveredPayload, filters)
+	return entries, err
+}
+
+// GetUncheckedDeliveredPayloads returns the oldest delivered payloads up to slotTo for which it wasn't checked yet whether they landed on chain
+func (s *DatabaseService) GetUncheckedDeliveredPayloads(slotTo, limit uint64) (entries []*DeliveredPayloadEntry, err error) {
+	query := `SELECT id, inserted_at, signed_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, gas_used, gas_limit, publish_ms, landed_status, landed_block_hash
+	FROM ` + vars.TableDeliveredPayload + `
+	WHERE landed_status = '' AND slot <= $1
+	ORDER BY slot ASC
+	LIMIT $2`
+
+	err = s.DB.Select(&entries, query, slotTo, limit)
+	return entries, err
+}
+
+// GetDeliveredPayloadsForBackcheck returns the next batch of delivered payloads of the slot range after the given id, by id,
+// optionally only those for which it wasn't checked yet whether they landed on chain
+func (s *DatabaseService) GetDeliveredPayloadsForBackcheck(slotFrom, slotTo uint64, afterID int64, uncheckedOnly bool, limit uint64) (entries []*DeliveredPayloadEntry, err error) {
+	query := `SELECT id, inserted_at, signed_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, num_blobs, blob_gas_used, excess_blob_gas, gas_used, gas_limit, publish_ms, landed_status, landed_block_hash
+	FROM ` + vars.TableDeliveredPayload + `
+	WHERE slot >= $1 AND slot <= $2 AND id > $3 AND ($4 = false OR landed_status = '')
+	ORDER BY id ASC
+	LIMIT $5`
+
+	err = s.DB.Select(&entries, query, slotFrom, slotTo, afterID, uncheckedOnly, limit)
+	return entries, err
+}
+
+func (s *DatabaseService) SetDeliveredPayloadLandedStatus(id int64, status, landedBlockHash string) error {
+	query := `UPDATE ` + vars.TableDeliveredPayload + ` SET landed_status=$1, landed_block_hash=$2 WHERE id=$3;`
+	_, err := s.DB.Exec(query, status, landedBlockHash, id)
+	return err
+}
+
+// RefreshDeliveredPayloadDailyStats recomputes the daily stats from the delivered payloads, starting the day before the
+// latest day with stats (to include the payloads delivered after its last refresh), or from the beginning if there are none
+func (s *DatabaseService) RefreshDeliveredPayloadDailyStats() error {
+	query := `INSERT INTO ` + vars.TableDeliveredPayloadDailyStats + ` (day, builder_pubkey, updated_at, num_payloads, total_value)
+	SELECT inserted_at::date, builder_pubkey, current_timestamp, COUNT(*), COALESCE(SUM(value), 0)
+	FROM ` + vars.TableDeliveredPayload + `
+	WHERE inserted_at >= COALESCE((SELECT MAX(day) - 1 FROM ` + vars.TableDeliveredPayloadDailyStats + `), '-infinity'::date)
+	GROUP BY 1, 2
+	ON CONFLICT (day, builder_pubkey) DO UPDATE SET
+		updated_at = EXCLUDED.updated_at,
+		num_payloads = EXCLUDED.num_payloads,
+		total_value = EXCLUDED.total_value;`
+	_, err := s.DB.Exec(query)
+	return err
+}
+
+// GetDeliveredPayloadDailyStats returns the daily stats of all builders from dayFrom to dayTo (inclusive)
+func (s *DatabaseService) GetDeliveredPayloadDailyStats(dayFrom, dayTo time.Time) (entries []*DeliveredPayloadDailyStatsEntry, err error) {
+	query := `SELECT day, builder_pubkey, updated_at, num_payloads, total_value
+	FROM ` + vars.TableDeliveredPayloadDailyStats + `
+	WHERE day >= $1::date AND day <= $2::date
+	ORDER BY day ASC, builder_pubkey ASC`
+	err = s.readDB.Select(&entries, query, dayFrom.UTC().Format(time.DateOnly), dayTo.UTC().Format(time.DateOnly))
+	return entries, err
+}
+
+// builderEpochStatsBatchEpochs is the number of epochs aggregated per statement by RefreshBuilderEpochStats (one day)
+const builderEpochStatsBatchEpochs = 225
+
+// RefreshBuilderEpochStats recomputes the epoch stats of the builders from the block submissions and delivered payloads,
+// starting the epoch before the latest one in the stats, so only the recent epochs are aggregated. Without stats, the
+// history is backfilled from the first submission on, in batches of builderEpochStatsBatchEpochs epochs.
+func (s *DatabaseService) RefreshBuilderEpochStats() error {
+	var epochFrom, epochTo sql.NullInt64
+	err := s.DB.Get(&epochFrom, `SELECT MAX(epoch) - 1 FROM `+vars.TableBuilderEpochStats)
+	if err != nil {
+		return err
+	}
+	if !epochFrom.Valid {
+		err = s.DB.Get(&epochFrom, `SELECT MIN(slot) / $1 FROM `+vars.TableBuilderBlockSubmission, common.SlotsPerEpoch)
+		if err != nil {
+			return err
+		}
+	}
+	err = s.DB.Get(&epochTo, `SELECT MAX(slot) / $1 FROM `+vars.TableBuilderBlockSubmission, common.SlotsPerEpoch)
+	if err != nil {
+		return err
+	}
+	if !epochFrom.Valid || !epochTo.Valid {
+		return nil // no submissions yet
+	}
+
+	// filtering by slot as well lets postgres skip the older partitions
+	query := `INSERT INTO ` + vars.TableBuilderEpochStats + ` (epoch, builder_pubkey, day, updated_at, num_submissions, num_sim_errors, num_payloads, total_value)
+	SELECT COALESCE(s.epoch, p.epoch), COALESCE(s.builder_pubkey, p.builder_pubkey), LEAST(s.day, p.day), current_timestamp,
+		COALESCE(s.num_submissions, 0), COALESCE(s.num_sim_errors, 0), COALESCE(p.num_payloads, 0), COALESCE(p.total_value, 0)
+	FROM (
+		SELECT epoch, builder_pubkey, MIN(inserted_at)::date AS day, COUNT(*) AS num_submissions, COUNT(*) FILTER (WHERE sim_error <> '') AS num_sim_errors
+		FROM ` + vars.TableBuilderBlockSubmission + `
+		WHERE slot >= $1 AND slot < $2 AND epoch >= $3 AND epoch < $4
+		GROUP BY 1, 2
+	) s FULL OUTER JOIN (
+		SELECT epoch, builder_pubkey, MIN(inserted_at)::date AS day, COUNT(*) AS num_payloads, SUM(value) AS total_value
+		FROM ` + vars.TableDeliveredPayload + `
+		WHERE slot >= $1 AND slot < $2 AND epoch >= $3 AND epoch < $4
+		GROUP BY 1, 2
+	) p ON s.epoch = p.epoch AND s.builder_pubkey = p.builder_pubkey
+	ON CONFLICT (epoch, builder_pubkey) DO UPDATE SET
+		day = EXCLUDED.day,
+		updated_at = EXCLUDED.updated_at,
+		num_submissions = EXCLUDED.num_submissions,
+		num_sim_errors = EXCLUDED.num_sim_errors,
+		num_payloads = EXCLUDED.num_payloads,
+		total_value = EXCLUDED.total_value;`
+	for from := uint64(max(epochFrom.Int64, 0)); from <= uint64(epochTo.Int64); from += builderEpochStatsBatchEpochs {
+		to := from + builderEpochStatsBatchEpochs
+		_, err = s.DB.Exec(query, from*common.SlotsPerEpoch, to*common.SlotsPerEpoch, from, to)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBuilderEpochStats returns the epoch stats from epochFrom to epochTo (inclusive), of all builders if builderPubkey
+// is empty
+func (s *DatabaseService) GetBuilderEpochStats(builderPubkey string, epochFrom, epochTo uint64) (entries []*BuilderEpochStatsEntry, err error) {
+	query := `SELECT epoch, builder_pubkey, day, updated_at, num_submissions, num_sim_errors, num_payloads, total_value
+	FROM ` + vars.TableBuilderEpochStats + `
+	WHERE epoch >= $1 AND epoch <= $2 AND ($3 = '' OR builder_pubkey = $3)
+	ORDER BY epoch ASC, builder_pubkey ASC`
+	err = s.readDB.Select(&entries, query, epochFrom, epochTo, builderPubkey)
+	return entries, err
+}
+
+// GetBuilderDailyStats returns the daily stats from dayFrom to dayTo (inclusive), summed up from the epoch stats, of all
+// builders if builderPubkey is empty
+func (s *DatabaseService) GetBuilderDailyStats(builderPubkey string, dayFrom, dayTo time.Time) (entries []*BuilderDailyStatsEntry, err error) {
+	query := `SELECT day, builder_pubkey, SUM(num_submissions) AS num_submissions, SUM(num_sim_errors) AS num_sim_errors,
+		SUM(num_payloads) AS num_payloads, SUM(total_value) AS total_value
+	FROM ` + vars.TableBuilderEpochStats + `
+	WHERE day >= $1::date AND day <= $2::date AND ($3 = '' OR builder_pubkey = $3)
+	GROUP BY 1, 2
+	ORDER BY day ASC, builder_pubkey ASC`
+	err = s.readDB.Select(&entries, query, dayFrom.UTC().Format(time.DateOnly), dayTo.UTC().Format(time.DateOnly), builderPubkey)
+	return entries, err
+}
+
+// GetBuilderIdentityEpochStats returns the epoch stats from epochFrom to epochTo (inclusive), summed up over the current
+// pubkeys of a builder identity
+func (s *DatabaseService) GetBuilderIdentityEpochStats(builderID string, epochFrom, epochTo uint64) (entries []*BuilderEpochStatsEntry, err error) {
+	query := `SELECT epoch, b.builder_identity AS builder_id, MIN(day) AS day, MAX(updated_at) AS updated_at, SUM(num_submissions) AS num_submissions,
+		SUM(num_sim_errors) AS num_sim_errors, SUM(num_payloads) AS num_payloads, SUM(total_value) AS total_value
+	FROM ` + vars.TableBuilderEpochStats + ` s
+	JOIN ` + vars.TableBlockBuilder + ` b ON s.builder_pubkey = b.builder_pubkey
+	WHERE epoch >= $1 AND epoch <= $2 AND b.builder_identity = $3
+	GROUP BY 1, 2
+	ORDER BY epoch ASC`
+	err = s.readDB.Select(&entries, query, epochFrom, epochTo, builderID)
+	return entries, err
+}
+
+// GetBuilderIdentityDailyStats returns the daily stats from dayFrom to dayTo (inclusive), summed up over the current
+// pubkeys of a builder identity
+func (s *DatabaseService) GetBuilderIdentityDailyStats(builderID string, dayFrom, dayTo time.Time) (entries []*BuilderDailyStatsEntry, err error) {
+	query := `SELECT day, b.builder_identity AS builder_id, SUM(num_submissions) AS num_submissions, SUM(num_sim_errors) AS num_sim_errors,
+		SUM(num_payloads) AS num_payloads, SUM(total_value) AS total_value
+	FROM ` + vars.TableBuilderEpochStats + ` s
+	JOIN ` + vars.TableBlockBuilder + ` b ON s.builder_pubkey = b.builder_pubkey
+	WHERE day >= $1::date AND day <= $2::date AND b.builder_identity = $3
+	GROUP BY 1, 2
+	ORDER BY day ASC`
+	err = s.readDB.Select(&entries, query, dayFrom.UTC().Format(time.DateOnly), dayTo.UTC().Format(time.DateOnly), builderID)
+	return entries, err
+}
+
 func (s *DatabaseService) GetNumDeliveredPayloads() (uint64, error) {
 	var count uint64
 	err := s.DB.QueryRow("SELECT COUNT(*) FROM " + vars.TableDeliveredPayload).Scan(&count)
@@ -411,13 +752,17 @@ func (s *DatabaseService) GetBuilderSubmissions(filters GetBuilderSubmissionsFil
 		"block_hash":     filters.BlockHash,
 		"block_number":   filters.BlockNumber,
 		"builder_pubkey": filters.BuilderPubkey,
+		"cursor":         filters.Cursor,
 	}
 
-	fields := "id, inserted_at, received_at, eligible_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, gas_used, gas_limit, optimistic_submission, block_value"
+	fields := "id, inserted_at, received_at, eligible_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, gas_used, gas_limit, optimistic_submission, block_value, adjusted_value, decode_duration, prechecks_duration, signature_check_duration, sim_queue_wait_duration, simulation_duration, redis_update_duration, total_duration"
 	limit := "LIMIT :limit"
 
-	whereConds := []string{
-		"(sim_success = true OR optimistic_submission = true)",
+	whereConds := []string{}
+	if filters.IncludeSimErrors {
+		fields += ", was_simulated, sim_success, sim_error, filter_error"
+	} else {
+		whereConds = append(whereConds, "(sim_success = true OR optimistic_submission = true)")
 	}
 	if filters.Slot > 0 {
 		whereConds = append(whereConds, "slot = :slot")
@@ -435,17 +780,26 @@ func (s *DatabaseService) GetBuilderSubmissions(filters GetBuilderSubmissionsFil
 		whereConds = append(whereConds, "builder_pubkey = :builder_pubkey")
 	}
 
+	// pages by id are always limited, ordering by id makes them continue exactly where the previous one ended. The
+	// first page is requested with the highest possible cursor.
+	orderBy := "slot DESC, inserted_at DESC"
+	if filters.Cursor > 0 {
+		whereConds = append(whereConds, "id < :cursor")
+		orderBy = "id DESC"
+		limit = "LIMIT :limit"
+	}
+
 	where := ""
 	if len(whereConds) > 0 {
 		where = "WHERE " + strings.Join(whereConds, " AND ")
 	}
 
-	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY slot DESC, inserted_at DESC %s", fields, vars.TableBuilderBlockSubmission, where, limit)
+	query := fmt.Sprintf("SELECT %s FROM %s %s ORDER BY %s %s", fields, vars.TableBuilderBlockSubmission, where, orderBy, limit)
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	entries := []*BuilderBlockSubmissionEntry{}
-	rows, err := s.DB.NamedQueryContext(ctx, query, arg)
+	rows, err := s.readDB.NamedQueryContext(ctx, query, arg)
 	if err != nil {
 		return nil, err
 	}
@@ -470,6 +824,93 @@ func (s *DatabaseService) GetBuilderSubmissionsBySlots(slotFrom, slotTo uint64)
 	return entries, err
 }
 
+// GetArchiveRows returns all columns of the next batch of rows of a table in the slot range, after the given id, by
+// id. These are the rows which get archived before they are pruned, including the failed block submissions.
+func (s *DatabaseService) GetArchiveRows(tableName string, slotFrom, slotTo uint64, afterID int64, limit uint64) (columns []ArchiveColumn, rows [][]any, err error) {
+	query := `SELECT * FROM ` + tableName + `
+	WHERE slot >= $1 AND slot <= $2 AND id > $3
+	ORDER BY id ASC
+	LIMIT $4`
+
+	res, err := s.DB.Queryx(query, slotFrom, slotTo, afterID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Close()
+
+	columnTypes, err := res.ColumnTypes()
+	if err != nil {
+		return nil, nil, err
+	}
+	columns = make([]ArchiveColumn, len(columnTypes))
+	for i, columnType := range columnTypes {
+		columns[i] = ArchiveColumn{Name: columnType.Name(), DBType: columnType.DatabaseTypeName()}
+	}
+	for res.Next() {
+		row, err := res.SliceScan()
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, value := range row {
+			// text and numeric columns are returned as bytes
+			if b, ok := value.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, res.Err()
+}
+
+// GetBuilderSubmissionsForResimulation returns the next batch of simulated block submissions of the slot range which have
+// a stored execution payload, after the given id, by id
+func (s *DatabaseService) GetBuilderSubmissionsForResimulation(slotFrom, slotTo uint64, afterID int64, limit uint64) (entries []*BuilderBlockSubmissionEntry, err error) {
+	query := `SELECT id, inserted_at, received_at, execution_payload_id, sim_success, sim_error, block_value, signature, slot, parent_hash, block_hash, builder_pubkey, proposer_pubkey, proposer_fee_recipient, gas_used, gas_limit, num_tx, value, epoch, block_number, adjusted_value
+	FROM ` + vars.TableBuilderBlockSubmission + `
+	WHERE was_simulated = true AND execution_payload_id IS NOT NULL AND slot >= $1 AND slot <= $2 AND id > $3
+	ORDER BY id ASC
+	LIMIT $4`
+
+	err = s.DB.Select(&entries, query, slotFrom, slotTo, afterID, limit)
+	return entries, err
+}
+
+// GetBuilderSubmissionsForExport returns the next batch of block submissions after the cursor, by id
+func (s *DatabaseService) GetBuilderSubmissionsForExport(filters ExportFilters) (entries []*BuilderBlockSubmissionEntry, err error) {
+	fields := "id, inserted_at, received_at, eligible_at, slot, epoch, builder_pubkey, proposer_pubkey, proposer_fee_recipient, parent_hash, block_hash, block_number, num_tx, value, block_value, gas_used, gas_limit, was_simulated, sim_success, sim_error, optimistic_submission, adjusted_value"
+	err = s.selectForExport(&entries, fields, vars.TableBuilderBlockSubmission, filters)
+	return entries, err
+}
+
+// selectForExport selects the next batch of rows of the table after the cursor, by id
+func (s *DatabaseService) selectForExport(dest any, fields, table string, filters ExportFilters) error {
+	arg := map[string]interface{}{
+		"cursor":        filters.Cursor,
+		"id_last":       filters.IDLast,
+		"inserted_from": filters.InsertedFrom,
+		"inserted_to":   filters.InsertedTo,
+		"limit":         filters.Limit,
+	}
+
+	whereConds := []string{"id > :cursor"}
+	if filters.IDLast > 0 {
+		whereConds = append(whereConds, "id <= :id_last")
+	}
+	if !filters.InsertedFrom.IsZero() {
+		whereConds = append(whereConds, "inserted_at >= :inserted_from")
+	}
+	if !filters.InsertedTo.IsZero() {
+		whereConds = append(whereConds, "inserted_at < :inserted_to")
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY id ASC LIMIT :limit", fields, table, strings.Join(whereConds, " AND "))
+	query, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return err
+	}
+	return s.readDB.Select(dest, s.readDB.Rebind(query), args...)
+}
+
 func (s *DatabaseService) UpsertBlockBuilderEntryAfterSubmission(lastSubmission *BuilderBlockSubmissionEntry, isError bool) error {
 	entry := BlockBuilderEntry{
 		BuilderPubkey:          lastSubmission.BuilderPubkey,
@@ -497,14 +938,14 @@ func (s *DatabaseService) UpsertBlockBuilderEntryAfterSubmission(lastSubmission
 }
 
 func (s *DatabaseService) GetBlockBuilders() ([]*BlockBuilderEntry, error) {
-	query := `SELECT id, inserted_at, builder_pubkey, description, is_high_prio, is_blacklisted, is_optimistic, collateral, builder_id, last_submission_id, last_submission_slot, num_submissions_total, num_submissions_simerror, num_sent_getpayload FROM ` + vars.TableBlockBuilder + ` ORDER BY id ASC;`
+	query := `SELECT id, inserted_at, builder_pubkey, description, is_high_prio, is_blacklisted, is_optimistic, collateral, builder_id, builder_identity, last_submission_id, last_submission_slot, num_submissions_total, num_submissions_simerror, num_sent_getpayload FROM ` + vars.TableBlockBuilder + ` ORDER BY id ASC;`
 	entries := []*BlockBuilderEntry{}
 	err := s.DB.Select(&entries, query)
 	return entries, err
 }
 
 func (s *DatabaseService) GetBlockBuilderByPubkey(pubkey string) (*BlockBuilderEntry, error) {
-	query := `SELECT id, inserted_at, builder_pubkey, description, is_high_prio, is_blacklisted, is_optimistic, collateral, builder_id, last_submission_id, last_submission_slot, num_submissions_total, num_submissions_simerror, num_sent_getpayload FROM ` + vars.TableBlockBuilder + ` WHERE builder_pubkey=$1;`
+	query := `SELECT id, inserted_at, builder_pubkey, description, is_high_prio, is_blacklisted, is_optimistic, collateral, builder_id, builder_identity, last_submission_id, last_submission_slot, num_submissions_total, num_submissions_simerror, num_sent_getpayload FROM ` + vars.TableBlockBuilder + ` WHERE builder_pubkey=$1;`
 	entry := &BlockBuilderEntry{}
 	err := s.DB.Get(entry, query, pubkey)
 	return entry, err
@@ -535,6 +976,61 @@ func (s *DatabaseService) SetBlockBuilderCollateral(pubkey, builderID, collatera
 	return err
 }
 
+func (s *DatabaseService) GetBuilderIdentities() ([]*BuilderIdentityEntry, error) {
+	query := `SELECT id, inserted_at, builder_id, description, is_high_prio, is_blacklisted FROM ` + vars.TableBuilderIdentity + ` ORDER BY id ASC;`
+	entries := []*BuilderIdentityEntry{}
+	err := s.DB.Select(&entries, query)
+	return entries, err
+}
+
+func (s *DatabaseService) GetBuilderIdentity(builderID string) (*BuilderIdentityEntry, error) {
+	query := `SELECT id, inserted_at, builder_id, description, is_high_prio, is_blacklisted FROM ` + vars.TableBuilderIdentity + ` WHERE builder_id=$1;`
+	entry := &BuilderIdentityEntry{}
+	err := s.DB.Get(entry, query, builderID)
+	return entry, err
+}
+
+// UpsertBuilderIdentity creates or updates a builder identity, and applies its status to all of its pubkeys
+func (s *DatabaseService) UpsertBuilderIdentity(entry BuilderIdentityEntry) error {
+	query := `WITH identity AS (
+		INSERT INTO ` + vars.TableBuilderIdentity + ` (builder_id, description, is_high_prio, is_blacklisted) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (builder_id) DO UPDATE SET
+			description = EXCLUDED.description,
+			is_high_prio = EXCLUDED.is_high_prio,
+			is_blacklisted = EXCLUDED.is_blacklisted
+		RETURNING builder_id, is_high_prio, is_blacklisted
+	)
+	UPDATE ` + vars.TableBlockBuilder + ` b SET is_high_prio = i.is_high_prio, is_blacklisted = i.is_blacklisted
+	FROM identity i WHERE b.builder_identity = i.builder_id;`
+	_, err := s.DB.Exec(query, entry.BuilderID, entry.Description, entry.IsHighPrio, entry.IsBlacklisted)
+	return err
+}
+
+// SetBlockBuilderIdentity adds a pubkey to a builder identity, which applies the status of the identity to it. An empty
+// builderID removes the pubkey from its identity, and it keeps its current status.
+func (s *DatabaseService) SetBlockBuilderIdentity(pubkey, builderID string) error {
+	if builderID == "" {
+		query := `UPDATE ` + vars.TableBlockBuilder + ` SET builder_identity='' WHERE builder_pubkey=$1;`
+		_, err := s.DB.Exec(query, pubkey)
+		return err
+	}
+
+	query := `UPDATE ` + vars.TableBlockBuilder + ` b SET builder_identity = i.builder_id, is_high_prio = i.is_high_prio, is_blacklisted = i.is_blacklisted
+	FROM ` + vars.TableBuilderIdentity + ` i WHERE i.builder_id=$1 AND b.builder_pubkey=$2;`
+	res, err := s.DB.Exec(query, builderID, pubkey)
+	if err != nil {
+		return err
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if numRows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (s *DatabaseService) IncBlockBuilderStatsAfterGetPayload(builderPubkey string) error {
 	query := `UPDATE ` + vars.TableBlockBuilder + `
 		SET num_sent_getpayload=num_sent_getpayload+1
@@ -555,6 +1051,102 @@ func (s *DatabaseService) DeleteExecutionPayloads(idFirst, idLast uint64) error
 	return err
 }
 
+func (s *DatabaseService) InsertArchiveManifestEntry(entry ArchiveManifestEntry) error {
+	query := `INSERT INTO ` + vars.TableArchiveManifest + `
+		(table_name, slot_from, slot_to, num_rows, location) VALUES
+		(:table_name, :slot_from, :slot_to, :num_rows, :location)`
+	_, err := s.DB.NamedExec(query, entry)
+	return err
+}
+
+// GetArchivedSlotTo returns the slot up to which (exclusive) the rows of a table were archived without gaps, starting
+// at the oldest row of the table. An archived range only counts if the table has no more rows in it than were exported,
+// so rows which were inserted after the export are not pruned. It returns 0 if nothing can be pruned.
+func (s *DatabaseService) GetArchivedSlotTo(tableName string) (slotTo uint64, err error) {
+	var oldestSlot sql.NullInt64
+	err = s.DB.Get(&oldestSlot, `SELECT MIN(slot) FROM `+tableName)
+	if err != nil || !oldestSlot.Valid {
+		return 0, err
+	}
+
+	entries := []*ArchiveManifestEntry{}
+	query := `SELECT id, inserted_at, table_name, slot_from, slot_to, num_rows, location
+	FROM ` + vars.TableArchiveManifest + `
+	WHERE table_name=$1
+	ORDER BY slot_from ASC`
+	err = s.DB.Select(&entries, query, tableName)
+	if err != nil {
+		return 0, err
+	}
+
+	slotTo = uint64(oldestSlot.Int64) //nolint:gosec
+	for _, entry := range entries {
+		if entry.SlotFrom > slotTo {
+			break
+		} else if entry.SlotTo < slotTo {
+			continue
+		}
+
+		var numRows uint64
+		err = s.DB.Get(&numRows, `SELECT COUNT(*) FROM `+tableName+` WHERE slot >= $1 AND slot <= $2`, entry.SlotFrom, entry.SlotTo)
+		if err != nil {
+			return 0, err
+		}
+		if numRows > entry.NumRows {
+			break
+		}
+		slotTo = entry.SlotTo + 1
+	}
+
+	if slotTo == uint64(oldestSlot.Int64) { //nolint:gosec
+		return 0, nil
+	}
+	return slotTo, nil
+}
+
+// PruneExecutionPayloads deletes up to limit execution payloads of slots before slotBefore, and returns how many were
+// deleted. Deleting in batches keeps the locks and the write load of each query small. Payloads which were delivered are
+// kept for the data API and for disputes.
+func (s *DatabaseService) PruneExecutionPayloads(slotBefore, limit uint64) (numDeleted int64, err error) {
+	query := `DELETE FROM ` + vars.TableExecutionPayload + ` WHERE id IN (
+		SELECT id FROM ` + vars.TableExecutionPayload + ` AS ep WHERE ep.slot < $1 AND NOT EXISTS (
+			SELECT 1 FROM ` + vars.TableDeliveredPayload + ` AS dp
+			WHERE dp.slot = ep.slot AND dp.proposer_pubkey = ep.proposer_pubkey AND dp.block_hash = ep.block_hash
+		) LIMIT $2
+	)`
+	res, err := s.DB.Exec(query, slotBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PruneBuilderBlockSubmissions deletes up to limit block submissions of slots before slotBefore, and returns how many
+// were deleted
+func (s *DatabaseService) PruneBuilderBlockSubmissions(slotBefore, limit uint64) (numDeleted int64, err error) {
+	query := `DELETE FROM ` + vars.TableBuilderBlockSubmission + ` WHERE slot < $1 AND (id, slot) IN (
+		SELECT id, slot FROM ` + vars.TableBuilderBlockSubmission + ` WHERE slot < $1 LIMIT $2
+	)`
+	res, err := s.DB.Exec(query, slotBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// PruneGetHeaderLog deletes up to limit served getHeader bids of slots before slotBefore, and returns how many were
+// deleted
+func (s *DatabaseService) PruneGetHeaderLog(slotBefore, limit uint64) (numDeleted int64, err error) {
+	query := `DELETE FROM ` + vars.TableGetHeaderLog + ` WHERE id IN (
+		SELECT id FROM ` + vars.TableGetHeaderLog + ` WHERE slot < $1 LIMIT $2
+	)`
+	res, err := s.DB.Exec(query, slotBefore, limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func (s *DatabaseService) InsertBuilderDemotion(submitBlockRequest *common.VersionedSubmitBlockRequest, simError error) error {
 	_submitBlockRequest, err := json.Marshal(submitBlockRequest)
 	if err != nil {
@@ -573,7 +1165,7 @@ func (s *DatabaseService) InsertBuilderDemotion(submitBlockRequest *common.Versi
 		BuilderPubkey:  submission.BidTrace.BuilderPubkey.String(),
 		ProposerPubkey: submission.BidTrace.ProposerPubkey.String(),
 
-		Value:        submission.BidTrace.Value.Dec(),
+		Value:        NewU256Str(submission.BidTrace.Value),
 		FeeRecipient: submission.BidTrace.ProposerFeeRecipient.String(),
 
 		BlockHash: submission.BidTrace.BlockHash.String(),
@@ -617,6 +1209,81 @@ func (s *DatabaseService) GetBuilderDemotion(trace *common.BidTraceV2WithBlobFie
 	return entry, nil
 }
 
+// GetBuilderDemotionCounts returns the number of demotions for every builder which was demoted at least once
+func (s *DatabaseService) GetBuilderDemotionCounts() (map[string]uint64, error) {
+	query := `SELECT builder_pubkey, COUNT(*) AS num_demotions FROM ` + vars.TableBuilderDemotions + ` GROUP BY builder_pubkey`
+	entries := []struct {
+		BuilderPubkey string `db:"builder_pubkey"`
+		NumDemotions  uint64 `db:"num_demotions"`
+	}{}
+	err := s.DB.Select(&entries, query)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]uint64, len(entries))
+	for _, entry := range entries {
+		counts[entry.BuilderPubkey] = entry.NumDemotions
+	}
+	return counts, nil
+}
+
+// GetBuilderScoreCounts returns the inputs of the builder scores, counted from epochFrom on: the submissions and failed
+// simulations (from the builder epoch stats), the demotions, and the failed getPayload requests for bids of the builder
+func (s *DatabaseService) GetBuilderScoreCounts(epochFrom uint64) (map[string]*common.BuilderScoreCounts, error) {
+	// filtering the submissions by slot lets postgres skip the older partitions
+	query := `SELECT builder_pubkey, SUM(num_submissions) AS num_submissions, SUM(num_sim_errors) AS num_sim_errors,
+		SUM(num_demotions) AS num_demotions, SUM(num_equivocations) AS num_equivocations, SUM(num_get_payload_failures) AS num_get_payload_failures
+	FROM (
+		SELECT builder_pubkey, num_submissions, num_sim_errors, 0 AS num_demotions, 0 AS num_equivocations, 0 AS num_get_payload_failures
+		FROM ` + vars.TableBuilderEpochStats + `
+		WHERE epoch >= $1
+		UNION ALL
+		SELECT builder_pubkey, 0, 0, 1, 0, 0
+		FROM ` + vars.TableBuilderDemotions + `
+		WHERE epoch >= $1
+		UNION ALL
+		SELECT b.builder_pubkey, 0, 0, 0, CASE WHEN f.reason = $3 THEN 1 ELSE 0 END, CASE WHEN f.reason <> $3 THEN 1 ELSE 0 END
+		FROM ` + vars.TableGetPayloadFailure + ` f
+		JOIN (
+			SELECT DISTINCT slot, block_hash, builder_pubkey FROM ` + vars.TableBuilderBlockSubmission + ` WHERE slot >= $2
+		) b ON b.slot = f.slot AND b.block_hash = f.block_hash
+		WHERE f.slot >= $2 AND f.reason IN ($3, $4, $5, $6)
+	) c
+	GROUP BY builder_pubkey`
+	entries := []struct {
+		BuilderPubkey string `db:"builder_pubkey"`
+		common.BuilderScoreCounts
+	}{}
+	err := s.DB.Select(&entries, query, epochFrom, epochFrom*common.SlotsPerEpoch, GetPayloadFailureEquivocation,
+		GetPayloadFailureBidInvalidated, GetPayloadFailureUnknownPayload, GetPayloadFailurePublishFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]*common.BuilderScoreCounts, len(entries))
+	for _, entry := range entries {
+		counts[entry.BuilderPubkey] = &entry.BuilderScoreCounts
+	}
+	return counts, nil
+}
+
+// GetBuilderRecentSimStats returns how many of the last numSubmissions simulated submissions of a builder failed simulation
+func (s *DatabaseService) GetBuilderRecentSimStats(builderPubkey string, numSubmissions uint64) (numSimulated, numSimErrors uint64, err error) {
+	query := `SELECT COUNT(*) AS num_simulated, COUNT(*) FILTER (WHERE sim_error <> '') AS num_sim_errors FROM (
+		SELECT sim_error FROM ` + vars.TableBuilderBlockSubmission + `
+		WHERE builder_pubkey = $1 AND was_simulated = true
+		ORDER BY id DESC
+		LIMIT $2
+	) AS recent`
+	stats := struct {
+		NumSimulated uint64 `db:"num_simulated"`
+		NumSimErrors uint64 `db:"num_sim_errors"`
+	}{}
+	err = s.DB.Get(&stats, query, builderPubkey, numSubmissions)
+	return stats.NumSimulated, stats.NumSimErrors, err
+}
+
 func (s *DatabaseService) GetTooLateGetPayload(slot uint64) (entries []*TooLateGetPayloadEntry, err error) {
 	query := `SELECT id, inserted_at, slot, slot_start_timestamp, request_timestamp, decode_timestamp, proposer_pubkey, block_hash, ms_into_slot FROM ` + vars.TableTooLateGetPayload + ` WHERE slot = $1`
 	err = s.DB.Select(&entries, query, slot)
@@ -641,3 +1308,128 @@ func (s *DatabaseService) InsertTooLateGetPayload(slot uint64, proposerPubkey, b
 	_, err := s.DB.NamedExec(query, entry)
 	return err
 }
+
+// InsertGetHeaderLogEntries saves a batch of served getHeader bids with one insert
+func (s *DatabaseService) InsertGetHeaderLogEntries(entries []GetHeaderLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	query := `INSERT INTO ` + vars.TableGetHeaderLog + `
+		(slot, parent_hash, proposer_pubkey, block_hash, value, user_agent, requested_at, latency_ms) VALUES
+		(:slot, :parent_hash, :proposer_pubkey, :block_hash, :value, :user_agent, :requested_at, :latency_ms);`
+	_, err := s.DB.NamedExec(query, entries)
+	return err
+}
+
+// GetGetHeaderLogEntries returns the served getHeader bids matching the filters, newest first
+func (s *DatabaseService) GetGetHeaderLogEntries(filters GetHeaderLogFilters) (entries []*GetHeaderLogEntry, err error) {
+	arg := map[string]interface{}{
+		"slot":            filters.Slot,
+		"proposer_pubkey": filters.ProposerPubkey,
+		"block_hash":      filters.BlockHash,
+		"limit":           filters.Limit,
+	}
+
+	whereConds := []string{}
+	if filters.Slot > 0 {
+		whereConds = append(whereConds, "slot = :slot")
+	}
+	if filters.ProposerPubkey != "" {
+		whereConds = append(whereConds, "proposer_pubkey = :proposer_pubkey")
+	}
+	if filters.BlockHash != "" {
+		whereConds = append(whereConds, "block_hash = :block_hash")
+	}
+
+	where := ""
+	if len(whereConds) > 0 {
+		where = "WHERE " + strings.Join(whereConds, " AND ")
+	}
+
+	query := `SELECT id, inserted_at, slot, parent_hash, proposer_pubkey, block_hash, value, user_agent, requested_at, latency_ms
+	FROM ` + vars.TableGetHeaderLog + ` ` + where + ` ORDER BY id DESC LIMIT :limit`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.readDB.NamedQueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entry := new(GetHeaderLogEntry)
+		if err = rows.StructScan(entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *DatabaseService) InsertGetPayloadFailure(entry GetPayloadFailureEntry) error {
+	query := `INSERT INTO ` + vars.TableGetPayloadFailure + `
+		(slot, proposer_index, proposer_pubkey, block_hash, reason, error, user_agent, requested_at, ms_into_slot) VALUES
+		(:slot, :proposer_index, :proposer_pubkey, :block_hash, :reason, :error, :user_agent, :requested_at, :ms_into_slot);`
+	_, err := s.DB.NamedExec(query, entry)
+	return err
+}
+
+// GetGetPayloadFailures returns the failed getPayload requests matching the filters, newest first
+func (s *DatabaseService) GetGetPayloadFailures(filters GetPayloadFailureFilters) (entries []*GetPayloadFailureEntry, err error) {
+	arg := map[string]interface{}{
+		"slot":            filters.Slot,
+		"proposer_pubkey": filters.ProposerPubkey,
+		"reason":          filters.Reason,
+		"limit":           filters.Limit,
+	}
+
+	whereConds := []string{}
+	if filters.Slot > 0 {
+		whereConds = append(whereConds, "slot = :slot")
+	}
+	if filters.ProposerPubkey != "" {
+		whereConds = append(whereConds, "proposer_pubkey = :proposer_pubkey")
+	}
+	if filters.Reason != "" {
+		whereConds = append(whereConds, "reason = :reason")
+	}
+
+	where := ""
+	if len(whereConds) > 0 {
+		where = "WHERE " + strings.Join(whereConds, " AND ")
+	}
+
+	query := `SELECT id, inserted_at, slot, proposer_index, proposer_pubkey, block_hash, reason, error, user_agent, requested_at, ms_into_slot
+	FROM ` + vars.TableGetPayloadFailure + ` ` + where + ` ORDER BY id DESC LIMIT :limit`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := s.readDB.NamedQueryContext(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		entry := new(GetPayloadFailureEntry)
+		if err = rows.StructScan(entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *DatabaseService) InsertInternalAPIAuditEntry(entry InternalAPIAuditEntry) error {
+	query := `INSERT INTO ` + vars.TableInternalAPIAuditLog + `
+		(actor, method, path, params, status_code, remote_addr) VALUES
+		(:actor, :method, :path, :params, :status_code, :remote_addr);`
+	_, err := s.DB.NamedExec(query, entry)
+	return err
+}
+
+// GetInternalAPIAuditEntries returns the latest entries of the internal API audit log, newest first
+func (s *DatabaseService) GetInternalAPIAuditEntries(limit uint64) (entries []*InternalAPIAuditEntry, err error) {
+	query := `SELECT id, inserted_at, actor, method, path, params, status_code, remote_addr FROM ` + vars.TableInternalAPIAuditLog + ` ORDER BY id DESC LIMIT $1`
+	err = s.DB.Select(&entries, query, limit)
+	return entries, err
+}
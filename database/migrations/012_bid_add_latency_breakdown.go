@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration012AddLatencyBreakdown = &migrate.Migration{
+	Id: "012-add-latency-breakdown",
+	Up: []string{`
+		ALTER TABLE ` + vars.TableBuilderBlockSubmission + ` ADD signature_check_duration bigint NOT NULL default 0;
+		ALTER TABLE ` + vars.TableBuilderBlockSubmission + ` ADD sim_queue_wait_duration  bigint NOT NULL default 0;
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
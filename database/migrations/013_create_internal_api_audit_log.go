@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration013CreateInternalAPIAuditLog = &migrate.Migration{
+	Id: "013-create-internal-api-audit-log",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableInternalAPIAuditLog + ` (
+			id          bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			inserted_at timestamp NOT NULL default current_timestamp,
+
+			actor       varchar(64) NOT NULL,
+			method      varchar(16) NOT NULL,
+			path        text NOT NULL,
+			params      text NOT NULL,
+			status_code integer NOT NULL,
+			remote_addr text NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS ` + vars.TableInternalAPIAuditLog + `_actor_idx ON ` + vars.TableInternalAPIAuditLog + `(actor);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration014PayloadAddLandedStatus = &migrate.Migration{
+	Id: "014-payload-add-landed-status",
+	Up: []string{`
+		-- payloads delivered before this migration are not checked anymore
+		ALTER TABLE ` + vars.TableDeliveredPayload + ` ADD landed_status varchar(16) NOT NULL DEFAULT 'unknown';
+		ALTER TABLE ` + vars.TableDeliveredPayload + ` ALTER COLUMN landed_status SET DEFAULT '';
+		ALTER TABLE ` + vars.TableDeliveredPayload + ` ADD landed_block_hash varchar(66) NOT NULL DEFAULT '';
+
+		CREATE INDEX IF NOT EXISTS ` + vars.TableDeliveredPayload + `_unchecked_slot_idx ON ` + vars.TableDeliveredPayload + `(slot) WHERE landed_status = '';
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
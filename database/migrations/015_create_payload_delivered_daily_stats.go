@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration015CreateDeliveredPayloadDailyStats = &migrate.Migration{
+	Id: "015-create-payload-delivered-daily-stats",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableDeliveredPayloadDailyStats + ` (
+			day            date NOT NULL,
+			builder_pubkey varchar(98) NOT NULL,
+			updated_at     timestamp NOT NULL default current_timestamp,
+
+			num_payloads bigint NOT NULL,
+			total_value  NUMERIC(48, 0) NOT NULL,
+
+			PRIMARY KEY (day, builder_pubkey)
+		);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
@@ -0,0 +1,95 @@
+package migrations
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// Migration016PartitionBySlot is a no-op. Partitioning the block submissions and delivered payloads by slot locks both
+// tables while it runs, so it is not applied automatically at startup, but offline with `tool migrate --partition-by-slot`
+// (see PartitionBySlot).
+var Migration016PartitionBySlot = &migrate.Migration{
+	Id:   "016-partition-by-slot",
+	Up:   []string{},
+	Down: []string{},
+
+	DisableTransactionUp:   false,
+	DisableTransactionDown: false,
+}
+
+// PartitionBySlot are the queries which partition the block submissions and delivered payloads by slot ranges of
+// vars.SlotPartitionSize slots, by table. The existing table becomes the first partition (<table>_legacy), covering all
+// slots up to the end of the range of its highest slot, so no rows are copied. Attaching it still builds the new primary
+// key index, which includes the slot, on all existing rows.
+//
+// Further partitions are created ahead of time by the housekeeper.
+var PartitionBySlot = map[string]string{
+	vars.TableBuilderBlockSubmission: partitionBySlot(vars.TableBuilderBlockSubmission, `
+			ALTER TABLE `+vars.TableBuilderBlockSubmission+` ADD PRIMARY KEY (id, slot);
+
+			CREATE INDEX `+vars.TableBuilderBlockSubmission+`_slot_idx ON `+vars.TableBuilderBlockSubmission+`("slot");
+			CREATE INDEX `+vars.TableBuilderBlockSubmission+`_blockhash_idx ON `+vars.TableBuilderBlockSubmission+`("block_hash");
+			CREATE INDEX `+vars.TableBuilderBlockSubmission+`_blocknumber_idx ON `+vars.TableBuilderBlockSubmission+`("block_number");
+			CREATE INDEX `+vars.TableBuilderBlockSubmission+`_builderpubkey_idx ON `+vars.TableBuilderBlockSubmission+`("builder_pubkey");
+			CREATE INDEX `+vars.TableBuilderBlockSubmission+`_simsuccess_idx ON `+vars.TableBuilderBlockSubmission+`("sim_success");
+			CREATE INDEX `+vars.TableBuilderBlockSubmission+`_executionpayloadid_idx ON `+vars.TableBuilderBlockSubmission+`("execution_payload_id");
+			CREATE INDEX `+vars.TableBuilderBlockSubmission+`_received_idx ON `+vars.TableBuilderBlockSubmission+`(received_at DESC);
+		`),
+	vars.TableDeliveredPayload: partitionBySlot(vars.TableDeliveredPayload, `
+			ALTER TABLE `+vars.TableDeliveredPayload+` ADD PRIMARY KEY (id, slot);
+			ALTER TABLE `+vars.TableDeliveredPayload+` ADD UNIQUE (slot, proposer_pubkey, block_hash);
+
+			CREATE INDEX `+vars.TableDeliveredPayload+`_slot_idx ON `+vars.TableDeliveredPayload+`("slot");
+			CREATE INDEX `+vars.TableDeliveredPayload+`_blockhash_idx ON `+vars.TableDeliveredPayload+`("block_hash");
+			CREATE INDEX `+vars.TableDeliveredPayload+`_blocknumber_idx ON `+vars.TableDeliveredPayload+`("block_number");
+			CREATE INDEX `+vars.TableDeliveredPayload+`_proposerpubkey_idx ON `+vars.TableDeliveredPayload+`("proposer_pubkey");
+			CREATE INDEX `+vars.TableDeliveredPayload+`_builderpubkey_idx ON `+vars.TableDeliveredPayload+`("builder_pubkey");
+			CREATE INDEX `+vars.TableDeliveredPayload+`_value_idx ON `+vars.TableDeliveredPayload+`("value");
+			CREATE INDEX `+vars.TableDeliveredPayload+`_unchecked_slot_idx ON `+vars.TableDeliveredPayload+`(slot) WHERE landed_status = '';
+		`),
+}
+
+// partitionBySlot returns the queries to replace the table with one partitioned by slot, with the given constraints
+// and indexes, and to attach the existing table as the first partition
+func partitionBySlot(table, constraintsAndIndexes string) string {
+	query := `
+		ALTER TABLE {table} RENAME TO {table}_legacy;
+
+		-- free the index and constraint names for the partitioned table
+		DO $$
+		DECLARE
+			idx text;
+		BEGIN
+			FOR idx IN SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = '{table}_legacy' LOOP
+				EXECUTE format('ALTER INDEX %I RENAME TO %I', idx, '{table}_legacy' || substr(idx, length('{table}') + 1));
+			END LOOP;
+		END $$;
+
+		CREATE TABLE {table} (LIKE {table}_legacy INCLUDING DEFAULTS) PARTITION BY RANGE (slot);
+		{constraintsAndIndexes}
+
+		DO $$
+		DECLARE
+			next_id bigint;
+			slot_to bigint;
+		BEGIN
+			SELECT COALESCE(max(id), 0) + 1, (COALESCE(max(slot), 0) / {partitionSize} + 1) * {partitionSize} INTO next_id, slot_to FROM {table}_legacy;
+
+			-- ids continue from the identity sequence of the existing table
+			ALTER TABLE {table}_legacy ALTER COLUMN id DROP IDENTITY;
+			CREATE SEQUENCE {table}_id_seq OWNED BY {table}.id;
+			PERFORM setval('{table}_id_seq', next_id, false);
+			ALTER TABLE {table} ALTER COLUMN id SET DEFAULT nextval('{table}_id_seq');
+
+			EXECUTE format('ALTER TABLE {table} ATTACH PARTITION {table}_legacy FOR VALUES FROM (MINVALUE) TO (%s)', slot_to);
+		END $$;
+	`
+	return strings.NewReplacer(
+		"{table}", table,
+		"{constraintsAndIndexes}", constraintsAndIndexes,
+		"{partitionSize}", strconv.Itoa(vars.SlotPartitionSize),
+	).Replace(query)
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration017CreateArchiveManifest = &migrate.Migration{
+	Id: "017-create-archive-manifest",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableArchiveManifest + ` (
+			id          bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			inserted_at timestamp NOT NULL default current_timestamp,
+
+			table_name text NOT NULL,
+			slot_from  bigint NOT NULL,
+			slot_to    bigint NOT NULL,
+			num_rows   bigint NOT NULL,
+			location   text NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS ` + vars.TableArchiveManifest + `_table_slot_idx ON ` + vars.TableArchiveManifest + `(table_name, slot_from);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
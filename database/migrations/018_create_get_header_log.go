@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration018CreateGetHeaderLog = &migrate.Migration{
+	Id: "018-create-get-header-log",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableGetHeaderLog + ` (
+			id          bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			inserted_at timestamp NOT NULL default current_timestamp,
+
+			slot            bigint NOT NULL,
+			parent_hash     varchar(66) NOT NULL,
+			proposer_pubkey varchar(98) NOT NULL,
+			block_hash      varchar(66) NOT NULL,
+			value           NUMERIC(48, 0) NOT NULL,
+
+			user_agent   text NOT NULL,
+			requested_at timestamp NOT NULL,
+			latency_ms   bigint NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS ` + vars.TableGetHeaderLog + `_slot_idx ON ` + vars.TableGetHeaderLog + `(slot);
+		CREATE INDEX IF NOT EXISTS ` + vars.TableGetHeaderLog + `_proposer_pubkey_idx ON ` + vars.TableGetHeaderLog + `(proposer_pubkey);
+		CREATE INDEX IF NOT EXISTS ` + vars.TableGetHeaderLog + `_block_hash_idx ON ` + vars.TableGetHeaderLog + `(block_hash);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
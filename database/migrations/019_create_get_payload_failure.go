@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration019CreateGetPayloadFailure = &migrate.Migration{
+	Id: "019-create-get-payload-failure",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableGetPayloadFailure + ` (
+			id          bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			inserted_at timestamp NOT NULL default current_timestamp,
+
+			slot            bigint NOT NULL,
+			proposer_index  bigint NOT NULL,
+			proposer_pubkey varchar(98) NOT NULL,
+			block_hash      varchar(66) NOT NULL,
+
+			reason varchar(32) NOT NULL,
+			error  text NOT NULL,
+
+			user_agent   text NOT NULL,
+			requested_at timestamp NOT NULL,
+			ms_into_slot bigint NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS ` + vars.TableGetPayloadFailure + `_slot_idx ON ` + vars.TableGetPayloadFailure + `(slot);
+		CREATE INDEX IF NOT EXISTS ` + vars.TableGetPayloadFailure + `_proposer_pubkey_idx ON ` + vars.TableGetPayloadFailure + `(proposer_pubkey);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// Migration020CreateValidatorRegistrationLatest adds a table with only the latest registration of every validator,
+// which is filled from the registration history. The history table keeps a row per accepted registration.
+var Migration020CreateValidatorRegistrationLatest = &migrate.Migration{
+	Id: "020-create-validator-registration-latest",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableValidatorRegistrationLatest + ` (
+			pubkey      varchar(98) PRIMARY KEY,
+			inserted_at timestamp NOT NULL default current_timestamp,
+			updated_at  timestamp NOT NULL default current_timestamp,
+
+			fee_recipient varchar(42) NOT NULL,
+			timestamp     bigint NOT NULL,
+			gas_limit     bigint NOT NULL,
+			signature     text NOT NULL
+		);
+	`, `
+		INSERT INTO ` + vars.TableValidatorRegistrationLatest + ` (pubkey, fee_recipient, timestamp, gas_limit, signature)
+		SELECT DISTINCT ON (pubkey) pubkey, fee_recipient, timestamp, gas_limit, signature
+		FROM ` + vars.TableValidatorRegistration + `
+		ORDER BY pubkey, timestamp DESC
+		ON CONFLICT (pubkey) DO NOTHING;
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
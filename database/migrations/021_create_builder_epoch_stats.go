@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration021CreateBuilderEpochStats = &migrate.Migration{
+	Id: "021-create-builder-epoch-stats",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableBuilderEpochStats + ` (
+			epoch          bigint NOT NULL,
+			builder_pubkey varchar(98) NOT NULL,
+			day            date NOT NULL,
+			updated_at     timestamp NOT NULL default current_timestamp,
+
+			num_submissions bigint NOT NULL,
+			num_sim_errors  bigint NOT NULL,
+			num_payloads    bigint NOT NULL,
+			total_value     NUMERIC(48, 0) NOT NULL,
+
+			PRIMARY KEY (epoch, builder_pubkey)
+		);
+
+		CREATE INDEX IF NOT EXISTS ` + vars.TableBuilderEpochStats + `_day_idx ON ` + vars.TableBuilderEpochStats + `(day);
+		CREATE INDEX IF NOT EXISTS ` + vars.TableBuilderEpochStats + `_builder_pubkey_idx ON ` + vars.TableBuilderEpochStats + `(builder_pubkey, epoch);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
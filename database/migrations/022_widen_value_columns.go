@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration022WidenValueColumns = &migrate.Migration{
+	Id: "022-widen-value-columns",
+	Up: []string{`
+		-- NUMERIC(78, 0) fits any uint256, and increasing the precision does not rewrite the tables
+		ALTER TABLE ` + vars.TableBuilderBlockSubmission + ` ALTER COLUMN value       TYPE NUMERIC(78, 0);
+		ALTER TABLE ` + vars.TableBuilderBlockSubmission + ` ALTER COLUMN block_value TYPE NUMERIC(78, 0);
+		ALTER TABLE ` + vars.TableDeliveredPayload + ` ALTER COLUMN value TYPE NUMERIC(78, 0);
+		ALTER TABLE ` + vars.TableBuilderDemotions + ` ALTER COLUMN value TYPE NUMERIC(78, 0);
+		ALTER TABLE ` + vars.TableBlockBuilder + ` ALTER COLUMN collateral TYPE NUMERIC(78, 0);
+		ALTER TABLE ` + vars.TableGetHeaderLog + ` ALTER COLUMN value TYPE NUMERIC(78, 0);
+
+		-- the stats totals are sums of values, which are not limited
+		ALTER TABLE ` + vars.TableDeliveredPayloadDailyStats + ` ALTER COLUMN total_value TYPE NUMERIC;
+		ALTER TABLE ` + vars.TableBuilderEpochStats + ` ALTER COLUMN total_value TYPE NUMERIC;
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
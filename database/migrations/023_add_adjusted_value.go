@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+var Migration023AddAdjustedValue = &migrate.Migration{
+	Id: "023-add-adjusted-value",
+	Up: []string{`
+		ALTER TABLE ` + vars.TableBuilderBlockSubmission + ` ADD adjusted_value NUMERIC(78, 0);
+		ALTER TABLE ` + vars.TableDeliveredPayload + ` ADD adjusted_value NUMERIC(78, 0);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// Migration024CreateBuilderIdentity creates the builder identities. The pubkeys of an identity are the block builders
+// with its builder_id in builder_identity, which is separate from the builder_id used to share the collateral.
+var Migration024CreateBuilderIdentity = &migrate.Migration{
+	Id: "024-create-builder-identity",
+	Up: []string{`
+		CREATE TABLE IF NOT EXISTS ` + vars.TableBuilderIdentity + ` (
+			id          bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+			inserted_at timestamp NOT NULL default current_timestamp,
+
+			builder_id  varchar(98) NOT NULL UNIQUE,
+			description text NOT NULL default '',
+
+			is_high_prio   boolean NOT NULL default false,
+			is_blacklisted boolean NOT NULL default false
+		);
+
+		ALTER TABLE ` + vars.TableBlockBuilder + ` ADD builder_identity varchar(98) NOT NULL default '';
+		CREATE INDEX IF NOT EXISTS ` + vars.TableBlockBuilder + `_builder_identity_idx ON ` + vars.TableBlockBuilder + `(builder_identity);
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
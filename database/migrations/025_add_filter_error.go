@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// Migration025AddFilterError records submissions rejected by the filter list separately from simulation errors
+var Migration025AddFilterError = &migrate.Migration{
+	Id: "025-add-filter-error",
+	Up: []string{`
+		ALTER TABLE ` + vars.TableBuilderBlockSubmission + ` ADD filter_error text NOT NULL default '';
+	`},
+	Down: []string{},
+
+	DisableTransactionUp:   true,
+	DisableTransactionDown: true,
+}
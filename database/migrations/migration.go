@@ -18,5 +18,19 @@ var Migrations = migrate.MemoryMigrationSource{
 		Migration009BlockBuilderRemoveReference,
 		Migration010PayloadAddBlobFields,
 		Migration011AddSimulatedBlockValue,
+		Migration012AddLatencyBreakdown,
+		Migration013CreateInternalAPIAuditLog,
+		Migration014PayloadAddLandedStatus,
+		Migration015CreateDeliveredPayloadDailyStats,
+		Migration016PartitionBySlot,
+		Migration017CreateArchiveManifest,
+		Migration018CreateGetHeaderLog,
+		Migration019CreateGetPayloadFailure,
+		Migration020CreateValidatorRegistrationLatest,
+		Migration021CreateBuilderEpochStats,
+		Migration022WidenValueColumns,
+		Migration023AddAdjustedValue,
+		Migration024CreateBuilderIdentity,
+		Migration025AddFilterError,
 	},
 }
@@ -3,6 +3,9 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"math/big"
+	"slices"
+	"sort"
 	"time"
 
 	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
@@ -15,6 +18,14 @@ type MockDB struct {
 	Builders     map[string]*BlockBuilderEntry
 	Demotions    map[string]bool
 	Refunds      map[string]bool
+	Identities   map[string]*BuilderIdentityEntry
+
+	DeliveredPayloadDailyStats []*DeliveredPayloadDailyStatsEntry
+	BuilderEpochStats          []*BuilderEpochStatsEntry
+	BuilderSubmissions         []*BuilderBlockSubmissionEntry
+	ValidatorRegistrations     []*ValidatorRegistrationEntry
+	GetHeaderLog               []*GetHeaderLogEntry
+	GetPayloadFailures         []*GetPayloadFailureEntry
 }
 
 func (db MockDB) NumRegisteredValidators() (count uint64, err error) {
@@ -25,22 +36,56 @@ func (db MockDB) SaveValidatorRegistration(entry ValidatorRegistrationEntry) err
 	return nil
 }
 
+func (db MockDB) SaveValidatorRegistrations(entries []ValidatorRegistrationEntry) error {
+	return nil
+}
+
 func (db MockDB) GetValidatorRegistration(pubkey string) (*ValidatorRegistrationEntry, error) {
 	return nil, nil
 }
 
 func (db MockDB) GetValidatorRegistrationsForPubkeys(pubkeys []string) (entries []*ValidatorRegistrationEntry, err error) {
-	return nil, nil
+	for _, entry := range db.ValidatorRegistrations {
+		if slices.Contains(pubkeys, entry.Pubkey) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (db MockDB) GetValidatorRegistrationAt(pubkey string, timestamp uint64) (entry *ValidatorRegistrationEntry, err error) {
+	for _, reg := range db.ValidatorRegistrations {
+		if reg.Pubkey == pubkey && reg.Timestamp <= timestamp && (entry == nil || reg.Timestamp > entry.Timestamp) {
+			entry = reg
+		}
+	}
+	if entry == nil {
+		return nil, sql.ErrNoRows
+	}
+	return entry, nil
+}
+
+func (db MockDB) GetValidatorRegistrationHistory(pubkey string, limit uint64) (entries []*ValidatorRegistrationEntry, err error) {
+	for _, entry := range db.ValidatorRegistrations {
+		if entry.Pubkey == pubkey && uint64(len(entries)) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
 }
 
 func (db MockDB) GetLatestValidatorRegistrations(timestampOnly bool) ([]*ValidatorRegistrationEntry, error) {
-	return nil, nil
+	return db.ValidatorRegistrations, nil
 }
 
 func (db MockDB) SaveBuilderBlockSubmission(payload *common.VersionedSubmitBlockRequest, requestError, validationError error, receivedAt, eligibleAt time.Time, wasSimulated, saveExecPayload bool, profile common.Profile, optimisticSubmission bool, blockValue *uint256.Int) (entry *BuilderBlockSubmissionEntry, err error) {
 	return nil, nil
 }
 
+func (db MockDB) SaveBuilderBlockSubmissions(submissions []*BuilderBlockSubmissionEntry, execPayloads []*ExecutionPayloadEntry) error {
+	return nil
+}
+
 func (db MockDB) GetExecutionPayloadEntryByID(executionPayloadID int64) (entry *ExecutionPayloadEntry, err error) {
 	return nil, nil
 }
@@ -74,11 +119,138 @@ func (db MockDB) GetDeliveredPayloads(idFirst, idLast uint64) (entries []*Delive
 	return nil, nil
 }
 
+func (db MockDB) GetDeliveredPayloadsForExport(filters ExportFilters) (entries []*DeliveredPayloadEntry, err error) {
+	return nil, nil
+}
+
+func (db MockDB) GetUncheckedDeliveredPayloads(slotTo, limit uint64) (entries []*DeliveredPayloadEntry, err error) {
+	return nil, nil
+}
+
+func (db MockDB) RefreshDeliveredPayloadDailyStats() error {
+	return nil
+}
+
+func (db MockDB) GetDeliveredPayloadDailyStats(dayFrom, dayTo time.Time) (entries []*DeliveredPayloadDailyStatsEntry, err error) {
+	for _, entry := range db.DeliveredPayloadDailyStats {
+		if !entry.Day.Before(dayFrom) && !entry.Day.After(dayTo) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (db MockDB) RefreshBuilderEpochStats() error {
+	return nil
+}
+
+func (db MockDB) GetBuilderEpochStats(builderPubkey string, epochFrom, epochTo uint64) (entries []*BuilderEpochStatsEntry, err error) {
+	for _, entry := range db.BuilderEpochStats {
+		if entry.Epoch >= epochFrom && entry.Epoch <= epochTo && (builderPubkey == "" || entry.BuilderPubkey == builderPubkey) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (db MockDB) GetBuilderDailyStats(builderPubkey string, dayFrom, dayTo time.Time) (entries []*BuilderDailyStatsEntry, err error) {
+	for _, entry := range db.BuilderEpochStats {
+		if entry.Day.Before(dayFrom) || entry.Day.After(dayTo) || (builderPubkey != "" && entry.BuilderPubkey != builderPubkey) {
+			continue
+		}
+		entries = append(entries, &BuilderDailyStatsEntry{
+			Day:            entry.Day,
+			BuilderPubkey:  entry.BuilderPubkey,
+			NumSubmissions: entry.NumSubmissions,
+			NumSimErrors:   entry.NumSimErrors,
+			NumPayloads:    entry.NumPayloads,
+			TotalValue:     entry.TotalValue,
+		})
+	}
+	return entries, nil
+}
+
+func (db MockDB) GetBuilderIdentityEpochStats(builderID string, epochFrom, epochTo uint64) (entries []*BuilderEpochStatsEntry, err error) {
+	byEpoch := make(map[uint64]*BuilderEpochStatsEntry)
+	for _, entry := range db.BuilderEpochStats {
+		builder, ok := db.Builders[entry.BuilderPubkey]
+		if !ok || builder.BuilderIdentity != builderID || entry.Epoch < epochFrom || entry.Epoch > epochTo {
+			continue
+		}
+		sum, ok := byEpoch[entry.Epoch]
+		if !ok {
+			sum = &BuilderEpochStatsEntry{Epoch: entry.Epoch, BuilderID: builderID, Day: entry.Day, TotalValue: "0"}
+			byEpoch[entry.Epoch] = sum
+			entries = append(entries, sum)
+		}
+		sum.NumSubmissions += entry.NumSubmissions
+		sum.NumSimErrors += entry.NumSimErrors
+		sum.NumPayloads += entry.NumPayloads
+		sum.TotalValue = addDecimalStrings(sum.TotalValue, entry.TotalValue)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Epoch < entries[j].Epoch })
+	return entries, nil
+}
+
+func (db MockDB) GetBuilderIdentityDailyStats(builderID string, dayFrom, dayTo time.Time) (entries []*BuilderDailyStatsEntry, err error) {
+	byDay := make(map[time.Time]*BuilderDailyStatsEntry)
+	for _, entry := range db.BuilderEpochStats {
+		builder, ok := db.Builders[entry.BuilderPubkey]
+		if !ok || builder.BuilderIdentity != builderID || entry.Day.Before(dayFrom) || entry.Day.After(dayTo) {
+			continue
+		}
+		sum, ok := byDay[entry.Day]
+		if !ok {
+			sum = &BuilderDailyStatsEntry{Day: entry.Day, BuilderID: builderID, TotalValue: "0"}
+			byDay[entry.Day] = sum
+			entries = append(entries, sum)
+		}
+		sum.NumSubmissions += entry.NumSubmissions
+		sum.NumSimErrors += entry.NumSimErrors
+		sum.NumPayloads += entry.NumPayloads
+		sum.TotalValue = addDecimalStrings(sum.TotalValue, entry.TotalValue)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Day.Before(entries[j].Day) })
+	return entries, nil
+}
+
+// addDecimalStrings adds up two decimal numbers, like the numeric values summed up by postgres
+func addDecimalStrings(a, b string) string {
+	x, _ := new(big.Int).SetString(a, 10)
+	y, _ := new(big.Int).SetString(b, 10)
+	if x == nil || y == nil {
+		return a
+	}
+	return x.Add(x, y).String()
+}
+
+func (db MockDB) GetDeliveredPayloadsForBackcheck(slotFrom, slotTo uint64, afterID int64, uncheckedOnly bool, limit uint64) (entries []*DeliveredPayloadEntry, err error) {
+	return nil, nil
+}
+
+func (db MockDB) SetDeliveredPayloadLandedStatus(id int64, status, landedBlockHash string) error {
+	return nil
+}
+
 func (db MockDB) GetNumDeliveredPayloads() (uint64, error) {
 	return 0, nil
 }
 
 func (db MockDB) GetBuilderSubmissions(filters GetBuilderSubmissionsFilters) ([]*BuilderBlockSubmissionEntry, error) {
+	entries := []*BuilderBlockSubmissionEntry{}
+	for _, entry := range db.BuilderSubmissions {
+		if filters.IncludeSimErrors || entry.SimSuccess || entry.OptimisticSubmission {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (db MockDB) GetBuilderSubmissionsForExport(filters ExportFilters) (entries []*BuilderBlockSubmissionEntry, err error) {
+	return nil, nil
+}
+
+func (db MockDB) GetBuilderSubmissionsForResimulation(slotFrom, slotTo uint64, afterID int64, limit uint64) (entries []*BuilderBlockSubmissionEntry, err error) {
 	return nil, nil
 }
 
@@ -86,6 +258,10 @@ func (db MockDB) GetBuilderSubmissionsBySlots(slotFrom, slotTo uint64) (entries
 	return nil, nil
 }
 
+func (db MockDB) GetArchiveRows(tableName string, slotFrom, slotTo uint64, afterID int64, limit uint64) (columns []ArchiveColumn, rows [][]any, err error) {
+	return nil, nil, nil
+}
+
 func (db MockDB) SaveDeliveredPayload(bidTrace *common.BidTraceV2WithBlobFields, signedBlindedBeaconBlock *common.VersionedSignedBlindedBeaconBlock, signedAt time.Time, publishMs uint64) error {
 	return nil
 }
@@ -99,6 +275,7 @@ func (db MockDB) GetBlockBuilders() ([]*BlockBuilderEntry, error) {
 	for _, v := range db.Builders {
 		res = append(res, v)
 	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
 	return res, nil
 }
 
@@ -146,6 +323,63 @@ func (db MockDB) SetBlockBuilderCollateral(pubkey, builderID, collateral string)
 	return nil
 }
 
+func (db MockDB) GetBuilderIdentities() ([]*BuilderIdentityEntry, error) {
+	res := []*BuilderIdentityEntry{}
+	for _, v := range db.Identities {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+func (db MockDB) GetBuilderIdentity(builderID string) (*BuilderIdentityEntry, error) {
+	identity, ok := db.Identities[builderID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return identity, nil
+}
+
+func (db MockDB) UpsertBuilderIdentity(entry BuilderIdentityEntry) error {
+	if db.Identities == nil {
+		return fmt.Errorf("no Identities map") //nolint:goerr113
+	}
+	identity, ok := db.Identities[entry.BuilderID]
+	if !ok {
+		identity = &BuilderIdentityEntry{ID: int64(len(db.Identities) + 1), BuilderID: entry.BuilderID}
+		db.Identities[entry.BuilderID] = identity
+	}
+	identity.Description = entry.Description
+	identity.IsHighPrio = entry.IsHighPrio
+	identity.IsBlacklisted = entry.IsBlacklisted
+	for _, v := range db.Builders {
+		if v.BuilderIdentity == entry.BuilderID {
+			v.IsHighPrio = entry.IsHighPrio
+			v.IsBlacklisted = entry.IsBlacklisted
+		}
+	}
+	return nil
+}
+
+func (db MockDB) SetBlockBuilderIdentity(pubkey, builderID string) error {
+	builder, ok := db.Builders[pubkey]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if builderID == "" {
+		builder.BuilderIdentity = ""
+		return nil
+	}
+	identity, ok := db.Identities[builderID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	builder.BuilderIdentity = identity.BuilderID
+	builder.IsHighPrio = identity.IsHighPrio
+	builder.IsBlacklisted = identity.IsBlacklisted
+	return nil
+}
+
 func (db MockDB) IncBlockBuilderStatsAfterGetHeader(slot uint64, blockhash string) error {
 	return nil
 }
@@ -188,6 +422,40 @@ func (db MockDB) GetBuilderDemotion(trace *common.BidTraceV2WithBlobFields) (*Bu
 	return nil, nil
 }
 
+func (db MockDB) GetBuilderDemotionCounts() (map[string]uint64, error) {
+	counts := make(map[string]uint64)
+	for pubkey, isDemoted := range db.Demotions {
+		if isDemoted {
+			counts[pubkey] = 1
+		}
+	}
+	return counts, nil
+}
+
+func (db MockDB) GetBuilderScoreCounts(epochFrom uint64) (map[string]*common.BuilderScoreCounts, error) {
+	counts := make(map[string]*common.BuilderScoreCounts)
+	for _, entry := range db.BuilderEpochStats {
+		if entry.Epoch < epochFrom {
+			continue
+		}
+		if counts[entry.BuilderPubkey] == nil {
+			counts[entry.BuilderPubkey] = &common.BuilderScoreCounts{}
+		}
+		counts[entry.BuilderPubkey].NumSubmissions += entry.NumSubmissions
+		counts[entry.BuilderPubkey].NumSimErrors += entry.NumSimErrors
+	}
+	for pubkey, isDemoted := range db.Demotions {
+		if isDemoted && counts[pubkey] != nil {
+			counts[pubkey].NumDemotions++
+		}
+	}
+	return counts, nil
+}
+
+func (db MockDB) GetBuilderRecentSimStats(builderPubkey string, numSubmissions uint64) (numSimulated, numSimErrors uint64, err error) {
+	return 0, 0, nil
+}
+
 func (db MockDB) GetTooLateGetPayload(slot uint64) (entries []*TooLateGetPayloadEntry, err error) {
 	return nil, nil
 }
@@ -195,3 +463,63 @@ func (db MockDB) GetTooLateGetPayload(slot uint64) (entries []*TooLateGetPayload
 func (db MockDB) InsertTooLateGetPayload(slot uint64, proposerPubkey, blockHash string, slotStart, requestTime, decodeTime, msIntoSlot uint64) error {
 	return nil
 }
+
+func (db MockDB) InsertInternalAPIAuditEntry(entry InternalAPIAuditEntry) error {
+	return nil
+}
+
+func (db MockDB) InsertGetHeaderLogEntries(entries []GetHeaderLogEntry) error {
+	return nil
+}
+
+func (db MockDB) GetGetHeaderLogEntries(filters GetHeaderLogFilters) (entries []*GetHeaderLogEntry, err error) {
+	for _, entry := range db.GetHeaderLog {
+		if filters.Slot > 0 && entry.Slot != filters.Slot {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (db MockDB) InsertGetPayloadFailure(entry GetPayloadFailureEntry) error {
+	return nil
+}
+
+func (db MockDB) GetGetPayloadFailures(filters GetPayloadFailureFilters) (entries []*GetPayloadFailureEntry, err error) {
+	for _, entry := range db.GetPayloadFailures {
+		if filters.Reason != "" && entry.Reason != filters.Reason {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (db MockDB) InsertArchiveManifestEntry(entry ArchiveManifestEntry) error {
+	return nil
+}
+
+func (db MockDB) GetArchivedSlotTo(tableName string) (slotTo uint64, err error) {
+	return 0, nil
+}
+
+func (db MockDB) PruneExecutionPayloads(slotBefore, limit uint64) (numDeleted int64, err error) {
+	return 0, nil
+}
+
+func (db MockDB) PruneBuilderBlockSubmissions(slotBefore, limit uint64) (numDeleted int64, err error) {
+	return 0, nil
+}
+
+func (db MockDB) PruneGetHeaderLog(slotBefore, limit uint64) (numDeleted int64, err error) {
+	return 0, nil
+}
+
+func (db MockDB) CreateSlotPartitions(slot, numPartitionsAhead uint64) (created []*SlotPartition, err error) {
+	return nil, nil
+}
+
+func (db MockDB) DetachSlotPartitions(slotBefore uint64, drop bool) (detached []*SlotPartition, err error) {
+	return nil, nil
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/flashbots/mev-boost-relay/database/migrations"
+	"github.com/flashbots/mev-boost-relay/database/vars"
+	"github.com/lib/pq"
+)
+
+// slotPartitionedTables are partitioned by slot ranges of vars.SlotPartitionSize slots
+var slotPartitionedTables = []string{vars.TableBuilderBlockSubmission, vars.TableDeliveredPayload}
+
+// slotPartitionBoundRegex matches the bound of a partition, i.e. "FOR VALUES FROM (MINVALUE) TO ('100000')"
+var slotPartitionBoundRegex = regexp.MustCompile(`FROM \('?(MINVALUE|\d+)'?\) TO \('?(MAXVALUE|\d+)'?\)`)
+
+// SlotPartition is a partition of a table partitioned by slot, covering the slots from SlotFrom up to SlotTo (exclusive)
+type SlotPartition struct {
+	Table    string
+	Name     string
+	SlotFrom uint64
+	SlotTo   uint64
+}
+
+// isPartitioned returns whether the table was partitioned by PartitionTablesBySlot
+func (s *DatabaseService) isPartitioned(table string) (isPartitioned bool, err error) {
+	err = s.DB.Get(&isPartitioned, `SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = to_regclass($1))`, table)
+	return isPartitioned, err
+}
+
+// PartitionTablesBySlot partitions the block submissions and delivered payloads by slot, unless they already are, and
+// returns the tables which were partitioned. Each table is locked until it is done, so this should run while the relay
+// services are stopped.
+func (s *DatabaseService) PartitionTablesBySlot() (partitioned []string, err error) {
+	for _, table := range slotPartitionedTables {
+		isPartitioned, err := s.isPartitioned(table)
+		if err != nil {
+			return partitioned, err
+		} else if isPartitioned {
+			continue
+		}
+
+		tx, err := s.DB.Begin()
+		if err != nil {
+			return partitioned, err
+		}
+		if _, err := tx.Exec(migrations.PartitionBySlot[table]); err != nil {
+			_ = tx.Rollback()
+			return partitioned, err
+		}
+		if err := tx.Commit(); err != nil {
+			return partitioned, err
+		}
+		partitioned = append(partitioned, table)
+	}
+	return partitioned, nil
+}
+
+// GetSlotPartitions returns the partitions of the tables partitioned by slot
+func (s *DatabaseService) GetSlotPartitions() (partitions []*SlotPartition, err error) {
+	query := `SELECT c.relname, pg_get_expr(c.relpartbound, c.oid)
+	FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+	WHERE i.inhparent = to_regclass($1)
+	ORDER BY c.relname`
+
+	for _, table := range slotPartitionedTables {
+		rows, err := s.DB.Query(query, table)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var name, bound string
+			if err := rows.Scan(&name, &bound); err != nil {
+				_ = rows.Close()
+				return nil, err
+			}
+			match := slotPartitionBoundRegex.FindStringSubmatch(bound)
+			if match == nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("unexpected bound of partition %s: %s", name, bound) //nolint:goerr113
+			}
+			partition := &SlotPartition{Table: table, Name: name, SlotTo: math.MaxUint64}
+			if match[1] != "MINVALUE" {
+				partition.SlotFrom, _ = strconv.ParseUint(match[1], 10, 64)
+			}
+			if match[2] != "MAXVALUE" {
+				partition.SlotTo, _ = strconv.ParseUint(match[2], 10, 64)
+			}
+			partitions = append(partitions, partition)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return partitions, nil
+}
+
+// CreateSlotPartitions creates the partitions of the tables partitioned by slot from the one containing the given slot
+// up to numPartitionsAhead further ones, unless their slots are already covered. Tables which were not partitioned yet
+// are skipped. It returns the created partitions.
+func (s *DatabaseService) CreateSlotPartitions(slot, numPartitionsAhead uint64) (created []*SlotPartition, err error) {
+	partitions, err := s.GetSlotPartitions()
+	if err != nil {
+		return nil, err
+	}
+
+	slotFrom := slot / vars.SlotPartitionSize * vars.SlotPartitionSize
+	for _, table := range slotPartitionedTables {
+		isPartitioned, err := s.isPartitioned(table)
+		if err != nil {
+			return created, err
+		} else if !isPartitioned {
+			continue
+		}
+		for i := uint64(0); i <= numPartitionsAhead; i++ {
+			partition := &SlotPartition{
+				Table:    table,
+				Name:     fmt.Sprintf("%s_p%d", table, slotFrom+i*vars.SlotPartitionSize),
+				SlotFrom: slotFrom + i*vars.SlotPartitionSize,
+				SlotTo:   slotFrom + (i+1)*vars.SlotPartitionSize,
+			}
+			if isSlotCovered(partitions, table, partition.SlotFrom) {
+				continue
+			}
+			query := fmt.Sprintf(`CREATE TABLE %s PARTITION OF %s FOR VALUES FROM (%d) TO (%d)`,
+				pq.QuoteIdentifier(partition.Name), table, partition.SlotFrom, partition.SlotTo)
+			if _, err := s.DB.Exec(query); err != nil {
+				return created, err
+			}
+			created = append(created, partition)
+		}
+	}
+	return created, nil
+}
+
+func isSlotCovered(partitions []*SlotPartition, table string, slot uint64) bool {
+	for _, partition := range partitions {
+		if partition.Table == table && partition.SlotFrom <= slot && slot < partition.SlotTo {
+			return true
+		}
+	}
+	return false
+}
+
+// DetachSlotPartitions detaches the partitions of the tables partitioned by slot which only cover slots before the
+// given slot. Detached partitions are kept as regular tables for archiving, unless drop is set. It returns the detached
+// partitions.
+func (s *DatabaseService) DetachSlotPartitions(slotBefore uint64, drop bool) (detached []*SlotPartition, err error) {
+	partitions, err := s.GetSlotPartitions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, partition := range partitions {
+		if partition.SlotTo > slotBefore {
+			continue
+		}
+		query := fmt.Sprintf(`ALTER TABLE %s DETACH PARTITION %s`, partition.Table, pq.QuoteIdentifier(partition.Name))
+		if _, err := s.DB.Exec(query); err != nil {
+			return detached, err
+		}
+		if drop {
+			if _, err := s.DB.Exec(`DROP TABLE ` + pq.QuoteIdentifier(partition.Name)); err != nil {
+				return detached, err
+			}
+		}
+		detached = append(detached, partition)
+	}
+	return detached, nil
+}
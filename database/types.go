@@ -2,16 +2,24 @@ package database
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"fmt"
 	"math"
 	"strconv"
 	"time"
 
 	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
 	"github.com/flashbots/go-boost-utils/utils"
+	"github.com/holiman/uint256"
 )
 
-var errTimestampOverflow = errors.New("timestamp overflow")
+var (
+	errTimestampOverflow = errors.New("timestamp overflow")
+	ErrInvalidU256       = errors.New("invalid uint256 value")
+
+	ErrMissingInsertedSubmission = errors.New("no id returned for inserted block submission")
+)
 
 func NewNullInt64(i int64) sql.NullInt64 {
 	return sql.NullInt64{
@@ -36,9 +44,65 @@ func NewNullTime(t time.Time) sql.NullTime {
 	}
 }
 
+// U256Str is a wei value in a NUMERIC(78, 0) column, as a decimal string. Scanning and saving it fails for anything
+// but a uint256, instead of silently truncating it or failing the whole query. The empty string is NULL.
+type U256Str string
+
+func NewU256Str(value *uint256.Int) U256Str {
+	return U256Str(value.Dec())
+}
+
+// Uint256 returns the value as a uint256, failing if it is not one
+func (s U256Str) Uint256() (*uint256.Int, error) {
+	value, err := uint256.FromDecimal(string(s))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidU256, string(s))
+	}
+	return value, nil
+}
+
+func (s U256Str) String() string {
+	return string(s)
+}
+
+func (s *U256Str) Scan(src any) error {
+	var str string
+	switch v := src.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	case int64:
+		str = strconv.FormatInt(v, 10)
+	default:
+		return fmt.Errorf("%w: unsupported type %T", ErrInvalidU256, src)
+	}
+
+	if _, err := U256Str(str).Uint256(); err != nil {
+		return err
+	}
+	*s = U256Str(str)
+	return nil
+}
+
+func (s U256Str) Value() (driver.Value, error) {
+	if s == "" {
+		return nil, nil //nolint:nilnil
+	}
+	if _, err := s.Uint256(); err != nil {
+		return nil, err
+	}
+	return string(s), nil
+}
+
 type GetPayloadsFilters struct {
 	Slot           int64
 	Cursor         int64
+	FromSlot       uint64  // inclusive lower bound of the slot, if > 0
+	ToSlot         *uint64 // inclusive upper bound of the slot, if set
 	Limit          uint64
 	BlockHash      string
 	BlockNumber    int64
@@ -49,10 +113,27 @@ type GetPayloadsFilters struct {
 
 type GetBuilderSubmissionsFilters struct {
 	Slot          int64
+	Cursor        int64 // only submissions with a lower id, by id descending (math.MaxInt64 for the first page)
 	Limit         int64
 	BlockHash     string
 	BlockNumber   int64
 	BuilderPubkey string
+
+	IncludeSimErrors bool // include submissions which failed the simulation, and load the simulation results
+}
+
+type GetPayloadFailureFilters struct {
+	Slot           uint64
+	ProposerPubkey string
+	Reason         string
+	Limit          uint64
+}
+
+type GetHeaderLogFilters struct {
+	Slot           uint64
+	ProposerPubkey string
+	BlockHash      string
+	Limit          uint64
 }
 
 type ValidatorRegistrationEntry struct {
@@ -149,6 +230,12 @@ type BuilderBlockSubmissionEntry struct {
 	SimReqError  string         `db:"sim_req_error"`
 	BlockValue   sql.NullString `db:"block_value"`
 
+	// FilterError is set if the block was rejected by the filter list, which is not counted as a simulation error
+	FilterError string `db:"filter_error"`
+
+	// AdjustedValue is the value of the bid served to proposers, if it was changed by a bid adjustment
+	AdjustedValue sql.NullString `db:"adjusted_value"`
+
 	// BidTrace data
 	Signature string `db:"signature"`
 
@@ -163,20 +250,22 @@ type BuilderBlockSubmissionEntry struct {
 	GasUsed  uint64 `db:"gas_used"`
 	GasLimit uint64 `db:"gas_limit"`
 
-	NumTx uint64 `db:"num_tx"`
-	Value string `db:"value"`
+	NumTx uint64  `db:"num_tx"`
+	Value U256Str `db:"value"`
 
 	// Helpers
 	Epoch       uint64 `db:"epoch"`
 	BlockNumber uint64 `db:"block_number"`
 
 	// Profile data.
-	DecodeDuration       uint64 `db:"decode_duration"`
-	PrechecksDuration    uint64 `db:"prechecks_duration"`
-	SimulationDuration   uint64 `db:"simulation_duration"`
-	RedisUpdateDuration  uint64 `db:"redis_update_duration"`
-	TotalDuration        uint64 `db:"total_duration"`
-	OptimisticSubmission bool   `db:"optimistic_submission"`
+	DecodeDuration         uint64 `db:"decode_duration"`
+	PrechecksDuration      uint64 `db:"prechecks_duration"`
+	SignatureCheckDuration uint64 `db:"signature_check_duration"`
+	SimQueueWaitDuration   uint64 `db:"sim_queue_wait_duration"`
+	SimulationDuration     uint64 `db:"simulation_duration"`
+	RedisUpdateDuration    uint64 `db:"redis_update_duration"`
+	TotalDuration          uint64 `db:"total_duration"`
+	OptimisticSubmission   bool   `db:"optimistic_submission"`
 }
 
 type DeliveredPayloadEntry struct {
@@ -200,16 +289,28 @@ type DeliveredPayloadEntry struct {
 	GasUsed  uint64 `db:"gas_used"`
 	GasLimit uint64 `db:"gas_limit"`
 
-	NumTx uint64 `db:"num_tx"`
-	Value string `db:"value"`
+	NumTx uint64  `db:"num_tx"`
+	Value U256Str `db:"value"`
 
 	NumBlobs      uint64 `db:"num_blobs"`
 	BlobGasUsed   uint64 `db:"blob_gas_used"`
 	ExcessBlobGas uint64 `db:"excess_blob_gas"`
 
 	PublishMs uint64 `db:"publish_ms"`
+
+	AdjustedValue sql.NullString `db:"adjusted_value"` // value of the bid served to the proposer, if it was adjusted
+
+	LandedStatus    string `db:"landed_status"`
+	LandedBlockHash string `db:"landed_block_hash"` // hash of the canonical block of the slot, if any
 }
 
+// Landed status of a delivered payload, empty until checked
+const (
+	PayloadLandedStatusLanded   = "landed"   // the payload is part of the chain
+	PayloadLandedStatusMissed   = "missed"   // there is no block in the slot
+	PayloadLandedStatusReplaced = "replaced" // the slot has a block with a different payload
+)
+
 type BlockBuilderEntry struct {
 	ID         int64     `db:"id"          json:"id"`
 	InsertedAt time.Time `db:"inserted_at" json:"inserted_at"`
@@ -224,6 +325,9 @@ type BlockBuilderEntry struct {
 	Collateral string `db:"collateral" json:"collateral"`
 	BuilderID  string `db:"builder_id" json:"builder_id"`
 
+	// BuilderIdentity is the builder_id of the builder identity this pubkey is grouped into, if any
+	BuilderIdentity string `db:"builder_identity" json:"builder_identity"`
+
 	LastSubmissionID   sql.NullInt64 `db:"last_submission_id"   json:"last_submission_id"`
 	LastSubmissionSlot uint64        `db:"last_submission_slot" json:"last_submission_slot"`
 
@@ -233,6 +337,19 @@ type BlockBuilderEntry struct {
 	NumSentGetPayload uint64 `db:"num_sent_getpayload" json:"num_sent_getpayload"`
 }
 
+// BuilderIdentityEntry is a named builder which groups the block builders (submission pubkeys) with its builder id as
+// their builder identity. The high-prio and blacklisted status of the identity applies to all of its pubkeys.
+type BuilderIdentityEntry struct {
+	ID         int64     `db:"id"          json:"id"`
+	InsertedAt time.Time `db:"inserted_at" json:"inserted_at"`
+
+	BuilderID   string `db:"builder_id"  json:"builder_id"`
+	Description string `db:"description" json:"description"`
+
+	IsHighPrio    bool `db:"is_high_prio"   json:"is_high_prio"`
+	IsBlacklisted bool `db:"is_blacklisted" json:"is_blacklisted"`
+}
+
 type BuilderDemotionEntry struct {
 	ID         int64     `db:"id"`
 	InsertedAt time.Time `db:"inserted_at"`
@@ -247,7 +364,7 @@ type BuilderDemotionEntry struct {
 	BuilderPubkey  string `db:"builder_pubkey"`
 	ProposerPubkey string `db:"proposer_pubkey"`
 
-	Value string `db:"value"`
+	Value U256Str `db:"value"`
 
 	FeeRecipient string `db:"fee_recipient"`
 
@@ -256,6 +373,134 @@ type BuilderDemotionEntry struct {
 	SimError string `db:"sim_error"`
 }
 
+// InternalAPIAuditEntry is a state-changing call to the internal API
+type InternalAPIAuditEntry struct {
+	ID         int64     `db:"id"`
+	InsertedAt time.Time `db:"inserted_at"`
+
+	Actor      string `db:"actor"`
+	Method     string `db:"method"`
+	Path       string `db:"path"`
+	Params     string `db:"params"`
+	StatusCode int    `db:"status_code"`
+	RemoteAddr string `db:"remote_addr"`
+}
+
+// DeliveredPayloadDailyStatsEntry is the number and total value of the payloads delivered for a builder on a UTC day
+type DeliveredPayloadDailyStatsEntry struct {
+	Day           time.Time `db:"day"`
+	BuilderPubkey string    `db:"builder_pubkey"`
+	UpdatedAt     time.Time `db:"updated_at"`
+
+	NumPayloads uint64 `db:"num_payloads"`
+	TotalValue  string `db:"total_value"`
+}
+
+// BuilderEpochStatsEntry is the number of submissions, failed simulations and delivered payloads (with their total value)
+// of a builder in an epoch. Day is the UTC day of the first submission or payload of the epoch.
+type BuilderEpochStatsEntry struct {
+	Epoch         uint64    `db:"epoch"`
+	BuilderPubkey string    `db:"builder_pubkey"`
+	BuilderID     string    `db:"builder_id"` // only set for the stats of a builder identity, instead of the pubkey
+	Day           time.Time `db:"day"`
+	UpdatedAt     time.Time `db:"updated_at"`
+
+	NumSubmissions uint64 `db:"num_submissions"`
+	NumSimErrors   uint64 `db:"num_sim_errors"`
+	NumPayloads    uint64 `db:"num_payloads"`
+	TotalValue     string `db:"total_value"`
+}
+
+// BuilderDailyStatsEntry is the sum of the epoch stats of a builder on a UTC day
+type BuilderDailyStatsEntry struct {
+	Day           time.Time `db:"day"`
+	BuilderPubkey string    `db:"builder_pubkey"`
+	BuilderID     string    `db:"builder_id"` // only set for the stats of a builder identity, instead of the pubkey
+
+	NumSubmissions uint64 `db:"num_submissions"`
+	NumSimErrors   uint64 `db:"num_sim_errors"`
+	NumPayloads    uint64 `db:"num_payloads"`
+	TotalValue     string `db:"total_value"`
+}
+
+// ExportFilters selects a batch of rows for an export, in the order of their id
+type ExportFilters struct {
+	Cursor       int64     // only rows with a higher id are returned
+	IDLast       uint64    // inclusive upper bound of the id, if > 0
+	InsertedFrom time.Time // inclusive lower bound of inserted_at, if set
+	InsertedTo   time.Time // exclusive upper bound of inserted_at, if set
+	Limit        uint64
+}
+
+// ArchiveManifestEntry records that the rows of a table from SlotFrom to SlotTo (inclusive) were exported to Location
+type ArchiveManifestEntry struct {
+	ID         int64     `db:"id"`
+	InsertedAt time.Time `db:"inserted_at"`
+
+	TableName string `db:"table_name"`
+	SlotFrom  uint64 `db:"slot_from"`
+	SlotTo    uint64 `db:"slot_to"`
+	NumRows   uint64 `db:"num_rows"`
+	Location  string `db:"location"`
+}
+
+// ArchiveColumn is a column of the rows which are archived, with the name of its database type (like INT8, BOOL,
+// TIMESTAMP, TEXT or NUMERIC)
+type ArchiveColumn struct {
+	Name   string
+	DBType string
+}
+
+// GetHeaderLogEntry is a bid which was served in response to a getHeader request
+type GetHeaderLogEntry struct {
+	ID         int64     `db:"id"`
+	InsertedAt time.Time `db:"inserted_at"`
+
+	Slot           uint64  `db:"slot"`
+	ParentHash     string  `db:"parent_hash"`
+	ProposerPubkey string  `db:"proposer_pubkey"`
+	BlockHash      string  `db:"block_hash"`
+	Value          U256Str `db:"value"`
+
+	UserAgent   string    `db:"user_agent"`
+	RequestedAt time.Time `db:"requested_at"`
+	LatencyMs   uint64    `db:"latency_ms"`
+}
+
+// Reasons of failed getPayload requests. Requests failing before the proposer signature was verified (not the current
+// slot, unknown proposer or invalid signature) are not saved.
+const (
+	GetPayloadFailureNotCurrentSlot   = "not_current_slot"
+	GetPayloadFailureUnknownProposer  = "unknown_proposer"
+	GetPayloadFailureInvalidSignature = "invalid_signature"
+
+	GetPayloadFailureBidInvalidated   = "bid_invalidated"
+	GetPayloadFailureEquivocation     = "equivocation"
+	GetPayloadFailureUnknownPayload   = "unknown_payload"
+	GetPayloadFailureAlreadyDelivered = "already_delivered"
+	GetPayloadFailureTooLate          = "too_late"
+	GetPayloadFailureInvalidHeader    = "invalid_header"
+	GetPayloadFailurePublishFailed    = "publish_failed"
+)
+
+// GetPayloadFailureEntry is a getPayload request which failed after its proposer signature was verified
+type GetPayloadFailureEntry struct {
+	ID         int64     `db:"id"`
+	InsertedAt time.Time `db:"inserted_at"`
+
+	Slot           uint64 `db:"slot"`
+	ProposerIndex  uint64 `db:"proposer_index"`
+	ProposerPubkey string `db:"proposer_pubkey"` // empty if the proposer index is unknown
+	BlockHash      string `db:"block_hash"`
+
+	Reason string `db:"reason"`
+	Error  string `db:"error"`
+
+	UserAgent   string    `db:"user_agent"`
+	RequestedAt time.Time `db:"requested_at"`
+	MsIntoSlot  int64     `db:"ms_into_slot"`
+}
+
 type TooLateGetPayloadEntry struct {
 	ID         int64     `db:"id"`
 	InsertedAt time.Time `db:"inserted_at"`
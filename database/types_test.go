@@ -17,3 +17,27 @@ func TestNewNullTime(t *testing.T) {
 	require.True(t, nt1.Valid)
 	require.Equal(t, t1, nt1.Time)
 }
+
+func TestU256Str(t *testing.T) {
+	maxU256 := "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+
+	var s U256Str
+	require.NoError(t, s.Scan([]byte(maxU256)))
+	require.Equal(t, U256Str(maxU256), s)
+	value, err := s.Uint256()
+	require.NoError(t, err)
+	require.Equal(t, maxU256, value.Dec())
+
+	require.NoError(t, s.Scan(nil))
+	require.Equal(t, U256Str(""), s)
+	dbValue, err := s.Value()
+	require.NoError(t, err)
+	require.Nil(t, dbValue)
+
+	// larger than a uint256, negative and fractional values
+	for _, invalid := range []string{maxU256 + "0", "-1", "1.5", "abc"} {
+		require.ErrorIs(t, s.Scan([]byte(invalid)), ErrInvalidU256, invalid)
+		_, err = U256Str(invalid).Value()
+		require.ErrorIs(t, err, ErrInvalidU256, invalid)
+	}
+}
@@ -1,18 +1,36 @@
 package database
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"strconv"
+	"time"
 
 	builderApi "github.com/attestantio/go-builder-client/api"
+	builderApiCapella "github.com/attestantio/go-builder-client/api/capella"
 	builderApiDeneb "github.com/attestantio/go-builder-client/api/deneb"
+	builderApiElectra "github.com/attestantio/go-builder-client/api/electra"
+	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/capella"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/holiman/uint256"
 )
 
 var ErrUnsupportedExecutionPayload = errors.New("unsupported execution payload version")
 
+// electraExecutionPayloadJSON is the stored execution payload of Electra blocks, which is the getPayload response
+// with the execution requests of the block submission, so that the submission can be rebuilt from it
+type electraExecutionPayloadJSON struct {
+	ExecutionPayload  *deneb.ExecutionPayload      `json:"execution_payload"`
+	BlobsBundle       *builderApiDeneb.BlobsBundle `json:"blobs_bundle"`
+	ExecutionRequests *electra.ExecutionRequests   `json:"execution_requests"`
+}
+
 func PayloadToExecPayloadEntry(payload *common.VersionedSubmitBlockRequest) (*ExecutionPayloadEntry, error) {
 	var _payload []byte
 	var version string
@@ -26,7 +44,7 @@ func PayloadToExecPayloadEntry(payload *common.VersionedSubmitBlockRequest) (*Ex
 		}
 		version = common.ForkVersionStringCapella
 	case spec.DataVersionDeneb:
-		_payload, err = json.Marshal(builderApiDeneb.ExecutionPayloadAndBlobsBundle{
+		_payload, err = json.Marshal(&builderApiDeneb.ExecutionPayloadAndBlobsBundle{
 			ExecutionPayload: payload.Deneb.ExecutionPayload,
 			BlobsBundle:      payload.Deneb.BlobsBundle,
 		})
@@ -35,9 +53,10 @@ func PayloadToExecPayloadEntry(payload *common.VersionedSubmitBlockRequest) (*Ex
 		}
 		version = common.ForkVersionStringDeneb
 	case spec.DataVersionElectra:
-		_payload, err = json.Marshal(builderApiDeneb.ExecutionPayloadAndBlobsBundle{
-			ExecutionPayload: payload.Electra.ExecutionPayload,
-			BlobsBundle:      payload.Electra.BlobsBundle,
+		_payload, err = json.Marshal(&electraExecutionPayloadJSON{
+			ExecutionPayload:  payload.Electra.ExecutionPayload,
+			BlobsBundle:       payload.Electra.BlobsBundle,
+			ExecutionRequests: payload.Electra.ExecutionRequests,
 		})
 		if err != nil {
 			return nil, err
@@ -62,6 +81,144 @@ func PayloadToExecPayloadEntry(payload *common.VersionedSubmitBlockRequest) (*Ex
 	}, nil
 }
 
+// NewBuilderBlockSubmissionEntry creates the entry of a block submission, without the execution payload id
+func NewBuilderBlockSubmissionEntry(payload *common.VersionedSubmitBlockRequest, requestError, validationError error, receivedAt, eligibleAt time.Time, wasSimulated bool, profile common.Profile, optimisticSubmission bool, blockValue *uint256.Int) (*BuilderBlockSubmissionEntry, error) {
+	simErrStr := ""
+	if validationError != nil {
+		simErrStr = validationError.Error()
+	}
+
+	requestErrStr := ""
+	if requestError != nil {
+		requestErrStr = requestError.Error()
+	}
+
+	blockValueStr := ""
+	if blockValue != nil {
+		blockValueStr = blockValue.Dec()
+	}
+	submission, err := common.GetBlockSubmissionInfo(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BuilderBlockSubmissionEntry{
+		ReceivedAt: NewNullTime(receivedAt),
+		EligibleAt: NewNullTime(eligibleAt),
+
+		WasSimulated: wasSimulated,
+		SimSuccess:   wasSimulated && validationError == nil,
+		SimError:     simErrStr,
+		SimReqError:  requestErrStr,
+		BlockValue: sql.NullString{
+			String: blockValueStr,
+			Valid:  blockValue != nil,
+		},
+
+		Signature: submission.Signature.String(),
+
+		Slot:       submission.BidTrace.Slot,
+		BlockHash:  submission.BidTrace.BlockHash.String(),
+		ParentHash: submission.BidTrace.ParentHash.String(),
+
+		BuilderPubkey:        submission.BidTrace.BuilderPubkey.String(),
+		ProposerPubkey:       submission.BidTrace.ProposerPubkey.String(),
+		ProposerFeeRecipient: submission.BidTrace.ProposerFeeRecipient.String(),
+
+		GasUsed:  submission.GasUsed,
+		GasLimit: submission.GasLimit,
+
+		NumTx: uint64(len(submission.Transactions)),
+		Value: NewU256Str(submission.BidTrace.Value),
+
+		Epoch:       submission.BidTrace.Slot / common.SlotsPerEpoch,
+		BlockNumber: submission.BlockNumber,
+
+		DecodeDuration:         profile.Decode,
+		PrechecksDuration:      profile.Prechecks,
+		SignatureCheckDuration: profile.SignatureCheck,
+		SimQueueWaitDuration:   profile.SimQueueWait,
+		SimulationDuration:     profile.Simulation,
+		RedisUpdateDuration:    profile.RedisUpdate,
+		TotalDuration:          profile.Total,
+		OptimisticSubmission:   optimisticSubmission,
+	}, nil
+}
+
+// BuilderSubmissionEntryToSubmitBlockRequest rebuilds a block submission from its entry (which must include the signature)
+// and its execution payload. The execution requests of Electra blocks are empty for payloads stored before they were
+// saved with the payload.
+func BuilderSubmissionEntryToSubmitBlockRequest(entry *BuilderBlockSubmissionEntry, payloadEntry *ExecutionPayloadEntry) (*common.VersionedSubmitBlockRequest, error) {
+	executionPayload, err := ExecutionPayloadEntryToExecutionPayload(payloadEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	messageJSON, err := json.Marshal(map[string]string{
+		"slot":                   strconv.FormatUint(entry.Slot, 10),
+		"parent_hash":            entry.ParentHash,
+		"block_hash":             entry.BlockHash,
+		"builder_pubkey":         entry.BuilderPubkey,
+		"proposer_pubkey":        entry.ProposerPubkey,
+		"proposer_fee_recipient": entry.ProposerFeeRecipient,
+		"gas_limit":              strconv.FormatUint(entry.GasLimit, 10),
+		"gas_used":               strconv.FormatUint(entry.GasUsed, 10),
+		"value":                  entry.Value.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	message := new(builderApiV1.BidTrace)
+	if err := json.Unmarshal(messageJSON, message); err != nil {
+		return nil, err
+	}
+	var signature phase0.BLSSignature
+	if err := json.Unmarshal([]byte(strconv.Quote(entry.Signature)), &signature); err != nil {
+		return nil, err
+	}
+
+	payload := &common.VersionedSubmitBlockRequest{}
+	payload.Version = executionPayload.Version
+	switch executionPayload.Version { //nolint:exhaustive
+	case spec.DataVersionCapella:
+		payload.Capella = &builderApiCapella.SubmitBlockRequest{
+			Message:          message,
+			ExecutionPayload: executionPayload.Capella,
+			Signature:        signature,
+		}
+	case spec.DataVersionDeneb:
+		payload.Deneb = &builderApiDeneb.SubmitBlockRequest{
+			Message:          message,
+			ExecutionPayload: executionPayload.Deneb.ExecutionPayload,
+			BlobsBundle:      executionPayload.Deneb.BlobsBundle,
+			Signature:        signature,
+		}
+	case spec.DataVersionElectra:
+		stored := new(electraExecutionPayloadJSON)
+		if err := json.Unmarshal([]byte(payloadEntry.Payload), stored); err != nil {
+			return nil, err
+		}
+		executionRequests := stored.ExecutionRequests
+		if executionRequests == nil {
+			executionRequests = &electra.ExecutionRequests{
+				Deposits:       []*electra.DepositRequest{},
+				Withdrawals:    []*electra.WithdrawalRequest{},
+				Consolidations: []*electra.ConsolidationRequest{},
+			}
+		}
+		payload.Electra = &builderApiElectra.SubmitBlockRequest{
+			Message:           message,
+			ExecutionPayload:  executionPayload.Electra.ExecutionPayload,
+			BlobsBundle:       executionPayload.Electra.BlobsBundle,
+			ExecutionRequests: executionRequests,
+			Signature:         signature,
+		}
+	default:
+		return nil, ErrUnsupportedExecutionPayload
+	}
+	return payload, nil
+}
+
 func DeliveredPayloadEntryToBidTraceV2JSON(payload *DeliveredPayloadEntry) common.BidTraceV2JSON {
 	return common.BidTraceV2JSON{
 		Slot:                 payload.Slot,
@@ -72,9 +229,10 @@ func DeliveredPayloadEntryToBidTraceV2JSON(payload *DeliveredPayloadEntry) commo
 		ProposerFeeRecipient: payload.ProposerFeeRecipient,
 		GasLimit:             payload.GasLimit,
 		GasUsed:              payload.GasUsed,
-		Value:                payload.Value,
+		Value:                payload.Value.String(),
 		NumTx:                payload.NumTx,
 		BlockNumber:          payload.BlockNumber,
+		AdjustedValue:        payload.AdjustedValue.String,
 	}
 }
 
@@ -84,10 +242,26 @@ func BuilderSubmissionEntryToBidTraceV2WithTimestampJSON(payload *BuilderBlockSu
 		timestamp = payload.ReceivedAt.Time
 	}
 
+	// The latency breakdown is only available if the profile data was loaded (and recorded) for the submission
+	var latency *common.SubmissionLatencyJSON
+	if payload.TotalDuration > 0 {
+		latency = &common.SubmissionLatencyJSON{
+			DecodeUs:         payload.DecodeDuration,
+			PrechecksUs:      payload.PrechecksDuration,
+			SignatureCheckUs: payload.SignatureCheckDuration,
+			SimQueueWaitUs:   payload.SimQueueWaitDuration,
+			SimulationUs:     payload.SimulationDuration,
+			RedisUpdateUs:    payload.RedisUpdateDuration,
+			TotalUs:          payload.TotalDuration,
+		}
+	}
+
 	return common.BidTraceV2WithTimestampJSON{
+		ID:                   payload.ID,
 		Timestamp:            timestamp.Unix(),
 		TimestampMs:          timestamp.UnixMilli(),
 		OptimisticSubmission: payload.OptimisticSubmission,
+		Latency:              latency,
 		BidTraceV2JSON: common.BidTraceV2JSON{
 			Slot:                 payload.Slot,
 			ParentHash:           payload.ParentHash,
@@ -97,13 +271,41 @@ func BuilderSubmissionEntryToBidTraceV2WithTimestampJSON(payload *BuilderBlockSu
 			ProposerFeeRecipient: payload.ProposerFeeRecipient,
 			GasLimit:             payload.GasLimit,
 			GasUsed:              payload.GasUsed,
-			Value:                payload.Value,
+			Value:                payload.Value.String(),
 			NumTx:                payload.NumTx,
 			BlockNumber:          payload.BlockNumber,
+			AdjustedValue:        payload.AdjustedValue.String,
 		},
 	}
 }
 
+func GetHeaderLogEntryToJSON(entry *GetHeaderLogEntry) common.GetHeaderLogJSON {
+	return common.GetHeaderLogJSON{
+		Slot:           entry.Slot,
+		ParentHash:     entry.ParentHash,
+		ProposerPubkey: entry.ProposerPubkey,
+		BlockHash:      entry.BlockHash,
+		Value:          entry.Value.String(),
+		UserAgent:      entry.UserAgent,
+		TimestampMs:    entry.RequestedAt.UnixMilli(),
+		LatencyMs:      entry.LatencyMs,
+	}
+}
+
+func GetPayloadFailureEntryToJSON(entry *GetPayloadFailureEntry) common.GetPayloadFailureJSON {
+	return common.GetPayloadFailureJSON{
+		Slot:           entry.Slot,
+		ProposerIndex:  entry.ProposerIndex,
+		ProposerPubkey: entry.ProposerPubkey,
+		BlockHash:      entry.BlockHash,
+		Reason:         entry.Reason,
+		Error:          entry.Error,
+		UserAgent:      entry.UserAgent,
+		TimestampMs:    entry.RequestedAt.UnixMilli(),
+		MsIntoSlot:     entry.MsIntoSlot,
+	}
+}
+
 func ExecutionPayloadEntryToExecutionPayload(executionPayloadEntry *ExecutionPayloadEntry) (payload *builderApi.VersionedSubmitBlindedBlockResponse, err error) {
 	payloadVersion := executionPayloadEntry.Version
 	if payloadVersion == common.ForkVersionStringElectra {
@@ -1,10 +1,19 @@
 package database
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
+	builderApiElectra "github.com/attestantio/go-builder-client/api/electra"
+	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/electra"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/flashbots/go-boost-utils/bls"
 	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/require"
 )
 
@@ -46,3 +55,114 @@ func TestExecutionPayloadEntryToExecutionPayloadDeneb(t *testing.T) {
 	require.Equal(t, "0xbd1ae4f7edb2315d2df70a8d9881fab8d6763fb1c00533ae729050928c38d05a", payload.Deneb.ExecutionPayload.BlockHash.String())
 	require.Len(t, payload.Deneb.BlobsBundle.Blobs, 1)
 }
+
+func TestBuilderSubmissionEntryToBidTraceV2WithTimestampJSON(t *testing.T) {
+	entry := &BuilderBlockSubmissionEntry{
+		ID:         7,
+		InsertedAt: time.Unix(1685616301, 0),
+		Slot:       5552306,
+
+		DecodeDuration:         42,
+		PrechecksDuration:      43,
+		SignatureCheckDuration: 44,
+		SimQueueWaitDuration:   45,
+		SimulationDuration:     46,
+		RedisUpdateDuration:    47,
+		TotalDuration:          48,
+	}
+
+	bid := BuilderSubmissionEntryToBidTraceV2WithTimestampJSON(entry)
+	require.Equal(t, uint64(5552306), bid.Slot)
+	require.Equal(t, int64(7), bid.ID)
+	require.Equal(t, int64(1685616301), bid.Timestamp)
+	require.Equal(t, &common.SubmissionLatencyJSON{
+		DecodeUs:         42,
+		PrechecksUs:      43,
+		SignatureCheckUs: 44,
+		SimQueueWaitUs:   45,
+		SimulationUs:     46,
+		RedisUpdateUs:    47,
+		TotalUs:          48,
+	}, bid.Latency)
+
+	// no latency breakdown without profile data
+	entry.TotalDuration = 0
+	bid = BuilderSubmissionEntryToBidTraceV2WithTimestampJSON(entry)
+	require.Nil(t, bid.Latency)
+}
+
+func TestBuilderSubmissionEntryToSubmitBlockRequest(t *testing.T) {
+	sk, _, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+
+	for _, version := range []spec.DataVersion{spec.DataVersionCapella, spec.DataVersionDeneb} {
+		t.Run(version.String(), func(t *testing.T) {
+			req := common.TestBuilderSubmitBlockRequest(sk, &common.BidTraceV2WithBlobFields{
+				BidTrace: builderApiV1.BidTrace{
+					Slot:  5552306,
+					Value: uint256.NewInt(123),
+				},
+			}, version)
+			payloadEntry, err := PayloadToExecPayloadEntry(req)
+			require.NoError(t, err)
+			entry, err := NewBuilderBlockSubmissionEntry(req, nil, nil, time.Now(), time.Now(), true, common.Profile{}, false, nil)
+			require.NoError(t, err)
+
+			rebuilt, err := BuilderSubmissionEntryToSubmitBlockRequest(entry, payloadEntry)
+			require.NoError(t, err)
+			expectedSSZ, err := req.MarshalSSZ()
+			require.NoError(t, err)
+			rebuiltSSZ, err := rebuilt.MarshalSSZ()
+			require.NoError(t, err)
+			require.Equal(t, expectedSSZ, rebuiltSSZ)
+		})
+	}
+
+	// Electra payloads are stored with the execution requests
+	denebReq := common.TestBuilderSubmitBlockRequest(sk, &common.BidTraceV2WithBlobFields{
+		BidTrace: builderApiV1.BidTrace{Slot: 5552306, Value: uint256.NewInt(123)},
+	}, spec.DataVersionDeneb)
+	req := &common.VersionedSubmitBlockRequest{}
+	req.Version = spec.DataVersionElectra
+	req.Electra = &builderApiElectra.SubmitBlockRequest{
+		Message:          denebReq.Deneb.Message,
+		ExecutionPayload: denebReq.Deneb.ExecutionPayload,
+		BlobsBundle:      denebReq.Deneb.BlobsBundle,
+		ExecutionRequests: &electra.ExecutionRequests{
+			Deposits:       []*electra.DepositRequest{{Pubkey: phase0.BLSPubKey{1}, WithdrawalCredentials: make([]byte, 32), Amount: 32000000000, Signature: phase0.BLSSignature{3}, Index: 7}},
+			Withdrawals:    []*electra.WithdrawalRequest{{SourceAddress: bellatrix.ExecutionAddress{2}, Amount: 1}},
+			Consolidations: []*electra.ConsolidationRequest{},
+		},
+		Signature: denebReq.Deneb.Signature,
+	}
+	payloadEntry, err := PayloadToExecPayloadEntry(req)
+	require.NoError(t, err)
+	entry, err := NewBuilderBlockSubmissionEntry(req, nil, nil, time.Now(), time.Now(), true, common.Profile{}, false, nil)
+	require.NoError(t, err)
+	rebuilt, err := BuilderSubmissionEntryToSubmitBlockRequest(entry, payloadEntry)
+	require.NoError(t, err)
+	expectedSSZ, err := req.MarshalSSZ()
+	require.NoError(t, err)
+	rebuiltSSZ, err := rebuilt.MarshalSSZ()
+	require.NoError(t, err)
+	require.Equal(t, expectedSSZ, rebuiltSSZ)
+
+	// the stored payload is still read as the getPayload response
+	resp, err := ExecutionPayloadEntryToExecutionPayload(payloadEntry)
+	require.NoError(t, err)
+	require.Equal(t, req.Electra.ExecutionPayload.BlockHash, resp.Electra.ExecutionPayload.BlockHash)
+
+	// payloads stored without the execution requests are rebuilt with empty ones
+	payloadEntry.Payload = `{"execution_payload":` + mustMarshalJSON(t, req.Electra.ExecutionPayload) + `,"blobs_bundle":` + mustMarshalJSON(t, req.Electra.BlobsBundle) + `}`
+	rebuilt, err = BuilderSubmissionEntryToSubmitBlockRequest(entry, payloadEntry)
+	require.NoError(t, err)
+	require.Equal(t, req.Electra.Message, rebuilt.Electra.Message)
+	require.Empty(t, rebuilt.Electra.ExecutionRequests.Deposits)
+}
+
+func mustMarshalJSON(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
@@ -7,7 +7,7 @@ var (
 	tableBase = common.GetEnv("DB_TABLE_PREFIX", "dev")
 
 	TableMigrations             = tableBase + "_migrations"
-	TableValidatorRegistration  = tableBase + "_validator_registration"
+	TableValidatorRegistration  = tableBase + "_validator_registration" // history, a row per accepted registration
 	TableExecutionPayload       = tableBase + "_execution_payload"
 	TableBuilderBlockSubmission = tableBase + "_builder_block_submission"
 	TableDeliveredPayload       = tableBase + "_payload_delivered"
@@ -15,4 +15,17 @@ var (
 	TableBuilderDemotions       = tableBase + "_builder_demotions"
 	TableBlockedValidator       = tableBase + "_blocked_validator"
 	TableTooLateGetPayload      = tableBase + "_too_late_get_payload"
+	TableInternalAPIAuditLog    = tableBase + "_internal_api_audit_log"
+	TableBuilderIdentity        = tableBase + "_builder_identity"
+
+	TableDeliveredPayloadDailyStats = tableBase + "_payload_delivered_daily_stats"
+	TableBuilderEpochStats          = tableBase + "_builder_epoch_stats"
+	TableArchiveManifest            = tableBase + "_archive_manifest"
+	TableGetHeaderLog               = tableBase + "_get_header_log"
+	TableGetPayloadFailure          = tableBase + "_get_payload_failure"
+
+	TableValidatorRegistrationLatest = tableBase + "_validator_registration_latest"
 )
+
+// SlotPartitionSize is the number of slots of a partition of the tables partitioned by slot
+const SlotPartitionSize = 100_000
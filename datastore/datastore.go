@@ -2,7 +2,11 @@
 package datastore
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -11,6 +15,7 @@ import (
 	builderApi "github.com/attestantio/go-builder-client/api"
 	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
 	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/flashbots/go-utils/cli"
 	"github.com/flashbots/mev-boost-relay/beaconclient"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/flashbots/mev-boost-relay/database"
@@ -22,6 +27,33 @@ import (
 
 var ErrExecutionPayloadNotFound = errors.New("execution payload not found")
 
+var (
+	// in between full refreshes, only validators with an index above the highest known one are queried
+	knownValidatorsFullRefreshEpochs = uint64(cli.GetEnvInt("KNOWN_VALIDATORS_FULL_REFRESH_EPOCHS", 16)) //nolint:gosec
+
+	// number of validator indices queried at once for the diff refresh
+	knownValidatorsDiffBatchSize = uint64(1000)
+
+	// if enabled, only the API instance holding a Redis lock queries the known validators from the beacon node, and
+	// stores them in Redis for the other instances
+	knownValidatorsShared = os.Getenv("KNOWN_VALIDATORS_SHARED") == "1"
+
+	// KnownValidatorsFromHousekeeper is whether only the housekeeper queries the known validators from the beacon node,
+	// and stores them in Redis, from where the API instances load them
+	KnownValidatorsFromHousekeeper = os.Getenv("KNOWN_VALIDATORS_FROM_HOUSEKEEPER") == "1"
+
+	// the lock is released after the refresh, this only applies if the instance holding it stops
+	knownValidatorsLockTTL = 5 * time.Minute
+
+	// how often an instance not holding the lock checks whether the refresh of the lock holder is done
+	knownValidatorsLockPollInterval = 500 * time.Millisecond
+)
+
+const lockNameKnownValidators = "known-validators"
+
+// number of validator registration timestamps written to Redis at once when restoring them from the database
+const validatorRegistrationsRestoreBatchSize = 10_000
+
 type GetHeaderResponseKey struct {
 	Slot           uint64
 	ParentHash     string
@@ -46,17 +78,33 @@ type Datastore struct {
 	knownValidatorsIsUpdating uberatomic.Bool
 	knownValidatorsLastSlot   uberatomic.Uint64
 
+	// only accessed by the refresh, which runs one at a time
+	knownValidatorsLastFullSlot uint64
+	knownValidatorsMaxIndex     uint64
+
+	registrationCache *registrationCache
+
+	// identifies this instance as owner of Redis locks
+	lockOwner string
+
 	// Used for proposer-API readiness check
 	KnownValidatorsWasUpdated uberatomic.Bool
 }
 
 func NewDatastore(redisCache *RedisCache, memcached *Memcached, db database.IDatabaseService) (ds *Datastore, err error) {
+	lockOwner := make([]byte, 16)
+	if _, err = rand.Read(lockOwner); err != nil {
+		return nil, err
+	}
+
 	ds = &Datastore{
 		db:                      db,
 		memcached:               memcached,
 		redis:                   redisCache,
 		knownValidatorsByPubkey: make(map[common.PubkeyHex]uint64),
 		knownValidatorsByIndex:  make(map[uint64]common.PubkeyHex),
+		registrationCache:       newRegistrationCache(registrationCacheTTL, registrationCacheMaxSize),
+		lockOwner:               hex.EncodeToString(lockOwner),
 	}
 
 	return ds, err
@@ -68,6 +116,16 @@ func NewDatastore(redisCache *RedisCache, memcached *Memcached, db database.IDat
 // This is why we schedule the requests for slot 4 and 20 of every epoch, 6 seconds
 // into the slot (on suggestion of @potuz). It's also run once at startup.
 func (ds *Datastore) RefreshKnownValidators(log *logrus.Entry, beaconClient beaconclient.IMultiBeaconClient, slot uint64) {
+	ds.refreshKnownValidatorsIfDue(log, beaconClient, slot, false)
+}
+
+// PublishKnownValidators loads the known validators from the CL client like RefreshKnownValidators, and stores them in
+// Redis for the API instances. It is used by the housekeeper if KNOWN_VALIDATORS_FROM_HOUSEKEEPER is enabled.
+func (ds *Datastore) PublishKnownValidators(log *logrus.Entry, beaconClient beaconclient.IMultiBeaconClient, slot uint64) {
+	ds.refreshKnownValidatorsIfDue(log, beaconClient, slot, true)
+}
+
+func (ds *Datastore) refreshKnownValidatorsIfDue(log *logrus.Entry, beaconClient beaconclient.IMultiBeaconClient, slot uint64, publish bool) {
 	// Ensure there's only one at a time
 	if isAlreadyUpdating := ds.knownValidatorsIsUpdating.Swap(true); isAlreadyUpdating {
 		return
@@ -107,24 +165,137 @@ func (ds *Datastore) RefreshKnownValidators(log *logrus.Entry, beaconClient beac
 		time.Sleep(6 * time.Second)
 	}
 
-	ds.RefreshKnownValidatorsWithoutChecks(log, beaconClient, slot)
+	// With shared known validators, only the instance holding the lock queries the beacon node. If the housekeeper
+	// publishes them, it holds the lock and the API instances only load them from Redis. The other instances wait
+	// for the lock to be released, to load the validators of this refresh and not of the previous one.
+	source := beaconValidatorsSource(beaconClient)
+	if KnownValidatorsFromHousekeeper && !publish {
+		ds.waitForKnownValidatorsLock(log)
+		source = ds.redisValidatorsSource()
+	} else if knownValidatorsShared || publish {
+		isLockHolder, err := ds.redis.AcquireLock(context.Background(), lockNameKnownValidators, ds.lockOwner, knownValidatorsLockTTL)
+		if err != nil {
+			log.WithError(err).Error("failed to acquire the known validators lock")
+			return
+		}
+		if isLockHolder {
+			defer func() {
+				err := ds.redis.ReleaseLock(context.Background(), lockNameKnownValidators, ds.lockOwner)
+				if err != nil {
+					log.WithError(err).Error("failed to release the known validators lock")
+				}
+			}()
+			source.publish = true
+		} else if publish {
+			log.Info("known validators are published by another instance")
+			return
+		} else {
+			ds.waitForKnownValidatorsLock(log)
+			source = ds.redisValidatorsSource()
+		}
+	}
+
+	// Only query new validators if the last full refresh was recent enough
+	if ds.knownValidatorsLastFullSlot > 0 && slot-ds.knownValidatorsLastFullSlot < knownValidatorsFullRefreshEpochs*common.SlotsPerEpoch {
+		ds.refreshNewKnownValidators(log, source, slot)
+		return
+	}
+
+	ds.refreshKnownValidators(log, source, slot)
+}
+
+// waitForKnownValidatorsLock waits until no instance holds the known validators lock, i.e. the lock holder has stored
+// the validators in Redis. After the lock TTL the validators are loaded anyway, as the lock holder likely stopped.
+func (ds *Datastore) waitForKnownValidatorsLock(log *logrus.Entry) {
+	timeStartWaiting := time.Now()
+	for time.Since(timeStartWaiting) < knownValidatorsLockTTL {
+		isLocked, err := ds.redis.IsLocked(context.Background(), lockNameKnownValidators)
+		if err != nil {
+			log.WithError(err).Error("failed to check the known validators lock")
+			return
+		}
+		if !isLocked {
+			log.WithField("durationWaitingMs", time.Since(timeStartWaiting).Milliseconds()).Debug("known validators lock released")
+			return
+		}
+		time.Sleep(knownValidatorsLockPollInterval)
+	}
+	log.Warn("known validators lock not released, loading the validators from redis anyway")
+}
+
+// validatorsSource is where the known validators are loaded from
+type validatorsSource struct {
+	name string
+
+	// stream calls fn for all validators (or only those with the given indices), and returns the number of validators
+	stream func(indices []uint64, fn func(index uint64, pubkey common.PubkeyHex)) (numValidators uint64, err error)
+
+	// whether the loaded validators are stored in Redis, for the API instances not holding the lock
+	publish bool
+}
+
+func beaconValidatorsSource(beaconClient beaconclient.IMultiBeaconClient) validatorsSource {
+	return validatorsSource{
+		name: "beacon node",
+		stream: func(indices []uint64, fn func(index uint64, pubkey common.PubkeyHex)) (uint64, error) {
+			return beaconClient.StreamStateValidators(beaconclient.StateIDHead, indices, func(valEntry beaconclient.ValidatorResponseEntry) { // head is fastest
+				fn(valEntry.Index, common.NewPubkeyHex(valEntry.Validator.Pubkey))
+			})
+		},
+	}
+}
+
+func (ds *Datastore) redisValidatorsSource() validatorsSource {
+	return validatorsSource{
+		name: "redis",
+		stream: func(indices []uint64, fn func(index uint64, pubkey common.PubkeyHex)) (uint64, error) {
+			return ds.redis.StreamKnownValidators(context.Background(), indices, fn)
+		},
+	}
 }
 
 func (ds *Datastore) RefreshKnownValidatorsWithoutChecks(log *logrus.Entry, beaconClient beaconclient.IMultiBeaconClient, slot uint64) {
-	log.Info("Querying validators from beacon node... (this may take a while)")
+	ds.refreshKnownValidators(log, beaconValidatorsSource(beaconClient), slot)
+}
+
+func (ds *Datastore) refreshKnownValidators(log *logrus.Entry, source validatorsSource, slot uint64) {
+	log = log.WithField("source", source.name)
+	log.Info("Querying validators... (this may take a while)")
 	timeStartFetching := time.Now()
-	validators, err := beaconClient.GetStateValidators(beaconclient.StateIDHead) // head is fastest
+
+	// The validators are streamed into the new maps directly, to avoid holding all full response entries in memory at once
+	numPrevValidators := ds.NumKnownValidators()
+	knownValidatorsByPubkey := make(map[common.PubkeyHex]uint64, numPrevValidators)
+	knownValidatorsByIndex := make(map[uint64]common.PubkeyHex, numPrevValidators)
+	maxIndex := uint64(0)
+	_, err := source.stream(nil, func(index uint64, pk common.PubkeyHex) {
+		knownValidatorsByPubkey[pk] = index
+		knownValidatorsByIndex[index] = pk
+		maxIndex = max(maxIndex, index)
+	})
 	if err != nil {
-		log.WithError(err).Error("failed to fetch validators from all beacon nodes")
+		log.WithError(err).Error("failed to fetch validators")
 		return
 	}
 
-	numValidators := len(validators.Data)
+	numValidators := len(knownValidatorsByIndex)
+	if numValidators == 0 {
+		// i.e. no instance has stored the validators in Redis yet
+		log.Warn("no validators received, keeping the previous known validators")
+		return
+	}
 	log = log.WithFields(logrus.Fields{
 		"numKnownValidators":        numValidators,
 		"durationFetchValidatorsMs": time.Since(timeStartFetching).Milliseconds(),
 	})
-	log.Infof("received known validators from beacon-node")
+	log.Infof("received known validators")
+
+	if source.publish {
+		err = ds.redis.SetKnownValidators(context.Background(), knownValidatorsByIndex)
+		if err != nil {
+			log.WithError(err).Error("failed to store known validators in redis")
+		}
+	}
 
 	err = ds.redis.SetStats(RedisStatsFieldValidatorsTotal, strconv.Itoa(numValidators))
 	if err != nil {
@@ -133,15 +304,8 @@ func (ds *Datastore) RefreshKnownValidatorsWithoutChecks(log *logrus.Entry, beac
 
 	// At this point, consider the update successful
 	ds.knownValidatorsLastSlot.Store(slot)
-
-	knownValidatorsByPubkey := make(map[common.PubkeyHex]uint64)
-	knownValidatorsByIndex := make(map[uint64]common.PubkeyHex)
-
-	for _, valEntry := range validators.Data {
-		pk := common.NewPubkeyHex(valEntry.Validator.Pubkey)
-		knownValidatorsByPubkey[pk] = valEntry.Index
-		knownValidatorsByIndex[valEntry.Index] = pk
-	}
+	ds.knownValidatorsLastFullSlot = slot
+	ds.knownValidatorsMaxIndex = maxIndex
 
 	ds.knownValidatorsLock.Lock()
 	ds.knownValidatorsByPubkey = knownValidatorsByPubkey
@@ -152,6 +316,67 @@ func (ds *Datastore) RefreshKnownValidatorsWithoutChecks(log *logrus.Entry, beac
 	log.Infof("known validators updated")
 }
 
+// refreshNewKnownValidators adds the validators with an index above the highest known one. New validators
+// get consecutive indices, so batches of indices are queried until one is not full.
+func (ds *Datastore) refreshNewKnownValidators(log *logrus.Entry, source validatorsSource, slot uint64) {
+	log = log.WithFields(logrus.Fields{
+		"fromIndex": ds.knownValidatorsMaxIndex + 1,
+		"source":    source.name,
+	})
+	timeStartFetching := time.Now()
+
+	numNewValidators := 0
+	for {
+		indices := make([]uint64, 0, knownValidatorsDiffBatchSize)
+		for i := range knownValidatorsDiffBatchSize {
+			indices = append(indices, ds.knownValidatorsMaxIndex+1+i)
+		}
+
+		newValidators := make(map[uint64]common.PubkeyHex)
+		numValidators, err := source.stream(indices, func(index uint64, pk common.PubkeyHex) {
+			newValidators[index] = pk
+		})
+		if err != nil {
+			log.WithError(err).Error("failed to fetch new validators")
+			return
+		}
+
+		if source.publish {
+			err = ds.redis.AddKnownValidators(context.Background(), newValidators)
+			if err != nil {
+				log.WithError(err).Error("failed to store new known validators in redis")
+			}
+		}
+
+		ds.knownValidatorsLock.Lock()
+		for index, pk := range newValidators {
+			ds.knownValidatorsByPubkey[pk] = index
+			ds.knownValidatorsByIndex[index] = pk
+			ds.knownValidatorsMaxIndex = max(ds.knownValidatorsMaxIndex, index)
+		}
+		ds.knownValidatorsLock.Unlock()
+		numNewValidators += len(newValidators)
+
+		if numValidators < knownValidatorsDiffBatchSize {
+			break
+		}
+	}
+
+	ds.knownValidatorsLastSlot.Store(slot)
+
+	numKnownValidators := ds.NumKnownValidators()
+	err := ds.redis.SetStats(RedisStatsFieldValidatorsTotal, strconv.Itoa(numKnownValidators))
+	if err != nil {
+		log.WithError(err).Error("failed to set stats for RedisStatsFieldValidatorsTotal")
+	}
+
+	log.WithFields(logrus.Fields{
+		"numNewValidators":          numNewValidators,
+		"numKnownValidators":        numKnownValidators,
+		"durationFetchValidatorsMs": time.Since(timeStartFetching).Milliseconds(),
+	}).Info("known validators updated with new validators")
+}
+
 func (ds *Datastore) IsKnownValidator(pubkeyHex common.PubkeyHex) bool {
 	ds.knownValidatorsLock.RLock()
 	defer ds.knownValidatorsLock.RUnlock()
@@ -184,22 +409,102 @@ func (ds *Datastore) SetKnownValidator(pubkeyHex common.PubkeyHex, index uint64)
 	ds.knownValidatorsByIndex[index] = pubkeyHex
 }
 
-// SaveValidatorRegistration saves a validator registration into both Redis and the database
-func (ds *Datastore) SaveValidatorRegistration(entry builderApiV1.SignedValidatorRegistration) error {
-	// First save in the database
-	err := ds.db.SaveValidatorRegistration(database.SignedValidatorRegistrationToEntry(entry))
-	if err != nil {
-		return errors.Wrap(err, "failed saving validator registration to database")
+// SaveValidatorRegistrations saves many validator registrations in the database with one insert, and their timestamps in
+// Redis in one batch. If the insert fails, the registrations are saved in halves of the batch until the failing ones are
+// isolated, which are skipped, and the last such error is returned.
+func (ds *Datastore) SaveValidatorRegistrations(entries []builderApiV1.SignedValidatorRegistration) (err error) {
+	dbEntries := make([]database.ValidatorRegistrationEntry, len(entries))
+	for i, entry := range entries {
+		dbEntries[i] = database.SignedValidatorRegistrationToEntry(entry)
 	}
+	saved := make([]bool, len(entries))
+	if dbErr := ds.saveValidatorRegistrationsInDatabase(dbEntries, saved); dbErr != nil {
+		err = errors.Wrap(dbErr, "failed saving validator registration to database")
+	}
+
+	timestamps := make(map[common.PubkeyHex]uint64, len(entries))
+	for i, entry := range entries {
+		if !saved[i] {
+			continue
+		}
+		pk := common.NewPubkeyHex(entry.Message.Pubkey.String())
+		timestamp := uint64(entry.Message.Timestamp.Unix()) //nolint:gosec
+		if timestamp > timestamps[pk] {
+			timestamps[pk] = timestamp
+		}
+	}
+
+	redisErr := ds.redis.SetValidatorRegistrationTimestampsIfNewer(timestamps)
+	if redisErr != nil {
+		return errors.Wrap(redisErr, "failed saving validator registrations to redis")
+	}
+	for pk, timestamp := range timestamps {
+		ds.registrationCache.set(pk, timestamp)
+	}
+	return err
+}
+
+// saveValidatorRegistrationsInDatabase inserts the entries in one batch, and if that fails, each half of them in turn.
+// The saved entries are marked in saved, and the last error of a single entry is returned.
+func (ds *Datastore) saveValidatorRegistrationsInDatabase(entries []database.ValidatorRegistrationEntry, saved []bool) (err error) {
+	if len(entries) == 0 {
+		return nil
+	}
+	err = ds.db.SaveValidatorRegistrations(entries)
+	if err == nil {
+		for i := range saved {
+			saved[i] = true
+		}
+		return nil
+	}
+	if len(entries) == 1 {
+		return err
+	}
+
+	half := len(entries) / 2
+	err = ds.saveValidatorRegistrationsInDatabase(entries[:half], saved[:half])
+	if secondErr := ds.saveValidatorRegistrationsInDatabase(entries[half:], saved[half:]); secondErr != nil {
+		err = secondErr
+	}
+	return err
+}
 
-	// then save in redis
-	pk := common.NewPubkeyHex(entry.Message.Pubkey.String())
-	err = ds.redis.SetValidatorRegistrationTimestampIfNewer(pk, uint64(entry.Message.Timestamp.Unix())) //nolint:gosec
+// RestoreValidatorRegistrationsInRedis saves the timestamps of all latest validator registrations from the database to
+// Redis, in batches, unless Redis has newer ones. It returns the number of registrations in the database.
+func (ds *Datastore) RestoreValidatorRegistrationsInRedis() (numRegistrations int, err error) {
+	regs, err := ds.db.GetLatestValidatorRegistrations(true)
 	if err != nil {
-		return errors.Wrap(err, "failed saving validator registration to redis")
+		return 0, err
 	}
 
-	return nil
+	timestamps := make(map[common.PubkeyHex]uint64, validatorRegistrationsRestoreBatchSize)
+	for i, reg := range regs {
+		timestamps[common.NewPubkeyHex(reg.Pubkey)] = reg.Timestamp
+		if len(timestamps) < validatorRegistrationsRestoreBatchSize && i < len(regs)-1 {
+			continue
+		}
+		err = ds.redis.SetValidatorRegistrationTimestampsIfNewer(timestamps)
+		if err != nil {
+			return 0, err
+		}
+		clear(timestamps)
+	}
+	return len(regs), nil
+}
+
+// GetValidatorRegistrationTimestamp returns the timestamp of the latest registration of a validator from memory or Redis,
+// or 0 if there is none
+func (ds *Datastore) GetValidatorRegistrationTimestamp(pubkeyHex common.PubkeyHex) (uint64, error) {
+	if timestamp, found := ds.registrationCache.get(pubkeyHex); found {
+		return timestamp, nil
+	}
+
+	timestamp, err := ds.redis.GetValidatorRegistrationTimestamp(pubkeyHex)
+	if err != nil {
+		return 0, err
+	}
+	ds.registrationCache.set(pubkeyHex, timestamp)
+	return timestamp, nil
 }
 
 // GetGetPayloadResponse returns the getPayload response from memory or Redis or Database
@@ -232,8 +537,8 @@ func (ds *Datastore) GetGetPayloadResponse(log *logrus.Entry, slot uint64, propo
 		}
 	}
 
-	// 3. try to get from database (should not happen, it's just a backup)
-	executionPayloadEntry, err := ds.db.GetExecutionPayloadEntryBySlotPkHash(slot, proposerPubkey, blockHash)
+	// 3. try to get from database (should not happen, it's just a backup, i.e. after a Redis flush or failover)
+	executionPayloadEntry, err := ds.db.GetExecutionPayloadEntryBySlotPkHash(slot, _proposerPubkey, _blockHash)
 	if errors.Is(err, sql.ErrNoRows) {
 		log.WithError(err).Warn("execution payload not found in database")
 		return nil, ErrExecutionPayloadNotFound
@@ -1,9 +1,15 @@
 package datastore
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/flashbots/mev-boost-relay/beaconclient"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/flashbots/mev-boost-relay/database"
 	"github.com/stretchr/testify/require"
@@ -70,6 +76,237 @@ func TestGetPayloadDatabaseFallback(t *testing.T) {
 			blockHash, err := payload.BlockHash()
 			require.NoError(t, err)
 			require.Equal(t, testCase.blockHash, blockHash.String())
+
+			// the database lookup uses the lowercase proposer pubkey and block hash
+			_, err = ds.GetGetPayloadResponse(common.TestLog, 1, "A", "B")
+			require.NoError(t, err)
 		})
 	}
 }
+
+func TestRefreshNewKnownValidators(t *testing.T) {
+	ds := setupTestDatastore(t, &database.MockDB{})
+	beaconInstance := beaconclient.NewMockBeaconInstance()
+	beaconClient := beaconclient.NewMultiBeaconClient(common.TestLog, []beaconclient.IBeaconInstance{beaconInstance})
+
+	addValidator := func(index uint64) common.PubkeyHex {
+		pk := common.NewPubkeyHex(fmt.Sprintf("0x%096x", index))
+		beaconInstance.AddValidator(beaconclient.ValidatorResponseEntry{
+			Index:     index,
+			Validator: beaconclient.ValidatorResponseValidatorData{Pubkey: pk.String()},
+		})
+		return pk
+	}
+
+	prevBatchSize := knownValidatorsDiffBatchSize
+	knownValidatorsDiffBatchSize = 2
+	t.Cleanup(func() { knownValidatorsDiffBatchSize = prevBatchSize })
+
+	for i := range uint64(3) {
+		addValidator(i)
+	}
+	ds.RefreshKnownValidatorsWithoutChecks(common.TestLog, beaconClient, 32)
+	require.Equal(t, 3, ds.NumKnownValidators())
+	require.Equal(t, uint64(2), ds.knownValidatorsMaxIndex)
+
+	// new validators are added over multiple batches
+	for i := uint64(3); i < 8; i++ {
+		addValidator(i)
+	}
+	ds.refreshNewKnownValidators(common.TestLog, beaconValidatorsSource(beaconClient), 48)
+	require.Equal(t, 8, ds.NumKnownValidators())
+	require.Equal(t, uint64(7), ds.knownValidatorsMaxIndex)
+	require.Equal(t, uint64(48), ds.knownValidatorsLastSlot.Load())
+	pk, found := ds.GetKnownValidatorPubkeyByIndex(7)
+	require.True(t, found)
+	require.True(t, ds.IsKnownValidator(pk))
+
+	// validators which are gone are only removed by the next full refresh
+	beaconInstance.SetValidators(make(map[common.PubkeyHex]beaconclient.ValidatorResponseEntry))
+	pk = addValidator(8)
+	ds.refreshNewKnownValidators(common.TestLog, beaconValidatorsSource(beaconClient), 64)
+	require.Equal(t, 9, ds.NumKnownValidators())
+	ds.RefreshKnownValidatorsWithoutChecks(common.TestLog, beaconClient, 80)
+	require.Equal(t, 1, ds.NumKnownValidators())
+	require.True(t, ds.IsKnownValidator(pk))
+}
+
+func TestRefreshKnownValidatorsShared(t *testing.T) {
+	prevShared, prevPollInterval := knownValidatorsShared, knownValidatorsLockPollInterval
+	knownValidatorsShared, knownValidatorsLockPollInterval = true, 10*time.Millisecond
+	t.Cleanup(func() { knownValidatorsShared, knownValidatorsLockPollInterval = prevShared, prevPollInterval })
+
+	// two API instances with the same Redis
+	redisTestServer, err := miniredis.Run()
+	require.NoError(t, err)
+	redisCache, err := NewRedisCache("", redisTestServer.Addr(), "")
+	require.NoError(t, err)
+	ds1, err := NewDatastore(redisCache, nil, &database.MockDB{})
+	require.NoError(t, err)
+	ds2, err := NewDatastore(redisCache, nil, &database.MockDB{})
+	require.NoError(t, err)
+
+	beaconInstance := beaconclient.NewMockBeaconInstance()
+	beaconClient := beaconclient.NewMultiBeaconClient(common.TestLog, []beaconclient.IBeaconInstance{beaconInstance})
+	for i := range uint64(3) {
+		beaconInstance.AddValidator(beaconclient.ValidatorResponseEntry{
+			Index:     i,
+			Validator: beaconclient.ValidatorResponseValidatorData{Pubkey: fmt.Sprintf("0x%096x", i)},
+		})
+	}
+
+	// the instance holding the lock queries the beacon node, stores the validators in Redis and releases the lock
+	ds1.RefreshKnownValidators(common.TestLog, beaconClient, 100)
+	require.Equal(t, 3, ds1.NumKnownValidators())
+	acquired, err := redisCache.AcquireLock(t.Context(), lockNameKnownValidators, ds1.lockOwner, time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	// while the lock holder is still busy, the other instance waits for it, and then loads the validators from Redis
+	done := make(chan struct{})
+	go func() {
+		ds2.RefreshKnownValidators(common.TestLog, beaconClient, 100)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, ds2.KnownValidatorsWasUpdated.Load())
+	err = redisCache.ReleaseLock(t.Context(), lockNameKnownValidators, ds1.lockOwner)
+	require.NoError(t, err)
+	<-done
+	require.Equal(t, 3, ds2.NumKnownValidators())
+	require.True(t, ds2.KnownValidatorsWasUpdated.Load())
+
+	// new validators are shared as well
+	beaconInstance.AddValidator(beaconclient.ValidatorResponseEntry{
+		Index:     3,
+		Validator: beaconclient.ValidatorResponseValidatorData{Pubkey: fmt.Sprintf("0x%096x", 3)},
+	})
+	source := beaconValidatorsSource(beaconClient)
+	source.publish = true
+	ds1.refreshNewKnownValidators(common.TestLog, source, 120)
+	ds2.refreshNewKnownValidators(common.TestLog, ds2.redisValidatorsSource(), 120)
+	require.Equal(t, 4, ds2.NumKnownValidators())
+	pk, found := ds2.GetKnownValidatorPubkeyByIndex(3)
+	require.True(t, found)
+	require.Equal(t, fmt.Sprintf("0x%096x", 3), pk.String())
+}
+
+func TestPublishKnownValidators(t *testing.T) {
+	prevFromHousekeeper := KnownValidatorsFromHousekeeper
+	KnownValidatorsFromHousekeeper = true
+	t.Cleanup(func() { KnownValidatorsFromHousekeeper = prevFromHousekeeper })
+
+	// the housekeeper and an API instance with the same Redis
+	redisTestServer, err := miniredis.Run()
+	require.NoError(t, err)
+	redisCache, err := NewRedisCache("", redisTestServer.Addr(), "")
+	require.NoError(t, err)
+	dsHousekeeper, err := NewDatastore(redisCache, nil, &database.MockDB{})
+	require.NoError(t, err)
+	dsAPI, err := NewDatastore(redisCache, nil, &database.MockDB{})
+	require.NoError(t, err)
+
+	beaconInstance := beaconclient.NewMockBeaconInstance()
+	beaconClient := beaconclient.NewMultiBeaconClient(common.TestLog, []beaconclient.IBeaconInstance{beaconInstance})
+	for i := range uint64(3) {
+		beaconInstance.AddValidator(beaconclient.ValidatorResponseEntry{
+			Index:     i,
+			Validator: beaconclient.ValidatorResponseValidatorData{Pubkey: fmt.Sprintf("0x%096x", i)},
+		})
+	}
+
+	// the API instance never queries the beacon node, and there are no validators in Redis yet
+	dsAPI.RefreshKnownValidators(common.TestLog, beaconClient, 100)
+	require.Equal(t, 0, dsAPI.NumKnownValidators())
+	require.False(t, dsAPI.KnownValidatorsWasUpdated.Load())
+
+	// the housekeeper doesn't publish while another one holds the lock
+	acquired, err := redisCache.AcquireLock(t.Context(), lockNameKnownValidators, "other-housekeeper", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	dsHousekeeper.PublishKnownValidators(common.TestLog, beaconClient, 100)
+	require.Equal(t, 0, dsHousekeeper.NumKnownValidators())
+	err = redisCache.ReleaseLock(t.Context(), lockNameKnownValidators, "other-housekeeper")
+	require.NoError(t, err)
+
+	// the housekeeper queries the beacon node and stores the validators in Redis, from where the API instance loads them
+	dsHousekeeper.PublishKnownValidators(common.TestLog, beaconClient, 100)
+	require.Equal(t, 3, dsHousekeeper.NumKnownValidators())
+	dsAPI.RefreshKnownValidators(common.TestLog, beaconClient, 100)
+	require.Equal(t, 3, dsAPI.NumKnownValidators())
+	require.True(t, dsAPI.KnownValidatorsWasUpdated.Load())
+}
+
+func TestRestoreValidatorRegistrationsInRedis(t *testing.T) {
+	pkOld := fmt.Sprintf("0x%096x", 1)
+	pkNew := fmt.Sprintf("0x%096x", 2)
+	ds := setupTestDatastore(t, &database.MockDB{ValidatorRegistrations: []*database.ValidatorRegistrationEntry{
+		{Pubkey: pkOld, Timestamp: 100},
+		{Pubkey: pkNew, Timestamp: 100},
+	}})
+
+	// a newer timestamp in Redis is kept
+	require.NoError(t, ds.redis.SetValidatorRegistrationTimestamp(common.NewPubkeyHex(pkNew), 200))
+
+	numRegistrations, err := ds.RestoreValidatorRegistrationsInRedis()
+	require.NoError(t, err)
+	require.Equal(t, 2, numRegistrations)
+	for pk, expected := range map[string]uint64{pkOld: 100, pkNew: 200} {
+		timestamp, err := ds.redis.GetValidatorRegistrationTimestamp(common.NewPubkeyHex(pk))
+		require.NoError(t, err)
+		require.Equal(t, expected, timestamp)
+	}
+}
+
+// failingRegistrationsDB fails to save any batch of validator registrations containing the failing pubkey
+type failingRegistrationsDB struct {
+	database.MockDB
+	failingPubkey string
+	numInserts    int
+}
+
+func (db *failingRegistrationsDB) SaveValidatorRegistrations(entries []database.ValidatorRegistrationEntry) error {
+	db.numInserts++
+	for _, entry := range entries {
+		if entry.Pubkey == db.failingPubkey {
+			return errFailingRegistration
+		}
+	}
+	return nil
+}
+
+var errFailingRegistration = errors.New("failing registration")
+
+func TestSaveValidatorRegistrations(t *testing.T) {
+	redisTestServer, err := miniredis.Run()
+	require.NoError(t, err)
+	redisDs, err := NewRedisCache("", redisTestServer.Addr(), "")
+	require.NoError(t, err)
+
+	entries := make([]builderApiV1.SignedValidatorRegistration, 8)
+	for i := range entries {
+		entries[i] = builderApiV1.SignedValidatorRegistration{
+			Message: &builderApiV1.ValidatorRegistration{
+				Pubkey:    phase0.BLSPubKey{byte(i + 1)},
+				Timestamp: time.Unix(100, 0),
+			},
+		}
+	}
+	db := &failingRegistrationsDB{failingPubkey: entries[5].Message.Pubkey.String()}
+	ds, err := NewDatastore(redisDs, nil, db)
+	require.NoError(t, err)
+
+	// the failing registration is isolated by halving the batch, and the others are saved
+	err = ds.SaveValidatorRegistrations(entries)
+	require.ErrorIs(t, err, errFailingRegistration)
+	require.Equal(t, 7, db.numInserts)
+	for i, entry := range entries {
+		timestamp, err := redisDs.GetValidatorRegistrationTimestamp(common.NewPubkeyHex(entry.Message.Pubkey.String()))
+		require.NoError(t, err)
+		if i == 5 {
+			require.Equal(t, uint64(0), timestamp)
+		} else {
+			require.Equal(t, uint64(100), timestamp)
+		}
+	}
+}
@@ -21,12 +21,17 @@ type Memcached struct {
 	keyPrefix string
 }
 
+// keyExecutionPayload returns the key of the execution payload of a given slot+proposerPubkey+blockHash, in the same
+// format as the Redis keys
+func (m *Memcached) keyExecutionPayload(slot uint64, proposerPubKey, blockHash string) string {
+	return fmt.Sprintf("%s/%s:cache-getpayload-response:%d_%s_%s", redisPrefix, m.keyPrefix, slot, proposerPubKey, blockHash)
+}
+
 // SaveExecutionPayload attempts to insert execution engine payload to memcached using composite key of slot,
 // proposer public key, block hash, and cache prefix if specified. Note that writes to the same key value
 // (i.e. same slot, proposer public key, and block hash) will overwrite the existing entry.
 func (m *Memcached) SaveExecutionPayload(slot uint64, proposerPubKey, blockHash string, payload *builderApi.VersionedSubmitBlindedBlockResponse) error {
-	// TODO: standardize key format with redis cache and re-use the same function(s)
-	key := fmt.Sprintf("boost-relay/%s:cache-getpayload-response:%d_%s_%s", m.keyPrefix, slot, proposerPubKey, blockHash)
+	key := m.keyExecutionPayload(slot, proposerPubKey, blockHash)
 
 	bytes, err := json.Marshal(payload)
 	if err != nil {
@@ -40,9 +45,7 @@ func (m *Memcached) SaveExecutionPayload(slot uint64, proposerPubKey, blockHash
 // GetExecutionPayload attempts to fetch execution engine payload from memcached using composite key of slot,
 // proposer public key, block hash, and cache prefix if specified.
 func (m *Memcached) GetExecutionPayload(slot uint64, proposerPubKey, blockHash string) (*builderApi.VersionedSubmitBlindedBlockResponse, error) {
-	// TODO: standardize key format with redis cache and re-use the same function(s)
-	key := fmt.Sprintf("boost-relay/%s:cache-getpayload-response:%d_%s_%s", m.keyPrefix, slot, proposerPubKey, blockHash)
-	item, err := m.client.Get(key)
+	item, err := m.client.Get(m.keyExecutionPayload(slot, proposerPubKey, blockHash))
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,7 +20,11 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/flashbots/go-utils/cli"
 	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/metrics"
+	"github.com/golang/snappy"
 	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	uberatomic "go.uber.org/atomic"
 )
 
 var (
@@ -26,23 +33,72 @@ var (
 
 	expiryBidCache = 45 * time.Second
 
-	RedisConfigFieldPubkey         = "pubkey"
-	RedisStatsFieldLatestSlot      = "latest-slot"
-	RedisStatsFieldValidatorsTotal = "validators-total"
+	// how long the block hash of the first getPayload request for a slot is kept, to detect proposer equivocation
+	expiryGetPayloadBlockHash = 10 * time.Minute
+
+	// how long the delivered getPayload response is kept to answer retries of the proposer
+	expiryGetPayloadResponse = 5 * time.Minute
+
+	// how long invalidated bids are remembered, to reject them in getHeader, getPayload and block submissions
+	expiryInvalidatedBids = 10 * time.Minute
+
+	// execution payloads are stored as snappy compressed SSZ in keys with this version suffix. Keys without it hold
+	// the uncompressed SSZ of earlier relay versions, which is only read as a fallback.
+	payloadKeyVersion = "v2"
+
+	// number of known validators or registration timestamps written to or scanned from Redis at once
+	knownValidatorsBatchSize = 10000
+
+	// number of keys scanned from Redis at once when auditing the keys
+	keyAuditBatchSize = 1000
+
+	// member of the invalidated bids set if all bids of the slot are invalidated
+	invalidatedBidsAllBuilders = "*"
+
+	RedisConfigFieldPubkey          = "pubkey"
+	RedisConfigFieldNextPubkey      = "next-pubkey"       // pubkey which the relay is rotated to, accepted besides the pubkey
+	RedisConfigFieldFeatureFlag     = "feature-flag:"     // prefix, followed by the flag name
+	RedisConfigFieldBuilderIPFilter = "builder-ip-filter" // allow and deny lists of the builder API, as JSON
+	RedisStatsFieldLatestSlot       = "latest-slot"
+	RedisStatsFieldValidatorsTotal  = "validators-total"
+
+	// events of the data stream, published on a pub/sub channel per type
+	DataStreamEventPayloadDelivered = "payload_delivered"
+	DataStreamEventTopBid           = "top_bid"
+
+	// writeLegacyPayloads also stores the payloads as uncompressed SSZ under the legacy keys, which is only needed while
+	// instances of the previous relay version serve getPayload during a rolling upgrade. It is removed in the next
+	// release, together with reading the legacy keys.
+	writeLegacyPayloads = os.Getenv("REDIS_WRITE_LEGACY_PAYLOADS") == "1"
 
 	ErrFailedUpdatingTopBidNoBids            = errors.New("failed to update top bid because no bids were found")
 	ErrAnotherPayloadAlreadyDeliveredForSlot = errors.New("another payload block hash for slot was already delivered")
 	ErrPastSlotAlreadyDelivered              = errors.New("payload for past slot was already delivered")
 
 	// Docs about redis settings: https://redis.io/docs/reference/clients/
-	redisConnectionPoolSize = cli.GetEnvInt("REDIS_CONNECTION_POOL_SIZE", 0) // 0 means use default (10 per CPU)
-	redisMinIdleConnections = cli.GetEnvInt("REDIS_MIN_IDLE_CONNECTIONS", 0) // 0 means use default
-	redisReadTimeoutSec     = cli.GetEnvInt("REDIS_READ_TIMEOUT_SEC", 0)     // 0 means use default (3 sec)
-	redisPoolTimeoutSec     = cli.GetEnvInt("REDIS_POOL_TIMEOUT_SEC", 0)     // 0 means use default (ReadTimeout + 1 sec)
-	redisWriteTimeoutSec    = cli.GetEnvInt("REDIS_WRITE_TIMEOUT_SEC", 0)    // 0 means use default (3 seconds)
+	DefaultRedisConnectionOpts = RedisConnectionOpts{
+		PoolSize:     cli.GetEnvInt("REDIS_CONNECTION_POOL_SIZE", 0),                           // 0 means use default (10 per CPU)
+		MinIdleConns: cli.GetEnvInt("REDIS_MIN_IDLE_CONNECTIONS", 0),                           // 0 means use default
+		DialTimeout:  time.Duration(cli.GetEnvInt("REDIS_DIAL_TIMEOUT_SEC", 0)) * time.Second,  // 0 means use default (5 sec)
+		ReadTimeout:  time.Duration(cli.GetEnvInt("REDIS_READ_TIMEOUT_SEC", 0)) * time.Second,  // 0 means use default (3 sec)
+		PoolTimeout:  time.Duration(cli.GetEnvInt("REDIS_POOL_TIMEOUT_SEC", 0)) * time.Second,  // 0 means use default (ReadTimeout + 1 sec)
+		WriteTimeout: time.Duration(cli.GetEnvInt("REDIS_WRITE_TIMEOUT_SEC", 0)) * time.Second, // 0 means use default (3 seconds)
+	}
 )
 
-func connectRedis(redisURI string) (*redis.Client, error) {
+// RedisConnectionOpts are the connection pool settings of the redis clients, zero values use the go-redis defaults
+type RedisConnectionOpts struct {
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	PoolTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// connectRedis connects to redis. With contextTimeout, the deadlines of the contexts apply to the redis calls, but a
+// connection whose call exceeds its deadline is closed instead of being reused.
+func connectRedis(redisURI string, connOpts RedisConnectionOpts, hook redis.Hook, contextTimeout bool) (*redis.Client, error) {
 	// Handle both URIs and full URLs, assume unencrypted connections
 	if !strings.HasPrefix(redisURI, redisScheme) && !strings.HasPrefix(redisURI, "rediss://") {
 		redisURI = redisScheme + redisURI
@@ -53,27 +109,33 @@ func connectRedis(redisURI string) (*redis.Client, error) {
 		return nil, err
 	}
 
-	if redisConnectionPoolSize > 0 {
-		redisOpts.PoolSize = redisConnectionPoolSize
+	if connOpts.PoolSize > 0 {
+		redisOpts.PoolSize = connOpts.PoolSize
+	}
+	if connOpts.MinIdleConns > 0 {
+		redisOpts.MinIdleConns = connOpts.MinIdleConns
 	}
-	if redisMinIdleConnections > 0 {
-		redisOpts.MinIdleConns = redisMinIdleConnections
+	if connOpts.DialTimeout > 0 {
+		redisOpts.DialTimeout = connOpts.DialTimeout
 	}
-	if redisReadTimeoutSec > 0 {
-		redisOpts.ReadTimeout = time.Duration(redisReadTimeoutSec) * time.Second
+	if connOpts.ReadTimeout > 0 {
+		redisOpts.ReadTimeout = connOpts.ReadTimeout
 	}
-	if redisPoolTimeoutSec > 0 {
-		redisOpts.PoolTimeout = time.Duration(redisPoolTimeoutSec) * time.Second
+	if connOpts.PoolTimeout > 0 {
+		redisOpts.PoolTimeout = connOpts.PoolTimeout
 	}
-	if redisWriteTimeoutSec > 0 {
-		redisOpts.WriteTimeout = time.Duration(redisWriteTimeoutSec) * time.Second
+	if connOpts.WriteTimeout > 0 {
+		redisOpts.WriteTimeout = connOpts.WriteTimeout
 	}
 
+	redisOpts.ContextTimeoutEnabled = contextTimeout
+
 	redisClient := redis.NewClient(redisOpts)
 	if _, err := redisClient.Ping(context.Background()).Result(); err != nil {
 		// unable to connect to redis
 		return nil, err
 	}
+	redisClient.AddHook(hook)
 	return redisClient, nil
 }
 
@@ -81,6 +143,14 @@ type RedisCache struct {
 	client         *redis.Client
 	readonlyClient *redis.Client
 
+	// getHeaderClient reads the bids for getHeader within the deadline of the request. It has its own connection pool,
+	// as connections are closed when the deadline is exceeded, which shouldn't affect the other calls.
+	getHeaderClient *redis.Client
+
+	keyspace string // common prefix of all keys
+
+	hooks []*redisMetricsHook
+
 	// prefixes (keys generated with a function)
 	prefixGetHeaderResponse           string
 	prefixExecPayloadCapella          string
@@ -93,35 +163,68 @@ type RedisCache struct {
 	prefixTopBidValue                 string
 	prefixFloorBid                    string
 	prefixFloorBidValue               string
+	prefixGetPayloadBlockHash         string
+	prefixGetPayloadResponse          string
+	prefixInvalidatedBids             string
+	prefixDataStream                  string
+	prefixLock                        string
 
 	// keys
 	keyValidatorRegistrationTimestamp string
+	keyProposerPreferences            string
 
 	keyRelayConfig        string
 	keyStats              string
 	keyProposerDuties     string
 	keyBlockBuilderStatus string
+	keyBuilderScores      string
 	keyLastSlotDelivered  string
 	keyLastHashDelivered  string
+	keyCircuitBreaker     string
+	keyDataAPIKeys        string
+	keyKnownValidators    string
+
+	// pub/sub channels of the in-memory top bid caches, which are only published to if enabled
+	keyTopBidUpdates     string
+	keyBidInvalidations  string
+	publishTopBidUpdates uberatomic.Bool
 }
 
 func NewRedisCache(prefix, redisURI, readonlyURI string) (*RedisCache, error) {
-	client, err := connectRedis(redisURI)
+	return NewRedisCacheWithOpts(prefix, redisURI, readonlyURI, DefaultRedisConnectionOpts)
+}
+
+// NewRedisCacheWithOpts connects to redis with the given connection pool settings, instead of the ones from the environment
+func NewRedisCacheWithOpts(prefix, redisURI, readonlyURI string, connOpts RedisConnectionOpts) (*RedisCache, error) {
+	if err := metrics.Setup(context.Background()); err != nil {
+		return nil, err
+	}
+
+	hooks := []*redisMetricsHook{newRedisMetricsHook("main"), newRedisMetricsHook("getheader")}
+	client, err := connectRedis(redisURI, connOpts, hooks[0], false)
+	if err != nil {
+		return nil, err
+	}
+	getHeaderClient, err := connectRedis(redisURI, connOpts, hooks[1], true)
 	if err != nil {
 		return nil, err
 	}
 
 	roClient := client
 	if readonlyURI != "" {
-		roClient, err = connectRedis(readonlyURI)
+		hooks = append(hooks, newRedisMetricsHook("readonly"))
+		roClient, err = connectRedis(readonlyURI, connOpts, hooks[2], false)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	return &RedisCache{
-		client:         client,
-		readonlyClient: roClient,
+		client:          client,
+		readonlyClient:  roClient,
+		getHeaderClient: getHeaderClient,
+		keyspace:        fmt.Sprintf("%s/%s", redisPrefix, prefix),
+		hooks:           hooks,
 
 		prefixGetHeaderResponse:      fmt.Sprintf("%s/%s:cache-gethead-response", redisPrefix, prefix),
 		prefixExecPayloadCapella:     fmt.Sprintf("%s/%s:cache-execpayload-capella", redisPrefix, prefix),
@@ -135,41 +238,79 @@ func NewRedisCache(prefix, redisURI, readonlyURI string) (*RedisCache, error) {
 		prefixTopBidValue:                 fmt.Sprintf("%s/%s:top-bid-value", redisPrefix, prefix),                  // prefix:slot_parentHash_proposerPubkey
 		prefixFloorBid:                    fmt.Sprintf("%s/%s:bid-floor", redisPrefix, prefix),                      // prefix:slot_parentHash_proposerPubkey
 		prefixFloorBidValue:               fmt.Sprintf("%s/%s:bid-floor-value", redisPrefix, prefix),                // prefix:slot_parentHash_proposerPubkey
+		prefixGetPayloadBlockHash:         fmt.Sprintf("%s/%s:getpayload-block-hash", redisPrefix, prefix),          // prefix:slot_proposerPubkey
+		prefixGetPayloadResponse:          fmt.Sprintf("%s/%s:getpayload-response", redisPrefix, prefix),            // prefix:slot_proposerPubkey_blockHash
+		prefixInvalidatedBids:             fmt.Sprintf("%s/%s:invalidated-bids", redisPrefix, prefix),               // set for slot with builderPubkeys and blockHashes as members
+		prefixDataStream:                  fmt.Sprintf("%s/%s:data-stream", redisPrefix, prefix),                    // pub/sub channel prefix:eventType
+		prefixLock:                        fmt.Sprintf("%s/%s:lock", redisPrefix, prefix),                           // prefix:name with the owner as value
 
 		keyValidatorRegistrationTimestamp: fmt.Sprintf("%s/%s:validator-registration-timestamp", redisPrefix, prefix),
+		keyProposerPreferences:            fmt.Sprintf("%s/%s:proposer-preferences", redisPrefix, prefix), // hashmap with proposerPubkey as field
 		keyRelayConfig:                    fmt.Sprintf("%s/%s:relay-config", redisPrefix, prefix),
 
 		keyStats:              fmt.Sprintf("%s/%s:stats", redisPrefix, prefix),
 		keyProposerDuties:     fmt.Sprintf("%s/%s:proposer-duties", redisPrefix, prefix),
 		keyBlockBuilderStatus: fmt.Sprintf("%s/%s:block-builder-status", redisPrefix, prefix),
+		keyBuilderScores:      fmt.Sprintf("%s/%s:builder-scores", redisPrefix, prefix),
 		keyLastSlotDelivered:  fmt.Sprintf("%s/%s:last-slot-delivered", redisPrefix, prefix),
 		keyLastHashDelivered:  fmt.Sprintf("%s/%s:last-hash-delivered", redisPrefix, prefix),
+		keyCircuitBreaker:     fmt.Sprintf("%s/%s:circuit-breaker", redisPrefix, prefix),
+		keyDataAPIKeys:        fmt.Sprintf("%s/%s:data-api-keys", redisPrefix, prefix),     // hashmap with the key hash as field and the label as value
+		keyKnownValidators:    fmt.Sprintf("%s/%s:known-validators", redisPrefix, prefix),  // hashmap with the validator index as field and the pubkey as value
+		keyTopBidUpdates:      fmt.Sprintf("%s/%s:top-bid-updates", redisPrefix, prefix),   // pub/sub channel, "slot getHeaderResponseKey"
+		keyBidInvalidations:   fmt.Sprintf("%s/%s:bid-invalidations", redisPrefix, prefix), // pub/sub channel, "slot id..."
 	}, nil
 }
 
+// EnableTopBidUpdates publishes the top bid updates and bid invalidations for the in-memory top bid caches. It has to be
+// enabled on all instances which update bids, if any instance serves getHeader from memory.
+func (r *RedisCache) EnableTopBidUpdates() {
+	r.publishTopBidUpdates.Store(true)
+}
+
+// SetLog sets the logger for slow Redis commands
+func (r *RedisCache) SetLog(log *logrus.Entry) {
+	for _, hook := range r.hooks {
+		hook.log.Store(log)
+	}
+}
+
 func (r *RedisCache) keyCacheGetHeaderResponse(slot uint64, parentHash, proposerPubkey string) string {
 	return fmt.Sprintf("%s:%d_%s_%s", r.prefixGetHeaderResponse, slot, parentHash, proposerPubkey)
 }
 
 func (r *RedisCache) keyExecPayloadCapella(slot uint64, proposerPubkey, blockHash string) string {
-	return fmt.Sprintf("%s:%d_%s_%s", r.prefixExecPayloadCapella, slot, proposerPubkey, blockHash)
+	return fmt.Sprintf("%s:%d_%s_%s:%s", r.prefixExecPayloadCapella, slot, proposerPubkey, blockHash, payloadKeyVersion)
 }
 
 func (r *RedisCache) keyPayloadContentsDeneb(slot uint64, proposerPubkey, blockHash string) string {
-	return fmt.Sprintf("%s:%d_%s_%s", r.prefixPayloadContentsDeneb, slot, proposerPubkey, blockHash)
+	return fmt.Sprintf("%s:%d_%s_%s:%s", r.prefixPayloadContentsDeneb, slot, proposerPubkey, blockHash, payloadKeyVersion)
 }
 
 func (r *RedisCache) keyPayloadContentsElectra(slot uint64, proposerPubkey, blockHash string) string {
-	return fmt.Sprintf("%s:%d_%s_%s", r.prefixPayloadContentsElectra, slot, proposerPubkey, blockHash)
+	return fmt.Sprintf("%s:%d_%s_%s:%s", r.prefixPayloadContentsElectra, slot, proposerPubkey, blockHash, payloadKeyVersion)
+}
+
+// legacyPayloadKey returns the key of an uncompressed SSZ payload of earlier relay versions
+func legacyPayloadKey(key string) string {
+	return strings.TrimSuffix(key, ":"+payloadKeyVersion)
 }
 
 func (r *RedisCache) keyCacheBidTrace(slot uint64, proposerPubkey, blockHash string) string {
 	return fmt.Sprintf("%s:%d_%s_%s", r.prefixBidTrace, slot, proposerPubkey, blockHash)
 }
 
-// keyLatestBidByBuilder returns the key for the getHeader response the latest bid by a specific builder
-func (r *RedisCache) keyLatestBidByBuilder(slot uint64, parentHash, proposerPubkey, builderPubkey string) string {
-	return fmt.Sprintf("%s:%d_%s_%s/%s", r.prefixBlockBuilderLatestBids, slot, parentHash, proposerPubkey, builderPubkey)
+func (r *RedisCache) keyDataStream(eventType string) string {
+	return fmt.Sprintf("%s:%s", r.prefixDataStream, eventType)
+}
+
+func (r *RedisCache) keyLock(name string) string {
+	return fmt.Sprintf("%s:%s", r.prefixLock, name)
+}
+
+// keyBlockBuilderLatestBids returns the hashmap key for the getHeader response of the latest bid by a specific builder
+func (r *RedisCache) keyBlockBuilderLatestBids(slot uint64, parentHash, proposerPubkey string) string {
+	return fmt.Sprintf("%s:%d_%s_%s", r.prefixBlockBuilderLatestBids, slot, parentHash, proposerPubkey)
 }
 
 // keyBlockBuilderLatestBidValue returns the hashmap key for the value of the latest bid by a specific builder
@@ -197,6 +338,21 @@ func (r *RedisCache) keyFloorBidValue(slot uint64, parentHash, proposerPubkey st
 	return fmt.Sprintf("%s:%d_%s_%s", r.prefixFloorBidValue, slot, parentHash, proposerPubkey)
 }
 
+// keyGetPayloadBlockHash returns the key for the block hash of the first getPayload request of a given slot+proposerPubkey
+func (r *RedisCache) keyGetPayloadBlockHash(slot uint64, proposerPubkey string) string {
+	return fmt.Sprintf("%s:%d_%s", r.prefixGetPayloadBlockHash, slot, proposerPubkey)
+}
+
+// keyGetPayloadResponse returns the key for the delivered getPayload response of a given slot+proposerPubkey+blockHash
+func (r *RedisCache) keyGetPayloadResponse(slot uint64, proposerPubkey, blockHash string) string {
+	return fmt.Sprintf("%s:%d_%s_%s", r.prefixGetPayloadResponse, slot, proposerPubkey, blockHash)
+}
+
+// keyInvalidatedBids returns the key for the set of invalidated builders and block hashes of a given slot
+func (r *RedisCache) keyInvalidatedBids(slot uint64) string {
+	return fmt.Sprintf("%s:%d", r.prefixInvalidatedBids, slot)
+}
+
 func (r *RedisCache) GetObj(key string, obj any) (err error) {
 	value, err := r.client.Get(context.Background(), key).Result()
 	if err != nil {
@@ -262,6 +418,146 @@ func (r *RedisCache) SetValidatorRegistrationTimestamp(proposerPubkey common.Pub
 	return r.client.HSet(context.Background(), r.keyValidatorRegistrationTimestamp, proposerPubkey.String(), timestamp).Err()
 }
 
+// setTimestampsIfNewerScript sets the hash fields in ARGV (pairs of field and timestamp) only if they don't have an
+// equal or newer timestamp, atomically so that concurrent updates can't overwrite newer timestamps
+var setTimestampsIfNewerScript = redis.NewScript(`
+for i = 1, #ARGV, 2 do
+	local known = tonumber(redis.call('HGET', KEYS[1], ARGV[i]))
+	if not known or known < tonumber(ARGV[i + 1]) then
+		redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 1])
+	end
+end
+return 0
+`)
+
+// SetValidatorRegistrationTimestampsIfNewer saves the registration timestamps of many validators in one round-trip,
+// skipping validators which already have an equal or newer timestamp
+func (r *RedisCache) SetValidatorRegistrationTimestampsIfNewer(timestamps map[common.PubkeyHex]uint64) error {
+	if len(timestamps) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, 2*len(timestamps))
+	for pubkey, timestamp := range timestamps {
+		args = append(args, strings.ToLower(pubkey.String()), timestamp)
+	}
+	return setTimestampsIfNewerScript.Run(context.Background(), r.client, []string{r.keyValidatorRegistrationTimestamp}, args...).Err()
+}
+
+// StreamValidatorRegistrationTimestamps calls fn for the registration timestamps of all validators, and returns the
+// number of validators
+func (r *RedisCache) StreamValidatorRegistrationTimestamps(ctx context.Context, fn func(pubkey common.PubkeyHex, timestamp uint64)) (numValidators uint64, err error) {
+	iter := r.client.HScan(ctx, r.keyValidatorRegistrationTimestamp, 0, "", int64(knownValidatorsBatchSize)).Iterator()
+	for iter.Next(ctx) {
+		field := iter.Val()
+		if !iter.Next(ctx) {
+			break
+		}
+		timestamp, err := strconv.ParseUint(iter.Val(), 10, 64)
+		if err != nil {
+			return numValidators, err
+		}
+		fn(common.NewPubkeyHex(field), timestamp)
+		numValidators++
+	}
+	return numValidators, iter.Err()
+}
+
+// SetProposerPreferences saves the signed preferences of a proposer, replacing previous ones
+func (r *RedisCache) SetProposerPreferences(preferences *common.SignedProposerPreferences) error {
+	marshalledValue, err := json.Marshal(preferences)
+	if err != nil {
+		return err
+	}
+	pubkey := strings.ToLower(preferences.Message.Pubkey.String())
+	return r.client.HSet(context.Background(), r.keyProposerPreferences, pubkey, marshalledValue).Err()
+}
+
+// GetProposerPreferences returns the signed preferences of the given proposers by (lowercase) pubkey, proposers without preferences are omitted
+func (r *RedisCache) GetProposerPreferences(proposerPubkeys ...string) (map[string]*common.SignedProposerPreferences, error) {
+	preferences := make(map[string]*common.SignedProposerPreferences)
+	if len(proposerPubkeys) == 0 {
+		return preferences, nil
+	}
+
+	fields := make([]string, len(proposerPubkeys))
+	for i, pubkey := range proposerPubkeys {
+		fields[i] = strings.ToLower(pubkey)
+	}
+	values, err := r.client.HMGet(context.Background(), r.keyProposerPreferences, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		valueStr, ok := value.(string)
+		if !ok {
+			continue
+		}
+		entry := new(common.SignedProposerPreferences)
+		if err := json.Unmarshal([]byte(valueStr), entry); err != nil {
+			return nil, err
+		}
+		preferences[fields[i]] = entry
+	}
+	return preferences, nil
+}
+
+// DataStreamEvent is an event of the data stream, with the JSON encoded data
+type DataStreamEvent struct {
+	Type string
+	Data []byte
+}
+
+// PublishDataStreamEvent sends an event to the data stream subscribers of all instances
+func (r *RedisCache) PublishDataStreamEvent(ctx context.Context, eventType string, data any) error {
+	marshalledValue, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return r.client.Publish(ctx, r.keyDataStream(eventType), marshalledValue).Err()
+}
+
+// SubscribeDataStream returns the data stream events of the given types. The channel is closed when the context is done.
+func (r *RedisCache) SubscribeDataStream(ctx context.Context, eventTypes ...string) (<-chan DataStreamEvent, error) {
+	channels := make([]string, len(eventTypes))
+	eventTypeByChannel := make(map[string]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		channels[i] = r.keyDataStream(eventType)
+		eventTypeByChannel[channels[i]] = eventType
+	}
+
+	pubsub := r.client.Subscribe(ctx, channels...)
+	// wait for the subscription to be confirmed, events published before are not received
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+
+	events := make(chan DataStreamEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case events <- DataStreamEvent{Type: eventTypeByChannel[msg.Channel], Data: []byte(msg.Payload)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
 func (r *RedisCache) CheckAndSetLastSlotAndHashDelivered(slot uint64, hash string) (err error) {
 	// More details about Redis optimistic locking:
 	// - https://redis.uptrace.dev/guide/go-redis-pipelines.html#transactions
@@ -301,6 +597,34 @@ func (r *RedisCache) CheckAndSetLastSlotAndHashDelivered(slot uint64, hash strin
 	return r.client.Watch(context.Background(), txf, r.keyLastSlotDelivered, r.keyLastHashDelivered)
 }
 
+// LockGetPayloadBlockHash atomically records the block hash of the first getPayload request for a slot and proposer,
+// and returns the recorded block hash. If it differs from the given block hash, the proposer is equivocating.
+func (r *RedisCache) LockGetPayloadBlockHash(slot uint64, proposerPubkey, blockHash string) (lockedBlockHash string, err error) {
+	key := r.keyGetPayloadBlockHash(slot, proposerPubkey)
+	isFirst, err := r.client.SetNX(context.Background(), key, blockHash, expiryGetPayloadBlockHash).Result()
+	if err != nil {
+		return "", err
+	}
+	if isFirst {
+		return blockHash, nil
+	}
+	return r.client.Get(context.Background(), key).Result()
+}
+
+// SaveDeliveredGetPayloadResponse stores the encoded getPayload response which was delivered to the proposer
+func (r *RedisCache) SaveDeliveredGetPayloadResponse(slot uint64, proposerPubkey, blockHash string, response []byte) error {
+	return r.client.Set(context.Background(), r.keyGetPayloadResponse(slot, proposerPubkey, blockHash), response, expiryGetPayloadResponse).Err()
+}
+
+// GetDeliveredGetPayloadResponse returns the encoded getPayload response which was delivered to the proposer, or nil if there is none
+func (r *RedisCache) GetDeliveredGetPayloadResponse(slot uint64, proposerPubkey, blockHash string) ([]byte, error) {
+	response, err := r.client.Get(context.Background(), r.keyGetPayloadResponse(slot, proposerPubkey, blockHash)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return response, err
+}
+
 func (r *RedisCache) GetLastSlotDelivered(ctx context.Context, pipeliner redis.Pipeliner) (slot uint64, err error) {
 	c := pipeliner.Get(ctx, r.keyLastSlotDelivered)
 	_, err = pipeliner.Exec(ctx)
@@ -314,6 +638,93 @@ func (r *RedisCache) GetLastHashDelivered() (hash string, err error) {
 	return r.client.Get(context.Background(), r.keyLastHashDelivered).Result()
 }
 
+func (r *RedisCache) SetCircuitBreakerState(state *common.CircuitBreakerState) (err error) {
+	return r.SetObj(r.keyCircuitBreaker, state, 0)
+}
+
+// GetCircuitBreakerState returns the circuit breaker state, or an empty state if it was never set
+func (r *RedisCache) GetCircuitBreakerState() (state *common.CircuitBreakerState, err error) {
+	state = new(common.CircuitBreakerState)
+	err = r.GetObj(r.keyCircuitBreaker, state)
+	if errors.Is(err, redis.Nil) {
+		return state, nil
+	}
+	return state, err
+}
+
+// circuitBreakerScript atomically updates the circuit breaker state, so that the checks of the delivered payloads (also
+// of several housekeepers) and the resets by an operator can't overwrite each other.
+//
+// KEYS: circuit breaker state
+// ARGV: "check" with the slot, "1" if the payload landed and the number of missed slots which trips the breaker; or
+// "reset"
+//
+// Returns the new state, or nil if the slot was already checked, and "1" if the breaker was tripped before.
+var circuitBreakerScript = redis.NewScript(`
+local encoded = redis.call('GET', KEYS[1])
+local state = {last_checked_slot = '0', num_missed_slots = '0', is_tripped = false}
+if encoded then
+	state = cjson.decode(encoded)
+end
+local wasTripped = state.is_tripped and '1' or '0'
+
+if ARGV[1] == 'check' then
+	if tonumber(state.last_checked_slot) >= tonumber(ARGV[2]) then
+		return {false, wasTripped}
+	end
+	state.last_checked_slot = ARGV[2]
+	if ARGV[3] == '1' then
+		state.num_missed_slots = '0'
+	else
+		state.num_missed_slots = tostring(tonumber(state.num_missed_slots) + 1)
+		if tonumber(state.num_missed_slots) >= tonumber(ARGV[4]) then
+			state.is_tripped = true
+		end
+	end
+else
+	state.num_missed_slots = '0'
+	state.is_tripped = false
+end
+
+encoded = cjson.encode(state)
+redis.call('SET', KEYS[1], encoded)
+return {encoded, wasTripped}
+`)
+
+// runCircuitBreakerScript returns the new state, nil if it wasn't updated, and whether the breaker was tripped before
+func (r *RedisCache) runCircuitBreakerScript(args ...any) (state *common.CircuitBreakerState, wasTripped bool, err error) {
+	res, err := circuitBreakerScript.Run(context.Background(), r.client, []string{r.keyCircuitBreaker}, args...).Slice()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(res) != 2 {
+		return nil, false, fmt.Errorf("unexpected circuit breaker script result: %v", res) //nolint:goerr113
+	}
+	wasTripped = res[1] == "1"
+	encoded, ok := res[0].(string)
+	if !ok {
+		return nil, wasTripped, nil
+	}
+	state = new(common.CircuitBreakerState)
+	err = json.Unmarshal([]byte(encoded), state)
+	return state, wasTripped, err
+}
+
+// RecordCircuitBreakerCheck records whether the delivered payload of the slot landed on chain, and trips the circuit
+// breaker once maxMissedSlots payloads in a row did not. The new state is nil if the slot was already checked.
+func (r *RedisCache) RecordCircuitBreakerCheck(slot uint64, landed bool, maxMissedSlots uint64) (state *common.CircuitBreakerState, wasTripped bool, err error) {
+	landedArg := "0"
+	if landed {
+		landedArg = "1"
+	}
+	return r.runCircuitBreakerScript("check", slot, landedArg, maxMissedSlots)
+}
+
+// ResetCircuitBreaker re-enables serving bids, and returns the new state and whether the breaker was tripped before
+func (r *RedisCache) ResetCircuitBreaker() (state *common.CircuitBreakerState, wasTripped bool, err error) {
+	return r.runCircuitBreakerScript("reset")
+}
+
 func (r *RedisCache) SetStats(field string, value any) (err error) {
 	return r.client.HSet(context.Background(), r.keyStats, field, value).Err()
 }
@@ -346,6 +757,271 @@ func (r *RedisCache) GetProposerDuties() (proposerDuties []common.BuilderGetVali
 	return proposerDuties, err
 }
 
+// AcquireLock takes the lock with the given name for the owner, unless another owner holds it. The lock is released
+// automatically after the ttl, in case the owner doesn't release it.
+func (r *RedisCache) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (acquired bool, err error) {
+	return r.client.SetNX(ctx, r.keyLock(name), owner, ttl).Result()
+}
+
+// IsLocked returns whether any owner holds the lock with the given name
+func (r *RedisCache) IsLocked(ctx context.Context, name string) (bool, error) {
+	n, err := r.client.Exists(ctx, r.keyLock(name)).Result()
+	return n > 0, err
+}
+
+// releaseLockScript deletes a lock only if it is still held by the owner, and not by another owner after it expired
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// ReleaseLock releases the lock with the given name, if it is held by the owner
+func (r *RedisCache) ReleaseLock(ctx context.Context, name, owner string) error {
+	return releaseLockScript.Run(ctx, r.client, []string{r.keyLock(name)}, owner).Err()
+}
+
+// SetKnownValidators replaces the known validators shared between API instances. They are written to a temporary
+// key in batches, and then renamed, so readers never see a partial set.
+func (r *RedisCache) SetKnownValidators(ctx context.Context, validators map[uint64]common.PubkeyHex) error {
+	if len(validators) == 0 {
+		return r.client.Del(ctx, r.keyKnownValidators).Err()
+	}
+
+	keyTmp := r.keyKnownValidators + ":tmp"
+	err := r.client.Del(ctx, keyTmp).Err()
+	if err != nil {
+		return err
+	}
+	err = r.hsetKnownValidators(ctx, keyTmp, validators)
+	if err != nil {
+		return err
+	}
+	return r.client.Rename(ctx, keyTmp, r.keyKnownValidators).Err()
+}
+
+// AddKnownValidators adds validators to the known validators shared between API instances
+func (r *RedisCache) AddKnownValidators(ctx context.Context, validators map[uint64]common.PubkeyHex) error {
+	return r.hsetKnownValidators(ctx, r.keyKnownValidators, validators)
+}
+
+func (r *RedisCache) hsetKnownValidators(ctx context.Context, key string, validators map[uint64]common.PubkeyHex) error {
+	pipe := r.client.Pipeline()
+	numQueued := 0
+	for index, pubkey := range validators {
+		pipe.HSet(ctx, key, strconv.FormatUint(index, 10), pubkey.String())
+		numQueued++
+		if numQueued == knownValidatorsBatchSize {
+			if _, err := pipe.Exec(ctx); err != nil {
+				return err
+			}
+			numQueued = 0
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// StreamKnownValidators calls fn for all known validators shared between API instances (or only those with the given
+// indices), and returns the number of validators
+func (r *RedisCache) StreamKnownValidators(ctx context.Context, indices []uint64, fn func(index uint64, pubkey common.PubkeyHex)) (numValidators uint64, err error) {
+	if indices != nil {
+		fields := make([]string, len(indices))
+		for i, index := range indices {
+			fields[i] = strconv.FormatUint(index, 10)
+		}
+		values, err := r.client.HMGet(ctx, r.keyKnownValidators, fields...).Result()
+		if err != nil {
+			return 0, err
+		}
+		for i, value := range values {
+			if pubkey, ok := value.(string); ok {
+				fn(indices[i], common.NewPubkeyHex(pubkey))
+				numValidators++
+			}
+		}
+		return numValidators, nil
+	}
+
+	iter := r.client.HScan(ctx, r.keyKnownValidators, 0, "", int64(knownValidatorsBatchSize)).Iterator()
+	for iter.Next(ctx) {
+		field := iter.Val()
+		if !iter.Next(ctx) {
+			break
+		}
+		index, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return numValidators, err
+		}
+		fn(index, common.NewPubkeyHex(iter.Val()))
+		numValidators++
+	}
+	return numValidators, iter.Err()
+}
+
+// RedisKeyStats are the number and memory usage of the keys with a prefix, as found by AuditKeys
+type RedisKeyStats struct {
+	Prefix       string
+	NumKeys      int64
+	MemoryBytes  int64
+	NumNoExpiry  int64 // keys without an expiration
+	NumExpirySet int64 // slot specific keys without an expiration, which were set to expire
+}
+
+// slotKeyExpiries returns the expiration of the slot specific keys by prefix
+func (r *RedisCache) slotKeyExpiries() map[string]time.Duration {
+	return map[string]time.Duration{
+		r.prefixGetHeaderResponse:           expiryBidCache,
+		r.prefixExecPayloadCapella:          expiryBidCache,
+		r.prefixPayloadContentsDeneb:        expiryBidCache,
+		r.prefixPayloadContentsElectra:      expiryBidCache,
+		r.prefixBidTrace:                    expiryBidCache,
+		r.prefixBlockBuilderLatestBids:      expiryBidCache,
+		r.prefixBlockBuilderLatestBidsValue: expiryBidCache,
+		r.prefixBlockBuilderLatestBidsTime:  expiryBidCache,
+		r.prefixTopBidValue:                 expiryBidCache,
+		r.prefixFloorBid:                    expiryBidCache,
+		r.prefixFloorBidValue:               expiryBidCache,
+		r.prefixGetPayloadBlockHash:         expiryGetPayloadBlockHash,
+		r.prefixGetPayloadResponse:          expiryGetPayloadResponse,
+		r.prefixInvalidatedBids:             expiryInvalidatedBids,
+	}
+}
+
+// AuditKeys scans all keys of the relay and returns their number and memory usage by prefix, sorted by prefix. Slot
+// specific keys without an expiration (i.e. written by an older relay version) are set to expire, so they don't pile up.
+func (r *RedisCache) AuditKeys(ctx context.Context) ([]*RedisKeyStats, error) {
+	statsByPrefix := make(map[string]*RedisKeyStats)
+	expiries := r.slotKeyExpiries()
+
+	keys := make([]string, 0, keyAuditBatchSize)
+	iter := r.client.Scan(ctx, 0, r.keyspace+":*", int64(keyAuditBatchSize)).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == keyAuditBatchSize {
+			if err := r.auditKeysBatch(ctx, keys, expiries, statsByPrefix); err != nil {
+				return nil, err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	if err := r.auditKeysBatch(ctx, keys, expiries, statsByPrefix); err != nil {
+		return nil, err
+	}
+
+	stats := make([]*RedisKeyStats, 0, len(statsByPrefix))
+	for _, s := range statsByPrefix {
+		stats = append(stats, s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Prefix < stats[j].Prefix
+	})
+	return stats, nil
+}
+
+func (r *RedisCache) auditKeysBatch(ctx context.Context, keys []string, expiries map[string]time.Duration, statsByPrefix map[string]*RedisKeyStats) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	memoryCmds := make([]*redis.IntCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	for i, key := range keys {
+		memoryCmds[i] = pipe.MemoryUsage(ctx, key)
+		ttlCmds[i] = pipe.TTL(ctx, key)
+	}
+	// keys can expire between the scan and the pipeline, which fails their commands with redis.Nil
+	_, err := pipe.Exec(ctx)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	expirePipe := r.client.Pipeline()
+	for i, key := range keys {
+		memoryBytes, err := memoryCmds[i].Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		name, _, _ := strings.Cut(strings.TrimPrefix(key, r.keyspace+":"), ":")
+		stats, found := statsByPrefix[name]
+		if !found {
+			stats = &RedisKeyStats{Prefix: name}
+			statsByPrefix[name] = stats
+		}
+		stats.NumKeys++
+		stats.MemoryBytes += memoryBytes
+
+		// TTL is -1 for keys without an expiration
+		if ttlCmds[i].Val() != -1 {
+			continue
+		}
+		stats.NumNoExpiry++
+		if expiry, isSlotKey := expiries[r.keyspace+":"+name]; isSlotKey {
+			expirePipe.Expire(ctx, key, expiry)
+			stats.NumExpirySet++
+		}
+	}
+	if expirePipe.Len() == 0 {
+		return nil
+	}
+	_, err = expirePipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisCache) SetBuilderScores(scores map[string]*common.BuilderScore) (err error) {
+	return r.SetObj(r.keyBuilderScores, scores, 0)
+}
+
+// GetBuilderScores returns the builder scores by builder pubkey, or an empty map if they were not computed yet
+func (r *RedisCache) GetBuilderScores() (scores map[string]*common.BuilderScore, err error) {
+	scores = make(map[string]*common.BuilderScore)
+	err = r.GetObj(r.keyBuilderScores, &scores)
+	if errors.Is(err, redis.Nil) {
+		return scores, nil
+	}
+	return scores, err
+}
+
+// SetBlockBuilderStatus publishes a builder status which overrides the status from the database on all API instances
+func (r *RedisCache) SetBlockBuilderStatus(builderPubkey string, status common.BuilderStatus) (err error) {
+	marshalledValue, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(context.Background(), r.keyBlockBuilderStatus, builderPubkey, marshalledValue).Err()
+}
+
+// GetBlockBuilderStatuses returns all published builder statuses by builder pubkey
+func (r *RedisCache) GetBlockBuilderStatuses() (statuses map[string]common.BuilderStatus, err error) {
+	res, err := r.client.HGetAll(context.Background(), r.keyBlockBuilderStatus).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses = make(map[string]common.BuilderStatus, len(res))
+	for builderPubkey, value := range res {
+		status := common.BuilderStatus{} //nolint:exhaustruct
+		err = json.Unmarshal([]byte(value), &status)
+		if err != nil {
+			return nil, err
+		}
+		statuses[builderPubkey] = status
+	}
+	return statuses, nil
+}
+
+func (r *RedisCache) DelBlockBuilderStatus(builderPubkey string) (err error) {
+	return r.client.HDel(context.Background(), r.keyBlockBuilderStatus, builderPubkey).Err()
+}
+
 func (r *RedisCache) SetRelayConfig(field, value string) (err error) {
 	return r.client.HSet(context.Background(), r.keyRelayConfig, field, value).Err()
 }
@@ -358,14 +1034,79 @@ func (r *RedisCache) GetRelayConfig(field string) (string, error) {
 	return res, err
 }
 
-func (r *RedisCache) GetBestBid(slot uint64, parentHash, proposerPubkey string) (*builderSpec.VersionedSignedBuilderBid, error) {
+func (r *RedisCache) DelRelayConfig(field string) (err error) {
+	return r.client.HDel(context.Background(), r.keyRelayConfig, field).Err()
+}
+
+// CompleteRelayPubkeyRotation atomically replaces the relay pubkey with the next pubkey, and clears the next pubkey
+func (r *RedisCache) CompleteRelayPubkeyRotation(nextPubkey string) (err error) {
+	_, err = r.client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.HSet(context.Background(), r.keyRelayConfig, RedisConfigFieldPubkey, nextPubkey)
+		pipe.HDel(context.Background(), r.keyRelayConfig, RedisConfigFieldNextPubkey)
+		return nil
+	})
+	return err
+}
+
+// SetFeatureFlag overrides the value of a feature flag in the relay config
+func (r *RedisCache) SetFeatureFlag(name string, enabled bool) (err error) {
+	return r.SetRelayConfig(RedisConfigFieldFeatureFlag+name, strconv.FormatBool(enabled))
+}
+
+// DelFeatureFlag removes the override of a feature flag from the relay config
+func (r *RedisCache) DelFeatureFlag(name string) (err error) {
+	return r.client.HDel(context.Background(), r.keyRelayConfig, RedisConfigFieldFeatureFlag+name).Err()
+}
+
+// GetFeatureFlags returns all feature flag overrides in the relay config, by flag name
+func (r *RedisCache) GetFeatureFlags() (map[string]bool, error) {
+	res, err := r.client.HGetAll(context.Background(), r.keyRelayConfig).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := make(map[string]bool)
+	for field, value := range res {
+		name, found := strings.CutPrefix(field, RedisConfigFieldFeatureFlag)
+		if !found {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for feature flag %s: %w", value, name, err)
+		}
+		flags[name] = enabled
+	}
+	return flags, nil
+}
+
+// SetDataAPIKey adds a data API key by its hash, with a label to identify its owner
+func (r *RedisCache) SetDataAPIKey(keyHash, label string) error {
+	return r.client.HSet(context.Background(), r.keyDataAPIKeys, keyHash, label).Err()
+}
+
+// DelDataAPIKey removes a data API key, and returns false if it didn't exist
+func (r *RedisCache) DelDataAPIKey(keyHash string) (bool, error) {
+	numDeleted, err := r.client.HDel(context.Background(), r.keyDataAPIKeys, keyHash).Result()
+	return numDeleted > 0, err
+}
+
+// GetDataAPIKeys returns the labels of all data API keys by key hash
+func (r *RedisCache) GetDataAPIKeys() (map[string]string, error) {
+	return r.client.HGetAll(context.Background(), r.keyDataAPIKeys).Result()
+}
+
+// GetBestBid returns the bid served by getHeader, within the deadline of the context
+func (r *RedisCache) GetBestBid(ctx context.Context, slot uint64, parentHash, proposerPubkey string) (*builderSpec.VersionedSignedBuilderBid, error) {
 	key := r.keyCacheGetHeaderResponse(slot, parentHash, proposerPubkey)
-	resp := new(builderSpec.VersionedSignedBuilderBid)
-	err := r.GetObj(key, resp)
+	value, err := r.getHeaderClient.Get(ctx, key).Result()
 	if errors.Is(err, redis.Nil) {
 		return nil, nil
+	} else if err != nil {
+		return nil, err
 	}
-	return resp, err
+	resp := new(builderSpec.VersionedSignedBuilderBid)
+	return resp, json.Unmarshal([]byte(value), resp)
 }
 
 func (r *RedisCache) GetPayloadContents(slot uint64, proposerPubkey, blockHash string) (*builderApi.VersionedSubmitBlindedBlockResponse, error) {
@@ -379,25 +1120,47 @@ func (r *RedisCache) GetPayloadContents(slot uint64, proposerPubkey, blockHash s
 	return resp, err
 }
 
+// getPayloadSSZ returns the decompressed SSZ of a payload, or the uncompressed SSZ of the legacy key if the payload was
+// stored by an earlier relay version. The legacy key is only read if the payload isn't found, as both keys hold the
+// payload while writeLegacyPayloads is set, and reading them together would transfer it twice.
+func (r *RedisCache) getPayloadSSZ(ctx context.Context, key string) ([]byte, error) {
+	val, err := r.client.Get(ctx, key).Bytes()
+	if err == nil {
+		return snappy.Decode(nil, val)
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	return r.client.Get(ctx, legacyPayloadKey(key)).Bytes()
+}
+
+// savePayloadSSZ saves the snappy compressed SSZ of a payload, and with writeLegacyPayloads also the uncompressed SSZ
+// under the legacy key, so that instances of the previous relay version can still serve the payload
+func (r *RedisCache) savePayloadSSZ(ctx context.Context, pipeliner redis.Pipeliner, key string, payloadSSZ []byte) error {
+	err := pipeliner.Set(ctx, key, snappy.Encode(nil, payloadSSZ), expiryBidCache).Err()
+	if err != nil || !writeLegacyPayloads {
+		return err
+	}
+	return pipeliner.Set(ctx, legacyPayloadKey(key), payloadSSZ, expiryBidCache).Err()
+}
+
 func (r *RedisCache) SavePayloadContentsElectra(ctx context.Context, tx redis.Pipeliner, slot uint64, proposerPubkey, blockHash string, execPayload *builderApiDeneb.ExecutionPayloadAndBlobsBundle) (err error) {
 	key := r.keyPayloadContentsElectra(slot, proposerPubkey, blockHash)
 	b, err := execPayload.MarshalSSZ()
 	if err != nil {
 		return err
 	}
-	return tx.Set(ctx, key, b, expiryBidCache).Err()
+	return r.savePayloadSSZ(ctx, tx, key, b)
 }
 
 func (r *RedisCache) GetPayloadContentsElectra(slot uint64, proposerPubkey, blockHash string) (*builderApi.VersionedSubmitBlindedBlockResponse, error) {
 	electraPayloadContents := new(builderApiDeneb.ExecutionPayloadAndBlobsBundle)
 
-	key := r.keyPayloadContentsElectra(slot, proposerPubkey, blockHash)
-	val, err := r.client.Get(context.Background(), key).Result()
+	val, err := r.getPayloadSSZ(context.Background(), r.keyPayloadContentsElectra(slot, proposerPubkey, blockHash))
 	if err != nil {
 		return nil, err
 	}
 
-	err = electraPayloadContents.UnmarshalSSZ([]byte(val))
+	err = electraPayloadContents.UnmarshalSSZ(val)
 	if err != nil {
 		return nil, err
 	}
@@ -414,19 +1177,18 @@ func (r *RedisCache) SavePayloadContentsDeneb(ctx context.Context, tx redis.Pipe
 	if err != nil {
 		return err
 	}
-	return tx.Set(ctx, key, b, expiryBidCache).Err()
+	return r.savePayloadSSZ(ctx, tx, key, b)
 }
 
 func (r *RedisCache) GetPayloadContentsDeneb(slot uint64, proposerPubkey, blockHash string) (*builderApi.VersionedSubmitBlindedBlockResponse, error) {
 	denebPayloadContents := new(builderApiDeneb.ExecutionPayloadAndBlobsBundle)
 
-	key := r.keyPayloadContentsDeneb(slot, proposerPubkey, blockHash)
-	val, err := r.client.Get(context.Background(), key).Result()
+	val, err := r.getPayloadSSZ(context.Background(), r.keyPayloadContentsDeneb(slot, proposerPubkey, blockHash))
 	if err != nil {
 		return nil, err
 	}
 
-	err = denebPayloadContents.UnmarshalSSZ([]byte(val))
+	err = denebPayloadContents.UnmarshalSSZ(val)
 	if err != nil {
 		return nil, err
 	}
@@ -443,19 +1205,18 @@ func (r *RedisCache) SaveExecutionPayloadCapella(ctx context.Context, pipeliner
 	if err != nil {
 		return err
 	}
-	return pipeliner.Set(ctx, key, b, expiryBidCache).Err()
+	return r.savePayloadSSZ(ctx, pipeliner, key, b)
 }
 
 func (r *RedisCache) GetExecutionPayloadCapella(slot uint64, proposerPubkey, blockHash string) (*builderApi.VersionedSubmitBlindedBlockResponse, error) {
 	capellaPayload := new(capella.ExecutionPayload)
 
-	key := r.keyExecPayloadCapella(slot, proposerPubkey, blockHash)
-	val, err := r.client.Get(context.Background(), key).Result()
+	val, err := r.getPayloadSSZ(context.Background(), r.keyExecPayloadCapella(slot, proposerPubkey, blockHash))
 	if err != nil {
 		return nil, err
 	}
 
-	err = capellaPayload.UnmarshalSSZ([]byte(val))
+	err = capellaPayload.UnmarshalSSZ(val)
 	if err != nil {
 		return nil, err
 	}
@@ -494,8 +1255,16 @@ func (r *RedisCache) GetBuilderLatestPayloadReceivedAt(ctx context.Context, pipe
 // SaveBuilderBid saves the latest bid by a specific builder. TODO: use transaction to make these writes atomic
 func (r *RedisCache) SaveBuilderBid(ctx context.Context, pipeliner redis.Pipeliner, slot uint64, parentHash, proposerPubkey, builderPubkey string, receivedAt time.Time, headerResp *builderSpec.VersionedSignedBuilderBid) (err error) {
 	// save the actual bid
-	keyLatestBid := r.keyLatestBidByBuilder(slot, parentHash, proposerPubkey, builderPubkey)
-	err = r.SetObjPipelined(ctx, pipeliner, keyLatestBid, headerResp, expiryBidCache)
+	keyLatestBids := r.keyBlockBuilderLatestBids(slot, parentHash, proposerPubkey)
+	marshalledBid, err := json.Marshal(headerResp)
+	if err != nil {
+		return err
+	}
+	err = pipeliner.HSet(ctx, keyLatestBids, builderPubkey, marshalledBid).Err()
+	if err != nil {
+		return err
+	}
+	err = pipeliner.Expire(ctx, keyLatestBids, expiryBidCache).Err()
 	if err != nil {
 		return err
 	}
@@ -532,14 +1301,83 @@ type SaveBidAndUpdateTopBidResponse struct {
 	TopBidValue     *big.Int
 	PrevTopBidValue *big.Int
 
+	// Time to load the floor value, and to queue the updates on the pipeline
 	TimePrep         time.Duration
 	TimeSavePayload  time.Duration
 	TimeSaveBid      time.Duration
 	TimeSaveTrace    time.Duration
 	TimeUpdateTopBid time.Duration
-	TimeUpdateFloor  time.Duration
+
+	// Time to execute all queued updates in a single round-trip
+	TimeExec time.Duration
 }
 
+// updateTopBidScript atomically updates the floor bid and the top bid, so that concurrent submissions (also from
+// other API instances) can't race each other and expose a stale or lower top bid. The bid values are compared as
+// decimal strings, because they exceed the precision of Lua numbers.
+//
+// KEYS: latest bid values hash, floor bid, floor bid value, top bid, top bid value, latest bids hash
+// ARGV: bid value, "1" if the bid should update the floor, expiry in seconds, submitting builder, top bid updates
+// channel (empty to not publish), slot
+//
+// The key of the updated top bid is published on the top bid updates channel, for the in-memory top bid caches of the
+// API instances, which then read the new top bid.
+// Returns the previous and the new top bid value, and the builder of the top bid (empty if it is the floor bid).
+var updateTopBidScript = redis.NewScript(`
+local function isGreater(a, b)
+	if #a ~= #b then
+		return #a > #b
+	end
+	return a > b
+end
+
+local floorValue = redis.call('GET', KEYS[3]) or '0'
+if ARGV[2] == '1' and isGreater(ARGV[1], floorValue) then
+	local bid = redis.call('HGET', KEYS[6], ARGV[4])
+	if not bid then
+		return redis.error_reply('could not copy floor bid of ' .. ARGV[4] .. ' from ' .. KEYS[6])
+	end
+	redis.call('SET', KEYS[2], bid, 'EX', ARGV[3])
+	redis.call('SET', KEYS[3], ARGV[1], 'EX', ARGV[3])
+	floorValue = ARGV[1]
+end
+
+local prevTopValue = redis.call('GET', KEYS[5]) or '0'
+
+local topBuilder, topValue = nil, '0'
+local bidValues = redis.call('HGETALL', KEYS[1])
+for i = 1, #bidValues, 2 do
+	if topBuilder == nil or isGreater(bidValues[i + 1], topValue) then
+		topBuilder, topValue = bidValues[i], bidValues[i + 1]
+	end
+end
+
+local topBid
+if topBuilder ~= nil and not isGreater(floorValue, topValue) then
+	topBid = redis.call('HGET', KEYS[6], topBuilder)
+	if not topBid then
+		return redis.error_reply('could not copy top bid of ' .. topBuilder .. ' from ' .. KEYS[6])
+	end
+elseif redis.call('EXISTS', KEYS[2]) == 1 then
+	topBuilder, topValue, topBid = '', floorValue, redis.call('GET', KEYS[2])
+else
+	redis.call('DEL', KEYS[4], KEYS[5])
+	if ARGV[5] ~= '' then
+		redis.call('PUBLISH', ARGV[5], ARGV[6] .. ' ' .. KEYS[4])
+	end
+	return {prevTopValue, '0', ''}
+end
+
+redis.call('SET', KEYS[4], topBid, 'EX', ARGV[3])
+redis.call('SET', KEYS[5], topValue, 'EX', ARGV[3])
+if ARGV[5] ~= '' then
+	redis.call('PUBLISH', ARGV[5], ARGV[6] .. ' ' .. KEYS[4])
+end
+return {prevTopValue, topValue, topBuilder}
+`)
+
+// SaveBidAndUpdateTopBid is the main logic of saving a new bid and updating the top bid. The payload, bid and trace are
+// saved and the top bid is updated (with updateTopBidScript) in a single pipeline execution.
 func (r *RedisCache) SaveBidAndUpdateTopBid(ctx context.Context, pipeliner redis.Pipeliner, trace *common.BidTraceV2WithBlobFields, payload *common.VersionedSubmitBlockRequest, getPayloadResponse *builderApi.VersionedSubmitBlindedBlockResponse, getHeaderResponse *builderSpec.VersionedSignedBuilderBid, reqReceivedAt time.Time, isCancellationEnabled bool, floorValue *big.Int) (state SaveBidAndUpdateTopBidResponse, err error) {
 	var prevTime, nextTime time.Time
 	prevTime = time.Now()
@@ -549,12 +1387,6 @@ func (r *RedisCache) SaveBidAndUpdateTopBid(ctx context.Context, pipeliner redis
 		return state, err
 	}
 
-	// Load latest bids for a given slot+parent+proposer
-	builderBids, err := NewBuilderBidsFromRedis(ctx, r, pipeliner, submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String())
-	if err != nil {
-		return state, err
-	}
-
 	// Load floor value (if not passed in already)
 	if floorValue == nil {
 		floorValue, err = r.GetFloorBidValue(ctx, pipeliner, submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String())
@@ -563,17 +1395,18 @@ func (r *RedisCache) SaveBidAndUpdateTopBid(ctx context.Context, pipeliner redis
 		}
 	}
 
-	// Get the reference top bid value
-	_, state.TopBidValue = builderBids.getTopBid()
-	if floorValue.Cmp(state.TopBidValue) == 1 {
-		state.TopBidValue = floorValue
+	// Bids are ranked by the value served to proposers, which differs from the submitted value if the bid was adjusted
+	value := submission.BidTrace.Value
+	if trace.AdjustedValue != nil {
+		value = trace.AdjustedValue
 	}
-	state.PrevTopBidValue = state.TopBidValue
 
 	// Abort now if non-cancellation bid is lower than floor value
-	isBidAboveFloor := submission.BidTrace.Value.ToBig().Cmp(floorValue) == 1
+	isBidAboveFloor := value.ToBig().Cmp(floorValue) == 1
 	if !isCancellationEnabled && !isBidAboveFloor {
-		return state, nil
+		state.TopBidValue, err = r.GetTopBidValue(ctx, pipeliner, submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String())
+		state.PrevTopBidValue = state.TopBidValue
+		return state, err
 	}
 
 	// Record time needed
@@ -615,7 +1448,6 @@ func (r *RedisCache) SaveBidAndUpdateTopBid(ctx context.Context, pipeliner redis
 	if err != nil {
 		return state, err
 	}
-	builderBids.bidValues[submission.BidTrace.BuilderPubkey.String()] = submission.BidTrace.Value.ToBig()
 
 	// Record time needed to save bid
 	nextTime = time.Now().UTC()
@@ -633,124 +1465,84 @@ func (r *RedisCache) SaveBidAndUpdateTopBid(ctx context.Context, pipeliner redis
 	state.TimeSaveTrace = nextTime.Sub(prevTime)
 	prevTime = nextTime
 
-	// If top bid value hasn't changed, abort now
-	_, state.TopBidValue = builderBids.getTopBid()
-	if state.TopBidValue.Cmp(state.PrevTopBidValue) == 0 {
-		return state, nil
-	}
+	// 4. Update the floor bid (for non-cancellable bids) and the top bid
+	c := r.queueTopBidUpdate(ctx, pipeliner, submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String(), submission.BidTrace.BuilderPubkey.String(), value.Dec(), !isCancellationEnabled)
 
-	state, err = r._updateTopBid(ctx, pipeliner, state, builderBids, submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String(), floorValue)
-	if err != nil {
-		return state, err
-	}
-	state.IsNewTopBid = submission.BidTrace.Value.ToBig().Cmp(state.TopBidValue) == 0
-	// An Exec happens in _updateTopBid.
-	state.WasBidSaved = true
-
-	// Record time needed to update top bid
+	// Record time needed to queue the top bid update, which includes the floor bid update of non-cancellable bids
 	nextTime = time.Now().UTC()
 	state.TimeUpdateTopBid = nextTime.Sub(prevTime)
 	prevTime = nextTime
 
-	if isCancellationEnabled || !isBidAboveFloor {
-		return state, nil
-	}
-
-	// Non-cancellable bid above floor should set new floor
-	keyBidSource := r.keyLatestBidByBuilder(submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String(), submission.BidTrace.BuilderPubkey.String())
-	keyFloorBid := r.keyFloorBid(submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String())
-	c := pipeliner.Copy(ctx, keyBidSource, keyFloorBid, 0, true)
+	// Execute all the updates in a single round-trip
 	_, err = pipeliner.Exec(ctx)
+	state.TimeExec = time.Now().UTC().Sub(prevTime)
 	if err != nil {
 		return state, err
 	}
 
-	wasCopied, copyErr := c.Result()
-	if copyErr != nil {
-		return state, copyErr
-	} else if wasCopied == 0 {
-		return state, fmt.Errorf("could not copy floor bid from %s to %s", keyBidSource, keyFloorBid) //nolint:goerr113
-	}
-	err = pipeliner.Expire(ctx, keyFloorBid, expiryBidCache).Err()
+	state, topBidBuilder, err := topBidUpdateResult(state, c)
 	if err != nil {
 		return state, err
 	}
+	state.IsNewTopBid = topBidBuilder == submission.BidTrace.BuilderPubkey.String()
+	state.WasBidSaved = true
+	return state, nil
+}
 
-	keyFloorBidValue := r.keyFloorBidValue(submission.BidTrace.Slot, submission.BidTrace.ParentHash.String(), submission.BidTrace.ProposerPubkey.String())
-	err = pipeliner.Set(ctx, keyFloorBidValue, submission.BidTrace.Value.Dec(), expiryBidCache).Err()
+// _updateTopBid recomputes the top bid from the latest bids of all builders and the floor bid
+func (r *RedisCache) _updateTopBid(ctx context.Context, pipeliner redis.Pipeliner, slot uint64, parentHash, proposerPubkey string) (state SaveBidAndUpdateTopBidResponse, err error) {
+	c := r.queueTopBidUpdate(ctx, pipeliner, slot, parentHash, proposerPubkey, "", "0", false)
+	_, err = pipeliner.Exec(ctx)
 	if err != nil {
 		return state, err
 	}
-
-	// Execute setting the floor bid
-	_, err = pipeliner.Exec(ctx)
-
-	// Record time needed to update floor
-	nextTime = time.Now().UTC()
-	state.TimeUpdateFloor = nextTime.Sub(prevTime)
-
+	state, _, err = topBidUpdateResult(state, c)
 	return state, err
 }
 
-func (r *RedisCache) _updateTopBid(ctx context.Context, pipeliner redis.Pipeliner, state SaveBidAndUpdateTopBidResponse, builderBids *BuilderBids, slot uint64, parentHash, proposerPubkey string, floorValue *big.Int) (resp SaveBidAndUpdateTopBidResponse, err error) {
-	if builderBids == nil {
-		builderBids, err = NewBuilderBidsFromRedis(ctx, r, pipeliner, slot, parentHash, proposerPubkey)
-		if err != nil {
-			return state, err
-		}
-	}
-
-	if len(builderBids.bidValues) == 0 {
-		return state, nil
-	}
-
-	// Load floor value (if not passed in already)
-	if floorValue == nil {
-		floorValue, err = r.GetFloorBidValue(ctx, pipeliner, slot, parentHash, proposerPubkey)
-		if err != nil {
-			return state, err
-		}
-	}
-
-	topBidBuilder := ""
-	topBidBuilder, state.TopBidValue = builderBids.getTopBid()
-	keyBidSource := r.keyLatestBidByBuilder(slot, parentHash, proposerPubkey, topBidBuilder)
-
-	// If floor value is higher than this bid, use floor bid instead
-	if floorValue.Cmp(state.TopBidValue) == 1 {
-		state.TopBidValue = floorValue
-		keyBidSource = r.keyFloorBid(slot, parentHash, proposerPubkey)
-	}
+// queueTopBidUpdate queues updateTopBidScript on the pipeline. If updateFloor is set, the bid of the builder becomes
+// the floor bid if its value is higher.
+func (r *RedisCache) queueTopBidUpdate(ctx context.Context, pipeliner redis.Pipeliner, slot uint64, parentHash, proposerPubkey, builderPubkey, value string, updateFloor bool) *redis.Cmd {
+	keys := []string{
+		r.keyBlockBuilderLatestBidsValue(slot, parentHash, proposerPubkey),
+		r.keyFloorBid(slot, parentHash, proposerPubkey),
+		r.keyFloorBidValue(slot, parentHash, proposerPubkey),
+		r.keyCacheGetHeaderResponse(slot, parentHash, proposerPubkey),
+		r.keyTopBidValue(slot, parentHash, proposerPubkey),
+		r.keyBlockBuilderLatestBids(slot, parentHash, proposerPubkey),
+	}
+	updateFloorArg := "0"
+	if updateFloor {
+		updateFloorArg = "1"
+	}
+	channel := ""
+	if r.publishTopBidUpdates.Load() {
+		channel = r.keyTopBidUpdates
+	}
+	return updateTopBidScript.Eval(ctx, pipeliner, keys, value, updateFloorArg, int(expiryBidCache.Seconds()), builderPubkey, channel, slot)
+}
 
-	// Copy winning bid to top bid cache
-	keyTopBid := r.keyCacheGetHeaderResponse(slot, parentHash, proposerPubkey)
-	c := pipeliner.Copy(context.Background(), keyBidSource, keyTopBid, 0, true)
-	_, err = pipeliner.Exec(ctx)
+// topBidUpdateResult sets the top bid values of the response from the result of updateTopBidScript, and returns the
+// builder of the top bid
+func topBidUpdateResult(state SaveBidAndUpdateTopBidResponse, c *redis.Cmd) (resp SaveBidAndUpdateTopBidResponse, topBidBuilder string, err error) {
+	result, err := c.StringSlice()
 	if err != nil {
-		return state, err
+		return state, "", err
+	} else if len(result) != 3 {
+		return state, "", fmt.Errorf("unexpected top bid update result: %v", result) //nolint:goerr113
 	}
-	wasCopied, err := c.Result()
-	if err != nil {
-		return state, err
-	} else if wasCopied == 0 {
-		return state, fmt.Errorf("could not copy top bid from %s to %s", keyBidSource, keyTopBid) //nolint:goerr113
-	}
-	err = pipeliner.Expire(context.Background(), keyTopBid, expiryBidCache).Err()
-	if err != nil {
-		return state, err
-	}
-
-	state.WasTopBidUpdated = state.PrevTopBidValue == nil || state.PrevTopBidValue.Cmp(state.TopBidValue) != 0
 
-	// 6. Finally, update the global top bid value
-	keyTopBidValue := r.keyTopBidValue(slot, parentHash, proposerPubkey)
-	err = pipeliner.Set(context.Background(), keyTopBidValue, state.TopBidValue.String(), expiryBidCache).Err()
-	if err != nil {
-		return state, err
+	var ok bool
+	state.PrevTopBidValue, ok = new(big.Int).SetString(result[0], 10)
+	if !ok {
+		return state, "", fmt.Errorf("could not set previous top bid value from %s", result[0]) //nolint:goerr113
 	}
-
-	_, err = pipeliner.Exec(ctx)
-	return state, err
+	state.TopBidValue, ok = new(big.Int).SetString(result[1], 10)
+	if !ok {
+		return state, "", fmt.Errorf("could not set top bid value from %s", result[1]) //nolint:goerr113
+	}
+	state.WasTopBidUpdated = state.PrevTopBidValue.Cmp(state.TopBidValue) != 0
+	return state, result[2], nil
 }
 
 // GetTopBidValue gets the top bid value for a given slot+parent+proposer combination
@@ -810,8 +1602,205 @@ func (r *RedisCache) DelBuilderBid(ctx context.Context, pipeliner redis.Pipeline
 	}
 
 	// update bids now to compute current top bid
-	state := SaveBidAndUpdateTopBidResponse{} //nolint:exhaustruct
-	_, err = r._updateTopBid(ctx, pipeliner, state, nil, slot, parentHash, proposerPubkey, nil)
+	_, err = r._updateTopBid(ctx, pipeliner, slot, parentHash, proposerPubkey)
+	return err
+}
+
+// InvalidateBids marks all bids of a slot (or only the bids of a builder, if builderPubkey is set) as invalid, and
+// deletes them from the bid and payload caches. Returns the number of deleted bids.
+func (r *RedisCache) InvalidateBids(ctx context.Context, slot uint64, builderPubkey string) (numDeleted int, err error) {
+	if builderPubkey == "" {
+		err = r.addInvalidatedBids(ctx, slot, invalidatedBidsAllBuilders)
+		if err != nil {
+			return 0, err
+		}
+		return r.delSlotBids(ctx, slot)
+	}
+
+	err = r.addInvalidatedBids(ctx, slot, builderPubkey)
+	if err != nil {
+		return 0, err
+	}
+	return r.delSlotBuilderBids(ctx, slot, builderPubkey)
+}
+
+// IsBidInvalidated returns true if all bids of the slot, or any of the given builder pubkeys or block hashes were invalidated
+func (r *RedisCache) IsBidInvalidated(ctx context.Context, slot uint64, ids ...string) (bool, error) {
+	return r.isBidInvalidated(ctx, r.client, slot, ids...)
+}
+
+// IsGetHeaderBidInvalidated is IsBidInvalidated for getHeader, within the deadline of the context
+func (r *RedisCache) IsGetHeaderBidInvalidated(ctx context.Context, slot uint64, ids ...string) (bool, error) {
+	return r.isBidInvalidated(ctx, r.getHeaderClient, slot, ids...)
+}
+
+func (r *RedisCache) isBidInvalidated(ctx context.Context, client *redis.Client, slot uint64, ids ...string) (bool, error) {
+	members := make([]any, 0, len(ids)+1)
+	members = append(members, invalidatedBidsAllBuilders)
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	isMember, err := client.SMIsMember(ctx, r.keyInvalidatedBids(slot), members...).Result()
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(isMember, true), nil
+}
+
+func (r *RedisCache) addInvalidatedBids(ctx context.Context, slot uint64, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]any, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+	key := r.keyInvalidatedBids(slot)
+	pipe := r.client.TxPipeline()
+	pipe.SAdd(ctx, key, members...)
+	pipe.Expire(ctx, key, expiryInvalidatedBids)
+	if r.publishTopBidUpdates.Load() {
+		pipe.Publish(ctx, r.keyBidInvalidations, fmt.Sprintf("%d %s", slot, strings.Join(ids, " ")))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// scanKeys returns all keys matching the pattern
+func (r *RedisCache) scanKeys(ctx context.Context, pattern string) ([]string, error) {
+	keys := []string{}
+	iter := r.client.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	return keys, iter.Err()
+}
+
+// delSlotBids deletes all bids, payloads and top bids of a slot
+func (r *RedisCache) delSlotBids(ctx context.Context, slot uint64) (numDeleted int, err error) {
+	prefixes := []string{
+		r.prefixGetHeaderResponse,
+		r.prefixExecPayloadCapella,
+		r.prefixPayloadContentsDeneb,
+		r.prefixPayloadContentsElectra,
+		r.prefixBidTrace,
+		r.prefixBlockBuilderLatestBids,
+		r.prefixBlockBuilderLatestBidsValue,
+		r.prefixBlockBuilderLatestBidsTime,
+		r.prefixTopBidValue,
+		r.prefixFloorBid,
+		r.prefixFloorBidValue,
+	}
+
+	keys := []string{}
+	for _, prefix := range prefixes {
+		prefixKeys, err := r.scanKeys(ctx, fmt.Sprintf("%s:%d_*", prefix, slot))
+		if err != nil {
+			return 0, err
+		}
+		if prefix == r.prefixBidTrace {
+			numDeleted = len(prefixKeys)
+		}
+		keys = append(keys, prefixKeys...)
+	}
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	return numDeleted, r.client.Del(ctx, keys...).Err()
+}
+
+// delSlotBuilderBids deletes the bids and payloads of a builder for a slot, and updates the top bids without them
+func (r *RedisCache) delSlotBuilderBids(ctx context.Context, slot uint64, builderPubkey string) (numDeleted int, err error) {
+	// delete the payloads of all bids by the builder, and remember their block hashes for getPayload
+	bidTraceKeys, err := r.scanKeys(ctx, fmt.Sprintf("%s:%d_*", r.prefixBidTrace, slot))
+	if err != nil {
+		return 0, err
+	}
+	blockHashes := []string{}
+	for _, key := range bidTraceKeys {
+		trace := new(common.BidTraceV2WithBlobFields)
+		err = r.GetObj(key, trace)
+		if errors.Is(err, redis.Nil) {
+			continue
+		} else if err != nil {
+			return numDeleted, err
+		}
+		if !strings.EqualFold(trace.BuilderPubkey.String(), builderPubkey) {
+			continue
+		}
+
+		proposerPubkey, blockHash := trace.ProposerPubkey.String(), trace.BlockHash.String()
+		err = r.client.Del(ctx,
+			key,
+			r.keyExecPayloadCapella(slot, proposerPubkey, blockHash),
+			r.keyPayloadContentsDeneb(slot, proposerPubkey, blockHash),
+			r.keyPayloadContentsElectra(slot, proposerPubkey, blockHash),
+			legacyPayloadKey(r.keyExecPayloadCapella(slot, proposerPubkey, blockHash)),
+			legacyPayloadKey(r.keyPayloadContentsDeneb(slot, proposerPubkey, blockHash)),
+			legacyPayloadKey(r.keyPayloadContentsElectra(slot, proposerPubkey, blockHash)),
+		).Err()
+		if err != nil {
+			return numDeleted, err
+		}
+		blockHashes = append(blockHashes, blockHash)
+		numDeleted++
+	}
+	err = r.addInvalidatedBids(ctx, slot, blockHashes...)
+	if err != nil {
+		return numDeleted, err
+	}
+
+	// remove the latest bids of the builder from every slot+parentHash+proposerPubkey, and recompute the top bids
+	bidValuesKeys, err := r.scanKeys(ctx, fmt.Sprintf("%s:%d_*", r.prefixBlockBuilderLatestBidsValue, slot))
+	if err != nil {
+		return numDeleted, err
+	}
+	for _, key := range bidValuesKeys {
+		parts := strings.Split(strings.TrimPrefix(key, r.prefixBlockBuilderLatestBidsValue+":"), "_")
+		if len(parts) != 3 {
+			continue
+		}
+		parentHash, proposerPubkey := parts[1], parts[2]
+		err = r.delBuilderBidAndUpdateTopBid(ctx, slot, parentHash, proposerPubkey, builderPubkey, blockHashes)
+		if err != nil {
+			return numDeleted, err
+		}
+	}
+	return numDeleted, nil
+}
+
+// delBuilderBidAndUpdateTopBid removes the latest bid of a builder, and the floor bid if it is one of the given block
+// hashes, and then recomputes the top bid
+func (r *RedisCache) delBuilderBidAndUpdateTopBid(ctx context.Context, slot uint64, parentHash, proposerPubkey, builderPubkey string, blockHashes []string) error {
+	err := r.client.HDel(ctx, r.keyBlockBuilderLatestBids(slot, parentHash, proposerPubkey), builderPubkey).Err()
+	if err != nil {
+		return err
+	}
+	err = r.client.HDel(ctx, r.keyBlockBuilderLatestBidsValue(slot, parentHash, proposerPubkey), builderPubkey).Err()
+	if err != nil {
+		return err
+	}
+	err = r.client.HDel(ctx, r.keyBlockBuilderLatestBidsTime(slot, parentHash, proposerPubkey), builderPubkey).Err()
+	if err != nil {
+		return err
+	}
+
+	// the floor bid is a copy of a bid, drop it if it is one of the builder's blocks
+	floorBid := new(builderSpec.VersionedSignedBuilderBid)
+	err = r.GetObj(r.keyFloorBid(slot, parentHash, proposerPubkey), floorBid)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	} else if err == nil {
+		floorBlockHash, err := floorBid.BlockHash()
+		if err != nil || slices.Contains(blockHashes, floorBlockHash.String()) {
+			err = r.client.Del(ctx, r.keyFloorBid(slot, parentHash, proposerPubkey), r.keyFloorBidValue(slot, parentHash, proposerPubkey)).Err()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = r._updateTopBid(ctx, r.client.Pipeline(), slot, parentHash, proposerPubkey)
 	return err
 }
 
@@ -839,10 +1828,21 @@ func (r *RedisCache) GetFloorBidValue(ctx context.Context, pipeliner redis.Pipel
 // SetFloorBidValue is used only for testing.
 func (r *RedisCache) SetFloorBidValue(slot uint64, parentHash, proposerPubkey, value string) error {
 	keyFloorBidValue := r.keyFloorBidValue(slot, parentHash, proposerPubkey)
-	err := r.client.Set(context.Background(), keyFloorBidValue, value, 0).Err()
+	err := r.client.Set(context.Background(), keyFloorBidValue, value, expiryBidCache).Err()
 	return err
 }
 
+// Ping checks the connections to the main and the readonly redis instance
+func (r *RedisCache) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return err
+	}
+	if r.readonlyClient != r.client {
+		return r.readonlyClient.Ping(ctx).Err()
+	}
+	return nil
+}
+
 func (r *RedisCache) NewPipeline() redis.Pipeliner { //nolint:ireturn,nolintlint
 	return r.client.Pipeline()
 }
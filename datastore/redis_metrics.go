@@ -0,0 +1,76 @@
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+	"github.com/flashbots/mev-boost-relay/metrics"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
+	uberatomic "go.uber.org/atomic"
+)
+
+// redis commands and pipelines taking longer than this are logged (0 to disable)
+var redisSlowCommandMs = cli.GetEnvInt("REDIS_SLOW_COMMAND_MS", 100)
+
+// redisMetricsHook records the latency of all commands and pipelines of a Redis client, and logs slow ones
+type redisMetricsHook struct {
+	client string // main or readonly
+	log    uberatomic.Pointer[logrus.Entry]
+}
+
+func newRedisMetricsHook(client string) *redisMetricsHook {
+	hook := &redisMetricsHook{client: client}
+	hook.log.Store(logrus.NewEntry(logrus.StandardLogger()))
+	return hook
+}
+
+func (h *redisMetricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h *redisMetricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		timeStarted := time.Now()
+		err := next(ctx, cmd)
+		h.record(ctx, cmd.Name(), time.Since(timeStarted), []redis.Cmder{cmd})
+		return err
+	}
+}
+
+func (h *redisMetricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		timeStarted := time.Now()
+		err := next(ctx, cmds)
+		h.record(ctx, "pipeline", time.Since(timeStarted), cmds)
+		return err
+	}
+}
+
+func (h *redisMetricsHook) record(ctx context.Context, command string, duration time.Duration, cmds []redis.Cmder) {
+	metrics.RedisCommandLatencyHistogram.Record(ctx, float64(duration.Microseconds())/1000,
+		otelapi.WithAttributes(attribute.String("command", command), attribute.String("client", h.client)))
+
+	if redisSlowCommandMs <= 0 || duration < time.Duration(redisSlowCommandMs)*time.Millisecond {
+		return
+	}
+	numCommandsByName := make(map[string]int)
+	for _, cmd := range cmds {
+		numCommandsByName[cmd.Name()]++
+	}
+	log := h.log.Load().WithFields(logrus.Fields{
+		"command":     command,
+		"client":      h.client,
+		"durationMs":  duration.Milliseconds(),
+		"numCommands": len(cmds),
+		"commands":    numCommandsByName,
+	})
+	// the first argument of a single command is the key, all further arguments could be large values
+	if len(cmds) == 1 && len(cmds[0].Args()) > 1 {
+		log = log.WithField("key", cmds[0].Args()[1])
+	}
+	log.Warn("slow redis command")
+}
@@ -0,0 +1,39 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisMetricsHookSlowCommands(t *testing.T) {
+	cache := setupTestRedis(t)
+	logger, logHook := test.NewNullLogger()
+	cache.SetLog(logrus.NewEntry(logger))
+	hook := cache.hooks[0]
+
+	// fast commands are not logged
+	require.NoError(t, cache.SetStats(RedisStatsFieldLatestSlot, 1))
+	require.Empty(t, logHook.AllEntries())
+
+	cmd := redis.NewStringCmd(t.Context(), "get", "some-key")
+	hook.record(t.Context(), cmd.Name(), time.Duration(redisSlowCommandMs)*time.Millisecond, []redis.Cmder{cmd})
+	require.Len(t, logHook.AllEntries(), 1)
+	entry := logHook.LastEntry()
+	require.Equal(t, "slow redis command", entry.Message)
+	require.Equal(t, "get", entry.Data["command"])
+	require.Equal(t, "some-key", entry.Data["key"])
+
+	// pipelines are logged with the number of commands by name
+	cmds := []redis.Cmder{cmd, redis.NewStringCmd(t.Context(), "get", "other-key"), redis.NewStatusCmd(t.Context(), "set", "key", "value")}
+	hook.record(t.Context(), "pipeline", time.Second, cmds)
+	require.Len(t, logHook.AllEntries(), 2)
+	entry = logHook.LastEntry()
+	require.Equal(t, "pipeline", entry.Data["command"])
+	require.Equal(t, map[string]int{"get": 2, "set": 1}, entry.Data["commands"])
+	require.NotContains(t, entry.Data, "key")
+}
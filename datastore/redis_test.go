@@ -3,6 +3,7 @@ package datastore
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"testing"
@@ -15,7 +16,9 @@ import (
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/golang/snappy"
 	"github.com/holiman/uint256"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
@@ -85,6 +88,36 @@ func TestRedisValidatorRegistration(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, result, timestamp3)
 	})
+
+	t.Run("test SetValidatorRegistrationTimestampsIfNewer", func(t *testing.T) {
+		pkOld := common.NewPubkeyHex("0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+		pkNew := common.NewPubkeyHex("0x9a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+		pkUnknown := common.NewPubkeyHex("0xaa1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+		require.NoError(t, cache.SetValidatorRegistrationTimestamp(pkOld, 100))
+		require.NoError(t, cache.SetValidatorRegistrationTimestamp(pkNew, 300))
+
+		err := cache.SetValidatorRegistrationTimestampsIfNewer(map[common.PubkeyHex]uint64{
+			pkOld:     200,
+			pkNew:     200,
+			pkUnknown: 200,
+		})
+		require.NoError(t, err)
+
+		for pk, expected := range map[common.PubkeyHex]uint64{pkOld: 200, pkNew: 300, pkUnknown: 200} {
+			result, err := cache.GetValidatorRegistrationTimestamp(pk)
+			require.NoError(t, err)
+			require.Equal(t, expected, result)
+		}
+
+		timestamps := make(map[common.PubkeyHex]uint64)
+		numValidators, err := cache.StreamValidatorRegistrationTimestamps(context.Background(), func(pubkey common.PubkeyHex, timestamp uint64) {
+			timestamps[pubkey] = timestamp
+		})
+		require.NoError(t, err)
+		require.Equal(t, uint64(len(timestamps)), numValidators)
+		require.Equal(t, uint64(200), timestamps[pkOld])
+		require.Equal(t, uint64(300), timestamps[pkNew])
+	})
 }
 
 func TestRedisProposerDuties(t *testing.T) {
@@ -113,6 +146,205 @@ func TestRedisProposerDuties(t *testing.T) {
 	require.Equal(t, duties[0].Entry.Message.FeeRecipient, duties2[0].Entry.Message.FeeRecipient)
 }
 
+func TestRedisDataAPIKeys(t *testing.T) {
+	cache := setupTestRedis(t)
+
+	require.NoError(t, cache.SetDataAPIKey("hash1", "explorer"))
+	require.NoError(t, cache.SetDataAPIKey("hash2", "dashboard"))
+	keys, err := cache.GetDataAPIKeys()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"hash1": "explorer", "hash2": "dashboard"}, keys)
+
+	found, err := cache.DelDataAPIKey("hash1")
+	require.NoError(t, err)
+	require.True(t, found)
+	found, err = cache.DelDataAPIKey("hash1")
+	require.NoError(t, err)
+	require.False(t, found)
+	keys, err = cache.GetDataAPIKeys()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"hash2": "dashboard"}, keys)
+}
+
+func TestRedisLock(t *testing.T) {
+	cache := setupTestRedis(t)
+
+	isLocked, err := cache.IsLocked(t.Context(), "test")
+	require.NoError(t, err)
+	require.False(t, isLocked)
+	acquired, err := cache.AcquireLock(t.Context(), "test", "owner1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+	isLocked, err = cache.IsLocked(t.Context(), "test")
+	require.NoError(t, err)
+	require.True(t, isLocked)
+	acquired, err = cache.AcquireLock(t.Context(), "test", "owner2", time.Minute)
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	// only the owner can release the lock
+	err = cache.ReleaseLock(t.Context(), "test", "owner2")
+	require.NoError(t, err)
+	acquired, err = cache.AcquireLock(t.Context(), "test", "owner2", time.Minute)
+	require.NoError(t, err)
+	require.False(t, acquired)
+	err = cache.ReleaseLock(t.Context(), "test", "owner1")
+	require.NoError(t, err)
+	acquired, err = cache.AcquireLock(t.Context(), "test", "owner2", time.Minute)
+	require.NoError(t, err)
+	require.True(t, acquired)
+}
+
+func TestRedisKnownValidators(t *testing.T) {
+	cache := setupTestRedis(t)
+	pk := func(index uint64) common.PubkeyHex { return common.NewPubkeyHex(fmt.Sprintf("0x%096x", index)) }
+	streamAll := func(indices []uint64) map[uint64]common.PubkeyHex {
+		validators := make(map[uint64]common.PubkeyHex)
+		numValidators, err := cache.StreamKnownValidators(t.Context(), indices, func(index uint64, pubkey common.PubkeyHex) {
+			validators[index] = pubkey
+		})
+		require.NoError(t, err)
+		require.Len(t, validators, int(numValidators)) //nolint:gosec
+		return validators
+	}
+
+	require.Empty(t, streamAll(nil))
+
+	err := cache.SetKnownValidators(t.Context(), map[uint64]common.PubkeyHex{0: pk(0), 1: pk(1), 2: pk(2)})
+	require.NoError(t, err)
+	err = cache.AddKnownValidators(t.Context(), map[uint64]common.PubkeyHex{3: pk(3)})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]common.PubkeyHex{0: pk(0), 1: pk(1), 2: pk(2), 3: pk(3)}, streamAll(nil))
+	require.Equal(t, map[uint64]common.PubkeyHex{3: pk(3)}, streamAll([]uint64{3, 4, 5}))
+
+	// setting the validators replaces all of them
+	err = cache.SetKnownValidators(t.Context(), map[uint64]common.PubkeyHex{5: pk(5)})
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]common.PubkeyHex{5: pk(5)}, streamAll(nil))
+}
+
+func TestRedisAuditKeys(t *testing.T) {
+	cache := setupTestRedis(t)
+	ctx := t.Context()
+
+	// slot specific keys written without an expiration, and keys which don't expire
+	keyBidTrace1 := cache.keyCacheBidTrace(1, "0x01", "0x02")
+	keyBidTrace2 := cache.keyCacheBidTrace(1, "0x01", "0x03")
+	require.NoError(t, cache.client.Set(ctx, keyBidTrace1, "trace", 0).Err())
+	require.NoError(t, cache.client.Set(ctx, keyBidTrace2, "trace", expiryBidCache).Err())
+	require.NoError(t, cache.SetStats(RedisStatsFieldLatestSlot, 1))
+	require.NoError(t, cache.client.Set(ctx, "other-relay:stats", "1", 0).Err())
+
+	stats, err := cache.AuditKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	require.Equal(t, "cache-bid-trace", stats[0].Prefix)
+	require.Equal(t, int64(2), stats[0].NumKeys)
+	require.Positive(t, stats[0].MemoryBytes)
+	require.Equal(t, int64(1), stats[0].NumNoExpiry)
+	require.Equal(t, int64(1), stats[0].NumExpirySet)
+	ttl, err := cache.client.TTL(ctx, keyBidTrace1).Result()
+	require.NoError(t, err)
+	require.Equal(t, expiryBidCache, ttl)
+
+	require.Equal(t, "stats", stats[1].Prefix)
+	require.Equal(t, int64(1), stats[1].NumKeys)
+	require.Equal(t, int64(1), stats[1].NumNoExpiry)
+	require.Equal(t, int64(0), stats[1].NumExpirySet)
+
+	// the expiration was set by the first audit
+	stats, err = cache.AuditKeys(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), stats[0].NumNoExpiry)
+}
+
+func TestRedisDataStream(t *testing.T) {
+	cache := setupTestRedis(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	events, err := cache.SubscribeDataStream(ctx, DataStreamEventTopBid)
+	require.NoError(t, err)
+
+	// only the subscribed event types are received
+	require.NoError(t, cache.PublishDataStreamEvent(ctx, DataStreamEventPayloadDelivered, map[string]string{"slot": "1"}))
+	require.NoError(t, cache.PublishDataStreamEvent(ctx, DataStreamEventTopBid, map[string]string{"slot": "2"}))
+	select {
+	case event := <-events:
+		require.Equal(t, DataStreamEventTopBid, event.Type)
+		require.JSONEq(t, `{"slot":"2"}`, string(event.Data))
+	case <-time.After(time.Second):
+		t.Fatal("no data stream event received")
+	}
+
+	// the channel is closed after the context is done
+	cancel()
+	_, ok := <-events
+	require.False(t, ok)
+}
+
+func TestRedisProposerPreferences(t *testing.T) {
+	cache := setupTestRedis(t)
+	proposerPubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	builderPubkey := phase0.BLSPubKey{0x01}
+
+	preferences, err := cache.GetProposerPreferences(proposerPubkey)
+	require.NoError(t, err)
+	require.Empty(t, preferences)
+
+	entry := &common.SignedProposerPreferences{
+		Message: &common.ProposerPreferences{
+			Pubkey:          phase0.BLSPubKey(hexutil.MustDecode(proposerPubkey)),
+			Timestamp:       1,
+			MinBidValue:     uint256.NewInt(100),
+			BlockedBuilders: []phase0.BLSPubKey{builderPubkey},
+		},
+	}
+	err = cache.SetProposerPreferences(entry)
+	require.NoError(t, err)
+
+	preferences, err = cache.GetProposerPreferences(phase0.BLSPubKey{}.String(), proposerPubkey)
+	require.NoError(t, err)
+	require.Len(t, preferences, 1)
+	require.Equal(t, entry, preferences[proposerPubkey])
+	require.True(t, preferences[proposerPubkey].Message.IsBuilderBlocked(builderPubkey))
+}
+
+func TestRedisBuilderScores(t *testing.T) {
+	cache := setupTestRedis(t)
+
+	scores, err := cache.GetBuilderScores()
+	require.NoError(t, err)
+	require.Empty(t, scores)
+
+	score := common.ComputeBuilderScore("0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249", common.BuilderScoreCounts{NumSubmissions: 100, NumSimErrors: 5, NumDemotions: 1})
+	err = cache.SetBuilderScores(map[string]*common.BuilderScore{score.BuilderPubkey: score})
+	require.NoError(t, err)
+
+	scores, err = cache.GetBuilderScores()
+	require.NoError(t, err)
+	require.Len(t, scores, 1)
+	require.Equal(t, score, scores[score.BuilderPubkey])
+}
+
+func TestRedisBlockBuilderStatus(t *testing.T) {
+	cache := setupTestRedis(t)
+	builderPubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	status := common.BuilderStatus{IsHighPrio: false, IsBlacklisted: false, IsOptimistic: false}
+
+	err := cache.SetBlockBuilderStatus(builderPubkey, status)
+	require.NoError(t, err)
+	statuses, err := cache.GetBlockBuilderStatuses()
+	require.NoError(t, err)
+	require.Equal(t, map[string]common.BuilderStatus{builderPubkey: status}, statuses)
+
+	err = cache.DelBlockBuilderStatus(builderPubkey)
+	require.NoError(t, err)
+	statuses, err = cache.GetBlockBuilderStatuses()
+	require.NoError(t, err)
+	require.Empty(t, statuses)
+}
+
 func TestBuilderBids(t *testing.T) {
 	versions := []spec.DataVersion{
 		spec.DataVersionCapella,
@@ -150,7 +382,7 @@ func TestBuilderBids(t *testing.T) {
 
 		// Helper to ensure writing to redis worked as expected
 		ensureBestBidValueEquals := func(expectedValue int64, builderPubkey string) {
-			bestBid, err := cache.GetBestBid(slot, parentHash, proposerPubkey)
+			bestBid, err := cache.GetBestBid(context.Background(), slot, parentHash, proposerPubkey)
 			require.NoError(t, err)
 			value, err := bestBid.Value()
 			require.NoError(t, err)
@@ -254,6 +486,76 @@ func TestBuilderBids(t *testing.T) {
 	}
 }
 
+func TestBuilderBidsCancellationWithEqualValue(t *testing.T) {
+	cache := setupTestRedis(t)
+	slot := uint64(2)
+	parentHash := "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747"
+	proposerPubkey := "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"
+	bApubkey := "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83"
+	bBpubkey := "0x2e02be2c9f9eccf9856478fdb7876598fed2da09f45c233969ba647a250231150ecf38bce5771adb6171c86b79a92f16"
+
+	saveBid := func(builderPubkey string, value uint64, blockHash phase0.Hash32) SaveBidAndUpdateTopBidResponse {
+		opts := common.CreateTestBlockSubmissionOpts{Slot: slot, ParentHash: parentHash, ProposerPubkey: proposerPubkey}
+		payload, getPayloadResp, _ := common.CreateTestBlockSubmission(t, builderPubkey, uint256.NewInt(value), &opts)
+		payload.Capella.Message.BlockHash = blockHash
+		payload.Capella.ExecutionPayload.BlockHash = blockHash
+		getHeaderResp, err := common.BuildGetHeaderResponse(context.Background(), payload, common.NewTestSigner(t), phase0.Domain{})
+		require.NoError(t, err)
+		trace := &common.BidTraceV2WithBlobFields{BidTrace: *payload.Capella.Message}
+		resp, err := cache.SaveBidAndUpdateTopBid(t.Context(), cache.NewPipeline(), trace, payload, getPayloadResp, getHeaderResp, time.Now(), true, nil)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// builder A and B both bid 10 with cancellations, then A cancels to 5
+	saveBid(bApubkey, 10, phase0.Hash32{0x0a})
+	saveBid(bBpubkey, 10, phase0.Hash32{0x0b})
+	resp := saveBid(bApubkey, 5, phase0.Hash32{0x0c})
+	require.True(t, resp.WasBidSaved)
+	require.False(t, resp.WasTopBidUpdated)
+	require.False(t, resp.IsNewTopBid)
+	require.Equal(t, big.NewInt(10), resp.TopBidValue)
+
+	// the top bid must be the one of builder B, not the cancelled one of builder A
+	bestBid, err := cache.GetBestBid(context.Background(), slot, parentHash, proposerPubkey)
+	require.NoError(t, err)
+	blockHash, err := bestBid.BlockHash()
+	require.NoError(t, err)
+	require.Equal(t, phase0.Hash32{0x0b}, blockHash)
+}
+
+func TestBuilderBidsConcurrent(t *testing.T) {
+	cache := setupTestRedis(t)
+	parentHash := "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747"
+	proposerPubkey := "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"
+	opts := common.CreateTestBlockSubmissionOpts{Slot: 2, ParentHash: parentHash, ProposerPubkey: proposerPubkey}
+	trace := &common.BidTraceV2WithBlobFields{}
+
+	// concurrent submissions of many builders must always end with the highest bid as top bid
+	numBuilders := 20
+	errC := make(chan error, numBuilders)
+	for i := 1; i <= numBuilders; i++ {
+		builderPubkey := phase0.BLSPubKey{byte(i)}
+		payload, getPayloadResp, getHeaderResp := common.CreateTestBlockSubmission(t, builderPubkey.String(), uint256.NewInt(uint64(i)), &opts)
+		go func() {
+			_, err := cache.SaveBidAndUpdateTopBid(context.Background(), cache.NewTxPipeline(), trace, payload, getPayloadResp, getHeaderResp, time.Now(), true, big.NewInt(0))
+			errC <- err
+		}()
+	}
+	for range numBuilders {
+		require.NoError(t, <-errC)
+	}
+
+	bestBid, err := cache.GetBestBid(context.Background(), opts.Slot, parentHash, proposerPubkey)
+	require.NoError(t, err)
+	value, err := bestBid.Value()
+	require.NoError(t, err)
+	require.Equal(t, uint64(numBuilders), value.Uint64())
+	topBidValue, err := cache.GetTopBidValue(t.Context(), cache.client.Pipeline(), opts.Slot, parentHash, proposerPubkey)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(int64(numBuilders)), topBidValue)
+}
+
 func TestRedisURIs(t *testing.T) {
 	t.Helper()
 	var err error
@@ -322,6 +624,259 @@ func TestCheckAndSetLastSlotAndHashDelivered(t *testing.T) {
 	require.ErrorIs(t, err, ErrPastSlotAlreadyDelivered)
 }
 
+func TestLockGetPayloadBlockHash(t *testing.T) {
+	cache := setupTestRedis(t)
+	slot := uint64(123)
+	proposerPubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	hash := "0x0000000000000000000000000000000000000000000000000000000000000000"
+	differentHash := "0x0000000000000000000000000000000000000000000000000000000000000001"
+
+	// first request locks the block hash
+	lockedHash, err := cache.LockGetPayloadBlockHash(slot, proposerPubkey, hash)
+	require.NoError(t, err)
+	require.Equal(t, hash, lockedHash)
+
+	// retries for the same block hash are fine
+	lockedHash, err = cache.LockGetPayloadBlockHash(slot, proposerPubkey, hash)
+	require.NoError(t, err)
+	require.Equal(t, hash, lockedHash)
+
+	// a different block hash returns the locked one
+	lockedHash, err = cache.LockGetPayloadBlockHash(slot, proposerPubkey, differentHash)
+	require.NoError(t, err)
+	require.Equal(t, hash, lockedHash)
+
+	// other slots are independent
+	lockedHash, err = cache.LockGetPayloadBlockHash(slot+1, proposerPubkey, differentHash)
+	require.NoError(t, err)
+	require.Equal(t, differentHash, lockedHash)
+}
+
+func TestPayloadContents(t *testing.T) {
+	cache := setupTestRedis(t)
+	slot := uint64(123)
+	proposerPubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	builderPubkey := "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83"
+	opts := common.CreateTestBlockSubmissionOpts{Slot: slot, ProposerPubkey: proposerPubkey, Version: spec.DataVersionDeneb}
+	_, getPayloadResp, _ := common.CreateTestBlockSubmission(t, builderPubkey, uint256.NewInt(10), &opts)
+	blockHash := getPayloadResp.Deneb.ExecutionPayload.BlockHash.String()
+	payloadSSZ, err := getPayloadResp.Deneb.MarshalSSZ()
+	require.NoError(t, err)
+
+	_, err = cache.GetPayloadContents(slot, proposerPubkey, blockHash)
+	require.ErrorIs(t, err, redis.Nil)
+
+	// payloads are stored as snappy compressed SSZ
+	pipeliner := cache.NewPipeline()
+	err = cache.SavePayloadContentsDeneb(t.Context(), pipeliner, slot, proposerPubkey, blockHash, getPayloadResp.Deneb)
+	require.NoError(t, err)
+	_, err = pipeliner.Exec(t.Context())
+	require.NoError(t, err)
+	stored, err := cache.client.Get(t.Context(), cache.keyPayloadContentsDeneb(slot, proposerPubkey, blockHash)).Bytes()
+	require.NoError(t, err)
+	decoded, err := snappy.Decode(nil, stored)
+	require.NoError(t, err)
+	require.Equal(t, payloadSSZ, decoded)
+
+	// and only with writeLegacyPayloads as uncompressed SSZ under the legacy key, for instances of the previous relay
+	// version
+	legacyKey := legacyPayloadKey(cache.keyPayloadContentsDeneb(slot, proposerPubkey, blockHash))
+	err = cache.client.Get(t.Context(), legacyKey).Err()
+	require.ErrorIs(t, err, redis.Nil)
+
+	writeLegacyPayloads = true
+	t.Cleanup(func() { writeLegacyPayloads = false })
+	pipeliner = cache.NewPipeline()
+	err = cache.SavePayloadContentsDeneb(t.Context(), pipeliner, slot, proposerPubkey, blockHash, getPayloadResp.Deneb)
+	require.NoError(t, err)
+	_, err = pipeliner.Exec(t.Context())
+	require.NoError(t, err)
+	stored, err = cache.client.Get(t.Context(), legacyKey).Bytes()
+	require.NoError(t, err)
+	require.Equal(t, payloadSSZ, stored)
+
+	resp, err := cache.GetPayloadContents(slot, proposerPubkey, blockHash)
+	require.NoError(t, err)
+	require.Equal(t, spec.DataVersionDeneb, resp.Version)
+	respSSZ, err := resp.Deneb.MarshalSSZ()
+	require.NoError(t, err)
+	require.Equal(t, payloadSSZ, respSSZ)
+
+	// uncompressed SSZ payloads of earlier relay versions are still returned
+	err = cache.client.Del(t.Context(), cache.keyPayloadContentsDeneb(slot, proposerPubkey, blockHash)).Err()
+	require.NoError(t, err)
+	resp, err = cache.GetPayloadContents(slot, proposerPubkey, blockHash)
+	require.NoError(t, err)
+	respSSZ, err = resp.Deneb.MarshalSSZ()
+	require.NoError(t, err)
+	require.Equal(t, payloadSSZ, respSSZ)
+}
+
+func TestDeliveredGetPayloadResponse(t *testing.T) {
+	cache := setupTestRedis(t)
+	slot := uint64(123)
+	proposerPubkey := "0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249"
+	hash := "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+	response, err := cache.GetDeliveredGetPayloadResponse(slot, proposerPubkey, hash)
+	require.NoError(t, err)
+	require.Nil(t, response)
+
+	err = cache.SaveDeliveredGetPayloadResponse(slot, proposerPubkey, hash, []byte(`{"version":"deneb"}`))
+	require.NoError(t, err)
+	response, err = cache.GetDeliveredGetPayloadResponse(slot, proposerPubkey, hash)
+	require.NoError(t, err)
+	require.Equal(t, []byte(`{"version":"deneb"}`), response)
+}
+
+func TestInvalidateBids(t *testing.T) {
+	cache := setupTestRedis(t)
+	slot := uint64(2)
+	parentHash := "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747"
+	proposerPubkey := "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"
+	bApubkey := "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83"
+	bBpubkey := "0x2e02be2c9f9eccf9856478fdb7876598fed2da09f45c233969ba647a250231150ecf38bce5771adb6171c86b79a92f16"
+	blockHashA := phase0.Hash32{0x0a}
+	blockHashB := phase0.Hash32{0x0b}
+
+	saveBid := func(slot uint64, builderPubkey string, value uint64, blockHash phase0.Hash32, isCancellationEnabled bool) {
+		opts := common.CreateTestBlockSubmissionOpts{Slot: slot, ParentHash: parentHash, ProposerPubkey: proposerPubkey}
+		payload, getPayloadResp, _ := common.CreateTestBlockSubmission(t, builderPubkey, uint256.NewInt(value), &opts)
+		payload.Capella.Message.BlockHash = blockHash
+		payload.Capella.ExecutionPayload.BlockHash = blockHash
+		getHeaderResp, err := common.BuildGetHeaderResponse(context.Background(), payload, common.NewTestSigner(t), phase0.Domain{})
+		require.NoError(t, err)
+		trace := &common.BidTraceV2WithBlobFields{BidTrace: *payload.Capella.Message}
+		_, err = cache.SaveBidAndUpdateTopBid(t.Context(), cache.NewPipeline(), trace, payload, getPayloadResp, getHeaderResp, time.Now(), isCancellationEnabled, nil)
+		require.NoError(t, err)
+	}
+	ensureTopBid := func(slot uint64, blockHash *phase0.Hash32) {
+		bid, err := cache.GetBestBid(context.Background(), slot, parentHash, proposerPubkey)
+		require.NoError(t, err)
+		if blockHash == nil {
+			require.Nil(t, bid)
+			return
+		}
+		bidBlockHash, err := bid.BlockHash()
+		require.NoError(t, err)
+		require.Equal(t, *blockHash, bidBlockHash)
+	}
+
+	// builder A is the top and floor bid
+	saveBid(slot, bBpubkey, 10, blockHashB, true)
+	saveBid(slot, bApubkey, 20, blockHashA, false)
+	saveBid(slot+1, bApubkey, 20, blockHashA, false)
+	ensureTopBid(slot, &blockHashA)
+
+	// invalidating a builder falls back to the next best bid
+	numDeleted, err := cache.InvalidateBids(t.Context(), slot, bApubkey)
+	require.NoError(t, err)
+	require.Equal(t, 1, numDeleted)
+	ensureTopBid(slot, &blockHashB)
+	floorValue, err := cache.GetFloorBidValue(t.Context(), cache.client.Pipeline(), slot, parentHash, proposerPubkey)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(0), floorValue)
+	_, err = cache.GetPayloadContents(slot, proposerPubkey, blockHashA.String())
+	require.ErrorIs(t, err, redis.Nil)
+	_, err = cache.GetPayloadContents(slot, proposerPubkey, blockHashB.String())
+	require.NoError(t, err)
+
+	isInvalidated, err := cache.IsBidInvalidated(t.Context(), slot, blockHashA.String())
+	require.NoError(t, err)
+	require.True(t, isInvalidated)
+	isInvalidated, err = cache.IsBidInvalidated(t.Context(), slot, bBpubkey, blockHashB.String())
+	require.NoError(t, err)
+	require.False(t, isInvalidated)
+
+	// invalidating the slot removes all bids
+	numDeleted, err = cache.InvalidateBids(t.Context(), slot, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, numDeleted)
+	ensureTopBid(slot, nil)
+	_, err = cache.GetPayloadContents(slot, proposerPubkey, blockHashB.String())
+	require.ErrorIs(t, err, redis.Nil)
+	isInvalidated, err = cache.IsBidInvalidated(t.Context(), slot, bBpubkey)
+	require.NoError(t, err)
+	require.True(t, isInvalidated)
+
+	// other slots are untouched
+	ensureTopBid(slot+1, &blockHashA)
+	isInvalidated, err = cache.IsBidInvalidated(t.Context(), slot+1, bApubkey)
+	require.NoError(t, err)
+	require.False(t, isInvalidated)
+}
+
+func TestCircuitBreakerState(t *testing.T) {
+	cache := setupTestRedis(t)
+
+	state, err := cache.GetCircuitBreakerState()
+	require.NoError(t, err)
+	require.Equal(t, common.CircuitBreakerState{}, *state)
+
+	err = cache.SetCircuitBreakerState(&common.CircuitBreakerState{LastCheckedSlot: 123, NumMissedSlots: 3, IsTripped: true})
+	require.NoError(t, err)
+	state, err = cache.GetCircuitBreakerState()
+	require.NoError(t, err)
+	require.Equal(t, common.CircuitBreakerState{LastCheckedSlot: 123, NumMissedSlots: 3, IsTripped: true}, *state)
+}
+
+func TestRecordCircuitBreakerCheck(t *testing.T) {
+	cache := setupTestRedis(t)
+
+	// missed payloads trip the breaker after the maximum
+	state, wasTripped, err := cache.RecordCircuitBreakerCheck(100, false, 2)
+	require.NoError(t, err)
+	require.False(t, wasTripped)
+	require.Equal(t, common.CircuitBreakerState{LastCheckedSlot: 100, NumMissedSlots: 1}, *state)
+	state, wasTripped, err = cache.RecordCircuitBreakerCheck(101, false, 2)
+	require.NoError(t, err)
+	require.False(t, wasTripped)
+	require.Equal(t, common.CircuitBreakerState{LastCheckedSlot: 101, NumMissedSlots: 2, IsTripped: true}, *state)
+
+	// slots are only checked once
+	state, wasTripped, err = cache.RecordCircuitBreakerCheck(101, false, 2)
+	require.NoError(t, err)
+	require.True(t, wasTripped)
+	require.Nil(t, state)
+
+	// a landed payload resets the missed slots, but the breaker stays tripped until reset
+	state, _, err = cache.RecordCircuitBreakerCheck(102, true, 2)
+	require.NoError(t, err)
+	require.Equal(t, common.CircuitBreakerState{LastCheckedSlot: 102, IsTripped: true}, *state)
+
+	state, wasTripped, err = cache.ResetCircuitBreaker()
+	require.NoError(t, err)
+	require.True(t, wasTripped)
+	require.Equal(t, common.CircuitBreakerState{LastCheckedSlot: 102}, *state)
+	state, err = cache.GetCircuitBreakerState()
+	require.NoError(t, err)
+	require.Equal(t, common.CircuitBreakerState{LastCheckedSlot: 102}, *state)
+}
+
+func TestFeatureFlags(t *testing.T) {
+	cache := setupTestRedis(t)
+
+	err := cache.SetRelayConfig(RedisConfigFieldPubkey, "0x01")
+	require.NoError(t, err)
+	flags, err := cache.GetFeatureFlags()
+	require.NoError(t, err)
+	require.Empty(t, flags)
+
+	err = cache.SetFeatureFlag("FORCE_GET_HEADER_204", true)
+	require.NoError(t, err)
+	err = cache.SetFeatureFlag("DISABLE_LOWPRIO_BUILDERS", false)
+	require.NoError(t, err)
+	flags, err = cache.GetFeatureFlags()
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"FORCE_GET_HEADER_204": true, "DISABLE_LOWPRIO_BUILDERS": false}, flags)
+
+	err = cache.DelFeatureFlag("FORCE_GET_HEADER_204")
+	require.NoError(t, err)
+	flags, err = cache.GetFeatureFlags()
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"DISABLE_LOWPRIO_BUILDERS": false}, flags)
+}
+
 // Test_CheckAndSetLastSlotAndHashDeliveredForTesting ensures the optimistic locking works
 // i.e. running CheckAndSetLastSlotAndHashDelivered leading to err == redis.TxFailedErr
 func Test_CheckAndSetLastSlotAndHashDeliveredForTesting(t *testing.T) {
@@ -0,0 +1,92 @@
+package datastore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+	"github.com/flashbots/mev-boost-relay/common"
+)
+
+var (
+	registrationCacheTTL     = time.Duration(cli.GetEnvInt("REGISTRATION_CACHE_TTL_SEC", 60)) * time.Second
+	registrationCacheMaxSize = cli.GetEnvInt("REGISTRATION_CACHE_MAX_SIZE", 1_000_000)
+)
+
+type registrationCacheEntry struct {
+	pubkey    common.PubkeyHex
+	timestamp uint64
+	expiresAt time.Time
+}
+
+// registrationCache keeps the validator registration timestamps in memory for a while, to avoid reading them
+// from Redis for every registration. A cached timestamp is never newer than the one in Redis, so a stale entry
+// at most causes a registration to be processed again.
+//
+// All entries have the same TTL, so the list ordered by the last update is also ordered by expiry. Expired entries
+// and, if the cache is full, the entries expiring first are removed from its front in O(1).
+type registrationCache struct {
+	lock    sync.RWMutex
+	ttl     time.Duration
+	maxSize int
+	entries map[common.PubkeyHex]*list.Element
+	byAge   *list.List // of *registrationCacheEntry, oldest first
+}
+
+func newRegistrationCache(ttl time.Duration, maxSize int) *registrationCache {
+	return &registrationCache{
+		lock:    sync.RWMutex{},
+		ttl:     ttl,
+		maxSize: maxSize,
+		entries: make(map[common.PubkeyHex]*list.Element),
+		byAge:   list.New(),
+	}
+}
+
+// get returns the cached timestamp, and whether it was found and not expired
+func (c *registrationCache) get(pubkey common.PubkeyHex) (timestamp uint64, found bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	elem, found := c.entries[pubkey]
+	if !found {
+		return 0, false
+	}
+	entry := elem.Value.(*registrationCacheEntry) //nolint:forcetypeassert
+	if time.Now().After(entry.expiresAt) {
+		return 0, false
+	}
+	return entry.timestamp, true
+}
+
+// set caches the timestamp, unless a newer one is cached already
+func (c *registrationCache) set(pubkey common.PubkeyHex, timestamp uint64) {
+	if c.maxSize <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	now := time.Now()
+	if elem, found := c.entries[pubkey]; found {
+		entry := elem.Value.(*registrationCacheEntry) //nolint:forcetypeassert
+		if now.Before(entry.expiresAt) && entry.timestamp > timestamp {
+			return
+		}
+		entry.timestamp = timestamp
+		entry.expiresAt = now.Add(c.ttl)
+		c.byAge.MoveToBack(elem)
+		return
+	}
+
+	// make room by removing expired entries, and the oldest entry if the cache is still full
+	for front := c.byAge.Front(); front != nil; front = c.byAge.Front() {
+		entry := front.Value.(*registrationCacheEntry) //nolint:forcetypeassert
+		if len(c.entries) < c.maxSize && now.Before(entry.expiresAt) {
+			break
+		}
+		c.byAge.Remove(front)
+		delete(c.entries, entry.pubkey)
+	}
+	c.entries[pubkey] = c.byAge.PushBack(&registrationCacheEntry{pubkey: pubkey, timestamp: timestamp, expiresAt: now.Add(c.ttl)})
+}
@@ -0,0 +1,77 @@
+package datastore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistrationCache(t *testing.T) {
+	pk1 := common.NewPubkeyHex("0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	pk2 := common.NewPubkeyHex("0x9a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+
+	t.Run("keeps the newest timestamp", func(t *testing.T) {
+		c := newRegistrationCache(time.Minute, 10)
+		_, found := c.get(pk1)
+		require.False(t, found)
+
+		c.set(pk1, 200)
+		c.set(pk1, 100)
+		timestamp, found := c.get(pk1)
+		require.True(t, found)
+		require.Equal(t, uint64(200), timestamp)
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		c := newRegistrationCache(0, 10)
+		c.set(pk1, 100)
+		_, found := c.get(pk1)
+		require.False(t, found)
+	})
+
+	t.Run("size is bounded", func(t *testing.T) {
+		c := newRegistrationCache(time.Minute, 1)
+		c.set(pk1, 100)
+		c.set(pk2, 100)
+		require.Len(t, c.entries, 1)
+		_, found := c.get(pk2)
+		require.True(t, found)
+	})
+
+	t.Run("evicts the oldest entries", func(t *testing.T) {
+		pk3 := common.NewPubkeyHex("0xaa1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+		c := newRegistrationCache(time.Minute, 2)
+		c.set(pk1, 100)
+		c.set(pk2, 100)
+		c.set(pk1, 200) // updating pk1 makes pk2 the oldest entry
+		c.set(pk3, 100)
+		require.Len(t, c.entries, 2)
+		require.Equal(t, 2, c.byAge.Len())
+		_, found := c.get(pk2)
+		require.False(t, found)
+		timestamp, found := c.get(pk1)
+		require.True(t, found)
+		require.Equal(t, uint64(200), timestamp)
+		_, found = c.get(pk3)
+		require.True(t, found)
+	})
+}
+
+func TestGetValidatorRegistrationTimestamp(t *testing.T) {
+	ds := setupTestDatastore(t, &database.MockDB{})
+	pk := common.NewPubkeyHex("0x8a1d7b8dd64e0aafe7ea7b6c95065c9364cf99d38470c12ee807d55f7de1529ad29ce2c422e0b65e3d5a05c02caca249")
+	require.NoError(t, ds.redis.SetValidatorRegistrationTimestamp(pk, 100))
+
+	timestamp, err := ds.GetValidatorRegistrationTimestamp(pk)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), timestamp)
+
+	// served from memory afterwards
+	require.NoError(t, ds.redis.SetValidatorRegistrationTimestamp(pk, 200))
+	timestamp, err = ds.GetValidatorRegistrationTimestamp(pk)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), timestamp)
+}
@@ -0,0 +1,229 @@
+package datastore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	builderSpec "github.com/attestantio/go-builder-client/spec"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+type topBidCacheEntry struct {
+	slot uint64
+	bid  *builderSpec.VersionedSignedBuilderBid // nil if there is no bid anymore
+}
+
+// TopBidCache keeps the top bid of every slot+parentHash+proposerPubkey in memory, so getHeader can be served without
+// reading from Redis. The keys of updated top bids are published by updateTopBidScript (on every instance), and the
+// invalidated bids by InvalidateBids. Only updates received since the subscription are known, for anything else Redis
+// has to be read.
+type TopBidCache struct {
+	redis *RedisCache
+	log   *logrus.Entry
+
+	lock        sync.RWMutex
+	bids        map[string]topBidCacheEntry    // by getHeader response key
+	invalidated map[uint64]map[string]struct{} // by slot, with builder pubkeys and block hashes
+	isSynced    bool                           // false while updates may be missed, until resubscribed
+}
+
+func NewTopBidCache(redis *RedisCache, log *logrus.Entry) *TopBidCache {
+	return &TopBidCache{
+		redis:       redis,
+		log:         log.WithField("component", "topBidCache"),
+		lock:        sync.RWMutex{},
+		bids:        make(map[string]topBidCacheEntry),
+		invalidated: make(map[uint64]map[string]struct{}),
+		isSynced:    false,
+	}
+}
+
+// Start subscribes to the top bid updates and bid invalidations, and applies them until the context is done
+func (c *TopBidCache) Start(ctx context.Context) error {
+	pubsub := c.redis.client.Subscribe(ctx, c.redis.keyTopBidUpdates, c.redis.keyBidInvalidations)
+	// wait for the subscription to be confirmed, updates published before are not received
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+	c.setSynced(true)
+
+	go func() {
+		defer pubsub.Close()
+		for {
+			msg, err := pubsub.Receive(ctx)
+			if ctx.Err() != nil {
+				return
+			} else if err != nil {
+				// updates may be missed while the connection is down, the subscription is restored on the next receive
+				c.log.WithError(err).Warn("top bid updates subscription failed, not serving from memory until resubscribed")
+				c.setSynced(false)
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				// once both channels are subscribed again, the updates which were missed are read from Redis
+				if msg.Count == 2 {
+					c.resync(ctx)
+				}
+			case *redis.Message:
+				c.handleMessage(ctx, msg)
+			}
+		}
+	}()
+	return nil
+}
+
+// resync reads the known top bids and invalidations from Redis, after updates may have been missed
+func (c *TopBidCache) resync(ctx context.Context) {
+	c.lock.RLock()
+	isSynced := c.isSynced
+	slotsByKey := make(map[string]uint64, len(c.bids))
+	for key, entry := range c.bids {
+		slotsByKey[key] = entry.slot
+	}
+	slots := make([]uint64, 0, len(c.invalidated))
+	for slot := range c.invalidated {
+		slots = append(slots, slot)
+	}
+	c.lock.RUnlock()
+	if isSynced {
+		return
+	}
+
+	for key, slot := range slotsByKey {
+		c.updateBid(ctx, slot, key)
+	}
+	for _, slot := range slots {
+		ids, err := c.redis.client.SMembers(ctx, c.redis.keyInvalidatedBids(slot)).Result()
+		if err != nil {
+			c.log.WithError(err).Error("could not read invalidated bids, clearing the cache")
+			c.clear()
+			break
+		}
+		c.lock.Lock()
+		c.invalidated[slot] = make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			c.invalidated[slot][id] = struct{}{}
+		}
+		c.lock.Unlock()
+	}
+	c.setSynced(true)
+	c.log.WithField("numBids", len(slotsByKey)).Info("top bid updates resubscribed")
+}
+
+// updateBid reads the top bid of a getHeader response key from Redis
+func (c *TopBidCache) updateBid(ctx context.Context, slot uint64, key string) {
+	entry := topBidCacheEntry{slot: slot, bid: nil}
+	value, err := c.redis.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		entry.bid = nil
+	} else if err == nil {
+		entry.bid = new(builderSpec.VersionedSignedBuilderBid)
+		err = json.Unmarshal(value, entry.bid)
+	}
+	if err != nil {
+		// drop the entry, so Redis is read instead of serving an outdated bid
+		c.log.WithError(err).Error("could not read top bid")
+		c.lock.Lock()
+		delete(c.bids, key)
+		c.lock.Unlock()
+		return
+	}
+	c.lock.Lock()
+	c.bids[key] = entry
+	c.lock.Unlock()
+}
+
+func (c *TopBidCache) handleMessage(ctx context.Context, msg *redis.Message) {
+	parts := strings.SplitN(msg.Payload, " ", 3)
+	if len(parts) < 2 {
+		c.log.WithField("payload", msg.Payload).Error("invalid top bid cache update")
+		return
+	}
+	slot, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		c.log.WithField("payload", msg.Payload).Error("invalid slot in top bid cache update")
+		return
+	}
+
+	if msg.Channel == c.redis.keyBidInvalidations {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		if c.invalidated[slot] == nil {
+			c.invalidated[slot] = make(map[string]struct{})
+		}
+		for _, id := range strings.Fields(msg.Payload)[1:] {
+			c.invalidated[slot][id] = struct{}{}
+		}
+		return
+	}
+
+	// only the key is published, the current top bid is read, which is at least as new as the update
+	c.updateBid(ctx, slot, parts[1])
+}
+
+// GetBestBid returns the top bid, and whether it is known. A known top bid is nil if there is none anymore.
+func (c *TopBidCache) GetBestBid(slot uint64, parentHash, proposerPubkey string) (bid *builderSpec.VersionedSignedBuilderBid, found bool) {
+	key := c.redis.keyCacheGetHeaderResponse(slot, parentHash, proposerPubkey)
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if !c.isSynced {
+		return nil, false
+	}
+	entry, found := c.bids[key]
+	return entry.bid, found
+}
+
+// IsBidInvalidated returns true if all bids of the slot, or any of the given builder pubkeys or block hashes were
+// invalidated since the subscription
+func (c *TopBidCache) IsBidInvalidated(slot uint64, ids ...string) bool {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	invalidated := c.invalidated[slot]
+	if _, found := invalidated[invalidatedBidsAllBuilders]; found {
+		return true
+	}
+	for _, id := range ids {
+		if _, found := invalidated[id]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// Prune removes the top bids and invalidations of slots before the given slot
+func (c *TopBidCache) Prune(slot uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, entry := range c.bids {
+		if entry.slot < slot {
+			delete(c.bids, key)
+		}
+	}
+	for s := range c.invalidated {
+		if s < slot {
+			delete(c.invalidated, s)
+		}
+	}
+}
+
+func (c *TopBidCache) setSynced(isSynced bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.isSynced = isSynced
+}
+
+func (c *TopBidCache) clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	clear(c.bids)
+	clear(c.invalidated)
+}
@@ -0,0 +1,121 @@
+package datastore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopBidCache(t *testing.T) {
+	cache := setupTestRedis(t)
+	cache.EnableTopBidUpdates()
+	topBidCache := NewTopBidCache(cache, common.TestLog)
+	require.NoError(t, topBidCache.Start(t.Context()))
+
+	slot := uint64(2)
+	parentHash := "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747"
+	proposerPubkey := "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"
+	bApubkey := "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83"
+	bBpubkey := "0x2e02be2c9f9eccf9856478fdb7876598fed2da09f45c233969ba647a250231150ecf38bce5771adb6171c86b79a92f16"
+
+	saveBid := func(builderPubkey string, value uint64, blockHash phase0.Hash32) {
+		opts := common.CreateTestBlockSubmissionOpts{Slot: slot, ParentHash: parentHash, ProposerPubkey: proposerPubkey}
+		payload, getPayloadResp, _ := common.CreateTestBlockSubmission(t, builderPubkey, uint256.NewInt(value), &opts)
+		payload.Capella.Message.BlockHash = blockHash
+		payload.Capella.ExecutionPayload.BlockHash = blockHash
+		getHeaderResp, err := common.BuildGetHeaderResponse(context.Background(), payload, common.NewTestSigner(t), phase0.Domain{})
+		require.NoError(t, err)
+		trace := &common.BidTraceV2WithBlobFields{BidTrace: *payload.Capella.Message}
+		_, err = cache.SaveBidAndUpdateTopBid(t.Context(), cache.NewPipeline(), trace, payload, getPayloadResp, getHeaderResp, time.Now(), true, nil)
+		require.NoError(t, err)
+	}
+	requireTopBid := func(blockHash phase0.Hash32) {
+		require.Eventually(t, func() bool {
+			bid, found := topBidCache.GetBestBid(slot, parentHash, proposerPubkey)
+			if !found || bid == nil {
+				return false
+			}
+			bidBlockHash, err := bid.BlockHash()
+			return err == nil && bidBlockHash == blockHash
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	_, found := topBidCache.GetBestBid(slot, parentHash, proposerPubkey)
+	require.False(t, found)
+
+	// the top bid follows the submissions, also when it is cancelled
+	saveBid(bApubkey, 10, phase0.Hash32{0x0a})
+	requireTopBid(phase0.Hash32{0x0a})
+	saveBid(bBpubkey, 20, phase0.Hash32{0x0b})
+	requireTopBid(phase0.Hash32{0x0b})
+	saveBid(bBpubkey, 5, phase0.Hash32{0x0c})
+	requireTopBid(phase0.Hash32{0x0a})
+
+	// invalidating a builder falls back to the next best bid
+	_, err := cache.InvalidateBids(t.Context(), slot, bApubkey)
+	require.NoError(t, err)
+	requireTopBid(phase0.Hash32{0x0c})
+	require.True(t, topBidCache.IsBidInvalidated(slot, phase0.Hash32{0x0a}.String()))
+	require.False(t, topBidCache.IsBidInvalidated(slot, phase0.Hash32{0x0c}.String()))
+
+	// invalidating all bids of the slot
+	_, err = cache.InvalidateBids(t.Context(), slot, "")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return topBidCache.IsBidInvalidated(slot, phase0.Hash32{0x0c}.String())
+	}, time.Second, 10*time.Millisecond)
+
+	// past slots are pruned
+	topBidCache.Prune(slot + 1)
+	_, found = topBidCache.GetBestBid(slot, parentHash, proposerPubkey)
+	require.False(t, found)
+	require.False(t, topBidCache.IsBidInvalidated(slot))
+}
+
+func TestTopBidCacheResync(t *testing.T) {
+	cache := setupTestRedis(t)
+	topBidCache := NewTopBidCache(cache, common.TestLog)
+	require.NoError(t, topBidCache.Start(t.Context()))
+
+	slot := uint64(2)
+	parentHash := "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747"
+	proposerPubkey := "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"
+	builderPubkey := "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83"
+	key := cache.keyCacheGetHeaderResponse(slot, parentHash, proposerPubkey)
+
+	// without top bid updates enabled nothing is published
+	opts := common.CreateTestBlockSubmissionOpts{Slot: slot, ParentHash: parentHash, ProposerPubkey: proposerPubkey}
+	payload, getPayloadResp, _ := common.CreateTestBlockSubmission(t, builderPubkey, uint256.NewInt(10), &opts)
+	getHeaderResp, err := common.BuildGetHeaderResponse(context.Background(), payload, common.NewTestSigner(t), phase0.Domain{})
+	require.NoError(t, err)
+	trace := &common.BidTraceV2WithBlobFields{BidTrace: *payload.Capella.Message}
+	_, err = cache.SaveBidAndUpdateTopBid(t.Context(), cache.NewPipeline(), trace, payload, getPayloadResp, getHeaderResp, time.Now(), true, nil)
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	_, found := topBidCache.GetBestBid(slot, parentHash, proposerPubkey)
+	require.False(t, found)
+
+	// known bids are not served while updates may be missed, and are read from Redis once resubscribed
+	topBidCache.lock.Lock()
+	topBidCache.bids[key] = topBidCacheEntry{slot: slot, bid: nil}
+	topBidCache.invalidated[slot] = map[string]struct{}{}
+	topBidCache.lock.Unlock()
+	_, err = cache.InvalidateBids(t.Context(), slot, builderPubkey)
+	require.NoError(t, err)
+	_, err = cache.SaveBidAndUpdateTopBid(t.Context(), cache.NewPipeline(), trace, payload, getPayloadResp, getHeaderResp, time.Now(), true, nil)
+	require.NoError(t, err)
+
+	topBidCache.setSynced(false)
+	_, found = topBidCache.GetBestBid(slot, parentHash, proposerPubkey)
+	require.False(t, found)
+	topBidCache.resync(t.Context())
+	bid, found := topBidCache.GetBestBid(slot, parentHash, proposerPubkey)
+	require.True(t, found)
+	require.NotNil(t, bid)
+	require.True(t, topBidCache.IsBidInvalidated(slot, builderPubkey))
+}
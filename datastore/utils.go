@@ -1,52 +0,0 @@
-package datastore
-
-import (
-	"context"
-	"errors"
-	"math/big"
-
-	"github.com/redis/go-redis/v9"
-)
-
-// BuilderBids supports redis.SaveBidAndUpdateTopBid
-type BuilderBids struct {
-	bidValues map[string]*big.Int
-}
-
-func NewBuilderBidsFromRedis(ctx context.Context, r *RedisCache, pipeliner redis.Pipeliner, slot uint64, parentHash, proposerPubkey string) (*BuilderBids, error) {
-	keyBidValues := r.keyBlockBuilderLatestBidsValue(slot, parentHash, proposerPubkey)
-	c := pipeliner.HGetAll(ctx, keyBidValues)
-	_, err := pipeliner.Exec(ctx)
-	if err != nil && !errors.Is(err, redis.Nil) {
-		return nil, err
-	}
-
-	bidValueMap, err := c.Result()
-	if err != nil {
-		return nil, err
-	}
-	return NewBuilderBids(bidValueMap), nil
-}
-
-func NewBuilderBids(bidValueMap map[string]string) *BuilderBids {
-	b := BuilderBids{
-		bidValues: make(map[string]*big.Int),
-	}
-	for builderPubkey, bidValue := range bidValueMap {
-		b.bidValues[builderPubkey] = new(big.Int)
-		b.bidValues[builderPubkey].SetString(bidValue, 10)
-	}
-	return &b
-}
-
-func (b *BuilderBids) getTopBid() (string, *big.Int) {
-	topBidBuilderPubkey := ""
-	topBidValue := big.NewInt(0)
-	for builderPubkey, bidValue := range b.bidValues {
-		if bidValue.Cmp(topBidValue) > 0 {
-			topBidValue = bidValue
-			topBidBuilderPubkey = builderPubkey
-		}
-	}
-	return topBidBuilderPubkey, topBidValue
-}
@@ -4,6 +4,7 @@ package metrics
 import (
 	"context"
 	"math"
+	"sync"
 
 	"go.opentelemetry.io/otel/exporters/prometheus"
 	otelapi "go.opentelemetry.io/otel/metric"
@@ -19,6 +20,9 @@ const (
 var (
 	meter otelapi.Meter
 
+	setupOnce sync.Once
+	setupErr  error
+
 	GetHeaderLatencyHistogram    otelapi.Float64Histogram
 	GetPayloadLatencyHistogram   otelapi.Float64Histogram
 	PublishBlockLatencyHistogram otelapi.Float64Histogram
@@ -32,9 +36,33 @@ var (
 
 	SubmitNewBlockRedisPayloadLatencyHistogram otelapi.Float64Histogram
 	SubmitNewBlockRedisTopBidLatencyHistogram  otelapi.Float64Histogram
-	SubmitNewBlockRedisFloorLatencyHistogram   otelapi.Float64Histogram
+	SubmitNewBlockRedisExecLatencyHistogram    otelapi.Float64Histogram
+
+	BuilderDemotionCount      otelapi.Int64Counter
+	ProposerEquivocationCount otelapi.Int64Counter
+
+	RequestCount            otelapi.Int64Counter
+	RequestLatencyHistogram otelapi.Float64Histogram
+
+	BlockSimQueueSize          otelapi.Int64Gauge
+	TopBidUpdateCount          otelapi.Int64Counter
+	ValidatorRegistrationCount otelapi.Int64Counter
+
+	RedisErrorCount    otelapi.Int64Counter
+	DatabaseErrorCount otelapi.Int64Counter
 
-	BuilderDemotionCount otelapi.Int64Counter
+	BlockSubmissionDBQueueSize otelapi.Int64Gauge
+	BlockSubmissionDBDropCount otelapi.Int64Counter
+	FilteredSubmissionCount    otelapi.Int64Counter
+	GetHeaderLogDBDropCount    otelapi.Int64Counter
+
+	RedisCommandLatencyHistogram otelapi.Float64Histogram
+
+	DatabasePrunedRowCount otelapi.Int64Counter
+
+	RedisKeyCount          otelapi.Int64Gauge
+	RedisKeyMemoryUsage    otelapi.Int64Gauge
+	RedisKeyExpirySetCount otelapi.Int64Counter
 
 	// latencyBoundariesMs is the set of buckets of exponentially growing
 	// latencies that are ranging from 5ms up to 12s
@@ -48,9 +76,24 @@ var (
 		}
 		return res
 	}()...)
+
+	// redisLatencyBoundariesMs is the set of buckets for redis commands, which
+	// mostly take well below a millisecond
+	redisLatencyBoundariesMs = otelapi.WithExplicitBucketBoundaries(
+		0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500,
+	)
 )
 
+// Setup creates the meter and all instruments. The exporter registers with the default prometheus registry,
+// so this is only done once per process, and subsequent calls return the result of the first one.
 func Setup(ctx context.Context) error {
+	setupOnce.Do(func() {
+		setupErr = setupInstruments(ctx)
+	})
+	return setupErr
+}
+
+func setupInstruments(ctx context.Context) error {
 	for _, setup := range []func(context.Context) error{
 		setupMeter, // must come first
 		setupGetHeaderLatency,
@@ -64,8 +107,25 @@ func Setup(ctx context.Context) error {
 		setupSubmitNewBlockRedisLatency,
 		setupSubmitNewBlockRedisPayloadLatency,
 		setupSubmitNewBlockRedisTopBidLatency,
-		setupSubmitNewBlockRedisFloorLatency,
+		setupSubmitNewBlockRedisExecLatency,
 		setupBuilderDemotionCount,
+		setupProposerEquivocationCount,
+		setupRequestCount,
+		setupRequestLatency,
+		setupBlockSimQueueSize,
+		setupTopBidUpdateCount,
+		setupValidatorRegistrationCount,
+		setupRedisErrorCount,
+		setupDatabaseErrorCount,
+		setupBlockSubmissionDBQueueSize,
+		setupBlockSubmissionDBDropCount,
+		setupFilteredSubmissionCount,
+		setupGetHeaderLogDBDropCount,
+		setupRedisCommandLatency,
+		setupDatabasePrunedRowCount,
+		setupRedisKeyCount,
+		setupRedisKeyMemoryUsage,
+		setupRedisKeyExpirySetCount,
 	} {
 		if err := setup(ctx); err != nil {
 			return err
@@ -254,14 +314,14 @@ func setupSubmitNewBlockRedisTopBidLatency(_ context.Context) error {
 	return nil
 }
 
-func setupSubmitNewBlockRedisFloorLatency(_ context.Context) error {
+func setupSubmitNewBlockRedisExecLatency(_ context.Context) error {
 	latency, err := meter.Float64Histogram(
-		"submit_new_block_redis_floor_latency",
-		otelapi.WithDescription("statistics on the redis update floor bid duration during redis updates of submitNewBlock requests"),
+		"submit_new_block_redis_exec_latency",
+		otelapi.WithDescription("statistics on the redis pipeline execution duration during redis updates of submitNewBlock requests"),
 		otelapi.WithUnit("ms"),
 		latencyBoundariesMs,
 	)
-	SubmitNewBlockRedisFloorLatencyHistogram = latency
+	SubmitNewBlockRedisExecLatencyHistogram = latency
 	if err != nil {
 		return err
 	}
@@ -279,3 +339,212 @@ func setupBuilderDemotionCount(_ context.Context) error {
 	}
 	return nil
 }
+
+func setupProposerEquivocationCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"proposer_equivocation_count",
+		otelapi.WithDescription("number of getPayload requests refused because another block hash was requested for the slot"),
+	)
+	ProposerEquivocationCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupRequestCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"request_count",
+		otelapi.WithDescription("number of http requests, by route, method and status code"),
+	)
+	RequestCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupRequestLatency(_ context.Context) error {
+	latency, err := meter.Float64Histogram(
+		"request_latency",
+		otelapi.WithDescription("statistics on the duration of http requests, by route and method"),
+		otelapi.WithUnit("ms"),
+		latencyBoundariesMs,
+	)
+	RequestLatencyHistogram = latency
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupBlockSimQueueSize(_ context.Context) error {
+	gauge, err := meter.Int64Gauge(
+		"block_sim_queue_size",
+		otelapi.WithDescription("number of waiting and active block simulation requests"),
+	)
+	BlockSimQueueSize = gauge
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupTopBidUpdateCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"top_bid_update_count",
+		otelapi.WithDescription("number of block submissions which updated the top bid"),
+	)
+	TopBidUpdateCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupValidatorRegistrationCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"validator_registration_count",
+		otelapi.WithDescription("number of received validator registrations, by result (new, unchanged, failed)"),
+	)
+	ValidatorRegistrationCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupRedisErrorCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"redis_error_count",
+		otelapi.WithDescription("number of failed redis operations, by operation"),
+	)
+	RedisErrorCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupDatabaseErrorCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"database_error_count",
+		otelapi.WithDescription("number of failed database operations, by operation"),
+	)
+	DatabaseErrorCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupBlockSubmissionDBQueueSize(_ context.Context) error {
+	gauge, err := meter.Int64Gauge(
+		"block_submission_db_queue_size",
+		otelapi.WithDescription("number of builder block submissions waiting to be saved to the database"),
+	)
+	BlockSubmissionDBQueueSize = gauge
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupBlockSubmissionDBDropCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"block_submission_db_drop_count",
+		otelapi.WithDescription("number of builder block submissions not saved to the database, by reason"),
+	)
+	BlockSubmissionDBDropCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupFilteredSubmissionCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"filtered_submission_count",
+		otelapi.WithDescription("number of block submissions rejected by the filter list"),
+	)
+	FilteredSubmissionCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupGetHeaderLogDBDropCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"get_header_log_db_drop_count",
+		otelapi.WithDescription("number of served getHeader bids not saved to the database, by reason"),
+	)
+	GetHeaderLogDBDropCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupRedisCommandLatency(_ context.Context) error {
+	latency, err := meter.Float64Histogram(
+		"redis_command_latency",
+		otelapi.WithDescription("statistics on the duration of redis commands and pipelines, by command and client"),
+		otelapi.WithUnit("ms"),
+		redisLatencyBoundariesMs,
+	)
+	RedisCommandLatencyHistogram = latency
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupDatabasePrunedRowCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"database_pruned_row_count",
+		otelapi.WithDescription("number of database rows deleted by the retention policy, by table"),
+	)
+	DatabasePrunedRowCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupRedisKeyCount(_ context.Context) error {
+	gauge, err := meter.Int64Gauge(
+		"redis_key_count",
+		otelapi.WithDescription("number of redis keys, by prefix"),
+	)
+	RedisKeyCount = gauge
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupRedisKeyMemoryUsage(_ context.Context) error {
+	gauge, err := meter.Int64Gauge(
+		"redis_key_memory_usage",
+		otelapi.WithDescription("memory usage of redis keys, by prefix"),
+		otelapi.WithUnit("By"),
+	)
+	RedisKeyMemoryUsage = gauge
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func setupRedisKeyExpirySetCount(_ context.Context) error {
+	counter, err := meter.Int64Counter(
+		"redis_key_expiry_set_count",
+		otelapi.WithDescription("number of slot specific redis keys without an expiration which were set to expire, by prefix"),
+	)
+	RedisKeyExpirySetCount = counter
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -3,14 +3,25 @@ package main
 // See also https://github.com/dvush/bls-vanity for creating vanity BLS keys!
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/mev-boost-relay/common"
+)
+
+var (
+	keystoreFile = flag.String("keystore", "", "if set, write the secret key to this EIP-2335 keystore file instead of printing it")
+	passwordFile = flag.String("password-file", "", "file with the password of the keystore (default: SECRET_KEY_PASSWORD env var)")
 )
 
 func main() {
+	flag.Parse()
+
 	sk := GenSecretKey()
 	// sk := SecretKeyFromHexString("0x")
 
@@ -20,11 +31,42 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
-	// Print secret key and public key
-	fmt.Printf("secret key: 0x%x\n", bls.SecretKeyToBytes(sk))
+	if *keystoreFile != "" {
+		WriteKeystore(sk, *keystoreFile, *passwordFile)
+		fmt.Printf("keystore:   %s\n", *keystoreFile)
+	} else {
+		fmt.Printf("secret key: 0x%x\n", bls.SecretKeyToBytes(sk))
+	}
 	fmt.Printf("public key: 0x%x\n", bls.PublicKeyToBytes(blsPubkey))
 }
 
+// WriteKeystore writes the secret key to a keystore file, encrypted with the password of the password file or env var
+func WriteKeystore(sk *bls.SecretKey, keystoreFile, passwordFile string) {
+	password := os.Getenv("SECRET_KEY_PASSWORD")
+	if passwordFile != "" {
+		var err error
+		password, err = common.ReadPasswordFile(passwordFile)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+	if password == "" {
+		log.Fatal("keystore password is required, use -password-file or SECRET_KEY_PASSWORD")
+	}
+
+	keystore, err := common.EncryptKeystore(sk, password)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	keystoreJSON, err := json.MarshalIndent(keystore, "", "  ")
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	if err := os.WriteFile(keystoreFile, keystoreJSON, 0o600); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
 // GenSecretKey generates a random secret key
 func GenSecretKey() *bls.SecretKey {
 	sk, _, err := bls.GenerateNewKeypair()
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/flashbots/go-utils/cli"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/holiman/uint256"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrMissingRelayFeePayment = errors.New("block does not pay the relay fee directly before the proposer payment")
+	ErrInvalidRelayFee        = errors.New("invalid relay fee")
+
+	// relay fee in basis points of the bid value, which is subtracted from the value served to proposers (0 to disable)
+	relayFeeBps = cli.GetEnvInt("BID_ADJUSTMENT_RELAY_FEE_BPS", 0)
+
+	// the transaction before the proposer payment must pay at least the relay fee to this address, required with a relay fee
+	relayFeeRecipient = os.Getenv("BID_ADJUSTMENT_RELAY_FEE_RECIPIENT")
+)
+
+// BidAdjuster is an optional step before the simulation of a block submission, which can rewrite the value of the bid
+// served to proposers. The adjusted value is used for the getHeader response, to check the floor and rank the bids, and
+// as the payment to the proposer which is checked by the simulation. It is recorded in the database and the bid traces
+// next to the submitted value.
+type BidAdjuster interface {
+	// AdjustBidValue returns the value of the bid served to proposers, or an error to reject the submission
+	AdjustBidValue(submission *common.BlockSubmissionInfo) (*uint256.Int, error)
+}
+
+// RelayFeeBidAdjuster subtracts a relay fee, in basis points of the bid value, from the value served to proposers. The
+// fee must be paid to the FeeRecipient by the transaction directly before the proposer payment, which stays the last
+// transaction of the block, and the rest of the value to the proposer.
+type RelayFeeBidAdjuster struct {
+	FeeBps       uint64
+	FeeRecipient ethcommon.Address
+}
+
+// newRelayFeeBidAdjusterFromEnv returns the relay fee bid adjuster configured by the environment, or nil if there is none
+func newRelayFeeBidAdjusterFromEnv(log *logrus.Entry) (*RelayFeeBidAdjuster, error) {
+	if relayFeeBps == 0 {
+		return nil, nil //nolint:nilnil
+	}
+	if relayFeeBps < 0 || relayFeeBps > 10_000 {
+		return nil, fmt.Errorf("%w: BID_ADJUSTMENT_RELAY_FEE_BPS must be between 0 and 10000: %d", ErrInvalidRelayFee, relayFeeBps)
+	}
+
+	// without a recipient the relay wouldn't collect the fee, and only under-report the bids
+	if !ethcommon.IsHexAddress(relayFeeRecipient) {
+		return nil, fmt.Errorf("%w: BID_ADJUSTMENT_RELAY_FEE_RECIPIENT must be set to a valid address: '%s'", ErrInvalidRelayFee, relayFeeRecipient)
+	}
+	adjuster := &RelayFeeBidAdjuster{
+		FeeBps:       uint64(relayFeeBps),
+		FeeRecipient: ethcommon.HexToAddress(relayFeeRecipient),
+	}
+
+	log.WithFields(logrus.Fields{
+		"feeBps":       adjuster.FeeBps,
+		"feeRecipient": relayFeeRecipient,
+	}).Warn("env: BID_ADJUSTMENT_RELAY_FEE_BPS - the relay fee is subtracted from the bid values served to proposers")
+	return adjuster, nil
+}
+
+func (a *RelayFeeBidAdjuster) AdjustBidValue(submission *common.BlockSubmissionInfo) (*uint256.Int, error) {
+	value := submission.BidTrace.Value
+	fee := new(uint256.Int).Mul(value, uint256.NewInt(a.FeeBps))
+	fee.Div(fee, uint256.NewInt(10_000))
+
+	// the proposer payment is the last transaction, as checked by the simulation and the proposer preferences
+	numTxs := len(submission.Transactions)
+	if numTxs < 2 {
+		return nil, ErrMissingRelayFeePayment
+	}
+	payment := new(types.Transaction)
+	err := payment.UnmarshalBinary(submission.Transactions[numTxs-1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMissingRelayFeePayment, err)
+	}
+	if payment.To() == nil || *payment.To() != ethcommon.Address(submission.BidTrace.ProposerFeeRecipient) {
+		return nil, fmt.Errorf("%w: the block must end with the proposer payment", ErrMissingRelayFeePayment)
+	}
+
+	tx := new(types.Transaction)
+	err = tx.UnmarshalBinary(submission.Transactions[numTxs-2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMissingRelayFeePayment, err)
+	}
+	if tx.To() == nil || *tx.To() != a.FeeRecipient || tx.Value().Cmp(fee.ToBig()) < 0 {
+		return nil, fmt.Errorf("%w: the relay fee is %s wei to %s", ErrMissingRelayFeePayment, fee.Dec(), a.FeeRecipient.Hex())
+	}
+
+	return new(uint256.Int).Sub(value, fee), nil
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayFeeBidAdjuster(t *testing.T) {
+	recipient := ethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	otherAddress := ethcommon.HexToAddress("0x2222222222222222222222222222222222222222")
+	proposerFeeRecipient := ethcommon.HexToAddress("0x3333333333333333333333333333333333333333")
+	submission := func(txs ...bellatrix.Transaction) *common.BlockSubmissionInfo {
+		return &common.BlockSubmissionInfo{
+			BidTrace:     &builderApiV1.BidTrace{Value: uint256.NewInt(1_000_000), ProposerFeeRecipient: bellatrix.ExecutionAddress(proposerFeeRecipient)},
+			Transactions: txs,
+		}
+	}
+	feeTx, _ := signedTestTransaction(t, recipient, 10_000)
+	lowFeeTx, _ := signedTestTransaction(t, recipient, 9_999)
+	paymentTx, _ := signedTestTransaction(t, proposerFeeRecipient, 990_000)
+	otherTx, _ := signedTestTransaction(t, otherAddress, 1)
+
+	t.Run("fee payment", func(t *testing.T) {
+		adjuster := &RelayFeeBidAdjuster{FeeBps: 100, FeeRecipient: recipient}
+		testCases := []struct {
+			name      string
+			txs       []bellatrix.Transaction
+			expectErr bool
+		}{
+			{name: "no transactions", expectErr: true},
+			{name: "fee paid before the proposer payment", txs: []bellatrix.Transaction{otherTx, feeTx, paymentTx}},
+			{name: "fee paid after the proposer payment", txs: []bellatrix.Transaction{otherTx, paymentTx, feeTx}, expectErr: true},
+			{name: "fee not paid directly before the proposer payment", txs: []bellatrix.Transaction{feeTx, otherTx, paymentTx}, expectErr: true},
+			{name: "no proposer payment", txs: []bellatrix.Transaction{otherTx, feeTx}, expectErr: true},
+			{name: "fee too low", txs: []bellatrix.Transaction{lowFeeTx, paymentTx}, expectErr: true},
+			{name: "invalid transaction", txs: []bellatrix.Transaction{{0x01, 0x02}, paymentTx}, expectErr: true},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				value, err := adjuster.AdjustBidValue(submission(tc.txs...))
+				if tc.expectErr {
+					require.ErrorIs(t, err, ErrMissingRelayFeePayment)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, uint256.NewInt(990_000), value)
+			})
+		}
+	})
+}
+
+func TestRelayFeeWithRequirePaymentLast(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	feeRecipient := ethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	proposerFeeRecipient := ethcommon.HexToAddress("0x3333333333333333333333333333333333333333")
+	adjuster := &RelayFeeBidAdjuster{FeeBps: 100, FeeRecipient: feeRecipient}
+	backend.relay.bidAdjuster = adjuster
+
+	slot := uint64(2)
+	pubkey := phase0.BLSPubKey{0x01}
+	backend.relay.proposerDutiesMap = map[uint64]*common.BuilderGetValidatorsResponseEntry{
+		slot: {Slot: slot, Preferences: &common.ProposerPreferences{Pubkey: pubkey, RequirePaymentLast: true}},
+	}
+
+	feeTx, _ := signedTestTransaction(t, feeRecipient, 10_000)
+	paymentTx, _ := signedTestTransaction(t, proposerFeeRecipient, 990_000)
+	submission := &common.BlockSubmissionInfo{
+		BidTrace:     &builderApiV1.BidTrace{Slot: slot, ProposerPubkey: pubkey, Value: uint256.NewInt(1_000_000), ProposerFeeRecipient: bellatrix.ExecutionAddress(proposerFeeRecipient)},
+		Transactions: []bellatrix.Transaction{feeTx, paymentTx},
+	}
+
+	// the relay fee directly before the proposer payment satisfies both
+	value, err := adjuster.AdjustBidValue(submission)
+	require.NoError(t, err)
+	require.Equal(t, uint256.NewInt(990_000), value)
+	require.True(t, backend.relay.checkSubmissionProposerPreferences(httptest.NewRecorder(), common.TestLog, submission))
+
+	// the relay fee after the proposer payment satisfies neither
+	submission.Transactions = []bellatrix.Transaction{paymentTx, feeTx}
+	_, err = adjuster.AdjustBidValue(submission)
+	require.ErrorIs(t, err, ErrMissingRelayFeePayment)
+	w := httptest.NewRecorder()
+	require.False(t, backend.relay.checkSubmissionProposerPreferences(w, common.TestLog, submission))
+	require.Contains(t, w.Body.String(), ErrProposerPaymentNotLast.Error())
+}
+
+func TestProposerPaymentOfAdjustedBid(t *testing.T) {
+	payload, _, _ := common.CreateTestBlockSubmission(t, "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83", uint256.NewInt(100), nil)
+
+	// the simulation checks the adjusted value as the proposer payment, without changing the submission
+	req := &common.BuilderBlockValidationRequest{
+		VersionedSubmitBlockRequest: payload,
+		ParentBeaconBlockRoot:       nil,
+		RegisteredGasLimit:          30_000_000,
+		ProposerPayment:             uint256.NewInt(90),
+	}
+	reqJSON, err := json.Marshal(req)
+	require.NoError(t, err)
+	decoded := struct {
+		Message builderApiV1.BidTrace `json:"message"`
+	}{}
+	require.NoError(t, json.Unmarshal(reqJSON, &decoded))
+	require.Equal(t, uint256.NewInt(90), decoded.Message.Value)
+	require.Equal(t, uint256.NewInt(100), payload.Capella.Message.Value)
+}
+
+func TestUpdateRedisBidAdjustedValue(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	opts := common.CreateTestBlockSubmissionOpts{Slot: 2, ParentHash: "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747", ProposerPubkey: "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"}
+	payload, _, _ := common.CreateTestBlockSubmission(t, "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83", uint256.NewInt(100), &opts)
+
+	updateResp, _, ok := backend.relay.updateRedisBid(redisUpdateBidOpts{
+		ctx:                  context.Background(),
+		w:                    httptest.NewRecorder(),
+		tx:                   backend.redis.NewTxPipeline(),
+		log:                  common.TestLog,
+		cancellationsEnabled: true,
+		floorBidValue:        big.NewInt(0),
+		payload:              payload,
+		adjustedValue:        uint256.NewInt(90),
+	})
+	require.True(t, ok)
+	require.Equal(t, big.NewInt(90), updateResp.TopBidValue)
+
+	// the adjusted value is served to proposers, and recorded in the bid trace next to the submitted value
+	bid, err := backend.redis.GetBestBid(context.Background(), opts.Slot, opts.ParentHash, opts.ProposerPubkey)
+	require.NoError(t, err)
+	value, err := bid.Value()
+	require.NoError(t, err)
+	require.Equal(t, uint256.NewInt(90), value)
+
+	bidTrace, err := backend.redis.GetBidTrace(opts.Slot, opts.ProposerPubkey, payload.Capella.Message.BlockHash.String())
+	require.NoError(t, err)
+	require.Equal(t, uint256.NewInt(100), bidTrace.Value)
+	require.Equal(t, uint256.NewInt(90), bidTrace.AdjustedValue)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"sync"
 	"sync/atomic"
@@ -26,79 +27,151 @@ var (
 	ErrNoDenebPayload   = errors.New("deneb payload is nil")
 	ErrNoElectraPayload = errors.New("electra payload is nil")
 
-	maxConcurrentBlocks = int64(cli.GetEnvInt("BLOCKSIM_MAX_CONCURRENT", 4)) // 0 for no maximum
-	simRequestTimeout   = time.Duration(cli.GetEnvInt("BLOCKSIM_TIMEOUT_MS", 10000)) * time.Millisecond
+	maxConcurrentBlocks         = int64(cli.GetEnvInt("BLOCKSIM_MAX_CONCURRENT", 4))                            // low-prio tier, 0 for no maximum
+	maxConcurrentHighPrioBlocks = int64(cli.GetEnvInt("BLOCKSIM_MAX_CONCURRENT_HIGH_PRIO", 4))                  // high-prio tier, 0 for no maximum
+	simRequestTimeout           = time.Duration(cli.GetEnvInt("BLOCKSIM_TIMEOUT_MS", 10000)) * time.Millisecond // per simulation request, 0 for no timeout
+
+	// http client connection pool settings
+	simMaxIdleConns    = cli.GetEnvInt("BLOCKSIM_MAX_IDLE_CONNS", 100)
+	simMaxConnsPerHost = cli.GetEnvInt("BLOCKSIM_MAX_CONNS_PER_HOST", 100) // 0 for no maximum
+	simIdleConnTimeout = time.Duration(cli.GetEnvInt("BLOCKSIM_IDLE_CONN_TIMEOUT_SEC", 90)) * time.Second
 )
 
 type IBlockSimRateLimiter interface {
-	Send(context context.Context, payload *common.BuilderBlockValidationRequest, isHighPrio, fastTrack bool) (*common.BuilderBlockValidationResponse, error, error)
+	Send(context context.Context, payload *common.BuilderBlockValidationRequest, isHighPrio, fastTrack bool) (*common.BuilderBlockValidationResponse, time.Duration, error, error)
 	CurrentCounter() int64
 }
 
+// blockSimQueue limits the number of concurrent simulations of a single priority tier
+type blockSimQueue struct {
+	cv            *sync.Cond
+	counter       int64 // waiting and active requests
+	active        int64 // active requests
+	maxConcurrent int64 // 0 for no maximum
+}
+
+func newBlockSimQueue(maxConcurrent int64) *blockSimQueue {
+	return &blockSimQueue{
+		cv:            sync.NewCond(&sync.Mutex{}),
+		counter:       0,
+		active:        0,
+		maxConcurrent: maxConcurrent,
+	}
+}
+
+// acquire blocks until a simulation slot of this tier is available, or the context is done
+func (q *blockSimQueue) acquire(ctx context.Context) error {
+	// wake up all waiters when the context is done, so they can check for cancellation
+	stop := context.AfterFunc(ctx, func() {
+		q.cv.L.Lock()
+		q.cv.Broadcast()
+		q.cv.L.Unlock()
+	})
+	defer stop()
+
+	q.cv.L.Lock()
+	defer q.cv.L.Unlock()
+	atomic.AddInt64(&q.counter, 1)
+	for q.maxConcurrent > 0 && q.active >= q.maxConcurrent {
+		if err := ctx.Err(); err != nil {
+			atomic.AddInt64(&q.counter, -1)
+			return err
+		}
+		q.cv.Wait()
+	}
+	q.active++
+	return nil
+}
+
+// release frees a simulation slot and wakes up the next waiting request
+func (q *blockSimQueue) release() {
+	q.cv.L.Lock()
+	q.active--
+	atomic.AddInt64(&q.counter, -1)
+	q.cv.Signal()
+	q.cv.L.Unlock()
+}
+
+// BlockSimulationRateLimiter sends block simulation requests to the block
+// simulator. High-prio and low-prio builders use separate queues with their own
+// concurrency limits, so that high-prio submissions are never stuck behind a
+// backlog of low-prio ones.
 type BlockSimulationRateLimiter struct {
-	cv          *sync.Cond
-	counter     int64
-	blockSimURL string
-	client      http.Client
+	highPrioQueue *blockSimQueue
+	lowPrioQueue  *blockSimQueue
+	blockSimURL   string
+	client        http.Client
 }
 
 func NewBlockSimulationRateLimiter(blockSimURL string) *BlockSimulationRateLimiter {
 	return &BlockSimulationRateLimiter{
-		cv:          sync.NewCond(&sync.Mutex{}),
-		counter:     0,
-		blockSimURL: blockSimURL,
-		client: http.Client{ //nolint:exhaustruct
-			Timeout: simRequestTimeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        100,
-				MaxIdleConnsPerHost: 100,
-				MaxConnsPerHost:     100,
-				IdleConnTimeout:     90 * time.Second,
-			},
+		highPrioQueue: newBlockSimQueue(maxConcurrentHighPrioBlocks),
+		lowPrioQueue:  newBlockSimQueue(maxConcurrentBlocks),
+		blockSimURL:   blockSimURL,
+		client:        newBlockSimHTTPClient(),
+	}
+}
+
+// newBlockSimHTTPClient returns a http client which keeps connections to the block simulator alive,
+// to avoid TCP and TLS handshakes on every simulation request
+func newBlockSimHTTPClient() http.Client {
+	return http.Client{ //nolint:exhaustruct
+		Transport: &http.Transport{ //nolint:exhaustruct
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{ //nolint:exhaustruct
+				Timeout:   5 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        simMaxIdleConns,
+			MaxIdleConnsPerHost: simMaxIdleConns, // all requests go to the same host
+			MaxConnsPerHost:     simMaxConnsPerHost,
+			IdleConnTimeout:     simIdleConnTimeout,
 		},
 	}
 }
 
+// Send simulates the block once there is a free slot in the queue of the priority tier. It also returns the
+// time spent waiting in the queue.
 func (b *BlockSimulationRateLimiter) Send(
 	context context.Context,
 	payload *common.BuilderBlockValidationRequest,
 	isHighPrio,
 	fastTrack bool,
-) (response *common.BuilderBlockValidationResponse, requestErr, validationErr error) {
-	b.cv.L.Lock()
-	cnt := atomic.AddInt64(&b.counter, 1)
-	if maxConcurrentBlocks > 0 && cnt > maxConcurrentBlocks {
-		b.cv.Wait()
+) (response *common.BuilderBlockValidationResponse, queueWait time.Duration, requestErr, validationErr error) {
+	queue := b.lowPrioQueue
+	if isHighPrio {
+		queue = b.highPrioQueue
 	}
-	b.cv.L.Unlock()
-
-	defer func() {
-		b.cv.L.Lock()
-		atomic.AddInt64(&b.counter, -1)
-		b.cv.Signal()
-		b.cv.L.Unlock()
-	}()
+	timeBeforeQueue := time.Now()
+	err := queue.acquire(context)
+	queueWait = time.Since(timeBeforeQueue)
+	if err != nil {
+		return nil, queueWait, fmt.Errorf("%w, %w", ErrRequestClosed, err), nil
+	}
+	defer queue.release()
 
 	if err := context.Err(); err != nil {
-		return nil, fmt.Errorf("%w, %w", ErrRequestClosed, err), nil
+		return nil, queueWait, fmt.Errorf("%w, %w", ErrRequestClosed, err), nil
 	}
 
 	var simReq *jsonrpc.JSONRPCRequest
 	if payload.Version == spec.DataVersionCapella && payload.Capella == nil {
-		return nil, ErrNoCapellaPayload, nil
+		return nil, queueWait, ErrNoCapellaPayload, nil
 	}
 
 	if payload.Version == spec.DataVersionDeneb && payload.Deneb == nil {
-		return nil, ErrNoDenebPayload, nil
+		return nil, queueWait, ErrNoDenebPayload, nil
 	}
 
 	if payload.Version == spec.DataVersionElectra && payload.Electra == nil {
-		return nil, ErrNoElectraPayload, nil
+		return nil, queueWait, ErrNoElectraPayload, nil
 	}
 
 	submission, err := common.GetBlockSubmissionInfo(payload.VersionedSubmitBlockRequest)
 	if err != nil {
-		return nil, err, nil
+		return nil, queueWait, err, nil
 	}
 
 	// Prepare headers
@@ -119,29 +192,33 @@ func (b *BlockSimulationRateLimiter) Send(
 	} else {
 		simReq = jsonrpc.NewJSONRPCRequest("1", "flashbots_validateBuilderSubmissionV2", payload)
 	}
-	res, requestErr, validationErr := SendJSONRPCRequest(&b.client, *simReq, b.blockSimURL, headers)
+	// Apply the timeout only to the simulation itself, not to the time spent waiting in the queue
+	simCtx, cancel := ctxWithTimeout(context, simRequestTimeout)
+	defer cancel()
+	res, requestErr, validationErr := SendJSONRPCRequest(simCtx, &b.client, *simReq, b.blockSimURL, headers)
 	response = new(common.BuilderBlockValidationResponse)
 	if res != nil {
 		if err := json.Unmarshal(res.Result, response); err != nil {
-			return nil, fmt.Errorf("unable to unmarshal response: %w", err), validationErr
+			return nil, queueWait, fmt.Errorf("unable to unmarshal response: %w", err), validationErr
 		}
 	}
-	return response, requestErr, validationErr
+	return response, queueWait, requestErr, validationErr
 }
 
-// CurrentCounter returns the number of waiting and active requests
+// CurrentCounter returns the number of waiting and active requests (of both tiers)
 func (b *BlockSimulationRateLimiter) CurrentCounter() int64 {
-	return atomic.LoadInt64(&b.counter)
+	return atomic.LoadInt64(&b.highPrioQueue.counter) + atomic.LoadInt64(&b.lowPrioQueue.counter)
 }
 
-// SendJSONRPCRequest sends the request to URL and returns the general JsonRpcResponse, or an error (note: not the JSONRPCError)
-func SendJSONRPCRequest(client *http.Client, req jsonrpc.JSONRPCRequest, url string, headers http.Header) (res *jsonrpc.JSONRPCResponse, requestErr, validationErr error) {
+// SendJSONRPCRequest sends the request to URL and returns the general JsonRpcResponse, or an error (note: not the JSONRPCError).
+// The request is aborted once ctx is done.
+func SendJSONRPCRequest(ctx context.Context, client *http.Client, req jsonrpc.JSONRPCRequest, url string, headers http.Header) (res *jsonrpc.JSONRPCResponse, requestErr, validationErr error) {
 	buf, err := json.Marshal(req)
 	if err != nil {
 		return nil, err, nil
 	}
 
-	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(buf))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(buf))
 	if err != nil {
 		return nil, err, nil
 	}
@@ -176,3 +253,11 @@ func SendJSONRPCRequest(client *http.Client, req jsonrpc.JSONRPCRequest, url str
 	}
 	return res, nil, nil
 }
+
+// ctxWithTimeout returns a context with the given timeout, or a cancelable context without deadline if timeout is 0
+func ctxWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flashbots/go-utils/jsonrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockSimQueue(t *testing.T) {
+	t.Run("blocks when the tier is full", func(t *testing.T) {
+		q := newBlockSimQueue(1)
+		require.NoError(t, q.acquire(t.Context()))
+
+		acquired := make(chan struct{})
+		go func() {
+			if err := q.acquire(t.Context()); err == nil {
+				close(acquired)
+			}
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("acquired slot although queue is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.Equal(t, int64(2), atomic.LoadInt64(&q.counter))
+
+		q.release()
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			t.Fatal("slot was not released")
+		}
+		q.release()
+		require.Equal(t, int64(0), atomic.LoadInt64(&q.counter))
+	})
+
+	t.Run("stops waiting when the context is canceled", func(t *testing.T) {
+		q := newBlockSimQueue(1)
+		require.NoError(t, q.acquire(t.Context()))
+
+		ctx, cancel := context.WithCancel(t.Context())
+		errC := make(chan error, 1)
+		go func() {
+			errC <- q.acquire(ctx)
+		}()
+		cancel()
+
+		select {
+		case err := <-errC:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("acquire did not return after cancellation")
+		}
+		require.Equal(t, int64(1), atomic.LoadInt64(&q.counter))
+	})
+
+	t.Run("no maximum", func(t *testing.T) {
+		q := newBlockSimQueue(0)
+		for range 10 {
+			require.NoError(t, q.acquire(t.Context()))
+		}
+		require.Equal(t, int64(10), atomic.LoadInt64(&q.counter))
+	})
+}
+
+func TestBlockSimulationRateLimiterTiers(t *testing.T) {
+	b := NewBlockSimulationRateLimiter("")
+	b.lowPrioQueue = newBlockSimQueue(1)
+	b.highPrioQueue = newBlockSimQueue(1)
+
+	// a full low-prio tier must not block high-prio requests
+	require.NoError(t, b.lowPrioQueue.acquire(t.Context()))
+	acquired := make(chan struct{})
+	go func() {
+		if err := b.highPrioQueue.acquire(t.Context()); err == nil {
+			close(acquired)
+		}
+	}()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("high-prio request blocked by low-prio queue")
+	}
+	require.Equal(t, int64(2), b.CurrentCounter())
+}
+
+func TestBlockSimHTTPClientReusesConnections(t *testing.T) {
+	var numConns int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+	}))
+	srv.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&numConns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := newBlockSimHTTPClient()
+	for range 5 {
+		req := jsonrpc.NewJSONRPCRequest("1", "flashbots_validateBuilderSubmissionV3", nil)
+		_, requestErr, validationErr := SendJSONRPCRequest(t.Context(), &client, *req, srv.URL, http.Header{})
+		require.NoError(t, requestErr)
+		require.NoError(t, validationErr)
+	}
+	require.Equal(t, int64(1), atomic.LoadInt64(&numConns))
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/flashbots/go-utils/cli"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrRequestBodyTooLarge = errors.New("request body too large")
+
+var (
+	// request body limits of the routes, block submissions are limited by the MaxPayloadBytes option
+	getPayloadMaxBodyBytes        = cli.GetEnvInt("GETPAYLOAD_MAX_BODY_BYTES", 1024*1024)            // 1 MiB
+	registerValidatorMaxBodyBytes = cli.GetEnvInt("REGISTER_VALIDATOR_MAX_BODY_BYTES", 32*1024*1024) // 32 MiB
+	apiMaxBodyBytes               = cli.GetEnvInt("API_MAX_BODY_BYTES", 1024*1024)                   // 1 MiB, all other routes
+)
+
+// maxBodyBytes returns the request body limit of the route with the given path template
+func (api *RelayAPI) maxBodyBytes(path string) int64 {
+	switch path {
+	case pathSubmitNewBlock:
+		return int64(api.opts.MaxPayloadBytes)
+	case pathRegisterValidator:
+		return int64(registerValidatorMaxBodyBytes)
+	case pathGetPayload:
+		return int64(getPayloadMaxBodyBytes)
+	case pathDataValidatorRegistration:
+		return int64(dataValidatorRegistrationsMaxBodyBytes)
+	default:
+		return int64(apiMaxBodyBytes)
+	}
+}
+
+// bodyLimitMiddleware rejects requests with a larger Content-Length than the limit of the route, and limits reading
+// the body of chunked requests (reads past the limit fail with http.MaxBytesError)
+func (api *RelayAPI) bodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		if route := mux.CurrentRoute(req); route != nil {
+			if tpl, err := route.GetPathTemplate(); err == nil {
+				path = tpl
+			}
+		}
+
+		limit := api.maxBodyBytes(path)
+		if req.ContentLength > limit {
+			api.log.WithFields(logrus.Fields{
+				"path":          req.URL.Path,
+				"contentLength": req.ContentLength,
+				"limit":         limit,
+			}).Warn("request body too large")
+			api.RespondError(w, http.StatusRequestEntityTooLarge, ErrRequestBodyTooLarge.Error())
+			return
+		}
+		req.Body = http.MaxBytesReader(w, req.Body, limit)
+		next.ServeHTTP(w, req)
+	})
+}
+
+// isMaxBytesError returns true if reading a request body failed because it exceeded the limit of the route
+func isMaxBytesError(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
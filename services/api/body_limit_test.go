@@ -0,0 +1,38 @@
+package api
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	require.Equal(t, int64(backend.relay.opts.MaxPayloadBytes), backend.relay.maxBodyBytes(pathSubmitNewBlock))
+	require.Equal(t, int64(registerValidatorMaxBodyBytes), backend.relay.maxBodyBytes(pathRegisterValidator))
+	require.Equal(t, int64(getPayloadMaxBodyBytes), backend.relay.maxBodyBytes(pathGetPayload))
+	require.Equal(t, int64(apiMaxBodyBytes), backend.relay.maxBodyBytes(pathProposerPreferences))
+
+	tooLarge := bytes.Repeat([]byte{' '}, getPayloadMaxBodyBytes+1)
+
+	// rejected by the Content-Length
+	rr := backend.requestBytes(http.MethodPost, pathGetPayload, tooLarge, nil)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	// rejected while reading a body without Content-Length
+	req, err := http.NewRequest(http.MethodPost, pathGetPayload, io.MultiReader(bytes.NewReader(tooLarge)))
+	require.NoError(t, err)
+	require.Equal(t, int64(0), req.ContentLength)
+	req.ContentLength = -1
+	rr = httptest.NewRecorder()
+	backend.relay.getRouter().ServeHTTP(rr, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	// small bodies are handled as before
+	rr = backend.requestBytes(http.MethodPost, pathGetPayload, []byte("{}"), nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrInvalidCIDR = errors.New("invalid CIDR")
+
+var (
+	// comma separated CIDRs (or single IPs) of clients which may use the builder API, all if empty
+	builderAPIAllowedCIDRs = os.Getenv("BUILDER_API_ALLOWED_CIDRS")
+	// comma separated CIDRs (or single IPs) of clients which may not use the builder API, also if they are allowed
+	builderAPIDeniedCIDRs = os.Getenv("BUILDER_API_DENIED_CIDRS")
+)
+
+// builderIPFilter holds the CIDR allow and deny lists of the builder API
+type builderIPFilter struct {
+	Allowed []netip.Prefix `json:"allowed"`
+	Denied  []netip.Prefix `json:"denied"`
+}
+
+// parseCIDRList parses a comma separated list of CIDRs, where single IPs are taken as CIDRs of just that IP
+func parseCIDRList(s string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidCIDR, entry)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidCIDR, entry)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// newBuilderIPFilter parses the comma separated allow and deny lists
+func newBuilderIPFilter(allowed, denied string) (*builderIPFilter, error) {
+	allowedPrefixes, err := parseCIDRList(allowed)
+	if err != nil {
+		return nil, err
+	}
+	deniedPrefixes, err := parseCIDRList(denied)
+	if err != nil {
+		return nil, err
+	}
+	return &builderIPFilter{Allowed: allowedPrefixes, Denied: deniedPrefixes}, nil
+}
+
+func (f *builderIPFilter) isEmpty() bool {
+	return len(f.Allowed) == 0 && len(f.Denied) == 0
+}
+
+// isAllowed returns false if the IP is in the deny list, or if there is an allow list which doesn't contain it.
+// Unparsable IPs are only allowed without any lists.
+func (f *builderIPFilter) isAllowed(ip string) bool {
+	if f.isEmpty() {
+		return true
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range f.Denied {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	if len(f.Allowed) == 0 {
+		return true
+	}
+	for _, prefix := range f.Allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateBuilderIPFilter applies the builder IP filter from Redis, or the one from the environment if none is set
+func (api *RelayAPI) updateBuilderIPFilter() error {
+	value, err := api.redis.GetRelayConfig(datastore.RedisConfigFieldBuilderIPFilter)
+	if err != nil {
+		return err
+	}
+
+	filter := api.builderIPFilterDefault
+	if value != "" {
+		filter = new(builderIPFilter)
+		if err := json.Unmarshal([]byte(value), filter); err != nil {
+			return err
+		}
+	}
+	api.builderIPFilter.Store(filter)
+	return nil
+}
+
+func (api *RelayAPI) startBuilderIPFilterPoller() {
+	ticker := time.NewTicker(featureFlagsPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := api.updateBuilderIPFilter()
+		if err != nil {
+			api.log.WithError(err).Error("failed to update builder IP filter")
+		}
+	}
+}
+
+// builderIPFilterMiddleware responds with 403 to builder API requests from clients which are not allowed
+func (api *RelayAPI) builderIPFilterMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ip := clientIP(req, api.trustedProxies)
+		if !api.builderIPFilter.Load().isAllowed(ip) {
+			api.log.WithFields(logrus.Fields{
+				"path":     req.URL.Path,
+				"clientIP": ip,
+			}).Info("builder API request from a filtered IP")
+			api.RespondError(w, http.StatusForbidden, "forbidden")
+			return
+		}
+		next(w, req)
+	}
+}
+
+// handleInternalBuilderIPFilter returns the builder IP filter. On POST/PUT the allow and deny lists are replaced with
+// the comma separated CIDRs of the allowed and denied query arguments, and DELETE restores the lists from the
+// environment. Changes are stored in Redis and apply to all API instances.
+func (api *RelayAPI) handleInternalBuilderIPFilter(w http.ResponseWriter, req *http.Request) {
+	var err error
+	switch req.Method {
+	case http.MethodGet:
+		api.RespondOK(w, api.builderIPFilter.Load())
+		return
+	case http.MethodDelete:
+		err = api.redis.DelRelayConfig(datastore.RedisConfigFieldBuilderIPFilter)
+	default:
+		args := req.URL.Query()
+		filter, parseErr := newBuilderIPFilter(args.Get("allowed"), args.Get("denied"))
+		if parseErr != nil {
+			api.RespondError(w, http.StatusBadRequest, parseErr.Error())
+			return
+		}
+		value, _ := json.Marshal(filter)
+		err = api.redis.SetRelayConfig(datastore.RedisConfigFieldBuilderIPFilter, string(value))
+	}
+	if err != nil {
+		api.log.WithError(err).Error("could not set builder IP filter")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = api.updateBuilderIPFilter()
+	if err != nil {
+		api.log.WithError(err).Error("could not update builder IP filter")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.RespondOK(w, api.builderIPFilter.Load())
+}
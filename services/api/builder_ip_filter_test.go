@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCIDRList(t *testing.T) {
+	prefixes, err := parseCIDRList("")
+	require.NoError(t, err)
+	require.Empty(t, prefixes)
+
+	prefixes, err = parseCIDRList("10.1.2.3/8, 192.168.0.1,2001:db8::/32")
+	require.NoError(t, err)
+	require.Len(t, prefixes, 3)
+	require.Equal(t, "10.0.0.0/8", prefixes[0].String())
+	require.Equal(t, "192.168.0.1/32", prefixes[1].String())
+	require.Equal(t, "2001:db8::/32", prefixes[2].String())
+
+	_, err = parseCIDRList("10.0.0.0/33")
+	require.ErrorIs(t, err, ErrInvalidCIDR)
+
+	_, err = parseCIDRList("localhost")
+	require.ErrorIs(t, err, ErrInvalidCIDR)
+}
+
+func TestBuilderIPFilter(t *testing.T) {
+	filter, err := newBuilderIPFilter("", "")
+	require.NoError(t, err)
+	require.True(t, filter.isAllowed("10.0.0.1"))
+	require.True(t, filter.isAllowed("invalid"))
+
+	filter, err = newBuilderIPFilter("", "10.0.0.0/8")
+	require.NoError(t, err)
+	require.False(t, filter.isAllowed("10.0.0.1"))
+	require.False(t, filter.isAllowed("::ffff:10.0.0.1"))
+	require.True(t, filter.isAllowed("192.168.0.1"))
+	require.False(t, filter.isAllowed("invalid"))
+
+	filter, err = newBuilderIPFilter("10.0.0.0/8", "10.0.0.2")
+	require.NoError(t, err)
+	require.True(t, filter.isAllowed("10.0.0.1"))
+	require.False(t, filter.isAllowed("10.0.0.2"))
+	require.False(t, filter.isAllowed("192.168.0.1"))
+}
+
+func TestInternalBuilderIPFilter(t *testing.T) {
+	backend := newTestBackend(t, 1)
+
+	getValidators := func(ip string) int {
+		req, err := http.NewRequest(http.MethodGet, pathBuilderGetValidators, nil)
+		require.NoError(t, err)
+		req.RemoteAddr = ip + ":1234"
+		rr := httptest.NewRecorder()
+		backend.relay.getRouter().ServeHTTP(rr, req)
+		return rr.Code
+	}
+	require.Equal(t, http.StatusOK, getValidators("192.168.0.1"))
+
+	// invalid CIDRs are rejected
+	rr := backend.request(http.MethodPost, pathInternalBuilderIPFilter+"?allowed=10.0.0.0/33", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// only allowed IPs, which are not denied, can use the builder API
+	rr = backend.request(http.MethodPost, pathInternalBuilderIPFilter+"?allowed=10.0.0.0/8&denied=10.0.0.2", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	filter := new(builderIPFilter)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), filter))
+	require.Len(t, filter.Allowed, 1)
+	require.Len(t, filter.Denied, 1)
+	require.Equal(t, http.StatusOK, getValidators("10.0.0.1"))
+	require.Equal(t, http.StatusForbidden, getValidators("10.0.0.2"))
+	require.Equal(t, http.StatusForbidden, getValidators("192.168.0.1"))
+
+	// the lists are kept in Redis for the other instances
+	backend.relay.builderIPFilter.Store(backend.relay.builderIPFilterDefault)
+	require.NoError(t, backend.relay.updateBuilderIPFilter())
+	require.Equal(t, http.StatusForbidden, getValidators("192.168.0.1"))
+
+	// the lists from the environment are restored
+	rr = backend.request(http.MethodDelete, pathInternalBuilderIPFilter, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, http.StatusOK, getValidators("192.168.0.1"))
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// comma separated CIDRs (or single IPs) of the load balancers in front of the relay, whose X-Forwarded-For header is
+// trusted. Without it, the client IP is always the address of the connection.
+var trustedProxyCIDRs = os.Getenv("TRUSTED_PROXY_CIDRS")
+
+// clientIP returns the IP address of the client, used for the data API rate limit and the builder IP filter. The
+// X-Forwarded-For header is only used for connections from a trusted proxy, and is read from the right, skipping the
+// entries added by trusted proxies, as anything further left can be set by the client.
+func clientIP(req *http.Request, trustedProxies []netip.Prefix) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		ip = req.RemoteAddr
+	}
+	if !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+
+	entries := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := strings.TrimSpace(entries[i])
+		if entry == "" {
+			continue
+		}
+		ip = entry
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	return ip
+}
+
+func isTrustedProxy(ip string, trustedProxies []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+)
+
+var (
+	// maximum number of concurrent requests per route group (0 for no limit). The getHeader and getPayload requests of
+	// the proposer API have their own limit, so status checks and validator registrations can't take their slots.
+	proposerAPIMaxConcurrent            = cli.GetEnvInt("PROPOSER_API_MAX_CONCURRENT_REQUESTS", 0)
+	proposerAPINonCriticalMaxConcurrent = cli.GetEnvInt("PROPOSER_API_NONCRITICAL_MAX_CONCURRENT_REQUESTS", 0)
+	builderAPIMaxConcurrent             = cli.GetEnvInt("BUILDER_API_MAX_CONCURRENT_REQUESTS", 0)
+	dataAPIMaxConcurrent                = cli.GetEnvInt("DATA_API_MAX_CONCURRENT_REQUESTS", 0)
+
+	// getHeader and getPayload requests wait this long for a free slot before they are rejected, as missing them is
+	// worse than a short delay. Requests to the other routes are rejected immediately.
+	proposerAPIQueueTimeout = time.Duration(cli.GetEnvInt("PROPOSER_API_QUEUE_TIMEOUT_MS", 500)) * time.Millisecond
+)
+
+// concurrencyLimiter limits the number of requests of a route group which are processed at once
+type concurrencyLimiter struct {
+	slots        chan struct{}
+	queueTimeout time.Duration // how long requests wait for a free slot, 0 to reject them immediately
+}
+
+// newConcurrencyLimiter returns nil if the maximum is 0, which disables the limit
+func newConcurrencyLimiter(maxConcurrent int, queueTimeout time.Duration) *concurrencyLimiter {
+	if maxConcurrent <= 0 {
+		return nil
+	}
+	return &concurrencyLimiter{
+		slots:        make(chan struct{}, maxConcurrent),
+		queueTimeout: queueTimeout,
+	}
+}
+
+// acquire takes a slot, waiting at most the queue timeout (or until the request is closed), and returns false if
+// none became free. Acquired slots must be released.
+func (l *concurrencyLimiter) acquire(req *http.Request) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if l.queueTimeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(l.queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.slots
+}
+
+// concurrencyLimitMiddleware responds with 429 if the limiter has no free slot for the request
+func (api *RelayAPI) concurrencyLimitMiddleware(limiter *concurrencyLimiter, next http.HandlerFunc) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !limiter.acquire(req) {
+			w.Header().Set("Retry-After", "1")
+			api.RespondErrorCode(w, http.StatusTooManyRequests, ErrorCodeTooManyConcurrent, "too many concurrent requests")
+			return
+		}
+		defer limiter.release()
+		next(w, req)
+	}
+}
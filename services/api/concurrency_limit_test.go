@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	require.Nil(t, newConcurrencyLimiter(0, time.Second))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// without a queue timeout, requests are rejected while all slots are taken
+	limiter := newConcurrencyLimiter(2, 0)
+	require.True(t, limiter.acquire(req))
+	require.True(t, limiter.acquire(req))
+	require.False(t, limiter.acquire(req))
+	limiter.release()
+	require.True(t, limiter.acquire(req))
+
+	// with a queue timeout, requests wait for a slot to be released
+	limiter = newConcurrencyLimiter(1, time.Second)
+	require.True(t, limiter.acquire(req))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.release()
+	}()
+	require.True(t, limiter.acquire(req))
+
+	// but not longer than the timeout, or the request is closed
+	limiter = newConcurrencyLimiter(1, 10*time.Millisecond)
+	require.True(t, limiter.acquire(req))
+	require.False(t, limiter.acquire(req))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.queueTimeout = time.Minute
+	require.False(t, limiter.acquire(req.WithContext(ctx)))
+}
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	limiter := newConcurrencyLimiter(1, 0)
+
+	started, done := make(chan struct{}), make(chan struct{})
+	handler := backend.relay.concurrencyLimitMiddleware(limiter, func(w http.ResponseWriter, req *http.Request) {
+		close(started)
+		<-done
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rr1 := httptest.NewRecorder()
+	go handler(rr1, httptest.NewRequest(http.MethodGet, "/", nil))
+	<-started
+
+	// the second request is rejected while the first one is processed
+	rr2 := httptest.NewRecorder()
+	handler(rr2, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusTooManyRequests, rr2.Code)
+	require.Contains(t, rr2.Body.String(), string(ErrorCodeTooManyConcurrent))
+	close(done)
+
+	// and the slot is released afterwards
+	require.Eventually(t, func() bool {
+		return limiter.acquire(httptest.NewRequest(http.MethodGet, "/", nil))
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestProposerAPILimiters(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.proposerAPILimiter = newConcurrencyLimiter(1, 0)
+	backend.relay.proposerAPINonCriticalLimiter = newConcurrencyLimiter(1, 0)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	// status checks are rejected while the non-critical slots are taken, but getHeader isn't affected
+	require.True(t, backend.relay.proposerAPINonCriticalLimiter.acquire(req))
+	rr := backend.request(http.MethodGet, pathStatus, nil)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	rr = backend.request(http.MethodGet, "/eth/v1/builder/header/1/0x00/0xaa", nil)
+	require.NotEqual(t, http.StatusTooManyRequests, rr.Code)
+	backend.relay.proposerAPINonCriticalLimiter.release()
+
+	// and the other way around
+	require.True(t, backend.relay.proposerAPILimiter.acquire(req))
+	rr = backend.request(http.MethodGet, "/eth/v1/builder/header/1/0x00/0xaa", nil)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	rr = backend.request(http.MethodGet, pathStatus, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/flashbots/go-utils/cli"
+	"github.com/flashbots/mev-boost-relay/common"
+)
+
+// how long clients may use data API responses without revalidating them (0 to always revalidate with the ETag)
+var dataAPICacheMaxAgeSec = cli.GetEnvInt("DATA_API_CACHE_MAX_AGE_SEC", 0)
+
+// bufferedResponseWriter keeps the response in memory, to be sent after the ETag was computed
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return b.body.Write(data)
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	b.status = status
+}
+
+// dataAPICacheMiddleware adds a weak ETag (a hash of the response) and Cache-Control headers to successful data API
+// responses, and responds with 304 if the ETag matches the If-None-Match header of the request
+func (api *RelayAPI) dataAPICacheMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		buf := &bufferedResponseWriter{header: w.Header(), status: http.StatusOK}
+		next(buf, req)
+
+		if buf.status == http.StatusOK {
+			etag := common.WeakETag(buf.body.Bytes())
+			w.Header().Set("ETag", etag)
+			if dataAPICacheMaxAgeSec > 0 {
+				w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", dataAPICacheMaxAgeSec))
+			} else {
+				w.Header().Set("Cache-Control", "no-cache")
+			}
+
+			if common.ETagMatches(req.Header.Get("If-None-Match"), etag) {
+				w.Header().Del("Content-Type")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+
+		w.WriteHeader(buf.status)
+		if _, err := w.Write(buf.body.Bytes()); err != nil {
+			api.log.WithError(err).Error("Couldn't write response")
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataAPICacheMiddleware(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	path := pathDataProposerPayloadDelivered + "?slot=1"
+
+	rr := backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	require.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+	require.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+	require.Equal(t, "[]\n", rr.Body.String())
+
+	// unchanged response
+	rr = backend.requestBytes(http.MethodGet, path, nil, map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.String())
+
+	// changed response
+	rr = backend.requestBytes(http.MethodGet, path, nil, map[string]string{"If-None-Match": `W/"0123"`})
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// errors are not cached
+	rr = backend.request(http.MethodGet, pathDataProposerPayloadDelivered+"?slot=abc", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Empty(t, rr.Header().Get("ETag"))
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+
+	"github.com/aohorodnyk/mimeheader"
+	"github.com/pkg/errors"
+)
+
+const (
+	TextCSV           = "text/csv"
+	ApplicationNDJSON = "application/x-ndjson"
+)
+
+var (
+	ErrInvalidDataFormat = errors.New("invalid format argument, supported are json, csv and ndjson")
+
+	dataFormatMimeTypes = map[string]string{
+		"json":   ApplicationJSON,
+		"csv":    TextCSV,
+		"ndjson": ApplicationNDJSON,
+	}
+)
+
+// csvRecord is a data API entry which can be exported as a CSV row
+type csvRecord[T any] interface {
+	*T
+	CSVHeader() []string
+	ToCSVRecord() []string
+}
+
+// negotiateDataResponseType returns the mime type of a data API response. The format argument takes precedence over
+// the Accept header, and JSON is used if neither selects a supported type. As the response depends on the Accept
+// header, it is added to the Vary header for caches.
+func negotiateDataResponseType(w http.ResponseWriter, req *http.Request) (mimeType string, err error) {
+	w.Header().Add("Vary", "Accept")
+	if format := req.URL.Query().Get("format"); format != "" {
+		mimeType, ok := dataFormatMimeTypes[format]
+		if !ok {
+			return "", ErrInvalidDataFormat
+		}
+		return mimeType, nil
+	}
+
+	ah := req.Header.Get("Accept")
+	if ah == "" {
+		return ApplicationJSON, nil
+	}
+	mh := mimeheader.ParseAcceptHeader(ah)
+	_, mimeType, matched := mh.Negotiate(
+		[]string{ApplicationJSON, TextCSV, ApplicationNDJSON},
+		ApplicationJSON,
+	)
+	if !matched {
+		return ApplicationJSON, nil
+	}
+	return mimeType, nil
+}
+
+// respondDataEntries writes data API entries as a JSON array, as CSV with a header row, or as one JSON object per line
+func respondDataEntries[T any, PT csvRecord[T]](api *RelayAPI, w http.ResponseWriter, mimeType string, entries []T) {
+	switch mimeType {
+	case TextCSV:
+		w.Header().Set("Content-Type", TextCSV)
+		w.WriteHeader(http.StatusOK)
+		csvWriter := csv.NewWriter(w)
+		if err := csvWriter.Write(PT(new(T)).CSVHeader()); err != nil {
+			api.log.WithError(err).Error("Couldn't write response")
+			return
+		}
+		for i := range entries {
+			if err := csvWriter.Write(PT(&entries[i]).ToCSVRecord()); err != nil {
+				api.log.WithError(err).Error("Couldn't write response")
+				return
+			}
+		}
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			api.log.WithError(err).Error("Couldn't write response")
+		}
+	case ApplicationNDJSON:
+		w.Header().Set("Content-Type", ApplicationNDJSON)
+		w.WriteHeader(http.StatusOK)
+		encoder := json.NewEncoder(w)
+		for i := range entries {
+			if err := encoder.Encode(entries[i]); err != nil {
+				api.log.WithError(err).Error("Couldn't write response")
+				return
+			}
+		}
+	default:
+		api.RespondOK(w, entries)
+	}
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateDataResponseType(t *testing.T) {
+	testCases := []struct {
+		Query  string
+		Header string
+		Mime   string
+		Error  error
+	}{
+		{Query: "", Header: "", Mime: ApplicationJSON},
+		{Query: "format=csv", Header: "", Mime: TextCSV},
+		{Query: "format=ndjson", Header: ApplicationJSON, Mime: ApplicationNDJSON},
+		{Query: "format=json", Header: TextCSV, Mime: ApplicationJSON},
+		{Query: "format=xml", Header: "", Error: ErrInvalidDataFormat},
+		{Query: "", Header: TextCSV, Mime: TextCSV},
+		{Query: "", Header: ApplicationNDJSON, Mime: ApplicationNDJSON},
+		{Query: "", Header: "text/html,*/*;q=0.8", Mime: ApplicationJSON},
+		{Query: "", Header: "text/html", Mime: ApplicationJSON},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Query+"_"+tc.Header, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "/?"+tc.Query, nil)
+			require.NoError(t, err)
+			req.Header.Set("Accept", tc.Header)
+			rr := httptest.NewRecorder()
+			mimeType, err := negotiateDataResponseType(rr, req)
+			require.ErrorIs(t, err, tc.Error)
+			require.Equal(t, tc.Mime, mimeType)
+			require.Equal(t, "Accept", rr.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestRespondDataEntries(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	entries := []common.BidTraceV2JSON{
+		{Slot: 1, BlockHash: "0x01", Value: "100", NumTx: 2, BlockNumber: 10},
+		{Slot: 2, BlockHash: "0x02", Value: "200", NumTx: 3, BlockNumber: 11},
+	}
+
+	rr := httptest.NewRecorder()
+	respondDataEntries(backend.relay, rr, TextCSV, entries)
+	require.Equal(t, TextCSV, rr.Header().Get("Content-Type"))
+	require.Equal(t, "slot,parent_hash,block_hash,builder_pubkey,proposer_pubkey,proposer_fee_recipient,gas_limit,gas_used,value,num_tx,block_number\n"+
+		"1,,0x01,,,,0,0,100,2,10\n"+
+		"2,,0x02,,,,0,0,200,3,11\n", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	respondDataEntries(backend.relay, rr, ApplicationNDJSON, entries)
+	require.Equal(t, ApplicationNDJSON, rr.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[1], `"block_hash":"0x02"`)
+
+	// an empty CSV export still has the header row
+	rr = httptest.NewRecorder()
+	respondDataEntries(backend.relay, rr, TextCSV, []common.BidTraceV2WithTimestampJSON{})
+	require.Equal(t, "slot,parent_hash,block_hash,builder_pubkey,proposer_pubkey,proposer_fee_recipient,gas_limit,gas_used,value,num_tx,block_number,timestamp,timestamp_ms,optimistic_submission,id\n", rr.Body.String())
+
+	// the bids received include the id, the cursor of the next page
+	rr = httptest.NewRecorder()
+	respondDataEntries(backend.relay, rr, TextCSV, []common.BidTraceV2WithTimestampJSON{{BidTraceV2JSON: entries[0], ID: 123}})
+	lines = strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasSuffix(lines[1], ",123"))
+
+	rr = httptest.NewRecorder()
+	respondDataEntries(backend.relay, rr, ApplicationJSON, entries)
+	require.Equal(t, ApplicationJSON, rr.Header().Get("Content-Type"))
+	require.Equal(t, byte('['), rr.Body.Bytes()[0])
+}
+
+func TestDataApiFormat(t *testing.T) {
+	backend := newTestBackend(t, 1)
+
+	rr := backend.request(http.MethodGet, pathDataProposerPayloadDelivered+"?format=csv", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, TextCSV, rr.Header().Get("Content-Type"))
+
+	rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?slot=1&format=ndjson", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, ApplicationNDJSON, rr.Header().Get("Content-Type"))
+
+	rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?slot=1&format=xml", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
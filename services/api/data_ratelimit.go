@@ -0,0 +1,212 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+)
+
+const (
+	// HeaderDataAPIKey is the request header with the API key for the higher rate limit of the data API
+	HeaderDataAPIKey = "X-API-Key"
+
+	// clients can send a burst of requests of this many seconds worth of their rate limit
+	dataAPIRateLimitBurstSec = 5
+
+	// interval at which idle buckets are removed
+	rateLimiterCleanupInterval = 10 * time.Second
+)
+
+var (
+	// requests per second of the data API per client IP (0 to disable rate limiting)
+	dataAPIRateLimitPerSec = cli.GetEnvInt("DATA_API_RATE_LIMIT_PER_SEC", 0)
+
+	// requests per second of the data API per API key (0 for no limit)
+	dataAPIKeyRateLimitPerSec = cli.GetEnvInt("DATA_API_KEY_RATE_LIMIT_PER_SEC", 0)
+
+	// behind a load balancer, all requests have the address of the load balancer unless it is a trusted proxy, so the
+	// rate limit by remote address must be allowed explicitly for relays which are reached directly
+	dataAPIRateLimitByRemoteAddr = os.Getenv("DATA_API_RATE_LIMIT_BY_REMOTE_ADDR") == "1"
+
+	ErrDataAPIRateLimitWithoutTrustedProxies = errors.New("cannot rate limit the data API without TRUSTED_PROXY_CIDRS (set DATA_API_RATE_LIMIT_BY_REMOTE_ADDR=1 to rate limit by the remote address)")
+)
+
+// rateLimiter is a token bucket rate limiter per key
+type rateLimiter struct {
+	mu          sync.Mutex
+	ratePerSec  float64
+	burst       float64
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+func newRateLimiter(ratePerSec, burst int) *rateLimiter {
+	return &rateLimiter{
+		ratePerSec: float64(ratePerSec),
+		burst:      float64(burst),
+		buckets:    make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the bucket of the key, and returns false if it was empty
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) > rateLimiterCleanupInterval {
+		// buckets which are full again are the same as new ones
+		refillDuration := time.Duration(l.burst / l.ratePerSec * float64(time.Second))
+		for k, bucket := range l.buckets {
+			if now.Sub(bucket.updatedAt) >= refillDuration {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastCleanup = now
+	}
+
+	bucket, found := l.buckets[key]
+	if !found {
+		bucket = &tokenBucket{tokens: l.burst, updatedAt: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*l.ratePerSec)
+	bucket.updatedAt = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// hashDataAPIKey returns the hash of an API key, under which it's stored and listed
+func hashDataAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// updateDataAPIKeys loads the data API keys from Redis
+func (api *RelayAPI) updateDataAPIKeys() error {
+	keys, err := api.redis.GetDataAPIKeys()
+	if err != nil {
+		return err
+	}
+	api.dataAPIKeys.Store(&keys)
+	return nil
+}
+
+func (api *RelayAPI) startDataAPIKeysPoller() {
+	ticker := time.NewTicker(featureFlagsPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := api.updateDataAPIKeys()
+		if err != nil {
+			api.log.WithError(err).Error("failed to update data API keys")
+		}
+	}
+}
+
+// dataAPIRateLimitMiddleware limits the requests to the data API per client IP, or per API key for requests with a
+// valid key in the X-API-Key header
+func (api *RelayAPI) dataAPIRateLimitMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if api.dataAPIIPRateLimiter == nil {
+			next(w, req)
+			return
+		}
+
+		limiter, limiterKey := api.dataAPIIPRateLimiter, clientIP(req, api.trustedProxies)
+		if key := req.Header.Get(HeaderDataAPIKey); key != "" {
+			keyHash := hashDataAPIKey(key)
+			keys := api.dataAPIKeys.Load()
+			if keys == nil {
+				api.RespondError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+			if _, found := (*keys)[keyHash]; !found {
+				api.RespondError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+			if api.dataAPIKeyRateLimiter == nil {
+				next(w, req)
+				return
+			}
+			limiter, limiterKey = api.dataAPIKeyRateLimiter, keyHash
+		}
+
+		if !limiter.allow(limiterKey, time.Now()) {
+			w.Header().Set("Retry-After", "1")
+			api.RespondError(w, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		next(w, req)
+	}
+}
+
+// handleInternalDataAPIKeys lists the data API keys, creates a key with a label on POST (i.e. ?label=explorer), and
+// removes a key by its hash on DELETE (i.e. ?hash=...). Keys are only returned once, when they are created.
+func (api *RelayAPI) handleInternalDataAPIKeys(w http.ResponseWriter, req *http.Request) {
+	args := req.URL.Query()
+
+	switch req.Method {
+	case http.MethodPost:
+		label := args.Get("label")
+		if label == "" {
+			api.RespondError(w, http.StatusBadRequest, "missing label argument")
+			return
+		}
+		keyBytes := make([]byte, 32)
+		if _, err := rand.Read(keyBytes); err != nil {
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		key := hex.EncodeToString(keyBytes)
+		entry := DataAPIKeyEntry{Hash: hashDataAPIKey(key), Label: label, Key: key}
+		if err := api.redis.SetDataAPIKey(entry.Hash, entry.Label); err != nil {
+			api.log.WithError(err).Error("could not save data API key")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		api.log.WithField("label", label).Info("data API key created")
+		api.RespondOK(w, entry)
+	case http.MethodDelete:
+		found, err := api.redis.DelDataAPIKey(args.Get("hash"))
+		if err != nil {
+			api.log.WithError(err).Error("could not remove data API key")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		} else if !found {
+			api.RespondError(w, http.StatusNotFound, "data API key not found")
+			return
+		}
+		api.log.WithField("hash", args.Get("hash")).Info("data API key removed")
+		api.RespondOK(w, NilResponse)
+	default:
+		keys, err := api.redis.GetDataAPIKeys()
+		if err != nil {
+			api.log.WithError(err).Error("could not get data API keys")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		entries := make([]DataAPIKeyEntry, 0, len(keys))
+		for hash, label := range keys {
+			entries = append(entries, DataAPIKeyEntry{Hash: hash, Label: label})
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Label < entries[j].Label
+		})
+		api.RespondOK(w, entries)
+	}
+}
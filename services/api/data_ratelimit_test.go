@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(2, 3)
+	now := time.Now()
+
+	// the burst is available right away, then tokens refill at the rate
+	for range 3 {
+		require.True(t, limiter.allow("a", now))
+	}
+	require.False(t, limiter.allow("a", now))
+	require.True(t, limiter.allow("b", now))
+	require.True(t, limiter.allow("a", now.Add(500*time.Millisecond)))
+	require.False(t, limiter.allow("a", now.Add(500*time.Millisecond)))
+
+	// idle buckets are removed
+	require.True(t, limiter.allow("c", now.Add(2*rateLimiterCleanupInterval)))
+	require.Len(t, limiter.buckets, 1)
+
+	// buckets are removed once they are full again, also before the cleanup interval of requests
+	limiter = newRateLimiter(2, 3)
+	require.True(t, limiter.allow("a", now))
+	require.True(t, limiter.allow("b", now.Add(rateLimiterCleanupInterval+time.Second)))
+	require.True(t, limiter.allow("c", now.Add(rateLimiterCleanupInterval+1500*time.Millisecond)))
+	require.Len(t, limiter.buckets, 2)
+	require.True(t, limiter.allow("d", now.Add(2*rateLimiterCleanupInterval+3*time.Second)))
+	require.Len(t, limiter.buckets, 1)
+}
+
+func TestClientIP(t *testing.T) {
+	trustedProxies, err := parseCIDRList("10.0.0.0/8")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "/", nil)
+	require.NoError(t, err)
+	req.RemoteAddr = "10.0.0.1:1234"
+	require.Equal(t, "10.0.0.1", clientIP(req, nil))
+	require.Equal(t, "10.0.0.1", clientIP(req, trustedProxies))
+
+	// the last entry not added by a trusted proxy
+	req.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2, 10.0.0.2")
+	require.Equal(t, "2.2.2.2", clientIP(req, trustedProxies))
+	require.Equal(t, "10.0.0.1", clientIP(req, nil))
+
+	// a client connecting directly can't choose its IP with X-Forwarded-For
+	req.RemoteAddr = "3.3.3.3:1234"
+	require.Equal(t, "3.3.3.3", clientIP(req, trustedProxies))
+}
+
+func TestBuilderIPFilterSpoofedForwardedFor(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	filter, err := newBuilderIPFilter("1.1.1.1", "")
+	require.NoError(t, err)
+	backend.relay.builderIPFilter.Store(filter)
+
+	handler := backend.relay.builderIPFilterMiddleware(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	req, err := http.NewRequest(http.MethodPost, pathSubmitNewBlock, nil)
+	require.NoError(t, err)
+	req.RemoteAddr = "3.3.3.3:1234"
+	req.Header.Set("X-Forwarded-For", "1.1.1.1")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	require.Equal(t, http.StatusForbidden, rr.Code)
+
+	// the same header from a trusted proxy is used
+	backend.relay.trustedProxies, err = parseCIDRList("3.3.3.3")
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDataAPIRateLimit(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.dataAPIIPRateLimiter = newRateLimiter(1, 2)
+	backend.relay.dataAPIKeyRateLimiter = newRateLimiter(1, 3)
+	path := pathDataProposerPayloadDelivered
+
+	// create an API key with the internal API
+	rr := backend.request(http.MethodPost, pathInternalDataAPIKeys+"?label=explorer", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	entry := new(DataAPIKeyEntry)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), entry))
+	require.Equal(t, "explorer", entry.Label)
+	require.Equal(t, hashDataAPIKey(entry.Key), entry.Hash)
+	require.NoError(t, backend.relay.updateDataAPIKeys())
+
+	rr = backend.request(http.MethodGet, pathInternalDataAPIKeys, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	entries := []DataAPIKeyEntry{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+	require.Equal(t, []DataAPIKeyEntry{{Hash: entry.Hash, Label: "explorer"}}, entries)
+
+	// the limit per IP
+	for range 2 {
+		rr = backend.request(http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+	require.Equal(t, "1", rr.Header().Get("Retry-After"))
+
+	// the limit per API key
+	for range 3 {
+		rr = backend.requestBytes(http.MethodGet, path, nil, map[string]string{HeaderDataAPIKey: entry.Key})
+		require.Equal(t, http.StatusOK, rr.Code)
+	}
+	rr = backend.requestBytes(http.MethodGet, path, nil, map[string]string{HeaderDataAPIKey: entry.Key})
+	require.Equal(t, http.StatusTooManyRequests, rr.Code)
+
+	rr = backend.requestBytes(http.MethodGet, path, nil, map[string]string{HeaderDataAPIKey: "invalid"})
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// removed keys are rejected
+	rr = backend.request(http.MethodDelete, pathInternalDataAPIKeys+"?hash="+entry.Hash, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = backend.request(http.MethodDelete, pathInternalDataAPIKeys+"?hash="+entry.Hash, nil)
+	require.Equal(t, http.StatusNotFound, rr.Code)
+	require.NoError(t, backend.relay.updateDataAPIKeys())
+	rr = backend.requestBytes(http.MethodGet, path, nil, map[string]string{HeaderDataAPIKey: entry.Key})
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestDataAPIRateLimitWithoutTrustedProxies(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	dataAPIRateLimitPerSec = 1
+	t.Cleanup(func() { dataAPIRateLimitPerSec = 0 })
+
+	// the rate limit by remote address needs to be allowed explicitly
+	_, err := NewRelayAPI(backend.relay.opts)
+	require.ErrorIs(t, err, ErrDataAPIRateLimitWithoutTrustedProxies)
+
+	dataAPIRateLimitByRemoteAddr = true
+	t.Cleanup(func() { dataAPIRateLimitByRemoteAddr = false })
+	_, err = NewRelayAPI(backend.relay.opts)
+	require.NoError(t, err)
+
+	dataAPIRateLimitByRemoteAddr = false
+	trustedProxyCIDRs = "10.0.0.0/8"
+	t.Cleanup(func() { trustedProxyCIDRs = "" })
+	_, err = NewRelayAPI(backend.relay.opts)
+	require.NoError(t, err)
+}
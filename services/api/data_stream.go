@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// maximum number of concurrent data stream subscribers of this instance, 0 means unlimited
+	dataStreamMaxConnections = int64(cli.GetEnvInt("DATA_STREAM_MAX_CONNECTIONS", 1000))
+
+	// comments are sent in this interval to keep idle connections open through proxies
+	dataStreamKeepaliveInterval = 15 * time.Second
+
+	dataStreamEventTypes = []string{datastore.DataStreamEventPayloadDelivered, datastore.DataStreamEventTopBid}
+
+	// events buffered per subscriber, events for subscribers which fall further behind are dropped
+	dataStreamSubscriberBufferSize = 100
+)
+
+// dataStreamHub subscribes to the data stream events of all instances once, and fans them out to the data stream
+// subscribers of this instance. The Redis subscription is started with the first subscriber, and restarted with the
+// next subscriber if it ended.
+type dataStreamHub struct {
+	redis *datastore.RedisCache
+	log   *logrus.Entry
+
+	lock        sync.Mutex
+	isStarted   bool
+	subscribers map[chan datastore.DataStreamEvent][]string // event types by subscriber
+}
+
+func newDataStreamHub(redis *datastore.RedisCache, log *logrus.Entry) *dataStreamHub {
+	return &dataStreamHub{
+		redis:       redis,
+		log:         log.WithField("component", "dataStreamHub"),
+		subscribers: make(map[chan datastore.DataStreamEvent][]string),
+	}
+}
+
+// subscribe returns a channel with the events of the given types, which is closed if the Redis subscription ends, and
+// the function to unsubscribe
+func (h *dataStreamHub) subscribe(eventTypes []string) (<-chan datastore.DataStreamEvent, func(), error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if !h.isStarted {
+		events, err := h.redis.SubscribeDataStream(context.Background(), dataStreamEventTypes...)
+		if err != nil {
+			return nil, nil, err
+		}
+		h.isStarted = true
+		go h.run(events)
+	}
+
+	subscriber := make(chan datastore.DataStreamEvent, dataStreamSubscriberBufferSize)
+	h.subscribers[subscriber] = eventTypes
+	unsubscribe := func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		delete(h.subscribers, subscriber)
+	}
+	return subscriber, unsubscribe, nil
+}
+
+// run sends the events to the subscribers of their type, without waiting for slow subscribers
+func (h *dataStreamHub) run(events <-chan datastore.DataStreamEvent) {
+	for event := range events {
+		h.lock.Lock()
+		for subscriber, eventTypes := range h.subscribers {
+			if !slices.Contains(eventTypes, event.Type) {
+				continue
+			}
+			select {
+			case subscriber <- event:
+			default:
+				h.log.WithField("eventType", event.Type).Debug("data stream subscriber is too slow, dropping event")
+			}
+		}
+		h.lock.Unlock()
+	}
+
+	h.log.Warn("data stream subscription ended, closing the streams")
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for subscriber := range h.subscribers {
+		close(subscriber)
+		delete(h.subscribers, subscriber)
+	}
+	h.isStarted = false
+}
+
+// handleDataStream sends the delivered payloads, and new top bids if requested with the events argument, as server-sent events
+func (api *RelayAPI) handleDataStream(w http.ResponseWriter, req *http.Request) {
+	eventTypes := []string{datastore.DataStreamEventPayloadDelivered}
+	if args := req.URL.Query(); args.Get("events") != "" {
+		eventTypes = strings.Split(args.Get("events"), ",")
+		for _, eventType := range eventTypes {
+			if !slices.Contains(dataStreamEventTypes, eventType) {
+				api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid events argument, supported are "+strings.Join(dataStreamEventTypes, ","))
+				return
+			}
+		}
+	}
+
+	if numConnections := api.dataStreamConnections.Inc(); dataStreamMaxConnections > 0 && numConnections > dataStreamMaxConnections {
+		api.dataStreamConnections.Dec()
+		api.RespondError(w, http.StatusServiceUnavailable, "too many data stream connections")
+		return
+	}
+	defer api.dataStreamConnections.Dec()
+
+	events, unsubscribe, err := api.dataStream.subscribe(eventTypes)
+	if err != nil {
+		api.log.WithError(err).Error("failed to subscribe to the data stream")
+		api.RespondError(w, http.StatusInternalServerError, "failed to subscribe to the data stream")
+		return
+	}
+	defer unsubscribe()
+
+	// the stream is kept open beyond the write timeout of the server
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		api.log.WithError(err).Debug("failed to clear the write deadline of the data stream")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		api.log.WithError(err).Error("failed to flush the data stream")
+		return
+	}
+
+	keepalive := time.NewTicker(dataStreamKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case <-api.dataStreamStop:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+		case <-keepalive.C:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
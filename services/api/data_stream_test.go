@@ -0,0 +1,103 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataStream(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	server := httptest.NewServer(backend.relay.getRouter())
+	defer server.Close()
+
+	t.Run("invalid events argument", func(t *testing.T) {
+		rr := backend.request(http.MethodGet, pathDataStream+"?events=payload_delivered,foo", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("too many connections", func(t *testing.T) {
+		backend.relay.dataStreamConnections.Store(dataStreamMaxConnections)
+		defer backend.relay.dataStreamConnections.Store(0)
+		rr := backend.request(http.MethodGet, pathDataStream, nil)
+		require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	})
+
+	t.Run("events", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+pathDataStream+"?events=top_bid", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		// the subscription is active once the headers were sent
+		require.NoError(t, backend.redis.PublishDataStreamEvent(ctx, datastore.DataStreamEventPayloadDelivered, map[string]string{"slot": "1"}))
+		require.NoError(t, backend.redis.PublishDataStreamEvent(ctx, datastore.DataStreamEventTopBid, map[string]string{"slot": "2"}))
+
+		reader := bufio.NewReader(resp.Body)
+		for _, expectedLine := range []string{"event: top_bid\n", "data: {\"slot\":\"2\"}\n", "\n"} {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			require.Equal(t, expectedLine, line)
+		}
+	})
+
+	t.Run("one redis subscription", func(t *testing.T) {
+		redisServer, err := miniredis.Run()
+		require.NoError(t, err)
+		defer redisServer.Close()
+		redisCache, err := datastore.NewRedisCache("", redisServer.Addr(), "")
+		require.NoError(t, err)
+
+		hub := newDataStreamHub(redisCache, backend.relay.log)
+		payloadEvents, unsubscribePayloads, err := hub.subscribe([]string{datastore.DataStreamEventPayloadDelivered})
+		require.NoError(t, err)
+		defer unsubscribePayloads()
+		allEvents, unsubscribeAll, err := hub.subscribe(dataStreamEventTypes)
+		require.NoError(t, err)
+		defer unsubscribeAll()
+
+		// the subscribers share the subscription of the hub to each event type
+		numSubscriptions := redisServer.PubSubNumSub(redisServer.PubSubChannels("*")...)
+		require.Len(t, numSubscriptions, len(dataStreamEventTypes))
+		for _, num := range numSubscriptions {
+			require.Equal(t, 1, num)
+		}
+
+		// and only receive the events of their types
+		require.NoError(t, redisCache.PublishDataStreamEvent(t.Context(), datastore.DataStreamEventTopBid, map[string]string{"slot": "2"}))
+		require.NoError(t, redisCache.PublishDataStreamEvent(t.Context(), datastore.DataStreamEventPayloadDelivered, map[string]string{"slot": "1"}))
+		for _, expected := range []string{datastore.DataStreamEventTopBid, datastore.DataStreamEventPayloadDelivered} {
+			select {
+			case event := <-allEvents:
+				require.Equal(t, expected, event.Type)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timeout waiting for the event")
+			}
+		}
+		select {
+		case event := <-payloadEvents:
+			require.Equal(t, datastore.DataStreamEventPayloadDelivered, event.Type)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for the event")
+		}
+	})
+
+	t.Run("other routes", func(t *testing.T) {
+		resp, err := http.Get(server.URL + pathDataProposerPayloadDelivered) //nolint:noctx
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+}
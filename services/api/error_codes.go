@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ErrorCode is a stable, machine-readable identifier of an API error. Unlike the error messages, error codes don't
+// change between releases, so clients can rely on them.
+type ErrorCode string
+
+// Generic error codes, used for errors without a specific code (by HTTP status)
+const (
+	ErrorCodeBadRequest           ErrorCode = "BAD_REQUEST"
+	ErrorCodeUnauthorized         ErrorCode = "UNAUTHORIZED"
+	ErrorCodeForbidden            ErrorCode = "FORBIDDEN"
+	ErrorCodeNotFound             ErrorCode = "NOT_FOUND"
+	ErrorCodeNotAcceptable        ErrorCode = "NOT_ACCEPTABLE"
+	ErrorCodeRequestTooLarge      ErrorCode = "REQUEST_TOO_LARGE"
+	ErrorCodeUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	ErrorCodeRateLimited          ErrorCode = "RATE_LIMITED"
+	ErrorCodeTooManyConcurrent    ErrorCode = "TOO_MANY_CONCURRENT_REQUESTS"
+	ErrorCodeInternalError        ErrorCode = "INTERNAL_ERROR"
+	ErrorCodeServiceUnavailable   ErrorCode = "SERVICE_UNAVAILABLE"
+	ErrorCodeGatewayTimeout       ErrorCode = "GATEWAY_TIMEOUT"
+)
+
+// Error codes of invalid requests
+const (
+	ErrorCodeInvalidRequestBody ErrorCode = "INVALID_REQUEST_BODY"
+	ErrorCodeInvalidArgument    ErrorCode = "INVALID_ARGUMENT"
+	ErrorCodeInvalidSlot        ErrorCode = "INVALID_SLOT"
+	ErrorCodeInvalidPubkey      ErrorCode = "INVALID_PUBKEY"
+	ErrorCodeInvalidHash        ErrorCode = "INVALID_HASH"
+	ErrorCodeInvalidSignature   ErrorCode = "INVALID_SIGNATURE"
+	ErrorCodeInvalidTimestamp   ErrorCode = "INVALID_TIMESTAMP"
+	ErrorCodePastSlot           ErrorCode = "PAST_SLOT"
+)
+
+// Error codes of the proposer API. The getPayload failures use the upper-cased failure reasons of the database
+// (i.e. UNKNOWN_PAYLOAD for database.GetPayloadFailureUnknownPayload).
+const (
+	ErrorCodeUnknownValidator    ErrorCode = "UNKNOWN_VALIDATOR"
+	ErrorCodeRegistrationsFailed ErrorCode = "REGISTRATIONS_FAILED"
+	ErrorCodeStalePreferences    ErrorCode = "STALE_PREFERENCES"
+)
+
+// Error codes of the builder API
+const (
+	ErrorCodeUnknownProposerDuty         ErrorCode = "UNKNOWN_PROPOSER_DUTY"
+	ErrorCodeFeeRecipientMismatch        ErrorCode = "FEE_RECIPIENT_MISMATCH"
+	ErrorCodePayloadAttributesUnknown    ErrorCode = "PAYLOAD_ATTRIBUTES_UNKNOWN"
+	ErrorCodeInvalidPrevRandao           ErrorCode = "INVALID_PREV_RANDAO"
+	ErrorCodeInvalidWithdrawals          ErrorCode = "INVALID_WITHDRAWALS"
+	ErrorCodeWrongFork                   ErrorCode = "WRONG_FORK"
+	ErrorCodeSubmissionTooLate           ErrorCode = "SUBMISSION_TOO_LATE"
+	ErrorCodeBuilderBlacklisted          ErrorCode = "BUILDER_BLACKLISTED"
+	ErrorCodeBuilderBlockedByProposer    ErrorCode = "BUILDER_BLOCKED_BY_PROPOSER"
+	ErrorCodeProposerPreferencesViolated ErrorCode = "PROPOSER_PREFERENCES_VIOLATED"
+	ErrorCodePayloadAlreadyDelivered     ErrorCode = "PAYLOAD_ALREADY_DELIVERED"
+	ErrorCodeCancellationsDisabled       ErrorCode = "CANCELLATIONS_DISABLED"
+	ErrorCodeBidsInvalidated             ErrorCode = "BIDS_INVALIDATED"
+	ErrorCodeSanityCheckFailed           ErrorCode = "SANITY_CHECK_FAILED"
+	ErrorCodeFilteredAddress             ErrorCode = "FILTERED_ADDRESS"
+	ErrorCodeSimulationFailed            ErrorCode = "SIMULATION_FAILED"
+	ErrorCodeSimulationError             ErrorCode = "SIMULATION_ERROR"
+	ErrorCodeSimulationTimeout           ErrorCode = "SIMULATION_TIMEOUT"
+	ErrorCodeNewerPayloadExists          ErrorCode = "NEWER_PAYLOAD_EXISTS"
+	ErrorCodeBidAdjustmentFailed         ErrorCode = "BID_ADJUSTMENT_FAILED"
+	ErrorCodeSubmissionInProgress        ErrorCode = "SUBMISSION_IN_PROGRESS"
+)
+
+// errorCodeForStatus returns the generic error code of an HTTP status
+func errorCodeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusUnauthorized:
+		return ErrorCodeUnauthorized
+	case http.StatusForbidden:
+		return ErrorCodeForbidden
+	case http.StatusNotFound:
+		return ErrorCodeNotFound
+	case http.StatusNotAcceptable:
+		return ErrorCodeNotAcceptable
+	case http.StatusRequestEntityTooLarge:
+		return ErrorCodeRequestTooLarge
+	case http.StatusUnsupportedMediaType:
+		return ErrorCodeUnsupportedMediaType
+	case http.StatusTooManyRequests:
+		return ErrorCodeRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrorCodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrorCodeGatewayTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		return ErrorCodeInternalError
+	}
+	return ErrorCodeBadRequest
+}
+
+// getPayloadFailureErrorCode returns the error code of a getPayload failure reason
+func getPayloadFailureErrorCode(reason string) ErrorCode {
+	return ErrorCode(strings.ToUpper(reason))
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCodeForStatus(t *testing.T) {
+	require.Equal(t, ErrorCodeBadRequest, errorCodeForStatus(http.StatusBadRequest))
+	require.Equal(t, ErrorCodeForbidden, errorCodeForStatus(http.StatusForbidden))
+	require.Equal(t, ErrorCodeRateLimited, errorCodeForStatus(http.StatusTooManyRequests))
+	require.Equal(t, ErrorCodeInternalError, errorCodeForStatus(http.StatusInternalServerError))
+	require.Equal(t, ErrorCodeInternalError, errorCodeForStatus(http.StatusBadGateway))
+	require.Equal(t, ErrorCode("UNKNOWN_PAYLOAD"), getPayloadFailureErrorCode(database.GetPayloadFailureUnknownPayload))
+}
+
+func TestErrorCodeResponses(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	parentHash := "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747"
+	proposerPubkey := "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"
+
+	requireErrorCode := func(path string, status int, errorCode ErrorCode) {
+		rr := backend.request(http.MethodGet, path, nil)
+		require.Equal(t, status, rr.Code)
+		resp := new(HTTPErrorResp)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+		require.Equal(t, status, resp.Code)
+		require.Equal(t, errorCode, resp.ErrorCode)
+		require.NotEmpty(t, resp.Message)
+	}
+
+	// specific error codes
+	requireErrorCode(fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, parentHash, "0x1234"), http.StatusBadRequest, ErrorCodeInvalidPubkey)
+	requireErrorCode(fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", 1, "0x1234", proposerPubkey), http.StatusBadRequest, ErrorCodeInvalidHash)
+	requireErrorCode(pathDataProposerPayloadDelivered+"?limit=abc", http.StatusBadRequest, ErrorCodeInvalidArgument)
+
+	// generic error code of the HTTP status
+	requireErrorCode("/internal/v1/builder-identities/unknown", http.StatusBadRequest, ErrorCodeBadRequest)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/sirupsen/logrus"
+	uberatomic "go.uber.org/atomic"
+)
+
+var featureFlagsPollInterval = common.GetEnvDurationSec("FEATURE_FLAGS_POLL_INTERVAL_SEC", 5)
+
+// dynamicFeatureFlags returns the feature flags which can be changed at runtime through the relay config in Redis,
+// by the name of their env var
+func (api *RelayAPI) dynamicFeatureFlags() map[string]*uberatomic.Bool {
+	return map[string]*uberatomic.Bool{
+		"FORCE_GET_HEADER_204":             &api.ffForceGetHeader204,
+		"DISABLE_LOWPRIO_BUILDERS":         &api.ffDisableLowPrioBuilders,
+		"DISABLE_PAYLOAD_DATABASE_STORAGE": &api.ffDisablePayloadDBStorage,
+		"REJECT_BLACKLISTED_BUILDERS":      &api.ffRejectBlacklistedBuilders,
+	}
+}
+
+// getFeatureFlags returns the current values of the dynamic feature flags
+func (api *RelayAPI) getFeatureFlags() map[string]bool {
+	flags := make(map[string]bool)
+	for name, ff := range api.dynamicFeatureFlags() {
+		flags[name] = ff.Load()
+	}
+	return flags
+}
+
+// updateFeatureFlags applies the feature flags from Redis. Flags without a value in Redis are reset to the value
+// from the environment.
+func (api *RelayAPI) updateFeatureFlags() error {
+	// don't touch the flags while shutting down, StopServer might have changed them
+	if api.srvShutdown.Load() {
+		return nil
+	}
+
+	overrides, err := api.redis.GetFeatureFlags()
+	if err != nil {
+		return err
+	}
+
+	for name, ff := range api.dynamicFeatureFlags() {
+		enabled, found := overrides[name]
+		if !found {
+			enabled = api.featureFlagDefaults[name]
+		}
+		if ff.Swap(enabled) != enabled {
+			api.log.WithFields(logrus.Fields{
+				"featureFlag": name,
+				"enabled":     enabled,
+			}).Warn("feature flag changed")
+		}
+	}
+	return nil
+}
+
+func (api *RelayAPI) startFeatureFlagsPoller() {
+	ticker := time.NewTicker(featureFlagsPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := api.updateFeatureFlags()
+		if err != nil {
+			api.log.WithError(err).Error("failed to update feature flags")
+		}
+	}
+}
+
+// handleInternalFeatureFlags returns the dynamic feature flags, and on POST/PUT overrides them with the query
+// arguments (i.e. ?FORCE_GET_HEADER_204=true). An empty value removes the override.
+func (api *RelayAPI) handleInternalFeatureFlags(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		api.RespondOK(w, api.getFeatureFlags())
+		return
+	}
+
+	args := req.URL.Query()
+	dynamicFlags := api.dynamicFeatureFlags()
+	for name := range args {
+		if _, found := dynamicFlags[name]; !found {
+			api.RespondError(w, http.StatusBadRequest, "unknown feature flag: "+name)
+			return
+		}
+		if args.Get(name) == "" {
+			continue
+		}
+		if _, err := strconv.ParseBool(args.Get(name)); err != nil {
+			api.RespondError(w, http.StatusBadRequest, "invalid value for feature flag: "+name)
+			return
+		}
+	}
+
+	for name := range args {
+		var err error
+		if args.Get(name) == "" {
+			err = api.redis.DelFeatureFlag(name)
+		} else {
+			enabled, _ := strconv.ParseBool(args.Get(name))
+			err = api.redis.SetFeatureFlag(name, enabled)
+		}
+		if err != nil {
+			api.log.WithError(err).WithField("featureFlag", name).Error("could not set feature flag")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	err := api.updateFeatureFlags()
+	if err != nil {
+		api.log.WithError(err).Error("could not update feature flags")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.RespondOK(w, api.getFeatureFlags())
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalFeatureFlags(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.featureFlagDefaults["DISABLE_LOWPRIO_BUILDERS"] = true
+	path := pathInternalFeatureFlags
+
+	getFlags := func() map[string]bool {
+		rr := backend.request(http.MethodGet, path, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		flags := make(map[string]bool)
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &flags))
+		return flags
+	}
+
+	t.Run("override flags", func(t *testing.T) {
+		rr := backend.request(http.MethodPost, path+"?FORCE_GET_HEADER_204=true&DISABLE_LOWPRIO_BUILDERS=false", nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.True(t, backend.relay.ffForceGetHeader204.Load())
+		require.False(t, backend.relay.ffDisableLowPrioBuilders.Load())
+		require.True(t, getFlags()["FORCE_GET_HEADER_204"])
+
+		overrides, err := backend.redis.GetFeatureFlags()
+		require.NoError(t, err)
+		require.Equal(t, map[string]bool{"FORCE_GET_HEADER_204": true, "DISABLE_LOWPRIO_BUILDERS": false}, overrides)
+	})
+
+	t.Run("flags from redis are applied on update", func(t *testing.T) {
+		require.NoError(t, backend.redis.SetFeatureFlag("REJECT_BLACKLISTED_BUILDERS", true))
+		require.NoError(t, backend.relay.updateFeatureFlags())
+		require.True(t, backend.relay.ffRejectBlacklistedBuilders.Load())
+	})
+
+	t.Run("removing an override restores the default", func(t *testing.T) {
+		rr := backend.request(http.MethodPost, path+"?FORCE_GET_HEADER_204=&DISABLE_LOWPRIO_BUILDERS=", nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.False(t, backend.relay.ffForceGetHeader204.Load())
+		require.True(t, backend.relay.ffDisableLowPrioBuilders.Load())
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		rr := backend.request(http.MethodPost, path+"?UNKNOWN_FLAG=true", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = backend.request(http.MethodPost, path+"?FORCE_GET_HEADER_204=maybe", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.False(t, getFlags()["FORCE_GET_HEADER_204"])
+	})
+}
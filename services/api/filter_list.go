@@ -0,0 +1,148 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/sirupsen/logrus"
+	uberatomic "go.uber.org/atomic"
+)
+
+var (
+	ErrFilteredAddress    = errors.New("block contains a transaction of a filtered address")
+	ErrInvalidFilterEntry = errors.New("invalid filter list entry")
+
+	// file path or http(s) URL of the filter list, block submissions with transactions from or to its addresses are rejected
+	filterListURI            = os.Getenv("FILTER_LIST_URI")
+	filterListReloadInterval = common.GetEnvDurationSec("FILTER_LIST_RELOAD_INTERVAL_SEC", 300)
+
+	filterListHTTPClient = http.Client{Timeout: 30 * time.Second}
+)
+
+// filterList is a set of addresses which may not send or receive transactions in the blocks of this relay
+type filterList struct {
+	log       *logrus.Entry
+	uri       string
+	addresses uberatomic.Pointer[map[ethcommon.Address]struct{}]
+}
+
+func newFilterList(log *logrus.Entry, uri string) *filterList {
+	return &filterList{
+		log: log.WithField("filterListURI", uri),
+		uri: uri,
+	}
+}
+
+// reload replaces the addresses with the current content of the filter list
+func (f *filterList) reload(ctx context.Context) error {
+	addresses, err := loadFilterList(ctx, f.uri)
+	if err != nil {
+		return err
+	}
+	f.addresses.Store(&addresses)
+	f.log.WithField("numAddresses", len(addresses)).Info("filter list loaded")
+	return nil
+}
+
+// startReloading reloads the filter list periodically, the previous addresses stay in use if reloading fails
+func (f *filterList) startReloading() {
+	ticker := time.NewTicker(filterListReloadInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		err := f.reload(context.Background())
+		if err != nil {
+			f.log.WithError(err).Error("failed to reload filter list")
+		}
+	}
+}
+
+// splitFilterErr separates a rejection by the filter list from the validation errors of a block, so that it isn't
+// counted as a simulation error of the builder
+func splitFilterErr(validationErr error) (simErr, filterErr error) {
+	if errors.Is(validationErr, ErrFilteredAddress) {
+		return nil, validationErr
+	}
+	return validationErr, nil
+}
+
+// check returns ErrFilteredAddress if a transaction is sent from or to a filtered address
+func (f *filterList) check(txs []bellatrix.Transaction) error {
+	addresses := f.addresses.Load()
+	if addresses == nil || len(*addresses) == 0 {
+		return nil
+	}
+
+	for i, rawTx := range txs {
+		tx := new(types.Transaction)
+		err := tx.UnmarshalBinary(rawTx)
+		if err != nil {
+			return fmt.Errorf("failed to decode transaction %d: %w", i, err)
+		}
+		if to := tx.To(); to != nil {
+			if _, found := (*addresses)[*to]; found {
+				return fmt.Errorf("%w: transaction %s to %s", ErrFilteredAddress, tx.Hash().String(), to.String())
+			}
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return fmt.Errorf("failed to recover sender of transaction %d: %w", i, err)
+		}
+		if _, found := (*addresses)[from]; found {
+			return fmt.Errorf("%w: transaction %s from %s", ErrFilteredAddress, tx.Hash().String(), from.String())
+		}
+	}
+	return nil
+}
+
+// loadFilterList reads a filter list from a file or an http(s) URL, with one address per line. Empty lines and
+// lines starting with '#' are ignored.
+func loadFilterList(ctx context.Context, uri string) (map[ethcommon.Address]struct{}, error) {
+	var r io.ReadCloser
+	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := filterListHTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: %d", common.ErrHTTPErrorResponse, resp.StatusCode)
+		}
+		r = resp.Body
+	} else {
+		file, err := os.Open(uri)
+		if err != nil {
+			return nil, err
+		}
+		r = file
+	}
+	defer r.Close()
+
+	addresses := make(map[ethcommon.Address]struct{})
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !ethcommon.IsHexAddress(line) {
+			return nil, fmt.Errorf("%w: line %d: %s", ErrInvalidFilterEntry, lineNum, line)
+		}
+		addresses[ethcommon.HexToAddress(line)] = struct{}{}
+	}
+	return addresses, scanner.Err()
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+const testFilterList = `# filtered addresses
+0x1111111111111111111111111111111111111111
+
+0x2222222222222222222222222222222222222222
+`
+
+// signedTestTransaction returns a raw transaction paying the value to the given address, and its sender
+func signedTestTransaction(t *testing.T, to ethcommon.Address, value int64) (bellatrix.Transaction, ethcommon.Address) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		To:        &to,
+		Value:     big.NewInt(value),
+		Gas:       21000,
+		GasFeeCap: big.NewInt(1),
+	})
+	require.NoError(t, err)
+	rawTx, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return rawTx, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestLoadFilterList(t *testing.T) {
+	expected := map[ethcommon.Address]struct{}{
+		ethcommon.HexToAddress("0x1111111111111111111111111111111111111111"): {},
+		ethcommon.HexToAddress("0x2222222222222222222222222222222222222222"): {},
+	}
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "filter-list.txt")
+		require.NoError(t, os.WriteFile(path, []byte(testFilterList), 0o600))
+		addresses, err := loadFilterList(t.Context(), path)
+		require.NoError(t, err)
+		require.Equal(t, expected, addresses)
+	})
+
+	t.Run("url", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/filter-list.txt" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(testFilterList))
+		}))
+		defer server.Close()
+
+		addresses, err := loadFilterList(t.Context(), server.URL+"/filter-list.txt")
+		require.NoError(t, err)
+		require.Equal(t, expected, addresses)
+
+		_, err = loadFilterList(t.Context(), server.URL+"/missing.txt")
+		require.ErrorIs(t, err, common.ErrHTTPErrorResponse)
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "filter-list.txt")
+		require.NoError(t, os.WriteFile(path, []byte(testFilterList+"0x1234\n"), 0o600))
+		_, err := loadFilterList(t.Context(), path)
+		require.ErrorIs(t, err, ErrInvalidFilterEntry)
+	})
+}
+
+func TestFilterListCheck(t *testing.T) {
+	filteredAddress := ethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	otherAddress := ethcommon.HexToAddress("0x3333333333333333333333333333333333333333")
+	txToFiltered, _ := signedTestTransaction(t, filteredAddress, 0)
+	txToOther, sender := signedTestTransaction(t, otherAddress, 0)
+	txFromOther, _ := signedTestTransaction(t, otherAddress, 0)
+
+	list := newFilterList(common.TestLog, "")
+
+	// Nothing is filtered before the list is loaded
+	require.NoError(t, list.check([]bellatrix.Transaction{txToFiltered}))
+
+	list.addresses.Store(&map[ethcommon.Address]struct{}{filteredAddress: {}})
+	require.NoError(t, list.check([]bellatrix.Transaction{txToOther, txFromOther}))
+	require.ErrorIs(t, list.check([]bellatrix.Transaction{txToOther, txToFiltered}), ErrFilteredAddress)
+	require.Error(t, list.check([]bellatrix.Transaction{{0x03}}))
+
+	list.addresses.Store(&map[ethcommon.Address]struct{}{sender: {}})
+	require.NoError(t, list.check([]bellatrix.Transaction{txFromOther}))
+	require.ErrorIs(t, list.check([]bellatrix.Transaction{txToOther}), ErrFilteredAddress)
+}
+
+func TestSimulateBlockFilterList(t *testing.T) {
+	pubkey, secretkey, backend := startTestBackend(t)
+	backend.relay.blockSimRateLimiter = &MockBlockSimulationRateLimiter{}
+	filteredAddress := ethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	backend.relay.filterList = newFilterList(common.TestLog, "")
+	backend.relay.filterList.addresses.Store(&map[ethcommon.Address]struct{}{filteredAddress: {}})
+
+	simulate := func(to ethcommon.Address) error {
+		rawTx, _ := signedTestTransaction(t, to, 0)
+		payload := common.TestBuilderSubmitBlockRequest(secretkey, getTestBidTrace(*pubkey, collateral, slot), spec.DataVersionDeneb)
+		payload.Deneb.ExecutionPayload.Transactions = []bellatrix.Transaction{rawTx}
+		_, _, requestErr, validationErr := backend.relay.simulateBlock(t.Context(), blockSimOptions{
+			log: backend.relay.log,
+			req: &common.BuilderBlockValidationRequest{VersionedSubmitBlockRequest: payload},
+		})
+		require.NoError(t, requestErr)
+		return validationErr
+	}
+
+	require.NoError(t, simulate(ethcommon.HexToAddress("0x3333333333333333333333333333333333333333")))
+	require.ErrorIs(t, simulate(filteredAddress), ErrFilteredAddress)
+}
+
+func TestSaveFilteredBlockSubmission(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	db := &blockSubmissionRecorderDB{}
+	backend.relay.db = db
+
+	// a rejection by the filter list is saved as such, and not as a simulation error
+	filterErr := fmt.Errorf("%w: transaction 0x01 to 0x02", ErrFilteredAddress)
+	simErr, filterErr := splitFilterErr(filterErr)
+	require.NoError(t, simErr)
+	payload, _, _ := common.CreateTestBlockSubmission(t, testBuilderPubkey, uint256.NewInt(1), &common.CreateTestBlockSubmissionOpts{Slot: testSlot})
+	backend.relay.saveBlockSubmissions([]*blockSubmissionDBTask{{
+		payload:   payload,
+		simResult: &blockSimResult{wasSimulated: true, filterErr: filterErr},
+		log:       backend.relay.log,
+	}})
+
+	require.Len(t, db.submissions, 1)
+	require.False(t, db.submissions[0].SimSuccess)
+	require.Empty(t, db.submissions[0].SimError)
+	require.Equal(t, filterErr.Error(), db.submissions[0].FilterError)
+
+	// other errors stay validation errors
+	simErr, filterErr = splitFilterErr(errFake)
+	require.ErrorIs(t, simErr, errFake)
+	require.NoError(t, filterErr)
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/sirupsen/logrus"
+)
+
+const internalAPIActorUnauthenticated = "unauthenticated"
+
+var (
+	ErrInvalidInternalAPITokens     = errors.New("invalid INTERNAL_API_TOKENS, expected a comma separated list of actor:token")
+	ErrInternalAPIWithoutTokens     = errors.New("cannot start internal API without INTERNAL_API_TOKENS (set INTERNAL_API_ALLOW_UNAUTHENTICATED=1 to allow unauthenticated requests)")
+	internalAPIAllowUnauthenticated = os.Getenv("INTERNAL_API_ALLOW_UNAUTHENTICATED") == "1"
+)
+
+// parseInternalAPITokens parses a comma separated list of actor:token pairs, and returns the actors by token
+func parseInternalAPITokens(s string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		actor, token, found := strings.Cut(entry, ":")
+		if !found || actor == "" || token == "" {
+			return nil, ErrInvalidInternalAPITokens
+		}
+		tokens[token] = actor
+	}
+	return tokens, nil
+}
+
+// authenticateInternalAPIRequest returns the actor of the request by its bearer token. If no tokens are configured,
+// all requests are allowed.
+func (api *RelayAPI) authenticateInternalAPIRequest(req *http.Request) (actor string, ok bool) {
+	if len(api.internalAPITokens) == 0 {
+		return internalAPIActorUnauthenticated, true
+	}
+
+	reqToken, found := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return "", false
+	}
+	for token, actor := range api.internalAPITokens {
+		if subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) == 1 {
+			return actor, true
+		}
+	}
+	return "", false
+}
+
+// internalAPIMiddleware authenticates requests to the internal API, and writes state-changing calls to the audit log
+func (api *RelayAPI) internalAPIMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		log := api.log.WithFields(logrus.Fields{
+			"method":     "internalAPI",
+			"path":       req.URL.Path,
+			"reqMethod":  req.Method,
+			"remoteAddr": req.RemoteAddr,
+		})
+
+		actor, ok := api.authenticateInternalAPIRequest(req)
+		if !ok {
+			log.Warn("unauthorized internal API request")
+			api.RespondError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+
+		if req.Method == http.MethodGet {
+			next(w, req)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, req)
+
+		entry := database.InternalAPIAuditEntry{
+			Actor:      actor,
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Params:     req.URL.RawQuery,
+			StatusCode: rec.status,
+			RemoteAddr: req.RemoteAddr,
+		}
+		log = log.WithFields(logrus.Fields{
+			"actor":      entry.Actor,
+			"params":     entry.Params,
+			"statusCode": entry.StatusCode,
+		})
+		log.Info("internal API call")
+		err := api.db.InsertInternalAPIAuditEntry(entry)
+		if err != nil {
+			log.WithError(err).Error("failed to save internal API call to the audit log")
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/beaconclient"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+type auditMockDB struct {
+	database.MockDB
+	entries []database.InternalAPIAuditEntry
+}
+
+func (db *auditMockDB) InsertInternalAPIAuditEntry(entry database.InternalAPIAuditEntry) error {
+	db.entries = append(db.entries, entry)
+	return nil
+}
+
+func TestParseInternalAPITokens(t *testing.T) {
+	tokens, err := parseInternalAPITokens("")
+	require.NoError(t, err)
+	require.Empty(t, tokens)
+
+	tokens, err = parseInternalAPITokens("alice:secret1, bob:secret2")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"secret1": "alice", "secret2": "bob"}, tokens)
+
+	_, err = parseInternalAPITokens("alice")
+	require.ErrorIs(t, err, ErrInvalidInternalAPITokens)
+
+	_, err = parseInternalAPITokens("alice:")
+	require.ErrorIs(t, err, ErrInvalidInternalAPITokens)
+}
+
+func TestInternalAPIWithoutTokens(t *testing.T) {
+	backend := newTestBackend(t, 1)
+
+	// the internal API is only enabled without tokens if explicitly allowed
+	prevAllowUnauthenticated := internalAPIAllowUnauthenticated
+	internalAPIAllowUnauthenticated = false
+	t.Cleanup(func() { internalAPIAllowUnauthenticated = prevAllowUnauthenticated })
+	_, err := NewRelayAPI(backend.relay.opts)
+	require.ErrorIs(t, err, ErrInternalAPIWithoutTokens)
+
+	opts := backend.relay.opts
+	opts.InternalAPI = false
+	_, err = NewRelayAPI(opts)
+	require.NoError(t, err)
+}
+
+func TestInternalAPIMiddleware(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	db := &auditMockDB{}
+	backend.relay.db = db
+	backend.relay.internalAPITokens = map[string]string{"secret": "alice"}
+
+	request := func(method, token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, pathInternalFeatureFlags+"?FORCE_GET_HEADER_204=true", nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		backend.relay.getRouter().ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("rejects requests without a valid token", func(t *testing.T) {
+		rr := request(http.MethodGet, "")
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		rr = request(http.MethodPost, "wrong")
+		require.Equal(t, http.StatusUnauthorized, rr.Code)
+		require.False(t, backend.relay.ffForceGetHeader204.Load())
+		require.Empty(t, db.entries)
+	})
+
+	t.Run("read-only calls are not audited", func(t *testing.T) {
+		rr := request(http.MethodGet, "secret")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Empty(t, db.entries)
+	})
+
+	t.Run("state-changing calls are audited", func(t *testing.T) {
+		rr := request(http.MethodPost, "secret")
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.True(t, backend.relay.ffForceGetHeader204.Load())
+		require.Len(t, db.entries, 1)
+		require.Equal(t, "alice", db.entries[0].Actor)
+		require.Equal(t, http.MethodPost, db.entries[0].Method)
+		require.Equal(t, pathInternalFeatureFlags, db.entries[0].Path)
+		require.Equal(t, "FORCE_GET_HEADER_204=true", db.entries[0].Params)
+		require.Equal(t, http.StatusOK, db.entries[0].StatusCode)
+	})
+}
+
+func TestInternalBuilders(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	builders := make(map[string]*database.BlockBuilderEntry)
+	for i := 1; i <= 3; i++ {
+		pubkey := fmt.Sprintf("0x%02d", i)
+		builders[pubkey] = &database.BlockBuilderEntry{ID: int64(i), BuilderPubkey: pubkey, IsOptimistic: true, Collateral: "1000", LastSubmissionSlot: uint64(i)}
+	}
+	backend.relay.db = database.MockDB{Builders: builders, Demotions: map[string]bool{"0x02": true}}
+	backend.relay.blockBuildersCache = map[string]*blockBuilderCacheEntry{
+		"0x01": {status: common.BuilderStatus{IsOptimistic: true}, collateral: big.NewInt(1000)},
+		"0x02": {status: common.BuilderStatus{IsOptimistic: false}, collateral: big.NewInt(1000)},
+	}
+
+	getBuilders := func(query string) []InternalBuilderEntry {
+		rr := backend.request(http.MethodGet, pathInternalBuilders+query, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+		entries := []InternalBuilderEntry{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &entries))
+		return entries
+	}
+
+	entries := getBuilders("")
+	require.Len(t, entries, 3)
+	require.True(t, entries[0].IsOptimisticEligible)
+	require.False(t, entries[1].IsOptimisticEligible) // demoted, not optimistic anymore
+	require.True(t, entries[1].IsOptimistic)
+	require.Equal(t, uint64(1), entries[1].NumDemotions)
+	require.False(t, entries[2].IsOptimisticEligible) // not in cache
+
+	require.Equal(t, int64(backend.relay.genesisInfo.Data.GenesisTime+common.SecondsPerSlot), entries[0].LastSubmissionTime.Unix()) //nolint:gosec
+
+	// pagination
+	entries = getBuilders("?limit=2")
+	require.Len(t, entries, 2)
+	require.Equal(t, "0x02", entries[1].BuilderPubkey)
+	entries = getBuilders(fmt.Sprintf("?limit=2&cursor=%d", entries[1].ID))
+	require.Len(t, entries, 1)
+	require.Equal(t, "0x03", entries[0].BuilderPubkey)
+
+	rr := backend.request(http.MethodGet, pathInternalBuilders+"?limit=1000", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestInternalBuilderIdentities(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	builders := map[string]*database.BlockBuilderEntry{
+		"0x01": {ID: 1, BuilderPubkey: "0x01"},
+		"0x02": {ID: 2, BuilderPubkey: "0x02"},
+	}
+	db := database.MockDB{Builders: builders, Identities: make(map[string]*database.BuilderIdentityEntry)}
+	backend.relay.db = db
+	require.NoError(t, backend.redis.SetBlockBuilderStatus("0x01", common.BuilderStatus{}))
+
+	// pubkeys can only be added to existing identities
+	path := "/internal/v1/builder-identities/builder-a"
+	rr := backend.request(http.MethodPost, path+"/0x01", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = backend.request(http.MethodPost, path+"?description=Builder+A&high_prio=true", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = backend.request(http.MethodPost, path+"/0x01", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = backend.request(http.MethodPut, path+"/0x02", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.True(t, builders["0x02"].IsHighPrio)
+
+	// the status of the identity applies to all of its pubkeys, and replaces automatic demotions
+	rr = backend.request(http.MethodPost, path+"?blacklisted=true", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	identity := InternalBuilderIdentityEntry{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &identity))
+	require.Equal(t, "Builder A", identity.Description)
+	require.True(t, identity.IsHighPrio)
+	require.True(t, identity.IsBlacklisted)
+	require.ElementsMatch(t, []string{"0x01", "0x02"}, identity.BuilderPubkeys)
+	for _, builder := range builders {
+		require.True(t, builder.IsBlacklisted)
+	}
+	statuses, err := backend.redis.GetBlockBuilderStatuses()
+	require.NoError(t, err)
+	require.Empty(t, statuses)
+
+	// removing a pubkey
+	rr = backend.request(http.MethodDelete, "/internal/v1/builder-identities/builder-b/0x02", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = backend.request(http.MethodDelete, path+"/0x02", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = backend.request(http.MethodGet, pathInternalBuilderIdentities, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	identities := []InternalBuilderIdentityEntry{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &identities))
+	require.Len(t, identities, 1)
+	require.Equal(t, []string{"0x01"}, identities[0].BuilderPubkeys)
+}
+
+func TestInternalRestoreRegistrations(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	pubkey := common.NewPubkeyHex(fmt.Sprintf("0x%096x", 1))
+	ds, err := datastore.NewDatastore(backend.redis, nil, database.MockDB{ValidatorRegistrations: []*database.ValidatorRegistrationEntry{
+		{Pubkey: pubkey.String(), Timestamp: 100},
+	}})
+	require.NoError(t, err)
+	backend.relay.datastore = ds
+
+	rr := backend.request(http.MethodPost, pathInternalRestoreRegistrations, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := RestoreRegistrationsResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, 1, resp.NumRegistrations)
+
+	timestamp, err := backend.redis.GetValidatorRegistrationTimestamp(pubkey)
+	require.NoError(t, err)
+	require.Equal(t, uint64(100), timestamp)
+}
+
+func TestInternalInvalidateBids(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.genesisInfo = &beaconclient.GetGenesisResponse{
+		Data: beaconclient.GetGenesisResponseData{
+			GenesisTime: uint64(time.Now().UTC().Unix()), //nolint:gosec
+		},
+	}
+
+	slot := uint64(2)
+	backend.relay.headSlot.Store(slot)
+	parentHash := "0x13e606c7b3d1faad7e83503ce3dedce4c6bb89b0c28ffb240d713c7b110b9747"
+	proposerPubkey := "0x6ae5932d1e248d987d51b58665b81848814202d7b23b343d20f2a167d12f07dcb01ca41c42fdd60b7fca9c4b90890792"
+	builderPubkey := "0xfa1ed37c3553d0ce1e9349b2c5063cf6e394d231c8d3e0df75e9462257c081543086109ffddaacc0aa76f33dc9661c83"
+	opts := common.CreateTestBlockSubmissionOpts{
+		Slot:           slot,
+		ParentHash:     parentHash,
+		ProposerPubkey: proposerPubkey,
+	}
+	payload, getPayloadResp, getHeaderResp := common.CreateTestBlockSubmission(t, builderPubkey, uint256.NewInt(99), &opts)
+	trace := &common.BidTraceV2WithBlobFields{BidTrace: *payload.Capella.Message}
+	_, err := backend.redis.SaveBidAndUpdateTopBid(t.Context(), backend.redis.NewPipeline(), trace, payload, getPayloadResp, getHeaderResp, time.Now(), false, nil)
+	require.NoError(t, err)
+
+	getHeaderPath := fmt.Sprintf("/eth/v1/builder/header/%d/%s/%s", slot, parentHash, proposerPubkey)
+	rr := backend.request(http.MethodGet, getHeaderPath, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = backend.request(http.MethodPost, pathInternalInvalidateBids+"?slot=abc", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = backend.request(http.MethodPost, fmt.Sprintf("%s?slot=%d&builder_pubkey=0x1234", pathInternalInvalidateBids, slot), nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = backend.request(http.MethodPost, fmt.Sprintf("%s?slot=%d&builder_pubkey=%s", pathInternalInvalidateBids, slot, builderPubkey), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := InvalidateBidsResponse{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, InvalidateBidsResponse{Slot: slot, BuilderPubkey: builderPubkey, NumDeleted: 1}, resp)
+
+	rr = backend.request(http.MethodGet, getHeaderPath, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	isInvalidated, err := backend.redis.IsBidInvalidated(t.Context(), slot, builderPubkey)
+	require.NoError(t, err)
+	require.True(t, isInvalidated)
+}
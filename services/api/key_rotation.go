@@ -0,0 +1,99 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/sirupsen/logrus"
+)
+
+// checkRelayPubkey ensures the pubkey is the same across all relay instances. During a key rotation, instances may
+// also use the next pubkey, so that instances with either key serve bids until the rotation is completed.
+func checkRelayPubkey(redis *datastore.RedisCache, log *logrus.Entry, pubkey string) error {
+	relayPubkey, err := redis.GetRelayConfig(datastore.RedisConfigFieldPubkey)
+	if err != nil {
+		return err
+	}
+	nextPubkey, err := redis.GetRelayConfig(datastore.RedisConfigFieldNextPubkey)
+	if err != nil {
+		return err
+	}
+
+	switch pubkey {
+	case relayPubkey:
+		return nil
+	case nextPubkey:
+		log.Warnf("Using the next relay pubkey of the key rotation, the relay pubkey is still %s", relayPubkey)
+		return nil
+	}
+	if relayPubkey == "" {
+		return redis.SetRelayConfig(datastore.RedisConfigFieldPubkey, pubkey)
+	}
+	return fmt.Errorf("%w: new=%s old=%s next=%s", ErrRelayPubkeyMismatch, pubkey, relayPubkey, nextPubkey)
+}
+
+// handleInternalRelayPubkey returns the relay pubkey and the next pubkey of a key rotation. A rotation is started by
+// registering the next pubkey on POST (i.e. ?next_pubkey=0x...), completed on PUT by making the next pubkey the relay
+// pubkey, and cancelled on DELETE.
+func (api *RelayAPI) handleInternalRelayPubkey(w http.ResponseWriter, req *http.Request) {
+	relayPubkey, err := api.redis.GetRelayConfig(datastore.RedisConfigFieldPubkey)
+	if err != nil {
+		api.log.WithError(err).Error("could not get relay pubkey")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	nextPubkey, err := api.redis.GetRelayConfig(datastore.RedisConfigFieldNextPubkey)
+	if err != nil {
+		api.log.WithError(err).Error("could not get next relay pubkey")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log := api.log.WithFields(logrus.Fields{
+		"relayPubkey": relayPubkey,
+		"nextPubkey":  nextPubkey,
+	})
+
+	switch req.Method {
+	case http.MethodPost:
+		pk, err := common.StrToPhase0Pubkey(req.URL.Query().Get("next_pubkey"))
+		if err != nil {
+			api.RespondError(w, http.StatusBadRequest, "invalid next_pubkey argument")
+			return
+		} else if pk.String() == relayPubkey {
+			api.RespondError(w, http.StatusBadRequest, "next_pubkey is already the relay pubkey")
+			return
+		}
+		nextPubkey = pk.String()
+		err = api.redis.SetRelayConfig(datastore.RedisConfigFieldNextPubkey, nextPubkey)
+		log = log.WithField("nextPubkey", nextPubkey)
+	case http.MethodPut:
+		if nextPubkey == "" {
+			api.RespondError(w, http.StatusBadRequest, "no key rotation in progress")
+			return
+		}
+		err = api.redis.CompleteRelayPubkeyRotation(nextPubkey)
+		relayPubkey, nextPubkey = nextPubkey, ""
+	case http.MethodDelete:
+		err = api.redis.DelRelayConfig(datastore.RedisConfigFieldNextPubkey)
+		nextPubkey = ""
+	}
+	if err != nil {
+		log.WithError(err).Error("could not update relay pubkey")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if req.Method != http.MethodGet {
+		log.Warn("updated relay key rotation")
+	}
+
+	response := RelayPubkeyResponse{
+		RelayPubkey: relayPubkey,
+		NextPubkey:  nextPubkey,
+	}
+	if api.opts.BlockBuilderAPI {
+		response.InstancePubkey = api.publicKey.String()
+	}
+	api.RespondOK(w, response)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRelayPubkey(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	relayPubkey := backend.relay.publicKey.String()
+	nextPubkey := common.NewTestSigner(t).PublicKey().String()
+
+	err := checkRelayPubkey(backend.redis, common.TestLog, nextPubkey)
+	require.ErrorIs(t, err, ErrRelayPubkeyMismatch)
+
+	require.NoError(t, backend.redis.SetRelayConfig(datastore.RedisConfigFieldNextPubkey, nextPubkey))
+	require.NoError(t, checkRelayPubkey(backend.redis, common.TestLog, nextPubkey))
+	require.NoError(t, checkRelayPubkey(backend.redis, common.TestLog, relayPubkey))
+
+	require.NoError(t, backend.redis.CompleteRelayPubkeyRotation(nextPubkey))
+	err = checkRelayPubkey(backend.redis, common.TestLog, relayPubkey)
+	require.ErrorIs(t, err, ErrRelayPubkeyMismatch)
+}
+
+func TestInternalRelayPubkey(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	relayPubkey := backend.relay.publicKey.String()
+	nextPubkey := common.NewTestSigner(t).PublicKey().String()
+	path := pathInternalRelayPubkey
+
+	request := func(method, url string) RelayPubkeyResponse {
+		rr := backend.request(method, url, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		resp := RelayPubkeyResponse{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("start rotation", func(t *testing.T) {
+		resp := request(http.MethodPost, path+"?next_pubkey="+nextPubkey)
+		require.Equal(t, RelayPubkeyResponse{RelayPubkey: relayPubkey, NextPubkey: nextPubkey, InstancePubkey: relayPubkey}, resp)
+		require.Equal(t, resp, request(http.MethodGet, path))
+	})
+
+	t.Run("cancel rotation", func(t *testing.T) {
+		resp := request(http.MethodDelete, path)
+		require.Equal(t, RelayPubkeyResponse{RelayPubkey: relayPubkey, InstancePubkey: relayPubkey}, resp)
+
+		rr := backend.request(http.MethodPut, path, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("complete rotation", func(t *testing.T) {
+		request(http.MethodPost, path+"?next_pubkey="+nextPubkey)
+		resp := request(http.MethodPut, path)
+		require.Equal(t, RelayPubkeyResponse{RelayPubkey: nextPubkey, InstancePubkey: relayPubkey}, resp)
+
+		pubkey, err := backend.redis.GetRelayConfig(datastore.RedisConfigFieldPubkey)
+		require.NoError(t, err)
+		require.Equal(t, nextPubkey, pubkey)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		rr := backend.request(http.MethodPost, path+"?next_pubkey=0x123", nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+
+		rr = backend.request(http.MethodPost, path+"?next_pubkey="+nextPubkey, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -199,7 +199,7 @@ func TestSimulateBlock(t *testing.T) {
 			backend.relay.blockSimRateLimiter = &MockBlockSimulationRateLimiter{
 				simulationError: tc.simulationError,
 			}
-			_, _, simErr := backend.relay.simulateBlock(t.Context(), blockSimOptions{
+			_, _, _, simErr := backend.relay.simulateBlock(t.Context(), blockSimOptions{
 				isHighPrio: true,
 				log:        backend.relay.log,
 				builder: &blockBuilderCacheEntry{
@@ -548,3 +548,26 @@ func TestInternalBuilderCollateral(t *testing.T) {
 	require.Equal(t, "builder0x69", resp.BuilderID)
 	require.Equal(t, "10000", resp.Collateral)
 }
+
+func TestInternalBlacklistedBuilders(t *testing.T) {
+	pubkey, _, backend := startTestBackend(t)
+	path := "/internal/v1/builders/blacklisted"
+
+	// No blacklisted builders.
+	rr := backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := []*database.BlockBuilderEntry{}
+	err := json.Unmarshal(rr.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Empty(t, resp)
+
+	// Blacklist the builder.
+	err = backend.relay.db.SetBlockBuilderStatus(pubkey.String(), common.BuilderStatus{IsBlacklisted: true})
+	require.NoError(t, err)
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	err = json.Unmarshal(rr.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Len(t, resp, 1)
+	require.Equal(t, pubkey.String(), resp[0].BuilderPubkey)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+)
+
+var (
+	// redis is pinged this often, and the API reports not ready while it is degraded (0 to disable)
+	redisHealthCheckInterval = time.Duration(cli.GetEnvInt("REDIS_HEALTH_CHECK_INTERVAL_MS", 1000)) * time.Millisecond
+	redisHealthCheckTimeout  = time.Duration(cli.GetEnvInt("REDIS_HEALTH_CHECK_TIMEOUT_MS", 500)) * time.Millisecond
+	// number of consecutive failed pings after which redis is considered degraded
+	redisHealthCheckMaxFailures = cli.GetEnvInt("REDIS_HEALTH_CHECK_MAX_FAILURES", 3)
+)
+
+// checkRedisHealth pings redis and updates the degraded state, which is set after redisHealthCheckMaxFailures
+// consecutive failures and cleared by the first successful ping. Returns the updated number of consecutive failures.
+func (api *RelayAPI) checkRedisHealth(failures int) int {
+	ctx, cancel := context.WithTimeout(context.Background(), redisHealthCheckTimeout)
+	defer cancel()
+
+	err := api.redis.Ping(ctx)
+	if err == nil {
+		if api.redisDegraded.Swap(false) {
+			api.log.Info("redis recovered, service is ready again")
+		}
+		return 0
+	}
+
+	failures++
+	api.log.WithError(err).WithField("failures", failures).Warn("redis health check failed")
+	if failures >= redisHealthCheckMaxFailures && !api.redisDegraded.Swap(true) {
+		api.log.WithError(err).Error("redis is degraded, service is not ready")
+	}
+	return failures
+}
+
+// startRedisHealthChecks checks redis every redisHealthCheckInterval until the server is stopped
+func (api *RelayAPI) startRedisHealthChecks() {
+	defer api.redisHealthLoopWG.Done()
+	failures := 0
+	ticker := time.NewTicker(redisHealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-api.redisHealthStop:
+			return
+		case <-ticker.C:
+			failures = api.checkRedisHealth(failures)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRedisHealth(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.datastore.KnownValidatorsWasUpdated.Store(true)
+	require.True(t, backend.relay.IsReady())
+
+	redisTestServer, err := miniredis.Run()
+	require.NoError(t, err)
+	backend.relay.redis, err = datastore.NewRedisCache("", redisTestServer.Addr(), "")
+	require.NoError(t, err)
+
+	// single failed pings don't affect readiness
+	redisTestServer.SetError("LOADING")
+	failures := 0
+	for range redisHealthCheckMaxFailures - 1 {
+		failures = backend.relay.checkRedisHealth(failures)
+		require.True(t, backend.relay.IsReady())
+	}
+
+	// consecutive failures make the service not ready
+	failures = backend.relay.checkRedisHealth(failures)
+	require.Equal(t, redisHealthCheckMaxFailures, failures)
+	require.False(t, backend.relay.IsReady())
+
+	// until redis responds again
+	redisTestServer.SetError("")
+	failures = backend.relay.checkRedisHealth(failures)
+	require.Equal(t, 0, failures)
+	require.True(t, backend.relay.IsReady())
+}
+
+func TestRedisHealthChecksStop(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.redisHealthLoopWG.Add(1)
+	go backend.relay.startRedisHealthChecks()
+
+	close(backend.relay.redisHealthStop)
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	require.True(t, waitWithTimeout(ctx, &backend.relay.redisHealthLoopWG))
+}
@@ -3,16 +3,23 @@ package api
 
 import (
 	"bytes"
+	"cmp"
 	"compress/gzip"
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
+	"net/netip"
 	"os"
+	"runtime/debug"
 	"slices"
 	"sort"
 	"strconv"
@@ -24,19 +31,21 @@ import (
 	"github.com/aohorodnyk/mimeheader"
 	builderApi "github.com/attestantio/go-builder-client/api"
 	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
+	builderSpec "github.com/attestantio/go-builder-client/spec"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/buger/jsonparser"
-	"github.com/flashbots/go-boost-utils/bls"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/flashbots/go-boost-utils/ssz"
 	"github.com/flashbots/go-boost-utils/utils"
 	"github.com/flashbots/go-utils/cli"
-	"github.com/flashbots/go-utils/httplogger"
 	"github.com/flashbots/mev-boost-relay/beaconclient"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/flashbots/mev-boost-relay/database"
 	"github.com/flashbots/mev-boost-relay/datastore"
 	"github.com/flashbots/mev-boost-relay/metrics"
+	"github.com/flashbots/mev-boost-relay/tracing"
 	"github.com/gorilla/mux"
 	"github.com/holiman/uint256"
 	"github.com/pkg/errors"
@@ -60,8 +69,13 @@ var (
 	ErrMissingDatastoreOpt        = errors.New("proposer datastore is nil")
 	ErrRelayPubkeyMismatch        = errors.New("relay pubkey does not match existing one")
 	ErrServerAlreadyStarted       = errors.New("server was already started")
-	ErrBuilderAPIWithoutSecretKey = errors.New("cannot start builder API without secret key")
+	ErrBuilderAPIWithoutSecretKey = errors.New("cannot start builder API without secret key or remote signer")
 	ErrNegativeTimestamp          = errors.New("timestamp cannot be negative")
+	ErrBuilderBlacklisted         = errors.New("builder is blacklisted")
+	ErrBidsInvalidated            = errors.New("bids for this slot were invalidated")
+	ErrBuilderBlockedByProposer   = errors.New("builder is blocked by the proposer")
+	ErrProposerPaymentNotLast     = errors.New("block does not end with the payment to the proposer fee recipient")
+	ErrMismatchedSlotDuration     = errors.New("slot duration does not match the beacon node")
 )
 
 var (
@@ -71,6 +85,9 @@ var (
 	pathGetHeader         = "/eth/v1/builder/header/{slot:[0-9]+}/{parent_hash:0x[a-fA-F0-9]+}/{pubkey:0x[a-fA-F0-9]+}"
 	pathGetPayload        = "/eth/v1/builder/blinded_blocks"
 
+	// Proposer API (relay-specific)
+	pathProposerPreferences = "/relay/v1/proposer/preferences"
+
 	// Block builder API
 	pathBuilderGetValidators = "/relay/v1/builder/validators"
 	pathSubmitNewBlock       = "/relay/v1/builder/blocks"
@@ -79,37 +96,112 @@ var (
 	pathDataProposerPayloadDelivered = "/relay/v1/data/bidtraces/proposer_payload_delivered"
 	pathDataBuilderBidsReceived      = "/relay/v1/data/bidtraces/builder_blocks_received"
 	pathDataValidatorRegistration    = "/relay/v1/data/validator_registration"
+	pathDataValidatorRegHistory      = "/relay/v1/data/validator_registration_history"
+	pathDataStream                   = "/relay/v1/data/stream"
+	pathDataStats                    = "/relay/v1/data/stats"
+	pathDataBuilderStats             = "/relay/v1/data/builder_stats"
+	pathDataGetHeaderLog             = "/relay/v1/data/get_header_log"
+	pathDataGetPayloadFailures       = "/relay/v1/data/get_payload_failures"
 
 	// Internal API
-	pathInternalBuilderStatus     = "/internal/v1/builder/{pubkey:0x[a-fA-F0-9]+}"
-	pathInternalBuilderCollateral = "/internal/v1/builder/collateral/{pubkey:0x[a-fA-F0-9]+}"
+	pathInternalBuilderStatus        = "/internal/v1/builder/{pubkey:0x[a-fA-F0-9]+}"
+	pathInternalBuilderCollateral    = "/internal/v1/builder/collateral/{pubkey:0x[a-fA-F0-9]+}"
+	pathInternalBlacklistedBuilders  = "/internal/v1/builders/blacklisted"
+	pathInternalBuilders             = "/internal/v1/builders"
+	pathInternalBuilderIdentities    = "/internal/v1/builder-identities"
+	pathInternalBuilderIdentity      = "/internal/v1/builder-identities/{builder_id:[a-zA-Z0-9_.-]+}"
+	pathInternalBuilderIdentityKey   = "/internal/v1/builder-identities/{builder_id:[a-zA-Z0-9_.-]+}/{pubkey:0x[a-fA-F0-9]+}"
+	pathInternalCircuitBreaker       = "/internal/v1/circuit-breaker"
+	pathInternalFeatureFlags         = "/internal/v1/feature-flags"
+	pathInternalBuilderIPFilter      = "/internal/v1/builder-ip-filter"
+	pathInternalInvalidateBids       = "/internal/v1/invalidate-bids"
+	pathInternalDataAPIKeys          = "/internal/v1/data-api-keys"
+	pathInternalRelayPubkey          = "/internal/v1/relay-pubkey"
+	pathInternalRestoreRegistrations = "/internal/v1/validator-registrations/restore"
+
+	// page sizes of the internal builders list
+	internalBuildersDefaultLimit uint64 = 100
+	internalBuildersMaxLimit     uint64 = 500
+
+	// day ranges of the data API stats
+	dataStatsDefaultDays = 30
+	dataStatsMaxDays     = 366
+
+	// maximum epoch range of the data API builder stats by epoch
+	dataBuilderStatsMaxEpochs uint64 = 1_000
+
+	// maximum number of entries of a getHeader log and getPayload failures query
+	dataGetHeaderLogMaxLimit       uint64 = 500
+	dataGetPayloadFailuresMaxLimit uint64 = 500
+
+	// reason codes of the saved failed getPayload requests (with a valid proposer signature), which can be used to
+	// filter them
+	getPayloadFailureReasons = []string{
+		database.GetPayloadFailureBidInvalidated,
+		database.GetPayloadFailureEquivocation,
+		database.GetPayloadFailureUnknownPayload,
+		database.GetPayloadFailureAlreadyDelivered,
+		database.GetPayloadFailureTooLate,
+		database.GetPayloadFailureInvalidHeader,
+		database.GetPayloadFailurePublishFailed,
+	}
+
+	// maximum number of validators of a validator registrations lookup, and the body limit of a lookup of that many
+	// pubkeys, which take 101 bytes as JSON strings with a separator, allowing for some whitespace
+	dataValidatorRegistrationsMaxPubkeys   = 5000
+	dataValidatorRegistrationsMaxBodyBytes = dataValidatorRegistrationsMaxPubkeys * 128
+
+	// maximum number of registrations of a validator registration history lookup
+	dataValidatorRegHistoryMaxLimit uint64 = 500
 
 	// number of goroutines to save active validator
 	numValidatorRegProcessors = cli.GetEnvInt("NUM_VALIDATOR_REG_PROCESSORS", 10)
+	numValidatorRegVerifiers  = cli.GetEnvInt("NUM_VALIDATOR_REG_VERIFIERS", 8) // signature verification workers per registerValidator request
+	validatorRegBatchSize     = cli.GetEnvInt("VALIDATOR_REG_BATCH_SIZE", 500)
+	validatorRegMaxAgeDays    = cli.GetEnvInt("VALIDATOR_REG_MAX_AGE_DAYS", 0) // 0 to accept registrations of any age since genesis
+
+	// number of goroutines to save builder block submissions, and the size of their queue
+	numBlockSubmissionDBProcessors = cli.GetEnvInt("NUM_BLOCK_SUBMISSION_DB_PROCESSORS", 4)
+	blockSubmissionDBBatchSize     = cli.GetEnvInt("BLOCK_SUBMISSION_DB_BATCH_SIZE", 100)
+	blockSubmissionDBQueueSize     = cli.GetEnvInt("BLOCK_SUBMISSION_DB_QUEUE_SIZE", 10_000)
+
+	// the served getHeader bids are saved by one goroutine in batches, and the size of its queue
+	getHeaderLogDBBatchSize = cli.GetEnvInt("GETHEADER_LOG_DB_BATCH_SIZE", 500)
+	getHeaderLogDBQueueSize = cli.GetEnvInt("GETHEADER_LOG_DB_QUEUE_SIZE", 10_000)
+
+	// getPayload requests of the same block hash are processed one at a time, holding this lock at most for a slot
+	getPayloadLockPrefix       = "getpayload-publish:"
+	getPayloadLockTTL          = 12 * time.Second
+	getPayloadLockPollInterval = 20 * time.Millisecond
 
 	// various timings
 	timeoutGetPayloadRetryMs  = cli.GetEnvInt("GETPAYLOAD_RETRY_TIMEOUT_MS", 100)
 	getHeaderRequestCutoffMs  = cli.GetEnvInt("GETHEADER_REQUEST_CUTOFF_MS", 3000)
+	getHeaderDeadlineMs       = cli.GetEnvInt("GETHEADER_DEADLINE_MS", 300) // 0 to wait for Redis without a deadline
 	getPayloadRequestCutoffMs = cli.GetEnvInt("GETPAYLOAD_REQUEST_CUTOFF_MS", 4000)
 	getPayloadResponseDelayMs = cli.GetEnvInt("GETPAYLOAD_RESPONSE_DELAY_MS", 1000)
+	getPayloadRevealMs        = cli.GetEnvInt("GETPAYLOAD_REVEAL_MS", 0)
+	submitBlockCutoffMs       = cli.GetEnvInt("SUBMIT_BLOCK_REQUEST_CUTOFF_MS", 0) // 0 to accept submissions at any time into the slot
 
 	// api settings
-	apiReadTimeoutMs       = cli.GetEnvInt("API_TIMEOUT_READ_MS", 1500)
-	apiReadHeaderTimeoutMs = cli.GetEnvInt("API_TIMEOUT_READHEADER_MS", 600)
-	apiIdleTimeoutMs       = cli.GetEnvInt("API_TIMEOUT_IDLE_MS", 3_000)
-	apiWriteTimeoutMs      = cli.GetEnvInt("API_TIMEOUT_WRITE_MS", 10_000)
-	apiMaxHeaderBytes      = cli.GetEnvInt("API_MAX_HEADER_BYTES", 60_000)
-	apiMaxPayloadBytes     = cli.GetEnvInt("API_MAX_PAYLOAD_BYTES", 15*1024*1024) // 15 MiB
+	apiMaxHeaderBytes = cli.GetEnvInt("API_MAX_HEADER_BYTES", 60_000)
 
 	// api shutdown: wait time (to allow removal from load balancer before stopping http server)
 	apiShutdownWaitDuration = common.GetEnvDurationSec("API_SHUTDOWN_WAIT_SEC", 30)
 
+	// api shutdown: maximum time to wait for in-flight requests and pending database writes to finish
+	apiShutdownTimeout = common.GetEnvDurationSec("API_SHUTDOWN_TIMEOUT_SEC", 30)
+
 	// api shutdown: whether to stop sending bids during shutdown phase (only useful if running a single-instance testnet setup)
 	apiShutdownStopSendingBids = os.Getenv("API_SHUTDOWN_STOP_SENDING_BIDS") == "1"
 
 	// maximum payload bytes for a block submission to be fast-tracked (large payloads slow down other fast-tracked requests!)
 	fastTrackPayloadSizeLimit = cli.GetEnvInt("FAST_TRACK_PAYLOAD_SIZE_LIMIT", 230_000)
 
+	// minimum builder scores (0-100) for high-prio and optimistic processing, if builder scores are enabled
+	builderScoreHighPrioMin   = float64(cli.GetEnvInt("BUILDER_SCORE_HIGH_PRIO_MIN", 95))
+	builderScoreOptimisticMin = float64(cli.GetEnvInt("BUILDER_SCORE_OPTIMISTIC_MIN", 99))
+
 	// user-agents which shouldn't receive bids
 	apiNoHeaderUserAgents = common.GetEnvStrSlice("NO_HEADER_USERAGENTS", []string{
 		"mev-boost/v1.5.0 Go-http-client/1.1", // Prysm v4.0.1 (Shapella signing issue)
@@ -129,19 +221,57 @@ type RelayAPIOpts struct {
 	Memcached    *datastore.Memcached
 	DB           database.IDatabaseService
 
-	SecretKey *bls.SecretKey // used to sign bids (getHeader responses)
+	Signer common.Signer // used to sign bids (getHeader responses), with a local secret key or a remote signer
 
 	// Network specific variables
 	EthNetDetails common.EthNetworkDetails
 
+	// getHeader responds with 204 for bids below this value (in wei), letting proposers build locally
+	MinBid *uint256.Int
+
+	// BidAdjuster optionally rewrites the bid values served to proposers (the relay fee adjuster configured by the
+	// environment is used if nil)
+	BidAdjuster BidAdjuster
+
 	// APIs to enable
 	ProposerAPI     bool
 	BlockBuilderAPI bool
 	DataAPI         bool
 	PprofAPI        bool
 	InternalAPI     bool
+
+	// HTTP server timeouts and request body size limit (defaults are used for zero values)
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxPayloadBytes   int
+
+	// Separate listeners of the proposer, builder and data APIs, which are served by the main listener if nil
+	ProposerListener *ListenerOpts
+	BuilderListener  *ListenerOpts
+	DataListener     *ListenerOpts
+}
+
+// ListenerOpts configures a separate HTTP listener for an API group, with its own timeouts (the timeouts of the main
+// listener are used for zero values)
+type ListenerOpts struct {
+	ListenAddr        string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
 }
 
+// Defaults of the HTTP server options
+const (
+	DefaultReadTimeout       = 1500 * time.Millisecond
+	DefaultReadHeaderTimeout = 600 * time.Millisecond
+	DefaultWriteTimeout      = 10 * time.Second
+	DefaultIdleTimeout       = 3 * time.Second
+	DefaultMaxPayloadBytes   = 15 * 1024 * 1024 // 15 MiB
+)
+
 type payloadAttributesHelper struct {
 	slot              uint64
 	parentHash        string
@@ -170,6 +300,21 @@ type blockSimResult struct {
 	optimisticSubmission bool
 	requestErr           error
 	validationErr        error
+	queueWait            time.Duration
+	filterErr            error // rejected by the filter list, which is not a simulation error
+}
+
+// blockSubmissionDBTask is a builder block submission waiting to be saved to the database
+type blockSubmissionDBTask struct {
+	payload     *common.VersionedSubmitBlockRequest
+	simResult   *blockSimResult
+	receivedAt  time.Time
+	eligibleAt  time.Time
+	savePayload bool
+	profile     common.Profile
+	log         *logrus.Entry
+
+	adjustedValue *uint256.Int // value served to proposers, if the bid was adjusted
 }
 
 // RelayAPI represents a single Relay instance
@@ -177,12 +322,17 @@ type RelayAPI struct {
 	opts RelayAPIOpts
 	log  *logrus.Entry
 
-	blsSk     *bls.SecretKey
+	signer    common.Signer
 	publicKey *phase0.BLSPubKey
 
-	srv         *http.Server
+	srvs        []*http.Server // the main server first, followed by the servers of separately listening API groups
 	srvStarted  uberatomic.Bool
 	srvShutdown uberatomic.Bool
+	srvStopped  chan struct{} // closed when StopServer has finished draining
+
+	redisDegraded     uberatomic.Bool // set by the redis health checks, makes /readyz report not ready
+	redisHealthStop   chan struct{}   // closed on shutdown to stop the redis health checks
+	redisHealthLoopWG sync.WaitGroup
 
 	beaconClient beaconclient.IMultiBeaconClient
 	datastore    *datastore.Datastore
@@ -196,15 +346,23 @@ type RelayAPI struct {
 	denebEpoch   int64
 	electraEpoch int64
 
+	// the parent slot of the latest payload attributes, which is ahead of headSlot while the head event is not processed yet
+	payloadAttrsParentSlot uberatomic.Uint64
+
 	proposerDutiesLock       sync.RWMutex
 	proposerDutiesResponse   *[]byte // raw http response
 	proposerDutiesMap        map[uint64]*common.BuilderGetValidatorsResponseEntry
+	proposerDuties           []common.BuilderGetValidatorsResponseEntry
+	proposerDutiesParents    map[uint64]*common.ProposerDutyParent // latest parent block by slot, from the payload attributes
 	proposerDutiesSlot       uint64
+	proposerDutiesMaxSlot    uint64 // highest slot with a known duty
 	isUpdatingProposerDuties uberatomic.Bool
 
 	blockSimRateLimiter IBlockSimRateLimiter
 
-	validatorRegC chan builderApiV1.SignedValidatorRegistration
+	validatorRegC    chan builderApiV1.SignedValidatorRegistration
+	blockSubmissionC chan *blockSubmissionDBTask
+	getHeaderLogC    chan database.GetHeaderLogEntry
 
 	// used to notify when a new validator has been registered
 	validatorUpdateCh chan struct{}
@@ -212,18 +370,33 @@ type RelayAPI struct {
 	// used to wait on any active getPayload calls on shutdown
 	getPayloadCallsInFlight sync.WaitGroup
 
+	// used to wait on pending database writes on shutdown
+	validatorRegProcessorWG    sync.WaitGroup
+	blockSubmissionProcessorWG sync.WaitGroup
+	getHeaderLogProcessorWG    sync.WaitGroup
+	backgroundDBWritesWG       sync.WaitGroup
+
 	// Feature flags
-	ffForceGetHeader204          bool
-	ffDisableLowPrioBuilders     bool
-	ffDisablePayloadDBStorage    bool // disable storing the execution payloads in the database
-	ffLogInvalidSignaturePayload bool // log payload if getPayload signature validation fails
-	ffEnableCancellations        bool // whether to enable block builder cancellations
-	ffRegValContinueOnInvalidSig bool // whether to continue processing further validators if one fails
-	ffIgnorableValidationErrors  bool // whether to enable ignorable validation errors
+	ffForceGetHeader204          uberatomic.Bool
+	ffDisableLowPrioBuilders     uberatomic.Bool
+	ffDisablePayloadDBStorage    uberatomic.Bool // disable storing the execution payloads in the database
+	ffLogInvalidSignaturePayload bool            // log payload if getPayload signature validation fails
+	ffDisableGetHeaderLog        bool            // disable storing the served getHeader bids in the database
+	ffEnableCancellations        bool            // whether to enable block builder cancellations
+	ffRegValContinueOnInvalidSig bool            // whether to accept requests with invalid validator signatures (which are skipped)
+	ffIgnorableValidationErrors  bool            // whether to enable ignorable validation errors
+	ffRejectBlacklistedBuilders  uberatomic.Bool // whether to respond with 403 to blacklisted builders, instead of silently accepting their submissions
+	ffBuilderScores              bool            // whether high-prio and optimistic status of builders are derived from their scores
+
+	featureFlagDefaults map[string]bool // values of the dynamic feature flags from the environment
+
+	internalAPITokens map[string]string // actor by token, for authentication of the internal API
 
 	payloadAttributes     map[string]payloadAttributesHelper // key:parentBlockHash
 	payloadAttributesLock sync.RWMutex
 
+	topBidCache *datastore.TopBidCache // top bids for getHeader in memory, if enabled
+
 	// The slot we are currently optimistically simulating.
 	optimisticSlot uberatomic.Uint64
 	// The number of optimistic blocks being processed (only used for logging).
@@ -232,6 +405,42 @@ type RelayAPI struct {
 	optimisticBlocksWG sync.WaitGroup
 	// Cache for builder statuses and collaterals.
 	blockBuildersCache map[string]*blockBuilderCacheEntry
+
+	// Whether the circuit breaker was tripped by the housekeeper, refreshed every slot.
+	isCircuitBreakerTripped uberatomic.Bool
+
+	// Results of already processed block submissions, to answer duplicates without simulating again.
+	submissionDedup *submissionDedupCache
+
+	// Addresses which may not appear in transactions of submitted blocks, nil if filtering is disabled.
+	filterList *filterList
+
+	// Rewrites the bid values served to proposers after the simulation, nil if bids are served as submitted.
+	bidAdjuster BidAdjuster
+
+	// Open data stream connections, which are closed when the server shuts down.
+	dataStream            *dataStreamHub
+	dataStreamConnections uberatomic.Int64
+	dataStreamStop        chan struct{}
+
+	// Rate limiters of the data API, nil if rate limiting is disabled, and the API keys for the higher limit by hash.
+	dataAPIIPRateLimiter  *rateLimiter
+	dataAPIKeyRateLimiter *rateLimiter
+	dataAPIKeys           uberatomic.Pointer[map[string]string]
+
+	// Concurrency limiters of the route groups, nil without a limit. The proposer API limiter is only for getHeader and
+	// getPayload, the other proposer API routes share the non-critical one.
+	proposerAPILimiter            *concurrencyLimiter
+	proposerAPINonCriticalLimiter *concurrencyLimiter
+	builderAPILimiter             *concurrencyLimiter
+	dataAPILimiter                *concurrencyLimiter
+
+	// CIDR allow and deny lists of the builder API, and the lists from the environment which apply without overrides
+	builderIPFilter        uberatomic.Pointer[builderIPFilter]
+	builderIPFilterDefault *builderIPFilter
+
+	// CIDRs of the proxies whose X-Forwarded-For header is trusted for the client IP
+	trustedProxies []netip.Prefix
 }
 
 // NewRelayAPI creates a new service. if builders is nil, allow any builder
@@ -248,46 +457,44 @@ func NewRelayAPI(opts RelayAPIOpts) (api *RelayAPI, err error) {
 		return nil, ErrMissingBeaconClientOpt
 	}
 
+	if err := tracing.Setup(context.Background(), opts.Log); err != nil {
+		return nil, err
+	}
+
 	if opts.Datastore == nil {
 		return nil, ErrMissingDatastoreOpt
 	}
 
+	opts.ReadTimeout = cmp.Or(opts.ReadTimeout, DefaultReadTimeout)
+	opts.ReadHeaderTimeout = cmp.Or(opts.ReadHeaderTimeout, DefaultReadHeaderTimeout)
+	opts.WriteTimeout = cmp.Or(opts.WriteTimeout, DefaultWriteTimeout)
+	opts.IdleTimeout = cmp.Or(opts.IdleTimeout, DefaultIdleTimeout)
+	opts.MaxPayloadBytes = cmp.Or(opts.MaxPayloadBytes, DefaultMaxPayloadBytes)
+
 	// If block-builder API is enabled, then ensure secret key is all set
 	var publicKey phase0.BLSPubKey
 	if opts.BlockBuilderAPI {
-		if opts.SecretKey == nil {
+		if opts.Signer == nil {
 			return nil, ErrBuilderAPIWithoutSecretKey
 		}
 
-		// If using a secret key, ensure it's the correct one
-		blsPubkey, err := bls.PublicKeyFromSecretKey(opts.SecretKey)
-		if err != nil {
-			return nil, err
-		}
-		publicKey, err = utils.BlsPublicKeyToPublicKey(blsPubkey)
-		if err != nil {
-			return nil, err
+		// Ensure the signer works, and signs with its public key
+		publicKey = opts.Signer.PublicKey()
+		if err := common.CheckSigner(context.Background(), opts.Signer); err != nil {
+			return nil, fmt.Errorf("signer check failed: %w", err)
 		}
 		opts.Log.Infof("Using BLS key: %s", publicKey.String())
 
-		// ensure pubkey is same across all relay instances
-		_pubkey, err := opts.Redis.GetRelayConfig(datastore.RedisConfigFieldPubkey)
-		if err != nil {
+		// ensure pubkey is same across all relay instances, or the next pubkey during a key rotation
+		if err := checkRelayPubkey(opts.Redis, opts.Log, publicKey.String()); err != nil {
 			return nil, err
-		} else if _pubkey == "" {
-			err := opts.Redis.SetRelayConfig(datastore.RedisConfigFieldPubkey, publicKey.String())
-			if err != nil {
-				return nil, err
-			}
-		} else if _pubkey != publicKey.String() {
-			return nil, fmt.Errorf("%w: new=%s old=%s", ErrRelayPubkeyMismatch, publicKey.String(), _pubkey)
 		}
 	}
 
 	api = &RelayAPI{
 		opts:         opts,
 		log:          opts.Log,
-		blsSk:        opts.SecretKey,
+		signer:       opts.Signer,
 		publicKey:    &publicKey,
 		datastore:    opts.Datastore,
 		beaconClient: opts.BeaconClient,
@@ -298,25 +505,39 @@ func NewRelayAPI(opts RelayAPIOpts) (api *RelayAPI, err error) {
 		payloadAttributes: make(map[string]payloadAttributesHelper),
 
 		proposerDutiesResponse: &[]byte{},
+		proposerDutiesParents:  make(map[uint64]*common.ProposerDutyParent),
 		blockSimRateLimiter:    NewBlockSimulationRateLimiter(opts.BlockSimURL),
 
+		srvStopped:        make(chan struct{}),
+		dataStreamStop:    make(chan struct{}),
+		redisHealthStop:   make(chan struct{}),
 		validatorRegC:     make(chan builderApiV1.SignedValidatorRegistration, 450_000),
+		blockSubmissionC:  make(chan *blockSubmissionDBTask, blockSubmissionDBQueueSize),
+		getHeaderLogC:     make(chan database.GetHeaderLogEntry, getHeaderLogDBQueueSize),
 		validatorUpdateCh: make(chan struct{}),
+
+		submissionDedup: newSubmissionDedupCache(),
+		dataStream:      newDataStreamHub(opts.Redis, opts.Log),
 	}
 
 	if os.Getenv("FORCE_GET_HEADER_204") == "1" {
 		api.log.Warn("env: FORCE_GET_HEADER_204 - forcing getHeader to always return 204")
-		api.ffForceGetHeader204 = true
+		api.ffForceGetHeader204.Store(true)
 	}
 
 	if os.Getenv("DISABLE_LOWPRIO_BUILDERS") == "1" {
 		api.log.Warn("env: DISABLE_LOWPRIO_BUILDERS - allowing only high-level builders")
-		api.ffDisableLowPrioBuilders = true
+		api.ffDisableLowPrioBuilders.Store(true)
 	}
 
 	if os.Getenv("DISABLE_PAYLOAD_DATABASE_STORAGE") == "1" {
 		api.log.Warn("env: DISABLE_PAYLOAD_DATABASE_STORAGE - disabling storing payloads in the database")
-		api.ffDisablePayloadDBStorage = true
+		api.ffDisablePayloadDBStorage.Store(true)
+	}
+
+	if os.Getenv("DISABLE_GETHEADER_DATABASE_LOG") == "1" {
+		api.log.Warn("env: DISABLE_GETHEADER_DATABASE_LOG - disabling storing served getHeader bids in the database")
+		api.ffDisableGetHeaderLog = true
 	}
 
 	if os.Getenv("LOG_INVALID_GETPAYLOAD_SIGNATURE") == "1" {
@@ -330,7 +551,7 @@ func NewRelayAPI(opts RelayAPIOpts) (api *RelayAPI, err error) {
 	}
 
 	if os.Getenv("REGISTER_VALIDATOR_CONTINUE_ON_INVALID_SIG") == "1" {
-		api.log.Warn("env: REGISTER_VALIDATOR_CONTINUE_ON_INVALID_SIG - validator registration will continue processing even if one validator has an invalid signature")
+		api.log.Warn("env: REGISTER_VALIDATOR_CONTINUE_ON_INVALID_SIG - validator registrations with an invalid signature will be skipped without failing the request")
 		api.ffRegValContinueOnInvalidSig = true
 	}
 
@@ -339,61 +560,211 @@ func NewRelayAPI(opts RelayAPIOpts) (api *RelayAPI, err error) {
 		api.ffIgnorableValidationErrors = true
 	}
 
+	if os.Getenv("REJECT_BLACKLISTED_BUILDERS") == "1" {
+		api.log.Warn("env: REJECT_BLACKLISTED_BUILDERS - submissions of blacklisted builders are rejected with 403 instead of silently accepted")
+		api.ffRejectBlacklistedBuilders.Store(true)
+	}
+
+	if os.Getenv("ENABLE_BUILDER_SCORES") == "1" {
+		api.log.Warn("env: ENABLE_BUILDER_SCORES - high-prio and optimistic status of builders are derived from their scores")
+		api.ffBuilderScores = true
+	}
+
+	if os.Getenv("ENABLE_GETHEADER_MEMORY_CACHE") == "1" {
+		// the instances updating bids publish them, the instances serving getHeader keep them in memory
+		opts.Redis.EnableTopBidUpdates()
+		if opts.ProposerAPI {
+			api.log.Warn("env: ENABLE_GETHEADER_MEMORY_CACHE - getHeader is served from the top bids kept in memory")
+			api.topBidCache = datastore.NewTopBidCache(opts.Redis, api.log)
+		}
+	}
+
+	api.featureFlagDefaults = api.getFeatureFlags()
+
+	api.trustedProxies, err = parseCIDRList(trustedProxyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	if len(api.trustedProxies) > 0 {
+		api.log.Infof("env: TRUSTED_PROXY_CIDRS - client IPs are taken from X-Forwarded-For for requests from %s", trustedProxyCIDRs)
+	}
+
+	api.builderIPFilterDefault, err = newBuilderIPFilter(builderAPIAllowedCIDRs, builderAPIDeniedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	if !api.builderIPFilterDefault.isEmpty() {
+		api.log.Warn("env: BUILDER_API_ALLOWED_CIDRS / BUILDER_API_DENIED_CIDRS - builder API requests are filtered by client IP")
+	}
+	api.builderIPFilter.Store(api.builderIPFilterDefault)
+
+	api.internalAPITokens, err = parseInternalAPITokens(os.Getenv("INTERNAL_API_TOKENS"))
+	if err != nil {
+		return nil, err
+	}
+	if opts.InternalAPI && len(api.internalAPITokens) == 0 {
+		if !internalAPIAllowUnauthenticated {
+			return nil, ErrInternalAPIWithoutTokens
+		}
+		api.log.Error("env: INTERNAL_API_ALLOW_UNAUTHENTICATED - the internal API is NOT authenticated, anyone who can reach it can change the relay state")
+	}
+
+	if filterListURI != "" {
+		api.log.Warn("env: FILTER_LIST_URI - block submissions with transactions of filtered addresses are rejected")
+		api.filterList = newFilterList(api.log, filterListURI)
+		err = api.filterList.reload(context.Background())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	api.bidAdjuster = opts.BidAdjuster
+	if api.bidAdjuster == nil {
+		relayFeeAdjuster, err := newRelayFeeBidAdjusterFromEnv(api.log)
+		if err != nil {
+			return nil, err
+		}
+		if relayFeeAdjuster != nil {
+			api.bidAdjuster = relayFeeAdjuster
+		}
+	}
+
+	if opts.DataAPI && dataAPIRateLimitPerSec > 0 {
+		if len(api.trustedProxies) == 0 && !dataAPIRateLimitByRemoteAddr {
+			return nil, ErrDataAPIRateLimitWithoutTrustedProxies
+		}
+		api.log.Infof("data API rate limit: %d requests per second per IP", dataAPIRateLimitPerSec)
+		api.dataAPIIPRateLimiter = newRateLimiter(dataAPIRateLimitPerSec, dataAPIRateLimitPerSec*dataAPIRateLimitBurstSec)
+		if dataAPIKeyRateLimitPerSec > 0 {
+			api.dataAPIKeyRateLimiter = newRateLimiter(dataAPIKeyRateLimitPerSec, dataAPIKeyRateLimitPerSec*dataAPIRateLimitBurstSec)
+		}
+	}
+
+	api.proposerAPILimiter = newConcurrencyLimiter(proposerAPIMaxConcurrent, proposerAPIQueueTimeout)
+	api.proposerAPINonCriticalLimiter = newConcurrencyLimiter(proposerAPINonCriticalMaxConcurrent, 0)
+	api.builderAPILimiter = newConcurrencyLimiter(builderAPIMaxConcurrent, 0)
+	api.dataAPILimiter = newConcurrencyLimiter(dataAPIMaxConcurrent, 0)
+
 	return api, nil
 }
 
+// routerGroups selects the APIs served by a router
+type routerGroups struct {
+	main     bool // root, metrics, pprof and internal API
+	proposer bool
+	builder  bool
+	data     bool
+}
+
+// mainRouterGroups returns the APIs of the main listener, which are all enabled ones without a separate listener
+func (api *RelayAPI) mainRouterGroups() routerGroups {
+	return routerGroups{
+		main:     true,
+		proposer: api.opts.ProposerAPI && api.opts.ProposerListener == nil,
+		builder:  api.opts.BlockBuilderAPI && api.opts.BuilderListener == nil,
+		data:     api.opts.DataAPI && api.opts.DataListener == nil,
+	}
+}
+
+// getRouter returns the router of the main listener
 func (api *RelayAPI) getRouter() http.Handler {
+	return api.newRouter(api.mainRouterGroups())
+}
+
+func (api *RelayAPI) newRouter(groups routerGroups) http.Handler {
 	r := mux.NewRouter()
 
-	r.HandleFunc("/", api.handleRoot).Methods(http.MethodGet)
+	// health checks are served by all listeners, for their load balancers
 	r.HandleFunc("/livez", api.handleLivez).Methods(http.MethodGet)
 	r.HandleFunc("/readyz", api.handleReadyz).Methods(http.MethodGet)
-	r.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	if groups.main {
+		r.HandleFunc("/", api.handleRoot).Methods(http.MethodGet)
+		r.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
+	}
 
 	// Proposer API
-	if api.opts.ProposerAPI {
+	if groups.proposer {
 		api.log.Info("proposer API enabled")
-		r.HandleFunc(pathStatus, api.handleStatus).Methods(http.MethodGet)
-		r.HandleFunc(pathRegisterValidator, api.handleRegisterValidator).Methods(http.MethodPost)
-		r.HandleFunc(pathGetHeader, api.handleGetHeader).Methods(http.MethodGet)
-		r.HandleFunc(pathGetPayload, api.handleGetPayload).Methods(http.MethodPost)
+		r.HandleFunc(pathStatus, api.concurrencyLimitMiddleware(api.proposerAPINonCriticalLimiter, api.handleStatus)).Methods(http.MethodGet)
+		r.HandleFunc(pathRegisterValidator, api.concurrencyLimitMiddleware(api.proposerAPINonCriticalLimiter, api.handleRegisterValidator)).Methods(http.MethodPost)
+		r.HandleFunc(pathGetHeader, api.concurrencyLimitMiddleware(api.proposerAPILimiter, api.handleGetHeader)).Methods(http.MethodGet)
+		r.HandleFunc(pathGetPayload, api.concurrencyLimitMiddleware(api.proposerAPILimiter, api.handleGetPayload)).Methods(http.MethodPost)
+		r.HandleFunc(pathProposerPreferences, api.concurrencyLimitMiddleware(api.proposerAPINonCriticalLimiter, api.handleProposerPreferences)).Methods(http.MethodPost)
 	}
 
 	// Builder API
-	if api.opts.BlockBuilderAPI {
+	if groups.builder {
 		api.log.Info("block builder API enabled")
-		r.HandleFunc(pathBuilderGetValidators, api.handleBuilderGetValidators).Methods(http.MethodGet)
-		r.HandleFunc(pathSubmitNewBlock, api.handleSubmitNewBlock).Methods(http.MethodPost)
+		r.HandleFunc(pathBuilderGetValidators, api.builderIPFilterMiddleware(api.concurrencyLimitMiddleware(api.builderAPILimiter, api.handleBuilderGetValidators))).Methods(http.MethodGet)
+		r.HandleFunc(pathSubmitNewBlock, api.builderIPFilterMiddleware(api.concurrencyLimitMiddleware(api.builderAPILimiter, api.handleSubmitNewBlock))).Methods(http.MethodPost)
 	}
 
 	// Data API
-	if api.opts.DataAPI {
+	if groups.data {
 		api.log.Info("data API enabled")
-		r.HandleFunc(pathDataProposerPayloadDelivered, api.handleDataProposerPayloadDelivered).Methods(http.MethodGet)
-		r.HandleFunc(pathDataBuilderBidsReceived, api.handleDataBuilderBidsReceived).Methods(http.MethodGet)
-		r.HandleFunc(pathDataValidatorRegistration, api.handleDataValidatorRegistration).Methods(http.MethodGet)
+		r.HandleFunc(pathDataProposerPayloadDelivered, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataProposerPayloadDelivered)))).Methods(http.MethodGet)
+		r.HandleFunc(pathDataBuilderBidsReceived, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataBuilderBidsReceived)))).Methods(http.MethodGet)
+		r.HandleFunc(pathDataValidatorRegistration, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataValidatorRegistration)))).Methods(http.MethodGet, http.MethodPost)
+		r.HandleFunc(pathDataValidatorRegHistory, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataValidatorRegistrationHistory)))).Methods(http.MethodGet)
+		r.HandleFunc(pathDataStats, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataStats)))).Methods(http.MethodGet)
+		r.HandleFunc(pathDataBuilderStats, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataBuilderStats)))).Methods(http.MethodGet)
+		r.HandleFunc(pathDataGetHeaderLog, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataGetHeaderLog)))).Methods(http.MethodGet)
+		r.HandleFunc(pathDataGetPayloadFailures, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.dataAPICacheMiddleware(api.handleDataGetPayloadFailures)))).Methods(http.MethodGet)
+		r.HandleFunc(pathDataStream, api.dataAPIRateLimitMiddleware(api.concurrencyLimitMiddleware(api.dataAPILimiter, api.handleDataStream))).Methods(http.MethodGet)
 	}
 
 	// Pprof
-	if api.opts.PprofAPI {
+	if groups.main && api.opts.PprofAPI {
 		api.log.Info("pprof API enabled")
 		r.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
 	}
 
 	// /internal/...
-	if api.opts.InternalAPI {
+	if groups.main && api.opts.InternalAPI {
 		api.log.Info("internal API enabled")
-		r.HandleFunc(pathInternalBuilderStatus, api.handleInternalBuilderStatus).Methods(http.MethodGet, http.MethodPost, http.MethodPut)
-		r.HandleFunc(pathInternalBuilderCollateral, api.handleInternalBuilderCollateral).Methods(http.MethodPost, http.MethodPut)
+		r.HandleFunc(pathInternalBuilderStatus, api.internalAPIMiddleware(api.handleInternalBuilderStatus)).Methods(http.MethodGet, http.MethodPost, http.MethodPut)
+		r.HandleFunc(pathInternalBuilderCollateral, api.internalAPIMiddleware(api.handleInternalBuilderCollateral)).Methods(http.MethodPost, http.MethodPut)
+		r.HandleFunc(pathInternalBlacklistedBuilders, api.internalAPIMiddleware(api.handleInternalBlacklistedBuilders)).Methods(http.MethodGet)
+		r.HandleFunc(pathInternalBuilders, api.internalAPIMiddleware(api.handleInternalBuilders)).Methods(http.MethodGet)
+		r.HandleFunc(pathInternalBuilderIdentities, api.internalAPIMiddleware(api.handleInternalBuilderIdentities)).Methods(http.MethodGet)
+		r.HandleFunc(pathInternalBuilderIdentity, api.internalAPIMiddleware(api.handleInternalBuilderIdentity)).Methods(http.MethodGet, http.MethodPost, http.MethodPut)
+		r.HandleFunc(pathInternalBuilderIdentityKey, api.internalAPIMiddleware(api.handleInternalBuilderIdentityKey)).Methods(http.MethodPost, http.MethodPut, http.MethodDelete)
+		r.HandleFunc(pathInternalCircuitBreaker, api.internalAPIMiddleware(api.handleInternalCircuitBreaker)).Methods(http.MethodGet, http.MethodPost, http.MethodPut)
+		r.HandleFunc(pathInternalBuilderIPFilter, api.internalAPIMiddleware(api.handleInternalBuilderIPFilter)).Methods(http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete)
+		r.HandleFunc(pathInternalFeatureFlags, api.internalAPIMiddleware(api.handleInternalFeatureFlags)).Methods(http.MethodGet, http.MethodPost, http.MethodPut)
+		r.HandleFunc(pathInternalInvalidateBids, api.internalAPIMiddleware(api.handleInternalInvalidateBids)).Methods(http.MethodPost)
+		r.HandleFunc(pathInternalDataAPIKeys, api.internalAPIMiddleware(api.handleInternalDataAPIKeys)).Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
+		r.HandleFunc(pathInternalRelayPubkey, api.internalAPIMiddleware(api.handleInternalRelayPubkey)).Methods(http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete)
+		r.HandleFunc(pathInternalRestoreRegistrations, api.internalAPIMiddleware(api.handleInternalRestoreRegistrations)).Methods(http.MethodPost)
 	}
 
 	mresp := common.MustB64Gunzip("H4sICAtOkWQAA2EudHh0AKWVPW+DMBCGd36Fe9fIi5Mt8uqqs4dIlZiCEqosKKhVO2Txj699GBtDcEl4JwTnh/t4dS7YWom2FcVaiETSDEmIC+pWLGRVgKrD3UY0iwnSj6THofQJDomiR13BnPgjvJDqNWX+OtzH7inWEGvr76GOCGtg3Kp7Ak+lus3zxLNtmXaMUncjcj1cwbOH3xBZtJCYG6/w+hdpB6ErpnqzFPZxO4FdXB3SAEgpscoDqWeULKmJA4qyfYFg0QV+p7hD8GGDd6C8+mElGDKab1CWeUQMVVvVDTJVj6nngHmNOmSoe6yH1BM3KZIKpuRaHKrOFd/3ksQwzdK+ejdM4VTzSDfjJsY1STeVTWb0T9JWZbJs8DvsNvwaddKdUy4gzVIzWWaWk3IF8D35kyUDf3FfKipwk/DYUee2nYyWQD0xEKDHeprzeXYwVmZD/lXt1OOg8EYhFfitsmQVcwmbUutpdt3PoqWdMyd2DYHKbgcmPlEYMxPjR6HhxOfuNG52xZr7TtzpygJJKNtWS14Uf0T6XSmzBwAA")
-	r.HandleFunc("/miladyz", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK); w.Write(mresp) }).Methods(http.MethodGet) //nolint:errcheck
+	if groups.main {
+		r.HandleFunc("/miladyz", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK); w.Write(mresp) }).Methods(http.MethodGet) //nolint:errcheck
+	}
 
 	// r.Use(mux.CORSMethodMiddleware(r))
-	loggedRouter := httplogger.LoggingMiddlewareLogrus(api.log, r)
+	r.Use(metricsMiddleware)
+	r.Use(api.bodyLimitMiddleware)
+	loggedRouter := api.loggingMiddleware(r)
 	withGz := gziphandler.GzipHandler(loggedRouter)
-	return withGz
+	if !groups.data {
+		return withGz
+	}
+
+	// The data stream is served without gzip, which would buffer the events instead of flushing them one by one
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == pathDataStream {
+			loggedRouter.ServeHTTP(w, req)
+			return
+		}
+		withGz.ServeHTTP(w, req)
+	})
+}
+
+// slotClock converts between slots and wallclock time, using the genesis time fetched from the beacon node at startup
+func (api *RelayAPI) slotClock() common.SlotClock {
+	return common.SlotClock{GenesisTime: api.genesisInfo.Data.GenesisTime}
 }
 
 // StartServer starts up this API instance and HTTP server
@@ -412,6 +783,11 @@ func (api *RelayAPI) StartServer() (err error) {
 		return err
 	}
 	currentSlot := syncStatus.HeadSlot
+	go api.beaconClient.StartHealthChecks()
+	if redisHealthCheckInterval > 0 {
+		api.redisHealthLoopWG.Add(1)
+		go api.startRedisHealthChecks()
+	}
 
 	// Initialize block builder cache.
 	api.blockBuildersCache = make(map[string]*blockBuilderCacheEntry)
@@ -423,6 +799,15 @@ func (api *RelayAPI) StartServer() (err error) {
 	}
 	log.Infof("genesis info: %d", api.genesisInfo.Data.GenesisTime)
 
+	// Ensure the configured slot duration matches the beacon chain
+	beaconSpec, err := api.beaconClient.GetSpec()
+	if err != nil {
+		return err
+	}
+	if beaconSpec.Data.SecondsPerSlot != common.SecondsPerSlot {
+		return fmt.Errorf("%w: SEC_PER_SLOT is %d, beacon node uses %d", ErrMismatchedSlotDuration, common.SecondsPerSlot, beaconSpec.Data.SecondsPerSlot)
+	}
+
 	// Get and prepare fork schedule
 	forkSchedule, err := api.beaconClient.GetForkSchedule()
 	if err != nil {
@@ -432,8 +817,10 @@ func (api *RelayAPI) StartServer() (err error) {
 	api.capellaEpoch = -1
 	api.denebEpoch = -1
 	api.electraEpoch = -1
+	forks := make([]common.ForkScheduleEntry, 0, len(forkSchedule.Data))
 	for _, fork := range forkSchedule.Data {
 		log.Infof("forkSchedule: version=%s / epoch=%d", fork.CurrentVersion, fork.Epoch)
+		forks = append(forks, common.ForkScheduleEntry{Epoch: fork.Epoch, CurrentVersion: fork.CurrentVersion})
 		switch fork.CurrentVersion {
 		case api.opts.EthNetDetails.CapellaForkVersionHex:
 			api.capellaEpoch = int64(fork.Epoch) //nolint:gosec
@@ -444,6 +831,8 @@ func (api *RelayAPI) StartServer() (err error) {
 		}
 	}
 
+	api.opts.EthNetDetails.SetForkSchedule(forks)
+
 	if api.denebEpoch == -1 {
 		// log warning that deneb epoch was not found in CL fork schedule, suggest CL upgrade
 		log.Info("Deneb epoch not found in fork schedule")
@@ -454,11 +843,12 @@ func (api *RelayAPI) StartServer() (err error) {
 	}
 
 	// Print fork version information
-	if hasReachedFork(currentSlot, api.electraEpoch) {
+	switch api.dataVersionAtSlot(currentSlot) { //nolint:exhaustive
+	case spec.DataVersionElectra:
 		log.Infof("electra fork detected (currentEpoch: %d / electraEpoch: %d)", common.SlotToEpoch(currentSlot), api.electraEpoch)
-	} else if hasReachedFork(currentSlot, api.denebEpoch) {
+	case spec.DataVersionDeneb:
 		log.Infof("deneb fork detected (currentEpoch: %d / denebEpoch: %d)", common.SlotToEpoch(currentSlot), api.denebEpoch)
-	} else if hasReachedFork(currentSlot, api.capellaEpoch) {
+	case spec.DataVersionCapella:
 		log.Infof("capella fork detected (currentEpoch: %d / capellaEpoch: %d)", common.SlotToEpoch(currentSlot), api.capellaEpoch)
 	}
 
@@ -471,8 +861,13 @@ func (api *RelayAPI) StartServer() (err error) {
 		// Start the validator registration db-save processor
 		api.log.Infof("starting %d validator registration processors", numValidatorRegProcessors)
 		for range numValidatorRegProcessors {
+			api.validatorRegProcessorWG.Add(1)
 			go api.startValidatorRegistrationDBProcessor()
 		}
+
+		// Start the getHeader log db-save processor
+		api.getHeaderLogProcessorWG.Add(1)
+		go api.startGetHeaderLogDBProcessor()
 	}
 
 	// start block-builder API specific things
@@ -480,9 +875,20 @@ func (api *RelayAPI) StartServer() (err error) {
 		// Initialize metrics
 		metrics.BuilderDemotionCount.Add(context.Background(), 0)
 
+		// Start the block submission db-save processor
+		api.log.Infof("starting %d block submission processors", numBlockSubmissionDBProcessors)
+		for range numBlockSubmissionDBProcessors {
+			api.blockSubmissionProcessorWG.Add(1)
+			go api.startBlockSubmissionDBProcessor()
+		}
+
 		// Get current proposer duties blocking before starting, to have them ready
 		api.updateProposerDuties(syncStatus.HeadSlot)
 
+		if api.filterList != nil {
+			go api.filterList.startReloading()
+		}
+
 		// Subscribe to payload attributes events (only for builder-api)
 		go func() {
 			c := make(chan beaconclient.PayloadAttributesEvent)
@@ -494,6 +900,39 @@ func (api *RelayAPI) StartServer() (err error) {
 		}()
 	}
 
+	// Apply feature flags from Redis, and keep polling them
+	err = api.updateFeatureFlags()
+	if err != nil {
+		log.WithError(err).Error("failed to update feature flags")
+	}
+	go api.startFeatureFlagsPoller()
+
+	// Apply the builder IP filter from Redis, and keep polling it
+	if api.opts.BlockBuilderAPI {
+		err = api.updateBuilderIPFilter()
+		if err != nil {
+			log.WithError(err).Error("failed to update builder IP filter")
+		}
+		go api.startBuilderIPFilterPoller()
+	}
+
+	// Load the data API keys, only used with rate limiting
+	if api.dataAPIIPRateLimiter != nil {
+		err = api.updateDataAPIKeys()
+		if err != nil {
+			log.WithError(err).Error("failed to update data API keys")
+		}
+		go api.startDataAPIKeysPoller()
+	}
+
+	// Keep the top bids in memory for getHeader, updated through Redis pub/sub
+	if api.topBidCache != nil {
+		err = api.topBidCache.Start(context.Background())
+		if err != nil {
+			return err
+		}
+	}
+
 	// Process current slot
 	api.processNewSlot(currentSlot)
 
@@ -507,30 +946,133 @@ func (api *RelayAPI) StartServer() (err error) {
 		}
 	}()
 
-	// create and start HTTP server
-	api.srv = &http.Server{
-		Addr:    api.opts.ListenAddr,
-		Handler: api.getRouter(),
+	// create the HTTP servers and bind all listeners before serving, so a listen address in use doesn't leave the
+	// other servers running
+	api.srvs = api.newServers()
+	listeners, err := listenAll(api.srvs)
+	if err != nil {
+		return err
+	}
 
-		ReadTimeout:       time.Duration(apiReadTimeoutMs) * time.Millisecond,
-		ReadHeaderTimeout: time.Duration(apiReadHeaderTimeoutMs) * time.Millisecond,
-		WriteTimeout:      time.Duration(apiWriteTimeoutMs) * time.Millisecond,
-		IdleTimeout:       time.Duration(apiIdleTimeoutMs) * time.Millisecond,
-		MaxHeaderBytes:    apiMaxHeaderBytes,
+	// the first server failing (or closed) ends this call
+	errC := make(chan error, len(api.srvs))
+	for i, srv := range api.srvs {
+		log.Infof("listening on %s", srv.Addr)
+		go func() { errC <- srv.Serve(listeners[i]) }()
 	}
-	err = api.srv.ListenAndServe()
+	err = <-errC
 	if errors.Is(err, http.ErrServerClosed) {
+		// wait for StopServer to finish draining before returning, so the process doesn't exit early
+		<-api.srvStopped
 		return nil
 	}
+	for _, srv := range api.srvs {
+		srv.Close()
+	}
 	return err
 }
 
+// listenAll binds the listen addresses of all servers, and closes the bound listeners again if any address fails
+func listenAll(srvs []*http.Server) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(srvs))
+	for _, srv := range srvs {
+		ln, err := net.Listen("tcp", cmp.Or(srv.Addr, ":http"))
+		if err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+// newServers returns the HTTP server of the main listener, and of the API groups with a separate listener
+func (api *RelayAPI) newServers() []*http.Server {
+	newServer := func(listener ListenerOpts, groups routerGroups) *http.Server {
+		srv := &http.Server{
+			Addr:    listener.ListenAddr,
+			Handler: api.newRouter(groups),
+
+			ReadTimeout:       cmp.Or(listener.ReadTimeout, api.opts.ReadTimeout),
+			ReadHeaderTimeout: cmp.Or(listener.ReadHeaderTimeout, api.opts.ReadHeaderTimeout),
+			WriteTimeout:      cmp.Or(listener.WriteTimeout, api.opts.WriteTimeout),
+			IdleTimeout:       cmp.Or(listener.IdleTimeout, api.opts.IdleTimeout),
+			MaxHeaderBytes:    apiMaxHeaderBytes,
+		}
+		// data streams never become idle, so they are closed for the shutdown to complete
+		if groups.data {
+			srv.RegisterOnShutdown(func() { close(api.dataStreamStop) })
+		}
+		return srv
+	}
+
+	servers := []*http.Server{newServer(ListenerOpts{ListenAddr: api.opts.ListenAddr}, api.mainRouterGroups())}
+	if api.opts.ProposerAPI && api.opts.ProposerListener != nil {
+		servers = append(servers, newServer(*api.opts.ProposerListener, routerGroups{proposer: true}))
+	}
+	if api.opts.BlockBuilderAPI && api.opts.BuilderListener != nil {
+		servers = append(servers, newServer(*api.opts.BuilderListener, routerGroups{builder: true}))
+	}
+	if api.opts.DataAPI && api.opts.DataListener != nil {
+		servers = append(servers, newServer(*api.opts.DataListener, routerGroups{data: true}))
+	}
+	return servers
+}
+
+// loggingMiddleware logs every request like httplogger.LoggingMiddlewareLogrus, but with a response writer which can be
+// unwrapped, so the data stream can be flushed through it
+func (api *RelayAPI) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				api.log.WithFields(logrus.Fields{
+					"err":    err,
+					"trace":  string(debug.Stack()),
+					"method": req.Method,
+				}).Error(fmt.Sprintf("http request panic: %s %s", req.Method, req.URL.EscapedPath()))
+			}
+		}()
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+		api.log.WithFields(logrus.Fields{
+			"status":   rec.status,
+			"method":   req.Method,
+			"path":     req.URL.EscapedPath(),
+			"duration": fmt.Sprintf("%f", time.Since(start).Seconds()),
+		}).Info(fmt.Sprintf("http: %s %s %d", req.Method, req.URL.EscapedPath(), rec.status))
+	})
+}
+
+// metricsMiddleware records the number and duration of requests per route. It is only called by mux for matched
+// routes, so requests are never labeled with the paths chosen by the clients.
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+
+		route, _ := mux.CurrentRoute(req).GetPathTemplate()
+		routeAttrs := []attribute.KeyValue{attribute.String("route", route), attribute.String("method", req.Method)}
+		metrics.RequestCount.Add(req.Context(), 1, otelapi.WithAttributes(append(routeAttrs, attribute.Int("statusCode", rec.status))...))
+		metrics.RequestLatencyHistogram.Record(req.Context(), float64(time.Since(start).Milliseconds()), otelapi.WithAttributes(routeAttrs...))
+	})
+}
+
 func (api *RelayAPI) IsReady() bool {
 	// If server is shutting down, return false
 	if api.srvShutdown.Load() {
 		return false
 	}
 
+	// Without a healthy redis, neither bids nor payloads can be served
+	if api.redisDegraded.Load() {
+		return false
+	}
+
 	// Proposer API readiness checks
 	if api.opts.ProposerAPI {
 		knownValidatorsUpdated := api.datastore.KnownValidatorsWasUpdated.Load()
@@ -545,69 +1087,258 @@ func (api *RelayAPI) IsReady() bool {
 // - Stop returning bids
 // - Set ready /readyz to negative status
 // - Wait a bit to allow removal of service from load balancer and draining of requests
+// - Stop accepting new connections and wait for in-flight requests (getPayload, submitNewBlock, ...)
+// - Flush pending database writes
 func (api *RelayAPI) StopServer() (err error) {
 	// avoid running this twice. setting srvShutdown to true makes /readyz switch to negative status
 	if wasStopping := api.srvShutdown.Swap(true); wasStopping {
 		return nil
 	}
+	defer close(api.srvStopped)
 
 	// start server shutdown
 	api.log.Info("Stopping server...")
 
 	// stop returning bids on getHeader calls (should only be used when running a single instance)
 	if api.opts.ProposerAPI && apiShutdownStopSendingBids {
-		api.ffForceGetHeader204 = true
+		api.ffForceGetHeader204.Store(true)
 		api.log.Info("Disabled returning bids on getHeader")
 	}
 
+	// the service reports not ready from now on, so redis doesn't have to be checked anymore
+	close(api.redisHealthStop)
+
 	// wait some time to get service removed from load balancer
 	api.log.Infof("Waiting %.2f seconds before shutdown...", apiShutdownWaitDuration.Seconds())
 	time.Sleep(apiShutdownWaitDuration)
 
-	// wait for any active getPayload call to finish
-	api.getPayloadCallsInFlight.Wait()
+	// stop accepting new connections, and wait for in-flight requests to finish
+	api.log.Info("Draining in-flight requests...")
+	ctx, cancel := context.WithTimeout(context.Background(), apiShutdownTimeout)
+	defer cancel()
+	for _, srv := range api.srvs {
+		if srvErr := srv.Shutdown(ctx); srvErr != nil && err == nil {
+			err = srvErr
+		}
+	}
+	if err != nil {
+		api.log.WithError(err).Error("failed to drain in-flight requests")
+	}
+
+	// wait for any active getPayload call and optimistic block processing to finish (also covers hijacked connections)
+	waitWithTimeout(ctx, &api.getPayloadCallsInFlight)
+	waitWithTimeout(ctx, &api.optimisticBlocksWG)
+
+	// flush pending database writes. no more registrations and block submissions are queued once all requests
+	// are finished, so the channels can be closed to let the processors save the remaining ones and exit.
+	api.log.Info("Flushing pending database writes...")
+	if err == nil {
+		close(api.validatorRegC)
+		close(api.blockSubmissionC)
+		close(api.getHeaderLogC)
+		if !waitWithTimeout(ctx, &api.validatorRegProcessorWG) {
+			api.log.WithField("numPendingRegistrations", len(api.validatorRegC)).Error("timed out saving pending validator registrations")
+		}
+		if !waitWithTimeout(ctx, &api.blockSubmissionProcessorWG) {
+			api.log.WithField("numPendingSubmissions", len(api.blockSubmissionC)).Error("timed out saving pending block submissions")
+		}
+		if !waitWithTimeout(ctx, &api.getHeaderLogProcessorWG) {
+			api.log.WithField("numPendingGetHeaderLogEntries", len(api.getHeaderLogC)).Error("timed out saving pending getHeader log entries")
+		}
+	}
+	if !waitWithTimeout(ctx, &api.backgroundDBWritesWG) {
+		api.log.Error("timed out waiting for pending database writes")
+	}
+	waitWithTimeout(ctx, &api.redisHealthLoopWG)
+
+	if err := tracing.Shutdown(ctx); err != nil {
+		api.log.WithError(err).Error("failed to flush pending spans")
+	}
+
+	api.log.Info("Server stopped")
+	return err
+}
+
+// waitWithTimeout waits for the WaitGroup, and returns false if the context is done before
+func waitWithTimeout(ctx context.Context, wg *sync.WaitGroup) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
 
-	// shutdown
-	return api.srv.Shutdown(context.Background())
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 func (api *RelayAPI) ValidatorUpdateCh() chan struct{} {
 	return api.validatorUpdateCh
 }
 
-func (api *RelayAPI) isCapella(slot uint64) bool {
-	return hasReachedFork(slot, api.capellaEpoch) && !hasReachedFork(slot, api.denebEpoch)
+// dataVersionAtSlot returns the fork which is active at the given slot, according to the fork schedule of the beacon node
+func (api *RelayAPI) dataVersionAtSlot(slot uint64) spec.DataVersion {
+	switch {
+	case hasReachedFork(slot, api.electraEpoch):
+		return spec.DataVersionElectra
+	case hasReachedFork(slot, api.denebEpoch):
+		return spec.DataVersionDeneb
+	case hasReachedFork(slot, api.capellaEpoch):
+		return spec.DataVersionCapella
+	default:
+		return spec.DataVersionBellatrix
+	}
+}
+
+// startValidatorRegistrationDBProcessor saves the registrations from the channel, in batches of those already waiting
+func (api *RelayAPI) startValidatorRegistrationDBProcessor() {
+	defer api.validatorRegProcessorWG.Done()
+	batch := make([]builderApiV1.SignedValidatorRegistration, 0, validatorRegBatchSize)
+	for valReg := range api.validatorRegC {
+		batch = append(batch[:0], valReg)
+	drain:
+		for len(batch) < validatorRegBatchSize {
+			select {
+			case valReg, ok := <-api.validatorRegC:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, valReg)
+			default:
+				break drain
+			}
+		}
+
+		err := api.datastore.SaveValidatorRegistrations(batch)
+		if err != nil {
+			metrics.DatabaseErrorCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("operation", "saveValidatorRegistrations")))
+			api.log.WithError(err).WithField("numRegistrations", len(batch)).Error("error saving validator registrations")
+		}
+	}
 }
 
-func (api *RelayAPI) isDeneb(slot uint64) bool {
-	return hasReachedFork(slot, api.denebEpoch) && !hasReachedFork(slot, api.electraEpoch)
+// startBlockSubmissionDBProcessor saves the block submissions from the channel, in batches of those already waiting
+func (api *RelayAPI) startBlockSubmissionDBProcessor() {
+	defer api.blockSubmissionProcessorWG.Done()
+	batch := make([]*blockSubmissionDBTask, 0, blockSubmissionDBBatchSize)
+	for task := range api.blockSubmissionC {
+		batch = append(batch[:0], task)
+	drain:
+		for len(batch) < blockSubmissionDBBatchSize {
+			select {
+			case task, ok := <-api.blockSubmissionC:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, task)
+			default:
+				break drain
+			}
+		}
+		metrics.BlockSubmissionDBQueueSize.Record(context.Background(), int64(len(api.blockSubmissionC)))
+		api.saveBlockSubmissions(batch)
+	}
 }
 
-func (api *RelayAPI) isElectra(slot uint64) bool {
-	return hasReachedFork(slot, api.electraEpoch)
+// startGetHeaderLogDBProcessor saves the served getHeader bids from the channel, in batches of those already waiting
+func (api *RelayAPI) startGetHeaderLogDBProcessor() {
+	defer api.getHeaderLogProcessorWG.Done()
+	batch := make([]database.GetHeaderLogEntry, 0, getHeaderLogDBBatchSize)
+	for entry := range api.getHeaderLogC {
+		batch = append(batch[:0], entry)
+	drain:
+		for len(batch) < getHeaderLogDBBatchSize {
+			select {
+			case entry, ok := <-api.getHeaderLogC:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, entry)
+			default:
+				break drain
+			}
+		}
+
+		err := api.db.InsertGetHeaderLogEntries(batch)
+		if err != nil {
+			metrics.DatabaseErrorCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("operation", "insertGetHeaderLogEntries")))
+			metrics.GetHeaderLogDBDropCount.Add(context.Background(), int64(len(batch)), otelapi.WithAttributes(attribute.String("reason", "database_error")))
+			api.log.WithError(err).WithField("numEntries", len(batch)).Error("failed to insert getHeader log entries into db")
+		}
+	}
 }
 
-func (api *RelayAPI) startValidatorRegistrationDBProcessor() {
-	for valReg := range api.validatorRegC {
-		err := api.datastore.SaveValidatorRegistration(valReg)
+// saveBlockSubmissions saves a batch of block submissions, and then updates the stats of their builders
+func (api *RelayAPI) saveBlockSubmissions(batch []*blockSubmissionDBTask) {
+	tasks := make([]*blockSubmissionDBTask, 0, len(batch))
+	submissions := make([]*database.BuilderBlockSubmissionEntry, 0, len(batch))
+	execPayloads := make([]*database.ExecutionPayloadEntry, 0, len(batch))
+	for _, task := range batch {
+		submission, err := database.NewBuilderBlockSubmissionEntry(task.payload, task.simResult.requestErr, task.simResult.validationErr, task.receivedAt, task.eligibleAt, task.simResult.wasSimulated, task.profile, task.simResult.optimisticSubmission, task.simResult.blockValue)
+		if err == nil && task.adjustedValue != nil {
+			submission.AdjustedValue = database.NewNullString(task.adjustedValue.Dec())
+		}
+		if err == nil && task.simResult.filterErr != nil {
+			submission.SimSuccess = false
+			submission.FilterError = task.simResult.filterErr.Error()
+		}
+		var execPayload *database.ExecutionPayloadEntry
+		if err == nil && task.savePayload {
+			execPayload, err = database.PayloadToExecPayloadEntry(task.payload)
+		}
 		if err != nil {
-			api.log.WithError(err).WithFields(logrus.Fields{
-				"reg_pubkey":       valReg.Message.Pubkey,
-				"reg_feeRecipient": valReg.Message.FeeRecipient,
-				"reg_gasLimit":     valReg.Message.GasLimit,
-				"reg_timestamp":    valReg.Message.Timestamp,
-			}).Error("error saving validator registration")
+			metrics.BlockSubmissionDBDropCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("reason", "invalid")))
+			task.log.WithError(err).Error("failed to create builder block submission entry")
+			continue
+		}
+		tasks = append(tasks, task)
+		submissions = append(submissions, submission)
+		execPayloads = append(execPayloads, execPayload)
+	}
+
+	err := api.db.SaveBuilderBlockSubmissions(submissions, execPayloads)
+	if err != nil {
+		// submissions which failed to save are left without an id
+		numDropped := 0
+		for _, submission := range submissions {
+			if submission.ID == 0 {
+				numDropped++
+			}
+		}
+		metrics.DatabaseErrorCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("operation", "saveBuilderBlockSubmissions")))
+		metrics.BlockSubmissionDBDropCount.Add(context.Background(), int64(numDropped), otelapi.WithAttributes(attribute.String("reason", "database_error")))
+		api.log.WithError(err).WithFields(logrus.Fields{
+			"numSubmissions": len(submissions),
+			"numDropped":     numDropped,
+		}).Error("saving builder block submissions to database failed")
+	}
+
+	for i, submission := range submissions {
+		if err != nil && submission.ID == 0 {
+			continue
+		}
+		upsertErr := api.db.UpsertBlockBuilderEntryAfterSubmission(submission, tasks[i].simResult.validationErr != nil)
+		if upsertErr != nil {
+			tasks[i].log.WithError(upsertErr).Error("failed to upsert block-builder-entry")
 		}
 	}
 }
 
 // simulateBlock sends a request for a block simulation to blockSimRateLimiter.
-func (api *RelayAPI) simulateBlock(ctx context.Context, opts blockSimOptions) (blockValue *uint256.Int, requestErr, validationErr error) {
+func (api *RelayAPI) simulateBlock(ctx context.Context, opts blockSimOptions) (blockValue *uint256.Int, queueWait time.Duration, requestErr, validationErr error) {
 	t := time.Now()
-	response, requestErr, validationErr := api.blockSimRateLimiter.Send(ctx, opts.req, opts.isHighPrio, opts.fastTrack)
+	metrics.BlockSimQueueSize.Record(ctx, api.blockSimRateLimiter.CurrentCounter())
+	ctx, span := tracing.Start(ctx, "simulation")
+	response, queueWait, requestErr, validationErr := api.blockSimRateLimiter.Send(ctx, opts.req, opts.isHighPrio, opts.fastTrack)
+	span.SetAttributes(attribute.Int64("queueWaitMs", queueWait.Milliseconds()), attribute.Bool("fastTrack", opts.fastTrack))
+	tracing.EndSpan(span, cmp.Or(requestErr, validationErr))
 	log := opts.log.WithFields(logrus.Fields{
-		"durationMs": time.Since(t).Milliseconds(),
-		"numWaiting": api.blockSimRateLimiter.CurrentCounter(),
+		"durationMs":  time.Since(t).Milliseconds(),
+		"queueWaitMs": queueWait.Milliseconds(),
+		"numWaiting":  api.blockSimRateLimiter.CurrentCounter(),
 	})
 	if validationErr != nil {
 		if api.ffIgnorableValidationErrors {
@@ -615,23 +1346,43 @@ func (api *RelayAPI) simulateBlock(ctx context.Context, opts blockSimOptions) (b
 			ignoreError := validationErr.Error() == ErrBlockAlreadyKnown || validationErr.Error() == ErrBlockRequiresReorg || strings.Contains(validationErr.Error(), ErrMissingTrieNode)
 			if ignoreError {
 				log.WithError(validationErr).Warn("block validation failed with ignorable error")
-				return nil, nil, nil
+				return nil, queueWait, nil, nil
 			}
 		}
 		log.WithError(validationErr).Warn("block validation failed")
-		return nil, nil, validationErr
+		return nil, queueWait, nil, validationErr
 	}
 	if requestErr != nil {
 		log.WithError(requestErr).Warn("block validation failed: request error")
-		return nil, requestErr, nil
+		return nil, queueWait, requestErr, nil
+	}
+
+	// Valid blocks are also checked against the filter list, a match is recorded like a validation error
+	if api.filterList != nil {
+		if err := api.checkFilterList(opts.req.VersionedSubmitBlockRequest); err != nil {
+			log.WithError(err).Warn("block validation failed: filter list")
+			return nil, queueWait, nil, err
+		}
 	}
 
 	log.Info("block validation successful")
 	if response == nil {
 		log.Warn("block validation response is nil")
-		return nil, nil, nil
+		return nil, queueWait, nil, nil
+	}
+	return response.BlockValue, queueWait, nil, nil
+}
+
+func (api *RelayAPI) checkFilterList(payload *common.VersionedSubmitBlockRequest) error {
+	txs, err := payload.Transactions()
+	if err != nil {
+		return err
+	}
+	err = api.filterList.check(txs)
+	if errors.Is(err, ErrFilteredAddress) {
+		metrics.FilteredSubmissionCount.Add(context.Background(), 1)
 	}
-	return response.BlockValue, nil, nil
+	return err
 }
 
 func (api *RelayAPI) demoteBuilder(pubkey string, req *common.VersionedSubmitBlockRequest, simError error) {
@@ -696,9 +1447,13 @@ func (api *RelayAPI) processOptimisticBlock(opts blockSimOptions, simResultC cha
 		// it for logging, it is not atomic to avoid the performance impact.
 		"optBlocksInFlight": api.optimisticBlocksInFlight,
 	}).Infof("simulating optimistic block with hash: %v", submission.BidTrace.BlockHash.String())
-	blockValue, reqErr, simErr := api.simulateBlock(ctx, opts)
-	simResultC <- &blockSimResult{reqErr == nil, blockValue, true, reqErr, simErr}
-	if reqErr != nil || simErr != nil {
+	blockValue, queueWait, reqErr, simErr := api.simulateBlock(ctx, opts)
+	simErr, filterErr := splitFilterErr(simErr)
+	simResultC <- &blockSimResult{reqErr == nil, blockValue, true, reqErr, simErr, queueWait, filterErr}
+	if filterErr != nil {
+		// The filter list changed since the upfront check, which is not the builder's fault
+		opts.log.WithError(filterErr).Warn("optimistic block rejected by the filter list")
+	} else if reqErr != nil || simErr != nil {
 		// Mark builder as non-optimistic.
 		opts.builder.status.IsOptimistic = false
 		api.log.WithError(simErr).Warn("block simulation failed in processOptimisticBlock, demoting builder")
@@ -775,7 +1530,10 @@ func (api *RelayAPI) processPayloadAttributes(payloadAttributes beaconclient.Pay
 		}
 	}
 
-	// Step 2: save new one
+	// Step 2: save new one, and move the past-slot bound for submissions ahead of a lagging head
+	if payloadAttrSlot-1 > api.payloadAttrsParentSlot.Load() {
+		api.payloadAttrsParentSlot.Store(payloadAttrSlot - 1)
+	}
 	api.payloadAttributes[getPayloadAttributesKey(payloadAttributes.Data.ParentBlockHash, payloadAttrSlot)] = payloadAttributesHelper{
 		slot:              payloadAttrSlot,
 		parentHash:        payloadAttributes.Data.ParentBlockHash,
@@ -788,23 +1546,78 @@ func (api *RelayAPI) processPayloadAttributes(payloadAttributes beaconclient.Pay
 		"randao":    payloadAttributes.Data.PayloadAttributes.PrevRandao,
 		"timestamp": payloadAttributes.Data.PayloadAttributes.Timestamp,
 	}).Info("updated payload attributes")
-}
 
-func (api *RelayAPI) processNewSlot(headSlot uint64) {
-	prevHeadSlot := api.headSlot.Load()
-	if headSlot <= prevHeadSlot {
-		return
+	// Let builders know about the parent block of the slot through the proposer duties
+	parent := &common.ProposerDutyParent{
+		ParentHash:  payloadAttributes.Data.ParentBlockHash,
+		BlockNumber: payloadAttributes.Data.ParentBlockNumber + 1,
+		Timestamp:   payloadAttributes.Data.PayloadAttributes.Timestamp,
+		PrevRandao:  payloadAttributes.Data.PayloadAttributes.PrevRandao,
 	}
-
-	// If there's gaps between previous and new headslot, print the missed slots
-	if prevHeadSlot > 0 {
-		for s := prevHeadSlot + 1; s < headSlot; s++ {
-			api.log.WithField("missedSlot", s).Warnf("missed slot: %d", s)
-		}
+	if hasReachedFork(payloadAttrSlot, api.capellaEpoch) {
+		parent.WithdrawalsRoot = withdrawalsRoot.String()
 	}
+	if parentBeaconRoot != nil {
+		parent.ParentBeaconBlockRoot = parentBeaconRoot.String()
+	}
+	api.proposerDutiesLock.Lock()
+	api.proposerDutiesParents[payloadAttrSlot] = parent
+	api.updateProposerDutiesResponse()
+	api.proposerDutiesLock.Unlock()
 
-	// store the head slot
-	api.headSlot.Store(headSlot)
+	// The payload attributes can arrive before the head event was processed (i.e. for headSlot+2 if the relay head is lagging).
+	// Make sure the proposer duty is known already, so submissions for that slot can be validated right away.
+	go api.ensureProposerDutyForSlot(payloadAttrSlot)
+}
+
+// latestHeadSlot returns the latest slot which can't receive submissions anymore: the head slot, or the slot before the
+// latest payload attributes if the head event for it was not processed yet (i.e. the relay head is lagging)
+func (api *RelayAPI) latestHeadSlot() uint64 {
+	return max(api.headSlot.Load(), api.payloadAttrsParentSlot.Load())
+}
+
+// ensureProposerDutyForSlot updates the proposer duties if there is no duty known for the given slot
+func (api *RelayAPI) ensureProposerDutyForSlot(slot uint64) {
+	api.proposerDutiesLock.RLock()
+	_, found := api.proposerDutiesMap[slot]
+	api.proposerDutiesLock.RUnlock()
+	if found {
+		return
+	}
+
+	// Ensure only one updating is running at a time
+	if api.isUpdatingProposerDuties.Swap(true) {
+		return
+	}
+	defer api.isUpdatingProposerDuties.Store(false)
+
+	api.log.WithField("slot", slot).Info("no proposer duty known for slot, updating proposer duties")
+	api.UpdateProposerDutiesWithoutChecks(api.headSlot.Load())
+}
+
+func (api *RelayAPI) processNewSlot(headSlot uint64) {
+	prevHeadSlot := api.headSlot.Load()
+	if headSlot <= prevHeadSlot {
+		return
+	}
+
+	// If there's gaps between previous and new headslot, print the missed slots
+	if prevHeadSlot > 0 {
+		for s := prevHeadSlot + 1; s < headSlot; s++ {
+			api.log.WithField("missedSlot", s).Warnf("missed slot: %d", s)
+		}
+	}
+
+	// store the head slot
+	api.headSlot.Store(headSlot)
+
+	// forget about submissions which can't be received anymore
+	api.submissionDedup.cleanup(headSlot + 1)
+
+	// forget about top bids which can't be requested anymore
+	if api.topBidCache != nil {
+		api.topBidCache.Prune(headSlot)
+	}
 
 	// only for builder-api
 	if api.opts.BlockBuilderAPI || api.opts.ProposerAPI {
@@ -817,6 +1630,7 @@ func (api *RelayAPI) processNewSlot(headSlot uint64) {
 
 	if api.opts.ProposerAPI {
 		go api.datastore.RefreshKnownValidators(api.log, api.beaconClient, headSlot)
+		go api.updateCircuitBreaker()
 	}
 
 	// log
@@ -828,6 +1642,17 @@ func (api *RelayAPI) processNewSlot(headSlot uint64) {
 	}).Infof("updated headSlot to %d", headSlot)
 }
 
+func (api *RelayAPI) updateCircuitBreaker() {
+	state, err := api.redis.GetCircuitBreakerState()
+	if err != nil {
+		api.log.WithError(err).Error("failed to get circuit breaker state")
+		return
+	}
+	if wasTripped := api.isCircuitBreakerTripped.Swap(state.IsTripped); wasTripped != state.IsTripped {
+		api.log.WithField("numMissedSlots", state.NumMissedSlots).Warnf("circuit breaker tripped: %t", state.IsTripped)
+	}
+}
+
 func (api *RelayAPI) updateProposerDuties(headSlot uint64) {
 	// Ensure only one updating is running at a time
 	if api.isUpdatingProposerDuties.Swap(true) {
@@ -835,8 +1660,9 @@ func (api *RelayAPI) updateProposerDuties(headSlot uint64) {
 	}
 	defer api.isUpdatingProposerDuties.Store(false)
 
-	// Update once every 8 slots (or more, if a slot was missed)
-	if headSlot%8 != 0 && headSlot-api.proposerDutiesSlot < 8 {
+	// Update once every 8 slots (or more, if a slot was missed), and on every slot while no duties of the next epoch are known
+	nextEpochKnown := api.proposerDutiesMaxSlot >= (headSlot/common.SlotsPerEpoch+1)*common.SlotsPerEpoch
+	if headSlot%8 != 0 && headSlot-api.proposerDutiesSlot < 8 && nextEpochKnown {
 		return
 	}
 
@@ -851,25 +1677,48 @@ func (api *RelayAPI) UpdateProposerDutiesWithoutChecks(headSlot uint64) {
 		return
 	}
 
-	// Prepare raw bytes for HTTP response
-	respBytes, err := json.Marshal(duties)
+	// Attach the preferences of the proposers, to enforce them and to let builders know about them
+	pubkeys := make([]string, 0, len(duties))
+	for _, duty := range duties {
+		if duty.Entry != nil && duty.Entry.Message != nil {
+			pubkeys = append(pubkeys, duty.Entry.Message.Pubkey.String())
+		}
+	}
+	preferences, err := api.redis.GetProposerPreferences(pubkeys...)
 	if err != nil {
-		api.log.WithError(err).Error("error marshalling duties")
+		api.log.WithError(err).Error("failed getting proposer preferences from redis")
+	}
+	for i, duty := range duties {
+		if duty.Entry == nil || duty.Entry.Message == nil {
+			continue
+		}
+		duties[i].GasLimit = duty.Entry.Message.GasLimit
+		if entry, found := preferences[strings.ToLower(duty.Entry.Message.Pubkey.String())]; found {
+			duties[i].Preferences = entry.Message
+			duties[i].HasPreferences = true
+		}
 	}
 
 	// Prepare the map for lookup by slot
 	dutiesMap := make(map[uint64]*common.BuilderGetValidatorsResponseEntry)
+	maxSlot := uint64(0)
 	for index, duty := range duties {
 		dutiesMap[duty.Slot] = &duties[index]
+		maxSlot = max(maxSlot, duty.Slot)
 	}
 
 	// Update
 	api.proposerDutiesLock.Lock()
-	if len(respBytes) > 0 {
-		api.proposerDutiesResponse = &respBytes
+	for slot := range api.proposerDutiesParents {
+		if slot <= headSlot {
+			delete(api.proposerDutiesParents, slot)
+		}
 	}
+	api.proposerDuties = duties
+	api.updateProposerDutiesResponse()
 	api.proposerDutiesMap = dutiesMap
 	api.proposerDutiesSlot = headSlot
+	api.proposerDutiesMaxSlot = maxSlot
 	api.proposerDutiesLock.Unlock()
 
 	// pretty-print
@@ -881,6 +1730,23 @@ func (api *RelayAPI) UpdateProposerDutiesWithoutChecks(headSlot uint64) {
 	api.log.Infof("proposer duties updated: %s", strings.Join(_duties, ", "))
 }
 
+// updateProposerDutiesResponse prepares the raw bytes of the getValidators response, with the parent blocks known from
+// the payload attributes. Must be called with the proposerDutiesLock held.
+func (api *RelayAPI) updateProposerDutiesResponse() {
+	duties := make([]common.BuilderGetValidatorsResponseEntry, len(api.proposerDuties))
+	copy(duties, api.proposerDuties)
+	for i, duty := range duties {
+		duties[i].Parent = api.proposerDutiesParents[duty.Slot]
+	}
+
+	respBytes, err := json.Marshal(duties)
+	if err != nil {
+		api.log.WithError(err).Error("error marshalling duties")
+		return
+	}
+	api.proposerDutiesResponse = &respBytes
+}
+
 func (api *RelayAPI) prepareBuildersForSlot(headSlot uint64) {
 	// Wait until there are no optimistic blocks being processed. Then we can
 	// safely update the slot.
@@ -894,6 +1760,20 @@ func (api *RelayAPI) prepareBuildersForSlot(headSlot uint64) {
 	}
 	api.log.Debugf("Updating builder cache with %d builders from database", len(builders))
 
+	var builderScores map[string]*common.BuilderScore
+	if api.ffBuilderScores {
+		builderScores, err = api.redis.GetBuilderScores()
+		if err != nil {
+			api.log.WithError(err).Error("unable to read builder scores from redis, using builder status from db")
+		}
+	}
+
+	// statuses published by the housekeeper, i.e. automatic demotions
+	builderStatuses, err := api.redis.GetBlockBuilderStatuses()
+	if err != nil {
+		api.log.WithError(err).Error("unable to read builder statuses from redis")
+	}
+
 	newCache := make(map[string]*blockBuilderCacheEntry)
 	for _, v := range builders {
 		entry := &blockBuilderCacheEntry{ //nolint:exhaustruct
@@ -903,6 +1783,13 @@ func (api *RelayAPI) prepareBuildersForSlot(headSlot uint64) {
 				IsOptimistic:  v.IsOptimistic,
 			},
 		}
+		if score, ok := builderScores[v.BuilderPubkey]; ok {
+			entry.status = applyBuilderScore(entry.status, score.Score)
+		}
+		if status, ok := builderStatuses[v.BuilderPubkey]; ok {
+			entry.status.IsHighPrio = entry.status.IsHighPrio && status.IsHighPrio
+			entry.status.IsOptimistic = entry.status.IsOptimistic && status.IsOptimistic
+		}
 		// Try to parse builder collateral string to big int.
 		builderCollateral, ok := big.NewInt(0).SetString(v.Collateral, 10)
 		if !ok {
@@ -916,8 +1803,24 @@ func (api *RelayAPI) prepareBuildersForSlot(headSlot uint64) {
 	api.blockBuildersCache = newCache
 }
 
+// applyBuilderScore derives the high-prio and optimistic status from the builder score. Blacklisting stays a manual
+// decision, and builders still need to be set as optimistic (with collateral) to be processed optimistically.
+func applyBuilderScore(status common.BuilderStatus, score float64) common.BuilderStatus {
+	return common.BuilderStatus{
+		IsHighPrio:    score >= builderScoreHighPrioMin,
+		IsBlacklisted: status.IsBlacklisted,
+		IsOptimistic:  status.IsOptimistic && score >= builderScoreOptimisticMin,
+	}
+}
+
+// RespondError responds with the generic error code of the HTTP status
 func (api *RelayAPI) RespondError(w http.ResponseWriter, code int, message string) {
-	api.Respond(w, code, HTTPErrorResp{code, message})
+	api.RespondErrorCode(w, code, errorCodeForStatus(code), message)
+}
+
+// RespondErrorCode responds with a specific error code, which clients can rely on instead of the message
+func (api *RelayAPI) RespondErrorCode(w http.ResponseWriter, code int, errorCode ErrorCode, message string) {
+	api.Respond(w, code, HTTPErrorResp{Code: code, ErrorCode: errorCode, Message: message})
 }
 
 func (api *RelayAPI) RespondOK(w http.ResponseWriter, response any) {
@@ -942,6 +1845,16 @@ func (api *RelayAPI) Respond(w http.ResponseWriter, code int, response any) {
 	}
 }
 
+// RespondSSZ writes an SSZ encoded response, with its fork in the Eth-Consensus-Version header
+func (api *RelayAPI) RespondSSZ(w http.ResponseWriter, version spec.DataVersion, response []byte) {
+	w.Header().Set("Content-Type", ApplicationOctetStream)
+	w.Header().Set(HeaderEthConsensusVersion, version.String())
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(response); err != nil {
+		api.log.WithError(err).Error("Couldn't write response")
+	}
+}
+
 func (api *RelayAPI) handleStatus(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
@@ -949,6 +1862,8 @@ func (api *RelayAPI) handleStatus(w http.ResponseWriter, req *http.Request) {
 const (
 	ApplicationJSON        = "application/json"
 	ApplicationOctetStream = "application/octet-stream"
+
+	HeaderEthConsensusVersion = "Eth-Consensus-Version"
 )
 
 // RequestAcceptsJSON returns true if the Accept header is empty (defaults to JSON)
@@ -1019,33 +1934,57 @@ func (api *RelayAPI) handleRegisterValidator(w http.ResponseWriter, req *http.Re
 	}
 
 	start := time.Now().UTC()
-	registrationTimestampUpperBound := start.Unix() + 10 // 10 seconds from now
+	registrationTimestampUpperBound := start.Unix() + 10                       // 10 seconds from now
+	registrationTimestampLowerBound := int64(api.genesisInfo.Data.GenesisTime) //nolint:gosec
+	if validatorRegMaxAgeDays > 0 {
+		registrationTimestampLowerBound = max(registrationTimestampLowerBound, start.Add(-time.Duration(validatorRegMaxAgeDays)*24*time.Hour).Unix())
+	}
 
 	numRegTotal := 0
-	numRegProcessed := 0
 	numRegActive := 0
-	numRegNew := 0
-	processingStoppedByError := false
+	numRegNew := uberatomic.NewInt64(0)
 
-	// Setup error handling
-	handleError := func(_log *logrus.Entry, code int, msg string) {
-		processingStoppedByError = true
+	// Invalid registrations are collected, without stopping the processing of the others
+	var failuresLock sync.Mutex
+	failures := []RegisterValidatorFailure{}
+	addFailure := func(_log *logrus.Entry, index int, pubkey string, errorCode ErrorCode, msg string) {
 		_log.Warnf("error: %s", msg)
-		api.RespondError(w, code, msg)
+		failuresLock.Lock()
+		failures = append(failures, RegisterValidatorFailure{Index: index, Pubkey: pubkey, ErrorCode: errorCode, Error: msg})
+		failuresLock.Unlock()
 	}
 
 	// Start processing
 	if req.ContentLength == 0 {
 		log.Info("empty request")
-		api.RespondError(w, http.StatusBadRequest, "empty request")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "empty request")
 		return
 	}
 
-	limitReader := io.LimitReader(req.Body, int64(apiMaxPayloadBytes))
-	body, err := io.ReadAll(limitReader)
-	if err != nil {
+	var r io.Reader = req.Body
+	isGzip := req.Header.Get("Content-Encoding") == "gzip"
+	log = log.WithField("reqIsGzip", isGzip)
+	if isGzip {
+		gzipReader, err := gzip.NewReader(req.Body)
+		if err != nil {
+			log.WithError(err).Warn("could not create gzip reader")
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
+			return
+		}
+		defer gzipReader.Close()
+		r = gzipReader
+	}
+
+	// the decompressed body is limited as well, bodyLimitMiddleware only limits the compressed size. Reads past the
+	// limit fail with http.MaxBytesError instead of truncating the body.
+	body, err := io.ReadAll(http.MaxBytesReader(w, io.NopCloser(r), int64(registerValidatorMaxBodyBytes)))
+	if isMaxBytesError(err) {
+		log.WithError(err).Warn("request body too large")
+		api.RespondErrorCode(w, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge, ErrRequestBodyTooLarge.Error())
+		return
+	} else if err != nil {
 		log.WithError(err).Warn("failed to read request body")
-		api.RespondError(w, http.StatusBadRequest, "failed to read request body")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "failed to read request body")
 		return
 	}
 	req.Body.Close()
@@ -1123,22 +2062,57 @@ func (api *RelayAPI) handleRegisterValidator(w http.ResponseWriter, req *http.Re
 		return reg, nil
 	}
 
+	// Verify the signatures of new registrations concurrently, and queue valid ones for saving
+	type regVerifyTask struct {
+		log   *logrus.Entry
+		index int
+		reg   *builderApiV1.SignedValidatorRegistration
+	}
+	regVerifyC := make(chan regVerifyTask, numValidatorRegVerifiers)
+	var regVerifyWG sync.WaitGroup
+	for range numValidatorRegVerifiers {
+		regVerifyWG.Add(1)
+		go func() {
+			defer regVerifyWG.Done()
+			for task := range regVerifyC {
+				ok, err := ssz.VerifySignature(task.reg.Message, api.opts.EthNetDetails.DomainBuilder, task.reg.Message.Pubkey[:], task.reg.Signature[:])
+				if err != nil {
+					task.log.WithError(err).Error("error verifying registerValidator signature")
+					addFailure(task.log, task.index, task.reg.Message.Pubkey.String(), ErrorCodeInvalidSignature, "failed to verify validator signature")
+					continue
+				} else if !ok {
+					task.log.Info("invalid validator signature")
+					if !api.ffRegValContinueOnInvalidSig {
+						addFailure(task.log, task.index, task.reg.Message.Pubkey.String(), ErrorCodeInvalidSignature, "invalid validator signature")
+					}
+					continue
+				}
+
+				// Now we have a new registration to process
+				numRegNew.Inc()
+
+				// Save to database
+				select {
+				case api.validatorRegC <- *task.reg:
+				default:
+					task.log.Error("validator registration channel full")
+				}
+			}
+		}()
+	}
+
 	// Iterate over the registrations
 	_, err = jsonparser.ArrayEach(body, func(value []byte, dataType jsonparser.ValueType, offset int, _err error) {
+		index := numRegTotal
 		numRegTotal += 1
-		if processingStoppedByError {
-			return
-		}
-		numRegProcessed += 1
 		regLog := log.WithFields(logrus.Fields{
-			"numRegistrationsSoFar":     numRegTotal,
-			"numRegistrationsProcessed": numRegProcessed,
+			"numRegistrationsSoFar": numRegTotal,
 		})
 
 		// Extract immediately necessary registration fields
 		signedValidatorRegistration, err := parseRegistration(value)
 		if err != nil {
-			handleError(regLog, http.StatusBadRequest, err.Error())
+			addFailure(regLog, index, "", ErrorCodeInvalidRequestBody, err.Error())
 			return
 		}
 
@@ -1154,23 +2128,23 @@ func (api *RelayAPI) handleRegisterValidator(w http.ResponseWriter, req *http.Re
 
 		// Ensure a valid timestamp (not too early, and not too far in the future)
 		registrationTimestamp := signedValidatorRegistration.Message.Timestamp.Unix()
-		if registrationTimestamp < int64(api.genesisInfo.Data.GenesisTime) { //nolint:gosec
-			handleError(regLog, http.StatusBadRequest, "timestamp too early")
+		if registrationTimestamp < registrationTimestampLowerBound {
+			addFailure(regLog, index, pkHex.String(), ErrorCodeInvalidTimestamp, "timestamp too early")
 			return
 		} else if registrationTimestamp > registrationTimestampUpperBound {
-			handleError(regLog, http.StatusBadRequest, "timestamp too far in the future")
+			addFailure(regLog, index, pkHex.String(), ErrorCodeInvalidTimestamp, "timestamp too far in the future")
 			return
 		}
 
 		// Check if a real validator
 		isKnownValidator := api.datastore.IsKnownValidator(pkHex)
 		if !isKnownValidator {
-			handleError(regLog, http.StatusBadRequest, fmt.Sprintf("not a known validator: %s", pkHex))
+			addFailure(regLog, index, pkHex.String(), ErrorCodeUnknownValidator, fmt.Sprintf("not a known validator: %s", pkHex))
 			return
 		}
 
 		// Check for a previous registration timestamp
-		prevTimestamp, err := api.redis.GetValidatorRegistrationTimestamp(pkHex)
+		prevTimestamp, err := api.datastore.GetValidatorRegistrationTimestamp(pkHex)
 		if err != nil {
 			regLog.WithError(err).Error("error getting last registration timestamp")
 		} else if prevTimestamp >= uint64(signedValidatorRegistration.Message.Timestamp.Unix()) { //nolint:gosec
@@ -1179,43 +2153,23 @@ func (api *RelayAPI) handleRegisterValidator(w http.ResponseWriter, req *http.Re
 		}
 
 		// Verify the signature
-		ok, err := ssz.VerifySignature(signedValidatorRegistration.Message, api.opts.EthNetDetails.DomainBuilder, signedValidatorRegistration.Message.Pubkey[:], signedValidatorRegistration.Signature[:])
-		if err != nil {
-			regLog.WithError(err).Error("error verifying registerValidator signature")
-			return
-		} else if !ok {
-			regLog.Info("invalid validator signature")
-			if api.ffRegValContinueOnInvalidSig {
-				return
-			} else {
-				handleError(regLog, http.StatusBadRequest, "failed to verify validator signature for "+signedValidatorRegistration.Message.Pubkey.String())
-				return
-			}
-		}
-
-		// Now we have a new registration to process
-		numRegNew += 1
-
-		// Save to database
-		select {
-		case api.validatorRegC <- *signedValidatorRegistration:
-		default:
-			regLog.Error("validator registration channel full")
-		}
+		regVerifyC <- regVerifyTask{log: regLog, index: index, reg: signedValidatorRegistration}
 	})
+	close(regVerifyC)
+	regVerifyWG.Wait()
 
 	log = log.WithFields(logrus.Fields{
-		"timeNeededSec":             time.Since(start).Seconds(),
-		"timeNeededMs":              time.Since(start).Milliseconds(),
-		"numRegistrations":          numRegTotal,
-		"numRegistrationsActive":    numRegActive,
-		"numRegistrationsProcessed": numRegProcessed,
-		"numRegistrationsNew":       numRegNew,
-		"processingStoppedByError":  processingStoppedByError,
+		"timeNeededSec":          time.Since(start).Seconds(),
+		"timeNeededMs":           time.Since(start).Milliseconds(),
+		"numRegistrations":       numRegTotal,
+		"numRegistrationsActive": numRegActive,
+		"numRegistrationsNew":    numRegNew.Load(),
+		"numRegistrationsFailed": len(failures),
 	})
 
 	if err != nil {
-		handleError(log, http.StatusBadRequest, "error in traversing json")
+		log.WithError(err).Warn("error: error in traversing json")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "error in traversing json")
 		return
 	}
 
@@ -1225,7 +2179,104 @@ func (api *RelayAPI) handleRegisterValidator(w http.ResponseWriter, req *http.Re
 	default:
 	}
 
+	numRegUnchanged := int64(numRegTotal-len(failures)) - numRegNew.Load()
+	metrics.ValidatorRegistrationCount.Add(req.Context(), numRegNew.Load(), otelapi.WithAttributes(attribute.String("result", "new")))
+	metrics.ValidatorRegistrationCount.Add(req.Context(), numRegUnchanged, otelapi.WithAttributes(attribute.String("result", "unchanged")))
+	metrics.ValidatorRegistrationCount.Add(req.Context(), int64(len(failures)), otelapi.WithAttributes(attribute.String("result", "failed")))
+
 	log.Info("validator registrations call processed")
+	if len(failures) > 0 {
+		sort.Slice(failures, func(i, j int) bool { return failures[i].Index < failures[j].Index })
+		api.Respond(w, http.StatusBadRequest, RegisterValidatorErrorResp{
+			Code:      http.StatusBadRequest,
+			ErrorCode: ErrorCodeRegistrationsFailed,
+			Message:   fmt.Sprintf("%d of %d registrations failed", len(failures), numRegTotal),
+			Failures:  failures,
+		})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (api *RelayAPI) handleProposerPreferences(w http.ResponseWriter, req *http.Request) {
+	ua := req.UserAgent()
+	log := api.log.WithFields(logrus.Fields{
+		"method":        "proposerPreferences",
+		"ua":            ua,
+		"headSlot":      api.headSlot.Load(),
+		"contentLength": req.ContentLength,
+	})
+
+	body, err := io.ReadAll(req.Body)
+	if isMaxBytesError(err) {
+		log.WithError(err).Warn("request body too large")
+		api.RespondErrorCode(w, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge, ErrRequestBodyTooLarge.Error())
+		return
+	} else if err != nil {
+		log.WithError(err).Warn("failed to read request body")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "failed to read request body")
+		return
+	}
+	req.Body.Close()
+
+	preferences := new(common.SignedProposerPreferences)
+	err = json.Unmarshal(body, preferences)
+	if err != nil || preferences.Message == nil {
+		log.WithError(err).Warn("failed to decode proposer preferences")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "failed to decode proposer preferences")
+		return
+	}
+	msg := preferences.Message
+	pubkey := common.NewPubkeyHex(msg.Pubkey.String())
+	log = log.WithFields(logrus.Fields{
+		"pubkey":             pubkey,
+		"timestamp":          msg.Timestamp,
+		"minBidValue":        msg.MinBidValue.String(),
+		"numBlockedBuilders": len(msg.BlockedBuilders),
+		"maxGasUsed":         msg.MaxGasUsed,
+		"requirePaymentLast": msg.RequirePaymentLast,
+	})
+
+	if !api.datastore.IsKnownValidator(pubkey) {
+		log.Info("proposer preferences of unknown validator")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeUnknownValidator, "not a known validator")
+		return
+	}
+
+	if msg.Timestamp > uint64(time.Now().Unix())+10 { //nolint:gosec
+		log.Info("proposer preferences timestamp too far in the future")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidTimestamp, "timestamp too far in the future")
+		return
+	}
+
+	ok, err := ssz.VerifySignature(msg, api.opts.EthNetDetails.DomainProposerPreferences, msg.Pubkey[:], preferences.Signature[:])
+	if err != nil || !ok {
+		log.WithError(err).Info("invalid proposer preferences signature")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidSignature, "invalid signature")
+		return
+	}
+
+	// Preferences can only be replaced by newer ones, which prevents replaying older signed preferences
+	knownPreferences, err := api.redis.GetProposerPreferences(pubkey.String())
+	if err != nil {
+		log.WithError(err).Error("failed getting proposer preferences from redis")
+		api.RespondError(w, http.StatusInternalServerError, "failed getting proposer preferences")
+		return
+	}
+	if known, found := knownPreferences[pubkey.String()]; found && known.Message.Timestamp >= msg.Timestamp {
+		log.WithField("knownTimestamp", known.Message.Timestamp).Info("proposer preferences are not newer than the known ones")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeStalePreferences, "timestamp is not newer than the current preferences")
+		return
+	}
+
+	err = api.redis.SetProposerPreferences(preferences)
+	if err != nil {
+		log.WithError(err).Error("failed saving proposer preferences to redis")
+		api.RespondError(w, http.StatusInternalServerError, "failed saving proposer preferences")
+		return
+	}
+
+	log.Info("proposer preferences updated")
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -1239,13 +2290,13 @@ func (api *RelayAPI) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 
 	slot, err := strconv.ParseUint(slotStr, 10, 64)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, common.ErrInvalidSlot.Error())
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidSlot, common.ErrInvalidSlot.Error())
 		return
 	}
 
 	requestTime := time.Now().UTC()
-	slotStartTimestamp := api.genesisInfo.Data.GenesisTime + (slot * common.SecondsPerSlot)
-	msIntoSlot := requestTime.UnixMilli() - int64(slotStartTimestamp*1000) //nolint:gosec
+	slotStartTimestamp := api.slotClock().SlotStartTimestamp(slot)
+	msIntoSlot := api.slotClock().MsIntoSlot(slot, requestTime)
 
 	log := api.log.WithFields(logrus.Fields{
 		"method":           "getHeader",
@@ -1261,23 +2312,23 @@ func (api *RelayAPI) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 	})
 
 	if len(proposerPubkeyHex) != 98 {
-		api.RespondError(w, http.StatusBadRequest, common.ErrInvalidPubkey.Error())
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidPubkey, common.ErrInvalidPubkey.Error())
 		return
 	}
 
 	if len(parentHashHex) != 66 {
-		api.RespondError(w, http.StatusBadRequest, common.ErrInvalidHash.Error())
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidHash, common.ErrInvalidHash.Error())
 		return
 	}
 
 	if slot < headSlot {
-		api.RespondError(w, http.StatusBadRequest, "slot is too old")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodePastSlot, "slot is too old")
 		return
 	}
 
-	// TODO: Use NegotiateRequestResponseType, for now we only accept JSON
-	if !RequestAcceptsJSON(req) {
-		api.RespondError(w, http.StatusNotAcceptable, "only Accept: application/json is currently supported")
+	respMimeType, err := NegotiateRequestResponseType(req)
+	if err != nil {
+		api.RespondError(w, http.StatusNotAcceptable, "only Accept: application/json or application/octet-stream is supported")
 		return
 	}
 
@@ -1295,24 +2346,50 @@ func (api *RelayAPI) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if api.ffForceGetHeader204 {
+	if api.ffForceGetHeader204.Load() {
 		log.Info("forced getHeader 204 response")
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
+	if api.isCircuitBreakerTripped.Load() {
+		log.Info("circuit breaker tripped, getHeader 204 response")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	// Only allow requests for the current slot until a certain cutoff time
 	if getHeaderRequestCutoffMs > 0 && msIntoSlot > 0 && msIntoSlot > int64(getHeaderRequestCutoffMs) {
-		log.Info("getHeader sent too late")
+		log.WithField("msAfterCutoff", msIntoSlot-int64(getHeaderRequestCutoffMs)).Info("getHeader sent too late")
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	bid, err := api.redis.GetBestBid(slot, parentHashHex, proposerPubkeyHex)
-	if err != nil {
-		log.WithError(err).Error("could not get bid")
-		api.RespondError(w, http.StatusBadRequest, err.Error())
-		return
+	// Serve the top bid from memory if known, and read it from Redis otherwise
+	var bid *builderSpec.VersionedSignedBuilderBid
+	isCachedBid := false
+	if api.topBidCache != nil {
+		bid, isCachedBid = api.topBidCache.GetBestBid(slot, parentHashHex, proposerPubkeyHex)
+	}
+	// Slow responses are worse than no bid, the proposer may time out and miss the slot
+	lookupCtx := req.Context()
+	if getHeaderDeadlineMs > 0 {
+		var cancel context.CancelFunc
+		lookupCtx, cancel = context.WithDeadline(lookupCtx, requestTime.Add(time.Duration(getHeaderDeadlineMs)*time.Millisecond))
+		defer cancel()
+	}
+	if !isCachedBid {
+		bid, err = api.redis.GetBestBid(lookupCtx, slot, parentHashHex, proposerPubkeyHex)
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(lookupCtx.Err(), context.DeadlineExceeded) {
+			log.WithField("deadlineMs", getHeaderDeadlineMs).Warn("getting the bid exceeded the deadline, getHeader 204 response")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		} else if err != nil {
+			metrics.RedisErrorCount.Add(req.Context(), 1, otelapi.WithAttributes(attribute.String("operation", "getBestBid")))
+			log.WithError(err).Error("could not get bid")
+			api.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 	}
 
 	if bid == nil || bid.IsEmpty() {
@@ -1337,25 +2414,139 @@ func (api *RelayAPI) handleGetHeader(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Don't serve bids below the relay's minimum bid, the proposer is better off building locally
+	if api.opts.MinBid != nil && value.Lt(api.opts.MinBid) {
+		log.WithFields(logrus.Fields{
+			"value":  value.String(),
+			"minBid": api.opts.MinBid.String(),
+		}).Info("bid below minimum bid, getHeader 204 response")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Don't serve bids below the minimum bid the proposer set for itself
+	preferences := api.proposerPreferencesForSlot(slot, proposerPubkeyHex)
+	if preferences != nil && preferences.MinBidValue != nil && value.Lt(preferences.MinBidValue) {
+		log.WithFields(logrus.Fields{
+			"value":  value.String(),
+			"minBid": preferences.MinBidValue.String(),
+		}).Info("bid below proposer minimum bid, getHeader 204 response")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	// Don't serve bids of builders the proposer blocked. The top bid may have been submitted before the preferences were
+	// known, so its builder is checked again.
+	if preferences != nil && len(preferences.BlockedBuilders) > 0 {
+		bidTrace, err := api.redis.GetBidTrace(slot, proposerPubkeyHex, blockHash.String())
+		if err != nil {
+			log.WithError(err).Warn("could not get the bid trace to check the blocked builders, getHeader 204 response")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if preferences.IsBuilderBlocked(bidTrace.BuilderPubkey) {
+			log.WithField("builderPubkey", bidTrace.BuilderPubkey.String()).Info("bid of a builder blocked by the proposer, getHeader 204 response")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+
+	// Don't serve bids which were invalidated through the internal API
+	var isInvalidated bool
+	if isCachedBid {
+		isInvalidated = api.topBidCache.IsBidInvalidated(slot, blockHash.String())
+	} else {
+		isInvalidated, err = api.redis.IsGetHeaderBidInvalidated(lookupCtx, slot, blockHash.String())
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.WithField("deadlineMs", getHeaderDeadlineMs).Warn("checking the bid invalidation exceeded the deadline, getHeader 204 response")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		} else if err != nil {
+			log.WithError(err).Error("could not check if bid was invalidated")
+		}
+	}
+	if isInvalidated {
+		log.WithField("blockHash", blockHash.String()).Warn("bid was invalidated, getHeader 204 response")
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	log.WithFields(logrus.Fields{
 		"value":     value.String(),
 		"blockHash": blockHash.String(),
 	}).Info("bid delivered")
 
-	api.RespondOK(w, bid)
+	if respMimeType == ApplicationOctetStream {
+		sszBytes, err := common.SignedBuilderBidToSSZ(bid)
+		if err != nil {
+			log.WithError(err).Error("could not encode bid as SSZ")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		api.RespondSSZ(w, bid.Version, sszBytes)
+	} else {
+		api.RespondOK(w, bid)
+	}
+
+	// Queue the served bid for saving to the database, to be able to compare it with the block the proposer used later
+	if !api.ffDisableGetHeaderLog {
+		entry := database.GetHeaderLogEntry{
+			Slot:           slot,
+			ParentHash:     parentHashHex,
+			ProposerPubkey: proposerPubkeyHex,
+			BlockHash:      blockHash.String(),
+			Value:          database.NewU256Str(value),
+			UserAgent:      ua,
+			RequestedAt:    requestTime,
+			LatencyMs:      uint64(time.Since(requestTime).Milliseconds()), //nolint:gosec
+		}
+		select {
+		case api.getHeaderLogC <- entry:
+		default:
+			metrics.GetHeaderLogDBDropCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("reason", "queue_full")))
+			log.Error("getHeader log channel full")
+		}
+	}
+}
+
+// saveGetPayloadFailure saves a failed getPayload request to the database in the background
+func (api *RelayAPI) saveGetPayloadFailure(log *logrus.Entry, entry database.GetPayloadFailureEntry) {
+	api.backgroundDBWritesWG.Add(1)
+	go func() {
+		defer api.backgroundDBWritesWG.Done()
+		err := api.db.InsertGetPayloadFailure(entry)
+		if err != nil {
+			log.WithError(err).Error("failed to insert getPayload failure into db")
+		}
+	}()
 }
 
+// checkProposerSignature verifies the signature with the beacon proposer domain of the fork at the slot of the block. If
+// the fork schedule doesn't know the fork of the slot (i.e. it couldn't be loaded), the domain of the block version is used.
 func (api *RelayAPI) checkProposerSignature(block *common.VersionedSignedBlindedBeaconBlock, pubKey []byte) (bool, error) {
+	var domain phase0.Domain
 	switch block.Version { //nolint:exhaustive
 	case spec.DataVersionCapella:
-		return verifyBlockSignature(block, api.opts.EthNetDetails.DomainBeaconProposerCapella, pubKey)
+		domain = api.opts.EthNetDetails.DomainBeaconProposerCapella
 	case spec.DataVersionDeneb:
-		return verifyBlockSignature(block, api.opts.EthNetDetails.DomainBeaconProposerDeneb, pubKey)
+		domain = api.opts.EthNetDetails.DomainBeaconProposerDeneb
 	case spec.DataVersionElectra:
-		return verifyBlockSignature(block, api.opts.EthNetDetails.DomainBeaconProposerElectra, pubKey)
+		domain = api.opts.EthNetDetails.DomainBeaconProposerElectra
 	default:
 		return false, errors.New("unsupported consensus data version")
 	}
+
+	slot, err := block.Slot()
+	if err != nil {
+		return false, err
+	}
+	slotDomain, err := api.opts.EthNetDetails.DomainBeaconProposerAtSlot(uint64(slot))
+	if err == nil {
+		domain = slotDomain
+	} else if !errors.Is(err, common.ErrUnknownForkVersion) {
+		return false, err
+	}
+	return verifyBlockSignature(block, domain, pubKey)
 }
 
 func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request) {
@@ -1365,6 +2556,9 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 	ua := req.UserAgent()
 	headSlot := api.headSlot.Load()
 	receivedAt := time.Now().UTC()
+
+	ctx, span := tracing.StartRequest(req, "getPayload")
+	defer span.End()
 	log := api.log.WithFields(logrus.Fields{
 		"method":                "getPayload",
 		"ua":                    ua,
@@ -1390,9 +2584,9 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 		)
 	}()
 
-	// TODO: Use NegotiateRequestResponseType, for now we only accept JSON
-	if !RequestAcceptsJSON(req) {
-		api.RespondError(w, http.StatusNotAcceptable, "only Accept: application/json is currently supported")
+	respMimeType, err := NegotiateRequestResponseType(req)
+	if err != nil {
+		api.RespondError(w, http.StatusNotAcceptable, "only Accept: application/json or application/octet-stream is supported")
 		return
 	}
 
@@ -1409,10 +2603,14 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 		}
 	}
 
-	// Read the body first, so we can decode it later
-	limitReader := io.LimitReader(req.Body, int64(apiMaxPayloadBytes))
-	body, err := io.ReadAll(limitReader)
+	// Read the body first, so we can decode it later (limited by bodyLimitMiddleware)
+	body, err := io.ReadAll(req.Body)
 	if err != nil {
+		if isMaxBytesError(err) {
+			log.WithError(err).Warn("getPayload request body too large")
+			api.RespondErrorCode(w, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge, ErrRequestBodyTooLarge.Error())
+			return
+		}
 		if strings.Contains(err.Error(), "i/o timeout") {
 			log.WithError(err).Error("getPayload request failed to decode (i/o timeout)")
 			api.RespondError(w, http.StatusInternalServerError, err.Error())
@@ -1420,7 +2618,7 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 		}
 
 		log.WithError(err).Error("could not read body of request from the beacon node")
-		api.RespondError(w, http.StatusBadRequest, err.Error())
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 		return
 	}
 
@@ -1428,32 +2626,33 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 	payload := new(common.VersionedSignedBlindedBeaconBlock)
 	if err := json.NewDecoder(bytes.NewReader(body)).Decode(payload); err != nil {
 		log.WithError(err).Warn("failed to decode getPayload request")
-		api.RespondError(w, http.StatusBadRequest, "failed to decode payload")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "failed to decode payload")
 		return
 	}
 
 	// Take time after the decoding, and add to logging
 	decodeTime := time.Now().UTC()
+	tracing.Record(ctx, "decode", receivedAt, decodeTime)
 	slot, err := payload.Slot()
 	if err != nil {
 		log.WithError(err).Warn("failed to get payload slot")
-		api.RespondError(w, http.StatusBadRequest, "failed to get payload slot")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "failed to get payload slot")
 		return
 	}
 	blockHash, err := payload.ExecutionBlockHash()
 	if err != nil {
 		log.WithError(err).Warn("failed to get payload block hash")
-		api.RespondError(w, http.StatusBadRequest, "failed to get payload block hash")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "failed to get payload block hash")
 		return
 	}
 	proposerIndex, err := payload.ProposerIndex()
 	if err != nil {
 		log.WithError(err).Warn("failed to get payload proposer index")
-		api.RespondError(w, http.StatusBadRequest, "failed to get payload proposer index")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, "failed to get payload proposer index")
 		return
 	}
-	slotStartTimestamp := api.genesisInfo.Data.GenesisTime + (uint64(slot) * common.SecondsPerSlot)
-	msIntoSlot := decodeTime.UnixMilli() - int64(slotStartTimestamp*1000) //nolint:gosec
+	slotStartTimestamp := api.slotClock().SlotStartTimestamp(uint64(slot))
+	msIntoSlot := api.slotClock().MsIntoSlot(uint64(slot), decodeTime)
 	log = log.WithFields(logrus.Fields{
 		"slot":                 slot,
 		"slotEpochPos":         (uint64(slot) % common.SlotsPerEpoch) + 1,
@@ -1464,6 +2663,13 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 		"proposerIndex":        proposerIndex,
 	})
 
+	// Only reveal payloads for the current slot (early requests are held until the reveal time below)
+	if msIntoSlot <= -int64(common.SecondsPerSlot*1000) || msIntoSlot >= int64(common.SecondsPerSlot*1000) {
+		log.Warn("getPayload not for the current slot")
+		api.RespondErrorCode(w, http.StatusBadRequest, getPayloadFailureErrorCode(database.GetPayloadFailureNotCurrentSlot), "not the current slot")
+		return
+	}
+
 	// Ensure the proposer index is expected
 	api.proposerDutiesLock.RLock()
 	slotDuty := api.proposerDutiesMap[uint64(slot)]
@@ -1474,7 +2680,7 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 		log = log.WithField("feeRecipient", slotDuty.Entry.Message.FeeRecipient.String())
 		if slotDuty.ValidatorIndex != uint64(proposerIndex) {
 			log.WithField("expectedProposerIndex", slotDuty.ValidatorIndex).Warn("not the expected proposer index")
-			api.RespondError(w, http.StatusBadRequest, "not the expected proposer index")
+			api.RespondErrorCode(w, http.StatusBadRequest, getPayloadFailureErrorCode(database.GetPayloadFailureUnknownProposer), "not the expected proposer index")
 			return
 		}
 	}
@@ -1483,7 +2689,7 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 	proposerPubkey, found := api.datastore.GetKnownValidatorPubkeyByIndex(uint64(proposerIndex))
 	if !found {
 		log.Errorf("could not find proposer pubkey for index %d", proposerIndex)
-		api.RespondError(w, http.StatusBadRequest, "could not match proposer index to pubkey")
+		api.RespondErrorCode(w, http.StatusBadRequest, getPayloadFailureErrorCode(database.GetPayloadFailureUnknownProposer), "could not match proposer index to pubkey")
 		return
 	}
 
@@ -1494,31 +2700,91 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 	pk, err := utils.HexToPubkey(proposerPubkey.String())
 	if err != nil {
 		log.WithError(err).Warn("could not convert pubkey to phase0.BLSPubKey")
-		api.RespondError(w, http.StatusBadRequest, "could not convert pubkey to phase0.BLSPubKey")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidPubkey, "could not convert pubkey to phase0.BLSPubKey")
 		return
 	}
 
 	// Validate proposer signature
+	timeBeforeSignatureCheck := time.Now().UTC()
 	ok, err := api.checkProposerSignature(payload, pk[:])
+	tracing.Record(ctx, "verifySignature", timeBeforeSignatureCheck, time.Now().UTC())
 	if !ok || err != nil {
 		if api.ffLogInvalidSignaturePayload {
 			txt, _ := json.Marshal(payload) //nolint:errchkjson
 			log.Info("payload_invalid_sig: ", string(txt), "pubkey:", proposerPubkey.String())
 		}
 		log.WithError(err).Warn("could not verify payload signature")
-		api.RespondError(w, http.StatusBadRequest, "could not verify payload signature")
+		api.RespondErrorCode(w, http.StatusBadRequest, getPayloadFailureErrorCode(database.GetPayloadFailureInvalidSignature), "could not verify payload signature")
 		return
 	}
 
+	// respondFailure responds with the error, and saves the failed request with its reason to the database. Only
+	// requests signed by the proposer are saved, so that anyone can't fill the database and the data API.
+	failure := database.GetPayloadFailureEntry{
+		Slot:           uint64(slot),
+		ProposerIndex:  uint64(proposerIndex),
+		ProposerPubkey: proposerPubkey.String(),
+		BlockHash:      blockHash.String(),
+		UserAgent:      ua,
+		RequestedAt:    receivedAt,
+		MsIntoSlot:     msIntoSlot,
+	}
+	respondFailure := func(code int, reason, message string) {
+		api.RespondErrorCode(w, code, getPayloadFailureErrorCode(reason), message)
+		entry := failure
+		entry.Reason = reason
+		entry.Error = message
+		api.saveGetPayloadFailure(log, entry)
+	}
+
 	// Log about received payload (with a valid proposer signature)
 	log = log.WithField("timestampAfterSignatureVerify", time.Now().UTC().UnixMilli())
 	log.Info("getPayload request received")
 
+	// Never reveal payloads of bids which were invalidated through the internal API
+	isInvalidated, err := api.redis.IsBidInvalidated(ctx, uint64(slot), blockHash.String())
+	if err != nil {
+		log.WithError(err).Error("could not check if bid was invalidated")
+	} else if isInvalidated {
+		log.Warn("bid was invalidated, not delivering payload")
+		respondFailure(http.StatusBadRequest, database.GetPayloadFailureBidInvalidated, ErrBidsInvalidated.Error())
+		return
+	}
+
+	// Lock the slot to the first requested block hash, and never reveal another payload for it (proposer equivocation)
+	lockedBlockHash, err := api.redis.LockGetPayloadBlockHash(uint64(slot), proposerPubkey.String(), blockHash.String())
+	if err != nil {
+		log.WithError(err).Error("failed to lock getPayload block hash")
+	} else if lockedBlockHash != blockHash.String() {
+		metrics.ProposerEquivocationCount.Add(req.Context(), 1)
+		log.WithField("lockedBlockHash", lockedBlockHash).Error("proposer equivocation: getPayload for a different block hash than the first request of the slot")
+		respondFailure(http.StatusBadRequest, database.GetPayloadFailureEquivocation, "another payload for this slot was already requested")
+		return
+	}
+
+	// Concurrent retries (also on other instances) wait for the first request to publish the block, and then get its
+	// response, instead of publishing the block once more
+	releaseLock := api.lockGetPayloadPublish(ctx, log, blockHash.String())
+	defer releaseLock()
+
+	// Retries for an already delivered payload get the identical response, without publishing the block again
+	deliveredResp, err := api.redis.GetDeliveredGetPayloadResponse(uint64(slot), proposerPubkey.String(), blockHash.String())
+	if err != nil {
+		log.WithError(err).Error("failed to get delivered getPayload response")
+	} else if deliveredResp != nil {
+		log.Info("getPayload retry, returning the delivered response")
+		api.respondGetPayload(w, log, respMimeType, deliveredResp, nil)
+		return
+	}
+
 	var getPayloadResp *builderApi.VersionedSubmitBlindedBlockResponse
 	var msNeededForPublishing uint64
 
 	// Save information about delivered payload
 	defer func() {
+		_, dbSpan := tracing.Start(ctx, "database")
+		defer dbSpan.End()
+
 		bidTrace, err := api.redis.GetBidTrace(uint64(slot), proposerPubkey.String(), blockHash.String())
 		if err != nil {
 			log.WithError(err).Info("failed to get bidTrace for delivered payload from redis")
@@ -1527,12 +2793,18 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 
 		err = api.db.SaveDeliveredPayload(bidTrace, payload, decodeTime, msNeededForPublishing)
 		if err != nil {
+			metrics.DatabaseErrorCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("operation", "saveDeliveredPayload")))
 			log.WithError(err).WithFields(logrus.Fields{
 				"bidTrace": bidTrace,
 				"payload":  payload,
 			}).Error("failed to save delivered payload")
 		}
 
+		err = api.redis.PublishDataStreamEvent(context.Background(), datastore.DataStreamEventPayloadDelivered, common.BidTraceV2{BidTrace: bidTrace.BidTrace, BlockNumber: bidTrace.BlockNumber, NumTx: bidTrace.NumTx})
+		if err != nil {
+			log.WithError(err).Error("failed to publish delivered payload to the data stream")
+		}
+
 		// Increment builder stats
 		err = api.db.IncBlockBuilderStatsAfterGetPayload(bidTrace.BuilderPubkey.String())
 		if err != nil {
@@ -1600,6 +2872,7 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 
 	// Get the response - from Redis, Memcache or DB
 	// note that recent mev-boost versions only send getPayload to relays that provided the bid
+	_, getPayloadSpan := tracing.Start(ctx, "getPayloadResponse")
 	getPayloadResp, err = api.datastore.GetGetPayloadResponse(log, uint64(slot), proposerPubkey.String(), blockHash.String())
 	if err != nil || getPayloadResp == nil {
 		log.WithError(err).Warn("failed getting execution payload (1/2)")
@@ -1614,7 +2887,7 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 				bid, err := api.db.GetBlockSubmissionEntry(uint64(slot), proposerPubkey.String(), blockHash.String())
 				if errors.Is(err, sql.ErrNoRows) {
 					log.Warn("failed getting execution payload (2/2) - payload not found, block was never submitted to this relay")
-					api.RespondError(w, http.StatusBadRequest, "no execution payload for this request - block was never seen by this relay")
+					api.RespondErrorCode(w, http.StatusBadRequest, getPayloadFailureErrorCode(database.GetPayloadFailureUnknownPayload), "no execution payload for this request - block was never seen by this relay")
 				} else if err != nil {
 					log.WithError(err).Error("failed getting execution payload (2/2) - payload not found, and error on checking bids")
 				} else if bid.EligibleAt.Valid {
@@ -1625,10 +2898,12 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 			} else { // some other error
 				log.WithError(err).Error("failed getting execution payload (2/2) - error")
 			}
-			api.RespondError(w, http.StatusBadRequest, "no execution payload for this request")
+			tracing.EndSpan(getPayloadSpan, err)
+			respondFailure(http.StatusBadRequest, database.GetPayloadFailureUnknownPayload, "no execution payload for this request")
 			return
 		}
 	}
+	getPayloadSpan.End()
 
 	// Now we know this relay also has the payload
 	log = log.WithField("timestampAfterLoadResponse", time.Now().UTC().UnixMilli())
@@ -1640,38 +2915,40 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 		if errors.Is(err, datastore.ErrAnotherPayloadAlreadyDeliveredForSlot) {
 			// BAD VALIDATOR, 2x GETPAYLOAD FOR DIFFERENT PAYLOADS
 			log.Warn("validator called getPayload twice for different payload hashes")
-			api.RespondError(w, http.StatusBadRequest, "another payload for this slot was already delivered")
+			respondFailure(http.StatusBadRequest, database.GetPayloadFailureEquivocation, "another payload for this slot was already delivered")
 			return
 		} else if errors.Is(err, datastore.ErrPastSlotAlreadyDelivered) {
 			// BAD VALIDATOR, 2x GETPAYLOAD FOR PAST SLOT
 			log.Warn("validator called getPayload for past slot")
-			api.RespondError(w, http.StatusBadRequest, "payload for this slot was already delivered")
+			respondFailure(http.StatusBadRequest, database.GetPayloadFailureAlreadyDelivered, "payload for this slot was already delivered")
 			return
 		} else if errors.Is(err, redis.TxFailedErr) {
 			// BAD VALIDATOR, 2x GETPAYLOAD + RACE
 			log.Warn("validator called getPayload twice (race)")
-			api.RespondError(w, http.StatusBadRequest, "payload for this slot was already delivered (race)")
+			respondFailure(http.StatusBadRequest, database.GetPayloadFailureEquivocation, "payload for this slot was already delivered (race)")
 			return
 		}
 		log.WithError(err).Error("redis.CheckAndSetLastSlotAndHashDelivered failed")
 	}
 
 	// Handle early/late requests
-	if msIntoSlot < 0 {
-		// Wait until slot start (t=0) if still in the future
-		_msSinceSlotStart := time.Now().UTC().UnixMilli() - int64(slotStartTimestamp*1000) //nolint:gosec
-		if _msSinceSlotStart < 0 {
-			delayMillis := _msSinceSlotStart * -1
+	if msIntoSlot < int64(getPayloadRevealMs) {
+		// Wait until the reveal time (slot start t=0 by default) if still in the future
+		_msSinceRevealTime := api.slotClock().MsIntoSlot(uint64(slot), time.Now()) - int64(getPayloadRevealMs)
+		if _msSinceRevealTime < 0 {
+			delayMillis := _msSinceRevealTime * -1
 			log = log.WithField("delayMillis", delayMillis)
-			log.Info("waiting until slot start t=0")
+			log.Infof("waiting until reveal time t=%d", getPayloadRevealMs)
 			time.Sleep(time.Duration(delayMillis) * time.Millisecond)
 		}
 	} else if getPayloadRequestCutoffMs > 0 && msIntoSlot > int64(getPayloadRequestCutoffMs) {
 		// Reject requests after cutoff time
 		log.Warn("getPayload sent too late")
-		api.RespondError(w, http.StatusBadRequest, fmt.Sprintf("sent too late - %d ms into slot", msIntoSlot))
+		respondFailure(http.StatusBadRequest, database.GetPayloadFailureTooLate, fmt.Sprintf("sent too late - %d ms into slot", msIntoSlot))
 
+		api.backgroundDBWritesWG.Add(1)
 		go func() {
+			defer api.backgroundDBWritesWG.Done()
 			err := api.db.InsertTooLateGetPayload(uint64(slot), proposerPubkey.String(), blockHash.String(), slotStartTimestamp, uint64(receivedAt.UnixMilli()), uint64(decodeTime.UnixMilli()), uint64(msIntoSlot)) //nolint:gosec
 			if err != nil {
 				log.WithError(err).Error("failed to insert payload too late into db")
@@ -1684,7 +2961,7 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 	err = EqBlindedBlockContentsToBlockContents(payload, getPayloadResp)
 	if err != nil {
 		log.WithError(err).Warn("ExecutionPayloadHeader not matching known ExecutionPayload")
-		api.RespondError(w, http.StatusBadRequest, "invalid execution payload header")
+		respondFailure(http.StatusBadRequest, database.GetPayloadFailureInvalidHeader, "invalid execution payload header")
 		return
 	}
 
@@ -1697,10 +2974,20 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 		api.RespondError(w, http.StatusInternalServerError, "failed to convert signed blinded beacon block to beacon block")
 		return
 	}
+	_, publishSpan := tracing.Start(ctx, "publishBlock")
 	code, err := api.beaconClient.PublishBlock(signedBeaconBlock) // errors are logged inside
-	if err != nil || (code != http.StatusOK && code != http.StatusAccepted) {
+	publishSpan.SetAttributes(attribute.Int("statusCode", code))
+	tracing.EndSpan(publishSpan, err)
+	if errors.Is(err, beaconclient.ErrBeaconBlock202) {
+		// the block was broadcast, so the payload is public already and has to be delivered to the proposer
+		log.WithError(err).Error("block was broadcast, but failed validation on the beacon nodes")
+	} else if err != nil || (code != http.StatusOK && code != http.StatusAccepted) {
 		log.WithError(err).WithField("code", code).Error("failed to publish block")
-		api.RespondError(w, http.StatusBadRequest, "failed to publish block")
+		if err != nil {
+			respondFailure(http.StatusBadRequest, database.GetPayloadFailurePublishFailed, fmt.Sprintf("failed to publish block: %s", err.Error()))
+		} else {
+			respondFailure(http.StatusBadRequest, database.GetPayloadFailurePublishFailed, "failed to publish block")
+		}
 		return
 	}
 
@@ -1713,8 +3000,18 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 	// give the beacon network some time to propagate the block
 	time.Sleep(time.Duration(getPayloadResponseDelayMs) * time.Millisecond)
 
-	// respond to the HTTP request
-	api.RespondOK(w, getPayloadResp)
+	// respond to the HTTP request, and keep the response for retries
+	respBytes, err := json.Marshal(getPayloadResp)
+	if err != nil {
+		log.WithError(err).Error("failed to encode getPayload response")
+		api.RespondError(w, http.StatusInternalServerError, "failed to encode getPayload response")
+		return
+	}
+	err = api.redis.SaveDeliveredGetPayloadResponse(uint64(slot), proposerPubkey.String(), blockHash.String(), respBytes)
+	if err != nil {
+		log.WithError(err).Error("failed to save delivered getPayload response")
+	}
+	api.respondGetPayload(w, log, respMimeType, respBytes, getPayloadResp)
 	blockNumber, err := payload.ExecutionBlockNumber()
 	if err != nil {
 		log.WithError(err).Info("failed to get block number")
@@ -1749,9 +3046,74 @@ func (api *RelayAPI) handleGetPayload(w http.ResponseWriter, req *http.Request)
 	log.Info("execution payload delivered")
 }
 
-// --------------------
-//
-//	BLOCK BUILDER APIS
+// lockGetPayloadPublish waits until no other getPayload request for the block hash is being processed, and returns the
+// function to release the lock. If the lock can't be taken because of a Redis error or a closed request, the request
+// proceeds without it.
+func (api *RelayAPI) lockGetPayloadPublish(ctx context.Context, log *logrus.Entry, blockHash string) (release func()) {
+	ownerBytes := make([]byte, 16)
+	if _, err := rand.Read(ownerBytes); err != nil {
+		log.WithError(err).Error("failed to create getPayload lock owner")
+		return func() {}
+	}
+	name := getPayloadLockPrefix + blockHash
+	owner := hex.EncodeToString(ownerBytes)
+
+	ticker := time.NewTicker(getPayloadLockPollInterval)
+	defer ticker.Stop()
+	for {
+		acquired, err := api.redis.AcquireLock(ctx, name, owner, getPayloadLockTTL)
+		if err != nil {
+			log.WithError(err).Error("failed to lock getPayload publishing")
+			return func() {}
+		} else if acquired {
+			return func() {
+				if err := api.redis.ReleaseLock(context.Background(), name, owner); err != nil {
+					log.WithError(err).Error("failed to release getPayload publishing lock")
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Warn("request closed while waiting for another getPayload request of the block")
+			return func() {}
+		case <-ticker.C:
+		}
+	}
+}
+
+// respondGetPayload writes the JSON encoded getPayload response, or its SSZ encoding if requested (decoding resp from
+// the JSON response if not given)
+func (api *RelayAPI) respondGetPayload(w http.ResponseWriter, log *logrus.Entry, mimeType string, respJSON []byte, resp *builderApi.VersionedSubmitBlindedBlockResponse) {
+	if mimeType == ApplicationOctetStream {
+		if resp == nil {
+			resp = new(builderApi.VersionedSubmitBlindedBlockResponse)
+			if err := json.Unmarshal(respJSON, resp); err != nil {
+				log.WithError(err).Error("failed to decode getPayload response")
+				api.RespondError(w, http.StatusInternalServerError, "failed to decode getPayload response")
+				return
+			}
+		}
+		sszBytes, err := common.GetPayloadResponseToSSZ(resp)
+		if err != nil {
+			log.WithError(err).Error("failed to encode getPayload response as SSZ")
+			api.RespondError(w, http.StatusInternalServerError, "failed to encode getPayload response")
+			return
+		}
+		api.RespondSSZ(w, resp.Version, sszBytes)
+		return
+	}
+
+	w.Header().Set("Content-Type", ApplicationJSON)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(respJSON); err != nil {
+		log.WithError(err).Warn("failed to write getPayload response")
+	}
+}
+
+// --------------------
+//
+//	BLOCK BUILDER APIS
 //
 // --------------------
 func (api *RelayAPI) handleBuilderGetValidators(w http.ResponseWriter, req *http.Request) {
@@ -1764,20 +3126,72 @@ func (api *RelayAPI) handleBuilderGetValidators(w http.ResponseWriter, req *http
 	}
 }
 
+// proposerPreferencesForSlot returns the preferences of the proposer of the slot, or nil if it has none or the proposer
+// pubkey does not match the proposer duty
+func (api *RelayAPI) proposerPreferencesForSlot(slot uint64, proposerPubkeyHex string) *common.ProposerPreferences {
+	api.proposerDutiesLock.RLock()
+	slotDuty := api.proposerDutiesMap[slot]
+	api.proposerDutiesLock.RUnlock()
+	if slotDuty == nil || slotDuty.Preferences == nil || !strings.EqualFold(slotDuty.Preferences.Pubkey.String(), proposerPubkeyHex) {
+		return nil
+	}
+	return slotDuty.Preferences
+}
+
+// checkSubmissionProposerPreferences rejects submissions of builders the proposer blocked, and submissions which use
+// more gas or don't order the payment to the proposer as the proposer requires
+func (api *RelayAPI) checkSubmissionProposerPreferences(w http.ResponseWriter, log *logrus.Entry, submission *common.BlockSubmissionInfo) bool {
+	bidTrace := submission.BidTrace
+	preferences := api.proposerPreferencesForSlot(bidTrace.Slot, bidTrace.ProposerPubkey.String())
+	if preferences == nil {
+		return true
+	}
+	if preferences.IsBuilderBlocked(bidTrace.BuilderPubkey) {
+		log.Info("builder is blocked by the proposer")
+		api.RespondErrorCode(w, http.StatusForbidden, ErrorCodeBuilderBlockedByProposer, ErrBuilderBlockedByProposer.Error())
+		return false
+	}
+	if preferences.MaxGasUsed > 0 && submission.GasUsed > preferences.MaxGasUsed {
+		msg := fmt.Sprintf("gas used %d is above the maximum of the proposer %d", submission.GasUsed, preferences.MaxGasUsed)
+		log.Info(msg)
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeProposerPreferencesViolated, msg)
+		return false
+	}
+	if preferences.RequirePaymentLast && !api.isProposerPaymentLast(submission) {
+		log.Info("block does not end with the proposer payment")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeProposerPreferencesViolated, ErrProposerPaymentNotLast.Error())
+		return false
+	}
+	return true
+}
+
+// isProposerPaymentLast returns whether the block ends with a transaction to the proposer fee recipient
+func (api *RelayAPI) isProposerPaymentLast(submission *common.BlockSubmissionInfo) bool {
+	index := len(submission.Transactions) - 1
+	if index < 0 {
+		return false
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(submission.Transactions[index]); err != nil {
+		return false
+	}
+	return tx.To() != nil && *tx.To() == ethcommon.Address(submission.BidTrace.ProposerFeeRecipient)
+}
+
 func (api *RelayAPI) checkSubmissionFeeRecipient(w http.ResponseWriter, log *logrus.Entry, bidTrace *builderApiV1.BidTrace) (uint64, bool) {
 	api.proposerDutiesLock.RLock()
 	slotDuty := api.proposerDutiesMap[bidTrace.Slot]
 	api.proposerDutiesLock.RUnlock()
 	if slotDuty == nil {
 		log.Warn("could not find slot duty")
-		api.RespondError(w, http.StatusBadRequest, "could not find slot duty")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeUnknownProposerDuty, "could not find slot duty")
 		return 0, false
 	} else if !strings.EqualFold(slotDuty.Entry.Message.FeeRecipient.String(), bidTrace.ProposerFeeRecipient.String()) {
 		log.WithFields(logrus.Fields{
 			"expectedFeeRecipient": slotDuty.Entry.Message.FeeRecipient.String(),
 			"actualFeeRecipient":   bidTrace.ProposerFeeRecipient.String(),
 		}).Info("fee recipient does not match")
-		api.RespondError(w, http.StatusBadRequest, "fee recipient does not match")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeFeeRecipientMismatch, "fee recipient does not match")
 		return 0, false
 	}
 	return slotDuty.Entry.Message.GasLimit, true
@@ -1793,14 +3207,14 @@ func (api *RelayAPI) checkSubmissionPayloadAttrs(w http.ResponseWriter, log *log
 			"payloadSlot":     submission.BidTrace.Slot,
 			"attrsSlot":       attrs.slot,
 		}).Warn("payload attributes not (yet) known")
-		api.RespondError(w, http.StatusBadRequest, "payload attributes not (yet) known")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodePayloadAttributesUnknown, "payload attributes not (yet) known")
 		return attrs, false
 	}
 
 	if submission.PrevRandao.String() != attrs.payloadAttributes.PrevRandao {
 		msg := fmt.Sprintf("incorrect prev_randao - got: %s, expected: %s", submission.PrevRandao.String(), attrs.payloadAttributes.PrevRandao)
 		log.Info(msg)
-		api.RespondError(w, http.StatusBadRequest, msg)
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidPrevRandao, msg)
 		return attrs, false
 	}
 
@@ -1808,13 +3222,13 @@ func (api *RelayAPI) checkSubmissionPayloadAttrs(w http.ResponseWriter, log *log
 		withdrawalsRoot, err := ComputeWithdrawalsRoot(submission.Withdrawals)
 		if err != nil {
 			log.WithError(err).Warn("could not compute withdrawals root from payload")
-			api.RespondError(w, http.StatusBadRequest, "could not compute withdrawals root")
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidWithdrawals, "could not compute withdrawals root")
 			return attrs, false
 		}
 		if withdrawalsRoot != attrs.withdrawalsRoot {
 			msg := fmt.Sprintf("incorrect withdrawals root - got: %s, expected: %s", withdrawalsRoot.String(), attrs.withdrawalsRoot.String())
 			log.Info(msg)
-			api.RespondError(w, http.StatusBadRequest, msg)
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidWithdrawals, msg)
 			return attrs, false
 		}
 	}
@@ -1823,39 +3237,46 @@ func (api *RelayAPI) checkSubmissionPayloadAttrs(w http.ResponseWriter, log *log
 }
 
 func (api *RelayAPI) checkSubmissionSlotDetails(w http.ResponseWriter, log *logrus.Entry, headSlot uint64, payload *common.VersionedSubmitBlockRequest, submission *common.BlockSubmissionInfo) bool {
-	if api.isElectra(submission.BidTrace.Slot) && payload.Electra == nil {
-		log.Info("rejecting submission - non electra payload for electra fork")
-		api.RespondError(w, http.StatusBadRequest, "not electra payload")
-		return false
-	}
-	if api.isDeneb(submission.BidTrace.Slot) && payload.Deneb == nil {
-		log.Info("rejecting submission - non deneb payload for deneb fork")
-		api.RespondError(w, http.StatusBadRequest, "not deneb payload")
-		return false
-	}
-	if api.isCapella(submission.BidTrace.Slot) && payload.Capella == nil {
-		log.Info("rejecting submission - non capella payload for capella fork")
-		api.RespondError(w, http.StatusBadRequest, "not capella payload")
+	// block submissions are only supported from capella onwards
+	forkVersion := api.dataVersionAtSlot(submission.BidTrace.Slot)
+	if forkVersion != spec.DataVersionBellatrix && payload.Version != forkVersion {
+		log.Infof("rejecting submission - %s payload for %s fork", payload.Version, forkVersion)
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeWrongFork, fmt.Sprintf("not %s payload", forkVersion))
 		return false
 	}
 
 	if submission.BidTrace.Slot <= headSlot {
 		log.Info("submitNewBlock failed: submission for past slot")
-		api.RespondError(w, http.StatusBadRequest, "submission for past slot")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodePastSlot, "submission for past slot")
 		return false
 	}
 
 	// Timestamp check
-	expectedTimestamp := api.genesisInfo.Data.GenesisTime + (submission.BidTrace.Slot * common.SecondsPerSlot)
+	expectedTimestamp := api.slotClock().SlotStartTimestamp(submission.BidTrace.Slot)
 	if submission.Timestamp != expectedTimestamp {
 		log.Warnf("incorrect timestamp. got %d, expected %d", submission.Timestamp, expectedTimestamp)
-		api.RespondError(w, http.StatusBadRequest, fmt.Sprintf("incorrect timestamp. got %d, expected %d", submission.Timestamp, expectedTimestamp))
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidTimestamp, fmt.Sprintf("incorrect timestamp. got %d, expected %d", submission.Timestamp, expectedTimestamp))
 		return false
 	}
 
 	return true
 }
 
+// checkSubmissionSlotCutoff rejects submissions which arrive too late into their slot to ever be delivered to the proposer
+func (api *RelayAPI) checkSubmissionSlotCutoff(w http.ResponseWriter, log *logrus.Entry, receivedAt time.Time, submission *common.BlockSubmissionInfo) bool {
+	if submitBlockCutoffMs <= 0 {
+		return true
+	}
+
+	msIntoSlot := api.slotClock().MsIntoSlot(submission.BidTrace.Slot, receivedAt)
+	if msIntoSlot > int64(submitBlockCutoffMs) {
+		log.WithField("msIntoSlot", msIntoSlot).Info("submitNewBlock failed: submission too late into the slot")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeSubmissionTooLate, fmt.Sprintf("submission too late - %d ms into slot", msIntoSlot))
+		return false
+	}
+	return true
+}
+
 func (api *RelayAPI) checkBuilderEntry(w http.ResponseWriter, log *logrus.Entry, builderPubkey phase0.BLSPubKey) (*blockBuilderCacheEntry, bool) {
 	builderEntry, ok := api.blockBuildersCache[builderPubkey.String()]
 	if !ok {
@@ -1872,13 +3293,17 @@ func (api *RelayAPI) checkBuilderEntry(w http.ResponseWriter, log *logrus.Entry,
 
 	if builderEntry.status.IsBlacklisted {
 		log.Info("builder is blacklisted")
+		if api.ffRejectBlacklistedBuilders.Load() {
+			api.RespondErrorCode(w, http.StatusForbidden, ErrorCodeBuilderBlacklisted, ErrBuilderBlacklisted.Error())
+			return builderEntry, false
+		}
 		time.Sleep(200 * time.Millisecond)
 		w.WriteHeader(http.StatusOK)
 		return builderEntry, false
 	}
 
 	// In case only high-prio requests are accepted, fail others
-	if api.ffDisableLowPrioBuilders && !builderEntry.status.IsHighPrio {
+	if api.ffDisableLowPrioBuilders.Load() && !builderEntry.status.IsHighPrio {
 		log.Info("rejecting low-prio builder (ff-disable-low-prio-builders)")
 		time.Sleep(200 * time.Millisecond)
 		w.WriteHeader(http.StatusOK)
@@ -1895,6 +3320,7 @@ type bidFloorOpts struct {
 	cancellationsEnabled bool
 	simResultC           chan *blockSimResult
 	submission           *common.BlockSubmissionInfo
+	bidValue             *uint256.Int // value served to proposers, which is the adjusted value if the bid was adjusted
 }
 
 func (api *RelayAPI) checkFloorBidValue(opts bidFloorOpts) (*big.Int, bool) {
@@ -1904,7 +3330,7 @@ func (api *RelayAPI) checkFloorBidValue(opts bidFloorOpts) (*big.Int, bool) {
 		opts.log.WithError(err).Error("failed to get delivered payload slot from redis")
 	} else if opts.submission.BidTrace.Slot <= slotLastPayloadDelivered {
 		opts.log.Info("rejecting submission because payload for this slot was already delivered")
-		api.RespondError(opts.w, http.StatusBadRequest, "payload for this slot was already delivered")
+		api.RespondErrorCode(opts.w, http.StatusBadRequest, ErrorCodePayloadAlreadyDelivered, "payload for this slot was already delivered")
 		return nil, false
 	}
 
@@ -1919,10 +3345,10 @@ func (api *RelayAPI) checkFloorBidValue(opts bidFloorOpts) (*big.Int, bool) {
 	// --------------------------------------------
 	// Skip submission if below the floor bid value
 	// --------------------------------------------
-	isBidBelowFloor := floorBidValue != nil && opts.submission.BidTrace.Value.ToBig().Cmp(floorBidValue) == -1
-	isBidAtOrBelowFloor := floorBidValue != nil && opts.submission.BidTrace.Value.ToBig().Cmp(floorBidValue) < 1
+	isBidBelowFloor := floorBidValue != nil && opts.bidValue.ToBig().Cmp(floorBidValue) == -1
+	isBidAtOrBelowFloor := floorBidValue != nil && opts.bidValue.ToBig().Cmp(floorBidValue) < 1
 	if opts.cancellationsEnabled && isBidBelowFloor { // with cancellations: if below floor -> delete previous bid
-		opts.simResultC <- &blockSimResult{false, nil, false, nil, nil}
+		opts.simResultC <- &blockSimResult{false, nil, false, nil, nil, 0, nil}
 		opts.log.Info("submission below floor bid value, with cancellation")
 		err := api.redis.DelBuilderBid(context.Background(), opts.tx, opts.submission.BidTrace.Slot, opts.submission.BidTrace.ParentHash.String(), opts.submission.BidTrace.ProposerPubkey.String(), opts.submission.BidTrace.BuilderPubkey.String())
 		if err != nil {
@@ -1933,7 +3359,7 @@ func (api *RelayAPI) checkFloorBidValue(opts bidFloorOpts) (*big.Int, bool) {
 		api.Respond(opts.w, http.StatusAccepted, "accepted bid below floor, skipped validation")
 		return nil, false
 	} else if !opts.cancellationsEnabled && isBidAtOrBelowFloor { // without cancellations: if at or below floor -> ignore
-		opts.simResultC <- &blockSimResult{false, nil, false, nil, nil}
+		opts.simResultC <- &blockSimResult{false, nil, false, nil, nil, 0, nil}
 		opts.log.Info("submission at or below floor bid value, without cancellation")
 		api.RespondMsg(opts.w, http.StatusAccepted, "accepted bid below floor, skipped validation")
 		return nil, false
@@ -1942,6 +3368,7 @@ func (api *RelayAPI) checkFloorBidValue(opts bidFloorOpts) (*big.Int, bool) {
 }
 
 type redisUpdateBidOpts struct {
+	ctx                  context.Context
 	w                    http.ResponseWriter
 	tx                   redis.Pipeliner
 	log                  *logrus.Entry
@@ -1949,11 +3376,12 @@ type redisUpdateBidOpts struct {
 	receivedAt           time.Time
 	floorBidValue        *big.Int
 	payload              *common.VersionedSubmitBlockRequest
+	adjustedValue        *uint256.Int // value served to proposers instead of the submitted value, if not nil
 }
 
 func (api *RelayAPI) updateRedisBid(opts redisUpdateBidOpts) (*datastore.SaveBidAndUpdateTopBidResponse, *builderApi.VersionedSubmitBlindedBlockResponse, bool) {
 	// Prepare the response data
-	getHeaderResponse, err := common.BuildGetHeaderResponse(opts.payload, api.blsSk, api.publicKey, api.opts.EthNetDetails.DomainBuilder)
+	getHeaderResponse, err := common.BuildGetHeaderResponseWithValue(opts.ctx, opts.payload, opts.adjustedValue, api.signer, api.opts.EthNetDetails.DomainBuilder)
 	if err != nil {
 		opts.log.WithError(err).Error("could not sign builder bid")
 		api.RespondError(opts.w, http.StatusBadRequest, err.Error())
@@ -1981,6 +3409,7 @@ func (api *RelayAPI) updateRedisBid(opts redisUpdateBidOpts) (*datastore.SaveBid
 		NumBlobs:      uint64(len(submission.Blobs)),
 		BlobGasUsed:   submission.BlobGasUsed,
 		ExcessBlobGas: submission.ExcessBlobGas,
+		AdjustedValue: opts.adjustedValue,
 	}
 
 	//
@@ -1988,10 +3417,14 @@ func (api *RelayAPI) updateRedisBid(opts redisUpdateBidOpts) (*datastore.SaveBid
 	//
 	updateBidResult, err := api.redis.SaveBidAndUpdateTopBid(context.Background(), opts.tx, &bidTrace, opts.payload, getPayloadResponse, getHeaderResponse, opts.receivedAt, opts.cancellationsEnabled, opts.floorBidValue)
 	if err != nil {
+		metrics.RedisErrorCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("operation", "saveBidAndUpdateTopBid")))
 		opts.log.WithError(err).Error("could not save bid and update top bids")
 		api.RespondError(opts.w, http.StatusInternalServerError, "failed saving and updating bid")
 		return nil, nil, false
 	}
+	if updateBidResult.WasTopBidUpdated {
+		metrics.TopBidUpdateCount.Add(context.Background(), 1)
+	}
 	return &updateBidResult, getPayloadResponse, true
 }
 
@@ -1999,10 +3432,13 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	var pf common.Profile
 	var prevTime, nextTime time.Time
 
-	headSlot := api.headSlot.Load()
+	headSlot := api.latestHeadSlot()
 	receivedAt := time.Now().UTC()
 	prevTime = receivedAt
 
+	ctx, span := tracing.StartRequest(req, "submitNewBlock")
+	defer span.End()
+
 	args := req.URL.Query()
 	isCancellationEnabled := args.Get("cancellations") == "1"
 
@@ -2029,7 +3465,7 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	// If cancellations are disabled but builder requested it, return error
 	if isCancellationEnabled && !api.ffEnableCancellations {
 		log.Info("builder submitted with cancellations enabled, but feature flag is disabled")
-		api.RespondError(w, http.StatusBadRequest, "cancellations are disabled")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeCancellationsDisabled, "cancellations are disabled")
 		return
 	}
 
@@ -2042,16 +3478,20 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 		r, err = gzip.NewReader(req.Body)
 		if err != nil {
 			log.WithError(err).Warn("could not create gzip reader")
-			api.RespondError(w, http.StatusBadRequest, err.Error())
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 			return
 		}
 	}
 
-	limitReader := io.LimitReader(r, int64(apiMaxPayloadBytes))
+	limitReader := io.LimitReader(r, int64(api.opts.MaxPayloadBytes))
 	requestPayloadBytes, err := io.ReadAll(limitReader)
-	if err != nil {
+	if isMaxBytesError(err) {
+		log.WithError(err).Warn("payload too large")
+		api.RespondErrorCode(w, http.StatusRequestEntityTooLarge, ErrorCodeRequestTooLarge, ErrRequestBodyTooLarge.Error())
+		return
+	} else if err != nil {
 		log.WithError(err).Warn("could not read payload")
-		api.RespondError(w, http.StatusBadRequest, err.Error())
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 		return
 	}
 
@@ -2072,7 +3512,7 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 			// SSZ decoding failed. try JSON as fallback (some builders used octet-stream for json before)
 			if err2 := json.Unmarshal(requestPayloadBytes, payload); err2 != nil {
 				log.WithError(fmt.Errorf("%w / %w", err, err2)).Warn("could not decode payload - SSZ or JSON")
-				api.RespondError(w, http.StatusBadRequest, err.Error())
+				api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 				return
 			}
 			log = log.WithField("reqContentType", "json")
@@ -2085,13 +3525,14 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 		pf.ContentType = "json"
 		if err := json.Unmarshal(requestPayloadBytes, payload); err != nil {
 			log.WithError(err).Warn("could not decode payload - JSON")
-			api.RespondError(w, http.StatusBadRequest, err.Error())
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 			return
 		}
 	}
 
 	nextTime = time.Now().UTC()
 	pf.Decode = uint64(nextTime.Sub(prevTime).Microseconds()) //nolint:gosec
+	tracing.Record(ctx, "decode", receivedAt, nextTime)
 	prevTime = nextTime
 
 	isLargeRequest := len(requestPayloadBytes) > fastTrackPayloadSizeLimit
@@ -2099,7 +3540,7 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	submission, err := common.GetBlockSubmissionInfo(payload)
 	if err != nil {
 		log.WithError(err).Warn("missing fields in submit block request")
-		api.RespondError(w, http.StatusBadRequest, err.Error())
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 		return
 	}
 	log = log.WithFields(logrus.Fields{
@@ -2114,20 +3555,25 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 		"payloadBytes":           len(requestPayloadBytes),
 		"isLargeRequest":         isLargeRequest,
 	})
+	span.SetAttributes(
+		attribute.Int64("slot", int64(submission.BidTrace.Slot)), //nolint:gosec
+		attribute.String("builderPubkey", submission.BidTrace.BuilderPubkey.String()),
+		attribute.String("blockHash", submission.BidTrace.BlockHash.String()),
+	)
 	if payload.Version >= spec.DataVersionDeneb {
 		blobs, err := payload.Blobs()
 		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, err.Error())
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 			return
 		}
 		blobGasUsed, err := payload.BlobGasUsed()
 		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, err.Error())
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 			return
 		}
 		excessBlobGas, err := payload.ExcessBlobGas()
 		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, err.Error())
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidRequestBody, err.Error())
 			return
 		}
 		log = log.WithFields(logrus.Fields{
@@ -2142,6 +3588,11 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	ok = api.checkSubmissionSlotCutoff(w, log, receivedAt, submission)
+	if !ok {
+		return
+	}
+
 	builderPubkey := submission.BidTrace.BuilderPubkey
 	builderEntry, ok := api.checkBuilderEntry(w, log, builderPubkey)
 	if !ok {
@@ -2150,11 +3601,24 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 
 	log = log.WithField("builderIsHighPrio", builderEntry.status.IsHighPrio)
 
+	isInvalidated, err := api.redis.IsBidInvalidated(ctx, submission.BidTrace.Slot, builderPubkey.String())
+	if err != nil {
+		log.WithError(err).Error("could not check if bids were invalidated")
+	} else if isInvalidated {
+		log.Info("bids of builder for this slot were invalidated")
+		api.RespondErrorCode(w, http.StatusForbidden, ErrorCodeBidsInvalidated, ErrBidsInvalidated.Error())
+		return
+	}
+
 	gasLimit, ok := api.checkSubmissionFeeRecipient(w, log, submission.BidTrace)
 	if !ok {
 		return
 	}
 
+	if ok := api.checkSubmissionProposerPreferences(w, log, submission); !ok {
+		return
+	}
+
 	// Don't accept blocks with 0 value
 	if submission.BidTrace.Value.ToBig().Cmp(ZeroU256.BigInt()) == 0 || len(submission.Transactions) == 0 {
 		log.Info("submitNewBlock failed: block with 0 value or no txs")
@@ -2166,7 +3630,7 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	err = SanityCheckBuilderBlockSubmission(payload)
 	if err != nil {
 		log.WithError(err).Info("block submission sanity checks failed")
-		api.RespondError(w, http.StatusBadRequest, err.Error())
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeSanityCheckFailed, err.Error())
 		return
 	}
 
@@ -2176,20 +3640,56 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	}
 
 	// Verify the signature
-	log = log.WithField("timestampBeforeSignatureCheck", time.Now().UTC().UnixMilli())
+	timeBeforeSignatureCheck := time.Now().UTC()
+	log = log.WithField("timestampBeforeSignatureCheck", timeBeforeSignatureCheck.UnixMilli())
 	signature := submission.Signature
 	ok, err = ssz.VerifySignature(submission.BidTrace, api.opts.EthNetDetails.DomainBuilder, builderPubkey[:], signature[:])
+	pf.SignatureCheck = uint64(time.Since(timeBeforeSignatureCheck).Microseconds()) //nolint:gosec
+	tracing.Record(ctx, "verifySignature", timeBeforeSignatureCheck, time.Now().UTC())
 	log = log.WithField("timestampAfterSignatureCheck", time.Now().UTC().UnixMilli())
 	if err != nil {
 		log.WithError(err).Warn("failed verifying builder signature")
-		api.RespondError(w, http.StatusBadRequest, "failed verifying builder signature")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidSignature, "failed verifying builder signature")
 		return
 	} else if !ok {
 		log.Warn("invalid builder signature")
-		api.RespondError(w, http.StatusBadRequest, "invalid signature")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidSignature, "invalid signature")
 		return
 	}
 
+	// Return the previous result if the builder already sent this block, instead of simulating it again. Not for
+	// cancellable submissions, where re-sending a block makes it the builder's latest bid again.
+	isTransientResult := false
+	if !isCancellationEnabled {
+		dedupKey := getSubmissionDedupKey(submission.BidTrace.Slot, submission.BidTrace.BlockHash.String(), builderPubkey.String())
+		dedupResult, isDuplicate := api.submissionDedup.getOrAdd(submission.BidTrace.Slot, dedupKey)
+		if isDuplicate {
+			api.respondDuplicateSubmission(w, log, dedupResult)
+			return
+		}
+		rec := newResponseRecorder(w)
+		w = rec
+		defer func() {
+			// the result of a closed request doesn't say anything about the block
+			api.submissionDedup.complete(dedupKey, dedupResult, rec, isTransientResult || req.Context().Err() != nil)
+		}()
+	}
+
+	// Optionally rewrite the value of the bid served to proposers. The adjusted value is used to check the floor, to rank
+	// the bid and as the proposer payment checked by the simulation.
+	var adjustedValue *uint256.Int // will be set if the bid value is adjusted
+	bidValue := submission.BidTrace.Value
+	if api.bidAdjuster != nil {
+		adjustedValue, err = api.bidAdjuster.AdjustBidValue(submission)
+		if err != nil {
+			log.WithError(err).Warn("bid adjustment failed")
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeBidAdjustmentFailed, err.Error())
+			return
+		}
+		bidValue = adjustedValue
+		log = log.WithField("adjustedValue", adjustedValue.Dec())
+	}
+
 	log = log.WithField("timestampBeforeCheckingFloorBid", time.Now().UTC().UnixMilli())
 
 	// Create the redis pipeline tx
@@ -2206,6 +3706,7 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 		cancellationsEnabled: isCancellationEnabled,
 		simResultC:           simResultC,
 		submission:           submission,
+		bidValue:             bidValue,
 	}
 	floorBidValue, ok := api.checkFloorBidValue(bfOpts)
 	if !ok {
@@ -2217,27 +3718,33 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 
 	// Deferred saving of the builder submission to database (whenever this function ends)
 	defer func() {
-		savePayloadToDatabase := !api.ffDisablePayloadDBStorage
+		savePayloadToDatabase := !api.ffDisablePayloadDBStorage.Load()
 		var simResult *blockSimResult
 		select {
 		case simResult = <-simResultC:
 		case <-time.After(10 * time.Second):
 			log.Warn("timed out waiting for simulation result")
-			simResult = &blockSimResult{false, nil, false, nil, nil}
+			simResult = &blockSimResult{false, nil, false, nil, nil, 0, nil}
 		}
-
-		submissionEntry, err := api.db.SaveBuilderBlockSubmission(payload, simResult.requestErr, simResult.validationErr, receivedAt, eligibleAt, simResult.wasSimulated, savePayloadToDatabase, pf, simResult.optimisticSubmission, simResult.blockValue)
-		if err != nil {
-			log.WithError(err).WithFields(logrus.Fields{
-				"payload":   payload,
-				"simResult": simResult,
-			}).Error("saving builder block submission to database failed")
-			return
+		pf.SimQueueWait = uint64(simResult.queueWait.Microseconds()) //nolint:gosec
+
+		// Queue for saving by the block submission processors, to not wait on the database here
+		task := &blockSubmissionDBTask{
+			payload:     payload,
+			simResult:   simResult,
+			receivedAt:  receivedAt,
+			eligibleAt:  eligibleAt,
+			savePayload: savePayloadToDatabase,
+			profile:     pf,
+			log:         log,
+
+			adjustedValue: adjustedValue,
 		}
-
-		err = api.db.UpsertBlockBuilderEntryAfterSubmission(submissionEntry, simResult.validationErr != nil)
-		if err != nil {
-			log.WithError(err).Error("failed to upsert block-builder-entry")
+		select {
+		case api.blockSubmissionC <- task:
+		default:
+			metrics.BlockSubmissionDBDropCount.Add(context.Background(), 1, otelapi.WithAttributes(attribute.String("reason", "queue_full")))
+			log.Error("block submission channel full")
 		}
 	}()
 
@@ -2253,7 +3760,7 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	if err != nil {
 		log.WithError(err).Error("failed to get top bid value from redis")
 	} else {
-		bidIsTopBid = submission.BidTrace.Value.ToBig().Cmp(topBidValue) == 1
+		bidIsTopBid = bidValue.ToBig().Cmp(topBidValue) == 1
 		log = log.WithFields(logrus.Fields{
 			"topBidValue":    topBidValue.String(),
 			"newBidIsTopBid": bidIsTopBid,
@@ -2285,34 +3792,51 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 			VersionedSubmitBlockRequest: payload,
 			RegisteredGasLimit:          gasLimit,
 			ParentBeaconBlockRoot:       attrs.parentBeaconRoot,
+			ProposerPayment:             adjustedValue,
 		},
 	}
 	// With sufficient collateral, process the block optimistically.
 	optimistic := builderEntry.status.IsOptimistic &&
-		builderEntry.collateral.Cmp(submission.BidTrace.Value.ToBig()) >= 0 &&
+		builderEntry.collateral.Cmp(bidValue.ToBig()) >= 0 &&
 		submission.BidTrace.Slot == api.optimisticSlot.Load()
 	pf.Optimistic = optimistic
+	if optimistic && api.filterList != nil {
+		// Optimistic blocks are served before the simulation finishes, so the filter list is checked upfront
+		if err := api.checkFilterList(payload); err != nil {
+			log.WithError(err).Warn("optimistic block submission failed: filter list")
+			validationErr, filterErr := splitFilterErr(err)
+			simResultC <- &blockSimResult{false, nil, false, nil, validationErr, 0, filterErr}
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeFilteredAddress, err.Error())
+			return
+		}
+	}
 	if optimistic {
 		go api.processOptimisticBlock(opts, simResultC)
 	} else {
-		// Simulate block (synchronously).
-		blockValue, requestErr, validationErr := api.simulateBlock(context.Background(), opts) // success/error logging happens inside
-		simResultC <- &blockSimResult{requestErr == nil, blockValue, false, requestErr, validationErr}
+		// Simulate block (synchronously). The simulation is canceled if the builder closes the request.
+		blockValue, queueWait, requestErr, validationErr := api.simulateBlock(ctx, opts) // success/error logging happens inside
+		validationErr, filterErr := splitFilterErr(validationErr)
+		simResultC <- &blockSimResult{requestErr == nil, blockValue, false, requestErr, validationErr, queueWait, filterErr}
 		validationDurationMs := time.Since(timeBeforeValidation).Milliseconds()
 		log = log.WithFields(logrus.Fields{
 			"timestampAfterValidation": time.Now().UTC().UnixMilli(),
 			"validationDurationMs":     validationDurationMs,
 		})
 		if requestErr != nil { // Request error
+			isTransientResult = true
 			if os.IsTimeout(requestErr) {
-				api.RespondError(w, http.StatusGatewayTimeout, "validation request timeout")
+				api.RespondErrorCode(w, http.StatusGatewayTimeout, ErrorCodeSimulationTimeout, "validation request timeout")
 			} else {
-				api.RespondError(w, http.StatusBadRequest, requestErr.Error())
+				api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeSimulationError, requestErr.Error())
 			}
 			return
 		} else {
 			if validationErr != nil {
-				api.RespondError(w, http.StatusBadRequest, validationErr.Error())
+				api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeSimulationFailed, validationErr.Error())
+				return
+			}
+			if filterErr != nil {
+				api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeFilteredAddress, filterErr.Error())
 				return
 			}
 		}
@@ -2339,12 +3863,13 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 			log.WithError(err).Error("failed getting latest payload receivedAt from redis")
 		} else if receivedAt.UnixMilli() < latestPayloadReceivedAt {
 			log.Infof("already have a newer payload: now=%d / prev=%d", receivedAt.UnixMilli(), latestPayloadReceivedAt)
-			api.RespondError(w, http.StatusBadRequest, "already using a newer payload")
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeNewerPayloadExists, "already using a newer payload")
 			return
 		}
 	}
 
 	redisOpts := redisUpdateBidOpts{
+		ctx:                  ctx,
 		w:                    w,
 		tx:                   tx,
 		log:                  log,
@@ -2352,8 +3877,11 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 		receivedAt:           receivedAt,
 		floorBidValue:        floorBidValue,
 		payload:              payload,
+		adjustedValue:        adjustedValue,
 	}
+	_, redisSpan := tracing.Start(ctx, "redis")
 	updateBidResult, getPayloadResponse, ok := api.updateRedisBid(redisOpts)
+	redisSpan.End()
 	if !ok {
 		return
 	}
@@ -2367,9 +3895,19 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 		"prevTopBidValue":            updateBidResult.PrevTopBidValue,
 		"profileRedisSavePayloadUs":  updateBidResult.TimeSavePayload.Microseconds(),
 		"profileRedisUpdateTopBidUs": updateBidResult.TimeUpdateTopBid.Microseconds(),
-		"profileRedisUpdateFloorUs":  updateBidResult.TimeUpdateFloor.Microseconds(),
+		"profileRedisExecUs":         updateBidResult.TimeExec.Microseconds(),
 	})
 
+	if updateBidResult.IsNewTopBid {
+		go func() {
+			topBid := common.BidTraceV2{BidTrace: *submission.BidTrace, BlockNumber: submission.BlockNumber, NumTx: uint64(len(submission.Transactions))}
+			err := api.redis.PublishDataStreamEvent(context.Background(), datastore.DataStreamEventTopBid, topBid)
+			if err != nil {
+				log.WithError(err).Error("failed to publish top bid to the data stream")
+			}
+		}()
+	}
+
 	if updateBidResult.WasBidSaved {
 		// Bid is eligible to win the auction
 		eligibleAt = time.Now().UTC()
@@ -2391,13 +3929,14 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	pf.RedisUpdate = uint64(nextTime.Sub(prevTime).Microseconds())                 //nolint:gosec
 	pf.RedisSavePayload = uint64(updateBidResult.TimeSavePayload.Microseconds())   //nolint:gosec
 	pf.RedisUpdateTopBid = uint64(updateBidResult.TimeUpdateTopBid.Microseconds()) //nolint:gosec
-	pf.RedisUpdateFloor = uint64(updateBidResult.TimeUpdateFloor.Microseconds())   //nolint:gosec
+	pf.RedisExec = uint64(updateBidResult.TimeExec.Microseconds())                 //nolint:gosec
 	pf.Total = uint64(nextTime.Sub(receivedAt).Microseconds())                     //nolint:gosec
 
 	// All done, log with profiling information
 	log.WithFields(logrus.Fields{
 		"profileDecodeUs":    pf.Decode,
 		"profilePrechecksUs": pf.Prechecks,
+		"profileSigCheckUs":  pf.SignatureCheck,
 		"profileSimUs":       pf.Simulation,
 		"profileRedisUs":     pf.RedisUpdate,
 		"profileTotalUs":     pf.Total,
@@ -2405,6 +3944,27 @@ func (api *RelayAPI) handleSubmitNewBlock(w http.ResponseWriter, req *http.Reque
 	w.WriteHeader(http.StatusOK)
 }
 
+// respondDuplicateSubmission replays the result of the first submission of the same block. While that one is still
+// being processed, the duplicate is rejected right away, instead of holding the request until the simulation is done.
+func (api *RelayAPI) respondDuplicateSubmission(w http.ResponseWriter, log *logrus.Entry, result *submissionResult) {
+	select {
+	case <-result.done:
+	default:
+		log.Info("duplicate submission, previous submission still being processed")
+		api.RespondErrorCode(w, http.StatusConflict, ErrorCodeSubmissionInProgress, "a submission of the same block is being processed")
+		return
+	}
+
+	log.WithField("prevResponseCode", result.code).Info("duplicate submission, returning previous result")
+	if result.contentType != "" {
+		w.Header().Set("Content-Type", result.contentType)
+	}
+	w.WriteHeader(result.code)
+	if _, err := w.Write(result.body); err != nil {
+		log.WithError(err).Warn("failed to write response for duplicate submission")
+	}
+}
+
 func (api *RelayAPI) saveBlockSubmissionMetrics(pf common.Profile, receivedTime time.Time) {
 	if pf.PayloadLoad > 0 {
 		metrics.SubmitNewBlockReadLatencyHistogram.Record(
@@ -2458,10 +4018,10 @@ func (api *RelayAPI) saveBlockSubmissionMetrics(pf common.Profile, receivedTime
 		)
 	}
 
-	if pf.RedisUpdateFloor > 0 {
-		metrics.SubmitNewBlockRedisFloorLatencyHistogram.Record(
+	if pf.RedisExec > 0 {
+		metrics.SubmitNewBlockRedisExecLatencyHistogram.Record(
 			context.Background(),
-			float64(pf.RedisUpdateFloor)/1000,
+			float64(pf.RedisExec)/1000,
 		)
 	}
 
@@ -2531,6 +4091,11 @@ func (api *RelayAPI) handleInternalBuilderStatus(w http.ResponseWriter, req *htt
 			api.RespondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		// the status set by the operator replaces an automatic demotion
+		err = api.redis.DelBlockBuilderStatus(builderPubkey)
+		if err != nil {
+			api.log.WithError(err).Error("could not delete published builder status")
+		}
 		api.RespondOK(w, st)
 	}
 }
@@ -2558,171 +4123,532 @@ func (api *RelayAPI) handleInternalBuilderCollateral(w http.ResponseWriter, req
 	}
 }
 
-// -----------
-//  DATA APIS
-// -----------
-
-func (api *RelayAPI) handleDataProposerPayloadDelivered(w http.ResponseWriter, req *http.Request) {
-	var err error
-	args := req.URL.Query()
-
-	filters := database.GetPayloadsFilters{
-		Limit: 200,
+func (api *RelayAPI) handleInternalBlacklistedBuilders(w http.ResponseWriter, req *http.Request) {
+	builders, err := api.db.GetBlockBuilders()
+	if err != nil {
+		api.log.WithError(err).Error("could not get block builders")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	if args.Get("slot") != "" && args.Get("cursor") != "" {
-		api.RespondError(w, http.StatusBadRequest, "cannot specify both slot and cursor")
-		return
-	} else if args.Get("slot") != "" {
-		filters.Slot, err = strconv.ParseInt(args.Get("slot"), 10, 64)
-		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid slot argument")
-			return
-		}
-	} else if args.Get("cursor") != "" {
-		filters.Cursor, err = strconv.ParseInt(args.Get("cursor"), 10, 64)
-		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid cursor argument")
-			return
+	response := []*database.BlockBuilderEntry{}
+	for _, builder := range builders {
+		if builder.IsBlacklisted {
+			response = append(response, builder)
 		}
 	}
+	api.RespondOK(w, response)
+}
 
-	if args.Get("block_hash") != "" {
-		_, err := utils.HexToHash(args.Get("block_hash"))
+// handleInternalBuilders lists all builders ordered by id. Pages are requested with the id of the last builder of
+// the previous page as cursor.
+func (api *RelayAPI) handleInternalBuilders(w http.ResponseWriter, req *http.Request) {
+	args := req.URL.Query()
+	cursor := int64(0)
+	limit := internalBuildersDefaultLimit
+	var err error
+	if args.Get("cursor") != "" {
+		cursor, err = strconv.ParseInt(args.Get("cursor"), 10, 64)
 		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid block_hash argument")
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid cursor argument")
 			return
 		}
-		filters.BlockHash = args.Get("block_hash")
 	}
-
-	if args.Get("block_number") != "" {
-		filters.BlockNumber, err = strconv.ParseInt(args.Get("block_number"), 10, 64)
-		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid block_number argument")
+	if args.Get("limit") != "" {
+		limit, err = strconv.ParseUint(args.Get("limit"), 10, 64)
+		if err != nil || limit == 0 {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid limit argument")
 			return
 		}
-	}
-
-	if args.Get("proposer_pubkey") != "" {
-		if err = checkBLSPublicKeyHex(args.Get("proposer_pubkey")); err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid proposer_pubkey argument")
+		if limit > internalBuildersMaxLimit {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum limit is %d", internalBuildersMaxLimit))
 			return
 		}
-		filters.ProposerPubkey = args.Get("proposer_pubkey")
 	}
 
-	if args.Get("builder_pubkey") != "" {
-		if err = checkBLSPublicKeyHex(args.Get("builder_pubkey")); err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid builder_pubkey argument")
-			return
-		}
-		filters.BuilderPubkey = args.Get("builder_pubkey")
+	builders, err := api.db.GetBlockBuilders()
+	if err != nil {
+		api.log.WithError(err).Error("could not get block builders")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	demotionCounts, err := api.db.GetBuilderDemotionCounts()
+	if err != nil {
+		api.log.WithError(err).Error("could not get builder demotion counts")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	if args.Get("limit") != "" {
-		_limit, err := strconv.ParseUint(args.Get("limit"), 10, 64)
-		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid limit argument")
-			return
+	response := []InternalBuilderEntry{}
+	for _, builder := range builders {
+		if builder.ID <= cursor {
+			continue
 		}
-		if _limit > filters.Limit {
-			api.RespondError(w, http.StatusBadRequest, fmt.Sprintf("maximum limit is %d", filters.Limit))
-			return
+		if uint64(len(response)) >= limit {
+			break
 		}
-		filters.Limit = _limit
-	}
 
-	if args.Get("order_by") == "value" {
-		filters.OrderByValue = 1
-	} else if args.Get("order_by") == "-value" {
-		filters.OrderByValue = -1
+		entry := InternalBuilderEntry{
+			BlockBuilderEntry: builder,
+			NumDemotions:      demotionCounts[builder.BuilderPubkey],
+		}
+		if cacheEntry, ok := api.blockBuildersCache[builder.BuilderPubkey]; ok {
+			entry.EffectiveIsHighPrio = cacheEntry.status.IsHighPrio
+			entry.EffectiveIsOptimistic = cacheEntry.status.IsOptimistic
+			entry.IsOptimisticEligible = cacheEntry.status.IsOptimistic && !cacheEntry.status.IsBlacklisted && cacheEntry.collateral.Sign() > 0
+		}
+		if builder.LastSubmissionSlot > 0 && api.genesisInfo != nil {
+			lastSubmissionTime := api.slotClock().SlotStartTime(builder.LastSubmissionSlot)
+			entry.LastSubmissionTime = &lastSubmissionTime
+		}
+		response = append(response, entry)
 	}
+	api.RespondOK(w, response)
+}
 
-	deliveredPayloads, err := api.db.GetRecentDeliveredPayloads(filters)
+// handleInternalBuilderIdentities lists all builder identities with their pubkeys
+func (api *RelayAPI) handleInternalBuilderIdentities(w http.ResponseWriter, req *http.Request) {
+	identities, err := api.db.GetBuilderIdentities()
 	if err != nil {
-		api.log.WithError(err).Error("error getting recently delivered payloads")
+		api.log.WithError(err).Error("could not get builder identities")
 		api.RespondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-
-	response := make([]common.BidTraceV2JSON, len(deliveredPayloads))
-	for i, payload := range deliveredPayloads {
-		response[i] = database.DeliveredPayloadEntryToBidTraceV2JSON(payload)
+	builders, err := api.db.GetBlockBuilders()
+	if err != nil {
+		api.log.WithError(err).Error("could not get block builders")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
+	response := make([]InternalBuilderIdentityEntry, len(identities))
+	for i, identity := range identities {
+		response[i] = newInternalBuilderIdentityEntry(identity, builders)
+	}
 	api.RespondOK(w, response)
 }
 
-func (api *RelayAPI) handleDataBuilderBidsReceived(w http.ResponseWriter, req *http.Request) {
-	var err error
-	args := req.URL.Query()
-
-	filters := database.GetBuilderSubmissionsFilters{
-		Limit:         500,
-		Slot:          0,
-		BlockHash:     "",
-		BlockNumber:   0,
-		BuilderPubkey: "",
+// newInternalBuilderIdentityEntry returns the identity with the pubkeys of the block builders grouped into it
+func newInternalBuilderIdentityEntry(identity *database.BuilderIdentityEntry, builders []*database.BlockBuilderEntry) InternalBuilderIdentityEntry {
+	entry := InternalBuilderIdentityEntry{
+		BuilderIdentityEntry: identity,
+		BuilderPubkeys:       []string{},
+	}
+	for _, builder := range builders {
+		if builder.BuilderIdentity == identity.BuilderID {
+			entry.BuilderPubkeys = append(entry.BuilderPubkeys, builder.BuilderPubkey)
+		}
 	}
+	return entry
+}
 
-	if args.Get("cursor") != "" {
-		api.RespondError(w, http.StatusBadRequest, "cursor argument not supported")
+// handleInternalBuilderIdentity returns a builder identity, or creates or updates it. The high-prio and blacklisted
+// status of the identity is applied to all of its pubkeys.
+func (api *RelayAPI) handleInternalBuilderIdentity(w http.ResponseWriter, req *http.Request) {
+	builderID := mux.Vars(req)["builder_id"]
+	identity, err := api.db.GetBuilderIdentity(builderID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		api.log.WithError(err).Error("could not get builder identity")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	if args.Get("slot") != "" {
-		filters.Slot, err = strconv.ParseInt(args.Get("slot"), 10, 64)
-		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid slot argument")
+	if req.Method == http.MethodGet {
+		if errors.Is(err, sql.ErrNoRows) {
+			api.RespondError(w, http.StatusBadRequest, "builder identity not found")
 			return
 		}
-	}
-
-	if args.Get("block_hash") != "" {
-		_, err := utils.HexToHash(args.Get("block_hash"))
+	} else {
+		if errors.Is(err, sql.ErrNoRows) {
+			identity = &database.BuilderIdentityEntry{BuilderID: builderID} //nolint:exhaustruct
+		}
+		trueStr := "true"
+		args := req.URL.Query()
+		if args.Has("description") {
+			identity.Description = args.Get("description")
+		}
+		if args.Get("high_prio") != "" {
+			identity.IsHighPrio = args.Get("high_prio") == trueStr
+		}
+		if args.Get("blacklisted") != "" {
+			identity.IsBlacklisted = args.Get("blacklisted") == trueStr
+		}
+		api.log.WithFields(logrus.Fields{
+			"builderID":     builderID,
+			"isHighPrio":    identity.IsHighPrio,
+			"isBlacklisted": identity.IsBlacklisted,
+		}).Info("updating builder identity")
+		err = api.db.UpsertBuilderIdentity(*identity)
 		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid block_hash argument")
+			err := fmt.Errorf("error updating builder identity: %v: %w", builderID, err)
+			api.log.Error(err)
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
-		filters.BlockHash = args.Get("block_hash")
-	}
-
-	if args.Get("block_number") != "" {
-		filters.BlockNumber, err = strconv.ParseInt(args.Get("block_number"), 10, 64)
+		identity, err = api.db.GetBuilderIdentity(builderID)
 		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid block_number argument")
+			api.log.WithError(err).Error("could not get builder identity")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	builders, err := api.db.GetBlockBuilders()
+	if err != nil {
+		api.log.WithError(err).Error("could not get block builders")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response := newInternalBuilderIdentityEntry(identity, builders)
+	if req.Method != http.MethodGet {
+		// the status set by the operator replaces an automatic demotion
+		for _, pubkey := range response.BuilderPubkeys {
+			if err := api.redis.DelBlockBuilderStatus(pubkey); err != nil {
+				api.log.WithError(err).Error("could not delete published builder status")
+			}
+		}
+	}
+	api.RespondOK(w, response)
+}
+
+// handleInternalBuilderIdentityKey adds a pubkey to a builder identity (POST/PUT), which applies the status of the
+// identity to it, or removes it from the identity (DELETE)
+func (api *RelayAPI) handleInternalBuilderIdentityKey(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	builderID := vars["builder_id"]
+	builderPubkey := vars["pubkey"]
+	builderEntry, err := api.db.GetBlockBuilderByPubkey(builderPubkey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			api.RespondError(w, http.StatusBadRequest, "builder not found")
+			return
+		}
+		api.log.WithError(err).Error("could not get block builder")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log := api.log.WithFields(logrus.Fields{
+		"builderID":     builderID,
+		"builderPubkey": builderPubkey,
+	})
+	if req.Method == http.MethodDelete {
+		if builderEntry.BuilderIdentity != builderID {
+			api.RespondError(w, http.StatusBadRequest, "builder is not part of this identity")
+			return
+		}
+		log.Info("removing builder from identity")
+		if err := api.db.SetBlockBuilderIdentity(builderPubkey, ""); err != nil {
+			log.WithError(err).Error("could not remove builder from identity")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		api.RespondOK(w, NilResponse)
+		return
+	}
+
+	if _, err := api.db.GetBuilderIdentity(builderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			api.RespondError(w, http.StatusBadRequest, "builder identity not found")
+			return
+		}
+		log.WithError(err).Error("could not get builder identity")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Info("adding builder to identity")
+	if err := api.db.SetBlockBuilderIdentity(builderPubkey, builderID); err != nil {
+		log.WithError(err).Error("could not add builder to identity")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := api.redis.DelBlockBuilderStatus(builderPubkey); err != nil {
+		log.WithError(err).Error("could not delete published builder status")
+	}
+	api.RespondOK(w, NilResponse)
+}
+
+func (api *RelayAPI) handleInternalCircuitBreaker(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodGet {
+		state, err := api.redis.GetCircuitBreakerState()
+		if err != nil {
+			api.log.WithError(err).Error("could not get circuit breaker state")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		api.RespondOK(w, state)
+		return
+	}
+
+	// re-enable serving bids
+	state, wasTripped, err := api.redis.ResetCircuitBreaker()
+	if err != nil {
+		api.log.WithError(err).Error("could not reset circuit breaker")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.log.WithField("wasTripped", wasTripped).Info("reset circuit breaker")
+	api.isCircuitBreakerTripped.Store(false)
+	api.RespondOK(w, state)
+}
+
+// handleInternalInvalidateBids invalidates all bids of a slot, or only those of a builder if builder_pubkey is given.
+// Invalidated bids are removed from the cache, and are neither served in getHeader nor delivered in getPayload.
+func (api *RelayAPI) handleInternalInvalidateBids(w http.ResponseWriter, req *http.Request) {
+	args := req.URL.Query()
+	slot, err := strconv.ParseUint(args.Get("slot"), 10, 64)
+	if err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid slot argument")
+		return
+	}
+
+	builderPubkey := ""
+	if args.Get("builder_pubkey") != "" {
+		pk, err := common.StrToPhase0Pubkey(args.Get("builder_pubkey"))
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid builder_pubkey argument")
+			return
+		}
+		builderPubkey = pk.String()
+	}
+
+	log := api.log.WithFields(logrus.Fields{
+		"slot":          slot,
+		"builderPubkey": builderPubkey,
+	})
+	numDeleted, err := api.redis.InvalidateBids(req.Context(), slot, builderPubkey)
+	if err != nil {
+		log.WithError(err).Error("could not invalidate bids")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.WithField("numDeleted", numDeleted).Warn("invalidated bids")
+	api.RespondOK(w, InvalidateBidsResponse{
+		Slot:          slot,
+		BuilderPubkey: builderPubkey,
+		NumDeleted:    numDeleted,
+	})
+}
+
+// handleInternalRestoreRegistrations saves the timestamps of the latest validator registrations from the database to
+// Redis, to restore them after a Redis data loss
+func (api *RelayAPI) handleInternalRestoreRegistrations(w http.ResponseWriter, req *http.Request) {
+	timeStarted := time.Now()
+	numRegistrations, err := api.datastore.RestoreValidatorRegistrationsInRedis()
+	if err != nil {
+		api.log.WithError(err).Error("could not restore validator registrations in Redis")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	api.log.WithFields(logrus.Fields{
+		"numRegistrations": numRegistrations,
+		"durationMs":       time.Since(timeStarted).Milliseconds(),
+	}).Info("restored validator registrations in Redis")
+	api.RespondOK(w, RestoreRegistrationsResponse{NumRegistrations: numRegistrations})
+}
+
+// -----------
+//  DATA APIS
+// -----------
+
+func (api *RelayAPI) handleDataProposerPayloadDelivered(w http.ResponseWriter, req *http.Request) {
+	var err error
+	args := req.URL.Query()
+
+	mimeType, err := negotiateDataResponseType(w, req)
+	if err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, err.Error())
+		return
+	}
+
+	filters := database.GetPayloadsFilters{
+		Limit: 200,
+	}
+
+	if args.Get("slot") != "" && args.Get("cursor") != "" {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "cannot specify both slot and cursor")
+		return
+	} else if args.Get("slot") != "" {
+		filters.Slot, err = strconv.ParseInt(args.Get("slot"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid slot argument")
+			return
+		}
+	} else if args.Get("cursor") != "" {
+		filters.Cursor, err = strconv.ParseInt(args.Get("cursor"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid cursor argument")
+			return
+		}
+	}
+
+	filters.FromSlot, filters.ToSlot, err = parseSlotRange(args, api.slotClock())
+	if err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, err.Error())
+		return
+	}
+	if filters.Slot > 0 && (filters.FromSlot > 0 || filters.ToSlot != nil) {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "cannot specify both slot and a slot range")
+		return
+	}
+
+	if args.Get("block_hash") != "" {
+		_, err := utils.HexToHash(args.Get("block_hash"))
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid block_hash argument")
+			return
+		}
+		filters.BlockHash = args.Get("block_hash")
+	}
+
+	if args.Get("block_number") != "" {
+		filters.BlockNumber, err = strconv.ParseInt(args.Get("block_number"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid block_number argument")
+			return
+		}
+	}
+
+	if args.Get("proposer_pubkey") != "" {
+		if err = checkBLSPublicKeyHex(args.Get("proposer_pubkey")); err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid proposer_pubkey argument")
+			return
+		}
+		filters.ProposerPubkey = args.Get("proposer_pubkey")
+	}
+
+	if args.Get("builder_pubkey") != "" {
+		if err = checkBLSPublicKeyHex(args.Get("builder_pubkey")); err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid builder_pubkey argument")
+			return
+		}
+		filters.BuilderPubkey = args.Get("builder_pubkey")
+	}
+
+	if args.Get("limit") != "" {
+		_limit, err := strconv.ParseUint(args.Get("limit"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid limit argument")
+			return
+		}
+		if _limit > filters.Limit {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum limit is %d", filters.Limit))
+			return
+		}
+		filters.Limit = _limit
+	}
+
+	if args.Get("order_by") == "value" {
+		filters.OrderByValue = 1
+	} else if args.Get("order_by") == "-value" {
+		filters.OrderByValue = -1
+	}
+
+	deliveredPayloads, err := api.db.GetRecentDeliveredPayloads(filters)
+	if err != nil {
+		api.log.WithError(err).Error("error getting recently delivered payloads")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]common.BidTraceV2JSON, len(deliveredPayloads))
+	for i, payload := range deliveredPayloads {
+		response[i] = database.DeliveredPayloadEntryToBidTraceV2JSON(payload)
+	}
+
+	respondDataEntries(api, w, mimeType, response)
+}
+
+func (api *RelayAPI) handleDataBuilderBidsReceived(w http.ResponseWriter, req *http.Request) {
+	var err error
+	args := req.URL.Query()
+
+	mimeType, err := negotiateDataResponseType(w, req)
+	if err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, err.Error())
+		return
+	}
+
+	filters := database.GetBuilderSubmissionsFilters{
+		Limit:         500,
+		Slot:          0,
+		BlockHash:     "",
+		BlockNumber:   0,
+		BuilderPubkey: "",
+	}
+
+	// paging starts with cursor=latest, and continues with the lowest id of the previous page as cursor
+	if args.Get("cursor") == "latest" {
+		filters.Cursor = math.MaxInt64
+	} else if args.Get("cursor") != "" {
+		filters.Cursor, err = strconv.ParseInt(args.Get("cursor"), 10, 64)
+		if err != nil || filters.Cursor <= 0 {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid cursor argument")
+			return
+		}
+	}
+
+	if args.Get("slot") != "" {
+		filters.Slot, err = strconv.ParseInt(args.Get("slot"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid slot argument")
+			return
+		}
+	}
+
+	if args.Get("block_hash") != "" {
+		_, err := utils.HexToHash(args.Get("block_hash"))
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid block_hash argument")
+			return
+		}
+		filters.BlockHash = args.Get("block_hash")
+	}
+
+	if args.Get("block_number") != "" {
+		filters.BlockNumber, err = strconv.ParseInt(args.Get("block_number"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid block_number argument")
 			return
 		}
 	}
 
 	if args.Get("builder_pubkey") != "" {
 		if err = checkBLSPublicKeyHex(args.Get("builder_pubkey")); err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid builder_pubkey argument")
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid builder_pubkey argument")
 			return
 		}
 		filters.BuilderPubkey = args.Get("builder_pubkey")
 	}
 
 	// at least one query arguments is required
-	if filters.Slot == 0 && filters.BlockHash == "" && filters.BlockNumber == 0 && filters.BuilderPubkey == "" {
-		api.RespondError(w, http.StatusBadRequest, "need to query for specific slot or block_hash or block_number or builder_pubkey")
+	if filters.Slot == 0 && filters.Cursor == 0 && filters.BlockHash == "" && filters.BlockNumber == 0 && filters.BuilderPubkey == "" {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "need to query for specific slot or cursor or block_hash or block_number or builder_pubkey")
 		return
 	}
 
 	if args.Get("limit") != "" {
 		_limit, err := strconv.ParseInt(args.Get("limit"), 10, 64)
 		if err != nil {
-			api.RespondError(w, http.StatusBadRequest, "invalid limit argument")
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid limit argument")
 			return
 		}
 		if _limit > filters.Limit {
-			api.RespondError(w, http.StatusBadRequest, fmt.Sprintf("maximum limit is %d", filters.Limit))
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum limit is %d", filters.Limit))
 			return
 		}
 		filters.Limit = _limit
 	}
 
+	// submissions which failed the simulation are only included on request, with the simulation results
+	if args.Get("include_sim_errors") != "" {
+		filters.IncludeSimErrors, err = strconv.ParseBool(args.Get("include_sim_errors"))
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid include_sim_errors argument")
+			return
+		}
+	}
+
 	blockSubmissions, err := api.db.GetBuilderSubmissions(filters)
 	if err != nil {
 		api.log.WithError(err).Error("error getting recent builder submissions")
@@ -2733,21 +4659,47 @@ func (api *RelayAPI) handleDataBuilderBidsReceived(w http.ResponseWriter, req *h
 	response := make([]common.BidTraceV2WithTimestampJSON, len(blockSubmissions))
 	for i, payload := range blockSubmissions {
 		response[i] = database.BuilderSubmissionEntryToBidTraceV2WithTimestampJSON(payload)
+		if filters.IncludeSimErrors && payload.WasSimulated {
+			response[i].SimSuccess = &payload.SimSuccess
+			response[i].SimError = payload.SimError
+			response[i].FilterError = payload.FilterError
+		}
 	}
 
-	api.RespondOK(w, response)
+	respondDataEntries(api, w, mimeType, response)
 }
 
+// handleDataValidatorRegistration returns the latest registration of a validator. Multiple validators can be looked up
+// at once, with a comma separated pubkey argument or a JSON list of pubkeys in a POST body, which returns the list of
+// the registrations found.
 func (api *RelayAPI) handleDataValidatorRegistration(w http.ResponseWriter, req *http.Request) {
+	var pubkeys []string
+	if req.Method == http.MethodPost {
+		err := json.NewDecoder(req.Body).Decode(&pubkeys)
+		if isMaxBytesError(err) {
+			api.RespondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large, maximum number of pubkeys is %d", dataValidatorRegistrationsMaxPubkeys))
+			return
+		} else if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid request body, expected a list of pubkeys")
+			return
+		}
+	} else if strings.Contains(req.URL.Query().Get("pubkey"), ",") {
+		pubkeys = strings.Split(req.URL.Query().Get("pubkey"), ",")
+	}
+	if pubkeys != nil {
+		api.respondValidatorRegistrations(w, pubkeys)
+		return
+	}
+
 	pkStr := req.URL.Query().Get("pubkey")
 	if pkStr == "" {
-		api.RespondError(w, http.StatusBadRequest, "missing pubkey argument")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "missing pubkey argument")
 		return
 	}
 
 	_, err := utils.HexToPubkey(pkStr)
 	if err != nil {
-		api.RespondError(w, http.StatusBadRequest, "invalid pubkey")
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid pubkey")
 		return
 	}
 
@@ -2772,6 +4724,380 @@ func (api *RelayAPI) handleDataValidatorRegistration(w http.ResponseWriter, req
 	api.RespondOK(w, signedRegistration)
 }
 
+// handleDataValidatorRegistrationHistory returns the accepted registrations of a validator, newest first
+func (api *RelayAPI) handleDataValidatorRegistrationHistory(w http.ResponseWriter, req *http.Request) {
+	args := req.URL.Query()
+
+	pkStr := args.Get("pubkey")
+	if pkStr == "" {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "missing pubkey argument")
+		return
+	}
+	if _, err := utils.HexToPubkey(pkStr); err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid pubkey")
+		return
+	}
+
+	limit := dataValidatorRegHistoryMaxLimit
+	if args.Get("limit") != "" {
+		_limit, err := strconv.ParseUint(args.Get("limit"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid limit argument")
+			return
+		}
+		if _limit > limit {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum limit is %d", limit))
+			return
+		}
+		limit = _limit
+	}
+
+	registrationEntries, err := api.db.GetValidatorRegistrationHistory(strings.ToLower(pkStr), limit)
+	if err != nil {
+		api.log.WithError(err).Error("error getting validator registration history")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]*builderApiV1.SignedValidatorRegistration, len(registrationEntries))
+	for i, registrationEntry := range registrationEntries {
+		response[i], err = registrationEntry.ToSignedValidatorRegistration()
+		if err != nil {
+			api.log.WithError(err).Error("error converting registration entry to signed validator registration")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	api.RespondOK(w, response)
+}
+
+// respondValidatorRegistrations responds with the latest registrations of the given validators, validators without a
+// registration are omitted
+func (api *RelayAPI) respondValidatorRegistrations(w http.ResponseWriter, pubkeys []string) {
+	if len(pubkeys) == 0 {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "missing pubkeys")
+		return
+	}
+	if len(pubkeys) > dataValidatorRegistrationsMaxPubkeys {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum number of pubkeys is %d", dataValidatorRegistrationsMaxPubkeys))
+		return
+	}
+
+	uniquePubkeys := make([]string, 0, len(pubkeys))
+	seen := make(map[string]bool, len(pubkeys))
+	for _, pkStr := range pubkeys {
+		pkStr = strings.ToLower(strings.TrimSpace(pkStr))
+		if _, err := utils.HexToPubkey(pkStr); err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid pubkey: "+pkStr)
+			return
+		}
+		if !seen[pkStr] {
+			seen[pkStr] = true
+			uniquePubkeys = append(uniquePubkeys, pkStr)
+		}
+	}
+
+	registrationEntries, err := api.db.GetValidatorRegistrationsForPubkeys(uniquePubkeys)
+	if err != nil {
+		api.log.WithError(err).Error("error getting validator registrations")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]*builderApiV1.SignedValidatorRegistration, len(registrationEntries))
+	for i, registrationEntry := range registrationEntries {
+		response[i], err = registrationEntry.ToSignedValidatorRegistration()
+		if err != nil {
+			api.log.WithError(err).Error("error converting registration entry to signed validator registration")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	api.RespondOK(w, response)
+}
+
+// handleDataStats returns the delivered payload stats of the days from from_day to to_day (YYYY-MM-DD, UTC), by default
+// of the last 30 days. The stats are refreshed by the housekeeper once per epoch.
+func (api *RelayAPI) handleDataStats(w http.ResponseWriter, req *http.Request) {
+	fromDay, toDay, err := parseDataStatsDayRange(req.URL.Query())
+	if err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, err.Error())
+		return
+	}
+
+	entries, err := api.db.GetDeliveredPayloadDailyStats(fromDay, toDay)
+	if err != nil {
+		api.log.WithError(err).Error("error getting delivered payload stats")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response, err := aggregateDeliveredPayloadStats(entries)
+	if err != nil {
+		api.log.WithError(err).Error("error aggregating delivered payload stats")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	response.FromDay = fromDay.Format(time.DateOnly)
+	response.ToDay = toDay.Format(time.DateOnly)
+	api.RespondOK(w, response)
+}
+
+// handleDataBuilderStats returns the number of submissions, failed simulations and delivered payloads of the builders
+// per day (from_day to to_day, like the stats), or per epoch if from_epoch or to_epoch is given. The stats are refreshed
+// by the housekeeper once per epoch. With builder_id, the stats of the current pubkeys of a builder identity are summed up.
+func (api *RelayAPI) handleDataBuilderStats(w http.ResponseWriter, req *http.Request) {
+	args := req.URL.Query()
+
+	builderPubkey := args.Get("builder_pubkey")
+	if builderPubkey != "" {
+		if err := checkBLSPublicKeyHex(builderPubkey); err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid builder_pubkey argument")
+			return
+		}
+	}
+	builderID := args.Get("builder_id")
+	if builderID != "" && builderPubkey != "" {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "cannot specify both builder_pubkey and builder_id")
+		return
+	}
+
+	if args.Get("from_epoch") == "" && args.Get("to_epoch") == "" {
+		fromDay, toDay, err := parseDataStatsDayRange(args)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, err.Error())
+			return
+		}
+
+		var entries []*database.BuilderDailyStatsEntry
+		if builderID != "" {
+			entries, err = api.db.GetBuilderIdentityDailyStats(builderID, fromDay, toDay)
+		} else {
+			entries, err = api.db.GetBuilderDailyStats(builderPubkey, fromDay, toDay)
+		}
+		if err != nil {
+			api.log.WithError(err).Error("error getting builder daily stats")
+			api.RespondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		response := make([]DataBuilderDailyStatsEntry, len(entries))
+		for i, entry := range entries {
+			response[i] = DataBuilderDailyStatsEntry{
+				Day:            entry.Day.Format(time.DateOnly),
+				BuilderPubkey:  entry.BuilderPubkey,
+				BuilderID:      entry.BuilderID,
+				NumSubmissions: entry.NumSubmissions,
+				NumSimErrors:   entry.NumSimErrors,
+				NumPayloads:    entry.NumPayloads,
+				TotalValue:     entry.TotalValue,
+			}
+		}
+		api.RespondOK(w, response)
+		return
+	}
+
+	toEpoch := api.headSlot.Load() / common.SlotsPerEpoch
+	if args.Get("to_epoch") != "" {
+		epoch, err := strconv.ParseUint(args.Get("to_epoch"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid to_epoch argument")
+			return
+		}
+		toEpoch = epoch
+	}
+
+	var fromEpoch uint64
+	if toEpoch >= dataBuilderStatsMaxEpochs {
+		fromEpoch = toEpoch - dataBuilderStatsMaxEpochs + 1
+	}
+	if args.Get("from_epoch") != "" {
+		epoch, err := strconv.ParseUint(args.Get("from_epoch"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid from_epoch argument")
+			return
+		}
+		fromEpoch = epoch
+	}
+
+	if fromEpoch > toEpoch {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "from_epoch is after to_epoch")
+		return
+	}
+	if toEpoch-fromEpoch >= dataBuilderStatsMaxEpochs {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum range is %d epochs", dataBuilderStatsMaxEpochs))
+		return
+	}
+
+	var entries []*database.BuilderEpochStatsEntry
+	var err error
+	if builderID != "" {
+		entries, err = api.db.GetBuilderIdentityEpochStats(builderID, fromEpoch, toEpoch)
+	} else {
+		entries, err = api.db.GetBuilderEpochStats(builderPubkey, fromEpoch, toEpoch)
+	}
+	if err != nil {
+		api.log.WithError(err).Error("error getting builder epoch stats")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]DataBuilderEpochStatsEntry, len(entries))
+	for i, entry := range entries {
+		response[i] = DataBuilderEpochStatsEntry{
+			Epoch:          entry.Epoch,
+			BuilderPubkey:  entry.BuilderPubkey,
+			BuilderID:      entry.BuilderID,
+			NumSubmissions: entry.NumSubmissions,
+			NumSimErrors:   entry.NumSimErrors,
+			NumPayloads:    entry.NumPayloads,
+			TotalValue:     entry.TotalValue,
+		}
+	}
+	api.RespondOK(w, response)
+}
+
+// handleDataGetHeaderLog returns the bids served in response to getHeader requests, newest first
+func (api *RelayAPI) handleDataGetHeaderLog(w http.ResponseWriter, req *http.Request) {
+	var err error
+	args := req.URL.Query()
+
+	mimeType, err := negotiateDataResponseType(w, req)
+	if err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, err.Error())
+		return
+	}
+
+	filters := database.GetHeaderLogFilters{
+		Limit: dataGetHeaderLogMaxLimit,
+	}
+
+	if args.Get("slot") != "" {
+		filters.Slot, err = strconv.ParseUint(args.Get("slot"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid slot argument")
+			return
+		}
+	}
+
+	if args.Get("proposer_pubkey") != "" {
+		if err = checkBLSPublicKeyHex(args.Get("proposer_pubkey")); err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid proposer_pubkey argument")
+			return
+		}
+		filters.ProposerPubkey = args.Get("proposer_pubkey")
+	}
+
+	if args.Get("block_hash") != "" {
+		_, err := utils.HexToHash(args.Get("block_hash"))
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid block_hash argument")
+			return
+		}
+		filters.BlockHash = args.Get("block_hash")
+	}
+
+	// at least one query arguments is required
+	if filters.Slot == 0 && filters.ProposerPubkey == "" && filters.BlockHash == "" {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "need to query for specific slot or proposer_pubkey or block_hash")
+		return
+	}
+
+	if args.Get("limit") != "" {
+		_limit, err := strconv.ParseUint(args.Get("limit"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid limit argument")
+			return
+		}
+		if _limit > filters.Limit {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum limit is %d", filters.Limit))
+			return
+		}
+		filters.Limit = _limit
+	}
+
+	entries, err := api.db.GetGetHeaderLogEntries(filters)
+	if err != nil {
+		api.log.WithError(err).Error("error getting getHeader log entries")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]common.GetHeaderLogJSON, len(entries))
+	for i, entry := range entries {
+		response[i] = database.GetHeaderLogEntryToJSON(entry)
+	}
+
+	respondDataEntries(api, w, mimeType, response)
+}
+
+// handleDataGetPayloadFailures returns the failed getPayload requests with their reason codes, newest first
+func (api *RelayAPI) handleDataGetPayloadFailures(w http.ResponseWriter, req *http.Request) {
+	var err error
+	args := req.URL.Query()
+
+	mimeType, err := negotiateDataResponseType(w, req)
+	if err != nil {
+		api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, err.Error())
+		return
+	}
+
+	filters := database.GetPayloadFailureFilters{
+		Limit: dataGetPayloadFailuresMaxLimit,
+	}
+
+	if args.Get("slot") != "" {
+		filters.Slot, err = strconv.ParseUint(args.Get("slot"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid slot argument")
+			return
+		}
+	}
+
+	if args.Get("proposer_pubkey") != "" {
+		if err = checkBLSPublicKeyHex(args.Get("proposer_pubkey")); err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid proposer_pubkey argument")
+			return
+		}
+		filters.ProposerPubkey = args.Get("proposer_pubkey")
+	}
+
+	if args.Get("reason") != "" {
+		if !slices.Contains(getPayloadFailureReasons, args.Get("reason")) {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid reason argument, supported are "+strings.Join(getPayloadFailureReasons, ", "))
+			return
+		}
+		filters.Reason = args.Get("reason")
+	}
+
+	if args.Get("limit") != "" {
+		_limit, err := strconv.ParseUint(args.Get("limit"), 10, 64)
+		if err != nil {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, "invalid limit argument")
+			return
+		}
+		if _limit > filters.Limit {
+			api.RespondErrorCode(w, http.StatusBadRequest, ErrorCodeInvalidArgument, fmt.Sprintf("maximum limit is %d", filters.Limit))
+			return
+		}
+		filters.Limit = _limit
+	}
+
+	entries, err := api.db.GetGetPayloadFailures(filters)
+	if err != nil {
+		api.log.WithError(err).Error("error getting getPayload failures")
+		api.RespondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	response := make([]common.GetPayloadFailureJSON, len(entries))
+	for i, entry := range entries {
+		response[i] = database.GetPayloadFailureEntryToJSON(entry)
+	}
+
+	respondDataEntries(api, w, mimeType, response)
+}
+
 func (api *RelayAPI) handleLivez(w http.ResponseWriter, req *http.Request) {
 	api.RespondMsg(w, http.StatusOK, "live")
 }
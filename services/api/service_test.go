@@ -3,11 +3,16 @@ package api
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,7 +26,10 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/go-boost-utils/ssz"
 	"github.com/flashbots/go-boost-utils/utils"
 	"github.com/flashbots/mev-boost-relay/beaconclient"
 	"github.com/flashbots/mev-boost-relay/common"
@@ -53,7 +61,7 @@ type testBackend struct {
 	redis     *datastore.RedisCache
 }
 
-func newTestBackend(t require.TestingT, numBeaconNodes int) *testBackend {
+func newTestBackend(t testing.TB, numBeaconNodes int) *testBackend {
 	redisClient, err := miniredis.Run()
 	require.NoError(t, err)
 
@@ -65,8 +73,7 @@ func newTestBackend(t require.TestingT, numBeaconNodes int) *testBackend {
 	ds, err := datastore.NewDatastore(redisCache, nil, db)
 	require.NoError(t, err)
 
-	sk, _, err := bls.GenerateNewKeypair()
-	require.NoError(t, err)
+	signer := common.NewTestSigner(t)
 
 	mainnetDetails, err := common.NewEthNetworkDetails(common.EthNetworkMainnet)
 	require.NoError(t, err)
@@ -79,13 +86,18 @@ func newTestBackend(t require.TestingT, numBeaconNodes int) *testBackend {
 		Redis:           redisCache,
 		DB:              db,
 		EthNetDetails:   *mainnetDetails,
-		SecretKey:       sk,
+		Signer:          signer,
 		ProposerAPI:     true,
 		BlockBuilderAPI: true,
 		DataAPI:         true,
 		InternalAPI:     true,
 	}
 
+	// most tests call the internal API without tokens
+	prevAllowUnauthenticated := internalAPIAllowUnauthenticated
+	internalAPIAllowUnauthenticated = true
+	t.Cleanup(func() { internalAPIAllowUnauthenticated = prevAllowUnauthenticated })
+
 	relay, err := NewRelayAPI(opts)
 	require.NoError(t, err)
 
@@ -160,6 +172,30 @@ func (be *testBackend) requestWithUA(method, path, userAgent string, payload any
 	return rr
 }
 
+// blockSubmissionRecorderDB records the saved builder block submissions
+type blockSubmissionRecorderDB struct {
+	database.MockDB
+	submissions  []*database.BuilderBlockSubmissionEntry
+	execPayloads []*database.ExecutionPayloadEntry
+}
+
+func (db *blockSubmissionRecorderDB) SaveBuilderBlockSubmissions(submissions []*database.BuilderBlockSubmissionEntry, execPayloads []*database.ExecutionPayloadEntry) error {
+	db.submissions = append(db.submissions, submissions...)
+	db.execPayloads = append(db.execPayloads, execPayloads...)
+	return nil
+}
+
+// getHeaderLogRecorderDB records the batches of saved getHeader log entries
+type getHeaderLogRecorderDB struct {
+	database.MockDB
+	batches [][]database.GetHeaderLogEntry
+}
+
+func (db *getHeaderLogRecorderDB) InsertGetHeaderLogEntries(entries []database.GetHeaderLogEntry) error {
+	db.batches = append(db.batches, slices.Clone(entries))
+	return nil
+}
+
 func TestWebserver(t *testing.T) {
 	t.Run("errors when webserver is already existing", func(t *testing.T) {
 		backend := newTestBackend(t, 1)
@@ -167,6 +203,144 @@ func TestWebserver(t *testing.T) {
 		err := backend.relay.StartServer()
 		require.Error(t, err)
 	})
+
+	t.Run("flushes pending validator registrations on shutdown", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		backend.relay.srvs = []*http.Server{{}} //nolint:gosec
+
+		prevShutdownWaitDuration := apiShutdownWaitDuration
+		apiShutdownWaitDuration = 0
+		t.Cleanup(func() { apiShutdownWaitDuration = prevShutdownWaitDuration })
+		reg := signedTestRegistration(t, backend, 1)
+		backend.relay.validatorRegC <- reg
+		backend.relay.validatorRegProcessorWG.Add(1)
+		go backend.relay.startValidatorRegistrationDBProcessor()
+
+		err := backend.relay.StopServer()
+		require.NoError(t, err)
+
+		timestamp, err := backend.redis.GetValidatorRegistrationTimestamp(common.NewPubkeyHex(reg.Message.Pubkey.String()))
+		require.NoError(t, err)
+		require.Equal(t, uint64(reg.Message.Timestamp.Unix()), timestamp) //nolint:gosec
+		require.True(t, backend.relay.srvShutdown.Load())
+	})
+
+	t.Run("flushes pending block submissions on shutdown", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		backend.relay.srvs = []*http.Server{{}} //nolint:gosec
+		db := &blockSubmissionRecorderDB{}
+		backend.relay.db = db
+
+		prevShutdownWaitDuration := apiShutdownWaitDuration
+		apiShutdownWaitDuration = 0
+		t.Cleanup(func() { apiShutdownWaitDuration = prevShutdownWaitDuration })
+		for i := range 3 {
+			payload, _, _ := common.CreateTestBlockSubmission(t, testBuilderPubkey, uint256.NewInt(uint64(i)), &common.CreateTestBlockSubmissionOpts{Slot: testSlot}) //nolint:gosec
+			backend.relay.blockSubmissionC <- &blockSubmissionDBTask{
+				payload:     payload,
+				simResult:   &blockSimResult{wasSimulated: true, validationErr: errFake},
+				savePayload: i != 1,
+				log:         backend.relay.log,
+			}
+		}
+		backend.relay.blockSubmissionProcessorWG.Add(1)
+		go backend.relay.startBlockSubmissionDBProcessor()
+
+		err := backend.relay.StopServer()
+		require.NoError(t, err)
+
+		require.Len(t, db.submissions, 3)
+		require.Len(t, db.execPayloads, 3)
+		require.Nil(t, db.execPayloads[1])
+		for i, submission := range db.submissions {
+			require.Equal(t, testSlot, submission.Slot)
+			require.Equal(t, strconv.Itoa(i), submission.Value.String())
+			require.False(t, submission.SimSuccess)
+			require.Equal(t, errFake.Error(), submission.SimError)
+		}
+	})
+
+	t.Run("flushes pending getHeader log entries on shutdown", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		backend.relay.srvs = []*http.Server{{}} //nolint:gosec
+		db := &getHeaderLogRecorderDB{}
+		backend.relay.db = db
+
+		prevShutdownWaitDuration, prevBatchSize := apiShutdownWaitDuration, getHeaderLogDBBatchSize
+		apiShutdownWaitDuration, getHeaderLogDBBatchSize = 0, 2
+		t.Cleanup(func() { apiShutdownWaitDuration, getHeaderLogDBBatchSize = prevShutdownWaitDuration, prevBatchSize })
+		for i := range 3 {
+			backend.relay.getHeaderLogC <- database.GetHeaderLogEntry{Slot: uint64(i)} //nolint:gosec
+		}
+		backend.relay.getHeaderLogProcessorWG.Add(1)
+		go backend.relay.startGetHeaderLogDBProcessor()
+
+		err := backend.relay.StopServer()
+		require.NoError(t, err)
+
+		// the entries already waiting are saved together, up to the batch size
+		require.Len(t, db.batches, 2)
+		require.Len(t, db.batches[0], 2)
+		require.Equal(t, uint64(2), db.batches[1][0].Slot)
+	})
+}
+
+func TestSeparateListeners(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.opts.BuilderListener = &ListenerOpts{ListenAddr: "localhost:12346", WriteTimeout: time.Minute}
+
+	servers := backend.relay.newServers()
+	require.Len(t, servers, 2)
+	require.Equal(t, "localhost:12345", servers[0].Addr)
+	require.Equal(t, "localhost:12346", servers[1].Addr)
+	require.Equal(t, time.Minute, servers[1].WriteTimeout)
+	require.Equal(t, backend.relay.opts.ReadTimeout, servers[1].ReadTimeout)
+
+	request := func(srv *http.Server, path string) int {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	// the builder API is only served by its own listener, which also serves the health checks
+	require.Equal(t, http.StatusNotFound, request(servers[0], pathBuilderGetValidators))
+	require.Equal(t, http.StatusOK, request(servers[1], pathBuilderGetValidators))
+	require.Equal(t, http.StatusOK, request(servers[1], "/livez"))
+	require.Equal(t, http.StatusNotFound, request(servers[1], "/"))
+	require.Equal(t, http.StatusNotFound, request(servers[1], pathInternalFeatureFlags))
+
+	// the other APIs are served by the main listener
+	require.Equal(t, http.StatusOK, request(servers[0], pathStatus))
+	require.Equal(t, http.StatusNotFound, request(servers[1], pathStatus))
+}
+
+func TestListenAll(t *testing.T) {
+	inUse, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	defer inUse.Close()
+
+	free, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	freeAddr := free.Addr().String()
+	require.NoError(t, free.Close())
+
+	// no listener is left open if one of the addresses can't be bound
+	srvs := []*http.Server{{Addr: freeAddr}, {Addr: inUse.Addr().String()}} //nolint:gosec
+	_, err = listenAll(srvs)
+	require.Error(t, err)
+	free, err = net.Listen("tcp", freeAddr)
+	require.NoError(t, err)
+	require.NoError(t, free.Close())
+
+	srvs = []*http.Server{{Addr: "localhost:0"}, {Addr: "localhost:0"}} //nolint:gosec
+	listeners, err := listenAll(srvs)
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+	for _, ln := range listeners {
+		require.NoError(t, ln.Close())
+	}
 }
 
 func TestWebserverRootHandler(t *testing.T) {
@@ -190,6 +364,16 @@ func TestLivez(t *testing.T) {
 	require.JSONEq(t, "{\"message\":\"live\"}\n", rr.Body.String())
 }
 
+func TestMetrics(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	rr := backend.request(http.MethodGet, "/livez", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	rr = backend.request(http.MethodGet, "/metrics", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), `route="/livez",statusCode="200"`)
+}
+
 func TestRegisterValidator(t *testing.T) {
 	path := "/eth/v1/builder/validators"
 
@@ -221,6 +405,216 @@ func TestRegisterValidator(t *testing.T) {
 		default:
 		}
 	})
+
+	t.Run("many validators", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		regs := make([]builderApiV1.SignedValidatorRegistration, 20)
+		for i := range regs {
+			regs[i] = signedTestRegistration(t, backend, uint64(i)) //nolint:gosec
+		}
+
+		rr := backend.request(http.MethodPost, path, regs)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Len(t, backend.relay.validatorRegC, len(regs))
+	})
+
+	t.Run("many validators with an invalid signature", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		regs := make([]builderApiV1.SignedValidatorRegistration, 20)
+		for i := range regs {
+			regs[i] = signedTestRegistration(t, backend, uint64(i)) //nolint:gosec
+		}
+		regs[10].Signature = regs[11].Signature
+		regs[15].Message.Timestamp = time.Now().Add(time.Hour)
+
+		// all other registrations are processed, and the failed ones are reported
+		rr := backend.request(http.MethodPost, path, regs)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Len(t, backend.relay.validatorRegC, len(regs)-2)
+
+		resp := RegisterValidatorErrorResp{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+		require.Equal(t, ErrorCodeRegistrationsFailed, resp.ErrorCode)
+		require.Equal(t, []RegisterValidatorFailure{
+			{Index: 10, Pubkey: regs[10].Message.Pubkey.String(), ErrorCode: ErrorCodeInvalidSignature, Error: "invalid validator signature"},
+			{Index: 15, Pubkey: regs[15].Message.Pubkey.String(), ErrorCode: ErrorCodeInvalidTimestamp, Error: "timestamp too far in the future"},
+		}, resp.Failures)
+	})
+
+	t.Run("gzip request", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		regs := []builderApiV1.SignedValidatorRegistration{signedTestRegistration(t, backend, 0), signedTestRegistration(t, backend, 1)}
+		jsonBytes, err := json.Marshal(regs)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err = zw.Write(jsonBytes)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		rr := backend.requestBytes(http.MethodPost, path, buf.Bytes(), map[string]string{"Content-Encoding": "gzip"})
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Len(t, backend.relay.validatorRegC, len(regs))
+	})
+
+	t.Run("gzip request too large after decompression", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		regs := []builderApiV1.SignedValidatorRegistration{signedTestRegistration(t, backend, 0), signedTestRegistration(t, backend, 0)}
+		jsonBytes, err := json.Marshal(regs)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		_, err = zw.Write(jsonBytes)
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		// the compressed body is within the limit, the decompressed one is not
+		require.Less(t, buf.Len(), len(jsonBytes)-1)
+		prevMaxBodyBytes := registerValidatorMaxBodyBytes
+		registerValidatorMaxBodyBytes = len(jsonBytes) - 1
+		t.Cleanup(func() { registerValidatorMaxBodyBytes = prevMaxBodyBytes })
+
+		rr := backend.requestBytes(http.MethodPost, path, buf.Bytes(), map[string]string{"Content-Encoding": "gzip"})
+		require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+		require.Contains(t, rr.Body.String(), string(ErrorCodeRequestTooLarge))
+		require.Empty(t, backend.relay.validatorRegC)
+	})
+
+	t.Run("registration too old", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+		validatorRegMaxAgeDays = 1
+		t.Cleanup(func() { validatorRegMaxAgeDays = 0 })
+
+		reg := signedTestRegistration(t, backend, 0)
+		reg.Message.Timestamp = time.Now().Add(-48 * time.Hour)
+
+		rr := backend.request(http.MethodPost, path, []builderApiV1.SignedValidatorRegistration{reg})
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "timestamp too early")
+	})
+}
+
+func TestProposerPreferences(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	slot := uint64(100)
+	builderPubkey := phase0.BLSPubKey{0x01}
+
+	sk, blsPubkey, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+	pubkey, err := utils.BlsPublicKeyToPublicKey(blsPubkey)
+	require.NoError(t, err)
+
+	signPreferences := func(timestamp uint64) common.SignedProposerPreferences {
+		msg := &common.ProposerPreferences{
+			Pubkey:          pubkey,
+			Timestamp:       timestamp,
+			MinBidValue:     uint256.NewInt(100),
+			BlockedBuilders: []phase0.BLSPubKey{builderPubkey},
+		}
+		sig, err := ssz.SignMessage(msg, backend.relay.opts.EthNetDetails.DomainProposerPreferences, sk)
+		require.NoError(t, err)
+		return common.SignedProposerPreferences{Message: msg, Signature: sig}
+	}
+	timestamp := uint64(time.Now().Unix()) //nolint:gosec
+	preferences := signPreferences(timestamp)
+
+	// Preferences of unknown validators are rejected
+	rr := backend.request(http.MethodPost, pathProposerPreferences, preferences)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Contains(t, rr.Body.String(), "not a known validator")
+
+	// Preferences with an invalid signature are rejected
+	backend.datastore.SetKnownValidator(common.PubkeyHex(pubkey.String()), 1)
+	invalidPreferences := signPreferences(timestamp)
+	invalidPreferences.Message.MinBidValue = uint256.NewInt(1)
+	rr = backend.request(http.MethodPost, pathProposerPreferences, invalidPreferences)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Contains(t, rr.Body.String(), "invalid signature")
+
+	// Preferences signed with the builder domain are rejected
+	builderDomainPreferences := signPreferences(timestamp)
+	builderDomainPreferences.Signature, err = ssz.SignMessage(builderDomainPreferences.Message, backend.relay.opts.EthNetDetails.DomainBuilder, sk)
+	require.NoError(t, err)
+	rr = backend.request(http.MethodPost, pathProposerPreferences, builderDomainPreferences)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Contains(t, rr.Body.String(), "invalid signature")
+
+	// Valid preferences are saved, and can't be replayed
+	rr = backend.request(http.MethodPost, pathProposerPreferences, preferences)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = backend.request(http.MethodPost, pathProposerPreferences, preferences)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = backend.request(http.MethodPost, pathProposerPreferences, signPreferences(timestamp+1))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// The preferences are attached to the proposer duties
+	err = backend.redis.SetProposerDuties([]common.BuilderGetValidatorsResponseEntry{{
+		Slot:           slot,
+		ValidatorIndex: 1,
+		Entry: &builderApiV1.SignedValidatorRegistration{
+			Message: &builderApiV1.ValidatorRegistration{Pubkey: pubkey},
+		},
+	}})
+	require.NoError(t, err)
+	backend.relay.UpdateProposerDutiesWithoutChecks(slot - 1)
+	slotDuty := backend.relay.proposerDutiesMap[slot]
+	require.NotNil(t, slotDuty.Preferences)
+	require.True(t, slotDuty.HasPreferences)
+	require.Equal(t, timestamp+1, slotDuty.Preferences.Timestamp)
+
+	// Submissions of blocked builders are rejected
+	feeRecipient := ethcommon.HexToAddress("0x1111111111111111111111111111111111111111")
+	paymentTx, _ := signedTestTransaction(t, feeRecipient, 1)
+	submission := &common.BlockSubmissionInfo{
+		BidTrace:     &builderApiV1.BidTrace{Slot: slot, ProposerPubkey: pubkey, BuilderPubkey: builderPubkey, ProposerFeeRecipient: bellatrix.ExecutionAddress(feeRecipient)},
+		GasUsed:      30000000,
+		Transactions: []bellatrix.Transaction{paymentTx},
+	}
+	w := httptest.NewRecorder()
+	require.False(t, backend.relay.checkSubmissionProposerPreferences(w, common.TestLog, submission))
+	require.Equal(t, http.StatusForbidden, w.Code)
+	submission.BidTrace.BuilderPubkey = phase0.BLSPubKey{0x02}
+	require.True(t, backend.relay.checkSubmissionProposerPreferences(httptest.NewRecorder(), common.TestLog, submission))
+
+	// Submissions using more gas than the maximum of the proposer are rejected
+	slotDuty.Preferences.MaxGasUsed = 20000000
+	w = httptest.NewRecorder()
+	require.False(t, backend.relay.checkSubmissionProposerPreferences(w, common.TestLog, submission))
+	require.Contains(t, w.Body.String(), string(ErrorCodeProposerPreferencesViolated))
+	submission.GasUsed = 20000000
+	require.True(t, backend.relay.checkSubmissionProposerPreferences(httptest.NewRecorder(), common.TestLog, submission))
+
+	// Submissions have to end with the proposer payment if the proposer requires it
+	slotDuty.Preferences.RequirePaymentLast = true
+	require.True(t, backend.relay.checkSubmissionProposerPreferences(httptest.NewRecorder(), common.TestLog, submission))
+	otherTx, _ := signedTestTransaction(t, ethcommon.Address{0x02}, 1)
+	submission.Transactions = append(submission.Transactions, otherTx)
+	w = httptest.NewRecorder()
+	require.False(t, backend.relay.checkSubmissionProposerPreferences(w, common.TestLog, submission))
+	require.Contains(t, w.Body.String(), ErrProposerPaymentNotLast.Error())
+}
+
+// signedTestRegistration returns a registration of a new known validator, signed with the builder domain of the backend
+func signedTestRegistration(t *testing.T, backend *testBackend, index uint64) builderApiV1.SignedValidatorRegistration {
+	t.Helper()
+	sk, blsPubkey, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+	pubkey, err := utils.BlsPublicKeyToPublicKey(blsPubkey)
+	require.NoError(t, err)
+
+	msg := &builderApiV1.ValidatorRegistration{
+		FeeRecipient: bellatrix.ExecutionAddress{0x02},
+		GasLimit:     30000000,
+		Timestamp:    time.Unix(time.Now().Unix(), 0),
+		Pubkey:       pubkey,
+	}
+	sig, err := ssz.SignMessage(msg, backend.relay.opts.EthNetDetails.DomainBuilder, sk)
+	require.NoError(t, err)
+	backend.datastore.SetKnownValidator(common.PubkeyHex(pubkey.String()), index)
+	return builderApiV1.SignedValidatorRegistration{Message: msg, Signature: sig}
 }
 
 func TestGetHeader(t *testing.T) {
@@ -293,9 +687,81 @@ func TestGetHeader(t *testing.T) {
 	require.Equal(t, spec.DataVersionDeneb, resp.Version)
 	require.Equal(t, bidValue.String(), value.String())
 
-	// Check 3: Request returns 204 if sending a filtered user agent
+	// Check 3: deneb request accepting SSZ returns the SSZ encoded bid
+	rr = backend.requestBytes(http.MethodGet, path, nil, map[string]string{"Accept": ApplicationOctetStream})
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, ApplicationOctetStream, rr.Header().Get("Content-Type"))
+	require.Equal(t, spec.DataVersionDeneb.String(), rr.Header().Get(HeaderEthConsensusVersion))
+	sszBid := new(builderApiDeneb.SignedBuilderBid)
+	require.NoError(t, sszBid.UnmarshalSSZ(rr.Body.Bytes()))
+	require.Equal(t, bidValue.String(), sszBid.Message.Value.String())
+
+	// Check 4: Request returns 204 if sending a filtered user agent
 	rr = backend.requestWithUA(http.MethodGet, path, "mev-boost/v1.5.0 Go-http-client/1.1", nil)
 	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	// Check 5: Request returns 204 while the circuit breaker is tripped, until it is reset
+	err = backend.redis.SetCircuitBreakerState(&common.CircuitBreakerState{LastCheckedSlot: slot, NumMissedSlots: 3, IsTripped: true})
+	require.NoError(t, err)
+	backend.relay.updateCircuitBreaker()
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = backend.request(http.MethodPost, pathInternalCircuitBreaker, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	state, err := backend.redis.GetCircuitBreakerState()
+	require.NoError(t, err)
+	require.Equal(t, common.CircuitBreakerState{LastCheckedSlot: slot, NumMissedSlots: 0, IsTripped: false}, *state)
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Check 6: Request returns 204 if the bid is below the minimum bid
+	backend.relay.opts.MinBid = uint256.NewInt(100)
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	backend.relay.opts.MinBid = bidValue
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Check 7: Request returns 204 if the bid is below the proposer's own minimum bid
+	backend.relay.proposerDutiesMap = map[uint64]*common.BuilderGetValidatorsResponseEntry{
+		slot + 1: {
+			Slot:        slot + 1,
+			Preferences: &common.ProposerPreferences{Pubkey: phase0.BLSPubKey(hexutil.MustDecode(proposerPubkey)), MinBidValue: uint256.NewInt(100)},
+		},
+	}
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	backend.relay.proposerDutiesMap[slot+1].Preferences.MinBidValue = bidValue
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Check 7b: Request returns 204 if the top bid is of a builder the proposer blocked
+	blockHash, err := getHeaderResp.BlockHash()
+	require.NoError(t, err)
+	blockedBuilderPubkey := phase0.BLSPubKey(hexutil.MustDecode(builderPubkey))
+	pipe := backend.redis.NewPipeline()
+	err = backend.redis.SaveBidTrace(t.Context(), pipe, &common.BidTraceV2WithBlobFields{BidTrace: builderApiV1.BidTrace{
+		Slot:           slot + 1,
+		ProposerPubkey: phase0.BLSPubKey(hexutil.MustDecode(proposerPubkey)),
+		BuilderPubkey:  blockedBuilderPubkey,
+		BlockHash:      blockHash,
+		Value:          bidValue,
+	}})
+	require.NoError(t, err)
+	_, err = pipe.Exec(t.Context())
+	require.NoError(t, err)
+	backend.relay.proposerDutiesMap[slot+1].Preferences.BlockedBuilders = []phase0.BLSPubKey{blockedBuilderPubkey}
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code)
+	backend.relay.proposerDutiesMap[slot+1].Preferences.BlockedBuilders = []phase0.BLSPubKey{{0x01}}
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// Check 8: Request returns 204 if sent after the cutoff time into the slot
+	backend.relay.genesisInfo.Data.GenesisTime = uint64(time.Now().UTC().Unix()) - (slot+1)*common.SecondsPerSlot - uint64(getHeaderRequestCutoffMs/1000) - 1 //nolint:gosec
+	rr = backend.request(http.MethodGet, path, nil)
+	require.Equal(t, http.StatusNoContent, rr.Code)
 }
 
 func TestBuilderApiGetValidators(t *testing.T) {
@@ -307,10 +773,16 @@ func TestBuilderApiGetValidators(t *testing.T) {
 			Slot:  1,
 			Entry: &common.ValidPayloadRegisterValidator,
 		},
+		{
+			Slot:  2,
+			Entry: &common.ValidPayloadRegisterValidator,
+		},
 	}
-	responseBytes, err := json.Marshal(duties)
+	err := backend.redis.SetProposerDuties(duties)
 	require.NoError(t, err)
-	backend.relay.proposerDutiesResponse = &responseBytes
+	parent := &common.ProposerDutyParent{ParentHash: testParentHash, BlockNumber: 100, PrevRandao: testPrevRandao}
+	backend.relay.proposerDutiesParents[2] = parent
+	backend.relay.UpdateProposerDutiesWithoutChecks(0)
 
 	rr := backend.request(http.MethodGet, path, nil)
 	require.Equal(t, http.StatusOK, rr.Code)
@@ -318,9 +790,23 @@ func TestBuilderApiGetValidators(t *testing.T) {
 	resp := []common.BuilderGetValidatorsResponseEntry{}
 	err = json.Unmarshal(rr.Body.Bytes(), &resp)
 	require.NoError(t, err)
-	require.Len(t, resp, 1)
+	require.Len(t, resp, 2)
 	require.Equal(t, uint64(1), resp[0].Slot)
 	require.Equal(t, common.ValidPayloadRegisterValidator, *resp[0].Entry)
+	require.Equal(t, common.ValidPayloadRegisterValidator.Message.GasLimit, resp[0].GasLimit)
+	require.False(t, resp[0].HasPreferences)
+	require.Nil(t, resp[0].Parent)
+	require.Equal(t, parent, resp[1].Parent)
+
+	// the parent block of a slot is added once its payload attributes are known
+	backend.relay.proposerDutiesLock.Lock()
+	backend.relay.proposerDutiesParents[1] = parent
+	backend.relay.updateProposerDutiesResponse()
+	backend.relay.proposerDutiesLock.Unlock()
+	rr = backend.request(http.MethodGet, path, nil)
+	resp = []common.BuilderGetValidatorsResponseEntry{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, parent, resp[0].Parent)
 }
 
 func TestDataApiGetDataProposerPayloadDelivered(t *testing.T) {
@@ -354,6 +840,269 @@ func TestDataApiGetDataProposerPayloadDelivered(t *testing.T) {
 			require.Contains(t, rr.Body.String(), "invalid block_hash argument")
 		}
 	})
+
+	t.Run("Slot range", func(t *testing.T) {
+		backend := newTestBackend(t, 1)
+
+		for _, query := range []string{"from_slot=10&to_slot=20", "from_ts=1606824023", "epoch=10", "cursor=20&from_slot=10"} {
+			rr := backend.request(http.MethodGet, path+"?"+query, nil)
+			require.Equal(t, http.StatusOK, rr.Code, query)
+		}
+
+		for _, query := range []string{"from_slot=20&to_slot=10", "from_slot=10&epoch=1", "slot=15&from_slot=10", "to_ts=abc"} {
+			rr := backend.request(http.MethodGet, path+"?"+query, nil)
+			require.Equal(t, http.StatusBadRequest, rr.Code, query)
+		}
+	})
+}
+
+func TestDataApiGetDataBuilderBidsReceived(t *testing.T) {
+	backend := newTestBackend(t, 1)
+
+	// A cursor is enough to page through all submissions, starting with the latest ones
+	rr := backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?cursor=latest", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?cursor=1000", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	for _, cursor := range []string{"abc", "0", "-1"} {
+		rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?cursor="+cursor, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code)
+		require.Contains(t, rr.Body.String(), "invalid cursor argument")
+	}
+
+	rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived, nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Submissions which failed the simulation are included on request, with the simulation results
+	backend.relay.db = database.MockDB{
+		BuilderSubmissions: []*database.BuilderBlockSubmissionEntry{
+			{ID: 2, Slot: 1, WasSimulated: true, SimSuccess: false, SimError: "invalid block"},
+			{ID: 1, Slot: 1, WasSimulated: true, SimSuccess: true},
+		},
+	}
+	rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?slot=1", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotContains(t, rr.Body.String(), "sim_")
+
+	rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?slot=1&include_sim_errors=true", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := []common.BidTraceV2WithTimestampJSON{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp, 2)
+	require.False(t, *resp[0].SimSuccess)
+	require.Equal(t, "invalid block", resp[0].SimError)
+	require.True(t, *resp[1].SimSuccess)
+
+	rr = backend.request(http.MethodGet, pathDataBuilderBidsReceived+"?slot=1&include_sim_errors=abc", nil)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestDataApiValidatorRegistrations(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	reg1 := signedTestRegistration(t, backend, 1)
+	reg2 := signedTestRegistration(t, backend, 2)
+	unregistered := signedTestRegistration(t, backend, 3)
+	entry1 := database.SignedValidatorRegistrationToEntry(reg1)
+	entry2 := database.SignedValidatorRegistrationToEntry(reg2)
+	backend.relay.db = database.MockDB{ValidatorRegistrations: []*database.ValidatorRegistrationEntry{&entry1, &entry2}}
+
+	pubkeys := []string{reg1.Message.Pubkey.String(), reg2.Message.Pubkey.String(), unregistered.Message.Pubkey.String()}
+	checkResponse := func(rr *httptest.ResponseRecorder) {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rr.Code)
+		resp := []builderApiV1.SignedValidatorRegistration{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+		require.Len(t, resp, 2)
+		require.Equal(t, reg1.Message.Pubkey, resp[0].Message.Pubkey)
+		require.Equal(t, reg2.Message.Pubkey, resp[1].Message.Pubkey)
+	}
+
+	// comma separated, with a duplicate
+	checkResponse(backend.request(http.MethodGet, pathDataValidatorRegistration+"?pubkey="+strings.Join(append(pubkeys, pubkeys[0]), ","), nil))
+
+	// POST body
+	checkResponse(backend.request(http.MethodPost, pathDataValidatorRegistration, pubkeys))
+
+	rr := backend.request(http.MethodPost, pathDataValidatorRegistration, []string{"0x1234"})
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = backend.request(http.MethodPost, pathDataValidatorRegistration, []string{})
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = backend.request(http.MethodPost, pathDataValidatorRegistration, make([]string, dataValidatorRegistrationsMaxPubkeys+1))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// the body limit allows the maximum number of pubkeys, also indented
+	maxPubkeys := make([]string, dataValidatorRegistrationsMaxPubkeys)
+	for i := range maxPubkeys {
+		maxPubkeys[i] = pubkeys[i%len(pubkeys)]
+	}
+	body, err := json.MarshalIndent(maxPubkeys, "", "    ")
+	require.NoError(t, err)
+	checkResponse(backend.requestBytes(http.MethodPost, pathDataValidatorRegistration, body, nil))
+
+	body = append(body, bytes.Repeat([]byte(" "), dataValidatorRegistrationsMaxBodyBytes)...)
+	rr = backend.requestBytes(http.MethodPost, pathDataValidatorRegistration, body, nil)
+	require.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestDataApiValidatorRegistrationHistory(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	reg := signedTestRegistration(t, backend, 1)
+	entry1 := database.SignedValidatorRegistrationToEntry(reg)
+	entry2 := entry1
+	entry2.Timestamp = entry1.Timestamp - 1
+	entry2.FeeRecipient = testAddress2.String()
+	backend.relay.db = database.MockDB{ValidatorRegistrations: []*database.ValidatorRegistrationEntry{&entry1, &entry2}}
+
+	rr := backend.request(http.MethodGet, pathDataValidatorRegHistory+"?pubkey="+reg.Message.Pubkey.String(), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := []builderApiV1.SignedValidatorRegistration{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp, 2)
+	require.Equal(t, testAddress2, resp[1].Message.FeeRecipient)
+
+	rr = backend.request(http.MethodGet, pathDataValidatorRegHistory+"?pubkey="+reg.Message.Pubkey.String()+"&limit=1", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp, 1)
+
+	for _, query := range []string{"", "?pubkey=0x1234", "?pubkey=" + reg.Message.Pubkey.String() + "&limit=501"} {
+		rr = backend.request(http.MethodGet, pathDataValidatorRegHistory+query, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestDataApiStats(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	backend.relay.db = database.MockDB{
+		DeliveredPayloadDailyStats: []*database.DeliveredPayloadDailyStatsEntry{
+			{Day: today.AddDate(0, 0, -40), BuilderPubkey: "0xa", NumPayloads: 1, TotalValue: "100"},
+			{Day: today, BuilderPubkey: "0xa", NumPayloads: 2, TotalValue: "200"},
+		},
+	}
+
+	// the last 30 days by default
+	rr := backend.request(http.MethodGet, pathDataStats, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := new(DataStatsResponse)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+	require.Equal(t, today.AddDate(0, 0, -29).Format(time.DateOnly), resp.FromDay)
+	require.Equal(t, []DataStatsBuilderEntry{{BuilderPubkey: "0xa", NumPayloads: 2, TotalValue: "200"}}, resp.Builders)
+
+	rr = backend.request(http.MethodGet, pathDataStats+"?from_day="+today.AddDate(0, 0, -40).Format(time.DateOnly), nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), resp))
+	require.Len(t, resp.Days, 2)
+
+	for _, query := range []string{"?from_day=2024-13-01", "?to_day=abc", "?from_day=2024-01-02&to_day=2024-01-01", "?from_day=2022-12-31&to_day=2024-01-01"} {
+		rr = backend.request(http.MethodGet, pathDataStats+query, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+	rr = backend.request(http.MethodGet, pathDataStats+"?from_day=2023-01-01&to_day=2024-01-01", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDataApiBuilderStats(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	builderPubkey := "0xb872a4f5f596ea7dfd695e45afbe4551b405b10dafba98b2d897c58a5047fc288ef2c1bc4216f906ea05d7fdbed61116"
+	backend.relay.db = database.MockDB{
+		BuilderEpochStats: []*database.BuilderEpochStatsEntry{
+			{Epoch: 10, Day: today.AddDate(0, 0, -40), BuilderPubkey: builderPubkey, NumSubmissions: 5, NumPayloads: 1, TotalValue: "100"},
+			{Epoch: 20, Day: today, BuilderPubkey: builderPubkey, NumSubmissions: 3, NumSimErrors: 1, TotalValue: "0"},
+		},
+	}
+
+	// per day, the last 30 days by default
+	rr := backend.request(http.MethodGet, pathDataBuilderStats, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var dailyResp []DataBuilderDailyStatsEntry
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &dailyResp))
+	require.Equal(t, []DataBuilderDailyStatsEntry{{Day: today.Format(time.DateOnly), BuilderPubkey: builderPubkey, NumSubmissions: 3, NumSimErrors: 1, TotalValue: "0"}}, dailyResp)
+
+	// per epoch
+	rr = backend.request(http.MethodGet, pathDataBuilderStats+"?from_epoch=5&to_epoch=15&builder_pubkey="+builderPubkey, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var epochResp []DataBuilderEpochStatsEntry
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &epochResp))
+	require.Len(t, epochResp, 1)
+	require.Equal(t, uint64(10), epochResp[0].Epoch)
+	require.Equal(t, uint64(5), epochResp[0].NumSubmissions)
+
+	// summed up over the pubkeys of a builder identity
+	otherPubkey := "0xa1885d66bef164889a2e35845c3b626545d7b0e513efe335e97c3a45e534013fa3bc38c3b7e6143695aecc4872ac52c4"
+	backend.relay.db = database.MockDB{
+		Builders: map[string]*database.BlockBuilderEntry{
+			builderPubkey: {BuilderPubkey: builderPubkey, BuilderIdentity: "builder"},
+			otherPubkey:   {BuilderPubkey: otherPubkey, BuilderIdentity: "builder"},
+		},
+		BuilderEpochStats: []*database.BuilderEpochStatsEntry{
+			{Epoch: 20, Day: today, BuilderPubkey: builderPubkey, NumSubmissions: 3, NumSimErrors: 1, NumPayloads: 1, TotalValue: "100"},
+			{Epoch: 20, Day: today, BuilderPubkey: otherPubkey, NumSubmissions: 2, TotalValue: "0"},
+		},
+	}
+	rr = backend.request(http.MethodGet, pathDataBuilderStats+"?from_epoch=20&to_epoch=20&builder_id=builder", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	epochResp = nil
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &epochResp))
+	require.Equal(t, []DataBuilderEpochStatsEntry{{Epoch: 20, BuilderID: "builder", NumSubmissions: 5, NumSimErrors: 1, NumPayloads: 1, TotalValue: "100"}}, epochResp)
+
+	for _, query := range []string{"?builder_pubkey=0x123", "?builder_pubkey=" + builderPubkey + "&builder_id=builder", "?from_day=abc", "?from_epoch=abc", "?from_epoch=2&to_epoch=1", "?from_epoch=0&to_epoch=1000"} {
+		rr = backend.request(http.MethodGet, pathDataBuilderStats+query, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestDataApiGetHeaderLog(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	requestedAt := time.Now().UTC()
+	backend.relay.db = database.MockDB{
+		GetHeaderLog: []*database.GetHeaderLogEntry{
+			{Slot: 2, BlockHash: "0x02", Value: "200", UserAgent: "mev-boost/v1.9", RequestedAt: requestedAt, LatencyMs: 5},
+			{Slot: 1, BlockHash: "0x01", Value: "100"},
+		},
+	}
+
+	rr := backend.request(http.MethodGet, pathDataGetHeaderLog+"?slot=2", nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := []common.GetHeaderLogJSON{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Equal(t, []common.GetHeaderLogJSON{{Slot: 2, BlockHash: "0x02", Value: "200", UserAgent: "mev-boost/v1.9", TimestampMs: requestedAt.UnixMilli(), LatencyMs: 5}}, resp)
+
+	for _, query := range []string{"", "?slot=abc", "?proposer_pubkey=0x01", "?block_hash=0x01", "?slot=1&limit=501"} {
+		rr = backend.request(http.MethodGet, pathDataGetHeaderLog+query, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+func TestDataApiGetPayloadFailures(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	backend.relay.db = database.MockDB{
+		GetPayloadFailures: []*database.GetPayloadFailureEntry{
+			{Slot: 2, Reason: database.GetPayloadFailureTooLate, Error: "sent too late - 4100 ms into slot", MsIntoSlot: 4100},
+			{Slot: 1, Reason: database.GetPayloadFailureEquivocation},
+		},
+	}
+
+	// failures of all slots are returned without filter, newest first
+	rr := backend.request(http.MethodGet, pathDataGetPayloadFailures, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	resp := []common.GetPayloadFailureJSON{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp, 2)
+
+	rr = backend.request(http.MethodGet, pathDataGetPayloadFailures+"?reason="+database.GetPayloadFailureTooLate, nil)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp, 1)
+	require.Equal(t, int64(4100), resp[0].MsIntoSlot)
+	require.Equal(t, "sent too late - 4100 ms into slot", resp[0].Error)
+
+	for _, query := range []string{"?slot=abc", "?proposer_pubkey=0x01", "?reason=unknown", "?reason=" + database.GetPayloadFailureInvalidSignature, "?limit=501"} {
+		rr = backend.request(http.MethodGet, pathDataGetPayloadFailures+query, nil)
+		require.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
 }
 
 func TestBuilderSubmitBlockSSZ(t *testing.T) {
@@ -695,6 +1444,15 @@ func TestProcessPayloadAttrs(t *testing.T) {
 		backend.relay.payloadAttributesLock.RUnlock()
 		require.Equal(t, expectedAttrs, attrs)
 
+		// the parent block is added to the proposer duties
+		require.Equal(t, &common.ProposerDutyParent{
+			ParentHash:            testParentHash,
+			BlockNumber:           1,
+			PrevRandao:            testPrevRandao,
+			WithdrawalsRoot:       testWithdrawalsRoot,
+			ParentBeaconBlockRoot: testParentHash,
+		}, backend.relay.proposerDutiesParents[testSlot])
+
 		attrsEvent.Data.ProposalSlot = testSlot + 1
 		backend.relay.processPayloadAttributes(attrsEvent)
 		backend.relay.payloadAttributesLock.RLock()
@@ -702,6 +1460,10 @@ func TestProcessPayloadAttrs(t *testing.T) {
 		backend.relay.payloadAttributesLock.RUnlock()
 		expectedAttrs.slot = testSlot + 1
 		require.Equal(t, expectedAttrs, attrs)
+
+		// submissions for the parent slot count as past, even before its head event is processed
+		require.Less(t, backend.relay.headSlot.Load(), testSlot)
+		require.Equal(t, testSlot, backend.relay.latestHeadSlot())
 	})
 
 	t.Run("Skips payload attribute update with same parent hash and slot", func(t *testing.T) {
@@ -753,6 +1515,59 @@ func TestProcessPayloadAttrs(t *testing.T) {
 	})
 }
 
+func TestEnsureProposerDutyForSlot(t *testing.T) {
+	_, _, backend := startTestBackend(t)
+	headSlot := backend.relay.headSlot.Load()
+	nextSlot := headSlot + 2 // ahead of the head event
+
+	err := backend.relay.redis.SetProposerDuties([]common.BuilderGetValidatorsResponseEntry{
+		{Slot: headSlot + 1, ValidatorIndex: 1},
+		{Slot: nextSlot, ValidatorIndex: 2},
+	})
+	require.NoError(t, err)
+
+	backend.relay.ensureProposerDutyForSlot(nextSlot)
+	backend.relay.proposerDutiesLock.RLock()
+	duty := backend.relay.proposerDutiesMap[nextSlot]
+	backend.relay.proposerDutiesLock.RUnlock()
+	require.NotNil(t, duty)
+	require.Equal(t, uint64(2), duty.ValidatorIndex)
+
+	// no update if the duty is already known
+	err = backend.relay.redis.SetProposerDuties([]common.BuilderGetValidatorsResponseEntry{})
+	require.NoError(t, err)
+	backend.relay.ensureProposerDutyForSlot(nextSlot)
+	backend.relay.proposerDutiesLock.RLock()
+	duty = backend.relay.proposerDutiesMap[nextSlot]
+	backend.relay.proposerDutiesLock.RUnlock()
+	require.NotNil(t, duty)
+}
+
+func TestUpdateProposerDutiesUntilNextEpochKnown(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	headSlot := uint64(common.SlotsPerEpoch*10 + 25)
+	nextEpochSlot := uint64(common.SlotsPerEpoch * 11)
+
+	// only duties of the current epoch are known
+	err := backend.relay.redis.SetProposerDuties([]common.BuilderGetValidatorsResponseEntry{{Slot: headSlot + 1}})
+	require.NoError(t, err)
+	backend.relay.updateProposerDuties(headSlot)
+	require.Equal(t, headSlot+1, backend.relay.proposerDutiesMaxSlot)
+
+	// reloaded on the next slot, because the next epoch is not yet covered
+	err = backend.relay.redis.SetProposerDuties([]common.BuilderGetValidatorsResponseEntry{{Slot: headSlot + 2}, {Slot: nextEpochSlot}})
+	require.NoError(t, err)
+	backend.relay.updateProposerDuties(headSlot + 1)
+	require.Equal(t, nextEpochSlot, backend.relay.proposerDutiesMaxSlot)
+	require.NotNil(t, backend.relay.proposerDutiesMap[nextEpochSlot])
+
+	// not reloaded anymore until the next regular update
+	err = backend.relay.redis.SetProposerDuties([]common.BuilderGetValidatorsResponseEntry{})
+	require.NoError(t, err)
+	backend.relay.updateProposerDuties(headSlot + 2)
+	require.NotNil(t, backend.relay.proposerDutiesMap[nextEpochSlot])
+}
+
 func TestCheckSubmissionPayloadAttrs(t *testing.T) {
 	withdrawalsRoot, err := utils.HexToHash(testWithdrawalsRoot)
 	require.NoError(t, err)
@@ -1027,6 +1842,77 @@ func TestCheckSubmissionSlotDetails(t *testing.T) {
 	}
 }
 
+func TestDataVersionAtSlot(t *testing.T) {
+	_, _, backend := startTestBackend(t)
+	backend.relay.capellaEpoch = 1
+	backend.relay.denebEpoch = 2
+	backend.relay.electraEpoch = -1
+
+	require.Equal(t, spec.DataVersionBellatrix, backend.relay.dataVersionAtSlot(0))
+	require.Equal(t, spec.DataVersionCapella, backend.relay.dataVersionAtSlot(common.SlotsPerEpoch))
+	require.Equal(t, spec.DataVersionDeneb, backend.relay.dataVersionAtSlot(2*common.SlotsPerEpoch))
+	require.Equal(t, spec.DataVersionDeneb, backend.relay.dataVersionAtSlot(100*common.SlotsPerEpoch))
+
+	backend.relay.electraEpoch = 3
+	require.Equal(t, spec.DataVersionElectra, backend.relay.dataVersionAtSlot(3*common.SlotsPerEpoch))
+}
+
+func TestCheckSubmissionSlotCutoff(t *testing.T) {
+	submission := &common.BlockSubmissionInfo{
+		BidTrace: &builderApiV1.BidTrace{
+			Slot: testSlot,
+		},
+	}
+	slotStart := time.Unix(int64(testSlot*common.SecondsPerSlot), 0) //nolint:gosec
+	cases := []struct {
+		description string
+		cutoffMs    int
+		receivedAt  time.Time
+		expectOk    bool
+	}{
+		{
+			description: "disabled",
+			cutoffMs:    0,
+			receivedAt:  slotStart.Add(10 * time.Second),
+			expectOk:    true,
+		},
+		{
+			description: "before_slot_start",
+			cutoffMs:    2000,
+			receivedAt:  slotStart.Add(-1 * time.Second),
+			expectOk:    true,
+		},
+		{
+			description: "before_cutoff",
+			cutoffMs:    2000,
+			receivedAt:  slotStart.Add(2 * time.Second),
+			expectOk:    true,
+		},
+		{
+			description: "failure_after_cutoff",
+			cutoffMs:    2000,
+			receivedAt:  slotStart.Add(2001 * time.Millisecond),
+			expectOk:    false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, _, backend := startTestBackend(t)
+			prevCutoff := submitBlockCutoffMs
+			submitBlockCutoffMs = tc.cutoffMs
+			defer func() { submitBlockCutoffMs = prevCutoff }()
+
+			w := httptest.NewRecorder()
+			log := logrus.NewEntry(logrus.New())
+			ok := backend.relay.checkSubmissionSlotCutoff(w, log, tc.receivedAt, submission)
+			require.Equal(t, tc.expectOk, ok)
+			if !ok {
+				require.Equal(t, http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
 func TestCheckBuilderEntry(t *testing.T) {
 	builderPubkey, err := utils.HexToPubkey(testBuilderPubkey)
 	require.NoError(t, err)
@@ -1079,7 +1965,7 @@ func TestCheckBuilderEntry(t *testing.T) {
 		t.Run(tc.description, func(t *testing.T) {
 			_, _, backend := startTestBackend(t)
 			backend.relay.blockBuildersCache[tc.pk.String()] = tc.entry
-			backend.relay.ffDisableLowPrioBuilders = true
+			backend.relay.ffDisableLowPrioBuilders.Store(true)
 			w := httptest.NewRecorder()
 			logger := logrus.New()
 			log := logrus.NewEntry(logger)
@@ -1089,6 +1975,46 @@ func TestCheckBuilderEntry(t *testing.T) {
 	}
 }
 
+func TestCheckBuilderEntryBlacklisted(t *testing.T) {
+	builderPubkey, err := utils.HexToPubkey(testBuilderPubkey)
+	require.NoError(t, err)
+	cases := []struct {
+		description  string
+		rejectBanned bool
+		expectCode   int
+	}{
+		{
+			description:  "silent_accept",
+			rejectBanned: false,
+			expectCode:   http.StatusOK,
+		},
+		{
+			description:  "reject_with_403",
+			rejectBanned: true,
+			expectCode:   http.StatusForbidden,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.description, func(t *testing.T) {
+			_, _, backend := startTestBackend(t)
+			backend.relay.blockBuildersCache[builderPubkey.String()] = &blockBuilderCacheEntry{
+				status: common.BuilderStatus{
+					IsBlacklisted: true,
+				},
+			}
+			backend.relay.ffRejectBlacklistedBuilders.Store(tc.rejectBanned)
+			w := httptest.NewRecorder()
+			log := logrus.NewEntry(logrus.New())
+			_, ok := backend.relay.checkBuilderEntry(w, log, builderPubkey)
+			require.False(t, ok)
+			require.Equal(t, tc.expectCode, w.Code)
+			if tc.rejectBanned {
+				require.Contains(t, w.Body.String(), ErrBuilderBlacklisted.Error())
+			}
+		})
+	}
+}
+
 func TestCheckFloorBidValue(t *testing.T) {
 	cases := []struct {
 		description          string
@@ -1185,6 +2111,7 @@ func TestCheckFloorBidValue(t *testing.T) {
 				cancellationsEnabled: tc.cancellationsEnabled,
 				simResultC:           simResultC,
 				submission:           submission,
+				bidValue:             submission.BidTrace.Value,
 			}
 			floor, ok := backend.relay.checkFloorBidValue(bfOpts)
 			require.Equal(t, tc.expectOk, ok)
@@ -1259,6 +2186,7 @@ func TestUpdateRedis(t *testing.T) {
 			floorValue, ok := floorValue.SetString(tc.floorValue, 10)
 			require.True(t, ok)
 			rOpts := redisUpdateBidOpts{
+				ctx:                  context.Background(),
 				w:                    w,
 				tx:                   tx,
 				log:                  log,
@@ -1276,6 +2204,86 @@ func TestUpdateRedis(t *testing.T) {
 	}
 }
 
+func goerliNetworkDetails(t *testing.T) *common.EthNetworkDetails {
+	t.Helper()
+	goerli, err := common.NewEthNetworkDetails(common.EthNetworkGoerli)
+	require.NoError(t, err)
+	goerli.SetForkSchedule([]common.ForkScheduleEntry{
+		{Epoch: 0, CurrentVersion: common.GenesisForkVersionGoerli},
+		{Epoch: 112260, CurrentVersion: common.BellatrixForkVersionGoerli},
+		{Epoch: 162304, CurrentVersion: common.CapellaForkVersionGoerli},
+		{Epoch: 231680, CurrentVersion: common.DenebForkVersionGoerli},
+	})
+	return goerli
+}
+
+func TestGetPayloadNotCurrentSlot(t *testing.T) {
+	jsonBytes := common.LoadGzippedBytes(t, "../../testdata/signedBlindedBeaconBlockCapella_Goerli.json.gz")
+	payload := new(common.VersionedSignedBlindedBeaconBlock)
+	require.NoError(t, json.Unmarshal(jsonBytes, payload))
+	slot, err := payload.Slot()
+	require.NoError(t, err)
+
+	slotStart := time.Now().UTC().Unix() - int64(uint64(slot)*common.SecondsPerSlot) //nolint:gosec
+	testCases := []struct {
+		name        string
+		genesisTime int64
+	}{
+		{name: "future slot", genesisTime: slotStart + 2*int64(common.SecondsPerSlot)},
+		{name: "past slot", genesisTime: slotStart - 2*int64(common.SecondsPerSlot)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			backend := newTestBackend(t, 1)
+			backend.relay.genesisInfo = &beaconclient.GetGenesisResponse{
+				Data: beaconclient.GetGenesisResponseData{
+					GenesisTime: uint64(tc.genesisTime), //nolint:gosec
+				},
+			}
+			rr := backend.requestBytes(http.MethodPost, pathGetPayload, jsonBytes, nil)
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			require.Contains(t, rr.Body.String(), "not the current slot")
+		})
+	}
+}
+
+func TestLockGetPayloadPublish(t *testing.T) {
+	backend := newTestBackend(t, 1)
+	blockHash := "0x01"
+
+	release := backend.relay.lockGetPayloadPublish(t.Context(), common.TestLog, blockHash)
+
+	// a concurrent request of the same block hash waits until the lock is released
+	acquired := make(chan struct{})
+	go func() {
+		backend.relay.lockGetPayloadPublish(t.Context(), common.TestLog, blockHash)()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held by another request")
+	case <-time.After(5 * getPayloadLockPollInterval):
+	}
+
+	// requests of other block hashes don't wait
+	backend.relay.lockGetPayloadPublish(t.Context(), common.TestLog, "0x02")()
+
+	release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+
+	// a closed request stops waiting
+	release = backend.relay.lockGetPayloadPublish(t.Context(), common.TestLog, blockHash)
+	defer release()
+	ctx, cancel := context.WithTimeout(t.Context(), 5*getPayloadLockPollInterval)
+	defer cancel()
+	backend.relay.lockGetPayloadPublish(ctx, common.TestLog, blockHash)()
+	require.Error(t, ctx.Err())
+}
+
 func TestCheckProposerSignature(t *testing.T) {
 	t.Run("Unsupported version", func(t *testing.T) {
 		_, _, backend := startTestBackend(t)
@@ -1293,9 +2301,7 @@ func TestCheckProposerSignature(t *testing.T) {
 		require.NoError(t, err)
 		// start backend with goerli network
 		_, _, backend := startTestBackend(t)
-		goerli, err := common.NewEthNetworkDetails(common.EthNetworkGoerli)
-		require.NoError(t, err)
-		backend.relay.opts.EthNetDetails = *goerli
+		backend.relay.opts.EthNetDetails = *goerliNetworkDetails(t)
 		// check signature
 		pubkey, err := utils.HexToPubkey("0xa8afcb5313602f936864b30600f568e04069e596ceed9b55e2a1c872c959ddcb90589636469c15d97e7565344d9ed4ad")
 		require.NoError(t, err)
@@ -1318,6 +2324,22 @@ func TestCheckProposerSignature(t *testing.T) {
 		payload.Capella.Signature = signature
 		// start backend with goerli network
 		_, _, backend := startTestBackend(t)
+		backend.relay.opts.EthNetDetails = *goerliNetworkDetails(t)
+		// check signature
+		pubkey, err := utils.HexToPubkey("0xa8afcb5313602f936864b30600f568e04069e596ceed9b55e2a1c872c959ddcb90589636469c15d97e7565344d9ed4ad")
+		require.NoError(t, err)
+		ok, err := backend.relay.checkProposerSignature(payload, pubkey[:])
+		require.NoError(t, err)
+		require.False(t, ok)
+	})
+
+	t.Run("Valid Capella Signature Without Fork Schedule", func(t *testing.T) {
+		jsonBytes := common.LoadGzippedBytes(t, "../../testdata/signedBlindedBeaconBlockCapella_Goerli.json.gz")
+		payload := new(common.VersionedSignedBlindedBeaconBlock)
+		err := json.Unmarshal(jsonBytes, payload)
+		require.NoError(t, err)
+		// start backend with goerli network, without a fork schedule the domain of the block version is used
+		_, _, backend := startTestBackend(t)
 		goerli, err := common.NewEthNetworkDetails(common.EthNetworkGoerli)
 		require.NoError(t, err)
 		backend.relay.opts.EthNetDetails = *goerli
@@ -1326,6 +2348,24 @@ func TestCheckProposerSignature(t *testing.T) {
 		require.NoError(t, err)
 		ok, err := backend.relay.checkProposerSignature(payload, pubkey[:])
 		require.NoError(t, err)
+		require.True(t, ok)
+	})
+
+	t.Run("Capella Signature With Deneb Domain", func(t *testing.T) {
+		jsonBytes := common.LoadGzippedBytes(t, "../../testdata/signedBlindedBeaconBlockCapella_Goerli.json.gz")
+		payload := new(common.VersionedSignedBlindedBeaconBlock)
+		err := json.Unmarshal(jsonBytes, payload)
+		require.NoError(t, err)
+		// start backend with goerli network, where deneb is active at the slot of the block
+		_, _, backend := startTestBackend(t)
+		goerli := goerliNetworkDetails(t)
+		goerli.SetForkSchedule([]common.ForkScheduleEntry{{Epoch: 0, CurrentVersion: common.DenebForkVersionGoerli}})
+		backend.relay.opts.EthNetDetails = *goerli
+		// check signature
+		pubkey, err := utils.HexToPubkey("0xa8afcb5313602f936864b30600f568e04069e596ceed9b55e2a1c872c959ddcb90589636469c15d97e7565344d9ed4ad")
+		require.NoError(t, err)
+		ok, err := backend.relay.checkProposerSignature(payload, pubkey[:])
+		require.NoError(t, err)
 		require.False(t, ok)
 	})
 
@@ -1336,9 +2376,7 @@ func TestCheckProposerSignature(t *testing.T) {
 		require.NoError(t, err)
 		// start backend with goerli network
 		_, _, backend := startTestBackend(t)
-		goerli, err := common.NewEthNetworkDetails(common.EthNetworkGoerli)
-		require.NoError(t, err)
-		backend.relay.opts.EthNetDetails = *goerli
+		backend.relay.opts.EthNetDetails = *goerliNetworkDetails(t)
 		// check signature
 		t.Log(payload.Deneb.Message.Slot)
 		pubkey, err := utils.HexToPubkey("0x8322b8af5c6d97e855cc75ad19d59b381a880630cded89268c14acb058cf3c5720ebcde5fa6087dcbb64dbd826936148")
@@ -1362,9 +2400,7 @@ func TestCheckProposerSignature(t *testing.T) {
 		payload.Deneb.Signature = signature
 		// start backend with goerli network
 		_, _, backend := startTestBackend(t)
-		goerli, err := common.NewEthNetworkDetails(common.EthNetworkGoerli)
-		require.NoError(t, err)
-		backend.relay.opts.EthNetDetails = *goerli
+		backend.relay.opts.EthNetDetails = *goerliNetworkDetails(t)
 		// check signature
 		pubkey, err := utils.HexToPubkey("0x8322b8af5c6d97e855cc75ad19d59b381a880630cded89268c14acb058cf3c5720ebcde5fa6087dcbb64dbd826936148")
 		require.NoError(t, err)
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// submissionResult is the response to a processed block submission, which is
+// replayed for duplicate submissions of the same block
+type submissionResult struct {
+	slot        uint64
+	done        chan struct{} // closed once the result is available
+	code        int
+	contentType string
+	body        []byte
+}
+
+// submissionDedupCache keeps track of the block submissions (slot, blockHash, builderPubkey)
+// that were already processed, so that retries of a builder don't cause additional simulations
+type submissionDedupCache struct {
+	lock    sync.Mutex
+	results map[string]*submissionResult
+}
+
+func newSubmissionDedupCache() *submissionDedupCache {
+	return &submissionDedupCache{
+		lock:    sync.Mutex{},
+		results: make(map[string]*submissionResult),
+	}
+}
+
+func getSubmissionDedupKey(slot uint64, blockHash, builderPubkey string) string {
+	return fmt.Sprintf("%d_%s_%s", slot, blockHash, builderPubkey)
+}
+
+// getOrAdd returns the existing result for the key if the submission was already seen (isDuplicate is true),
+// otherwise it adds a new pending result which needs to be completed by the caller
+func (c *submissionDedupCache) getOrAdd(slot uint64, key string) (result *submissionResult, isDuplicate bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if result, ok := c.results[key]; ok {
+		return result, true
+	}
+	result = &submissionResult{slot: slot, done: make(chan struct{})} //nolint:exhaustruct
+	c.results[key] = result
+	return result, false
+}
+
+// complete stores the response of a submission and releases all waiting duplicates. Transient results (i.e. of a
+// closed request or a failed simulation request), rate limits and server-side errors are not cached, so that a
+// retry of the submission is processed again.
+func (c *submissionDedupCache) complete(key string, result *submissionResult, rec *responseRecorder, isTransient bool) {
+	result.code = rec.code
+	result.contentType = rec.Header().Get("Content-Type")
+	result.body = rec.body.Bytes()
+	close(result.done)
+
+	if isTransient || result.code == http.StatusTooManyRequests || result.code >= http.StatusInternalServerError {
+		c.lock.Lock()
+		if c.results[key] == result {
+			delete(c.results, key)
+		}
+		c.lock.Unlock()
+	}
+}
+
+// cleanup removes all results of slots older than the given slot
+func (c *submissionDedupCache) cleanup(slot uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, result := range c.results {
+		if result.slot < slot {
+			delete(c.results, key)
+		}
+	}
+}
+
+// responseRecorder is a http.ResponseWriter which keeps a copy of the status code and body written
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, code: http.StatusOK, body: bytes.Buffer{}}
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
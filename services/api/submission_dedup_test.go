@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubmissionDedupCache(t *testing.T) {
+	key := getSubmissionDedupKey(testSlot, testParentHash, testBuilderPubkey)
+
+	t.Run("duplicate gets previous result", func(t *testing.T) {
+		c := newSubmissionDedupCache()
+		result, isDuplicate := c.getOrAdd(testSlot, key)
+		require.False(t, isDuplicate)
+
+		rec := newResponseRecorder(httptest.NewRecorder())
+		rec.Header().Set("Content-Type", "application/json")
+		rec.WriteHeader(http.StatusBadRequest)
+		_, err := rec.Write([]byte(`{"code":400,"message":"invalid signature"}`))
+		require.NoError(t, err)
+		c.complete(key, result, rec, false)
+
+		dupResult, isDuplicate := c.getOrAdd(testSlot, key)
+		require.True(t, isDuplicate)
+		require.Equal(t, result, dupResult)
+
+		backend := newTestBackend(t, 1)
+		w := httptest.NewRecorder()
+		backend.relay.respondDuplicateSubmission(w, logrus.NewEntry(logrus.New()), dupResult)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.JSONEq(t, `{"code":400,"message":"invalid signature"}`, w.Body.String())
+	})
+
+	t.Run("duplicate of a pending submission is rejected right away", func(t *testing.T) {
+		c := newSubmissionDedupCache()
+		c.getOrAdd(testSlot, key)
+		dupResult, isDuplicate := c.getOrAdd(testSlot, key)
+		require.True(t, isDuplicate)
+
+		backend := newTestBackend(t, 1)
+		w := httptest.NewRecorder()
+		backend.relay.respondDuplicateSubmission(w, logrus.NewEntry(logrus.New()), dupResult)
+		require.Equal(t, http.StatusConflict, w.Code)
+		require.Contains(t, w.Body.String(), string(ErrorCodeSubmissionInProgress))
+	})
+
+	t.Run("server errors are not cached", func(t *testing.T) {
+		c := newSubmissionDedupCache()
+		result, _ := c.getOrAdd(testSlot, key)
+
+		rec := newResponseRecorder(httptest.NewRecorder())
+		rec.WriteHeader(http.StatusGatewayTimeout)
+		c.complete(key, result, rec, false)
+
+		_, isDuplicate := c.getOrAdd(testSlot, key)
+		require.False(t, isDuplicate)
+	})
+
+	t.Run("transient results are not cached", func(t *testing.T) {
+		c := newSubmissionDedupCache()
+		result, _ := c.getOrAdd(testSlot, key)
+
+		// i.e. a simulation request error of a request which was closed by the builder
+		rec := newResponseRecorder(httptest.NewRecorder())
+		rec.WriteHeader(http.StatusBadRequest)
+		c.complete(key, result, rec, true)
+
+		// the retry is processed again
+		_, isDuplicate := c.getOrAdd(testSlot, key)
+		require.False(t, isDuplicate)
+	})
+
+	t.Run("rate limited results are not cached", func(t *testing.T) {
+		c := newSubmissionDedupCache()
+		result, _ := c.getOrAdd(testSlot, key)
+
+		rec := newResponseRecorder(httptest.NewRecorder())
+		rec.WriteHeader(http.StatusTooManyRequests)
+		c.complete(key, result, rec, false)
+
+		_, isDuplicate := c.getOrAdd(testSlot, key)
+		require.False(t, isDuplicate)
+	})
+
+	t.Run("cleanup of old slots", func(t *testing.T) {
+		c := newSubmissionDedupCache()
+		c.getOrAdd(testSlot, key)
+		c.cleanup(testSlot)
+		require.Len(t, c.results, 1)
+		c.cleanup(testSlot + 1)
+		require.Empty(t, c.results)
+	})
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"errors"
+	"time"
 
 	boostTypes "github.com/flashbots/go-boost-utils/types"
+	"github.com/flashbots/mev-boost-relay/database"
 )
 
 var (
@@ -16,8 +18,116 @@ var (
 )
 
 type HTTPErrorResp struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code      int       `json:"code"`
+	ErrorCode ErrorCode `json:"error_code"`
+	Message   string    `json:"message"`
+}
+
+// RegisterValidatorFailure describes why a single registration of a registerValidator request was rejected
+type RegisterValidatorFailure struct {
+	Index     int       `json:"index"`
+	Pubkey    string    `json:"pubkey,omitempty"`
+	ErrorCode ErrorCode `json:"error_code"`
+	Error     string    `json:"error"`
+}
+
+// RegisterValidatorErrorResp lists the rejected registrations, all others of the request were processed
+type RegisterValidatorErrorResp struct {
+	Code      int                        `json:"code"`
+	ErrorCode ErrorCode                  `json:"error_code"`
+	Message   string                     `json:"message"`
+	Failures  []RegisterValidatorFailure `json:"failures"`
+}
+
+// InternalBuilderEntry is a builder as listed by the internal API, with the status currently applied by the relay
+// (after automatic demotions and builder scores) next to the status set by the operator
+type InternalBuilderEntry struct {
+	*database.BlockBuilderEntry
+
+	EffectiveIsHighPrio   bool       `json:"effective_is_high_prio"`
+	EffectiveIsOptimistic bool       `json:"effective_is_optimistic"`
+	IsOptimisticEligible  bool       `json:"is_optimistic_eligible"` // optimistic, not blacklisted and with collateral
+	LastSubmissionTime    *time.Time `json:"last_submission_time"`
+	NumDemotions          uint64     `json:"num_demotions"`
+}
+
+// InternalBuilderIdentityEntry is a builder identity as listed by the internal API, with the pubkeys grouped into it
+type InternalBuilderIdentityEntry struct {
+	*database.BuilderIdentityEntry
+
+	BuilderPubkeys []string `json:"builder_pubkeys"`
+}
+
+// RestoreRegistrationsResponse is the response of the internal API after restoring the validator registrations in Redis
+type RestoreRegistrationsResponse struct {
+	NumRegistrations int `json:"num_registrations"`
+}
+
+// InvalidateBidsResponse is the response of the internal API after invalidating the bids of a slot
+type InvalidateBidsResponse struct {
+	Slot          uint64 `json:"slot,string"`
+	BuilderPubkey string `json:"builder_pubkey,omitempty"`
+	NumDeleted    int    `json:"num_deleted"`
+}
+
+// RelayPubkeyResponse is the relay pubkey, the next pubkey while a key rotation is in progress, and the pubkey of the
+// API instance which answered the request
+type RelayPubkeyResponse struct {
+	RelayPubkey    string `json:"relay_pubkey"`
+	NextPubkey     string `json:"next_pubkey,omitempty"`
+	InstancePubkey string `json:"instance_pubkey,omitempty"`
+}
+
+// DataStatsResponse is the number and total value (in wei) of the payloads delivered from FromDay to ToDay (inclusive,
+// UTC), per builder and per day
+type DataStatsResponse struct {
+	FromDay  string                  `json:"from_day"`
+	ToDay    string                  `json:"to_day"`
+	Builders []DataStatsBuilderEntry `json:"builders"`
+	Days     []DataStatsDayEntry     `json:"days"`
+}
+
+type DataStatsBuilderEntry struct {
+	BuilderPubkey string `json:"builder_pubkey"`
+	NumPayloads   uint64 `json:"num_payloads,string"`
+	TotalValue    string `json:"total_value"`
+}
+
+type DataStatsDayEntry struct {
+	Day         string `json:"day"`
+	NumPayloads uint64 `json:"num_payloads,string"`
+	TotalValue  string `json:"total_value"`
+}
+
+// DataBuilderDailyStatsEntry is the number of submissions, failed simulations and delivered payloads (with their total
+// value in wei) of a builder on a UTC day
+type DataBuilderDailyStatsEntry struct {
+	Day            string `json:"day"`
+	BuilderPubkey  string `json:"builder_pubkey,omitempty"`
+	BuilderID      string `json:"builder_id,omitempty"`
+	NumSubmissions uint64 `json:"num_submissions,string"`
+	NumSimErrors   uint64 `json:"num_sim_errors,string"`
+	NumPayloads    uint64 `json:"num_payloads,string"`
+	TotalValue     string `json:"total_value"`
+}
+
+// DataBuilderEpochStatsEntry is the number of submissions, failed simulations and delivered payloads (with their total
+// value in wei) of a builder in an epoch
+type DataBuilderEpochStatsEntry struct {
+	Epoch          uint64 `json:"epoch,string"`
+	BuilderPubkey  string `json:"builder_pubkey,omitempty"`
+	BuilderID      string `json:"builder_id,omitempty"`
+	NumSubmissions uint64 `json:"num_submissions,string"`
+	NumSimErrors   uint64 `json:"num_sim_errors,string"`
+	NumPayloads    uint64 `json:"num_payloads,string"`
+	TotalValue     string `json:"total_value"`
+}
+
+// DataAPIKeyEntry is a data API key as listed by the internal API, the key itself is only included after creating it
+type DataAPIKeyEntry struct {
+	Hash  string `json:"hash"`
+	Label string `json:"label"`
+	Key   string `json:"key,omitempty"`
 }
 
 type HTTPMessageResp struct {
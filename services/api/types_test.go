@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"testing"
 
 	builderApiCapella "github.com/attestantio/go-builder-client/api/capella"
@@ -14,7 +15,6 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/deneb"
 	"github.com/attestantio/go-eth2-client/spec/electra"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
-	"github.com/flashbots/go-boost-utils/bls"
 	"github.com/flashbots/go-boost-utils/ssz"
 	"github.com/flashbots/go-boost-utils/types"
 	"github.com/flashbots/go-boost-utils/utils"
@@ -171,16 +171,7 @@ func TestBuilderBlockRequestToSignedBuilderBid(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			sk, _, err := bls.GenerateNewKeypair()
-			require.NoError(t, err)
-
-			pubkey, err := bls.PublicKeyFromSecretKey(sk)
-			require.NoError(t, err)
-
-			publicKey, err := utils.BlsPublicKeyToPublicKey(pubkey)
-			require.NoError(t, err)
-
-			signedBuilderBid, err := common.BuildGetHeaderResponse(tc.reqPayload, sk, &publicKey, ssz.DomainBuilder)
+			signedBuilderBid, err := common.BuildGetHeaderResponse(context.Background(), tc.reqPayload, common.NewTestSigner(t), ssz.DomainBuilder)
 			require.NoError(t, err)
 
 			bidValue, err := signedBuilderBid.Value()
@@ -2,8 +2,15 @@ package api
 
 import (
 	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
 
 	builderApi "github.com/attestantio/go-builder-client/api"
+	builderApiDeneb "github.com/attestantio/go-builder-client/api/deneb"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/capella"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
@@ -11,6 +18,7 @@ import (
 	"github.com/flashbots/go-boost-utils/bls"
 	"github.com/flashbots/go-boost-utils/utils"
 	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
 	"github.com/pkg/errors"
 )
 
@@ -25,7 +33,10 @@ var (
 	ErrPayloadMismatch      = errors.New("beacon-block and payload version mismatch")
 	ErrHeaderHTRMismatch    = errors.New("beacon-block and payload header mismatch")
 	ErrBlobMismatch         = errors.New("beacon-block and payload blob contents mismatch")
+	ErrBlobsBundleMismatch  = errors.New("blobs bundle commitments, proofs and blobs length mismatch")
 	ErrNotAcceptable        = errors.New("not acceptable")
+	ErrInvalidSlotRange     = errors.New("invalid slot range")
+	ErrInvalidTotalValue    = errors.New("invalid total value")
 )
 
 func SanityCheckBuilderBlockSubmission(payload *common.VersionedSubmitBlockRequest) error {
@@ -41,6 +52,18 @@ func SanityCheckBuilderBlockSubmission(payload *common.VersionedSubmitBlockReque
 		return ErrParentHashMismatch
 	}
 
+	// the blob sidecars are built from the bundle when publishing, so it must be consistent
+	var blobsBundle *builderApiDeneb.BlobsBundle
+	switch payload.Version { //nolint:exhaustive
+	case spec.DataVersionDeneb:
+		blobsBundle = payload.Deneb.BlobsBundle
+	case spec.DataVersionElectra:
+		blobsBundle = payload.Electra.BlobsBundle
+	}
+	if blobsBundle != nil && (len(blobsBundle.Commitments) != len(blobsBundle.Blobs) || len(blobsBundle.Proofs) != len(blobsBundle.Blobs)) {
+		return ErrBlobsBundleMismatch
+	}
+
 	return nil
 }
 
@@ -155,6 +178,152 @@ func checkBLSPublicKeyHex(pkHex string) error {
 	return err
 }
 
+// parseSlotRange returns the inclusive slot range of the from_slot/to_slot, from_ts/to_ts (unix seconds) or epoch
+// query arguments, toSlot is nil if there is no upper bound
+func parseSlotRange(args url.Values, clock common.SlotClock) (fromSlot uint64, toSlot *uint64, err error) {
+	parseArg := func(name string) (value uint64, found bool, err error) {
+		if args.Get(name) == "" {
+			return 0, false, nil
+		}
+		value, err = strconv.ParseUint(args.Get(name), 10, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("%w: invalid %s argument", ErrInvalidSlotRange, name)
+		}
+		return value, true, nil
+	}
+
+	numKinds := 0
+	if fromSlotArg, found, err := parseArg("from_slot"); err != nil {
+		return 0, nil, err
+	} else if found {
+		fromSlot = fromSlotArg
+	}
+	if toSlotArg, found, err := parseArg("to_slot"); err != nil {
+		return 0, nil, err
+	} else if found {
+		toSlot = &toSlotArg
+	}
+	if args.Has("from_slot") || args.Has("to_slot") {
+		numKinds++
+	}
+
+	// timestamps select the slots which started within the range
+	if fromTs, found, err := parseArg("from_ts"); err != nil {
+		return 0, nil, err
+	} else if found {
+		fromSlot = clock.SlotAt(time.Unix(int64(fromTs), 0)) //nolint:gosec
+		if clock.SlotStartTimestamp(fromSlot) < fromTs {
+			fromSlot++
+		}
+	}
+	if toTs, found, err := parseArg("to_ts"); err != nil {
+		return 0, nil, err
+	} else if found {
+		if toTs < clock.GenesisTime {
+			return 0, nil, fmt.Errorf("%w: to_ts is before genesis", ErrInvalidSlotRange)
+		}
+		toTsSlot := clock.SlotAt(time.Unix(int64(toTs), 0)) //nolint:gosec
+		toSlot = &toTsSlot
+	}
+	if args.Has("from_ts") || args.Has("to_ts") {
+		numKinds++
+	}
+
+	if epoch, found, err := parseArg("epoch"); err != nil {
+		return 0, nil, err
+	} else if found {
+		fromSlot = epoch * common.SlotsPerEpoch
+		epochToSlot := fromSlot + common.SlotsPerEpoch - 1
+		toSlot = &epochToSlot
+		numKinds++
+	}
+
+	if numKinds > 1 {
+		return 0, nil, fmt.Errorf("%w: only one of slots, timestamps or epoch can be used", ErrInvalidSlotRange)
+	}
+	if toSlot != nil && fromSlot > *toSlot {
+		return 0, nil, fmt.Errorf("%w: start is after the end", ErrInvalidSlotRange)
+	}
+	return fromSlot, toSlot, nil
+}
+
+// parseDataStatsDayRange returns the days from the from_day and to_day arguments (YYYY-MM-DD, UTC), by default the last
+// dataStatsDefaultDays days
+func parseDataStatsDayRange(args url.Values) (fromDay, toDay time.Time, err error) {
+	toDay = time.Now().UTC().Truncate(24 * time.Hour)
+	if args.Get("to_day") != "" {
+		toDay, err = time.Parse(time.DateOnly, args.Get("to_day"))
+		if err != nil {
+			return fromDay, toDay, errors.New("invalid to_day argument") //nolint:goerr113
+		}
+	}
+
+	fromDay = toDay.AddDate(0, 0, -(dataStatsDefaultDays - 1))
+	if args.Get("from_day") != "" {
+		fromDay, err = time.Parse(time.DateOnly, args.Get("from_day"))
+		if err != nil {
+			return fromDay, toDay, errors.New("invalid from_day argument") //nolint:goerr113
+		}
+	}
+
+	if fromDay.After(toDay) {
+		return fromDay, toDay, errors.New("from_day is after to_day") //nolint:goerr113
+	}
+	if fromDay.AddDate(0, 0, dataStatsMaxDays).Before(toDay.AddDate(0, 0, 1)) {
+		return fromDay, toDay, fmt.Errorf("maximum range is %d days", dataStatsMaxDays) //nolint:goerr113
+	}
+	return fromDay, toDay, nil
+}
+
+// aggregateDeliveredPayloadStats sums up the daily stats of the builders per builder (by descending total value) and per day
+func aggregateDeliveredPayloadStats(entries []*database.DeliveredPayloadDailyStatsEntry) (*DataStatsResponse, error) {
+	type stats struct {
+		numPayloads uint64
+		totalValue  *big.Int
+	}
+	add := func(statsByKey map[string]*stats, key string, entry *database.DeliveredPayloadDailyStatsEntry, value *big.Int) {
+		s, ok := statsByKey[key]
+		if !ok {
+			s = &stats{totalValue: new(big.Int)}
+			statsByKey[key] = s
+		}
+		s.numPayloads += entry.NumPayloads
+		s.totalValue.Add(s.totalValue, value)
+	}
+
+	builders := make(map[string]*stats)
+	days := make(map[string]*stats)
+	for _, entry := range entries {
+		value, ok := new(big.Int).SetString(entry.TotalValue, 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTotalValue, entry.TotalValue)
+		}
+		add(builders, entry.BuilderPubkey, entry, value)
+		add(days, entry.Day.UTC().Format(time.DateOnly), entry, value)
+	}
+
+	response := &DataStatsResponse{
+		Builders: make([]DataStatsBuilderEntry, 0, len(builders)),
+		Days:     make([]DataStatsDayEntry, 0, len(days)),
+	}
+	for builderPubkey, s := range builders {
+		response.Builders = append(response.Builders, DataStatsBuilderEntry{BuilderPubkey: builderPubkey, NumPayloads: s.numPayloads, TotalValue: s.totalValue.String()})
+	}
+	sort.Slice(response.Builders, func(i, j int) bool {
+		if cmp := builders[response.Builders[i].BuilderPubkey].totalValue.Cmp(builders[response.Builders[j].BuilderPubkey].totalValue); cmp != 0 {
+			return cmp > 0
+		}
+		return response.Builders[i].BuilderPubkey < response.Builders[j].BuilderPubkey
+	})
+	for day, s := range days {
+		response.Days = append(response.Days, DataStatsDayEntry{Day: day, NumPayloads: s.numPayloads, TotalValue: s.totalValue.String()})
+	}
+	sort.Slice(response.Days, func(i, j int) bool {
+		return response.Days[i].Day < response.Days[j].Day
+	})
+	return response, nil
+}
+
 func hasReachedFork(slot uint64, forkEpoch int64) bool {
 	if forkEpoch < 0 {
 		return false
@@ -184,3 +353,19 @@ func verifyBlockSignature(block *common.VersionedSignedBlindedBeaconBlock, domai
 func getPayloadAttributesKey(parentHash string, slot uint64) string {
 	return fmt.Sprintf("%s-%d", parentHash, slot)
 }
+
+// statusRecorder is a http.ResponseWriter which remembers the status code of the response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap returns the wrapped writer, so http.ResponseController can flush streamed responses
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
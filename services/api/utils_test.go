@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/deneb"
+	"github.com/flashbots/go-boost-utils/bls"
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanityCheckBuilderBlockSubmissionBlobsBundle(t *testing.T) {
+	sk, _, err := bls.GenerateNewKeypair()
+	require.NoError(t, err)
+	payload := common.TestBuilderSubmitBlockRequest(sk, &common.BidTraceV2WithBlobFields{
+		BidTrace: builderApiV1.BidTrace{
+			Slot:  1,
+			Value: uint256.NewInt(1),
+		},
+	}, spec.DataVersionDeneb)
+
+	require.NoError(t, SanityCheckBuilderBlockSubmission(payload))
+
+	// a commitment without blob and proof
+	payload.Deneb.BlobsBundle.Commitments = append(payload.Deneb.BlobsBundle.Commitments, deneb.KZGCommitment{})
+	require.ErrorIs(t, SanityCheckBuilderBlockSubmission(payload), ErrBlobsBundleMismatch)
+
+	payload.Deneb.BlobsBundle.Proofs = append(payload.Deneb.BlobsBundle.Proofs, deneb.KZGProof{})
+	payload.Deneb.BlobsBundle.Blobs = append(payload.Deneb.BlobsBundle.Blobs, deneb.Blob{})
+	require.NoError(t, SanityCheckBuilderBlockSubmission(payload))
+}
+
+func TestParseSlotRange(t *testing.T) {
+	clock := common.SlotClock{GenesisTime: 1000}
+	slotTs := func(slot, offset uint64) string {
+		return strconv.FormatUint(clock.SlotStartTimestamp(slot)+offset, 10)
+	}
+	slotPtr := func(slot uint64) *uint64 {
+		return &slot
+	}
+
+	testCases := []struct {
+		name     string
+		query    string
+		fromSlot uint64
+		toSlot   *uint64
+		err      bool
+	}{
+		{name: "no range", query: ""},
+		{name: "slots", query: "from_slot=10&to_slot=20", fromSlot: 10, toSlot: slotPtr(20)},
+		{name: "only from_slot", query: "from_slot=10", fromSlot: 10},
+		// slot 0 is an upper bound, not a missing one
+		{name: "to_slot 0", query: "to_slot=0", toSlot: slotPtr(0)},
+		{name: "to_ts in slot 0", query: "to_ts=" + slotTs(0, 1), toSlot: slotPtr(0)},
+		{name: "timestamps at slot start", query: "from_ts=" + slotTs(10, 0) + "&to_ts=" + slotTs(20, 0), fromSlot: 10, toSlot: slotPtr(20)},
+		// the first slot starting at or after from_ts, up to the slot containing to_ts
+		{name: "timestamps within slots", query: "from_ts=" + slotTs(10, 1) + "&to_ts=" + slotTs(20, 1), fromSlot: 11, toSlot: slotPtr(20)},
+		{name: "timestamp before genesis", query: "from_ts=0", fromSlot: 0},
+		{name: "epoch", query: "epoch=2", fromSlot: 64, toSlot: slotPtr(95)},
+		{name: "start after slot 0", query: "from_slot=1&to_slot=0", err: true},
+		{name: "invalid from_slot", query: "from_slot=abc", err: true},
+		{name: "start after end", query: "from_slot=20&to_slot=10", err: true},
+		{name: "to_ts before genesis", query: "to_ts=10", err: true},
+		{name: "slots and timestamps", query: "from_slot=10&to_ts=" + slotTs(20, 0), err: true},
+		{name: "slots and epoch", query: "from_slot=10&epoch=2", err: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			args, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+			fromSlot, toSlot, err := parseSlotRange(args, clock)
+			if tc.err {
+				require.ErrorIs(t, err, ErrInvalidSlotRange)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.fromSlot, fromSlot)
+			require.Equal(t, tc.toSlot, toSlot)
+		})
+	}
+}
+
+func TestAggregateDeliveredPayloadStats(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	entries := []*database.DeliveredPayloadDailyStatsEntry{
+		{Day: day1, BuilderPubkey: "0xa", NumPayloads: 2, TotalValue: "100"},
+		{Day: day1, BuilderPubkey: "0xb", NumPayloads: 1, TotalValue: "300"},
+		{Day: day2, BuilderPubkey: "0xa", NumPayloads: 3, TotalValue: "1000000000000000000000"},
+	}
+
+	response, err := aggregateDeliveredPayloadStats(entries)
+	require.NoError(t, err)
+	require.Equal(t, []DataStatsBuilderEntry{
+		{BuilderPubkey: "0xa", NumPayloads: 5, TotalValue: "1000000000000000000100"},
+		{BuilderPubkey: "0xb", NumPayloads: 1, TotalValue: "300"},
+	}, response.Builders)
+	require.Equal(t, []DataStatsDayEntry{
+		{Day: "2024-01-01", NumPayloads: 3, TotalValue: "400"},
+		{Day: "2024-01-02", NumPayloads: 3, TotalValue: "1000000000000000000000"},
+	}, response.Days)
+
+	_, err = aggregateDeliveredPayloadStats([]*database.DeliveredPayloadDailyStatsEntry{{Day: day1, TotalValue: "abc"}})
+	require.ErrorIs(t, err, ErrInvalidTotalValue)
+}
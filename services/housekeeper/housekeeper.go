@@ -1,34 +1,93 @@
 // Package housekeeper contains the service doing all required regular tasks
 //
 // - Update known validators
+// - Storing the known validators in Redis for the API instances, if KNOWN_VALIDATORS_FROM_HOUSEKEEPER is enabled
 // - Updating proposer duties
 // - Saving metrics
-// - Deleting old bids
+// - Updating builder scores
+// - Demoting builders with too many simulation failures
+// - Tripping the circuit breaker if delivered payloads keep missing
+// - Recording whether delivered payloads landed on chain
+// - Refreshing the daily delivered payload stats
+// - Refreshing the epoch stats of the builders
+// - Creating and detaching the database partitions by slot
+// - Deleting execution payloads, block submissions and served getHeader bids past their retention
+// - Auditing the Redis keys, and letting slot specific keys without an expiration expire
 // - ...
 package housekeeper
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	builderApiV1 "github.com/attestantio/go-builder-client/api/v1"
+	"github.com/flashbots/go-utils/cli"
 	"github.com/flashbots/mev-boost-relay/beaconclient"
 	"github.com/flashbots/mev-boost-relay/common"
 	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/flashbots/mev-boost-relay/database/vars"
 	"github.com/flashbots/mev-boost-relay/datastore"
+	"github.com/flashbots/mev-boost-relay/metrics"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	otelapi "go.opentelemetry.io/otel/metric"
 	uberatomic "go.uber.org/atomic"
 )
 
+var (
+	// builders need at least this many submissions before a score is computed for them
+	builderScoreMinSubmissions = uint64(cli.GetEnvInt("BUILDER_SCORE_MIN_SUBMISSIONS", 100)) //nolint:gosec
+	// the builder scores are computed from the submissions, demotions and failed getPayload requests of this many recent epochs
+	builderScoreWindowEpochs = uint64(cli.GetEnvInt("BUILDER_SCORE_WINDOW_EPOCHS", 1575)) //nolint:gosec
+
+	// high-prio and optimistic builders are demoted if more than this percentage of their last simulated submissions failed (0 to disable)
+	autoDemotionNumSubmissions       = uint64(cli.GetEnvInt("AUTO_DEMOTION_NUM_SUBMISSIONS", 100))       //nolint:gosec
+	autoDemotionMaxSimFailurePercent = uint64(cli.GetEnvInt("AUTO_DEMOTION_MAX_SIM_FAILURE_PERCENT", 0)) //nolint:gosec
+
+	// the circuit breaker trips after this many delivered payloads in a row did not land on chain (0 to disable)
+	circuitBreakerMissedSlots = uint64(cli.GetEnvInt("CIRCUIT_BREAKER_MISSED_SLOTS", 0)) //nolint:gosec
+
+	// delivered payloads are checked for whether they landed on chain once they are this many slots old (0 to disable)
+	deliveredPayloadCheckDelaySlots = uint64(cli.GetEnvInt("DELIVERED_PAYLOAD_CHECK_DELAY_SLOTS", 32)) //nolint:gosec
+
+	// the number and memory usage of the Redis keys is logged and recorded every this many epochs (0 to disable)
+	redisKeyAuditIntervalEpochs = uint64(cli.GetEnvInt("REDIS_KEY_AUDIT_INTERVAL_EPOCHS", 1)) //nolint:gosec
+
+	// number of database partitions by slot created ahead of the current one
+	dbPartitionsAhead = uint64(cli.GetEnvInt("DB_PARTITIONS_AHEAD", 2)) //nolint:gosec
+
+	// database partitions by slot are detached once all their slots are this far behind the head slot (0 to keep all),
+	// and dropped if DB_PARTITION_DROP_DETACHED is set
+	dbPartitionRetentionSlots = uint64(cli.GetEnvInt("DB_PARTITION_RETENTION_SLOTS", 0)) //nolint:gosec
+	dbPartitionDropDetached   = os.Getenv("DB_PARTITION_DROP_DETACHED") == "1"
+
+	// execution payloads, block submissions and served getHeader bids are deleted once they are this many days old (0
+	// to keep all)
+	dbRetentionExecutionPayloadsDays = uint64(cli.GetEnvInt("DB_RETENTION_EXECUTION_PAYLOADS_DAYS", 0)) //nolint:gosec
+	dbRetentionBlockSubmissionsDays  = uint64(cli.GetEnvInt("DB_RETENTION_BLOCK_SUBMISSIONS_DAYS", 0))  //nolint:gosec
+	dbRetentionGetHeaderLogDays      = uint64(cli.GetEnvInt("DB_RETENTION_GETHEADER_LOG_DAYS", 0))      //nolint:gosec
+
+	// block submissions are only deleted once their slots are recorded in the archive manifest
+	dbPruneOnlyArchived = os.Getenv("DB_PRUNE_ONLY_ARCHIVED") == "1"
+
+	// number of rows deleted at once by the retention policy
+	dbPruneBatchSize = uint64(cli.GetEnvInt("DB_PRUNE_BATCH_SIZE", 10_000)) //nolint:gosec
+)
+
 type HousekeeperOpts struct {
 	Log          *logrus.Entry
 	Redis        *datastore.RedisCache
+	Datastore    *datastore.Datastore
 	DB           database.IDatabaseService
 	BeaconClient beaconclient.IMultiBeaconClient
 
@@ -41,6 +100,7 @@ type Housekeeper struct {
 	log  *logrus.Entry
 
 	redis        *datastore.RedisCache
+	datastore    *datastore.Datastore
 	db           database.IDatabaseService
 	beaconClient beaconclient.IMultiBeaconClient
 
@@ -49,13 +109,29 @@ type Housekeeper struct {
 
 	isStarted                uberatomic.Bool
 	isUpdatingProposerDuties uberatomic.Bool
+	isUpdatingBuilderScores  uberatomic.Bool
+	isDemotingBuilders       uberatomic.Bool
+	isCheckingDelivered      uberatomic.Bool
+	isCheckingLanded         uberatomic.Bool
+	isRefreshingStats        uberatomic.Bool
+	isRefreshingBuilderStats uberatomic.Bool
+	isAuditingRedisKeys      uberatomic.Bool
+	isUpdatingPartitions     uberatomic.Bool
+	isPruningDatabase        uberatomic.Bool
 	proposerDutiesSlot       uint64
+	proposerDutiesEpochTo    uint64 // last epoch for which the duties were fetched
 
 	headSlot uberatomic.Uint64
 
 	proposersAlreadySaved map[uint64]string // to avoid repeating redis writes
 }
 
+// maximum number of delivered payloads checked for whether they landed on chain per slot
+const deliveredPayloadsCheckBatchSize = 32
+
+// pause between the batches of rows deleted by the retention policy, to leave room for other queries
+const dbPruneBatchPause = 100 * time.Millisecond
+
 var ErrServerAlreadyStarted = errors.New("server was already started")
 
 func NewHousekeeper(opts *HousekeeperOpts) *Housekeeper {
@@ -63,6 +139,7 @@ func NewHousekeeper(opts *HousekeeperOpts) *Housekeeper {
 		opts:                  opts,
 		log:                   opts.Log,
 		redis:                 opts.Redis,
+		datastore:             opts.Datastore,
 		db:                    opts.DB,
 		beaconClient:          opts.BeaconClient,
 		pprofAPI:              opts.PprofAPI,
@@ -80,11 +157,16 @@ func (hk *Housekeeper) Start() (err error) {
 		return ErrServerAlreadyStarted
 	}
 
+	if err := metrics.Setup(context.Background()); err != nil {
+		return err
+	}
+
 	// Get best beacon-node status by head slot, process current slot and start slot updates
 	bestSyncStatus, err := hk.beaconClient.BestSyncStatus()
 	if err != nil {
 		return err
 	}
+	go hk.beaconClient.StartHealthChecks()
 
 	// Start pprof API, if requested
 	if hk.pprofAPI {
@@ -93,6 +175,10 @@ func (hk *Housekeeper) Start() (err error) {
 
 	// Start initial tasks
 	go hk.updateValidatorRegistrationsInRedis()
+	go hk.updateBuilderScores(bestSyncStatus.HeadSlot)
+	go hk.refreshDeliveredPayloadStats()
+	go hk.refreshBuilderStats()
+	go hk.updateSlotPartitions(bestSyncStatus.HeadSlot)
 
 	// Process the current slot
 	hk.processNewSlot(bestSyncStatus.HeadSlot)
@@ -110,6 +196,7 @@ func (hk *Housekeeper) startPprofAPI() {
 	r := mux.NewRouter()
 	hk.log.Infof("Starting pprof API at %s", hk.pprofListenAddress)
 	r.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
+	r.Handle("/metrics", promhttp.Handler()).Methods(http.MethodGet)
 	srv := http.Server{ //nolint:gosec
 		Addr:    hk.pprofListenAddress,
 		Handler: r,
@@ -143,6 +230,38 @@ func (hk *Housekeeper) processNewSlot(headSlot uint64) {
 	// Update proposer duties
 	go hk.updateProposerDuties(headSlot)
 
+	// Store the known validators in Redis for the API instances (the datastore only refreshes them on some slots)
+	if datastore.KnownValidatorsFromHousekeeper && hk.datastore != nil {
+		go hk.datastore.PublishKnownValidators(hk.log, hk.beaconClient, headSlot)
+	}
+
+	// Update builder scores, demote failing builders and refresh the stats once per epoch
+	if headSlot%common.SlotsPerEpoch == 0 {
+		go hk.updateBuilderScores(headSlot)
+		if autoDemotionMaxSimFailurePercent > 0 {
+			go hk.demoteFailingBuilders()
+		}
+		go hk.refreshDeliveredPayloadStats()
+		go hk.refreshBuilderStats()
+		go hk.updateSlotPartitions(headSlot)
+		go hk.pruneDatabase(headSlot)
+	}
+
+	// Audit the Redis keys
+	if redisKeyAuditIntervalEpochs > 0 && headSlot%(common.SlotsPerEpoch*redisKeyAuditIntervalEpochs) == 0 {
+		go hk.auditRedisKeys()
+	}
+
+	// Check whether the last delivered payload landed on chain
+	if circuitBreakerMissedSlots > 0 {
+		go hk.checkLastDeliveredPayload(headSlot)
+	}
+
+	// Record whether older delivered payloads landed on chain
+	if deliveredPayloadCheckDelaySlots > 0 {
+		go hk.checkDeliveredPayloadsLanded(headSlot)
+	}
+
 	// Set headSlot in redis (for the website)
 	err := hk.redis.SetStats(datastore.RedisStatsFieldLatestSlot, headSlot)
 	if err != nil {
@@ -163,8 +282,10 @@ func (hk *Housekeeper) updateProposerDuties(headSlot uint64) {
 	}
 	defer hk.isUpdatingProposerDuties.Store(false)
 
+	// Update every half epoch, or on every slot until the duties of the next epoch are available
 	slotsForHalfAnEpoch := common.SlotsPerEpoch / 2
-	if headSlot%slotsForHalfAnEpoch != 0 && headSlot-hk.proposerDutiesSlot < slotsForHalfAnEpoch {
+	nextEpochKnown := hk.proposerDutiesEpochTo > headSlot/common.SlotsPerEpoch
+	if headSlot%slotsForHalfAnEpoch != 0 && headSlot-hk.proposerDutiesSlot < slotsForHalfAnEpoch && nextEpochKnown {
 		return
 	}
 
@@ -187,13 +308,15 @@ func (hk *Housekeeper) UpdateProposerDutiesWithoutChecks(headSlot uint64) {
 		return
 	}
 	entries := r.Data
+	epochTo := epoch
 
-	// Query next epoch
+	// Query next epoch, retried on the following slots if not yet available
 	r2, err := hk.beaconClient.GetProposerDuties(epoch + 1)
 	if err != nil {
-		log.WithError(err).Error("failed to get proposer duties for next epoch for all beacon nodes")
-	} else if r2 != nil {
+		log.WithError(err).Warn("failed to get proposer duties for next epoch for all beacon nodes")
+	} else if r2 != nil && len(r2.Data) > 0 {
 		entries = append(entries, r2.Data...)
+		epochTo = epoch + 1
 	}
 
 	// Get registrations from database
@@ -238,6 +361,7 @@ func (hk *Housekeeper) UpdateProposerDutiesWithoutChecks(headSlot uint64) {
 		return
 	}
 	hk.proposerDutiesSlot = headSlot
+	hk.proposerDutiesEpochTo = epochTo
 
 	// Pretty-print
 	_duties := make([]string, len(proposerDuties))
@@ -245,26 +369,417 @@ func (hk *Housekeeper) UpdateProposerDutiesWithoutChecks(headSlot uint64) {
 		_duties[i] = strconv.FormatUint(duty.Slot, 10)
 	}
 	sort.Strings(_duties)
-	log.WithField("numDuties", len(_duties)).Infof("proposer duties updated: %s", strings.Join(_duties, ", "))
+	log.WithFields(logrus.Fields{
+		"numDuties": len(_duties),
+		"epochTo":   epochTo,
+	}).Infof("proposer duties updated: %s", strings.Join(_duties, ", "))
 }
 
 // updateValidatorRegistrationsInRedis saves all latest validator registrations from the database to Redis
 func (hk *Housekeeper) updateValidatorRegistrationsInRedis() {
-	regs, err := hk.db.GetLatestValidatorRegistrations(true)
+	if hk.datastore == nil {
+		hk.log.Warn("no datastore, not updating validator registrations in Redis")
+		return
+	}
+
+	hk.log.Info("updating validator registrations in Redis...")
+	timeStarted := time.Now()
+	numRegistrations, err := hk.datastore.RestoreValidatorRegistrationsInRedis()
+	if err != nil {
+		hk.log.WithError(err).Error("failed to update validator registrations in Redis")
+		return
+	}
+	hk.log.Infof("updating %d validator registrations in Redis done - %f sec", numRegistrations, time.Since(timeStarted).Seconds())
+}
+
+// updateBuilderScores computes the scores of all builders with enough recent submissions from the database, and saves
+// them to Redis
+func (hk *Housekeeper) updateBuilderScores(headSlot uint64) {
+	// Should only happen once at a time
+	if hk.isUpdatingBuilderScores.Swap(true) {
+		return
+	}
+	defer hk.isUpdatingBuilderScores.Store(false)
+
+	var epochFrom uint64
+	if headEpoch := headSlot / common.SlotsPerEpoch; headEpoch > builderScoreWindowEpochs {
+		epochFrom = headEpoch - builderScoreWindowEpochs
+	}
+
+	counts, err := hk.db.GetBuilderScoreCounts(epochFrom)
 	if err != nil {
-		hk.log.WithError(err).Error("failed to get latest validator registrations")
+		hk.log.WithError(err).Error("failed to get builder score counts")
+		return
+	}
+
+	scores := make(map[string]*common.BuilderScore)
+	for builderPubkey, builderCounts := range counts {
+		if builderCounts.NumSubmissions < builderScoreMinSubmissions {
+			continue
+		}
+		scores[builderPubkey] = common.ComputeBuilderScore(builderPubkey, *builderCounts)
+	}
+
+	err = hk.redis.SetBuilderScores(scores)
+	if err != nil {
+		hk.log.WithError(err).Error("failed to save builder scores")
+		return
+	}
+	hk.log.WithField("epochFrom", epochFrom).Infof("updated scores of %d builders", len(scores))
+}
+
+// refreshDeliveredPayloadStats updates the daily stats of the delivered payloads, which are served by the data API
+func (hk *Housekeeper) refreshDeliveredPayloadStats() {
+	// Should only happen once at a time
+	if hk.isRefreshingStats.Swap(true) {
+		return
+	}
+	defer hk.isRefreshingStats.Store(false)
+
+	timeStarted := time.Now()
+	err := hk.db.RefreshDeliveredPayloadDailyStats()
+	if err != nil {
+		hk.log.WithError(err).Error("failed to refresh delivered payload stats")
+		return
+	}
+	hk.log.Infof("refreshed delivered payload stats - %f sec", time.Since(timeStarted).Seconds())
+}
+
+// refreshBuilderStats updates the epoch stats of the builders (submissions, failed simulations and delivered payloads),
+// which are served by the data API
+func (hk *Housekeeper) refreshBuilderStats() {
+	// Should only happen once at a time
+	if hk.isRefreshingBuilderStats.Swap(true) {
+		return
+	}
+	defer hk.isRefreshingBuilderStats.Store(false)
+
+	timeStarted := time.Now()
+	err := hk.db.RefreshBuilderEpochStats()
+	if err != nil {
+		hk.log.WithError(err).Error("failed to refresh builder stats")
+		return
+	}
+	hk.log.Infof("refreshed builder stats - %f sec", time.Since(timeStarted).Seconds())
+}
+
+// auditRedisKeys logs and records the number and memory usage of the Redis keys by prefix. Slot specific keys without
+// an expiration are set to expire by the audit.
+func (hk *Housekeeper) auditRedisKeys() {
+	// Should only happen once at a time
+	if hk.isAuditingRedisKeys.Swap(true) {
+		return
+	}
+	defer hk.isAuditingRedisKeys.Store(false)
+
+	ctx := context.Background()
+	timeStarted := time.Now()
+	stats, err := hk.redis.AuditKeys(ctx)
+	if err != nil {
+		hk.log.WithError(err).Error("failed to audit redis keys")
+		return
+	}
+
+	for _, s := range stats {
+		attrs := otelapi.WithAttributes(attribute.String("prefix", s.Prefix))
+		metrics.RedisKeyCount.Record(ctx, s.NumKeys, attrs)
+		metrics.RedisKeyMemoryUsage.Record(ctx, s.MemoryBytes, attrs)
+		metrics.RedisKeyExpirySetCount.Add(ctx, s.NumExpirySet, attrs)
+
+		log := hk.log.WithFields(logrus.Fields{
+			"prefix":       s.Prefix,
+			"numKeys":      s.NumKeys,
+			"memoryBytes":  s.MemoryBytes,
+			"numNoExpiry":  s.NumNoExpiry,
+			"numExpirySet": s.NumExpirySet,
+		})
+		if s.NumExpirySet > 0 {
+			log.Warn("redis keys without expiration were set to expire")
+		} else {
+			log.Info("redis keys")
+		}
+	}
+	hk.log.Infof("audited redis keys - %f sec", time.Since(timeStarted).Seconds())
+}
+
+// updateSlotPartitions creates the database partitions by slot ahead of time, and detaches the expired ones
+func (hk *Housekeeper) updateSlotPartitions(headSlot uint64) {
+	// Should only happen once at a time
+	if hk.isUpdatingPartitions.Swap(true) {
+		return
+	}
+	defer hk.isUpdatingPartitions.Store(false)
+
+	log := hk.log.WithField("headSlot", headSlot)
+	created, err := hk.db.CreateSlotPartitions(headSlot, dbPartitionsAhead)
+	for _, partition := range created {
+		log.WithFields(logrus.Fields{
+			"partition": partition.Name,
+			"slotFrom":  partition.SlotFrom,
+			"slotTo":    partition.SlotTo,
+		}).Info("created database partition")
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to create database partitions")
+		return
+	}
+
+	if dbPartitionRetentionSlots == 0 || headSlot <= dbPartitionRetentionSlots {
+		return
+	}
+	detached, err := hk.db.DetachSlotPartitions(headSlot-dbPartitionRetentionSlots, dbPartitionDropDetached)
+	for _, partition := range detached {
+		log.WithFields(logrus.Fields{
+			"partition": partition.Name,
+			"slotFrom":  partition.SlotFrom,
+			"slotTo":    partition.SlotTo,
+			"dropped":   dbPartitionDropDetached,
+		}).Info("detached expired database partition")
+	}
+	if err != nil {
+		log.WithError(err).Error("failed to detach expired database partitions")
+	}
+}
+
+// pruneDatabase deletes the execution payloads, block submissions and served getHeader bids older than their retention,
+// in batches
+func (hk *Housekeeper) pruneDatabase(headSlot uint64) {
+	// Should only happen once at a time
+	if hk.isPruningDatabase.Swap(true) {
 		return
 	}
+	defer hk.isPruningDatabase.Store(false)
+
+	slotsPerDay := uint64(24*time.Hour/time.Second) / common.SecondsPerSlot
+	if dbRetentionExecutionPayloadsDays > 0 && headSlot > dbRetentionExecutionPayloadsDays*slotsPerDay {
+		hk.pruneTable(vars.TableExecutionPayload, headSlot-dbRetentionExecutionPayloadsDays*slotsPerDay, hk.db.PruneExecutionPayloads)
+	}
+	if dbRetentionBlockSubmissionsDays > 0 && headSlot > dbRetentionBlockSubmissionsDays*slotsPerDay {
+		slotBefore := headSlot - dbRetentionBlockSubmissionsDays*slotsPerDay
+		if dbPruneOnlyArchived {
+			archivedSlotTo, err := hk.db.GetArchivedSlotTo(vars.TableBuilderBlockSubmission)
+			if err != nil {
+				hk.log.WithError(err).Error("failed to get the archived slots")
+				return
+			}
+			slotBefore = min(slotBefore, archivedSlotTo)
+		}
+		hk.pruneTable(vars.TableBuilderBlockSubmission, slotBefore, hk.db.PruneBuilderBlockSubmissions)
+	}
+	if dbRetentionGetHeaderLogDays > 0 && headSlot > dbRetentionGetHeaderLogDays*slotsPerDay {
+		hk.pruneTable(vars.TableGetHeaderLog, headSlot-dbRetentionGetHeaderLogDays*slotsPerDay, hk.db.PruneGetHeaderLog)
+	}
+}
 
-	hk.log.Infof("updating %d validator registrations in Redis...", len(regs))
+func (hk *Housekeeper) pruneTable(table string, slotBefore uint64, prune func(slotBefore, limit uint64) (int64, error)) {
+	log := hk.log.WithFields(logrus.Fields{
+		"table":      table,
+		"slotBefore": slotBefore,
+	})
 	timeStarted := time.Now()
+	numDeletedTotal := int64(0)
+	for {
+		numDeleted, err := prune(slotBefore, dbPruneBatchSize)
+		numDeletedTotal += numDeleted
+		metrics.DatabasePrunedRowCount.Add(context.Background(), numDeleted, otelapi.WithAttributes(attribute.String("table", table)))
+		if err != nil {
+			log.WithError(err).Error("failed to prune database table")
+			break
+		}
+		if numDeleted == 0 || uint64(numDeleted) < dbPruneBatchSize { //nolint:gosec
+			break
+		}
+		time.Sleep(dbPruneBatchPause)
+	}
+	if numDeletedTotal > 0 {
+		log.WithField("numDeleted", numDeletedTotal).Infof("pruned database table - %f sec", time.Since(timeStarted).Seconds())
+	}
+}
+
+// demoteFailingBuilders removes the high-prio and optimistic status of builders whose recent submissions failed simulation
+// too often. The new status is saved to the database, and published to Redis so that all API instances apply it.
+func (hk *Housekeeper) demoteFailingBuilders() {
+	// Should only happen once at a time
+	if hk.isDemotingBuilders.Swap(true) {
+		return
+	}
+	defer hk.isDemotingBuilders.Store(false)
+
+	builders, err := hk.db.GetBlockBuilders()
+	if err != nil {
+		hk.log.WithError(err).Error("failed to get block builders")
+		return
+	}
+
+	for _, builder := range builders {
+		if builder.IsBlacklisted || (!builder.IsHighPrio && !builder.IsOptimistic) {
+			continue
+		}
 
-	for _, reg := range regs {
-		err = hk.redis.SetValidatorRegistrationTimestampIfNewer(common.NewPubkeyHex(reg.Pubkey), reg.Timestamp)
+		log := hk.log.WithField("builderPubkey", builder.BuilderPubkey)
+		numSimulated, numSimErrors, err := hk.db.GetBuilderRecentSimStats(builder.BuilderPubkey, autoDemotionNumSubmissions)
 		if err != nil {
-			hk.log.WithError(err).Error("failed to set validator registration")
+			log.WithError(err).Error("failed to get recent simulation stats of builder")
+			continue
+		}
+
+		// only judge builders with a full window of simulated submissions
+		if numSimulated < autoDemotionNumSubmissions || numSimErrors*100 <= autoDemotionMaxSimFailurePercent*numSimulated {
 			continue
 		}
+
+		status := common.BuilderStatus{
+			IsHighPrio:    false,
+			IsBlacklisted: builder.IsBlacklisted,
+			IsOptimistic:  false,
+		}
+		log = log.WithFields(logrus.Fields{
+			"numSimulated": numSimulated,
+			"numSimErrors": numSimErrors,
+		})
+		err = hk.db.SetBlockBuilderStatus(builder.BuilderPubkey, status)
+		if err != nil {
+			log.WithError(err).Error("failed to save status of demoted builder")
+			continue
+		}
+		err = hk.redis.SetBlockBuilderStatus(builder.BuilderPubkey, status)
+		if err != nil {
+			log.WithError(err).Error("failed to publish status of demoted builder")
+			continue
+		}
+		log.Warn("demoted builder because of too many simulation failures")
+	}
+}
+
+// checkLastDeliveredPayload looks up whether the last payload delivered by the relay is part of the chain,
+// and trips the circuit breaker if too many delivered payloads in a row were missed.
+func (hk *Housekeeper) checkLastDeliveredPayload(headSlot uint64) {
+	// Should only happen once at a time
+	if hk.isCheckingDelivered.Swap(true) {
+		return
+	}
+	defer hk.isCheckingDelivered.Store(false)
+
+	lastSlotDelivered, err := hk.redis.GetLastSlotDelivered(context.Background(), hk.redis.NewPipeline())
+	if errors.Is(err, redis.Nil) {
+		return
+	} else if err != nil {
+		hk.log.WithError(err).Error("failed to get last slot delivered")
+		return
+	}
+
+	// wait until the chain has moved past the slot of the delivered payload
+	if lastSlotDelivered >= headSlot {
+		return
+	}
+
+	state, err := hk.redis.GetCircuitBreakerState()
+	if err != nil {
+		hk.log.WithError(err).Error("failed to get circuit breaker state")
+		return
+	}
+	if state.LastCheckedSlot >= lastSlotDelivered {
+		return
+	}
+
+	lastHashDelivered, err := hk.redis.GetLastHashDelivered()
+	if err != nil {
+		hk.log.WithError(err).Error("failed to get last hash delivered")
+		return
+	}
+
+	log := hk.log.WithFields(logrus.Fields{
+		"slot":      lastSlotDelivered,
+		"blockHash": lastHashDelivered,
+	})
+
+	landedStatus, _, err := hk.deliveredPayloadLandedStatus(lastSlotDelivered, lastHashDelivered)
+	if err != nil {
+		log.WithError(err).Error("failed to get block of last delivered payload")
+		return
+	}
+
+	landed := landedStatus == database.PayloadLandedStatusLanded
+	state, wasTripped, err := hk.redis.RecordCircuitBreakerCheck(lastSlotDelivered, landed, circuitBreakerMissedSlots)
+	if err != nil {
+		log.WithError(err).Error("failed to save circuit breaker state")
+		return
+	} else if state == nil || landed {
+		return
+	}
+
+	log.WithField("numMissedSlots", state.NumMissedSlots).Warn("delivered payload did not land on chain")
+	if state.IsTripped && !wasTripped {
+		log.WithField("numMissedSlots", state.NumMissedSlots).Error("circuit breaker tripped, not serving bids until reset by an operator")
+	}
+}
+
+// checkDeliveredPayloadsLanded records for the delivered payloads whether they landed on chain, once they
+// are old enough to not be reorged anymore
+func (hk *Housekeeper) checkDeliveredPayloadsLanded(headSlot uint64) {
+	// Should only happen once at a time
+	if hk.isCheckingLanded.Swap(true) {
+		return
+	}
+	defer hk.isCheckingLanded.Store(false)
+
+	if headSlot <= deliveredPayloadCheckDelaySlots {
+		return
+	}
+
+	entries, err := hk.db.GetUncheckedDeliveredPayloads(headSlot-deliveredPayloadCheckDelaySlots, deliveredPayloadsCheckBatchSize)
+	if err != nil {
+		hk.log.WithError(err).Error("failed to get unchecked delivered payloads")
+		return
+	}
+
+	for _, entry := range entries {
+		log := hk.log.WithFields(logrus.Fields{
+			"slot":           entry.Slot,
+			"blockHash":      entry.BlockHash,
+			"builderPubkey":  entry.BuilderPubkey,
+			"proposerPubkey": entry.ProposerPubkey,
+		})
+
+		landedStatus, landedBlockHash, err := hk.deliveredPayloadLandedStatus(entry.Slot, entry.BlockHash)
+		if err != nil {
+			log.WithError(err).Error("failed to get block of delivered payload")
+			return
+		}
+
+		err = hk.db.SetDeliveredPayloadLandedStatus(entry.ID, landedStatus, landedBlockHash)
+		if err != nil {
+			log.WithError(err).Error("failed to save landed status of delivered payload")
+			return
+		}
+
+		if landedStatus != database.PayloadLandedStatusLanded {
+			log.WithFields(logrus.Fields{
+				"landedStatus":    landedStatus,
+				"landedBlockHash": landedBlockHash,
+			}).Warn("delivered payload did not land on chain")
+		}
+	}
+}
+
+// deliveredPayloadLandedStatus looks up the canonical block of the slot, and returns whether it contains the delivered payload
+func (hk *Housekeeper) deliveredPayloadLandedStatus(slot uint64, blockHash string) (landedStatus, landedBlockHash string, err error) {
+	return DeliveredPayloadLandedStatus(hk.beaconClient, slot, blockHash)
+}
+
+// DeliveredPayloadLandedStatus looks up the canonical block of the slot, and returns whether it contains the delivered payload
+// (landed), whether there is no block (missed), or the hash of the payload it contains instead (replaced)
+func DeliveredPayloadLandedStatus(beaconClient beaconclient.IMultiBeaconClient, slot uint64, blockHash string) (landedStatus, landedBlockHash string, err error) {
+	blockResp, err := beaconClient.GetBlockBySlot(slot)
+	if errors.Is(err, beaconclient.ErrBlockNotFound) {
+		return database.PayloadLandedStatusMissed, "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+
+	landedBlockHash = blockResp.Data.Message.Body.ExecutionPayloadHeader.BlockHash
+	if landedBlockHash == blockHash {
+		return database.PayloadLandedStatusLanded, landedBlockHash, nil
 	}
-	hk.log.Infof("updating %d validator registrations in Redis done - %f sec", len(regs), time.Since(timeStarted).Seconds())
+	return database.PayloadLandedStatusReplaced, landedBlockHash, nil
 }
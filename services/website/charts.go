@@ -0,0 +1,140 @@
+package website
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/database"
+)
+
+const (
+	// days shown in the charts, and number of builders in the top builders chart
+	chartsNumDays        = 30
+	chartsNumTopBuilders = 10
+)
+
+// ChartsData is the time-series of the delivered payloads of the last chartsNumDays days, which is rendered on the
+// website and served as JSON
+type ChartsData struct {
+	FromDay     string                `json:"from_day"`
+	ToDay       string                `json:"to_day"`
+	NumPayloads uint64                `json:"num_payloads"`
+	TotalValue  string                `json:"total_value"` // in wei
+	Days        []*ChartsDayEntry     `json:"days"`
+	TopBuilders []*ChartsBuilderEntry `json:"top_builders"`
+}
+
+type ChartsDayEntry struct {
+	Day           string `json:"day"`
+	NumPayloads   uint64 `json:"num_payloads"`
+	TotalValueEth string `json:"total_value_eth"`
+	AvgValueEth   string `json:"avg_value_eth"`
+
+	BarPercent uint64 `json:"-"` // number of payloads relative to the busiest day
+}
+
+type ChartsBuilderEntry struct {
+	BuilderPubkey  string `json:"builder_pubkey"`
+	NumPayloads    uint64 `json:"num_payloads"`
+	NumSubmissions uint64 `json:"num_submissions"`
+	TotalValueEth  string `json:"total_value_eth"`
+	SharePercent   string `json:"share_percent"` // of the delivered payloads
+
+	BarPercent uint64 `json:"-"` // number of payloads relative to the top builder
+}
+
+type chartsStats struct {
+	numPayloads    uint64
+	numSubmissions uint64
+	totalValue     *big.Int
+}
+
+func (s *chartsStats) add(entry *database.BuilderDailyStatsEntry) {
+	s.numPayloads += entry.NumPayloads
+	s.numSubmissions += entry.NumSubmissions
+	if value, ok := new(big.Int).SetString(entry.TotalValue, 10); ok {
+		s.totalValue.Add(s.totalValue, value)
+	}
+}
+
+// buildChartsData aggregates the daily builder stats from fromDay to toDay into the charts. Days without stats are
+// included with zero payloads, so the time-series has no gaps.
+func buildChartsData(entries []*database.BuilderDailyStatsEntry, fromDay, toDay time.Time) *ChartsData {
+	statsByDay := make(map[string]*chartsStats)
+	statsByBuilder := make(map[string]*chartsStats)
+	total := &chartsStats{totalValue: new(big.Int)}
+	for _, entry := range entries {
+		day := entry.Day.Format(time.DateOnly)
+		if statsByDay[day] == nil {
+			statsByDay[day] = &chartsStats{totalValue: new(big.Int)}
+		}
+		statsByDay[day].add(entry)
+		if statsByBuilder[entry.BuilderPubkey] == nil {
+			statsByBuilder[entry.BuilderPubkey] = &chartsStats{totalValue: new(big.Int)}
+		}
+		statsByBuilder[entry.BuilderPubkey].add(entry)
+		total.add(entry)
+	}
+
+	data := &ChartsData{
+		FromDay:     fromDay.Format(time.DateOnly),
+		ToDay:       toDay.Format(time.DateOnly),
+		NumPayloads: total.numPayloads,
+		TotalValue:  total.totalValue.String(),
+		Days:        []*ChartsDayEntry{},
+		TopBuilders: []*ChartsBuilderEntry{},
+	}
+
+	var maxDayPayloads uint64
+	for day := fromDay; !day.After(toDay); day = day.AddDate(0, 0, 1) {
+		entry := &ChartsDayEntry{Day: day.Format(time.DateOnly)}
+		s := statsByDay[entry.Day]
+		if s == nil || s.numPayloads == 0 {
+			entry.TotalValueEth = weiToEthText(new(big.Int))
+			entry.AvgValueEth = entry.TotalValueEth
+		} else {
+			entry.NumPayloads = s.numPayloads
+			entry.TotalValueEth = weiToEthText(s.totalValue)
+			entry.AvgValueEth = weiToEthText(new(big.Int).Div(s.totalValue, new(big.Int).SetUint64(s.numPayloads)))
+		}
+		maxDayPayloads = max(maxDayPayloads, entry.NumPayloads)
+		data.Days = append(data.Days, entry)
+	}
+	for _, entry := range data.Days {
+		if maxDayPayloads > 0 {
+			entry.BarPercent = entry.NumPayloads * 100 / maxDayPayloads
+		}
+	}
+
+	for builderPubkey, s := range statsByBuilder {
+		if s.numPayloads == 0 {
+			continue
+		}
+		data.TopBuilders = append(data.TopBuilders, &ChartsBuilderEntry{
+			BuilderPubkey:  builderPubkey,
+			NumPayloads:    s.numPayloads,
+			NumSubmissions: s.numSubmissions,
+			TotalValueEth:  weiToEthText(s.totalValue),
+			SharePercent:   big.NewFloat(float64(s.numPayloads)*100/float64(total.numPayloads)).Text('f', 1),
+		})
+	}
+	sort.Slice(data.TopBuilders, func(i, j int) bool {
+		if data.TopBuilders[i].NumPayloads != data.TopBuilders[j].NumPayloads {
+			return data.TopBuilders[i].NumPayloads > data.TopBuilders[j].NumPayloads
+		}
+		return data.TopBuilders[i].BuilderPubkey < data.TopBuilders[j].BuilderPubkey
+	})
+	if len(data.TopBuilders) > chartsNumTopBuilders {
+		data.TopBuilders = data.TopBuilders[:chartsNumTopBuilders]
+	}
+	for _, entry := range data.TopBuilders {
+		entry.BarPercent = entry.NumPayloads * 100 / data.TopBuilders[0].NumPayloads
+	}
+	return data
+}
+
+// weiToEthText returns the value in ETH with 4 decimals
+func weiToEthText(wei *big.Int) string {
+	return weiBigIntToEthBigFloat(wei).Text('f', 4)
+}
@@ -0,0 +1,36 @@
+package website
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/database"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildChartsData(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	charts := buildChartsData([]*database.BuilderDailyStatsEntry{
+		{Day: today, BuilderPubkey: "0xa", NumPayloads: 3, NumSubmissions: 10, TotalValue: "3000000000000000000"},
+		{Day: today.AddDate(0, 0, -1), BuilderPubkey: "0xb", NumPayloads: 1, TotalValue: "500000000000000000"},
+	}, today.AddDate(0, 0, -2), today)
+
+	// days without payloads are included
+	require.Len(t, charts.Days, 3)
+	require.Equal(t, uint64(0), charts.Days[0].NumPayloads)
+	require.Equal(t, "1.0000", charts.Days[2].AvgValueEth)
+	require.Equal(t, uint64(100), charts.Days[2].BarPercent)
+	require.Equal(t, uint64(33), charts.Days[1].BarPercent)
+
+	require.Equal(t, uint64(4), charts.NumPayloads)
+	require.Equal(t, "3500000000000000000", charts.TotalValue)
+	require.Len(t, charts.TopBuilders, 2)
+	require.Equal(t, "0xa", charts.TopBuilders[0].BuilderPubkey)
+	require.Equal(t, "75.0", charts.TopBuilders[0].SharePercent)
+
+	stats := buildHomepageStats(&StatusHTMLData{Network: "mainnet", NumPayloadsDelivered: 100, Charts: charts}, []*database.DeliveredPayloadEntry{{Slot: 1, Value: "2"}})
+	require.Equal(t, uint64(100), stats.NumPayloadsDelivered)
+	require.Equal(t, 3, stats.RecentDays)
+	require.Equal(t, "3500000000000000000", stats.RecentTotalValue)
+	require.Equal(t, "2", stats.RecentPayloads[0].Value)
+}
@@ -2,6 +2,7 @@ package website
 
 import (
 	_ "embed"
+	htmltemplate "html/template"
 	"math/big"
 	"text/template"
 
@@ -11,6 +12,11 @@ import (
 	"golang.org/x/text/message"
 )
 
+const (
+	contentTypeHTML = "text/html; charset=utf-8"
+	contentTypeJSON = "application/json"
+)
+
 var (
 	// Printer for pretty printing numbers
 	printer = message.NewPrinter(language.English)
@@ -33,6 +39,7 @@ type StatusHTMLData struct { //nolint:musttag
 	HeadSlot                    uint64
 	NumPayloadsDelivered        uint64
 	Payloads                    []*database.DeliveredPayloadEntry
+	Charts                      *ChartsData
 
 	ValueLink      string
 	ValueOrderIcon string
@@ -44,6 +51,73 @@ type StatusHTMLData struct { //nolint:musttag
 	RelayURL          string
 }
 
+// HomepageStats are the numbers of the homepage, served as JSON at /api/stats for monitoring tools and relay lists
+type HomepageStats struct {
+	Network              string `json:"network"`
+	RelayPubkey          string `json:"relay_pubkey"`
+	HeadSlot             uint64 `json:"head_slot,string"`
+	ValidatorsTotal      uint64 `json:"validators_total,string"`
+	ValidatorsRegistered uint64 `json:"validators_registered,string"`
+	NumPayloadsDelivered uint64 `json:"num_payloads_delivered,string"`
+
+	// delivered payloads of the last days (from the charts), the values are in wei
+	RecentDays        int                     `json:"recent_days"`
+	RecentNumPayloads uint64                  `json:"recent_num_payloads,string"`
+	RecentTotalValue  string                  `json:"recent_total_value"`
+	RecentPayloads    []*HomepageStatsPayload `json:"recent_payloads"`
+}
+
+type HomepageStatsPayload struct {
+	Slot          uint64 `json:"slot,string"`
+	BlockNumber   uint64 `json:"block_number,string"`
+	BlockHash     string `json:"block_hash"`
+	BuilderPubkey string `json:"builder_pubkey"`
+	NumTx         uint64 `json:"num_tx,string"`
+	Value         string `json:"value"`
+}
+
+// SearchHTMLData is the result of a search by slot, block hash or pubkey
+type SearchHTMLData struct { //nolint:musttag
+	Network         string
+	Query           string
+	Error           string
+	LinkBeaconchain string
+	LinkDataAPI     string
+
+	Payloads       []*database.DeliveredPayloadEntry
+	Submissions    []*database.BuilderBlockSubmissionEntry
+	NumSubmissions int // including the ones not shown
+	DataAPILinks   []DataAPILink
+}
+
+// buildHomepageStats returns the numbers of the homepage data, with the given recent payloads
+func buildHomepageStats(data *StatusHTMLData, payloads []*database.DeliveredPayloadEntry) *HomepageStats {
+	stats := &HomepageStats{
+		Network:              data.Network,
+		RelayPubkey:          data.RelayPubkey,
+		HeadSlot:             data.HeadSlot,
+		ValidatorsTotal:      data.ValidatorsTotal,
+		ValidatorsRegistered: data.ValidatorsRegistered,
+		NumPayloadsDelivered: data.NumPayloadsDelivered,
+		RecentDays:           len(data.Charts.Days),
+		RecentNumPayloads:    data.Charts.NumPayloads,
+		RecentTotalValue:     data.Charts.TotalValue,
+		RecentPayloads:       []*HomepageStatsPayload{},
+	}
+
+	for _, payload := range payloads {
+		stats.RecentPayloads = append(stats.RecentPayloads, &HomepageStatsPayload{
+			Slot:          payload.Slot,
+			BlockNumber:   payload.BlockNumber,
+			BlockHash:     payload.BlockHash,
+			BuilderPubkey: payload.BuilderPubkey,
+			NumTx:         payload.NumTx,
+			Value:         payload.Value.String(),
+		})
+	}
+	return stats
+}
+
 func weiToEth(wei string) string {
 	weiBigInt := new(big.Int)
 	weiBigInt.SetString(wei, 10)
@@ -76,6 +150,14 @@ var funcMap = template.FuncMap{
 //go:embed website.html
 var htmlContent string
 
+//go:embed search.html
+var searchHTMLContent string
+
 func ParseIndexTemplate() (*template.Template, error) {
 	return template.New("index").Funcs(funcMap).Parse(htmlContent)
 }
+
+// ParseSearchTemplate parses the search results template, which escapes the contents because they include the query
+func ParseSearchTemplate() (*htmltemplate.Template, error) {
+	return htmltemplate.New("search").Funcs(htmltemplate.FuncMap(funcMap)).Parse(searchHTMLContent)
+}
@@ -0,0 +1,91 @@
+package website
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/common"
+)
+
+// maximum number of rendered search results kept in memory
+const searchCacheMaxEntries = 1_000
+
+// cachedPage is a rendered page with its ETag, a hash of the contents
+type cachedPage struct {
+	body        []byte
+	contentType string
+	etag        string
+	renderedAt  time.Time
+}
+
+func newCachedPage(body []byte, contentType string) *cachedPage {
+	return &cachedPage{
+		body:        body,
+		contentType: contentType,
+		etag:        common.WeakETag(body),
+		renderedAt:  time.Now(),
+	}
+}
+
+// writeCachedPage responds with the page, or with 304 if the ETag matches the If-None-Match header of the request.
+// Clients may use the page until the next refresh without revalidating it.
+func (srv *Webserver) writeCachedPage(w http.ResponseWriter, req *http.Request, page *cachedPage) {
+	w.Header().Set("ETag", page.etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(srv.refreshInterval.Seconds())))
+	if common.ETagMatches(req.Header.Get("If-None-Match"), page.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", page.contentType)
+	if _, err := w.Write(page.body); err != nil {
+		srv.log.WithError(err).Error("error writing page")
+	}
+}
+
+// pageCache keeps rendered pages by key for a limited time, so repeated requests don't query the database again
+type pageCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	lock  sync.Mutex
+	pages map[string]*cachedPage
+}
+
+func newPageCache(ttl time.Duration, maxEntries int) *pageCache {
+	return &pageCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		pages:      make(map[string]*cachedPage),
+	}
+}
+
+// get returns the page of the key, or nil if there is none or it expired
+func (c *pageCache) get(key string) *cachedPage {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	page := c.pages[key]
+	if page == nil || time.Since(page.renderedAt) >= c.ttl {
+		return nil
+	}
+	return page
+}
+
+// set saves the page of the key. When the cache is full, the expired pages are removed, and all pages if none expired.
+func (c *pageCache) set(key string, page *cachedPage) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.pages) >= c.maxEntries {
+		for k, p := range c.pages {
+			if time.Since(p.renderedAt) >= c.ttl {
+				delete(c.pages, k)
+			}
+		}
+		if len(c.pages) >= c.maxEntries {
+			c.pages = make(map[string]*cachedPage)
+		}
+	}
+	c.pages[key] = page
+}
@@ -0,0 +1,50 @@
+package website
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/mev-boost-relay/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCachedPage(t *testing.T) {
+	srv := &Webserver{log: common.TestLog, refreshInterval: 10 * time.Second}
+	page := newCachedPage([]byte("<html></html>"), contentTypeHTML)
+
+	rr := httptest.NewRecorder()
+	srv.writeCachedPage(rr, httptest.NewRequest(http.MethodGet, "/", nil), page)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "<html></html>", rr.Body.String())
+	require.Equal(t, "public, max-age=10", rr.Header().Get("Cache-Control"))
+	etag := rr.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	srv.writeCachedPage(rr, req, page)
+	require.Equal(t, http.StatusNotModified, rr.Code)
+	require.Empty(t, rr.Body.String())
+}
+
+func TestPageCache(t *testing.T) {
+	cache := newPageCache(time.Minute, 2)
+	cache.set("a", newCachedPage([]byte("a"), contentTypeHTML))
+	cache.set("b", newCachedPage([]byte("b"), contentTypeHTML))
+	require.Equal(t, []byte("a"), cache.get("a").body)
+
+	// expired pages are not returned, and removed when the cache is full
+	cache.pages["a"].renderedAt = time.Now().Add(-time.Hour)
+	require.Nil(t, cache.get("a"))
+	cache.set("c", newCachedPage([]byte("c"), contentTypeHTML))
+	require.Len(t, cache.pages, 2)
+	require.NotNil(t, cache.get("b"))
+	require.NotNil(t, cache.get("c"))
+
+	// all pages are removed if none expired
+	cache.set("d", newCachedPage([]byte("d"), contentTypeHTML))
+	require.Len(t, cache.pages, 1)
+}
@@ -0,0 +1,130 @@
+package website
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/flashbots/mev-boost-relay/database"
+)
+
+// maximum number of delivered payloads and builder submissions shown for a search
+const searchMaxResults = 50
+
+var (
+	ErrInvalidSearchQuery = errors.New("search for a slot, block hash (0x + 64 hex characters) or pubkey (0x + 96 hex characters)")
+
+	searchBlockHashRegex = regexp.MustCompile(`^0x[0-9a-f]{64}$`)
+	searchPubkeyRegex    = regexp.MustCompile(`^0x[0-9a-f]{96}$`)
+)
+
+// searchQuery is a parsed search, exactly one of the fields is set
+type searchQuery struct {
+	slot      uint64
+	blockHash string
+	pubkey    string
+}
+
+// String returns the normalized query, which is the key of the cached results
+func (q *searchQuery) String() string {
+	switch {
+	case q.blockHash != "":
+		return q.blockHash
+	case q.pubkey != "":
+		return q.pubkey
+	default:
+		return strconv.FormatUint(q.slot, 10)
+	}
+}
+
+func parseSearchQuery(q string) (*searchQuery, error) {
+	q = strings.ToLower(strings.TrimSpace(q))
+	switch {
+	case searchBlockHashRegex.MatchString(q):
+		return &searchQuery{blockHash: q}, nil
+	case searchPubkeyRegex.MatchString(q):
+		return &searchQuery{pubkey: q}, nil
+	}
+
+	slot, err := strconv.ParseUint(q, 10, 63)
+	if err != nil || slot == 0 {
+		return nil, ErrInvalidSearchQuery
+	}
+	return &searchQuery{slot: slot}, nil
+}
+
+// DataAPILink is a data API query with the complete results of a search
+type DataAPILink struct {
+	Label string
+	URL   string
+}
+
+// searchFilter is a data API query argument
+type searchFilter struct {
+	name  string
+	value string
+}
+
+// search looks up the delivered payloads and builder submissions of the query. A pubkey is searched as proposer and
+// as builder.
+func (srv *Webserver) search(query *searchQuery) (*SearchHTMLData, error) {
+	var payloadFilters []searchFilter
+	var submissionFilter searchFilter
+	switch {
+	case query.slot > 0:
+		submissionFilter = searchFilter{"slot", strconv.FormatUint(query.slot, 10)}
+		payloadFilters = []searchFilter{submissionFilter}
+	case query.blockHash != "":
+		submissionFilter = searchFilter{"block_hash", query.blockHash}
+		payloadFilters = []searchFilter{submissionFilter}
+	default:
+		submissionFilter = searchFilter{"builder_pubkey", query.pubkey}
+		payloadFilters = []searchFilter{{"proposer_pubkey", query.pubkey}, submissionFilter}
+	}
+
+	data := &SearchHTMLData{
+		Payloads:    []*database.DeliveredPayloadEntry{},
+		Submissions: []*database.BuilderBlockSubmissionEntry{},
+	}
+	for _, filter := range payloadFilters {
+		filters := database.GetPayloadsFilters{
+			Slot:      int64(query.slot), //nolint:gosec
+			BlockHash: query.blockHash,
+			Limit:     searchMaxResults,
+		}
+		if filter.name == "proposer_pubkey" {
+			filters.ProposerPubkey = filter.value
+		} else if filter.name == "builder_pubkey" {
+			filters.BuilderPubkey = filter.value
+		}
+		payloads, err := srv.db.GetRecentDeliveredPayloads(filters)
+		if err != nil {
+			return nil, err
+		}
+		data.Payloads = append(data.Payloads, payloads...)
+		data.DataAPILinks = append(data.DataAPILinks, DataAPILink{
+			Label: "Delivered payloads by " + filter.name,
+			URL:   srv.opts.LinkDataAPI + "/relay/v1/data/bidtraces/proposer_payload_delivered?" + filter.name + "=" + filter.value,
+		})
+	}
+
+	// filtering by slot or block hash returns all submissions, only the first ones are shown
+	submissions, err := srv.db.GetBuilderSubmissions(database.GetBuilderSubmissionsFilters{
+		Slot:             int64(query.slot), //nolint:gosec
+		BlockHash:        query.blockHash,
+		BuilderPubkey:    query.pubkey,
+		Limit:            searchMaxResults,
+		IncludeSimErrors: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	data.NumSubmissions = len(submissions)
+	data.Submissions = submissions[:min(len(submissions), searchMaxResults)]
+	data.DataAPILinks = append(data.DataAPILinks, DataAPILink{
+		Label: "Builder submissions by " + submissionFilter.name,
+		URL:   srv.opts.LinkDataAPI + "/relay/v1/data/bidtraces/builder_blocks_received?include_sim_errors=true&" + submissionFilter.name + "=" + submissionFilter.value,
+	})
+	return data, nil
+}
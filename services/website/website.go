@@ -3,7 +3,9 @@ package website
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
+	htmltemplate "html/template"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -29,6 +31,8 @@ import (
 var (
 	ErrServerAlreadyStarted = errors.New("server was already started")
 	EnablePprof             = os.Getenv("PPROF") == "1"
+
+	DefaultRefreshInterval = 10 * time.Second
 )
 
 type WebserverOpts struct {
@@ -44,6 +48,8 @@ type WebserverOpts struct {
 	LinkEtherscan     string
 	LinkDataAPI       string
 	RelayURL          string
+
+	RefreshInterval time.Duration // how often the pages are rendered, and how long search results are cached
 }
 
 type Webserver struct {
@@ -57,12 +63,18 @@ type Webserver struct {
 	srvStarted uberatomic.Bool
 
 	indexTemplate    *template.Template
+	searchTemplate   *htmltemplate.Template
 	statusHTMLData   StatusHTMLData
 	rootResponseLock sync.RWMutex
 
-	htmlDefault     *[]byte
-	htmlByValueDesc *[]byte
-	htmlByValueAsc  *[]byte
+	htmlDefault     *cachedPage
+	htmlByValueDesc *cachedPage
+	htmlByValueAsc  *cachedPage
+	chartsJSON      *cachedPage
+	statsJSON       *cachedPage
+
+	refreshInterval time.Duration
+	searchCache     *pageCache
 
 	minifier *minify.M
 }
@@ -74,15 +86,25 @@ func NewWebserver(opts *WebserverOpts) (*Webserver, error) {
 	minifier.AddFunc("text/css", html.Minify)
 	minifier.AddFunc("text/html", html.Minify)
 
+	refreshInterval := opts.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultRefreshInterval
+	}
+
 	server := &Webserver{
 		opts:  opts,
 		log:   opts.Log,
 		redis: opts.Redis,
 		db:    opts.DB,
 
-		htmlDefault:     &[]byte{},
-		htmlByValueDesc: &[]byte{},
-		htmlByValueAsc:  &[]byte{},
+		htmlDefault:     newCachedPage([]byte{}, contentTypeHTML),
+		htmlByValueDesc: newCachedPage([]byte{}, contentTypeHTML),
+		htmlByValueAsc:  newCachedPage([]byte{}, contentTypeHTML),
+		chartsJSON:      newCachedPage([]byte("{}"), contentTypeJSON),
+		statsJSON:       newCachedPage([]byte("{}"), contentTypeJSON),
+
+		refreshInterval: refreshInterval,
+		searchCache:     newPageCache(refreshInterval, searchCacheMaxEntries),
 
 		minifier: minifier,
 	}
@@ -92,6 +114,11 @@ func NewWebserver(opts *WebserverOpts) (*Webserver, error) {
 		return nil, err
 	}
 
+	server.searchTemplate, err = ParseSearchTemplate()
+	if err != nil {
+		return nil, err
+	}
+
 	server.statusHTMLData = StatusHTMLData{
 		Network:                     opts.NetworkDetails.Name,
 		RelayPubkey:                 opts.RelayPubkeyHex,
@@ -106,6 +133,7 @@ func NewWebserver(opts *WebserverOpts) (*Webserver, error) {
 		HeadSlot:                    0,
 		NumPayloadsDelivered:        0,
 		Payloads:                    []*database.DeliveredPayloadEntry{},
+		Charts:                      &ChartsData{},
 		ValueLink:                   "",
 		ValueOrderIcon:              "",
 		ShowConfigDetails:           opts.ShowConfigDetails,
@@ -123,11 +151,11 @@ func (srv *Webserver) StartServer() (err error) {
 		return ErrServerAlreadyStarted
 	}
 
-	// Start background task to regularly update status HTML data
+	// Start background task to regularly update status HTML data, the requests are served from the rendered pages
 	go func() {
 		for {
 			srv.updateHTML()
-			time.Sleep(10 * time.Second)
+			time.Sleep(srv.refreshInterval)
 		}
 	}()
 
@@ -151,6 +179,9 @@ func (srv *Webserver) StartServer() (err error) {
 func (srv *Webserver) getRouter() http.Handler {
 	r := mux.NewRouter()
 	r.HandleFunc("/", srv.handleRoot).Methods(http.MethodGet)
+	r.HandleFunc("/charts.json", srv.handleChartsJSON).Methods(http.MethodGet)
+	r.HandleFunc("/api/stats", srv.handleStatsJSON).Methods(http.MethodGet)
+	r.HandleFunc("/search", srv.handleSearch).Methods(http.MethodGet)
 	if EnablePprof {
 		srv.log.Info("pprof API enabled")
 		r.PathPrefix("/debug/pprof/").Handler(http.DefaultServeMux)
@@ -187,6 +218,15 @@ func (srv *Webserver) updateHTML() {
 		srv.log.WithError(err).Error("error getting number of delivered payloads")
 	}
 
+	// charts of the last days, from the builder stats which the housekeeper refreshes once per epoch
+	toDay := time.Now().UTC().Truncate(24 * time.Hour)
+	fromDay := toDay.AddDate(0, 0, -(chartsNumDays - 1))
+	builderStats, err := srv.db.GetBuilderDailyStats("", fromDay, toDay)
+	if err != nil {
+		srv.log.WithError(err).Error("error getting builder daily stats")
+	}
+	charts := buildChartsData(builderStats, fromDay, toDay)
+
 	_latestSlot, err := srv.redis.GetStats(datastore.RedisStatsFieldLatestSlot)
 	if err != nil && !errors.Is(err, redis.Nil) {
 		srv.log.WithError(err).Error("error getting latest slot")
@@ -206,6 +246,7 @@ func (srv *Webserver) updateHTML() {
 	srv.statusHTMLData.ValidatorsRegistered = _numRegistered
 	srv.statusHTMLData.NumPayloadsDelivered = _numPayloadsDelivered
 	srv.statusHTMLData.HeadSlot = _latestSlotInt
+	srv.statusHTMLData.Charts = charts
 
 	// Now generate the HTML
 	htmlDefault := bytes.Buffer{}
@@ -250,27 +291,93 @@ func (srv *Webserver) updateHTML() {
 		srv.log.WithError(err).Error("error minifying htmlByValueAsc")
 	}
 
+	chartsJSON, err := json.Marshal(charts)
+	if err != nil {
+		srv.log.WithError(err).Error("error encoding charts")
+	}
+	statsJSON, err := json.Marshal(buildHomepageStats(&srv.statusHTMLData, payloads))
+	if err != nil {
+		srv.log.WithError(err).Error("error encoding stats")
+	}
+
 	// Swap the html pointers
 	srv.rootResponseLock.Lock()
-	srv.htmlDefault = &htmlDefaultBytes
-	srv.htmlByValueDesc = &htmlValueDescBytes
-	srv.htmlByValueAsc = &htmlValueDescAsc
+	srv.htmlDefault = newCachedPage(htmlDefaultBytes, contentTypeHTML)
+	srv.htmlByValueDesc = newCachedPage(htmlValueDescBytes, contentTypeHTML)
+	srv.htmlByValueAsc = newCachedPage(htmlValueDescAsc, contentTypeHTML)
+	srv.chartsJSON = newCachedPage(chartsJSON, contentTypeJSON)
+	srv.statsJSON = newCachedPage(statsJSON, contentTypeJSON)
 	srv.rootResponseLock.Unlock()
 }
 
 func (srv *Webserver) handleRoot(w http.ResponseWriter, req *http.Request) {
-	var err error
-
 	srv.rootResponseLock.RLock()
-	defer srv.rootResponseLock.RUnlock()
+	page := srv.htmlDefault
 	if req.URL.Query().Get("order_by") == "-value" {
-		_, err = w.Write(*srv.htmlByValueDesc)
+		page = srv.htmlByValueDesc
 	} else if req.URL.Query().Get("order_by") == "value" {
-		_, err = w.Write(*srv.htmlByValueAsc)
-	} else {
-		_, err = w.Write(*srv.htmlDefault)
+		page = srv.htmlByValueAsc
 	}
+	srv.rootResponseLock.RUnlock()
+	srv.writeCachedPage(w, req, page)
+}
+
+func (srv *Webserver) handleChartsJSON(w http.ResponseWriter, req *http.Request) {
+	srv.rootResponseLock.RLock()
+	page := srv.chartsJSON
+	srv.rootResponseLock.RUnlock()
+	srv.writeCachedPage(w, req, page)
+}
+
+func (srv *Webserver) handleStatsJSON(w http.ResponseWriter, req *http.Request) {
+	srv.rootResponseLock.RLock()
+	page := srv.statsJSON
+	srv.rootResponseLock.RUnlock()
+	srv.writeCachedPage(w, req, page)
+}
+
+// handleSearch renders the delivered payloads and builder submissions of a slot, block hash or pubkey. The results are
+// cached until the next refresh of the pages.
+func (srv *Webserver) handleSearch(w http.ResponseWriter, req *http.Request) {
+	q := req.URL.Query().Get("q")
+	status := http.StatusOK
+	data := &SearchHTMLData{}
+	query, err := parseSearchQuery(q)
 	if err != nil {
-		srv.log.WithError(err).Error("error writing template")
+		status = http.StatusBadRequest
+		data.Error = err.Error()
+	} else if page := srv.searchCache.get(query.String()); page != nil {
+		srv.writeCachedPage(w, req, page)
+		return
+	} else {
+		q = query.String()
+		data, err = srv.search(query)
+		if err != nil {
+			srv.log.WithError(err).Error("error searching")
+			status = http.StatusInternalServerError
+			data = &SearchHTMLData{Error: "search failed, please try again"}
+		}
+	}
+	data.Network = srv.opts.NetworkDetails.Name
+	data.Query = q
+	data.LinkBeaconchain = srv.opts.LinkBeaconchain
+	data.LinkDataAPI = srv.opts.LinkDataAPI
+
+	html := bytes.Buffer{}
+	if err := srv.searchTemplate.Execute(&html, data); err != nil {
+		srv.log.WithError(err).Error("error rendering search template")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		if _, err := w.Write(html.Bytes()); err != nil {
+			srv.log.WithError(err).Error("error writing search results")
+		}
+		return
 	}
+	page := newCachedPage(html.Bytes(), contentTypeHTML)
+	srv.searchCache.set(query.String(), page)
+	srv.writeCachedPage(w, req, page)
 }
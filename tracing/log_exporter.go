@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// logExporter writes finished spans to the log, to be picked up by the log pipeline
+type logExporter struct {
+	log *logrus.Entry
+}
+
+func newLogExporter(log *logrus.Entry) *logExporter {
+	return &logExporter{log: log.WithField("component", "tracing")}
+}
+
+func (e *logExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	for _, span := range spans {
+		fields := logrus.Fields{
+			"span":        span.Name(),
+			"traceID":     span.SpanContext().TraceID().String(),
+			"spanID":      span.SpanContext().SpanID().String(),
+			"timestampMs": span.StartTime().UnixMilli(),
+			"durationUs":  span.EndTime().Sub(span.StartTime()).Microseconds(),
+		}
+		if span.Parent().IsValid() {
+			fields["parentSpanID"] = span.Parent().SpanID().String()
+		}
+		for _, attr := range span.Attributes() {
+			fields[string(attr.Key)] = attr.Value.Emit()
+		}
+		if span.Status().Description != "" {
+			fields["error"] = span.Status().Description
+		}
+		e.log.WithFields(fields).Info("span")
+	}
+	return nil
+}
+
+func (e *logExporter) Shutdown(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package tracing provides opentelemetry tracing primitives to the rest of the app
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/flashbots/go-utils/cli"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "mev-boost-relay"
+)
+
+var (
+	tracingEnabled       = os.Getenv("TRACING_ENABLED") == "1"
+	tracingSamplePercent = cli.GetEnvInt("TRACING_SAMPLE_PERCENT", 100)
+
+	// tracer uses the global tracer provider, which is a no-op until Setup installs the sdk provider
+	tracer = otel.Tracer(tracerName)
+
+	provider   *sdktrace.TracerProvider
+	setupOnce  sync.Once
+	setupErr   error
+	propagator = propagation.TraceContext{}
+)
+
+// Setup installs the global tracer provider if tracing is enabled (TRACING_ENABLED=1). Finished spans are sent to an
+// OTLP collector if one is configured (OTEL_EXPORTER_OTLP_ENDPOINT or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT), and written
+// to the log otherwise. It is only done once per process, and subsequent calls return the result of the first one.
+func Setup(ctx context.Context, log *logrus.Entry) error {
+	setupOnce.Do(func() {
+		if !tracingEnabled {
+			return
+		}
+
+		var exporter sdktrace.SpanExporter = newLogExporter(log)
+		exporterName := "log"
+		if otlpEndpointConfigured() {
+			exporter, setupErr = newOTLPExporter(ctx)
+			if setupErr != nil {
+				return
+			}
+			exporterName = "otlp"
+		}
+
+		setupErr = setupProvider(ctx, exporter)
+		if setupErr == nil {
+			log.WithField("exporter", exporterName).Infof("tracing enabled, sampling %d%% of traces", tracingSamplePercent)
+		}
+	})
+	return setupErr
+}
+
+func setupProvider(ctx context.Context, exporter sdktrace.SpanExporter) error {
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(tracerName)),
+	)
+	if err != nil {
+		return err
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(float64(tracingSamplePercent)/100))),
+	)
+	otel.SetTracerProvider(provider)
+	return nil
+}
+
+// Shutdown flushes all pending spans
+func Shutdown(ctx context.Context) error {
+	if provider == nil {
+		return nil
+	}
+	return provider.Shutdown(ctx)
+}
+
+// StartRequest starts the root span of a http request. If the caller sent a W3C traceparent header, the span
+// continues the caller's trace.
+func StartRequest(req *http.Request, name string) (context.Context, trace.Span) {
+	ctx := propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// Start starts a child span of the span in ctx
+func Start(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name)
+}
+
+// Record adds a child span for an operation which already finished, e.g. one which was measured for profiling
+func Record(ctx context.Context, name string, start, end time.Time) {
+	_, span := tracer.Start(ctx, name, trace.WithTimestamp(start))
+	span.End(trace.WithTimestamp(end))
+}
+
+// EndSpan ends the span, and marks it as failed if err is not nil
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// SetAttributes adds attributes to the span in ctx
+func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartRequestContinuesTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	require.NoError(t, setupProvider(context.Background(), exporter))
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	req, err := http.NewRequest(http.MethodPost, "/eth/v1/builder/blocks", nil)
+	require.NoError(t, err)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	ctx, span := StartRequest(req, "submitNewBlock")
+	Record(ctx, "decode", time.Now().Add(-time.Millisecond), time.Now())
+	span.End()
+	require.NoError(t, provider.ForceFlush(context.Background()))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "decode", spans[0].Name)
+	require.Equal(t, "submitNewBlock", spans[1].Name)
+	for _, s := range spans {
+		require.Equal(t, traceID, s.SpanContext.TraceID().String())
+	}
+	require.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.URL.Path
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", srv.URL)
+	require.True(t, otlpEndpointConfigured())
+
+	exporter, err := newOTLPExporter(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, setupProvider(context.Background(), exporter))
+
+	// the global tracer stays bound to the provider of the first test, so the span is started on the new one directly
+	_, span := provider.Tracer(tracerName).Start(context.Background(), "getPayload")
+	span.End()
+	require.NoError(t, provider.ForceFlush(context.Background()))
+	require.Equal(t, "/v1/traces", <-received)
+}